@@ -221,6 +221,17 @@ type HostedControlPlaneSpec struct {
 	//
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// ControlPlaneComponentOverrides when specified, tune the Deployments and StatefulSets of the hosted control plane
+	// components. Each entry is matched by the name of the Deployment or StatefulSet of the component, e.g. kube-apiserver.
+	// The overrides take precedence over the ResourceRequestOverrideAnnotationPrefix, KubeAPIServerGOGCAnnotation,
+	// KubeAPIServerGOMemoryLimitAnnotation and priority class annotations.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=100
+	ControlPlaneComponentOverrides []ControlPlaneComponentOverride `json:"controlPlaneComponentOverrides,omitempty"`
 }
 
 // AvailabilityPolicy specifies a high level availability policy for components.
@@ -179,6 +179,13 @@ type HostedClusterSpec struct {
 	// +optional
 	Channel string `json:"channel,omitempty"`
 
+	// upgradePolicy selects how the HostedCluster follows the updates recommended by the
+	// update service for its channel, as reported in status.version.availableUpdates.
+	// When omitted, spec.release is only changed by the user.
+	//
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+
 	// InfraID is a globally unique identifier for the cluster. This identifier
 	// will be used to associate various cloud resources with the HostedCluster
 	// and its associated NodePools.
@@ -351,6 +358,113 @@ type HostedClusterSpec struct {
 	//
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// ControlPlaneComponentOverrides when specified, tune the Deployments and StatefulSets of the hosted control plane
+	// components. Each entry is matched by the name of the Deployment or StatefulSet of the component, e.g. kube-apiserver.
+	// The overrides take precedence over the ResourceRequestOverrideAnnotationPrefix, KubeAPIServerGOGCAnnotation,
+	// KubeAPIServerGOMemoryLimitAnnotation and priority class annotations.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=100
+	ControlPlaneComponentOverrides []ControlPlaneComponentOverride `json:"controlPlaneComponentOverrides,omitempty"`
+}
+
+// ControlPlaneComponentName is the name of the Deployment or StatefulSet of a hosted control plane component.
+//
+// +kubebuilder:validation:Enum=etcd;kube-apiserver;kube-controller-manager;kube-scheduler;openshift-apiserver;openshift-oauth-apiserver;oauth-openshift;openshift-controller-manager;openshift-route-controller-manager;cluster-policy-controller;cluster-version-operator;konnectivity-agent;ignition-server;ignition-server-proxy;cluster-network-operator;ingress-operator;dns-operator;cluster-node-tuning-operator;cluster-image-registry-operator;cluster-storage-operator;csi-snapshot-controller-operator;cloud-credential-operator;cloud-controller-manager;cluster-autoscaler;machine-approver;cluster-api;capi-provider;control-plane-operator;control-plane-pki-operator;hosted-cluster-config-operator;catalog-operator;olm-operator;packageserver;router;multus-admission-controller;network-node-identity
+type ControlPlaneComponentName string
+
+// ControlPlaneComponentOverride tunes a Deployment or StatefulSet of the hosted control plane.
+//
+// +kubebuilder:validation:XValidation:rule="self.name != 'etcd' || !has(self.replicas)", message="the replicas of etcd can not be overridden"
+type ControlPlaneComponentOverride struct {
+	// name is the name of the Deployment or StatefulSet of the component, e.g. kube-apiserver.
+	//
+	// +kubebuilder:validation:Required
+	Name ControlPlaneComponentName `json:"name"`
+
+	// replicas overrides the number of replicas derived from the controllerAvailabilityPolicy.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=5
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// priorityClassName overrides the priority class of the pods of the component.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// containers tune the containers of the pods of the component.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=20
+	Containers []ControlPlaneContainerOverride `json:"containers,omitempty"`
+}
+
+// ControlPlaneContainerOverride tunes a container of a hosted control plane component.
+type ControlPlaneContainerOverride struct {
+	// name is the name of the container, or init container.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// resources overrides the requests and limits of the container. Only the resources specified are overridden,
+	// other requests and limits of the container are kept.
+	//
+	// +optional
+	Resources *ControlPlaneContainerResources `json:"resources,omitempty"`
+
+	// goRuntime sets the Go runtime environment of the container.
+	//
+	// +optional
+	GoRuntime *GoRuntimeOverride `json:"goRuntime,omitempty"`
+
+	// extraArgs are appended to the arguments of the container. They must be flags in the --flag=value form, and only
+	// the flags which tune the performance or the verbosity of the component are allowed, e.g. --max-requests-inflight
+	// for the kube-apiserver or --quota-backend-bytes for etcd. Other flags are rejected.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=50
+	// +kubebuilder:validation:items:MaxLength=1024
+	// +kubebuilder:validation:XValidation:rule="self.all(arg, arg.matches('^--[a-z0-9][-a-z0-9]*=.*$'))", message="extraArgs must be flags in the --flag=value form"
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+}
+
+// ControlPlaneContainerResources are the resources overridden on a container.
+type ControlPlaneContainerResources struct {
+	// requests overrides the resource requests of the container.
+	//
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+
+	// limits overrides the resource limits of the container.
+	//
+	// +optional
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+}
+
+// GoRuntimeOverride sets the Go runtime environment variables of a container.
+type GoRuntimeOverride struct {
+	// gogc sets the GOGC environment variable, the garbage collection target percentage, or off.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+|off)$`
+	GOGC string `json:"gogc,omitempty"`
+
+	// goMemoryLimit sets the GOMEMLIMIT environment variable, the soft memory limit of the Go runtime, e.g. 3GiB.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(B|KiB|MiB|GiB|TiB)?$`
+	GOMemoryLimit string `json:"goMemoryLimit,omitempty"`
 }
 
 // OLMCatalogPlacement is an enum specifying the placement of OLM catalog components.
@@ -1577,6 +1691,42 @@ type AzurePlatformSpec struct {
 	SecurityGroupID   string `json:"securityGroupID"`
 }
 
+// UpgradePolicyType is the way a HostedCluster follows its recommended updates.
+// +kubebuilder:validation:Enum=Manual;AutoPatch;AutoMinor
+type UpgradePolicyType string
+
+const (
+	// UpgradePolicyManual means spec.release is only changed by the user.
+	UpgradePolicyManual UpgradePolicyType = "Manual"
+	// UpgradePolicyAutoPatch means the newest recommended update within the current minor
+	// version is applied.
+	UpgradePolicyAutoPatch UpgradePolicyType = "AutoPatch"
+	// UpgradePolicyAutoMinor means the newest recommended update of the current or next
+	// minor version is applied.
+	UpgradePolicyAutoMinor UpgradePolicyType = "AutoMinor"
+)
+
+// UpgradePolicy selects how a HostedCluster and its NodePools follow the updates
+// recommended by the update service.
+type UpgradePolicy struct {
+	// type is the way the HostedCluster follows its recommended updates.
+	// Automatic updates are not applied while the ClusterVersionUpgradeable condition is false,
+	// while the control plane is rolling out a release, when spec.controlPlaneRelease is set,
+	// or while a HostedClusterUpgradePlan which selects the HostedCluster has not completed.
+	// Conditional updates, which come with known risks, are never applied automatically.
+	//
+	// +kubebuilder:default=Manual
+	// +optional
+	Type UpgradePolicyType `json:"type,omitempty"`
+
+	// nodePools, when true together with an automatic type, rolls NodePools which ran a
+	// release previously run by the control plane to the HostedCluster release once the
+	// control plane upgrade completes.
+	//
+	// +optional
+	NodePools bool `json:"nodePools,omitempty"`
+}
+
 // Release represents the metadata for an OCP release payload image.
 type Release struct {
 	// Image is the image pullspec of an OCP release payload image.
@@ -770,6 +770,92 @@ func (in *ClusterVersionStatus) DeepCopy() *ClusterVersionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneComponentOverride) DeepCopyInto(out *ControlPlaneComponentOverride) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ControlPlaneContainerOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneComponentOverride.
+func (in *ControlPlaneComponentOverride) DeepCopy() *ControlPlaneComponentOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneComponentOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneContainerOverride) DeepCopyInto(out *ControlPlaneContainerOverride) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(ControlPlaneContainerResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GoRuntime != nil {
+		in, out := &in.GoRuntime, &out.GoRuntime
+		*out = new(GoRuntimeOverride)
+		**out = **in
+	}
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneContainerOverride.
+func (in *ControlPlaneContainerOverride) DeepCopy() *ControlPlaneContainerOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneContainerOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneContainerResources) DeepCopyInto(out *ControlPlaneContainerResources) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneContainerResources.
+func (in *ControlPlaneContainerResources) DeepCopy() *ControlPlaneContainerResources {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneContainerResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSSpec) DeepCopyInto(out *DNSSpec) {
 	*out = *in
@@ -866,6 +952,21 @@ func (in *Filter) DeepCopy() *Filter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GoRuntimeOverride) DeepCopyInto(out *GoRuntimeOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoRuntimeOverride.
+func (in *GoRuntimeOverride) DeepCopy() *GoRuntimeOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(GoRuntimeOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostedCluster) DeepCopyInto(out *HostedCluster) {
 	*out = *in
@@ -934,6 +1035,11 @@ func (in *HostedClusterSpec) DeepCopyInto(out *HostedClusterSpec) {
 		*out = new(Release)
 		**out = **in
 	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(UpgradePolicy)
+		**out = **in
+	}
 	in.Platform.DeepCopyInto(&out.Platform)
 	in.DNS.DeepCopyInto(&out.DNS)
 	in.Networking.DeepCopyInto(&out.Networking)
@@ -999,6 +1105,13 @@ func (in *HostedClusterSpec) DeepCopyInto(out *HostedClusterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ControlPlaneComponentOverrides != nil {
+		in, out := &in.ControlPlaneComponentOverrides, &out.ControlPlaneComponentOverrides
+		*out = make([]ControlPlaneComponentOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterSpec.
@@ -1206,6 +1319,13 @@ func (in *HostedControlPlaneSpec) DeepCopyInto(out *HostedControlPlaneSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ControlPlaneComponentOverrides != nil {
+		in, out := &in.ControlPlaneComponentOverrides, &out.ControlPlaneComponentOverrides
+		*out = make([]ControlPlaneComponentOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedControlPlaneSpec.
@@ -2578,6 +2698,21 @@ func (in *UnmanagedEtcdSpec) DeepCopy() *UnmanagedEtcdSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePolicy) DeepCopyInto(out *UpgradePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePolicy.
+func (in *UpgradePolicy) DeepCopy() *UpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Volume) DeepCopyInto(out *Volume) {
 	*out = *in
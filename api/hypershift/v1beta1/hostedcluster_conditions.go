@@ -99,6 +99,28 @@ const (
 	// A failure here may require external user intervention to resolve. E.g. oidc was deleted out of band.
 	ValidOIDCConfiguration ConditionType = "ValidOIDCConfiguration"
 
+	// ServiceAccountSigningKeyRotating indicates if a rotation of the service account signing key
+	// requested through the ServiceAccountSigningKeyRotationAnnotation is in progress. The reason
+	// is the current step of the rotation.
+	ServiceAccountSigningKeyRotating ConditionType = "ServiceAccountSigningKeyRotating"
+
+	// Hibernating indicates if the HostedCluster is hibernated through the HibernateAnnotation, or
+	// is on its way in or out of hibernation. The reason is the current step.
+	Hibernating ConditionType = "Hibernating"
+
+	// GuestDNSAvailable indicates if pods of the guest cluster can resolve service names.
+	// It is only reported when the GuestProbesAnnotation is set.
+	GuestDNSAvailable ConditionType = "GuestDNSAvailable"
+	// GuestServiceNetworkAvailable indicates if pods of the guest cluster can reach other pods through a service.
+	// It is only reported when the GuestProbesAnnotation is set.
+	GuestServiceNetworkAvailable ConditionType = "GuestServiceNetworkAvailable"
+	// GuestIngressAvailable indicates if the default ingress of the guest cluster serves routes.
+	// It is only reported when the GuestProbesAnnotation is set.
+	GuestIngressAvailable ConditionType = "GuestIngressAvailable"
+	// GuestKonnectivityAvailable indicates if the kube-apiserver can reach pods of the guest cluster
+	// through the konnectivity tunnel. It is only reported when the GuestProbesAnnotation is set.
+	GuestKonnectivityAvailable ConditionType = "GuestKonnectivityAvailable"
+
 	// ValidReleaseImage indicates if the release image set in the spec is valid
 	// for the HostedCluster. For example, this can be set false if the
 	// HostedCluster itself attempts an unsupported version before 4.9 or an
@@ -174,6 +196,10 @@ const (
 	ClusterSizeTransitionPending = "ClusterSizeTransitionPending"
 	// ClusterSizeTransitionRequired exposes the next t-shirt size that the cluster will transition to.
 	ClusterSizeTransitionRequired = "ClusterSizeTransitionRequired"
+	// ClusterResourceRequestsComputed indicates that the resource requests of control plane components were
+	// sized from their observed usage. The last transition time for this condition is used to manage how
+	// quickly the requests are updated.
+	ClusterResourceRequestsComputed = "ClusterResourceRequestsComputed"
 )
 
 // Reasons.
@@ -222,6 +248,15 @@ const (
 	ReconciliationInvalidPausedUntilConditionReason = "InvalidPausedUntilValue"
 
 	KubeVirtSuboptimalMTUReason = "KubeVirtSuboptimalMTUDetected"
+
+	PublishingNewServiceAccountKeyReason  = "PublishingNewKey"
+	SigningWithNewServiceAccountKeyReason = "SigningWithNewKey"
+
+	HibernationInProgressReason = "HibernationInProgress"
+	HibernatedReason            = "Hibernated"
+	ResumingReason              = "Resuming"
+
+	GuestProbeFailedReason = "ProbeFailed"
 )
 
 // Messages.
@@ -29,6 +29,10 @@ const (
 	// AuditWebhookKubeconfigKey is the key name in the AuditWebhook secret that stores audit webhook kubeconfig
 	AuditWebhookKubeconfigKey          = "webhook-kubeconfig"
 	DisablePKIReconciliationAnnotation = "hypershift.openshift.io/disable-pki-reconciliation"
+	// PublishOIDCDocumentsAnnotation opts a HostedCluster which is not on AWS and has a custom issuer URL into
+	// having its OIDC discovery documents published by the operator to its OIDC storage provider.
+	// AWS clusters always have their documents published.
+	PublishOIDCDocumentsAnnotation = "hypershift.openshift.io/publish-oidc-documents"
 	// SkipReleaseImageValidation skips any release validation that the HO version might dictate for any HC and skip min supported version check for NodePools.
 	SkipReleaseImageValidation                = "hypershift.openshift.io/skip-release-image-validation"
 	IdentityProviderOverridesAnnotationPrefix = "idpoverrides.hypershift.openshift.io/"
@@ -301,6 +305,42 @@ const (
 	// KubeAPIServerVerbosityLevelAnnotation allows specifing the log verbosity of kube-apiserver.
 	KubeAPIServerVerbosityLevelAnnotation = "hypershift.openshift.io/kube-apiserver-verbosity-level"
 
+	// IgnitionServerTokenBindingAnnotation enables token binding in the ignition server when set to "true".
+	// Machines are then created pointing at a placeholder user data Secret that does not exist, and the
+	// ignition server gives each of them its own user data, carrying a random token, before the instance
+	// can be launched. A payload request must present that token to claim the single-use enrollment of
+	// its Machine, so the shared NodePool token alone can no longer be used to fetch payloads.
+	// Enabling or disabling it rolls out the NodePool Machines.
+	// Refused requests are reported as events on the NodePool token Secret.
+	IgnitionServerTokenBindingAnnotation = "hypershift.openshift.io/ignition-server-token-binding"
+
+	// ServiceAccountSigningKeyRotationAnnotation requests a rotation of the service account signing key
+	// generated by HyperShift when set to a value which differs from the last rotation, e.g. a date.
+	// The new public key is first published in the OIDC documents and trusted by the kube-apiserver,
+	// signing switches to the new key after the overlap, and the old public key is retired after
+	// another overlap. Keys set through spec.serviceAccountSigningKey are not rotated.
+	// The progress is reported in the ServiceAccountSigningKeyRotating condition.
+	ServiceAccountSigningKeyRotationAnnotation = "hypershift.openshift.io/service-account-signing-key-rotation"
+
+	// ServiceAccountSigningKeyRotationOverlapAnnotation is the duration of each overlap of a service
+	// account signing key rotation, e.g. "48h". It defaults to 24h and should exceed the lifetime of
+	// bound service account tokens and the time relying parties cache the OIDC documents.
+	ServiceAccountSigningKeyRotationOverlapAnnotation = "hypershift.openshift.io/service-account-signing-key-rotation-overlap"
+
+	// HibernateAnnotation, when set to "true", hibernates the HostedCluster to free its resources. NodePools are
+	// scaled to zero, then the control plane is scaled down after a final etcd snapshot. Removing the annotation
+	// resumes the control plane in dependency order, renewing certificates which expired in the meantime, and
+	// restores the NodePools. The progress is reported in the Hibernating condition.
+	HibernateAnnotation = "hypershift.openshift.io/hibernate"
+
+	// GuestProbesAnnotation, when set to "true", runs a synthetic prober inside the guest cluster which
+	// periodically checks DNS resolution, service connectivity, the default ingress route and the konnectivity
+	// tunnel. The results are reported in the GuestDNSAvailable, GuestServiceNetworkAvailable,
+	// GuestIngressAvailable and GuestKonnectivityAvailable conditions and as guest cluster metrics.
+	// The prober runs the control-plane-operator image on the guest cluster nodes, so the pull secret of the
+	// guest cluster must grant access to the registry serving that image.
+	GuestProbesAnnotation = "hypershift.openshift.io/guest-probes"
+
 	// NodePoolSupportsKubevirtTopologySpreadConstraintsAnnotation indicates if the NodePool currently supports
 	// using TopologySpreadConstraints on the KubeVirt VMs.
 	//
@@ -314,6 +354,12 @@ const (
 	// This annotation signals to the NodePool controller that it is safe to use TopologySpreadConstraints on a NodePool
 	// without triggering an unexpected update of KubeVirt VMs.
 	NodePoolSupportsKubevirtTopologySpreadConstraintsAnnotation = "hypershift.openshift.io/nodepool-supports-kubevirt-topology-spread-constraints"
+
+	// HostedClusterInfrastructureAnnotation names a HostedClusterInfrastructure in the namespace of the
+	// HostedCluster whose cloud resources the HostedCluster uses. The HostedCluster is not reconciled until
+	// the infrastructure is available, then the resources reported in its status are set in the fields of
+	// the HostedCluster spec which are not set yet. Fields which are set must match the infrastructure.
+	HostedClusterInfrastructureAnnotation = "hypershift.openshift.io/infrastructure"
 )
 
 // HostedClusterSpec is the desired behavior of a HostedCluster.
@@ -360,18 +406,27 @@ type HostedClusterSpec struct {
 	// +optional
 	Channel string `json:"channel,omitempty"`
 
+	// upgradePolicy selects how the HostedCluster follows the updates recommended by the
+	// update service for its channel, as reported in status.version.availableUpdates.
+	// When omitted, spec.release is only changed by the user.
+	//
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+
 	// InfraID is a globally unique identifier for the cluster. This identifier
 	// will be used to associate various cloud resources with the HostedCluster
 	// and its associated NodePools.
 	//
 	// +optional
 	// +immutable
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf", message="infraID is immutable once set"
 	InfraID string `json:"infraID,omitempty"`
 
 	// Platform specifies the underlying infrastructure provider for the cluster
 	// and is used to configure platform specific behavior.
 	//
 	// +immutable
+	// +kubebuilder:validation:XValidation:rule="self.type == oldSelf.type", message="platform type is immutable"
 	Platform PlatformSpec `json:"platform"`
 
 	// ControllerAvailabilityPolicy specifies the availability policy applied to
@@ -400,6 +455,10 @@ type HostedClusterSpec struct {
 	//
 	// +immutable
 	// +kubebuilder:default={networkType: "OVNKubernetes", clusterNetwork: {{cidr: "10.132.0.0/14"}}, serviceNetwork: {{cidr: "172.31.0.0/16"}}}
+	// +kubebuilder:validation:XValidation:rule="self.networkType == oldSelf.networkType", message="networkType is immutable"
+	// +kubebuilder:validation:XValidation:rule="self.clusterNetwork == oldSelf.clusterNetwork", message="clusterNetwork is immutable"
+	// +kubebuilder:validation:XValidation:rule="has(self.serviceNetwork) == has(oldSelf.serviceNetwork) && (!has(self.serviceNetwork) || self.serviceNetwork == oldSelf.serviceNetwork)", message="serviceNetwork is immutable"
+	// +kubebuilder:validation:XValidation:rule="has(self.machineNetwork) == has(oldSelf.machineNetwork) && (!has(self.machineNetwork) || self.machineNetwork == oldSelf.machineNetwork)", message="machineNetwork is immutable"
 	Networking ClusterNetworking `json:"networking"`
 
 	// Autoscaling specifies auto-scaling behavior that applies to all NodePools
@@ -415,6 +474,7 @@ type HostedClusterSpec struct {
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default={managementType: "Managed", managed: {storage: {type: "PersistentVolume", persistentVolume: {size: "8Gi"}}}}
 	// +immutable
+	// +kubebuilder:validation:XValidation:rule="self.managementType == oldSelf.managementType", message="etcd managementType is immutable"
 	Etcd EtcdSpec `json:"etcd"`
 
 	// Services specifies how individual control plane services are published from
@@ -531,6 +591,113 @@ type HostedClusterSpec struct {
 	//
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// ControlPlaneComponentOverrides when specified, tune the Deployments and StatefulSets of the hosted control plane
+	// components. Each entry is matched by the name of the Deployment or StatefulSet of the component, e.g. kube-apiserver.
+	// The overrides take precedence over the ResourceRequestOverrideAnnotationPrefix, KubeAPIServerGOGCAnnotation,
+	// KubeAPIServerGOMemoryLimitAnnotation and priority class annotations.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=100
+	ControlPlaneComponentOverrides []ControlPlaneComponentOverride `json:"controlPlaneComponentOverrides,omitempty"`
+}
+
+// ControlPlaneComponentName is the name of the Deployment or StatefulSet of a hosted control plane component.
+//
+// +kubebuilder:validation:Enum=etcd;kube-apiserver;kube-controller-manager;kube-scheduler;openshift-apiserver;openshift-oauth-apiserver;oauth-openshift;openshift-controller-manager;openshift-route-controller-manager;cluster-policy-controller;cluster-version-operator;konnectivity-agent;ignition-server;ignition-server-proxy;cluster-network-operator;ingress-operator;dns-operator;cluster-node-tuning-operator;cluster-image-registry-operator;cluster-storage-operator;csi-snapshot-controller-operator;cloud-credential-operator;cloud-controller-manager;cluster-autoscaler;machine-approver;cluster-api;capi-provider;control-plane-operator;control-plane-pki-operator;hosted-cluster-config-operator;catalog-operator;olm-operator;packageserver;router;multus-admission-controller;network-node-identity
+type ControlPlaneComponentName string
+
+// ControlPlaneComponentOverride tunes a Deployment or StatefulSet of the hosted control plane.
+//
+// +kubebuilder:validation:XValidation:rule="self.name != 'etcd' || !has(self.replicas)", message="the replicas of etcd can not be overridden"
+type ControlPlaneComponentOverride struct {
+	// name is the name of the Deployment or StatefulSet of the component, e.g. kube-apiserver.
+	//
+	// +kubebuilder:validation:Required
+	Name ControlPlaneComponentName `json:"name"`
+
+	// replicas overrides the number of replicas derived from the controllerAvailabilityPolicy.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=5
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// priorityClassName overrides the priority class of the pods of the component.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// containers tune the containers of the pods of the component.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=20
+	Containers []ControlPlaneContainerOverride `json:"containers,omitempty"`
+}
+
+// ControlPlaneContainerOverride tunes a container of a hosted control plane component.
+type ControlPlaneContainerOverride struct {
+	// name is the name of the container, or init container.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// resources overrides the requests and limits of the container. Only the resources specified are overridden,
+	// other requests and limits of the container are kept.
+	//
+	// +optional
+	Resources *ControlPlaneContainerResources `json:"resources,omitempty"`
+
+	// goRuntime sets the Go runtime environment of the container.
+	//
+	// +optional
+	GoRuntime *GoRuntimeOverride `json:"goRuntime,omitempty"`
+
+	// extraArgs are appended to the arguments of the container. They must be flags in the --flag=value form, and only
+	// the flags which tune the performance or the verbosity of the component are allowed, e.g. --max-requests-inflight
+	// for the kube-apiserver or --quota-backend-bytes for etcd. Other flags are rejected.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=50
+	// +kubebuilder:validation:items:MaxLength=1024
+	// +kubebuilder:validation:XValidation:rule="self.all(arg, arg.matches('^--[a-z0-9][-a-z0-9]*=.*$'))", message="extraArgs must be flags in the --flag=value form"
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+}
+
+// ControlPlaneContainerResources are the resources overridden on a container.
+type ControlPlaneContainerResources struct {
+	// requests overrides the resource requests of the container.
+	//
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+
+	// limits overrides the resource limits of the container.
+	//
+	// +optional
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+}
+
+// GoRuntimeOverride sets the Go runtime environment variables of a container.
+type GoRuntimeOverride struct {
+	// gogc sets the GOGC environment variable, the garbage collection target percentage, or off.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9]+|off)$`
+	GOGC string `json:"gogc,omitempty"`
+
+	// goMemoryLimit sets the GOMEMLIMIT environment variable, the soft memory limit of the Go runtime, e.g. 3GiB.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(B|KiB|MiB|GiB|TiB)?$`
+	GOMemoryLimit string `json:"goMemoryLimit,omitempty"`
 }
 
 // OLMCatalogPlacement is an enum specifying the placement of OLM catalog components.
@@ -1841,6 +2008,42 @@ type OpenStackPlatformSpec struct {
 	CACertSecret     *corev1.LocalObjectReference `json:"caCertSecret,omitempty"`
 }
 
+// UpgradePolicyType is the way a HostedCluster follows its recommended updates.
+// +kubebuilder:validation:Enum=Manual;AutoPatch;AutoMinor
+type UpgradePolicyType string
+
+const (
+	// UpgradePolicyManual means spec.release is only changed by the user.
+	UpgradePolicyManual UpgradePolicyType = "Manual"
+	// UpgradePolicyAutoPatch means the newest recommended update within the current minor
+	// version is applied.
+	UpgradePolicyAutoPatch UpgradePolicyType = "AutoPatch"
+	// UpgradePolicyAutoMinor means the newest recommended update of the current or next
+	// minor version is applied.
+	UpgradePolicyAutoMinor UpgradePolicyType = "AutoMinor"
+)
+
+// UpgradePolicy selects how a HostedCluster and its NodePools follow the updates
+// recommended by the update service.
+type UpgradePolicy struct {
+	// type is the way the HostedCluster follows its recommended updates.
+	// Automatic updates are not applied while the ClusterVersionUpgradeable condition is false,
+	// while the control plane is rolling out a release, when spec.controlPlaneRelease is set,
+	// or while a HostedClusterUpgradePlan which selects the HostedCluster has not completed.
+	// Conditional updates, which come with known risks, are never applied automatically.
+	//
+	// +kubebuilder:default=Manual
+	// +optional
+	Type UpgradePolicyType `json:"type,omitempty"`
+
+	// nodePools, when true together with an automatic type, rolls NodePools which ran a
+	// release previously run by the control plane to the HostedCluster release once the
+	// control plane upgrade completes.
+	//
+	// +optional
+	NodePools bool `json:"nodePools,omitempty"`
+}
+
 // Release represents the metadata for an OCP release payload image.
 type Release struct {
 	// Image is the image pullspec of an OCP release payload image.
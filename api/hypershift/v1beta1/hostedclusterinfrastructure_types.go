@@ -0,0 +1,521 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	SchemeBuilder.Register(func(scheme *runtime.Scheme) error {
+		scheme.AddKnownTypes(SchemeGroupVersion,
+			&HostedClusterInfrastructure{},
+			&HostedClusterInfrastructureList{},
+		)
+		return nil
+	})
+}
+
+// The following are the conditions and reasons of a HostedClusterInfrastructure.
+const (
+	// InfrastructureAvailable indicates whether the cloud resources of the infrastructure exist and
+	// match its spec as of the last sync.
+	InfrastructureAvailable ConditionType = "Available"
+
+	InfrastructureSyncedReason           string = "Synced"
+	InfrastructureSyncFailedReason       string = "SyncFailed"
+	InfrastructureInvalidConfigReason    string = "InvalidConfiguration"
+	InfrastructureCredentialsErrorReason string = "CredentialsError"
+)
+
+// HostedClusterInfrastructureSpec defines the cloud resources of a HostedCluster.
+type HostedClusterInfrastructureSpec struct {
+	// infraID is the identifier the cloud resources of the infrastructure are tagged with. It must be
+	// used as the infraID of the HostedCluster consuming the infrastructure.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=255
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="infraID is immutable"
+	InfraID string `json:"infraID"`
+
+	// dns is the DNS configuration of the cluster, used to look up its public zone and to name its
+	// private zones.
+	//
+	// +kubebuilder:validation:Required
+	DNS HostedClusterInfrastructureDNS `json:"dns"`
+
+	// platform is the cloud platform of the infrastructure.
+	//
+	// +kubebuilder:validation:Required
+	Platform HostedClusterInfrastructurePlatform `json:"platform"`
+
+	// resyncInterval is how often the cloud resources reported in the status are checked for drift, e.g. a
+	// deleted NAT gateway. They are only synced again, which repairs them, when drift is found.
+	//
+	// +kubebuilder:default="10m"
+	// +optional
+	ResyncInterval metav1.Duration `json:"resyncInterval,omitempty"`
+}
+
+// HostedClusterInfrastructureDNS is the DNS configuration of a HostedClusterInfrastructure.
+type HostedClusterInfrastructureDNS struct {
+	// baseDomain is the base domain of the cluster. It must have a public zone the credentials can
+	// create records in.
+	//
+	// +kubebuilder:validation:Required
+	BaseDomain string `json:"baseDomain"`
+
+	// baseDomainPrefix is prepended to the base domain to name the private zone of the cluster. It
+	// defaults to the name of the HostedClusterInfrastructure. Use "none" for no prefix. It is only used
+	// on AWS.
+	//
+	// +optional
+	BaseDomainPrefix *string `json:"baseDomainPrefix,omitempty"`
+}
+
+// HostedClusterInfrastructurePlatform is the cloud platform of a HostedClusterInfrastructure.
+//
+// +kubebuilder:validation:XValidation:rule="self.type != 'AWS' || has(self.aws)",message="aws is required when type is AWS"
+// +kubebuilder:validation:XValidation:rule="self.type != 'Azure' || has(self.azure)",message="azure is required when type is Azure"
+// +kubebuilder:validation:XValidation:rule="self.type != 'PowerVS' || has(self.powervs)",message="powervs is required when type is PowerVS"
+type HostedClusterInfrastructurePlatform struct {
+	// type is the type of the cloud platform.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=AWS;Azure;PowerVS
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="type is immutable"
+	Type PlatformType `json:"type"`
+
+	// aws is the AWS infrastructure.
+	//
+	// +optional
+	AWS *AWSInfrastructureSpec `json:"aws,omitempty"`
+
+	// azure is the Azure infrastructure.
+	//
+	// +optional
+	Azure *AzureInfrastructureSpec `json:"azure,omitempty"`
+
+	// powervs is the IBM Cloud PowerVS infrastructure.
+	//
+	// +optional
+	PowerVS *PowerVSInfrastructureSpec `json:"powervs,omitempty"`
+}
+
+// AWSInfrastructureSpec defines the AWS resources of a HostedCluster.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.existingVPC) || !has(self.zones)",message="zones cannot be set with existingVPC"
+type AWSInfrastructureSpec struct {
+	// region is the AWS region of the infrastructure.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="region is immutable"
+	Region string `json:"region"`
+
+	// credentialsSecret references a secret in the namespace of the HostedClusterInfrastructure with the
+	// AWS credentials allowed to manage the resources of the infrastructure in its "aws_access_key_id",
+	// "aws_secret_access_key" and optional "aws_session_token" keys.
+	//
+	// +kubebuilder:validation:Required
+	CredentialsSecret corev1.LocalObjectReference `json:"credentialsSecret"`
+
+	// zones are the availability zones of the VPC, a private and a public subnet are created in each.
+	// It defaults to the first zone of the region.
+	//
+	// +optional
+	// +listType=set
+	Zones []string `json:"zones,omitempty"`
+
+	// existingVPC is an existing VPC that is adopted instead of creating one. The VPC and its subnets are
+	// tagged as shared with the cluster and are never deleted.
+	//
+	// +optional
+	ExistingVPC *AWSInfrastructureExistingVPC `json:"existingVPC,omitempty"`
+
+	// singleNATGateway creates a single NAT gateway for all the zones instead of one per zone.
+	//
+	// +optional
+	SingleNATGateway bool `json:"singleNATGateway,omitempty"`
+
+	// dualStack associates an IPv6 CIDR with the VPC and makes its subnets dual-stack.
+	//
+	// +optional
+	DualStack bool `json:"dualStack,omitempty"`
+
+	// resourceTags are additional tags set on the AWS resources of the infrastructure.
+	//
+	// +kubebuilder:validation:MaxItems=25
+	// +optional
+	ResourceTags []AWSResourceTag `json:"resourceTags,omitempty"`
+
+	// iam enables the management of the IAM resources of the cluster: the OIDC provider, the roles of
+	// the control plane components and the instance profile of the workers.
+	//
+	// +optional
+	IAM *AWSInfrastructureIAMSpec `json:"iam,omitempty"`
+}
+
+// AWSInfrastructureExistingVPC is an existing VPC used by a HostedClusterInfrastructure.
+type AWSInfrastructureExistingVPC struct {
+	// id is the ID of the VPC.
+	//
+	// +kubebuilder:validation:Required
+	ID string `json:"id"`
+
+	// privateSubnetIDs are the IDs of the private subnets of the VPC, at most one per availability zone.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	PrivateSubnetIDs []string `json:"privateSubnetIDs"`
+
+	// publicSubnetIDs are the IDs of the public subnets of the VPC.
+	//
+	// +optional
+	// +listType=set
+	PublicSubnetIDs []string `json:"publicSubnetIDs,omitempty"`
+}
+
+// AWSInfrastructureIAMSpec defines the IAM resources of a HostedClusterInfrastructure.
+type AWSInfrastructureIAMSpec struct {
+	// issuerURL is the URL of the OIDC issuer of the cluster. It defaults to the location of the OIDC
+	// documents of the cluster in the OIDC bucket of the HyperShift operator.
+	//
+	// +optional
+	IssuerURL string `json:"issuerURL,omitempty"`
+}
+
+// AzureInfrastructureSpec defines the Azure resources of a HostedCluster.
+type AzureInfrastructureSpec struct {
+	// location is the Azure location of the infrastructure.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="location is immutable"
+	Location string `json:"location"`
+
+	// credentialsSecret references a secret in the namespace of the HostedClusterInfrastructure with the
+	// Azure credentials allowed to manage the resources of the infrastructure in its "AZURE_SUBSCRIPTION_ID",
+	// "AZURE_TENANT_ID", "AZURE_CLIENT_ID" and "AZURE_CLIENT_SECRET" keys, the same as the credentials
+	// secret of an Azure HostedCluster.
+	//
+	// +kubebuilder:validation:Required
+	CredentialsSecret corev1.LocalObjectReference `json:"credentialsSecret"`
+
+	// resourceGroupName is an existing resource group the resources of the infrastructure are created in.
+	// By default, a resource group named after the HostedClusterInfrastructure and its infraID is created
+	// and it is deleted with all its resources when the HostedClusterInfrastructure is deleted. An existing
+	// resource group is never deleted, so the resources created in it must be deleted manually.
+	//
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="resourceGroupName is immutable"
+	ResourceGroupName string `json:"resourceGroupName,omitempty"`
+
+	// resourceGroupTags are additional tags set on the resource group created for the infrastructure.
+	//
+	// +optional
+	ResourceGroupTags map[string]string `json:"resourceGroupTags,omitempty"`
+
+	// vnetID is the ID of an existing virtual network that is used instead of creating one.
+	//
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="vnetID is immutable"
+	VnetID string `json:"vnetID,omitempty"`
+
+	// subnetID is the ID of an existing subnet of the virtual network the VMs are placed in.
+	//
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="subnetID is immutable"
+	SubnetID string `json:"subnetID,omitempty"`
+
+	// networkSecurityGroupID is the ID of an existing network security group that is used instead of
+	// creating one.
+	//
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="networkSecurityGroupID is immutable"
+	NetworkSecurityGroupID string `json:"networkSecurityGroupID,omitempty"`
+
+	// rhcosImage is the URL of the RHCOS VHD the boot image of the NodePools is created from. It is only
+	// uploaded once. It can be obtained from the "rhel-coreos-extensions" stream of the release image, e.g.
+	// with `oc adm release info --image-for=machine-os-images`.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="rhcosImage is immutable"
+	RHCOSImage string `json:"rhcosImage"`
+}
+
+// PowerVSInfrastructureSpec defines the IBM Cloud PowerVS resources of a HostedCluster.
+type PowerVSInfrastructureSpec struct {
+	// resourceGroup is the IBM Cloud resource group the resources of the infrastructure are created in.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="resourceGroup is immutable"
+	ResourceGroup string `json:"resourceGroup"`
+
+	// region is the IBM Cloud PowerVS region of the infrastructure.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="region is immutable"
+	Region string `json:"region"`
+
+	// zone is the IBM Cloud PowerVS zone of the infrastructure.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="zone is immutable"
+	Zone string `json:"zone"`
+
+	// vpcRegion is the IBM Cloud region of the VPC of the infrastructure.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="vpcRegion is immutable"
+	VPCRegion string `json:"vpcRegion"`
+
+	// credentialsSecret references a secret in the namespace of the HostedClusterInfrastructure with the
+	// IBM Cloud API key allowed to manage the resources of the infrastructure in its "ibmcloud_api_key" key.
+	//
+	// +kubebuilder:validation:Required
+	CredentialsSecret corev1.LocalObjectReference `json:"credentialsSecret"`
+
+	// powerEdgeRouter connects the PowerVS workspace and the VPC with a transit gateway through the Power
+	// Edge Router instead of a cloud connection.
+	//
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="powerEdgeRouter is immutable"
+	PowerEdgeRouter bool `json:"powerEdgeRouter,omitempty"`
+
+	// transitGatewayLocation is the IBM Cloud location of the transit gateway, it is required with
+	// powerEdgeRouter.
+	//
+	// +optional
+	TransitGatewayLocation string `json:"transitGatewayLocation,omitempty"`
+
+	// transitGatewayGlobalRouting enables global routing for the transit gateway of powerEdgeRouter.
+	//
+	// +optional
+	TransitGatewayGlobalRouting bool `json:"transitGatewayGlobalRouting,omitempty"`
+}
+
+// HostedClusterInfrastructureStatus reports the cloud resources of a HostedClusterInfrastructure.
+type HostedClusterInfrastructureStatus struct {
+	// aws reports the AWS resources of the infrastructure, to be used in the spec of the HostedCluster
+	// and its NodePools.
+	//
+	// +optional
+	AWS *AWSInfrastructureStatus `json:"aws,omitempty"`
+
+	// azure reports the Azure resources of the infrastructure, to be used in the spec of the HostedCluster
+	// and its NodePools.
+	//
+	// +optional
+	Azure *AzureInfrastructureStatus `json:"azure,omitempty"`
+
+	// powervs reports the IBM Cloud PowerVS resources of the infrastructure, to be used in the spec of the
+	// HostedCluster and its NodePools.
+	//
+	// +optional
+	PowerVS *PowerVSInfrastructureStatus `json:"powervs,omitempty"`
+
+	// lastSyncTime is the last time the cloud resources were successfully synced or verified.
+	//
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// observedGeneration is the generation of the infrastructure last synced.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// conditions contains details about the state of the infrastructure.
+	// Current condition types are: "Available".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// AWSInfrastructureStatus reports the AWS resources of a HostedClusterInfrastructure.
+type AWSInfrastructureStatus struct {
+	// vpcID is the ID of the VPC of the cluster.
+	//
+	// +optional
+	VPCID string `json:"vpcID,omitempty"`
+
+	// machineCIDR is the IPv4 CIDR of the VPC, to be used as machine network of the cluster.
+	//
+	// +optional
+	MachineCIDR string `json:"machineCIDR,omitempty"`
+
+	// machineCIDRv6 is the IPv6 CIDR of the VPC of a dual-stack infrastructure.
+	//
+	// +optional
+	MachineCIDRv6 string `json:"machineCIDRv6,omitempty"`
+
+	// zones are the availability zones of the infrastructure and the private subnets NodePools are
+	// created in.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Zones []AWSInfrastructureZone `json:"zones,omitempty"`
+
+	// publicZoneID is the ID of the public hosted zone of the base domain.
+	//
+	// +optional
+	PublicZoneID string `json:"publicZoneID,omitempty"`
+
+	// privateZoneID is the ID of the private hosted zone of the cluster.
+	//
+	// +optional
+	PrivateZoneID string `json:"privateZoneID,omitempty"`
+
+	// localZoneID is the ID of the private hosted zone used by private clusters.
+	//
+	// +optional
+	LocalZoneID string `json:"localZoneID,omitempty"`
+
+	// iam reports the IAM resources of the cluster, when they are managed.
+	//
+	// +optional
+	IAM *AWSInfrastructureIAMStatus `json:"iam,omitempty"`
+}
+
+// AWSInfrastructureZone is an availability zone of a HostedClusterInfrastructure.
+type AWSInfrastructureZone struct {
+	// name is the name of the availability zone.
+	Name string `json:"name"`
+
+	// subnetID is the ID of the private subnet of the zone.
+	SubnetID string `json:"subnetID"`
+}
+
+// AWSInfrastructureIAMStatus reports the IAM resources of a HostedClusterInfrastructure.
+type AWSInfrastructureIAMStatus struct {
+	// issuerURL is the URL of the OIDC issuer of the cluster.
+	IssuerURL string `json:"issuerURL"`
+
+	// instanceProfile is the name of the instance profile of the workers.
+	InstanceProfile string `json:"instanceProfile"`
+
+	// roles are the ARNs of the roles of the control plane components.
+	Roles AWSRolesRef `json:"roles"`
+}
+
+// AzureInfrastructureStatus reports the Azure resources of a HostedClusterInfrastructure.
+type AzureInfrastructureStatus struct {
+	// subscriptionID is the ID of the Azure subscription of the infrastructure.
+	SubscriptionID string `json:"subscriptionID"`
+
+	// resourceGroupName is the name of the resource group of the infrastructure.
+	ResourceGroupName string `json:"resourceGroupName"`
+
+	// vnetID is the ID of the virtual network of the cluster.
+	VnetID string `json:"vnetID"`
+
+	// subnetID is the ID of the subnet the VMs are placed in.
+	SubnetID string `json:"subnetID"`
+
+	// securityGroupID is the ID of the network security group of the subnet.
+	SecurityGroupID string `json:"securityGroupID"`
+
+	// machineIdentityID is the ID of the managed identity of the VMs.
+	MachineIdentityID string `json:"machineIdentityID"`
+
+	// bootImageID is the ID of the boot image of the NodePools.
+	BootImageID string `json:"bootImageID"`
+
+	// publicZoneID is the ID of the public DNS zone of the base domain.
+	//
+	// +optional
+	PublicZoneID string `json:"publicZoneID,omitempty"`
+
+	// privateZoneID is the ID of the private DNS zone of the cluster.
+	//
+	// +optional
+	PrivateZoneID string `json:"privateZoneID,omitempty"`
+}
+
+// PowerVSInfrastructureStatus reports the IBM Cloud PowerVS resources of a HostedClusterInfrastructure.
+type PowerVSInfrastructureStatus struct {
+	// accountID is the IBM Cloud account ID of the infrastructure.
+	AccountID string `json:"accountID"`
+
+	// cisInstanceCRN is the CRN of the Cloud Internet Services instance of the base domain.
+	CISInstanceCRN string `json:"cisInstanceCRN"`
+
+	// cisDomainID is the ID of the base domain in the Cloud Internet Services instance, used as the DNS
+	// zone of the cluster.
+	CISDomainID string `json:"cisDomainID"`
+
+	// serviceInstanceID is the ID of the PowerVS workspace of the cluster.
+	ServiceInstanceID string `json:"serviceInstanceID"`
+
+	// dhcpID is the ID of the DHCP server of the PowerVS workspace.
+	DHCPID string `json:"dhcpID"`
+
+	// subnet is the DHCP subnet of the PowerVS workspace the NodePools are created in.
+	Subnet PowerVSResourceReference `json:"subnet"`
+
+	// vpc is the VPC the load balancers of the cluster are created in.
+	VPC PowerVSVPC `json:"vpc"`
+
+	// vpcID is the ID of the VPC.
+	VPCID string `json:"vpcID"`
+
+	// vpcSubnetID is the ID of the subnet of the VPC.
+	VPCSubnetID string `json:"vpcSubnetID"`
+
+	// credentials references the secrets, in the namespace of the HostedClusterInfrastructure, with the API
+	// keys of the service IDs of the control plane components. They can only be read once from IBM Cloud,
+	// so the service IDs are recreated whenever one of the secrets is missing.
+	Credentials PowerVSInfrastructureCredentials `json:"credentials"`
+}
+
+// PowerVSInfrastructureCredentials references the secrets with the credentials of the control plane
+// components of a PowerVS HostedCluster.
+type PowerVSInfrastructureCredentials struct {
+	// kubeCloudControllerCreds is the secret of the kube cloud controller.
+	KubeCloudControllerCreds corev1.LocalObjectReference `json:"kubeCloudControllerCreds"`
+
+	// nodePoolManagementCreds is the secret of the NodePool management.
+	NodePoolManagementCreds corev1.LocalObjectReference `json:"nodePoolManagementCreds"`
+
+	// ingressOperatorCloudCreds is the secret of the ingress operator.
+	IngressOperatorCloudCreds corev1.LocalObjectReference `json:"ingressOperatorCloudCreds"`
+
+	// storageOperatorCloudCreds is the secret of the storage operator.
+	StorageOperatorCloudCreds corev1.LocalObjectReference `json:"storageOperatorCloudCreds"`
+
+	// imageRegistryOperatorCloudCreds is the secret of the image registry operator.
+	ImageRegistryOperatorCloudCreds corev1.LocalObjectReference `json:"imageRegistryOperatorCloudCreds"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hostedclusterinfrastructures,shortName=hci;hcis,scope=Namespaced
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Infra ID",type="string",JSONPath=".spec.infraID",description="Infra ID"
+// +kubebuilder:printcolumn:name="Platform",type="string",JSONPath=".spec.platform.type",description="Platform"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Available\")].status",description="Available"
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime",description="Last successful sync"
+// +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[?(@.type==\"Available\")].message",description="Message"
+// HostedClusterInfrastructure creates or adopts the cloud resources of a HostedCluster, such as its network,
+// DNS zones and identities, keeps them in sync with its spec and deletes them when it is deleted.
+// A HostedCluster consumes a HostedClusterInfrastructure of its namespace by naming it in its
+// "hypershift.openshift.io/infrastructure" annotation: it waits for the infrastructure to be available and
+// takes the resources reported in its status for the fields of its spec which are not set.
+type HostedClusterInfrastructure struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HostedClusterInfrastructureSpec   `json:"spec,omitempty"`
+	Status HostedClusterInfrastructureStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// HostedClusterInfrastructureList contains a list of HostedClusterInfrastructure
+type HostedClusterInfrastructureList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HostedClusterInfrastructure `json:"items"`
+}
@@ -0,0 +1,219 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	SchemeBuilder.Register(func(scheme *runtime.Scheme) error {
+		scheme.AddKnownTypes(SchemeGroupVersion,
+			&HostedClusterUpgradePlan{},
+			&HostedClusterUpgradePlanList{},
+		)
+		return nil
+	})
+}
+
+// The following are the conditions and reasons of a HostedClusterUpgradePlan.
+const (
+	// UpgradePlanProgressing indicates whether the plan is upgrading HostedClusters.
+	UpgradePlanProgressing ConditionType = "Progressing"
+	// UpgradePlanHalted indicates whether the plan stopped upgrading further HostedClusters
+	// because the upgrade of a HostedCluster failed.
+	UpgradePlanHalted ConditionType = "Halted"
+
+	UpgradePlanCompletedReason     string = "Completed"
+	UpgradePlanPausedReason        string = "Paused"
+	UpgradePlanSoakingReason       string = "Soaking"
+	UpgradePlanClusterFailedReason string = "ClusterUpgradeFailed"
+)
+
+// HostedClusterUpgradePhase is the upgrade progress of a HostedCluster selected by a HostedClusterUpgradePlan.
+type HostedClusterUpgradePhase string
+
+const (
+	// HostedClusterUpgradePending means the upgrade of the HostedCluster has not started.
+	HostedClusterUpgradePending HostedClusterUpgradePhase = "Pending"
+	// HostedClusterUpgradeUpgrading means the HostedCluster is rolling out the release of the plan.
+	HostedClusterUpgradeUpgrading HostedClusterUpgradePhase = "Upgrading"
+	// HostedClusterUpgradeCompleted means the HostedCluster completed the rollout of the release of the plan.
+	HostedClusterUpgradeCompleted HostedClusterUpgradePhase = "Completed"
+	// HostedClusterUpgradeFailed means the HostedCluster reports its upgrade is failing, blocked or degraded.
+	HostedClusterUpgradeFailed HostedClusterUpgradePhase = "Failed"
+)
+
+// HostedClusterUpgradeWavePhase is the upgrade progress of a wave of a HostedClusterUpgradePlan.
+type HostedClusterUpgradeWavePhase string
+
+const (
+	// HostedClusterUpgradeWavePending means no HostedCluster of the wave is upgraded yet.
+	HostedClusterUpgradeWavePending HostedClusterUpgradeWavePhase = "Pending"
+	// HostedClusterUpgradeWaveUpgrading means the HostedClusters of the wave are being upgraded.
+	HostedClusterUpgradeWaveUpgrading HostedClusterUpgradeWavePhase = "Upgrading"
+	// HostedClusterUpgradeWaveSoaking means every HostedCluster of the wave is upgraded and the
+	// plan waits for the soak duration of the wave before starting the next one.
+	HostedClusterUpgradeWaveSoaking HostedClusterUpgradeWavePhase = "Soaking"
+	// HostedClusterUpgradeWaveCompleted means the wave is upgraded and soaked.
+	HostedClusterUpgradeWaveCompleted HostedClusterUpgradeWavePhase = "Completed"
+)
+
+// HostedClusterUpgradePlanSpec defines the desired state of HostedClusterUpgradePlan
+type HostedClusterUpgradePlanSpec struct {
+	// release is the release image the HostedClusters selected by the plan are upgraded to.
+	//
+	// +kubebuilder:validation:Required
+	Release Release `json:"release"`
+
+	// selector selects the HostedClusters in the namespace of the plan which are upgraded.
+	//
+	// +kubebuilder:validation:Required
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// waves are upgraded one after the other, in order. A HostedCluster belongs to the first
+	// wave whose selector matches it. HostedClusters which match no wave are not upgraded.
+	// The next wave starts once every HostedCluster of the previous wave completed its upgrade
+	// and the soak duration of the previous wave elapsed.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Waves []HostedClusterUpgradeWave `json:"waves"`
+
+	// paused stops the plan from starting the upgrade of further HostedClusters. Upgrades which
+	// already started are not interrupted.
+	//
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// HostedClusterUpgradeWave is a group of HostedClusters upgraded together.
+type HostedClusterUpgradeWave struct {
+	// name identifies the wave.
+	//
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// selector selects the HostedClusters of the wave among the ones selected by the plan.
+	// When omitted, every HostedCluster which does not belong to a previous wave belongs to this one.
+	//
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// maxConcurrency is the maximum number of HostedClusters of the wave upgrading at the same time.
+	//
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrency int32 `json:"maxConcurrency,omitempty"`
+
+	// soakDuration is how long the plan waits after every HostedCluster of the wave completed its
+	// upgrade before starting the next wave. A HostedCluster failing during that time halts the plan.
+	//
+	// +optional
+	SoakDuration metav1.Duration `json:"soakDuration,omitempty"`
+}
+
+// HostedClusterUpgradePlanStatus defines the observed state of HostedClusterUpgradePlan
+type HostedClusterUpgradePlanStatus struct {
+	// currentWave is the name of the wave being upgraded or soaked. It is empty when every wave completed.
+	//
+	// +optional
+	CurrentWave string `json:"currentWave,omitempty"`
+
+	// waves reports the progress of each wave of the plan.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Waves []HostedClusterUpgradeWaveStatus `json:"waves,omitempty"`
+
+	// clusters reports the upgrade progress of each HostedCluster selected by the plan.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Clusters []HostedClusterUpgradeStatus `json:"clusters,omitempty"`
+
+	// observedGeneration is the generation of the plan last reconciled.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// conditions contains details about the progress of the plan.
+	// Current condition types are: "Progressing" and "Halted".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// HostedClusterUpgradeWaveStatus is the progress of a wave of a HostedClusterUpgradePlan.
+type HostedClusterUpgradeWaveStatus struct {
+	// name is the name of the wave.
+	Name string `json:"name"`
+
+	// phase is the progress of the wave.
+	Phase HostedClusterUpgradeWavePhase `json:"phase"`
+
+	// completionTime is the time every HostedCluster of the wave completed its upgrade.
+	//
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// HostedClusterUpgradeStatus is the upgrade progress of a HostedCluster selected by a HostedClusterUpgradePlan.
+type HostedClusterUpgradeStatus struct {
+	// name is the name of the HostedCluster.
+	Name string `json:"name"`
+
+	// wave is the name of the wave the HostedCluster belongs to.
+	Wave string `json:"wave"`
+
+	// phase is the upgrade progress of the HostedCluster.
+	Phase HostedClusterUpgradePhase `json:"phase"`
+
+	// message explains why the upgrade of the HostedCluster failed.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// startedTime is the time the plan started the upgrade of the HostedCluster.
+	//
+	// +optional
+	StartedTime *metav1.Time `json:"startedTime,omitempty"`
+
+	// completionTime is the time the HostedCluster completed the rollout of the release of the plan.
+	//
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hostedclusterupgradeplans,shortName=hcup;hcups,scope=Namespaced
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Release",type="string",JSONPath=".spec.release.image",description="Release"
+// +kubebuilder:printcolumn:name="Wave",type="string",JSONPath=".status.currentWave",description="Current wave"
+// +kubebuilder:printcolumn:name="Progressing",type="string",JSONPath=".status.conditions[?(@.type==\"Progressing\")].status",description="Progressing"
+// +kubebuilder:printcolumn:name="Halted",type="string",JSONPath=".status.conditions[?(@.type==\"Halted\")].status",description="Halted"
+// +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[?(@.type==\"Progressing\")].message",description="Message"
+// HostedClusterUpgradePlan upgrades a fleet of HostedClusters to a release in waves.
+type HostedClusterUpgradePlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HostedClusterUpgradePlanSpec   `json:"spec,omitempty"`
+	Status HostedClusterUpgradePlanStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// HostedClusterUpgradePlanList contains a list of HostedClusterUpgradePlan
+type HostedClusterUpgradePlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HostedClusterUpgradePlan `json:"items"`
+}
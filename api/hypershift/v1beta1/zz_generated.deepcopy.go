@@ -225,6 +225,138 @@ func (in *AWSEndpointServiceStatus) DeepCopy() *AWSEndpointServiceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSInfrastructureExistingVPC) DeepCopyInto(out *AWSInfrastructureExistingVPC) {
+	*out = *in
+	if in.PrivateSubnetIDs != nil {
+		in, out := &in.PrivateSubnetIDs, &out.PrivateSubnetIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PublicSubnetIDs != nil {
+		in, out := &in.PublicSubnetIDs, &out.PublicSubnetIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSInfrastructureExistingVPC.
+func (in *AWSInfrastructureExistingVPC) DeepCopy() *AWSInfrastructureExistingVPC {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSInfrastructureExistingVPC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSInfrastructureIAMSpec) DeepCopyInto(out *AWSInfrastructureIAMSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSInfrastructureIAMSpec.
+func (in *AWSInfrastructureIAMSpec) DeepCopy() *AWSInfrastructureIAMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSInfrastructureIAMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSInfrastructureIAMStatus) DeepCopyInto(out *AWSInfrastructureIAMStatus) {
+	*out = *in
+	out.Roles = in.Roles
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSInfrastructureIAMStatus.
+func (in *AWSInfrastructureIAMStatus) DeepCopy() *AWSInfrastructureIAMStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSInfrastructureIAMStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSInfrastructureSpec) DeepCopyInto(out *AWSInfrastructureSpec) {
+	*out = *in
+	out.CredentialsSecret = in.CredentialsSecret
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExistingVPC != nil {
+		in, out := &in.ExistingVPC, &out.ExistingVPC
+		*out = new(AWSInfrastructureExistingVPC)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceTags != nil {
+		in, out := &in.ResourceTags, &out.ResourceTags
+		*out = make([]AWSResourceTag, len(*in))
+		copy(*out, *in)
+	}
+	if in.IAM != nil {
+		in, out := &in.IAM, &out.IAM
+		*out = new(AWSInfrastructureIAMSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSInfrastructureSpec.
+func (in *AWSInfrastructureSpec) DeepCopy() *AWSInfrastructureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSInfrastructureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSInfrastructureStatus) DeepCopyInto(out *AWSInfrastructureStatus) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]AWSInfrastructureZone, len(*in))
+		copy(*out, *in)
+	}
+	if in.IAM != nil {
+		in, out := &in.IAM, &out.IAM
+		*out = new(AWSInfrastructureIAMStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSInfrastructureStatus.
+func (in *AWSInfrastructureStatus) DeepCopy() *AWSInfrastructureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSInfrastructureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSInfrastructureZone) DeepCopyInto(out *AWSInfrastructureZone) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSInfrastructureZone.
+func (in *AWSInfrastructureZone) DeepCopy() *AWSInfrastructureZone {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSInfrastructureZone)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWSKMSAuthSpec) DeepCopyInto(out *AWSKMSAuthSpec) {
 	*out = *in
@@ -483,6 +615,44 @@ func (in *AgentPlatformSpec) DeepCopy() *AgentPlatformSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureInfrastructureSpec) DeepCopyInto(out *AzureInfrastructureSpec) {
+	*out = *in
+	out.CredentialsSecret = in.CredentialsSecret
+	if in.ResourceGroupTags != nil {
+		in, out := &in.ResourceGroupTags, &out.ResourceGroupTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureInfrastructureSpec.
+func (in *AzureInfrastructureSpec) DeepCopy() *AzureInfrastructureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureInfrastructureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureInfrastructureStatus) DeepCopyInto(out *AzureInfrastructureStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureInfrastructureStatus.
+func (in *AzureInfrastructureStatus) DeepCopy() *AzureInfrastructureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureInfrastructureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureKMSKey) DeepCopyInto(out *AzureKMSKey) {
 	*out = *in
@@ -833,6 +1003,92 @@ func (in *ClusterVersionStatus) DeepCopy() *ClusterVersionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneComponentOverride) DeepCopyInto(out *ControlPlaneComponentOverride) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ControlPlaneContainerOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneComponentOverride.
+func (in *ControlPlaneComponentOverride) DeepCopy() *ControlPlaneComponentOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneComponentOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneContainerOverride) DeepCopyInto(out *ControlPlaneContainerOverride) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(ControlPlaneContainerResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GoRuntime != nil {
+		in, out := &in.GoRuntime, &out.GoRuntime
+		*out = new(GoRuntimeOverride)
+		**out = **in
+	}
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneContainerOverride.
+func (in *ControlPlaneContainerOverride) DeepCopy() *ControlPlaneContainerOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneContainerOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneContainerResources) DeepCopyInto(out *ControlPlaneContainerResources) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneContainerResources.
+func (in *ControlPlaneContainerResources) DeepCopy() *ControlPlaneContainerResources {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneContainerResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSSpec) DeepCopyInto(out *DNSSpec) {
 	*out = *in
@@ -929,6 +1185,21 @@ func (in *Filter) DeepCopy() *Filter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GoRuntimeOverride) DeepCopyInto(out *GoRuntimeOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoRuntimeOverride.
+func (in *GoRuntimeOverride) DeepCopy() *GoRuntimeOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(GoRuntimeOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostedCluster) DeepCopyInto(out *HostedCluster) {
 	*out = *in
@@ -956,6 +1227,174 @@ func (in *HostedCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterInfrastructure) DeepCopyInto(out *HostedClusterInfrastructure) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterInfrastructure.
+func (in *HostedClusterInfrastructure) DeepCopy() *HostedClusterInfrastructure {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterInfrastructure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostedClusterInfrastructure) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterInfrastructureDNS) DeepCopyInto(out *HostedClusterInfrastructureDNS) {
+	*out = *in
+	if in.BaseDomainPrefix != nil {
+		in, out := &in.BaseDomainPrefix, &out.BaseDomainPrefix
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterInfrastructureDNS.
+func (in *HostedClusterInfrastructureDNS) DeepCopy() *HostedClusterInfrastructureDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterInfrastructureDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterInfrastructureList) DeepCopyInto(out *HostedClusterInfrastructureList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HostedClusterInfrastructure, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterInfrastructureList.
+func (in *HostedClusterInfrastructureList) DeepCopy() *HostedClusterInfrastructureList {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterInfrastructureList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostedClusterInfrastructureList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterInfrastructurePlatform) DeepCopyInto(out *HostedClusterInfrastructurePlatform) {
+	*out = *in
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(AWSInfrastructureSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureInfrastructureSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PowerVS != nil {
+		in, out := &in.PowerVS, &out.PowerVS
+		*out = new(PowerVSInfrastructureSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterInfrastructurePlatform.
+func (in *HostedClusterInfrastructurePlatform) DeepCopy() *HostedClusterInfrastructurePlatform {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterInfrastructurePlatform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterInfrastructureSpec) DeepCopyInto(out *HostedClusterInfrastructureSpec) {
+	*out = *in
+	in.DNS.DeepCopyInto(&out.DNS)
+	in.Platform.DeepCopyInto(&out.Platform)
+	out.ResyncInterval = in.ResyncInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterInfrastructureSpec.
+func (in *HostedClusterInfrastructureSpec) DeepCopy() *HostedClusterInfrastructureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterInfrastructureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterInfrastructureStatus) DeepCopyInto(out *HostedClusterInfrastructureStatus) {
+	*out = *in
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(AWSInfrastructureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureInfrastructureStatus)
+		**out = **in
+	}
+	if in.PowerVS != nil {
+		in, out := &in.PowerVS, &out.PowerVS
+		*out = new(PowerVSInfrastructureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterInfrastructureStatus.
+func (in *HostedClusterInfrastructureStatus) DeepCopy() *HostedClusterInfrastructureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterInfrastructureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostedClusterList) DeepCopyInto(out *HostedClusterList) {
 	*out = *in
@@ -997,6 +1436,11 @@ func (in *HostedClusterSpec) DeepCopyInto(out *HostedClusterSpec) {
 		*out = new(Release)
 		**out = **in
 	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(UpgradePolicy)
+		**out = **in
+	}
 	in.Platform.DeepCopyInto(&out.Platform)
 	in.DNS.DeepCopyInto(&out.DNS)
 	in.Networking.DeepCopyInto(&out.Networking)
@@ -1062,6 +1506,13 @@ func (in *HostedClusterSpec) DeepCopyInto(out *HostedClusterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ControlPlaneComponentOverrides != nil {
+		in, out := &in.ControlPlaneComponentOverrides, &out.ControlPlaneComponentOverrides
+		*out = make([]ControlPlaneComponentOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterSpec.
@@ -1117,6 +1568,188 @@ func (in *HostedClusterStatus) DeepCopy() *HostedClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterUpgradePlan) DeepCopyInto(out *HostedClusterUpgradePlan) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterUpgradePlan.
+func (in *HostedClusterUpgradePlan) DeepCopy() *HostedClusterUpgradePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterUpgradePlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostedClusterUpgradePlan) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterUpgradePlanList) DeepCopyInto(out *HostedClusterUpgradePlanList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HostedClusterUpgradePlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterUpgradePlanList.
+func (in *HostedClusterUpgradePlanList) DeepCopy() *HostedClusterUpgradePlanList {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterUpgradePlanList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostedClusterUpgradePlanList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterUpgradePlanSpec) DeepCopyInto(out *HostedClusterUpgradePlanSpec) {
+	*out = *in
+	out.Release = in.Release
+	in.Selector.DeepCopyInto(&out.Selector)
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]HostedClusterUpgradeWave, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterUpgradePlanSpec.
+func (in *HostedClusterUpgradePlanSpec) DeepCopy() *HostedClusterUpgradePlanSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterUpgradePlanSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterUpgradePlanStatus) DeepCopyInto(out *HostedClusterUpgradePlanStatus) {
+	*out = *in
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]HostedClusterUpgradeWaveStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]HostedClusterUpgradeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterUpgradePlanStatus.
+func (in *HostedClusterUpgradePlanStatus) DeepCopy() *HostedClusterUpgradePlanStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterUpgradePlanStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterUpgradeStatus) DeepCopyInto(out *HostedClusterUpgradeStatus) {
+	*out = *in
+	if in.StartedTime != nil {
+		in, out := &in.StartedTime, &out.StartedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterUpgradeStatus.
+func (in *HostedClusterUpgradeStatus) DeepCopy() *HostedClusterUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterUpgradeWave) DeepCopyInto(out *HostedClusterUpgradeWave) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.SoakDuration = in.SoakDuration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterUpgradeWave.
+func (in *HostedClusterUpgradeWave) DeepCopy() *HostedClusterUpgradeWave {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterUpgradeWave)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostedClusterUpgradeWaveStatus) DeepCopyInto(out *HostedClusterUpgradeWaveStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterUpgradeWaveStatus.
+func (in *HostedClusterUpgradeWaveStatus) DeepCopy() *HostedClusterUpgradeWaveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostedClusterUpgradeWaveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostedControlPlane) DeepCopyInto(out *HostedControlPlane) {
 	*out = *in
@@ -1254,6 +1887,13 @@ func (in *HostedControlPlaneSpec) DeepCopyInto(out *HostedControlPlaneSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ControlPlaneComponentOverrides != nil {
+		in, out := &in.ControlPlaneComponentOverrides, &out.ControlPlaneComponentOverrides
+		*out = make([]ControlPlaneComponentOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedControlPlaneSpec.
@@ -2363,6 +3003,60 @@ func (in *PlatformStatus) DeepCopy() *PlatformStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PowerVSInfrastructureCredentials) DeepCopyInto(out *PowerVSInfrastructureCredentials) {
+	*out = *in
+	out.KubeCloudControllerCreds = in.KubeCloudControllerCreds
+	out.NodePoolManagementCreds = in.NodePoolManagementCreds
+	out.IngressOperatorCloudCreds = in.IngressOperatorCloudCreds
+	out.StorageOperatorCloudCreds = in.StorageOperatorCloudCreds
+	out.ImageRegistryOperatorCloudCreds = in.ImageRegistryOperatorCloudCreds
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerVSInfrastructureCredentials.
+func (in *PowerVSInfrastructureCredentials) DeepCopy() *PowerVSInfrastructureCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(PowerVSInfrastructureCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PowerVSInfrastructureSpec) DeepCopyInto(out *PowerVSInfrastructureSpec) {
+	*out = *in
+	out.CredentialsSecret = in.CredentialsSecret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerVSInfrastructureSpec.
+func (in *PowerVSInfrastructureSpec) DeepCopy() *PowerVSInfrastructureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PowerVSInfrastructureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PowerVSInfrastructureStatus) DeepCopyInto(out *PowerVSInfrastructureStatus) {
+	*out = *in
+	in.Subnet.DeepCopyInto(&out.Subnet)
+	out.VPC = in.VPC
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerVSInfrastructureStatus.
+func (in *PowerVSInfrastructureStatus) DeepCopy() *PowerVSInfrastructureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PowerVSInfrastructureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PowerVSNodePoolPlatform) DeepCopyInto(out *PowerVSNodePoolPlatform) {
 	*out = *in
@@ -2647,6 +3341,21 @@ func (in *UnmanagedEtcdSpec) DeepCopy() *UnmanagedEtcdSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePolicy) DeepCopyInto(out *UpgradePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePolicy.
+func (in *UpgradePolicy) DeepCopy() *UpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Volume) DeepCopyInto(out *Volume) {
 	*out = *in
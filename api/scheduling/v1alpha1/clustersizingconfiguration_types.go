@@ -62,6 +62,93 @@ type ClusterSizingConfigurationSpec struct {
 	// NonRequestServingNodesBufferPerZone is the number of extra nodes to allocate for non request serving
 	// workloads per zone.
 	NonRequestServingNodesBufferPerZone *resource.Quantity `json:"nonRequestServingNodesBufferPerZone,omitempty"`
+
+	// +kubebuilder:validation:Optional
+
+	// UsageBasedSizing, when set, sizes the resource requests of control plane components from their observed
+	// usage, in addition to the t-shirt size of the cluster. Recommendations are applied once the cluster has
+	// settled in its t-shirt size and are subject to the same transition delays and concurrency limits.
+	UsageBasedSizing *UsageBasedSizingConfiguration `json:"usageBasedSizing,omitempty"`
+}
+
+// UsageSource is a source of usage metrics for control plane components.
+// +kubebuilder:validation:Enum=MetricsAPI;Prometheus
+type UsageSource string
+
+const (
+	// MetricsAPIUsageSource reads the current usage from the metrics.k8s.io API of the management cluster.
+	MetricsAPIUsageSource UsageSource = "MetricsAPI"
+	// PrometheusUsageSource reads the peak usage from a Prometheus compatible query endpoint.
+	PrometheusUsageSource UsageSource = "Prometheus"
+)
+
+// UsageBasedSizingConfiguration configures the sizing of control plane components from their observed usage.
+// +kubebuilder:validation:XValidation:rule="self.source != 'Prometheus' || has(self.prometheus)", message="prometheus is required when the source is Prometheus"
+type UsageBasedSizingConfiguration struct {
+	// +kubebuilder:validation:Required
+
+	// Source is where the usage of control plane components is read from.
+	Source UsageSource `json:"source"`
+
+	// +kubebuilder:validation:Optional
+
+	// Prometheus configures the Prometheus endpoint used when the source is Prometheus.
+	Prometheus *PrometheusUsageSourceConfiguration `json:"prometheus,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=500
+	// +kubebuilder:default=20
+
+	// HeadroomPercent is added on top of the observed usage to compute the recommended requests.
+	HeadroomPercent int32 `json:"headroomPercent,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=10
+
+	// HysteresisPercent is how much a recommendation must differ from the requests currently applied,
+	// relative to them, before the requests are updated. This prevents churn from small usage changes.
+	HysteresisPercent int32 `json:"hysteresisPercent,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	// +kubebuilder:default={{deploymentName:"kube-apiserver",containerName:"kube-apiserver"},{deploymentName:"etcd",containerName:"etcd"},{deploymentName:"openshift-apiserver",containerName:"openshift-apiserver"}}
+
+	// Components are the containers whose requests are sized from usage. When unset, the kube-apiserver,
+	// etcd and openshift-apiserver containers are sized.
+	Components []UsageBasedSizingComponent `json:"components,omitempty"`
+}
+
+// PrometheusUsageSourceConfiguration configures a Prometheus compatible query endpoint.
+type PrometheusUsageSourceConfiguration struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://.*$`
+
+	// URL is the base URL of the Prometheus HTTP API, e.g. http://prometheus-operated.monitoring.svc:9090.
+	URL string `json:"url"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(s|m|h))+$`
+	// +kubebuilder:default=`1h`
+
+	// Window is the period over which the peak usage is taken.
+	Window metav1.Duration `json:"window,omitempty"`
+}
+
+// UsageBasedSizingComponent identifies a container of a control plane component sized from usage.
+type UsageBasedSizingComponent struct {
+	// +kubebuilder:validation:Required
+
+	// DeploymentName is the name of the deployment or statefulset of the component.
+	DeploymentName string `json:"deploymentName"`
+
+	// +kubebuilder:validation:Required
+
+	// ContainerName is the name of the container sized from usage.
+	ContainerName string `json:"containerName"`
 }
 
 // SizeConfiguration holds options for clusters of a given size.
@@ -101,6 +101,11 @@ func (in *ClusterSizingConfigurationSpec) DeepCopyInto(out *ClusterSizingConfigu
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.UsageBasedSizing != nil {
+		in, out := &in.UsageBasedSizing, &out.UsageBasedSizing
+		*out = new(UsageBasedSizingConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSizingConfigurationSpec.
@@ -238,6 +243,22 @@ func (in *NodeCountCriteria) DeepCopy() *NodeCountCriteria {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusUsageSourceConfiguration) DeepCopyInto(out *PrometheusUsageSourceConfiguration) {
+	*out = *in
+	out.Window = in.Window
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusUsageSourceConfiguration.
+func (in *PrometheusUsageSourceConfiguration) DeepCopy() *PrometheusUsageSourceConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusUsageSourceConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequest) DeepCopyInto(out *ResourceRequest) {
 	*out = *in
@@ -305,3 +326,43 @@ func (in *TransitionDelayConfiguration) DeepCopy() *TransitionDelayConfiguration
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageBasedSizingComponent) DeepCopyInto(out *UsageBasedSizingComponent) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageBasedSizingComponent.
+func (in *UsageBasedSizingComponent) DeepCopy() *UsageBasedSizingComponent {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageBasedSizingComponent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageBasedSizingConfiguration) DeepCopyInto(out *UsageBasedSizingConfiguration) {
+	*out = *in
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		*out = new(PrometheusUsageSourceConfiguration)
+		**out = **in
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]UsageBasedSizingComponent, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageBasedSizingConfiguration.
+func (in *UsageBasedSizingConfiguration) DeepCopy() *UsageBasedSizingConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageBasedSizingConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
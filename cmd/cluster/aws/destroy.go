@@ -8,10 +8,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/openshift/hypershift/cmd/cluster/core"
-	awsinfra "github.com/openshift/hypershift/cmd/infra/aws"
 	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
 	"github.com/openshift/hypershift/cmd/log"
 	"github.com/openshift/hypershift/cmd/util"
+	awsinfra "github.com/openshift/hypershift/support/infra/aws"
 )
 
 func NewDestroyCommand(opts *core.DestroyOptions) *cobra.Command {
@@ -9,8 +9,8 @@ import (
 
 	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
 	"github.com/openshift/hypershift/cmd/cluster/core"
-	azureinfra "github.com/openshift/hypershift/cmd/infra/azure"
 	"github.com/openshift/hypershift/cmd/util"
+	azureinfra "github.com/openshift/hypershift/support/infra/azure"
 	"github.com/openshift/hypershift/support/releaseinfo"
 	"github.com/spf13/pflag"
 	corev1 "k8s.io/api/core/v1"
@@ -8,9 +8,9 @@ import (
 	"testing"
 
 	"github.com/openshift/hypershift/cmd/cluster/core"
-	azureinfra "github.com/openshift/hypershift/cmd/infra/azure"
 	"github.com/openshift/hypershift/cmd/util"
 	"github.com/openshift/hypershift/support/certs"
+	azureinfra "github.com/openshift/hypershift/support/infra/azure"
 	"github.com/openshift/hypershift/support/testutil"
 	"github.com/openshift/hypershift/test/integration/framework"
 	"github.com/spf13/pflag"
@@ -8,9 +8,9 @@ import (
 	"syscall"
 
 	"github.com/openshift/hypershift/cmd/cluster/core"
-	azureinfra "github.com/openshift/hypershift/cmd/infra/azure"
 	"github.com/openshift/hypershift/cmd/log"
 	"github.com/openshift/hypershift/cmd/util"
+	azureinfra "github.com/openshift/hypershift/support/infra/azure"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/spf13/cobra"
@@ -9,9 +9,12 @@ import (
 	"github.com/openshift/hypershift/cmd/cluster/aws"
 	"github.com/openshift/hypershift/cmd/cluster/azure"
 	"github.com/openshift/hypershift/cmd/cluster/core"
+	"github.com/openshift/hypershift/cmd/cluster/diagnose"
 	"github.com/openshift/hypershift/cmd/cluster/kubevirt"
 	"github.com/openshift/hypershift/cmd/cluster/none"
+	"github.com/openshift/hypershift/cmd/cluster/plan"
 	"github.com/openshift/hypershift/cmd/cluster/powervs"
+	"github.com/openshift/hypershift/cmd/cluster/status"
 	"github.com/openshift/hypershift/cmd/log"
 )
 
@@ -24,6 +27,7 @@ func NewCreateCommands() *cobra.Command {
 	}
 
 	core.BindDeveloperOptions(opts, cmd.PersistentFlags())
+	core.BindFromFileOption(cmd)
 
 	cmd.MarkFlagsMutuallyExclusive("service-cidr", "default-dual")
 	cmd.MarkFlagsMutuallyExclusive("cluster-cidr", "default-dual")
@@ -70,3 +74,18 @@ func NewDestroyCommands() *cobra.Command {
 
 	return cmd
 }
+
+// NewCommand returns the day-2 commands operating on existing HostedClusters.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "cluster",
+		Short:        "Inspect and operate existing HyperShift HostedClusters",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(plan.NewCommand())
+	cmd.AddCommand(diagnose.NewCommand())
+	cmd.AddCommand(status.NewCommand())
+
+	return cmd
+}
@@ -3,13 +3,12 @@ package core
 import (
 	"bytes"
 	"context"
+	coreerrors "errors"
 	"fmt"
 	"math/rand"
 	"os"
 	"os/exec"
 	"path"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -30,8 +29,9 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	kubeclient "k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
@@ -45,7 +45,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/consolelogs"
 	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/tunnel"
 	"github.com/openshift/hypershift/cmd/util"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/sharedingress"
@@ -102,7 +104,29 @@ type DumpOptions struct {
 	DumpGuestCluster bool
 	ImpersonateAs    string
 
+	// Namespaces are the namespaces of the management cluster to dump. It defaults to the namespaces
+	// of the hosted cluster, its control plane and the HyperShift operator.
+	Namespaces []string
+	// Components are the control plane components whose resources and logs are dumped. Objects that
+	// don't belong to a component are always dumped. All components are dumped if empty.
+	Components []string
+	// ExcludeComponents are the control plane components whose resources and logs are not dumped.
+	ExcludeComponents []string
+	// LogsSince limits the logs to the ones more recent than the duration, if set.
+	LogsSince time.Duration
+	// MaxFileSize is the maximum size in bytes of a dumped file, 0 for no limit. Logs keep their
+	// most recent lines and other files their beginning.
+	MaxFileSize int64
+	// DisableRedaction disables the redaction of certificates, keys, pull secrets and tokens in the
+	// dumped files. The values of secrets are always redacted.
+	DisableRedaction bool
+	// SkipConsoleLogs skips the console logs of the Machines which never became a Node.
+	SkipConsoleLogs bool
+
 	Log logr.Logger
+
+	// manifest indexes the files of the dump.
+	manifest *DumpManifest
 }
 
 func NewDumpCommand() *cobra.Command {
@@ -118,6 +142,7 @@ func NewDumpCommand() *cobra.Command {
 		ArtifactDir:    "",
 		ArchiveDump:    true,
 		AgentNamespace: "",
+		MaxFileSize:    100 * 1024 * 1024,
 		Log:            log.Log,
 	}
 
@@ -128,6 +153,13 @@ func NewDumpCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&opts.ArchiveDump, "archive-dump", opts.ArchiveDump, "Create a tar archive of the artifact directory")
 	cmd.Flags().StringVar(&opts.AgentNamespace, "agent-namespace", opts.AgentNamespace, "For agent platform, the namespace where the agents are located")
 	cmd.Flags().BoolVar(&opts.DumpGuestCluster, "dump-guest-cluster", opts.DumpGuestCluster, "If the guest cluster contents should also be dumped")
+	cmd.Flags().StringSliceVar(&opts.Namespaces, "namespaces", opts.Namespaces, "Namespaces of the management cluster to dump. Defaults to the namespaces of the hostedcluster, its control plane and the HyperShift operator")
+	cmd.Flags().StringSliceVar(&opts.Components, "components", opts.Components, "Control plane components whose resources and logs are dumped, e.g. kube-apiserver,etcd. Resources that don't belong to a component are always dumped. Defaults to all components")
+	cmd.Flags().StringSliceVar(&opts.ExcludeComponents, "exclude-components", opts.ExcludeComponents, "Control plane components whose resources and logs are not dumped")
+	cmd.Flags().DurationVar(&opts.LogsSince, "since", opts.LogsSince, "Only dump logs newer than a relative duration like 5s, 2m, or 3h. Defaults to all logs")
+	cmd.Flags().Int64Var(&opts.MaxFileSize, "max-file-size", opts.MaxFileSize, "Maximum size in bytes of a dumped file, 0 for no limit. Logs keep their most recent lines")
+	cmd.Flags().BoolVar(&opts.DisableRedaction, "disable-redaction", opts.DisableRedaction, "Do not redact certificates, keys, pull secrets and tokens in the dumped files. The values of secrets are always redacted")
+	cmd.Flags().BoolVar(&opts.SkipConsoleLogs, "skip-console-logs", opts.SkipConsoleLogs, "Do not dump the console logs of the machines which never became a node. They are read with the platform credentials of the hostedcluster")
 
 	cmd.MarkFlagRequired("artifact-dir")
 
@@ -205,7 +237,7 @@ func dumpGuestCluster(ctx context.Context, opts *DumpOptions) error {
 		return fmt.Errorf("failed to get a kubernetes client: %w", err)
 	}
 	forwarderOutput := &bytes.Buffer{}
-	forwarder := portForwarder{
+	forwarder := util.PortForwarder{
 		Namespace: podToForward.Namespace,
 		PodName:   podToForward.Name,
 		Config:    restConfig,
@@ -221,7 +253,7 @@ func dumpGuestCluster(ctx context.Context, opts *DumpOptions) error {
 	defer close(forwarderStop)
 
 	opts.Log.Info("Dumping guestcluster", "target", target)
-	if err := DumpGuestCluster(ctx, opts.Log, kubeconfigFileName, target); err != nil {
+	if err := DumpGuestCluster(ctx, opts, kubeconfigFileName, target); err != nil {
 		return fmt.Errorf("failed to dump guest cluster: %w", err)
 	}
 	opts.Log.Info("Successfully dumped guest cluster", "duration", time.Since(start).String())
@@ -230,15 +262,9 @@ func dumpGuestCluster(ctx context.Context, opts *DumpOptions) error {
 }
 
 func createGuestKubeconfig(ctx context.Context, c client.Client, cpNamespace string, localPort int, log logr.Logger) (string, error) {
-
-	localhostKubeconfigSecret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "localhost-kubeconfig",
-			Namespace: cpNamespace,
-		},
-	}
-	if err := c.Get(ctx, client.ObjectKeyFromObject(localhostKubeconfigSecret), localhostKubeconfigSecret); err != nil {
-		return "", fmt.Errorf("failed to get hostedcluster localhost kubeconfig: %w", err)
+	localhostKubeconfig, err := tunnel.LocalKubeconfig(ctx, c, cpNamespace, localPort)
+	if err != nil {
+		return "", err
 	}
 	kubeconfigFile, err := os.CreateTemp(os.TempDir(), "kubeconfig-")
 	if err != nil {
@@ -252,22 +278,7 @@ func createGuestKubeconfig(ctx context.Context, c client.Client, cpNamespace str
 			log.Error(err, "Failed to close temporary kubeconfig file")
 		}
 	}()
-	localhostKubeconfig, err := clientcmd.Load(localhostKubeconfigSecret.Data["kubeconfig"])
-	if err != nil {
-		return "", fmt.Errorf("failed to parse localhost kubeconfig: %w", err)
-	}
-	if len(localhostKubeconfig.Clusters) == 0 {
-		return "", fmt.Errorf("no clusters found in localhost kubeconfig")
-	}
-
-	for k := range localhostKubeconfig.Clusters {
-		localhostKubeconfig.Clusters[k].Server = fmt.Sprintf("https://localhost:%d", localPort)
-	}
-	localhostKubeconfigYaml, err := clientcmd.Write(*localhostKubeconfig)
-	if err != nil {
-		return "", fmt.Errorf("failed to serialize localhost kubeconfig: %w", err)
-	}
-	if _, err := kubeconfigFile.Write(localhostKubeconfigYaml); err != nil {
+	if _, err := kubeconfigFile.Write(localhostKubeconfig); err != nil {
 		return "", fmt.Errorf("failed to write kubeconfig data: %w", err)
 	}
 	return kubeconfigFile.Name(), nil
@@ -277,10 +288,6 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 	var c client.Client
 	var err error
 
-	ocCommand, err := exec.LookPath("oc")
-	if err != nil || len(ocCommand) == 0 {
-		return fmt.Errorf("cannot find oc command")
-	}
 	cfg, err := util.GetConfig()
 	if err != nil {
 		return err
@@ -301,6 +308,21 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 			return err
 		}
 	}
+	kubeClient, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get a kubernetes client: %w", err)
+	}
+	opts.manifest = newDumpManifest(opts)
+	d := &dumper{
+		client:     c,
+		kubeClient: kubeClient,
+		restConfig: cfg,
+		opts:       opts,
+		manifest:   opts.manifest,
+		cluster:    managementCluster,
+		dir:        opts.ArtifactDir,
+	}
+
 	allNodePools := &hyperv1.NodePoolList{}
 	if err = c.List(ctx, allNodePools, client.InNamespace(opts.Namespace)); err != nil {
 		opts.Log.Error(err, "Cannot list nodepools")
@@ -311,31 +333,22 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 			nodePools = append(nodePools, &allNodePools.Items[i])
 		}
 	}
-	cmd := OCAdmInspect{
-		oc:             ocCommand,
-		artifactDir:    opts.ArtifactDir,
-		agentNamespace: opts.AgentNamespace,
-		log:            opts.Log,
-	}
-
-	if len(opts.ImpersonateAs) > 0 {
-		cmd.impersonate = opts.ImpersonateAs
-	}
-
-	objectNames := make([]string, 0, len(nodePools)+1)
-	objectNames = append(objectNames, typedName(&hyperv1.HostedCluster{}, opts.Name))
-	for _, nodePool := range nodePools {
-		objectNames = append(objectNames, typedName(&hyperv1.NodePool{}, nodePool.Name))
-	}
-	cmd.WithNamespace(opts.Namespace).Run(ctx, objectNames...)
 
-	cmd.Run(ctx, objectType(&corev1.Node{}))
+	d.dumpResources(ctx, opts.Namespace, gvksFor([]client.Object{&hyperv1.HostedCluster{}}), func(obj *unstructured.Unstructured) bool {
+		return obj.GetName() == opts.Name
+	})
+	d.dumpResources(ctx, opts.Namespace, gvksFor([]client.Object{&hyperv1.NodePool{}}), func(obj *unstructured.Unstructured) bool {
+		clusterName, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterName")
+		return clusterName == opts.Name
+	})
+	d.dumpResources(ctx, "", gvksFor([]client.Object{&corev1.Node{}}), nil)
 
 	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(opts.Namespace, opts.Name)
 
 	kubevirtExternalInfraClusters, localKubevirtInUse := shouldDumpKubevirt(nodePools)
 
 	resources := append(coreResources,
+		&corev1.Secret{},
 		&capiv1.Cluster{},
 		&capiv1.MachineDeployment{},
 		&capiv1.Machine{},
@@ -360,7 +373,6 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 
 	// The management cluster may not be an OpenShift cluster.
 	// Only dump registered OpenShift GVKs to avoid errors.
-	kubeClient := kubeclient.NewForConfigOrDie(cfg)
 	kubeDiscoveryClient := kubeClient.Discovery()
 	for _, resource := range ocpResources {
 		gvk, err := c.GroupVersionKindFor(resource)
@@ -380,35 +392,47 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 		resources = append(resources, kubevirtResources...)
 	}
 
-	resourceList := strings.Join(resourceTypes(resources), ",")
+	resourceGVKs := gvksFor(resources)
 	if opts.AgentNamespace != "" {
 		// Additional Agent platform resources
-		resourceList += ",clusterdeployment.hive.openshift.io,agentclusterinstall.extensions.hive.openshift.io"
-	}
-
-	namespaces := []string{controlPlaneNamespace, opts.Namespace, hypershiftNamespace}
-	if localKubevirtInUse {
-		namespaces = append(namespaces, kubevirtNamespace)
-	}
-	if sharedingress.UseSharedIngress() {
-		namespaces = append(namespaces, sharedingress.RouterNamespace)
+		resourceGVKs = append(resourceGVKs,
+			schema.GroupVersionKind{Group: "hive.openshift.io", Version: "v1", Kind: "ClusterDeployment"},
+			schema.GroupVersionKind{Group: "extensions.hive.openshift.io", Version: "v1beta1", Kind: "AgentClusterInstall"},
+		)
+	}
+
+	namespaces, logNamespaces := opts.Namespaces, opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{controlPlaneNamespace, opts.Namespace, hypershiftNamespace}
+		logNamespaces = []string{controlPlaneNamespace, hypershiftNamespace}
+		if localKubevirtInUse {
+			namespaces = append(namespaces, kubevirtNamespace)
+		}
+		if sharedingress.UseSharedIngress() {
+			namespaces = append(namespaces, sharedingress.RouterNamespace)
+			logNamespaces = append(logNamespaces, sharedingress.RouterNamespace)
+		}
 	}
 
 	for _, ns := range namespaces {
-		cmd.WithNamespace(ns).Run(ctx, resourceList)
+		d.dumpResources(ctx, ns, resourceGVKs, nil)
+	}
+	for _, ns := range logNamespaces {
+		d.dumpLogs(ctx, ns)
 	}
 
-	outputLogs(ctx, opts.Log, kubeClient, opts.ArtifactDir, controlPlaneNamespace, opts)
-	outputLogs(ctx, opts.Log, kubeClient, opts.ArtifactDir, hypershiftNamespace, opts)
-	if sharedingress.UseSharedIngress() {
-		outputLogs(ctx, opts.Log, kubeClient, opts.ArtifactDir, sharedingress.RouterNamespace, opts)
+	if !opts.SkipConsoleLogs {
+		dumpConsoleLogs(ctx, d, cfg, controlPlaneNamespace)
 	}
 
 	if opts.AgentNamespace != "" {
-		cmd.WithNamespace(opts.AgentNamespace).Run(ctx, "agent.agent-install.openshift.io,infraenv.agent-install.openshift.io")
+		d.dumpResources(ctx, opts.AgentNamespace, []schema.GroupVersionKind{
+			{Group: "agent-install.openshift.io", Version: "v1beta1", Kind: "Agent"},
+			{Group: "agent-install.openshift.io", Version: "v1beta1", Kind: "InfraEnv"},
+		}, nil)
 	}
 
-	gatherNetworkLogs(ocCommand, controlPlaneNamespace, opts.ArtifactDir, ctx, c, opts.Log)
+	d.dumpNetworkLogs(ctx, controlPlaneNamespace)
 
 	if opts.DumpGuestCluster {
 		if err = dumpGuestCluster(ctx, opts); err != nil {
@@ -425,6 +449,10 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 		}
 	}
 
+	if err := writeManifest(opts, opts.manifest); err != nil {
+		return err
+	}
+
 	if opts.ArchiveDump {
 		if err = CreateArchive(ctx, opts); err != nil {
 			return err
@@ -434,20 +462,38 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 	return nil
 }
 
+// dumpConsoleLogs dumps the console logs of the Machines which never became a Node with the console log
+// provider of the platform of the hosted cluster.
+func dumpConsoleLogs(ctx context.Context, d *dumper, cfg *restclient.Config, controlPlaneNamespace string) {
+	hostedCluster := &hyperv1.HostedCluster{}
+	if err := d.client.Get(ctx, types.NamespacedName{Namespace: d.opts.Namespace, Name: d.opts.Name}, hostedCluster); err != nil {
+		d.recordError(fmt.Errorf("failed to get hostedcluster to dump console logs: %w", err))
+		return
+	}
+	provider, err := consolelogs.NewProvider(ctx, d.client, cfg, hostedCluster)
+	if err != nil {
+		if coreerrors.Is(err, consolelogs.ErrPlatformNotSupported) {
+			d.opts.Log.Info("Skipping console logs", "reason", err.Error())
+			return
+		}
+		d.recordError(fmt.Errorf("failed to create console log provider: %w", err))
+		return
+	}
+	d.dumpConsoleLogs(ctx, provider, controlPlaneNamespace)
+}
+
 // DumpGuestCluster dumps resources from a hosted cluster using its apiserver
 // indicated by the provided kubeconfig. This function assumes that pods aren't
 // able to be scheduled and so can only gather information directly accessible
 // through the api server.
-func DumpGuestCluster(ctx context.Context, log logr.Logger, kubeconfig string, destDir string) error {
-	ocCommand, err := exec.LookPath("oc")
-	if err != nil || len(ocCommand) == 0 {
-		return fmt.Errorf("cannot find oc command")
+func DumpGuestCluster(ctx context.Context, opts *DumpOptions, kubeconfig string, destDir string) error {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to load guest cluster kubeconfig: %w", err)
 	}
-	cmd := OCAdmInspect{
-		oc:          ocCommand,
-		artifactDir: destDir,
-		kubeconfig:  kubeconfig,
-		log:         log,
+	d, err := newClusterDumper(cfg, opts, guestCluster, destDir)
+	if err != nil {
+		return err
 	}
 
 	resources := append(coreResources,
@@ -457,6 +503,7 @@ func DumpGuestCluster(ctx context.Context, log logr.Logger, kubeconfig string, d
 		&corev1.Namespace{},
 		&corev1.Node{},
 		&corev1.PersistentVolume{},
+		&corev1.Secret{},
 		&rbacv1.ClusterRole{},
 		&rbacv1.ClusterRoleBinding{},
 		&rbacv1.Role{},
@@ -472,210 +519,37 @@ func DumpGuestCluster(ctx context.Context, log logr.Logger, kubeconfig string, d
 		&snapshotv1.VolumeSnapshotContent{},
 	)
 
-	resourceList := strings.Join(resourceTypes(resources), ",")
-	cmd.Run(ctx, resourceList)
-	dumpWorkerNodeLogsCmd := OCAdmNodeLogs{
-		oc:          ocCommand,
-		artifactDir: destDir,
-		kubeconfig:  kubeconfig,
-		role:        "worker",
-		log:         log,
-	}
-	dumpWorkerNodeLogsCmd.Run(ctx)
+	d.dumpResources(ctx, "", gvksFor(resources), nil)
+	d.dumpLogs(ctx, "")
+	d.dumpNodeLogs(ctx, "worker")
 	return nil
 }
 
-type OCAdmInspect struct {
-	oc             string
-	artifactDir    string
-	namespace      string
-	kubeconfig     string
-	agentNamespace string
-	impersonate    string
-	log            logr.Logger
-}
-
-func (i *OCAdmInspect) WithNamespace(namespace string) *OCAdmInspect {
-	withNS := *i
-	withNS.namespace = namespace
-	return &withNS
-}
-
-func (i *OCAdmInspect) Run(ctx context.Context, cmdArgs ...string) {
-	allArgs := []string{"adm", "inspect", "--dest-dir", i.artifactDir}
-	if len(i.namespace) > 0 {
-		allArgs = append(allArgs, "-n", i.namespace)
-	}
-	if len(i.kubeconfig) > 0 {
-		allArgs = append(allArgs, "--kubeconfig", i.kubeconfig)
-	}
-	if len(i.impersonate) > 0 {
-		allArgs = append(allArgs, "--as", i.impersonate)
-	}
-	allArgs = append(allArgs, cmdArgs...)
-	cmd := exec.CommandContext(ctx, i.oc, allArgs...)
-	// oc adm inspect command always returns an error so ignore
-	cmd.CombinedOutput()
-}
-
-type OCAdmNodeLogs struct {
-	oc          string
-	artifactDir string
-	kubeconfig  string
-	log         logr.Logger
-	role        string
-}
-
-func (i *OCAdmNodeLogs) Run(ctx context.Context, cmdArgs ...string) {
-	allArgs := []string{"adm", "node-logs"}
-	if len(i.kubeconfig) > 0 {
-		allArgs = append(allArgs, "--kubeconfig", i.kubeconfig)
-	}
-	nodeLogsFileName := "nodes.log"
-	if len(i.role) > 0 {
-		allArgs = append(allArgs, "--role", i.role)
-		nodeLogsFileName = fmt.Sprintf("%s.%s", i.role, nodeLogsFileName)
-	}
-	allArgs = append(allArgs, cmdArgs...)
-	nodeLogsFile, err := os.Create(filepath.Join(i.artifactDir, nodeLogsFileName))
+// newClusterDumper returns a dumper of the cluster of the config into destDir.
+func newClusterDumper(cfg *restclient.Config, opts *DumpOptions, cluster, destDir string) (*dumper, error) {
+	c, err := client.New(cfg, client.Options{Scheme: hyperapi.Scheme})
 	if err != nil {
-		i.log.Info(fmt.Sprintf("failed creating file to dump node-logs: %v", err))
-		return
-	}
-	defer nodeLogsFile.Close()
-	cmd := exec.CommandContext(ctx, i.oc, allArgs...)
-	var errb bytes.Buffer
-	cmd.Stdout = nodeLogsFile
-	cmd.Stderr = &errb
-	if err := cmd.Run(); err != nil {
-		i.log.Info(fmt.Sprintf("failed running command oc %s: %v, %s", strings.Join(allArgs, " "), err, errb.String()))
-	}
-}
-
-func objectType(obj client.Object) string {
-	var kind, group string
-	gvks, _, err := hyperapi.Scheme.ObjectKinds(obj)
-	if err != nil || len(gvks) == 0 {
-		kind = "unknown"
-		group = "unknown"
-	} else {
-		kind = strings.ToLower(gvks[0].Kind)
-		group = gvks[0].Group
+		return nil, fmt.Errorf("failed to create %s cluster client: %w", cluster, err)
 	}
-	if len(group) > 0 {
-		return fmt.Sprintf("%s.%s", kind, group)
-	} else {
-		return kind
-	}
-}
-
-func resourceTypes(objs []client.Object) []string {
-	result := make([]string, 0, len(objs))
-	for _, obj := range objs {
-		result = append(result, objectType(obj))
-	}
-	return result
-}
-
-func typedName(obj client.Object, name string) string {
-	return fmt.Sprintf("%s/%s", objectType(obj), name)
-}
-
-type LogChecker func(filename string, content []byte)
-
-func outputLogs(ctx context.Context, l logr.Logger, c kubeclient.Interface, artifactDir string, namespace string, opts *DumpOptions) {
-	podList, err := c.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	kubeClient, err := kubeclient.NewForConfig(cfg)
 	if err != nil {
-		opts.Log.Error(err, fmt.Sprintf("Cannot list pods in %s namespace", namespace))
-		return
-	}
-	if len(podList.Items) == 0 {
-		opts.Log.Info(fmt.Sprintf("No pods in %s namespace", namespace))
-		return
-	}
-
-	dir := filepath.Join(artifactDir, "namespaces", namespace, "core", "pods", "logs")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		l.Error(err, "Cannot create directory", "directory", dir)
-		return
-	}
-
-	opts.Log.Info("dumping container logs", "namespace", namespace)
-	for _, pod := range podList.Items {
-		for _, container := range pod.Spec.InitContainers {
-			outputLog(ctx, l, filepath.Join(dir, fmt.Sprintf("%s-%s.log", pod.Name, container.Name)),
-				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name}), false, opts.LogCheckers...)
-			outputLog(ctx, l, filepath.Join(dir, fmt.Sprintf("%s-%s-previous.log", pod.Name, container.Name)),
-				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, Previous: true}), true, opts.LogCheckers...)
-		}
-		for _, container := range pod.Spec.Containers {
-			outputLog(ctx, l, filepath.Join(dir, fmt.Sprintf("%s-%s.log", pod.Name, container.Name)),
-				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name}), false, opts.LogCheckers...)
-			outputLog(ctx, l, filepath.Join(dir, fmt.Sprintf("%s-%s-previous.log", pod.Name, container.Name)),
-				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, Previous: true}), true, opts.LogCheckers...)
-		}
-	}
-}
-
-func outputLog(ctx context.Context, l logr.Logger, fileName string, req *restclient.Request, skipLogErr bool, checker ...LogChecker) {
-	b, err := req.DoRaw(ctx)
-	if err != nil {
-		if !skipLogErr {
-			l.Info("Failed to get pod log", "req", req.URL().String(), "error", err.Error())
-		}
-		return
-	}
-	for _, c := range checker {
-		c(fileName, b)
-	}
-	if err := os.WriteFile(fileName, b, 0644); err != nil {
-		l.Error(err, "Failed to write file", "file", fileName)
-	}
+		return nil, fmt.Errorf("failed to create %s cluster kubernetes client: %w", cluster, err)
+	}
+	if opts.manifest == nil {
+		opts.manifest = newDumpManifest(opts)
+	}
+	return &dumper{
+		client:     c,
+		kubeClient: kubeClient,
+		restConfig: cfg,
+		opts:       opts,
+		manifest:   opts.manifest,
+		cluster:    cluster,
+		dir:        destDir,
+	}, nil
 }
 
-func gatherNetworkLogs(ocCommand, controlPlaneNamespace, artifactDir string, ctx context.Context, c client.Client, l logr.Logger) {
-	// copy ovn dbs and save db cluster status for all ovnkube-master pods
-	dir := filepath.Join(artifactDir, "network_logs")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		l.Error(err, "Cannot create directory", "directory", dir)
-		return
-	}
-	podList := &corev1.PodList{}
-	if err := c.List(ctx, podList, &client.ListOptions{
-		Namespace:     controlPlaneNamespace,
-		LabelSelector: labels.SelectorFromValidatedSet(labels.Set{"app": "ovnkube-master"}),
-	}); err != nil {
-		l.Error(err, "Cannot list ovnkube pods in controlplane namespace", "namespace", controlPlaneNamespace)
-	}
-	for _, pod := range podList.Items {
-		for _, dbType := range []string{"n", "s"} {
-			allArgs := []string{"cp", fmt.Sprintf("%s/%s:/etc/ovn/ovn%sb_db.db", controlPlaneNamespace, pod.Name, dbType),
-				"-c", fmt.Sprintf("%sbdb", dbType), filepath.Join(dir, fmt.Sprintf("%s_ovn%sb_db.db", pod.Name, dbType))}
-			cmd := exec.CommandContext(ctx, ocCommand, allArgs...)
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				l.Info("Copy ovn dbs command returned an error", "args", allArgs, "error", err.Error(), "output", string(out))
-			}
-			var dbName string
-			if dbType == "n" {
-				dbName = "OVN_Northbound"
-			} else {
-				dbName = "OVN_Southbound"
-			}
-			allArgs = []string{"exec", "-n", controlPlaneNamespace, pod.Name, "-c", fmt.Sprintf("%sbdb", dbType),
-				"--", "bash", "-c", fmt.Sprintf("ovn-appctl -t /var/run/ovn/ovn%sb_db.ctl cluster/status %s", dbType, dbName)}
-			cmd = exec.CommandContext(ctx, ocCommand, allArgs...)
-			out, err = cmd.CombinedOutput()
-			if err != nil {
-				l.Info("Get ovn db status command returned an error", "args", allArgs, "error", err.Error(), "output", string(out))
-			}
-			fileName := filepath.Join(dir, fmt.Sprintf("%s_%s_status", pod.Name, dbName))
-			if err := os.WriteFile(fileName, out, 0644); err != nil {
-				l.Error(err, "Failed to write file", "file", fileName)
-			}
-		}
-	}
-}
+type LogChecker func(filename string, content []byte)
 
 func CreateArchive(ctx context.Context, opts *DumpOptions) error {
 	files, err := os.ReadDir(opts.ArtifactDir)
@@ -700,10 +574,7 @@ func CreateArchive(ctx context.Context, opts *DumpOptions) error {
 	return nil
 }
 
-var (
-	kvExtResources     = append(coreResources, kubevirtResources...)
-	kvExtResourcesList = strings.Join(resourceTypes(kvExtResources), ",")
-)
+var kvExtResources = append(coreResources, kubevirtResources...)
 
 func dumpKubevirtExternalCluster(ctx context.Context, mngmtCl client.Client, creds *hyperv1.KubevirtPlatformCredentials, npNS string, destDir string, opts *DumpOptions) error {
 	kubeconfig, err := kvinfra.GetKubeConfig(ctx, mngmtCl, npNS, creds.InfraKubeConfigSecret.Name)
@@ -711,60 +582,26 @@ func dumpKubevirtExternalCluster(ctx context.Context, mngmtCl client.Client, cre
 		return fmt.Errorf("failed to get KubeVirt external infra-cluster:  %w", err)
 	}
 
-	kubeconfigFile, err := writeKubeconfigToFile(kubeconfig)
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		_ = os.Remove(kubeconfigFile)
-	}()
-
-	ocCommand, err := exec.LookPath("oc")
-	if err != nil || len(ocCommand) == 0 {
-		return fmt.Errorf("cannot find oc command")
-	}
-	cmd := OCAdmInspect{
-		oc:          ocCommand,
-		artifactDir: destDir,
-		kubeconfig:  kubeconfigFile,
-		log:         opts.Log,
-	}
-
 	cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
 	if err != nil {
 		return fmt.Errorf("can't get infra cluster rest config; %w", err)
 	}
 
-	kubeClient := kubeclient.NewForConfigOrDie(cfg)
+	d, err := newClusterDumper(cfg, opts, externalInfraCluster, destDir)
+	if err != nil {
+		return err
+	}
 
 	opts.Log.Info("dumping external infra cluster", "host", cfg.Host)
 	namespaces := []string{creds.InfraNamespace, kubevirtNamespace}
 	for _, ns := range namespaces {
-		cmd.WithNamespace(ns).Run(ctx, kvExtResourcesList)
-		outputLogs(ctx, opts.Log, kubeClient, destDir, ns, opts)
+		d.dumpResources(ctx, ns, gvksFor(kvExtResources), nil)
+		d.dumpLogs(ctx, ns)
 	}
 
 	return nil
 }
 
-func writeKubeconfigToFile(data []byte) (string, error) {
-	kubeconfigFile, err := os.CreateTemp(os.TempDir(), "kubeconfig-")
-	if err != nil {
-		return "", fmt.Errorf("failed to get create kubeconfig file for KubeVirt external infra-cluster:  %w", err)
-	}
-	_, err = kubeconfigFile.Write(data)
-	if err != nil {
-		return "", fmt.Errorf("failed to get create kubeconfig file for KubeVirt external infra-cluster:  %w", err)
-	}
-	err = kubeconfigFile.Close()
-	if err != nil {
-		return "", fmt.Errorf("failed to get create kubeconfig file for KubeVirt external infra-cluster:  %w", err)
-	}
-
-	return kubeconfigFile.Name(), err
-}
-
 type kubevirtExtCluster struct {
 	uid        string
 	nodePoolNS string
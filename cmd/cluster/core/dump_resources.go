@@ -0,0 +1,537 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/utils/ptr"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/consolelogs"
+	hyperapi "github.com/openshift/hypershift/support/api"
+)
+
+const (
+	// manifestFileName is the name of the index of the files of a dump, at the root of the artifact directory.
+	manifestFileName = "manifest.json"
+
+	redactedValue = "REDACTED"
+
+	managementCluster    = "management"
+	guestCluster         = "guest"
+	externalInfraCluster = "external-infra"
+)
+
+// DumpManifest indexes the files of a dump.
+type DumpManifest struct {
+	HostedCluster      string             `json:"hostedCluster"`
+	StartTime          metav1.Time        `json:"startTime"`
+	CompletionTime     metav1.Time        `json:"completionTime"`
+	Redacted           bool               `json:"redacted"`
+	LogsSince          string             `json:"logsSince,omitempty"`
+	MaxFileSize        int64              `json:"maxFileSize,omitempty"`
+	Components         []string           `json:"components,omitempty"`
+	ExcludedComponents []string           `json:"excludedComponents,omitempty"`
+	Files              []DumpManifestFile `json:"files"`
+	Errors             []string           `json:"errors,omitempty"`
+}
+
+// DumpManifestFile describes a file of a dump.
+type DumpManifestFile struct {
+	// Path is the path of the file relative to the artifact directory.
+	Path string `json:"path"`
+	// Type is the type of content of the file: resources, log, node-log, console-log, network or other
+	// for files collected by external commands.
+	Type string `json:"type"`
+	// Cluster is the cluster the content was collected from: management, guest or external-infra.
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// Resource is the resource of the objects of a resources file, e.g. deployments.apps.
+	Resource  string `json:"resource,omitempty"`
+	Items     int    `json:"items,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	Component string `json:"component,omitempty"`
+	Previous  bool   `json:"previous,omitempty"`
+	Machine   string `json:"machine,omitempty"`
+	Node      string `json:"node,omitempty"`
+	Size      int64  `json:"size"`
+	// OriginalSize is the size of the content before it was truncated to the maximum file size.
+	OriginalSize int64 `json:"originalSize,omitempty"`
+	Truncated    bool  `json:"truncated,omitempty"`
+}
+
+// dumper collects resources and pod logs of a cluster into a directory of the artifact directory.
+type dumper struct {
+	client     client.Client
+	kubeClient kubeclient.Interface
+	opts       *DumpOptions
+	manifest   *DumpManifest
+	// restConfig is the config of the cluster, used to run commands in its pods.
+	restConfig *restclient.Config
+	cluster    string
+	// dir is the directory the content of the cluster is written to, within opts.ArtifactDir.
+	dir string
+}
+
+func newDumpManifest(opts *DumpOptions) *DumpManifest {
+	manifest := &DumpManifest{
+		HostedCluster:      fmt.Sprintf("%s/%s", opts.Namespace, opts.Name),
+		StartTime:          metav1.Now(),
+		Redacted:           !opts.DisableRedaction,
+		MaxFileSize:        opts.MaxFileSize,
+		Components:         opts.Components,
+		ExcludedComponents: opts.ExcludeComponents,
+	}
+	if opts.LogsSince > 0 {
+		manifest.LogsSince = opts.LogsSince.String()
+	}
+	return manifest
+}
+
+// writeManifest indexes the files that were written by external commands and writes the manifest.
+func writeManifest(opts *DumpOptions, manifest *DumpManifest) error {
+	indexed := sets.New[string]()
+	for _, file := range manifest.Files {
+		indexed.Insert(file.Path)
+	}
+	if err := filepath.WalkDir(opts.ArtifactDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(opts.ArtifactDir, path)
+		if err != nil || indexed.Has(relativePath) || relativePath == manifestFileName {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, DumpManifestFile{Path: relativePath, Type: "other", Size: info.Size()})
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to index artifact directory: %w", err)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	manifest.CompletionTime = metav1.Now()
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize manifest: %w", err)
+	}
+	return os.WriteFile(filepath.Join(opts.ArtifactDir, manifestFileName), content, 0644)
+}
+
+func (d *dumper) recordError(err error) {
+	d.opts.Log.Info("Failed to dump", "cluster", d.cluster, "error", err.Error())
+	d.manifest.Errors = append(d.manifest.Errors, fmt.Sprintf("%s: %v", d.cluster, err))
+}
+
+// gvksFor returns the group version kinds of objects of the scheme.
+func gvksFor(objs []client.Object) []schema.GroupVersionKind {
+	result := make([]schema.GroupVersionKind, 0, len(objs))
+	for _, obj := range objs {
+		gvks, _, err := hyperapi.Scheme.ObjectKinds(obj)
+		if err != nil || len(gvks) == 0 {
+			continue
+		}
+		result = append(result, gvks[0])
+	}
+	return result
+}
+
+// dumpResources writes the objects of each kind in the namespace, or in all namespaces if it is empty, to
+// a file per resource and namespace. Kinds not served by the cluster are skipped. If keep is set, only
+// the objects for which it returns true are written.
+func (d *dumper) dumpResources(ctx context.Context, namespace string, gvks []schema.GroupVersionKind, keep func(*unstructured.Unstructured) bool) {
+	for _, gvk := range gvks {
+		mapping, err := d.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if !meta.IsNoMatchError(err) {
+				d.recordError(fmt.Errorf("failed to map %s: %w", gvk, err))
+			}
+			continue
+		}
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		var listOpts []client.ListOption
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace && namespace != "" {
+			listOpts = append(listOpts, client.InNamespace(namespace))
+		}
+		if err := d.client.List(ctx, list, listOpts...); err != nil {
+			d.recordError(fmt.Errorf("failed to list %s: %w", mapping.Resource.GroupResource(), err))
+			continue
+		}
+
+		itemsByNamespace := map[string][]unstructured.Unstructured{}
+		for i := range list.Items {
+			item := &list.Items[i]
+			if keep != nil && !keep(item) {
+				continue
+			}
+			if !d.opts.includesComponent(item.GetLabels()) {
+				continue
+			}
+			redactObject(item)
+			itemsByNamespace[item.GetNamespace()] = append(itemsByNamespace[item.GetNamespace()], *item)
+		}
+
+		group := mapping.Resource.Group
+		if group == "" {
+			group = "core"
+		}
+		for ns, items := range itemsByNamespace {
+			nsList := &unstructured.UnstructuredList{Object: list.Object, Items: items}
+			content, err := yaml.Marshal(nsList)
+			if err != nil {
+				d.recordError(fmt.Errorf("failed to serialize %s: %w", mapping.Resource.GroupResource(), err))
+				continue
+			}
+			relativePath := filepath.Join("cluster-scoped-resources", group, mapping.Resource.Resource+".yaml")
+			if ns != "" {
+				relativePath = filepath.Join("namespaces", ns, group, mapping.Resource.Resource+".yaml")
+			}
+			d.writeFile(relativePath, content, false, DumpManifestFile{
+				Type:      "resources",
+				Namespace: ns,
+				Resource:  mapping.Resource.GroupResource().String(),
+				Items:     len(items),
+			})
+		}
+	}
+}
+
+// dumpLogs writes the current and previous logs of the containers of the pods in the namespace, or in
+// all namespaces if it is empty.
+func (d *dumper) dumpLogs(ctx context.Context, namespace string) {
+	podList, err := d.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		d.recordError(fmt.Errorf("failed to list pods in namespace %q: %w", namespace, err))
+		return
+	}
+	d.opts.Log.Info("Dumping container logs", "cluster", d.cluster, "namespace", namespace)
+	for _, pod := range podList.Items {
+		if !d.opts.includesComponent(pod.Labels) {
+			continue
+		}
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, container := range containers {
+			d.dumpLog(ctx, &pod, container.Name, false)
+			d.dumpLog(ctx, &pod, container.Name, true)
+		}
+	}
+}
+
+func (d *dumper) dumpLog(ctx context.Context, pod *corev1.Pod, container string, previous bool) {
+	logOpts := &corev1.PodLogOptions{Container: container, Previous: previous}
+	if d.opts.LogsSince > 0 {
+		logOpts.SinceSeconds = ptr.To(int64(d.opts.LogsSince.Seconds()))
+	}
+	stream, err := d.kubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOpts).Stream(ctx)
+	if err != nil {
+		// previous logs only exist for restarted containers
+		if !previous {
+			d.recordError(fmt.Errorf("failed to get logs of container %s of pod %s/%s: %w", container, pod.Namespace, pod.Name, err))
+		}
+		return
+	}
+	defer stream.Close()
+	content, size, err := readTail(stream, d.opts.MaxFileSize)
+	if err != nil {
+		d.recordError(fmt.Errorf("failed to read logs of container %s of pod %s/%s: %w", container, pod.Namespace, pod.Name, err))
+	}
+
+	name := fmt.Sprintf("%s-%s.log", pod.Name, container)
+	if previous {
+		name = fmt.Sprintf("%s-%s-previous.log", pod.Name, container)
+	}
+	relativePath := filepath.Join("namespaces", pod.Namespace, "core", "pods", "logs", name)
+	for _, check := range d.opts.LogCheckers {
+		check(filepath.Join(d.dir, relativePath), content)
+	}
+	file := DumpManifestFile{
+		Type:      "log",
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Container: container,
+		Component: componentOf(pod.Labels),
+		Previous:  previous,
+	}
+	if size > int64(len(content)) {
+		file.Truncated = true
+		file.OriginalSize = size
+	}
+	d.writeFile(relativePath, content, true, file)
+}
+
+// dumpConsoleLogs writes the console logs of the Machines in the namespace which never became a Node,
+// whose instances likely failed to boot or to join the cluster.
+func (d *dumper) dumpConsoleLogs(ctx context.Context, provider consolelogs.Provider, namespace string) {
+	machines := &capiv1.MachineList{}
+	if err := d.client.List(ctx, machines, client.InNamespace(namespace)); err != nil {
+		d.recordError(fmt.Errorf("failed to list machines in namespace %q: %w", namespace, err))
+		return
+	}
+	machinesWithoutNode := consolelogs.MachinesWithoutNode(machines.Items)
+	if len(machinesWithoutNode) == 0 {
+		return
+	}
+	d.opts.Log.Info("Dumping console logs of machines without node", "cluster", d.cluster, "namespace", namespace, "machines", len(machinesWithoutNode))
+	for i := range machinesWithoutNode {
+		machine := &machinesWithoutNode[i]
+		consoleLog, err := provider.ConsoleLog(ctx, machine)
+		if err != nil {
+			d.recordError(fmt.Errorf("failed to get console log of machine %s/%s: %w", namespace, machine.Name, err))
+			continue
+		}
+		d.writeFile(filepath.Join("console-logs", machine.Name+".log"), consoleLog, true, DumpManifestFile{
+			Type:      "console-log",
+			Namespace: namespace,
+			Machine:   machine.Name,
+		})
+	}
+}
+
+// dumpNodeLogs writes the journal of the nodes with the role, read through the kubelet API with the
+// nodes/proxy subresource.
+func (d *dumper) dumpNodeLogs(ctx context.Context, role string) {
+	nodes := &corev1.NodeList{}
+	if err := d.client.List(ctx, nodes, client.HasLabels{"node-role.kubernetes.io/" + role}); err != nil {
+		d.recordError(fmt.Errorf("failed to list %s nodes: %w", role, err))
+		return
+	}
+	d.opts.Log.Info("Dumping node logs", "cluster", d.cluster, "role", role, "nodes", len(nodes.Items))
+	for _, node := range nodes.Items {
+		request := d.kubeClient.CoreV1().RESTClient().Get().Resource("nodes").Name(node.Name).SubResource("proxy", "logs", "journal")
+		if d.opts.LogsSince > 0 {
+			request = request.Param("since", fmt.Sprintf("-%ds", int64(d.opts.LogsSince.Seconds())))
+		}
+		stream, err := request.Stream(ctx)
+		if err != nil {
+			d.recordError(fmt.Errorf("failed to get logs of node %s: %w", node.Name, err))
+			continue
+		}
+		content, size, err := readTail(stream, d.opts.MaxFileSize)
+		stream.Close()
+		if err != nil {
+			d.recordError(fmt.Errorf("failed to read logs of node %s: %w", node.Name, err))
+		}
+
+		relativePath := filepath.Join("cluster-scoped-resources", "core", "nodes", "logs", node.Name+".log")
+		for _, check := range d.opts.LogCheckers {
+			check(filepath.Join(d.dir, relativePath), content)
+		}
+		file := DumpManifestFile{Type: "node-log", Node: node.Name}
+		if size > int64(len(content)) {
+			file.Truncated = true
+			file.OriginalSize = size
+		}
+		d.writeFile(relativePath, content, true, file)
+	}
+}
+
+// dumpNetworkLogs writes the OVN northbound and southbound databases of the ovnkube-master pods in the
+// namespace and their cluster status.
+func (d *dumper) dumpNetworkLogs(ctx context.Context, namespace string) {
+	pods := &corev1.PodList{}
+	if err := d.client.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{"app": "ovnkube-master"}); err != nil {
+		d.recordError(fmt.Errorf("failed to list ovnkube-master pods in namespace %q: %w", namespace, err))
+		return
+	}
+	for _, pod := range pods.Items {
+		for _, dbType := range []string{"n", "s"} {
+			dbName := "OVN_Northbound"
+			if dbType == "s" {
+				dbName = "OVN_Southbound"
+			}
+			container := dbType + "bdb"
+			file := DumpManifestFile{Type: "network", Namespace: namespace, Pod: pod.Name, Container: container}
+
+			db, err := d.exec(ctx, &pod, container, "cat", fmt.Sprintf("/etc/ovn/ovn%sb_db.db", dbType))
+			if err != nil {
+				d.recordError(fmt.Errorf("failed to copy %s database of pod %s/%s: %w", dbName, namespace, pod.Name, err))
+			} else {
+				d.writeFile(filepath.Join("network_logs", fmt.Sprintf("%s_ovn%sb_db.db", pod.Name, dbType)), db, false, file)
+			}
+
+			status, err := d.exec(ctx, &pod, container, "ovn-appctl", "-t", fmt.Sprintf("/var/run/ovn/ovn%sb_db.ctl", dbType), "cluster/status", dbName)
+			if err != nil {
+				d.recordError(fmt.Errorf("failed to get %s status of pod %s/%s: %w", dbName, namespace, pod.Name, err))
+				continue
+			}
+			d.writeFile(filepath.Join("network_logs", fmt.Sprintf("%s_%s_status", pod.Name, dbName)), status, false, file)
+		}
+	}
+}
+
+// exec runs the command in the container of the pod and returns its output.
+func (d *dumper) exec(ctx context.Context, pod *corev1.Pod, container string, command ...string) ([]byte, error) {
+	request := d.kubeClient.CoreV1().RESTClient().Post().Resource("pods").Namespace(pod.Namespace).Name(pod.Name).SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{Container: container, Command: command, Stdout: true, Stderr: true}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(d.restConfig, "POST", request.URL())
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+// writeFile redacts the content, caps it to the maximum file size, keeping its end when keepTail is set
+// and its beginning otherwise, and writes it in the directory of the dumper.
+func (d *dumper) writeFile(relativePath string, content []byte, keepTail bool, file DumpManifestFile) {
+	if !d.opts.DisableRedaction {
+		content = redactContent(content)
+	}
+	if max := d.opts.MaxFileSize; max > 0 && int64(len(content)) > max {
+		if !file.Truncated {
+			file.Truncated = true
+			file.OriginalSize = int64(len(content))
+		}
+		if keepTail {
+			content = content[int64(len(content))-max:]
+		} else {
+			content = content[:max]
+		}
+	}
+
+	path := filepath.Join(d.dir, relativePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		d.recordError(fmt.Errorf("failed to create directory for %s: %w", path, err))
+		return
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		d.recordError(fmt.Errorf("failed to write %s: %w", path, err))
+		return
+	}
+	if manifestPath, err := filepath.Rel(d.opts.ArtifactDir, path); err == nil {
+		file.Path = manifestPath
+	} else {
+		file.Path = path
+	}
+	file.Cluster = d.cluster
+	file.Size = int64(len(content))
+	d.manifest.Files = append(d.manifest.Files, file)
+}
+
+// readTail reads the stream and returns at most its last max bytes, starting at a line, and the total
+// number of bytes read. A max of 0 reads the whole stream.
+func readTail(r io.Reader, max int64) ([]byte, int64, error) {
+	var buf []byte
+	var size int64
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		size += int64(n)
+		// keep the byte before the tail to know whether the tail starts at a line
+		if max > 0 && int64(len(buf)) > 2*max+1 {
+			buf = append([]byte(nil), buf[int64(len(buf))-max-1:]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return buf, size, err
+		}
+	}
+	if max > 0 && int64(len(buf)) > max {
+		startsLine := buf[int64(len(buf))-max-1] == '\n'
+		buf = buf[int64(len(buf))-max:]
+		if !startsLine {
+			if idx := bytes.IndexByte(buf, '\n'); idx >= 0 {
+				buf = buf[idx+1:]
+			}
+		}
+	}
+	return buf, size, nil
+}
+
+// componentOf returns the control plane component of an object from its labels.
+func componentOf(labels map[string]string) string {
+	if component := labels[hyperv1.ControlPlaneComponent]; component != "" {
+		return component
+	}
+	return labels["app"]
+}
+
+// includesComponent returns whether the resources and logs of an object are dumped. Objects that don't
+// belong to a component are always dumped.
+func (o *DumpOptions) includesComponent(labels map[string]string) bool {
+	component := componentOf(labels)
+	if component == "" {
+		return true
+	}
+	if sets.New(o.ExcludeComponents...).Has(component) {
+		return false
+	}
+	return len(o.Components) == 0 || sets.New(o.Components...).Has(component)
+}
+
+var (
+	// pemBlockRegexp matches certificates and keys, including in escaped strings.
+	pemBlockRegexp = regexp.MustCompile(`-----BEGIN ([A-Z0-9 ]+)-----[\s\S]*?-----END [A-Z0-9 ]+-----`)
+	// dockerAuthRegexp matches the credentials of pull secrets, including in escaped strings.
+	dockerAuthRegexp = regexp.MustCompile(`(\\?"(?:auth|password|identitytoken)\\?"\s*:\s*\\?")[^"\\]*`)
+	// bearerTokenRegexp matches the bearer tokens of authorization headers.
+	bearerTokenRegexp = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	// kubeconfigTokenRegexp matches the tokens of the users of kubeconfigs, e.g. in ConfigMaps, including
+	// in escaped strings.
+	kubeconfigTokenRegexp = regexp.MustCompile(`(?m)((?:^|\\n)[ \t]*(?:id-|refresh-|access-)?token:[ \t]*(?:\\?["'])?)[^\s"'\\]+`)
+)
+
+// redactContent replaces certificates, keys, pull secret credentials, bearer tokens and kubeconfig
+// tokens in the content.
+func redactContent(content []byte) []byte {
+	content = pemBlockRegexp.ReplaceAll(content, []byte("-----BEGIN $1-----"+redactedValue+"-----END $1-----"))
+	content = dockerAuthRegexp.ReplaceAll(content, []byte("${1}"+redactedValue))
+	content = bearerTokenRegexp.ReplaceAll(content, []byte("${1}"+redactedValue))
+	content = kubeconfigTokenRegexp.ReplaceAll(content, []byte("${1}"+redactedValue))
+	return content
+}
+
+// redactObject replaces the values of secrets, which are never dumped, regardless of whether the
+// content is redacted.
+func redactObject(obj *unstructured.Unstructured) {
+	if obj.GroupVersionKind().GroupKind() != (schema.GroupKind{Kind: "Secret"}) {
+		return
+	}
+	for _, field := range []string{"data", "stringData"} {
+		values, found, _ := unstructured.NestedMap(obj.Object, field)
+		if !found {
+			continue
+		}
+		for key := range values {
+			values[key] = redactedValue
+		}
+		_ = unstructured.SetNestedMap(obj.Object, values, field)
+	}
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; ok {
+		delete(annotations, corev1.LastAppliedConfigAnnotation)
+		obj.SetAnnotations(annotations)
+	}
+}
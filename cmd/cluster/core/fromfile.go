@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// CreateClusterFileAPIVersion is the version of the schema of files given to `create cluster --from-file`.
+	CreateClusterFileAPIVersion = "cli.hypershift.openshift.io/v1alpha1"
+	// CreateClusterFileKind is the kind of files given to `create cluster --from-file`.
+	CreateClusterFileKind = "CreateClusterOptions"
+
+	fromFileFlag = "from-file"
+)
+
+// CreateClusterFile is the schema of a file holding the inputs of `create cluster`, so they can be kept in git
+// rather than in a script of flags. Options are keyed by the name of the flag that sets them and take the same
+// values, lists for flags that can be specified multiple times and maps for key=value flags. The options are
+// decoded strictly, unknown options and values of the wrong type are rejected, e.g.:
+//
+//	apiVersion: cli.hypershift.openshift.io/v1alpha1
+//	kind: CreateClusterOptions
+//	platform: AWS
+//	options:
+//	  name: example
+//	  base-domain: example.com
+//	  node-pool-replicas: 3
+//	  annotations:
+//	  - hypershift.openshift.io/cleanup-cloud-resources=true
+//	platformOptions:
+//	  region: us-east-1
+//	  zones: [us-east-1a, us-east-1b]
+//
+// Flags given on the command line take precedence over the options in the file.
+type CreateClusterFile struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// Platform is the platform the cluster is created on. It must match the platform subcommand, e.g. AWS
+	// for `create cluster aws`.
+	Platform string `json:"platform"`
+
+	// Options are the options common to all platforms.
+	Options CreateClusterFileOptions `json:"options,omitempty"`
+
+	// PlatformOptions are the options of the platform, they are decoded into the CreateClusterFileOptions type
+	// of the platform, e.g. AWSCreateClusterFileOptions for AWS.
+	PlatformOptions json.RawMessage `json:"platformOptions,omitempty"`
+}
+
+// BindFromFileOption adds the --from-file flag to the `create cluster` command. The options in the file are
+// set on the flags of the platform subcommand before it runs, so they go through the same validation and
+// completion as options given as flags.
+func BindFromFileOption(cmd *cobra.Command) {
+	var path string
+	cmd.PersistentFlags().StringVar(&path, fromFileFlag, path, fmt.Sprintf("Path to a %s file with the options of the cluster. Flags take precedence over the options in the file.", CreateClusterFileKind))
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if path == "" {
+			return nil
+		}
+		return ApplyCreateClusterFile(cmd, path)
+	}
+}
+
+// ApplyCreateClusterFile sets the flags of a platform subcommand of `create cluster` from the options in the
+// file, leaving flags that were set on the command line untouched.
+func ApplyCreateClusterFile(cmd *cobra.Command, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	file := CreateClusterFile{}
+	if err := yaml.UnmarshalStrict(raw, &file); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.APIVersion != CreateClusterFileAPIVersion || file.Kind != CreateClusterFileKind {
+		return fmt.Errorf("unsupported file %s: expected apiVersion %s and kind %s, got %q and %q", path, CreateClusterFileAPIVersion, CreateClusterFileKind, file.APIVersion, file.Kind)
+	}
+	if !strings.EqualFold(file.Platform, cmd.Name()) {
+		return fmt.Errorf("file %s is for platform %q, cannot use it with `create cluster %s`", path, file.Platform, cmd.Name())
+	}
+	newPlatformOptions, exists := createClusterFilePlatformOptions[cmd.Name()]
+	if !exists {
+		return fmt.Errorf("unsupported platform %q in %s", file.Platform, path)
+	}
+	platformOptions := newPlatformOptions()
+	if len(file.PlatformOptions) > 0 && !bytes.Equal(file.PlatformOptions, []byte("null")) {
+		decoder := json.NewDecoder(bytes.NewReader(file.PlatformOptions))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(platformOptions); err != nil {
+			return fmt.Errorf("failed to parse platformOptions in %s: %w", path, err)
+		}
+	}
+
+	if err := applyOptions(cmd.Flags(), cmd.InheritedFlags(), file.Options); err != nil {
+		return fmt.Errorf("invalid options in %s: %w", path, err)
+	}
+	if err := applyOptions(cmd.Flags(), cmd.LocalNonPersistentFlags(), platformOptions); err != nil {
+		return fmt.Errorf("invalid platformOptions in %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyOptions sets the flags from the typed options, by converting them back to the options keyed by flag name
+// of their JSON form. Options which are not bound by the command, like the developer options in the product CLI,
+// are rejected.
+func applyOptions(flags, allowed *pflag.FlagSet, typedOptions interface{}) error {
+	raw, err := json.Marshal(typedOptions)
+	if err != nil {
+		return err
+	}
+	options := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &options); err != nil {
+		return err
+	}
+
+	// sort to set options deterministically, so that errors are reported consistently
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := allowed.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("option %q is not supported by this command", name)
+		}
+		if flag.Changed {
+			// flags on the command line override the file
+			continue
+		}
+		values, err := optionValues(flag, options[name])
+		if err != nil {
+			return fmt.Errorf("option %q: %w", name, err)
+		}
+		for _, value := range values {
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("option %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// optionValues converts the value of an option to the values the flag would be given on the command line.
+func optionValues(flag *pflag.Flag, value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		if !strings.HasSuffix(flag.Value.Type(), "Slice") && !strings.HasSuffix(flag.Value.Type(), "Array") {
+			return nil, fmt.Errorf("expected a single %s value, got a list", flag.Value.Type())
+		}
+		var values []string
+		for _, item := range v {
+			itemValue, err := scalarOptionValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, itemValue)
+		}
+		return values, nil
+	case map[string]interface{}:
+		if flag.Value.Type() != "stringToString" {
+			return nil, fmt.Errorf("expected a %s value, got a map", flag.Value.Type())
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var values []string
+		for _, key := range keys {
+			itemValue, err := scalarOptionValue(v[key])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, fmt.Sprintf("%s=%s", key, itemValue))
+		}
+		return values, nil
+	default:
+		scalar, err := scalarOptionValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{scalar}, nil
+	}
+}
+
+func scalarOptionValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
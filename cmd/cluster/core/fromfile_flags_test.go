@@ -0,0 +1,47 @@
+package core_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/hypershift/cmd/cluster"
+	"github.com/openshift/hypershift/cmd/cluster/core"
+)
+
+func TestCreateClusterFileOptionsMatchFlags(t *testing.T) {
+	g := NewWithT(t)
+	cmd := cluster.NewCreateCommands()
+
+	for _, name := range optionNames(core.CreateClusterFileOptions{}) {
+		g.Expect(cmd.PersistentFlags().Lookup(name)).ToNot(BeNil(), "option %q has no create cluster flag", name)
+	}
+
+	for platform, options := range map[string]interface{}{
+		"aws":      core.AWSCreateClusterFileOptions{},
+		"azure":    core.AzureCreateClusterFileOptions{},
+		"kubevirt": core.KubeVirtCreateClusterFileOptions{},
+		"agent":    core.AgentCreateClusterFileOptions{},
+		"powervs":  core.PowerVSCreateClusterFileOptions{},
+		"none":     core.NoneCreateClusterFileOptions{},
+	} {
+		platformCmd, _, err := cmd.Find([]string{platform})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(platformCmd.Name()).To(Equal(platform))
+		for _, name := range optionNames(options) {
+			g.Expect(platformCmd.LocalNonPersistentFlags().Lookup(name)).ToNot(BeNil(), "option %q has no create cluster %s flag", name, platform)
+		}
+	}
+}
+
+func optionNames(options interface{}) []string {
+	var names []string
+	optionsType := reflect.TypeOf(options)
+	for i := 0; i < optionsType.NumField(); i++ {
+		name, _, _ := strings.Cut(optionsType.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}
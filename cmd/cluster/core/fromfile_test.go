@@ -0,0 +1,188 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+)
+
+func TestApplyCreateClusterFile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		file        string
+		args        []string
+		expectError bool
+		validate    func(g Gomega, opts *RawCreateOptions, zones []string, region string)
+	}{
+		{
+			name: "options and platform options are set from the file",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: AWS
+options:
+  name: from-file
+  node-pool-replicas: 3
+  fips: true
+  node-drain-timeout: 10m
+  service-cidr: [172.30.0.0/16, fd02::/112]
+  node-selector:
+    role: cp
+    disk: fast
+  olm-catalog-placement: Guest
+platformOptions:
+  region: us-west-2
+  zones: [us-west-2a, us-west-2b]
+`,
+			validate: func(g Gomega, opts *RawCreateOptions, zones []string, region string) {
+				g.Expect(opts.Name).To(Equal("from-file"))
+				g.Expect(opts.NodePoolReplicas).To(Equal(int32(3)))
+				g.Expect(opts.FIPS).To(BeTrue())
+				g.Expect(opts.NodeDrainTimeout).To(Equal(10 * time.Minute))
+				g.Expect(opts.ServiceCIDR).To(Equal([]string{"172.30.0.0/16", "fd02::/112"}))
+				g.Expect(opts.NodeSelector).To(Equal(map[string]string{"role": "cp", "disk": "fast"}))
+				g.Expect(opts.OLMCatalogPlacement).To(Equal(hyperv1.GuestOLMCatalogPlacement))
+				g.Expect(opts.Namespace).To(Equal("clusters"))
+				g.Expect(region).To(Equal("us-west-2"))
+				g.Expect(zones).To(Equal([]string{"us-west-2a", "us-west-2b"}))
+			},
+		},
+		{
+			name: "flags override the file",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: aws
+options:
+  name: from-file
+  service-cidr: [172.30.0.0/16]
+platformOptions:
+  region: us-west-2
+`,
+			args: []string{"--name=from-flag", "--service-cidr=10.0.0.0/16", "--region=eu-west-1"},
+			validate: func(g Gomega, opts *RawCreateOptions, zones []string, region string) {
+				g.Expect(opts.Name).To(Equal("from-flag"))
+				g.Expect(opts.ServiceCIDR).To(Equal([]string{"10.0.0.0/16"}))
+				g.Expect(region).To(Equal("eu-west-1"))
+			},
+		},
+		{
+			name: "unknown option",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: AWS
+options:
+  nmae: typo
+`,
+			expectError: true,
+		},
+		{
+			name: "platform option given as core option",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: AWS
+options:
+  region: us-west-2
+`,
+			expectError: true,
+		},
+		{
+			name: "list for a single valued option",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: AWS
+options:
+  name: [a, b]
+`,
+			expectError: true,
+		},
+		{
+			name: "value of the wrong type",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: AWS
+options:
+  fips: "yes"
+`,
+			expectError: true,
+		},
+		{
+			name: "unknown platform option",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: AWS
+platformOptions:
+  regoin: us-west-2
+`,
+			expectError: true,
+		},
+		{
+			name: "platform option not bound by the command",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: AWS
+platformOptions:
+  instance-type: m5.xlarge
+`,
+			expectError: true,
+		},
+		{
+			name: "file for another platform",
+			file: `apiVersion: cli.hypershift.openshift.io/v1alpha1
+kind: CreateClusterOptions
+platform: Azure
+`,
+			expectError: true,
+		},
+		{
+			name: "unsupported version",
+			file: `apiVersion: cli.hypershift.openshift.io/v2
+kind: CreateClusterOptions
+platform: AWS
+`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path := filepath.Join(t.TempDir(), "cluster.yaml")
+			g.Expect(os.WriteFile(path, []byte(tc.file), 0600)).To(Succeed())
+
+			opts := DefaultOptions()
+			var zones []string
+			var region string
+			var ran bool
+			cmd := &cobra.Command{Use: "cluster", SilenceUsage: true, SilenceErrors: true}
+			BindDeveloperOptions(opts, cmd.PersistentFlags())
+			BindFromFileOption(cmd)
+			platform := &cobra.Command{
+				Use: "aws",
+				RunE: func(cmd *cobra.Command, args []string) error {
+					ran = true
+					return nil
+				},
+			}
+			platform.Flags().StringVar(&region, "region", "us-east-1", "")
+			platform.Flags().StringSliceVar(&zones, "zones", zones, "")
+			cmd.AddCommand(platform)
+
+			cmd.SetArgs(append([]string{"aws", "--from-file", path}, tc.args...))
+			err := cmd.Execute()
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(ran).To(BeFalse())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ran).To(BeTrue())
+			tc.validate(g, opts, zones, region)
+		})
+	}
+}
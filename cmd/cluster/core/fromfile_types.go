@@ -0,0 +1,283 @@
+package core
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+)
+
+// CreateClusterFileOptions are the options common to all platforms in a CreateClusterFile. Each option is keyed
+// by the name of the `create cluster` flag that sets it and takes the same values. Options which control the
+// invocation rather than the cluster, like --render and --wait, can only be given as flags.
+type CreateClusterFileOptions struct {
+	// Namespace is the namespace of the HostedCluster and its resources.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the HostedCluster.
+	Name string `json:"name,omitempty"`
+	// BaseDomain is the ingress base domain of the cluster.
+	BaseDomain string `json:"base-domain,omitempty"`
+	// BaseDomainPrefix is the ingress base domain prefix of the cluster, it defaults to the name of the cluster.
+	// Use "none" for an empty prefix.
+	BaseDomainPrefix string `json:"base-domain-prefix,omitempty"`
+	// ExternalDNSDomain is the domain the hostnames of the services published with a LoadBalancer or a Route
+	// are set in.
+	ExternalDNSDomain string `json:"external-dns-domain,omitempty"`
+	// NetworkType is the SDN provider of the cluster, one of Calico, OVNKubernetes, OpenShiftSDN or Other.
+	NetworkType string `json:"network-type,omitempty"`
+	// ReleaseImage is the OCP release image of the cluster.
+	ReleaseImage string `json:"release-image,omitempty"`
+	// ReleaseStream is the OCP release stream of the cluster, e.g. 4.15.0-0.nightly. It is ignored when
+	// ReleaseImage is set.
+	ReleaseStream string `json:"release-stream,omitempty"`
+	// ControlPlaneOperatorImage overrides the image of the control plane operator.
+	ControlPlaneOperatorImage string `json:"control-plane-operator-image,omitempty"`
+	// PullSecret is the path to a pull secret file.
+	PullSecret string `json:"pull-secret,omitempty"`
+	// ControlPlaneAvailabilityPolicy is the availability policy of the hosted control plane components, either
+	// SingleReplica or HighlyAvailable.
+	ControlPlaneAvailabilityPolicy string `json:"control-plane-availability-policy,omitempty"`
+	// InfraAvailabilityPolicy is the availability policy of the infrastructure services in the guest cluster,
+	// either SingleReplica or HighlyAvailable.
+	InfraAvailabilityPolicy string `json:"infra-availability-policy,omitempty"`
+	// SSHKey is the path to an SSH public key file.
+	SSHKey string `json:"ssh-key,omitempty"`
+	// GenerateSSH generates the SSH keys of the cluster.
+	GenerateSSH *bool `json:"generate-ssh,omitempty"`
+	// AdditionalTrustBundle is the path to a file with a user CA bundle.
+	AdditionalTrustBundle string `json:"additional-trust-bundle,omitempty"`
+	// ImageContentSources is the path to a file with image content sources.
+	ImageContentSources string `json:"image-content-sources,omitempty"`
+	// NodePoolReplicas is the number of replicas of the NodePool created with the cluster. No NodePool is created
+	// when it is lower than 0.
+	NodePoolReplicas *int32 `json:"node-pool-replicas,omitempty"`
+	// NodeDrainTimeout is the NodeDrainTimeout of the created NodePools.
+	NodeDrainTimeout *metav1.Duration `json:"node-drain-timeout,omitempty"`
+	// NodeVolumeDetachTimeout is the NodeVolumeDetachTimeout of the created NodePools.
+	NodeVolumeDetachTimeout *metav1.Duration `json:"node-volume-detach-timeout,omitempty"`
+	// NodeUpgradeType is the upgrade strategy of the created NodePools, either Replace or InPlace.
+	NodeUpgradeType hyperv1.UpgradeType `json:"node-upgrade-type,omitempty"`
+	// Arch is the default processor architecture of the NodePool, e.g. amd64 or arm64.
+	Arch string `json:"arch,omitempty"`
+	// Annotations are the annotations of the HostedCluster, as key=value.
+	Annotations []string `json:"annotations,omitempty"`
+	// FIPS enables the FIPS mode of the nodes.
+	FIPS *bool `json:"fips,omitempty"`
+	// AutoRepair enables the repair of machines with machine health checks.
+	AutoRepair *bool `json:"auto-repair,omitempty"`
+	// EtcdStorageClass is the storage class of the etcd data volumes.
+	EtcdStorageClass string `json:"etcd-storage-class,omitempty"`
+	// InfraID is the infrastructure ID of the resources of the cluster.
+	InfraID string `json:"infra-id,omitempty"`
+	// InfraJSON is the path to a file with the infrastructure of the cluster. The infrastructure is created
+	// when it is not set.
+	InfraJSON string `json:"infra-json,omitempty"`
+	// ServiceCIDR are the CIDRs of the service network.
+	ServiceCIDR []string `json:"service-cidr,omitempty"`
+	// ClusterCIDR are the CIDRs of the cluster network.
+	ClusterCIDR []string `json:"cluster-cidr,omitempty"`
+	// DefaultDual uses the dual-stack defaults for the service and cluster CIDRs. It cannot be set with
+	// ServiceCIDR or ClusterCIDR.
+	DefaultDual *bool `json:"default-dual,omitempty"`
+	// NodeSelector is the node selector of the hosted control plane pods.
+	NodeSelector map[string]string `json:"node-selector,omitempty"`
+	// Tolerations are the tolerations of the hosted control plane pods, each a comma separated list of key,
+	// value, operator, effect and tolerationSeconds options, e.g. key=node-role.kubernetes.io/master,operator=Exists.
+	Tolerations []string `json:"toleration,omitempty"`
+	// OLMCatalogPlacement is the placement of the OLM catalogs, either Management or Guest.
+	OLMCatalogPlacement hyperv1.OLMCatalogPlacement `json:"olm-catalog-placement,omitempty"`
+	// OLMDisableDefaultSources disables the default OLM catalog sources.
+	OLMDisableDefaultSources *bool `json:"olm-disable-default-sources,omitempty"`
+	// PausedUntil pauses the creation of the HostedCluster until an RFC3339 date, or until the field is removed
+	// when it is "true".
+	PausedUntil string `json:"pausedUntil,omitempty"`
+}
+
+// AWSCreateClusterFileOptions are the platform options of a CreateClusterFile for `create cluster aws`.
+type AWSCreateClusterFileOptions struct {
+	// Region is the AWS region of the infrastructure.
+	Region string `json:"region,omitempty"`
+	// Zones are the availability zones the NodePools are created in.
+	Zones []string `json:"zones,omitempty"`
+	// InstanceType is the instance type of the NodePool machines.
+	InstanceType string `json:"instance-type,omitempty"`
+	// RootVolumeType is the type of the root volume of the NodePool machines, e.g. gp3 or io2.
+	RootVolumeType string `json:"root-volume-type,omitempty"`
+	// RootVolumeIOPS are the IOPS of the root volume of the NodePool machines when its type is io1.
+	RootVolumeIOPS *int64 `json:"root-volume-iops,omitempty"`
+	// RootVolumeSize is the size of the root volume of the NodePool machines in GiB, at least 8.
+	RootVolumeSize *int64 `json:"root-volume-size,omitempty"`
+	// RootVolumeKMSKey is the ID or the ARN of the KMS key encrypting the root volume of the NodePool machines.
+	RootVolumeKMSKey string `json:"root-volume-kms-key,omitempty"`
+	// AdditionalTags are additional tags of the AWS resources, as key=value.
+	AdditionalTags []string `json:"additional-tags,omitempty"`
+	// EndpointAccess is the access of the control plane endpoints, one of Public, PublicAndPrivate or Private.
+	EndpointAccess string `json:"endpoint-access,omitempty"`
+	// KMSKeyARN is the ARN of the KMS key encrypting etcd. A generated AESCBC key is used when it is not set.
+	KMSKeyARN string `json:"kms-key-arn,omitempty"`
+	// EnableProxy sets up a proxy rather than allowing the nodes direct internet access.
+	EnableProxy *bool `json:"enable-proxy,omitempty"`
+	// SecretCreds is the name of a secret in the namespace of the cluster with the sts-creds, the pull-secret
+	// and the base-domain of the cluster.
+	SecretCreds string `json:"secret-creds,omitempty"`
+	// OIDCIssuerURL is the issuer URL of the OIDC provider. It is required when the HyperShift operator does not
+	// store the OIDC documents in S3.
+	OIDCIssuerURL string `json:"oidc-issuer-url,omitempty"`
+	// MultiArch validates that the release supports multi-arch NodePools.
+	MultiArch *bool `json:"multi-arch,omitempty"`
+	// IAMJSON is the path to a file with the IAM of the cluster. The IAM is created when it is not set.
+	IAMJSON string `json:"iam-json,omitempty"`
+	// SingleNATGateway creates a single NAT gateway, even if multiple zones are set.
+	SingleNATGateway *bool `json:"single-nat-gateway,omitempty"`
+	// RoleARN is the ARN of the role to assume.
+	RoleARN string `json:"role-arn,omitempty"`
+	// STSCreds is the path to the STS credentials file used to assume the role.
+	STSCreds string `json:"sts-creds,omitempty"`
+}
+
+// AzureCreateClusterFileOptions are the platform options of a CreateClusterFile for `create cluster azure`.
+type AzureCreateClusterFileOptions struct {
+	// AzureCreds is the path to an Azure credentials file.
+	AzureCreds string `json:"azure-creds,omitempty"`
+	// Location is the Azure location of the cluster.
+	Location string `json:"location,omitempty"`
+	// EncryptionKeyID is the identifier of the etcd encryption key, in the form of
+	// https://<vaultName>.vault.azure.net/keys/<keyName>/<keyVersion>.
+	EncryptionKeyID string `json:"encryption-key-id,omitempty"`
+	// InstanceType is the instance type of the nodes.
+	InstanceType string `json:"instance-type,omitempty"`
+	// RootDiskSize is the size of the root disk of the NodePool machines in GB, at least 16.
+	RootDiskSize *int32 `json:"root-disk-size,omitempty"`
+	// AvailabilityZones are the availability zones the NodePools are created in, one NodePool per zone.
+	AvailabilityZones []string `json:"availability-zones,omitempty"`
+	// ResourceGroupName is the resource group the infrastructure of the cluster is created in.
+	ResourceGroupName string `json:"resource-group-name,omitempty"`
+	// ResourceGroupTags are additional tags of the created resource group.
+	ResourceGroupTags map[string]string `json:"resource-group-tags,omitempty"`
+	// VnetID is the ID of an existing VNET.
+	VnetID string `json:"vnet-id,omitempty"`
+	// SubnetID is the ID of the subnet of the VMs.
+	SubnetID string `json:"subnet-id,omitempty"`
+	// NetworkSecurityGroupID is the ID of the network security group of the default NodePool.
+	NetworkSecurityGroupID string `json:"network-security-group-id,omitempty"`
+	// DiskEncryptionSetID is the ID of the disk encryption set encrypting the OS disks of the VMs.
+	DiskEncryptionSetID string `json:"disk-encryption-set-id,omitempty"`
+	// DiskStorageAccountType is the storage account type of the OS disks of the VMs.
+	DiskStorageAccountType string `json:"disk-storage-account-type,omitempty"`
+	// EnableEphemeralDisk sets up the VMs of the default NodePool with ephemeral OS disks.
+	EnableEphemeralDisk *bool `json:"enable-ephemeral-disk,omitempty"`
+}
+
+// KubeVirtCreateClusterFileOptions are the platform options of a CreateClusterFile for `create cluster kubevirt`.
+type KubeVirtCreateClusterFileOptions struct {
+	// InfraKubeconfigFile is the path to the kubeconfig of an external infra cluster the nodes are created on.
+	InfraKubeconfigFile string `json:"infra-kubeconfig-file,omitempty"`
+	// InfraNamespace is the existing namespace of the external infra cluster the VirtualMachines are created in.
+	InfraNamespace string `json:"infra-namespace,omitempty"`
+	// InfraStorageClassMappings map infra StorageClasses to guest StorageClasses, as <infra>/<guest>[,group=<group>].
+	InfraStorageClassMappings []string `json:"infra-storage-class-mapping,omitempty"`
+	// InfraVolumeSnapshotClassMappings map infra VolumeSnapshotClasses to guest VolumeSnapshotClasses, as
+	// <infra>/<guest>[,group=<group>].
+	InfraVolumeSnapshotClassMappings []string `json:"infra-volumesnapshot-class-mapping,omitempty"`
+	// APIServerAddress is the API server address used by the components outside the control plane.
+	APIServerAddress string `json:"api-server-address,omitempty"`
+	// ServicePublishingStrategy is how the services of the cluster are exposed, either Ingress or NodePort.
+	ServicePublishingStrategy string `json:"service-publishing-strategy,omitempty"`
+	// Memory is the memory visible inside the guest OS, e.g. 8Gi.
+	Memory string `json:"memory,omitempty"`
+	// Cores is the number of cores of the VirtualMachineInstances, at least 1.
+	Cores *uint32 `json:"cores,omitempty"`
+	// ContainerDisk is a reference to an image with the disk the machines are created from.
+	ContainerDisk string `json:"containerdisk,omitempty"`
+	// RootVolumeStorageClass is the storage class of the root volume of the machines.
+	RootVolumeStorageClass string `json:"root-volume-storage-class,omitempty"`
+	// RootVolumeSize is the size of the root volume of the machines in Gi.
+	RootVolumeSize *uint32 `json:"root-volume-size,omitempty"`
+	// RootVolumeAccessModes is a comma separated list of the access modes of the root volume of the machines.
+	RootVolumeAccessModes string `json:"root-volume-access-modes,omitempty"`
+	// RootVolumeVolumeMode is the volume mode of the root volume of the machines, either Block or Filesystem.
+	RootVolumeVolumeMode string `json:"root-volume-volume-mode,omitempty"`
+	// RootVolumeCacheStrategy is the caching strategy of the boot image, either None or PVC.
+	RootVolumeCacheStrategy string `json:"root-volume-cache-strategy,omitempty"`
+	// NetworkMultiQueue enables the vhost multiqueue feature of the virtio network interfaces, either Enable
+	// or Disable.
+	NetworkMultiQueue string `json:"network-multiqueue,omitempty"`
+	// QoSClass is the QoS class of the VirtualMachineInstances, either Burstable or Guaranteed.
+	QoSClass string `json:"qos-class,omitempty"`
+	// AdditionalNetworks are additional networks attached to the nodes, e.g. name:ns1/nad-foo.
+	AdditionalNetworks []string `json:"additional-network,omitempty"`
+	// AttachDefaultNetwork attaches the default pod network to the nodes. It can only be set with
+	// AdditionalNetworks.
+	AttachDefaultNetwork *bool `json:"attach-default-network,omitempty"`
+	// VMNodeSelector is the node selector of the VirtualMachines.
+	VMNodeSelector map[string]string `json:"vm-node-selector,omitempty"`
+	// HostDevices are the PCI devices of the infra cluster exposed to the nodes, e.g. <device-name>,count:3.
+	HostDevices []string `json:"host-device-name,omitempty"`
+}
+
+// AgentCreateClusterFileOptions are the platform options of a CreateClusterFile for `create cluster agent`.
+type AgentCreateClusterFileOptions struct {
+	// APIServerAddress is the IP address of the Kubernetes API of the cluster.
+	APIServerAddress string `json:"api-server-address,omitempty"`
+	// AgentNamespace is the namespace the Agents are searched in.
+	AgentNamespace string `json:"agent-namespace,omitempty"`
+	// AgentLabelSelector selects the Agents by their labels, e.g. 'size=large,zone notin (az1,az2)'.
+	AgentLabelSelector string `json:"agentLabelSelector,omitempty"`
+}
+
+// PowerVSCreateClusterFileOptions are the platform options of a CreateClusterFile for `create cluster powervs`.
+type PowerVSCreateClusterFileOptions struct {
+	// ResourceGroup is the IBM Cloud resource group.
+	ResourceGroup string `json:"resource-group,omitempty"`
+	// Region is the IBM Cloud region.
+	Region string `json:"region,omitempty"`
+	// Zone is the IBM Cloud zone.
+	Zone string `json:"zone,omitempty"`
+	// CloudInstanceID is the ID of an existing PowerVS service instance to reuse.
+	CloudInstanceID string `json:"cloud-instance-id,omitempty"`
+	// CloudConnection is an existing cloud connection in the zone to reuse.
+	CloudConnection string `json:"cloud-connection,omitempty"`
+	// VPCRegion is the IBM Cloud region of the VPC resources.
+	VPCRegion string `json:"vpc-region,omitempty"`
+	// VPC is the name of an existing VPC to reuse.
+	VPC string `json:"vpc,omitempty"`
+	// SysType is the system type hosting the instances, e.g. s922, e980 or e880.
+	SysType string `json:"sys-type,omitempty"`
+	// ProcType is the processor type, one of dedicated, shared or capped.
+	ProcType hyperv1.PowerVSNodePoolProcType `json:"proc-type,omitempty"`
+	// Processors is the number of processors allocated, e.g. 0.5.
+	Processors string `json:"processors,omitempty"`
+	// Memory is the memory allocated in GB.
+	Memory *int32 `json:"memory,omitempty"`
+	// Debug logs the PowerVS API requests and responses.
+	Debug *bool `json:"debug,omitempty"`
+	// RecreateSecrets recreates the credentials of the cluster, which is required when the infrastructure is
+	// created again.
+	RecreateSecrets *bool `json:"recreate-secrets,omitempty"`
+	// PowerEdgeRouter connects PowerVS and the VPC with a transit gateway rather than a cloud connection.
+	PowerEdgeRouter *bool `json:"power-edge-router,omitempty"`
+	// TransitGatewayGlobalRouting uses the global routing mode for the created transit gateway.
+	TransitGatewayGlobalRouting *bool `json:"transit-gateway-global-routing,omitempty"`
+	// TransitGatewayLocation is the IBM Cloud location of the transit gateway.
+	TransitGatewayLocation string `json:"transit-gateway-location,omitempty"`
+	// TransitGateway is the name of an existing transit gateway to reuse.
+	TransitGateway string `json:"transit-gateway,omitempty"`
+}
+
+// NoneCreateClusterFileOptions are the platform options of a CreateClusterFile for `create cluster none`.
+type NoneCreateClusterFileOptions struct {
+	// ExternalAPIServerAddress is the external address of the API server.
+	ExternalAPIServerAddress string `json:"external-api-server-address,omitempty"`
+	// ExposeThroughLoadBalancer exposes the services with LoadBalancers rather than node ports.
+	ExposeThroughLoadBalancer *bool `json:"expose-through-load-balancer,omitempty"`
+}
+
+// createClusterFilePlatformOptions returns the platform options of a CreateClusterFile for a platform
+// subcommand of `create cluster` by its name.
+var createClusterFilePlatformOptions = map[string]func() interface{}{
+	"aws":      func() interface{} { return &AWSCreateClusterFileOptions{} },
+	"azure":    func() interface{} { return &AzureCreateClusterFileOptions{} },
+	"kubevirt": func() interface{} { return &KubeVirtCreateClusterFileOptions{} },
+	"agent":    func() interface{} { return &AgentCreateClusterFileOptions{} },
+	"powervs":  func() interface{} { return &PowerVSCreateClusterFileOptions{} },
+	"none":     func() interface{} { return &NoneCreateClusterFileOptions{} },
+}
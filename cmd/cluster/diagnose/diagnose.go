@@ -0,0 +1,795 @@
+package diagnose
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
+	"github.com/openshift/hypershift/support/api"
+	"github.com/openshift/hypershift/support/conditions"
+)
+
+const (
+	// machineProvisioningTimeout is how long a Machine may take to become a Node before it is reported.
+	machineProvisioningTimeout = 20 * time.Minute
+	// podPendingTimeout is how long a control plane pod may be pending before it is reported.
+	podPendingTimeout = 10 * time.Minute
+	// crashLoopRestarts is the number of restarts from which a running container is reported.
+	crashLoopRestarts = 5
+	// nodePoolAnnotation is set on the token secrets by the NodePool controller.
+	nodePoolAnnotation = "hypershift.openshift.io/nodePool"
+)
+
+type Options struct {
+	Namespace            string
+	Name                 string
+	Output               string
+	SkipIgnitionProbe    bool
+	SkipGuestCluster     bool
+	IgnitionProbeTimeout time.Duration
+}
+
+func NewCommand() *cobra.Command {
+	opts := &Options{
+		Namespace:            "clusters",
+		Output:               "text",
+		IgnitionProbeTimeout: 10 * time.Second,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "diagnose",
+		Short: "Checks a HostedCluster and the objects it owns for known failure signatures",
+		Long: `Checks a HostedCluster and the objects it owns for known failure signatures.
+
+The HostedCluster, its HostedControlPlane and NodePools, the control plane pods, the CAPI Cluster,
+MachineDeployments and Machines, the NodePool token secrets and the Nodes of the guest cluster are
+collected and checked against a set of rules. The findings are ranked by severity and come with a
+suggested remediation.`,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the HostedCluster")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the HostedCluster")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "The format of the report: text or json")
+	cmd.Flags().BoolVar(&opts.SkipIgnitionProbe, "skip-ignition-probe", opts.SkipIgnitionProbe, "If true, the ignition endpoint is not probed")
+	cmd.Flags().BoolVar(&opts.SkipGuestCluster, "skip-guest-cluster", opts.SkipGuestCluster, "If true, the Nodes of the guest cluster are not collected")
+	cmd.Flags().DurationVar(&opts.IgnitionProbeTimeout, "ignition-probe-timeout", opts.IgnitionProbeTimeout, "The timeout of the ignition endpoint probe")
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if opts.Output != "text" && opts.Output != "json" {
+			return fmt.Errorf("unsupported output format %q, must be text or json", opts.Output)
+		}
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		collector := &Collector{Client: c, SkipGuestCluster: opts.SkipGuestCluster}
+		if !opts.SkipIgnitionProbe {
+			collector.ProbeIgnition = httpProbe(opts.IgnitionProbeTimeout)
+		}
+		snapshot, err := collector.Collect(cmd.Context(), opts.Namespace, opts.Name)
+		if err != nil {
+			log.Log.Error(err, "Failed to collect HostedCluster state")
+			return err
+		}
+		report := Diagnose(snapshot, time.Now())
+		if opts.Output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+		return FormatReport(os.Stdout, report)
+	}
+	return cmd
+}
+
+// Snapshot is the state of a HostedCluster and the objects it owns that the rules are checked against.
+// Objects which could not be collected are recorded in CollectionErrors.
+type Snapshot struct {
+	HostedCluster      *hyperv1.HostedCluster
+	HostedControlPlane *hyperv1.HostedControlPlane
+	NodePools          []hyperv1.NodePool
+	Pods               []corev1.Pod
+	CAPICluster        *capiv1.Cluster
+	MachineDeployments []capiv1.MachineDeployment
+	Machines           []capiv1.Machine
+	TokenSecrets       []corev1.Secret
+	// Nodes are the Nodes of the guest cluster, nil when they were not collected.
+	Nodes []corev1.Node
+	// IgnitionProbeError is the result of probing the ignition endpoint, nil when it is reachable or was not probed.
+	IgnitionProbeError error
+	CollectionErrors   map[string]error
+}
+
+// Collector collects the Snapshot of a HostedCluster.
+type Collector struct {
+	Client crclient.Client
+	// ProbeIgnition checks that the ignition endpoint is reachable, it is not probed when nil.
+	ProbeIgnition func(ctx context.Context, endpoint string) error
+	// GuestClient returns a client of the guest cluster from its kubeconfig, a client is created
+	// from the kubeconfig when nil.
+	GuestClient      func(kubeconfig []byte) (crclient.Client, error)
+	SkipGuestCluster bool
+}
+
+// Collect walks the objects of a HostedCluster. Only failing to get the HostedCluster is an error,
+// other objects which can't be collected are recorded in the Snapshot.
+func (c *Collector) Collect(ctx context.Context, namespace, name string) (*Snapshot, error) {
+	hcluster := &hyperv1.HostedCluster{}
+	if err := c.Client.Get(ctx, crclient.ObjectKey{Namespace: namespace, Name: name}, hcluster); err != nil {
+		return nil, fmt.Errorf("failed to get HostedCluster %s/%s: %w", namespace, name, err)
+	}
+	snapshot := &Snapshot{HostedCluster: hcluster, CollectionErrors: map[string]error{}}
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(namespace, name)
+
+	hcp := &hyperv1.HostedControlPlane{}
+	if err := c.Client.Get(ctx, crclient.ObjectKey{Namespace: controlPlaneNamespace, Name: name}, hcp); err != nil {
+		if !apierrors.IsNotFound(err) {
+			snapshot.CollectionErrors["HostedControlPlane"] = err
+		}
+	} else {
+		snapshot.HostedControlPlane = hcp
+	}
+
+	nodePools := &hyperv1.NodePoolList{}
+	if err := c.Client.List(ctx, nodePools, crclient.InNamespace(namespace)); err != nil {
+		snapshot.CollectionErrors["NodePools"] = err
+	}
+	for _, nodePool := range nodePools.Items {
+		if nodePool.Spec.ClusterName == name {
+			snapshot.NodePools = append(snapshot.NodePools, nodePool)
+		}
+	}
+
+	pods := &corev1.PodList{}
+	if err := c.Client.List(ctx, pods, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		snapshot.CollectionErrors["Pods"] = err
+	}
+	snapshot.Pods = pods.Items
+
+	capiClusters := &capiv1.ClusterList{}
+	if err := c.Client.List(ctx, capiClusters, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		snapshot.CollectionErrors["CAPI Cluster"] = err
+	} else if len(capiClusters.Items) > 0 {
+		snapshot.CAPICluster = &capiClusters.Items[0]
+	}
+	machineDeployments := &capiv1.MachineDeploymentList{}
+	if err := c.Client.List(ctx, machineDeployments, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		snapshot.CollectionErrors["MachineDeployments"] = err
+	}
+	snapshot.MachineDeployments = machineDeployments.Items
+	machines := &capiv1.MachineList{}
+	if err := c.Client.List(ctx, machines, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		snapshot.CollectionErrors["Machines"] = err
+	}
+	snapshot.Machines = machines.Items
+
+	secrets := &corev1.SecretList{}
+	if err := c.Client.List(ctx, secrets, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		snapshot.CollectionErrors["Token secrets"] = err
+	}
+	for _, secret := range secrets.Items {
+		if secret.Annotations[nodepool.TokenSecretAnnotation] == "true" {
+			// the payload of the token secrets is not needed to diagnose them
+			secret.Data = nil
+			snapshot.TokenSecrets = append(snapshot.TokenSecrets, secret)
+		}
+	}
+
+	if c.ProbeIgnition != nil && hcluster.Status.IgnitionEndpoint != "" {
+		snapshot.IgnitionProbeError = c.ProbeIgnition(ctx, hcluster.Status.IgnitionEndpoint)
+	}
+
+	if !c.SkipGuestCluster {
+		nodes, err := c.guestNodes(ctx, hcluster)
+		if err != nil {
+			snapshot.CollectionErrors["Guest cluster Nodes"] = err
+		}
+		snapshot.Nodes = nodes
+	}
+	return snapshot, nil
+}
+
+func (c *Collector) guestNodes(ctx context.Context, hcluster *hyperv1.HostedCluster) ([]corev1.Node, error) {
+	if hcluster.Status.KubeConfig == nil {
+		return nil, fmt.Errorf("the HostedCluster has no kubeconfig yet")
+	}
+	kubeconfigSecret := &corev1.Secret{}
+	if err := c.Client.Get(ctx, crclient.ObjectKey{Namespace: hcluster.Namespace, Name: hcluster.Status.KubeConfig.Name}, kubeconfigSecret); err != nil {
+		return nil, fmt.Errorf("failed to get the kubeconfig secret: %w", err)
+	}
+	newClient := c.GuestClient
+	if newClient == nil {
+		newClient = guestClient
+	}
+	guest, err := newClient(kubeconfigSecret.Data["kubeconfig"])
+	if err != nil {
+		return nil, err
+	}
+	nodes := &corev1.NodeList{}
+	if err := guest.List(ctx, nodes); err != nil {
+		return nil, fmt.Errorf("failed to list guest cluster Nodes: %w", err)
+	}
+	return nodes.Items, nil
+}
+
+func guestClient(kubeconfig []byte) (crclient.Client, error) {
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the guest cluster kubeconfig: %w", err)
+	}
+	cfg.Timeout = 15 * time.Second
+	return crclient.New(cfg, crclient.Options{Scheme: api.Scheme})
+}
+
+// httpProbe returns a probe of the healthz endpoint of the ignition server. The certificate of the
+// ignition server is signed by the CA of the guest cluster, only reachability is checked.
+func httpProbe(timeout time.Duration) func(ctx context.Context, endpoint string) error {
+	httpClient := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	return func(ctx context.Context, endpoint string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/healthz", endpoint), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// Severity ranks findings, from the most to the least likely to block the HostedCluster.
+type Severity string
+
+const (
+	SeverityError   Severity = "Error"
+	SeverityWarning Severity = "Warning"
+	SeverityInfo    Severity = "Info"
+)
+
+var severityRank = map[Severity]int{
+	SeverityError:   0,
+	SeverityWarning: 1,
+	SeverityInfo:    2,
+}
+
+// Finding is a failure signature found by a rule.
+type Finding struct {
+	Severity    Severity `json:"severity"`
+	Check       string   `json:"check"`
+	Object      string   `json:"object"`
+	Message     string   `json:"message"`
+	Remediation string   `json:"remediation,omitempty"`
+}
+
+// Report is the ranked result of checking the rules against a Snapshot.
+type Report struct {
+	HostedCluster string    `json:"hostedCluster"`
+	Findings      []Finding `json:"findings"`
+}
+
+// rule checks a Snapshot for a failure signature.
+type rule func(snapshot *Snapshot, now time.Time) []Finding
+
+var rules = []rule{
+	collectionErrors,
+	hostedClusterConditions,
+	hostedControlPlaneConditions,
+	nodePoolConditions,
+	controlPlanePods,
+	ignitionEndpoint,
+	capiCluster,
+	machineDeployments,
+	machines,
+	tokenSecrets,
+	guestNodes,
+}
+
+// Diagnose checks the rules against a Snapshot and ranks the findings by severity.
+func Diagnose(snapshot *Snapshot, now time.Time) *Report {
+	report := &Report{HostedCluster: crclient.ObjectKeyFromObject(snapshot.HostedCluster).String(), Findings: []Finding{}}
+	for _, r := range rules {
+		report.Findings = append(report.Findings, r(snapshot, now)...)
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if severityRank[a.Severity] != severityRank[b.Severity] {
+			return severityRank[a.Severity] < severityRank[b.Severity]
+		}
+		return a.Object < b.Object
+	})
+	return report
+}
+
+func objectName(kind string, obj metav1.Object) string {
+	return fmt.Sprintf("%s %s/%s", kind, obj.GetNamespace(), obj.GetName())
+}
+
+func collectionErrors(snapshot *Snapshot, _ time.Time) []Finding {
+	var findings []Finding
+	for _, what := range sortedKeys(snapshot.CollectionErrors) {
+		findings = append(findings, Finding{
+			Severity:    SeverityWarning,
+			Check:       "Collection",
+			Object:      what,
+			Message:     fmt.Sprintf("Failed to collect %s, the checks relying on them are skipped: %v", what, snapshot.CollectionErrors[what]),
+			Remediation: "Check the permissions of the current user on the management cluster and, for the guest cluster, that its API server is reachable from here.",
+		})
+	}
+	return findings
+}
+
+// hostedClusterRemediations are the remediations of well known HostedCluster conditions, by condition type.
+var hostedClusterRemediations = map[string]string{
+	string(hyperv1.HostedClusterAvailable):               "Check the other findings, the HostedCluster is available once its control plane is.",
+	string(hyperv1.InfrastructureReady):                  "Check that the services of the control plane are published, e.g. that their load balancers and routes have been admitted.",
+	string(hyperv1.KubeAPIServerAvailable):               "Check the kube-apiserver pods and their logs in the control plane namespace.",
+	string(hyperv1.IgnitionEndpointAvailable):            "Check the ignition-server pods and the route or service which publishes them.",
+	string(hyperv1.EtcdAvailable):                        "Check the etcd pods, their logs and persistent volumes in the control plane namespace.",
+	string(hyperv1.ValidReleaseInfo):                     "Check that the release image exists and can be pulled with the pull secret of the HostedCluster.",
+	string(hyperv1.ValidReleaseImage):                    "Check that the release image is a supported version and can be pulled with the pull secret of the HostedCluster.",
+	string(hyperv1.ValidHostedClusterConfiguration):      "Fix the HostedCluster spec as described in the condition message.",
+	string(hyperv1.ValidHostedControlPlaneConfiguration): "Fix the HostedCluster spec as described in the condition message.",
+	string(hyperv1.SupportedHostedCluster):               "Check that the HyperShift operator supports the version of the HostedCluster.",
+	string(hyperv1.ClusterVersionSucceeding):             "Check the ClusterVersion and the ClusterOperators of the guest cluster.",
+	string(hyperv1.ClusterVersionAvailable):              "Check the ClusterVersion and the ClusterOperators of the guest cluster.",
+	string(hyperv1.ClusterVersionProgressing):            "Check the ClusterVersion and the ClusterOperators of the guest cluster.",
+	string(hyperv1.ClusterVersionReleaseAccepted):        "Check that the release image is signed and can be pulled from the guest cluster.",
+	string(hyperv1.ReconciliationActive):                 "Remove spec.pausedUntil to resume the reconciliation of the HostedCluster.",
+	string(hyperv1.ReconciliationSucceeded):              "Check the HyperShift operator logs for the reconciliation error.",
+	string(hyperv1.PlatformCredentialsFound):             "Check that the platform credentials of the HostedCluster exist and are valid.",
+	string(hyperv1.ValidOIDCConfiguration):               "Check that the OIDC documents of the HostedCluster have been published to the OIDC bucket.",
+	string(hyperv1.ValidAWSIdentityProvider):             "Check that the roles of the HostedCluster trust its OIDC provider and allow the required actions.",
+	string(hyperv1.AWSEndpointAvailable):                 "Check the AWSEndpointService and the VPC endpoint of the control plane.",
+	string(hyperv1.AWSEndpointServiceAvailable):          "Check the AWSEndpointService and the VPC endpoint service of the control plane.",
+}
+
+// nodePoolRemediations are the remediations of well known NodePool conditions, by condition type.
+var nodePoolRemediations = map[string]string{
+	hyperv1.NodePoolValidReleaseImageConditionType:     "Check that the release image of the NodePool exists and is compatible with the HostedCluster.",
+	hyperv1.NodePoolValidGeneratedPayloadConditionType: "Check the token secret of the NodePool and the ignition-server logs in the control plane namespace.",
+	hyperv1.NodePoolValidMachineConfigConditionType:    "Fix the config referenced by the NodePool as described in the condition message.",
+	hyperv1.NodePoolValidTuningConfigConditionType:     "Fix the tuning config referenced by the NodePool as described in the condition message.",
+	hyperv1.NodePoolAllMachinesReadyConditionType:      "Check the Machine findings and the logs of the CAPI provider in the control plane namespace.",
+	hyperv1.NodePoolAllNodesHealthyConditionType:       "Check the Node findings of the guest cluster.",
+	hyperv1.NodePoolReachedIgnitionEndpoint:            "Check that the machines can reach the ignition endpoint and review their console logs.",
+	hyperv1.NodePoolValidPlatformImageType:             "Check that a boot image is available for the release and the region of the NodePool.",
+}
+
+const defaultConditionRemediation = "Check the condition message and the logs of the HyperShift operator."
+
+func conditionRemediation(remediations map[string]string, conditionType string) string {
+	if remediation, ok := remediations[conditionType]; ok {
+		return remediation
+	}
+	return defaultConditionRemediation
+}
+
+func hostedClusterConditions(snapshot *Snapshot, _ time.Time) []Finding {
+	hcluster := snapshot.HostedCluster
+	var findings []Finding
+	expected := conditions.ExpectedHCConditions(hcluster)
+	for _, condition := range hcluster.Status.Conditions {
+		expectedStatus, known := expected[hyperv1.ConditionType(condition.Type)]
+		if !known || expectedStatus == metav1.ConditionUnknown || condition.Status == expectedStatus {
+			continue
+		}
+		severity := SeverityError
+		// progressing is expected while rolling out
+		if condition.Type == string(hyperv1.HostedClusterProgressing) || condition.Type == string(hyperv1.ClusterVersionProgressing) {
+			severity = SeverityInfo
+		}
+		findings = append(findings, Finding{
+			Severity:    severity,
+			Check:       "HostedClusterCondition",
+			Object:      objectName("HostedCluster", hcluster),
+			Message:     fmt.Sprintf("%s is %s: %s: %s", condition.Type, condition.Status, condition.Reason, condition.Message),
+			Remediation: conditionRemediation(hostedClusterRemediations, condition.Type),
+		})
+	}
+	return findings
+}
+
+func hostedControlPlaneConditions(snapshot *Snapshot, _ time.Time) []Finding {
+	hcp := snapshot.HostedControlPlane
+	if hcp == nil {
+		if _, failed := snapshot.CollectionErrors["HostedControlPlane"]; failed || snapshot.HostedCluster.DeletionTimestamp != nil {
+			return nil
+		}
+		return []Finding{{
+			Severity:    SeverityError,
+			Check:       "HostedControlPlaneExists",
+			Object:      objectName("HostedCluster", snapshot.HostedCluster),
+			Message:     "The HostedControlPlane does not exist.",
+			Remediation: "Check the HyperShift operator logs and the ReconciliationSucceeded condition of the HostedCluster.",
+		}}
+	}
+	var findings []Finding
+	if condition := meta.FindStatusCondition(hcp.Status.Conditions, string(hyperv1.HostedControlPlaneAvailable)); condition != nil && condition.Status == metav1.ConditionFalse {
+		findings = append(findings, Finding{
+			Severity:    SeverityError,
+			Check:       "HostedControlPlaneCondition",
+			Object:      objectName("HostedControlPlane", hcp),
+			Message:     fmt.Sprintf("Available is False: %s: %s", condition.Reason, condition.Message),
+			Remediation: "Check the control plane pod findings and the control-plane-operator logs.",
+		})
+	}
+	if condition := meta.FindStatusCondition(hcp.Status.Conditions, string(hyperv1.HostedControlPlaneDegraded)); condition != nil && condition.Status == metav1.ConditionTrue {
+		findings = append(findings, Finding{
+			Severity:    SeverityWarning,
+			Check:       "HostedControlPlaneCondition",
+			Object:      objectName("HostedControlPlane", hcp),
+			Message:     fmt.Sprintf("Degraded is True: %s: %s", condition.Reason, condition.Message),
+			Remediation: "Check the control plane pod findings and the control-plane-operator logs.",
+		})
+	}
+	return findings
+}
+
+func nodePoolConditions(snapshot *Snapshot, _ time.Time) []Finding {
+	var findings []Finding
+	for i := range snapshot.NodePools {
+		nodePool := &snapshot.NodePools[i]
+		expected := conditions.ExpectedNodePoolConditions(nodePool)
+		for _, condition := range nodePool.Status.Conditions {
+			expectedStatus, known := expected[condition.Type]
+			if !known || condition.Status == expectedStatus {
+				continue
+			}
+			severity := SeverityError
+			switch condition.Type {
+			case hyperv1.NodePoolUpdatingVersionConditionType, hyperv1.NodePoolUpdatingConfigConditionType, hyperv1.NodePoolUpdatingPlatformMachineTemplateConditionType:
+				// updating is expected while rolling out
+				severity = SeverityInfo
+			case hyperv1.NodePoolAutoscalingEnabledConditionType, hyperv1.NodePoolAutorepairEnabledConditionType:
+				// these reflect the spec
+				continue
+			}
+			findings = append(findings, Finding{
+				Severity:    severity,
+				Check:       "NodePoolCondition",
+				Object:      objectName("NodePool", nodePool),
+				Message:     fmt.Sprintf("%s is %s: %s: %s", condition.Type, condition.Status, condition.Reason, condition.Message),
+				Remediation: conditionRemediation(nodePoolRemediations, condition.Type),
+			})
+		}
+	}
+	return findings
+}
+
+// failingWaitingReasons are the reasons of waiting containers which don't recover on their own.
+var failingWaitingReasons = map[string]string{
+	"CrashLoopBackOff":           "Check the logs of the previous run of the container, e.g. with `oc logs --previous`.",
+	"ImagePullBackOff":           "Check that the image exists and can be pulled with the pull secret of the HostedCluster.",
+	"ErrImagePull":               "Check that the image exists and can be pulled with the pull secret of the HostedCluster.",
+	"CreateContainerConfigError": "Check that the secrets and config maps mounted by the container exist.",
+	"CreateContainerError":       "Check the events of the pod for the reason the container could not be created.",
+	"InvalidImageName":           "Check the image references of the release of the HostedCluster.",
+}
+
+func controlPlanePods(snapshot *Snapshot, now time.Time) []Finding {
+	var findings []Finding
+	for i := range snapshot.Pods {
+		pod := &snapshot.Pods[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodPending && now.Sub(pod.CreationTimestamp.Time) > podPendingTimeout {
+			message := fmt.Sprintf("The pod has been pending since %s", pod.CreationTimestamp.UTC().Format(time.RFC3339))
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+					message = fmt.Sprintf("%s: %s", message, condition.Message)
+				}
+			}
+			findings = append(findings, Finding{
+				Severity:    SeverityError,
+				Check:       "ControlPlanePodPending",
+				Object:      objectName("Pod", pod),
+				Message:     message,
+				Remediation: "Check the capacity, taints and labels of the management cluster nodes dedicated to control planes and the persistent volume claims of the pod.",
+			})
+		}
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if waiting := status.State.Waiting; waiting != nil {
+				if remediation, failing := failingWaitingReasons[waiting.Reason]; failing {
+					findings = append(findings, Finding{
+						Severity:    SeverityError,
+						Check:       "ControlPlaneContainer",
+						Object:      objectName("Pod", pod),
+						Message:     fmt.Sprintf("Container %s is waiting with %s after %d restarts: %s", status.Name, waiting.Reason, status.RestartCount, waiting.Message),
+						Remediation: remediation,
+					})
+					continue
+				}
+			}
+			if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+				findings = append(findings, Finding{
+					Severity:    SeverityWarning,
+					Check:       "ControlPlaneContainer",
+					Object:      objectName("Pod", pod),
+					Message:     fmt.Sprintf("Container %s was OOMKilled at %s", status.Name, terminated.FinishedAt.UTC().Format(time.RFC3339)),
+					Remediation: "Check the memory usage of the container and the resource requests of the control plane.",
+				})
+			} else if status.RestartCount >= crashLoopRestarts {
+				findings = append(findings, Finding{
+					Severity:    SeverityWarning,
+					Check:       "ControlPlaneContainer",
+					Object:      objectName("Pod", pod),
+					Message:     fmt.Sprintf("Container %s has restarted %d times", status.Name, status.RestartCount),
+					Remediation: "Check the logs of the previous run of the container, e.g. with `oc logs --previous`.",
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func ignitionEndpoint(snapshot *Snapshot, _ time.Time) []Finding {
+	hcluster := snapshot.HostedCluster
+	if snapshot.IgnitionProbeError == nil {
+		return nil
+	}
+	return []Finding{{
+		Severity:    SeverityError,
+		Check:       "IgnitionEndpointReachable",
+		Object:      objectName("HostedCluster", hcluster),
+		Message:     fmt.Sprintf("The ignition endpoint %s is not reachable from here: %v", hcluster.Status.IgnitionEndpoint, snapshot.IgnitionProbeError),
+		Remediation: "Check the ignition-server pods, the route or service which publishes them and that the endpoint resolves. Private clusters are only reachable from their VPC.",
+	}}
+}
+
+func capiCluster(snapshot *Snapshot, _ time.Time) []Finding {
+	cluster := snapshot.CAPICluster
+	if cluster == nil {
+		return nil
+	}
+	var findings []Finding
+	if cluster.Status.FailureMessage != nil {
+		findings = append(findings, Finding{
+			Severity:    SeverityError,
+			Check:       "CAPICluster",
+			Object:      objectName("Cluster", cluster),
+			Message:     fmt.Sprintf("The CAPI Cluster failed: %s", *cluster.Status.FailureMessage),
+			Remediation: "Check the logs of the cluster-api and the CAPI provider deployments in the control plane namespace.",
+		})
+	}
+	if condition := capiCondition(cluster.Status.Conditions, capiv1.InfrastructureReadyCondition); condition != nil && condition.Status == corev1.ConditionFalse {
+		findings = append(findings, Finding{
+			Severity:    SeverityError,
+			Check:       "CAPICluster",
+			Object:      objectName("Cluster", cluster),
+			Message:     fmt.Sprintf("InfrastructureReady is False: %s: %s", condition.Reason, condition.Message),
+			Remediation: "Check the infrastructure cluster object and the logs of the CAPI provider in the control plane namespace.",
+		})
+	}
+	return findings
+}
+
+func machineDeployments(snapshot *Snapshot, _ time.Time) []Finding {
+	var findings []Finding
+	for i := range snapshot.MachineDeployments {
+		md := &snapshot.MachineDeployments[i]
+		if condition := capiCondition(md.Status.Conditions, capiv1.MachineDeploymentAvailableCondition); condition != nil && condition.Status == corev1.ConditionFalse {
+			findings = append(findings, Finding{
+				Severity:    SeverityWarning,
+				Check:       "MachineDeploymentAvailable",
+				Object:      objectName("MachineDeployment", md),
+				Message:     fmt.Sprintf("%d of %d replicas are available: %s: %s", md.Status.AvailableReplicas, md.Status.Replicas, condition.Reason, condition.Message),
+				Remediation: "Check the Machine findings of the MachineDeployment.",
+			})
+		}
+	}
+	return findings
+}
+
+func machines(snapshot *Snapshot, now time.Time) []Finding {
+	var findings []Finding
+	for i := range snapshot.Machines {
+		machine := &snapshot.Machines[i]
+		if machine.DeletionTimestamp != nil {
+			continue
+		}
+		if machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil {
+			var reason, message string
+			if machine.Status.FailureReason != nil {
+				reason = string(*machine.Status.FailureReason)
+			}
+			if machine.Status.FailureMessage != nil {
+				message = *machine.Status.FailureMessage
+			}
+			findings = append(findings, Finding{
+				Severity:    SeverityError,
+				Check:       "MachineFailed",
+				Object:      objectName("Machine", machine),
+				Message:     fmt.Sprintf("The Machine failed: %s: %s", reason, message),
+				Remediation: "Check the quota and the instance type of the NodePool platform and the logs of the CAPI provider. With autoRepair enabled the Machine is replaced.",
+			})
+			continue
+		}
+		if machine.Status.NodeRef == nil && now.Sub(machine.CreationTimestamp.Time) > machineProvisioningTimeout {
+			message := fmt.Sprintf("The Machine was created at %s and is %s but has not become a Node", machine.CreationTimestamp.UTC().Format(time.RFC3339), machine.Status.Phase)
+			remediation := "The instance did not join the guest cluster. Check its console logs for ignition errors, e.g. with `hypershift console-logs`, and that it can reach the ignition endpoint and the API server."
+			if condition := capiCondition(machine.Status.Conditions, capiv1.InfrastructureReadyCondition); condition != nil && condition.Status == corev1.ConditionFalse {
+				message = fmt.Sprintf("%s, InfrastructureReady is False: %s: %s", message, condition.Reason, condition.Message)
+				remediation = "The instance was not created. Check the quota and the instance type of the NodePool platform and the logs of the CAPI provider."
+			}
+			findings = append(findings, Finding{
+				Severity:    SeverityError,
+				Check:       "MachineNotNode",
+				Object:      objectName("Machine", machine),
+				Message:     message,
+				Remediation: remediation,
+			})
+		}
+	}
+	return findings
+}
+
+func tokenSecrets(snapshot *Snapshot, now time.Time) []Finding {
+	// machines still provisioning use the token of the config they were created with
+	provisioning := map[string]int{}
+	for _, machine := range snapshot.Machines {
+		if machine.Status.NodeRef == nil && machine.DeletionTimestamp == nil {
+			provisioning[machine.Labels[capiv1.MachineDeploymentNameLabel]]++
+		}
+	}
+	if _, failed := snapshot.CollectionErrors["Token secrets"]; failed {
+		return nil
+	}
+	active := map[string]bool{}
+	var findings []Finding
+	for i := range snapshot.TokenSecrets {
+		secret := &snapshot.TokenSecrets[i]
+		nodePoolName := secret.Annotations[nodePoolAnnotation]
+		expiration, expiring := secret.Annotations[hyperv1.IgnitionServerTokenExpirationTimestampAnnotation]
+		if !expiring {
+			active[nodePoolName] = true
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339, expiration)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity:    SeverityWarning,
+				Check:       "TokenSecretExpiration",
+				Object:      objectName("Secret", secret),
+				Message:     fmt.Sprintf("The token expiration timestamp %q is invalid: %v", expiration, err),
+				Remediation: "Delete the token secret, the NodePool controller generates a new one for the current config.",
+			})
+			continue
+		}
+		if expiresAt.After(now) {
+			continue
+		}
+		_, name, _ := strings.Cut(nodePoolName, "/")
+		if count := provisioning[name]; count > 0 {
+			findings = append(findings, Finding{
+				Severity:    SeverityError,
+				Check:       "TokenSecretExpired",
+				Object:      objectName("Secret", secret),
+				Message:     fmt.Sprintf("The token of NodePool %s expired at %s while %d of its Machines are still provisioning, they can't fetch their ignition config if they were created with it", nodePoolName, expiresAt.UTC().Format(time.RFC3339), count),
+				Remediation: "Delete the Machines which have not become Nodes, the NodePool recreates them with the current token.",
+			})
+		}
+	}
+	for i := range snapshot.NodePools {
+		nodePool := &snapshot.NodePools[i]
+		key := crclient.ObjectKeyFromObject(nodePool).String()
+		if active[key] || nodePool.DeletionTimestamp != nil {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity:    SeverityWarning,
+			Check:       "TokenSecretExists",
+			Object:      objectName("NodePool", nodePool),
+			Message:     "The NodePool has no active token secret, new Machines can't fetch their ignition config.",
+			Remediation: "Check the ValidGeneratedPayload condition of the NodePool and the HyperShift operator logs.",
+		})
+	}
+	return findings
+}
+
+func guestNodes(snapshot *Snapshot, _ time.Time) []Finding {
+	var findings []Finding
+	for i := range snapshot.Nodes {
+		node := &snapshot.Nodes[i]
+		for _, condition := range node.Status.Conditions {
+			if condition.Type != corev1.NodeReady || condition.Status == corev1.ConditionTrue {
+				continue
+			}
+			findings = append(findings, Finding{
+				Severity:    SeverityError,
+				Check:       "NodeReady",
+				Object:      fmt.Sprintf("Node %s (NodePool %s)", node.Name, valueOrNone(node.Labels[hyperv1.NodePoolLabel])),
+				Message:     fmt.Sprintf("The Node has been NotReady since %s: %s: %s", condition.LastTransitionTime.UTC().Format(time.RFC3339), condition.Reason, condition.Message),
+				Remediation: "Check the kubelet and the network pods of the Node in the guest cluster. With autoRepair enabled the Machine is replaced.",
+			})
+		}
+	}
+	return findings
+}
+
+func capiCondition(conditions capiv1.Conditions, conditionType capiv1.ConditionType) *capiv1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func sortedKeys(m map[string]error) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FormatReport prints a human readable report of the findings.
+func FormatReport(out io.Writer, report *Report) error {
+	fmt.Fprintf(out, "Diagnosis of HostedCluster %s\n\n", report.HostedCluster)
+	if len(report.Findings) == 0 {
+		fmt.Fprintln(out, "No known failure signature was found.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tCHECK\tOBJECT\tMESSAGE")
+	for _, finding := range report.Findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", finding.Severity, finding.Check, finding.Object, finding.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "\nSuggested remediations:")
+	seen := map[string]bool{}
+	for _, finding := range report.Findings {
+		key := finding.Object + "\x00" + finding.Remediation
+		if finding.Remediation == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		fmt.Fprintf(out, "  %s (%s): %s\n", finding.Object, finding.Check, finding.Remediation)
+	}
+	return nil
+}
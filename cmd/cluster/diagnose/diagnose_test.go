@@ -0,0 +1,263 @@
+package diagnose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
+	"github.com/openshift/hypershift/support/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func hostedCluster() *hyperv1.HostedCluster {
+	return &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"},
+		Spec:       hyperv1.HostedClusterSpec{Platform: hyperv1.PlatformSpec{Type: hyperv1.NonePlatform}},
+		Status: hyperv1.HostedClusterStatus{
+			IgnitionEndpoint: "ignition.example.com",
+			KubeConfig:       &corev1.LocalObjectReference{Name: "hc-admin-kubeconfig"},
+		},
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	created := metav1.NewTime(now.Add(-time.Hour))
+	testCases := []struct {
+		name     string
+		snapshot *Snapshot
+		expected []Finding
+	}{
+		{
+			name: "When everything is healthy it should report nothing",
+			snapshot: &Snapshot{
+				HostedCluster: func() *hyperv1.HostedCluster {
+					hc := hostedCluster()
+					hc.Status.Conditions = []metav1.Condition{
+						{Type: string(hyperv1.HostedClusterAvailable), Status: metav1.ConditionTrue},
+						{Type: string(hyperv1.HostedClusterDegraded), Status: metav1.ConditionFalse},
+					}
+					return hc
+				}(),
+				HostedControlPlane: &hyperv1.HostedControlPlane{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "hc"}},
+				NodePools:          []hyperv1.NodePool{{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "np"}}},
+				TokenSecrets: []corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "token-np-1", Annotations: map[string]string{
+					nodePoolAnnotation: "clusters/np",
+				}}}},
+				Nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node"}, Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				}}}},
+			},
+		},
+		{
+			name: "When conditions are unexpected it should report them with their remediation and rank progressing last",
+			snapshot: &Snapshot{
+				HostedCluster: func() *hyperv1.HostedCluster {
+					hc := hostedCluster()
+					hc.Status.Conditions = []metav1.Condition{
+						{Type: string(hyperv1.HostedClusterProgressing), Status: metav1.ConditionTrue, Reason: "Rollout", Message: "rolling out"},
+						{Type: string(hyperv1.ValidReleaseImage), Status: metav1.ConditionFalse, Reason: "InvalidImage", Message: "not found"},
+						{Type: "Unknown", Status: metav1.ConditionFalse},
+					}
+					return hc
+				}(),
+				HostedControlPlane: &hyperv1.HostedControlPlane{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "hc"}, Status: hyperv1.HostedControlPlaneStatus{
+					Conditions: []metav1.Condition{{Type: string(hyperv1.HostedControlPlaneDegraded), Status: metav1.ConditionTrue, Reason: "Unavailable", Message: "etcd"}},
+				}},
+				NodePools: []hyperv1.NodePool{{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "np"}, Status: hyperv1.NodePoolStatus{Conditions: []hyperv1.NodePoolCondition{
+					{Type: hyperv1.NodePoolReachedIgnitionEndpoint, Status: corev1.ConditionFalse, Reason: "Timeout", Message: "no machine reached it"},
+					{Type: hyperv1.NodePoolAutoscalingEnabledConditionType, Status: corev1.ConditionTrue},
+				}}}},
+			},
+			expected: []Finding{
+				{Severity: SeverityError, Check: "HostedClusterCondition", Object: "HostedCluster clusters/hc", Message: "ValidReleaseImage is False: InvalidImage: not found", Remediation: hostedClusterRemediations[string(hyperv1.ValidReleaseImage)]},
+				{Severity: SeverityError, Check: "NodePoolCondition", Object: "NodePool clusters/np", Message: "ReachedIgnitionEndpoint is False: Timeout: no machine reached it", Remediation: nodePoolRemediations[hyperv1.NodePoolReachedIgnitionEndpoint]},
+				{Severity: SeverityWarning, Check: "HostedControlPlaneCondition", Object: "HostedControlPlane clusters-hc/hc", Message: "Degraded is True: Unavailable: etcd", Remediation: "Check the control plane pod findings and the control-plane-operator logs."},
+				{Severity: SeverityWarning, Check: "TokenSecretExists", Object: "NodePool clusters/np", Message: "The NodePool has no active token secret, new Machines can't fetch their ignition config.", Remediation: "Check the ValidGeneratedPayload condition of the NodePool and the HyperShift operator logs."},
+				{Severity: SeverityInfo, Check: "HostedClusterCondition", Object: "HostedCluster clusters/hc", Message: "Progressing is True: Rollout: rolling out", Remediation: defaultConditionRemediation},
+			},
+		},
+		{
+			name: "When control plane pods fail it should report them",
+			snapshot: &Snapshot{
+				HostedCluster:      hostedCluster(),
+				HostedControlPlane: &hyperv1.HostedControlPlane{},
+				Pods: []corev1.Pod{
+					{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "kube-apiserver"},
+						Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{
+							{Name: "kube-apiserver", RestartCount: 12, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off"}}},
+							{Name: "audit-logs", RestartCount: 6},
+						}},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "etcd-0", CreationTimestamp: created},
+						Status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: []corev1.PodCondition{
+							{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Message: "0/3 nodes are available"},
+						}},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "etcd-1", CreationTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+						Status:     corev1.PodStatus{Phase: corev1.PodPending},
+					},
+				},
+			},
+			expected: []Finding{
+				{Severity: SeverityError, Check: "ControlPlanePodPending", Object: "Pod clusters-hc/etcd-0", Message: "The pod has been pending since 2024-03-01T11:00:00Z: 0/3 nodes are available", Remediation: "Check the capacity, taints and labels of the management cluster nodes dedicated to control planes and the persistent volume claims of the pod."},
+				{Severity: SeverityError, Check: "ControlPlaneContainer", Object: "Pod clusters-hc/kube-apiserver", Message: "Container kube-apiserver is waiting with CrashLoopBackOff after 12 restarts: back-off", Remediation: failingWaitingReasons["CrashLoopBackOff"]},
+				{Severity: SeverityWarning, Check: "ControlPlaneContainer", Object: "Pod clusters-hc/kube-apiserver", Message: "Container audit-logs has restarted 6 times", Remediation: "Check the logs of the previous run of the container, e.g. with `oc logs --previous`."},
+			},
+		},
+		{
+			name: "When machines don't become nodes it should report them and the expired token they may use",
+			snapshot: &Snapshot{
+				HostedCluster:      hostedCluster(),
+				HostedControlPlane: &hyperv1.HostedControlPlane{},
+				IgnitionProbeError: fmt.Errorf("connection refused"),
+				NodePools:          []hyperv1.NodePool{{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "np"}}},
+				Machines: []capiv1.Machine{
+					{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "np-a", CreationTimestamp: created, Labels: map[string]string{capiv1.MachineDeploymentNameLabel: "np"}},
+						Status:     capiv1.MachineStatus{Phase: "Provisioned"},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "np-b", CreationTimestamp: created, Labels: map[string]string{capiv1.MachineDeploymentNameLabel: "np"}},
+						Status: capiv1.MachineStatus{
+							FailureReason:  ptr.To(capierrors.CreateMachineError),
+							FailureMessage: ptr.To("InsufficientInstanceCapacity"),
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "np-c", CreationTimestamp: created, Labels: map[string]string{capiv1.MachineDeploymentNameLabel: "np"}},
+						Status:     capiv1.MachineStatus{Phase: "Running", NodeRef: &corev1.ObjectReference{Name: "node"}},
+					},
+				},
+				TokenSecrets: []corev1.Secret{
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "token-np-1", Annotations: map[string]string{
+						nodePoolAnnotation: "clusters/np",
+					}}},
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "token-np-0", Annotations: map[string]string{
+						nodePoolAnnotation: "clusters/np",
+						hyperv1.IgnitionServerTokenExpirationTimestampAnnotation: now.Add(-time.Minute).Format(time.RFC3339),
+					}}},
+				},
+				Nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{hyperv1.NodePoolLabel: "np"}}, Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Reason: "KubeletNotReady", Message: "network plugin not ready", LastTransitionTime: created},
+				}}}},
+			},
+			expected: []Finding{
+				{Severity: SeverityError, Check: "IgnitionEndpointReachable", Object: "HostedCluster clusters/hc", Message: "The ignition endpoint ignition.example.com is not reachable from here: connection refused", Remediation: "Check the ignition-server pods, the route or service which publishes them and that the endpoint resolves. Private clusters are only reachable from their VPC."},
+				{Severity: SeverityError, Check: "MachineNotNode", Object: "Machine clusters-hc/np-a", Message: "The Machine was created at 2024-03-01T11:00:00Z and is Provisioned but has not become a Node", Remediation: "The instance did not join the guest cluster. Check its console logs for ignition errors, e.g. with `hypershift console-logs`, and that it can reach the ignition endpoint and the API server."},
+				{Severity: SeverityError, Check: "MachineFailed", Object: "Machine clusters-hc/np-b", Message: "The Machine failed: CreateError: InsufficientInstanceCapacity", Remediation: "Check the quota and the instance type of the NodePool platform and the logs of the CAPI provider. With autoRepair enabled the Machine is replaced."},
+				{Severity: SeverityError, Check: "NodeReady", Object: "Node node (NodePool np)", Message: "The Node has been NotReady since 2024-03-01T11:00:00Z: KubeletNotReady: network plugin not ready", Remediation: "Check the kubelet and the network pods of the Node in the guest cluster. With autoRepair enabled the Machine is replaced."},
+				{Severity: SeverityError, Check: "TokenSecretExpired", Object: "Secret clusters-hc/token-np-0", Message: "The token of NodePool clusters/np expired at 2024-03-01T11:59:00Z while 2 of its Machines are still provisioning, they can't fetch their ignition config if they were created with it", Remediation: "Delete the Machines which have not become Nodes, the NodePool recreates them with the current token."},
+			},
+		},
+		{
+			name: "When the HostedControlPlane is missing and objects could not be collected it should report it",
+			snapshot: &Snapshot{
+				HostedCluster:    hostedCluster(),
+				NodePools:        []hyperv1.NodePool{{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "np"}}},
+				CollectionErrors: map[string]error{"Token secrets": fmt.Errorf("forbidden")},
+			},
+			expected: []Finding{
+				{Severity: SeverityError, Check: "HostedControlPlaneExists", Object: "HostedCluster clusters/hc", Message: "The HostedControlPlane does not exist.", Remediation: "Check the HyperShift operator logs and the ReconciliationSucceeded condition of the HostedCluster."},
+				{Severity: SeverityWarning, Check: "Collection", Object: "Token secrets", Message: "Failed to collect Token secrets, the checks relying on them are skipped: forbidden", Remediation: "Check the permissions of the current user on the management cluster and, for the guest cluster, that its API server is reachable from here."},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			report := Diagnose(tc.snapshot, now)
+			g.Expect(report.HostedCluster).To(Equal("clusters/hc"))
+			if tc.expected == nil {
+				g.Expect(report.Findings).To(BeEmpty())
+				return
+			}
+			g.Expect(report.Findings).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	g := NewWithT(t)
+	hc := hostedCluster()
+	objects := []crclient.Object{
+		hc,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc-admin-kubeconfig"}, Data: map[string][]byte{"kubeconfig": []byte("guest")}},
+		&hyperv1.HostedControlPlane{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "hc"}},
+		&hyperv1.NodePool{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "np"}, Spec: hyperv1.NodePoolSpec{ClusterName: "hc"}},
+		&hyperv1.NodePool{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "other"}, Spec: hyperv1.NodePoolSpec{ClusterName: "other"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "kube-apiserver"}},
+		&capiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "hc-infra"}},
+		&capiv1.MachineDeployment{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "np"}},
+		&capiv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "np-a"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "token-np-1", Annotations: map[string]string{nodepool.TokenSecretAnnotation: "true"}}, Data: map[string][]byte{"token": []byte("secret")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "pull-secret"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects...).Build()
+	guest := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}).Build()
+
+	var probed string
+	collector := &Collector{
+		Client: c,
+		ProbeIgnition: func(_ context.Context, endpoint string) error {
+			probed = endpoint
+			return nil
+		},
+		GuestClient: func(kubeconfig []byte) (crclient.Client, error) {
+			g.Expect(string(kubeconfig)).To(Equal("guest"))
+			return guest, nil
+		},
+	}
+	snapshot, err := collector.Collect(context.Background(), "clusters", "hc")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(snapshot.CollectionErrors).To(BeEmpty())
+	g.Expect(snapshot.HostedControlPlane).ToNot(BeNil())
+	g.Expect(snapshot.NodePools).To(HaveLen(1))
+	g.Expect(snapshot.Pods).To(HaveLen(1))
+	g.Expect(snapshot.CAPICluster).ToNot(BeNil())
+	g.Expect(snapshot.MachineDeployments).To(HaveLen(1))
+	g.Expect(snapshot.Machines).To(HaveLen(1))
+	g.Expect(snapshot.TokenSecrets).To(HaveLen(1))
+	g.Expect(snapshot.TokenSecrets[0].Data).To(BeNil())
+	g.Expect(snapshot.Nodes).To(HaveLen(1))
+	g.Expect(probed).To(Equal("ignition.example.com"))
+
+	_, err = collector.Collect(context.Background(), "clusters", "missing")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestFormatReport(t *testing.T) {
+	g := NewWithT(t)
+	report := &Report{HostedCluster: "clusters/hc", Findings: []Finding{
+		{Severity: SeverityError, Check: "MachineFailed", Object: "Machine clusters-hc/np-a", Message: "failed", Remediation: "Replace it."},
+		{Severity: SeverityError, Check: "MachineNotNode", Object: "Machine clusters-hc/np-a", Message: "not a node", Remediation: "Replace it."},
+	}}
+	out := &bytes.Buffer{}
+	g.Expect(FormatReport(out, report)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Error     MachineFailed"))
+	g.Expect(bytes.Count(out.Bytes(), []byte("Replace it."))).To(Equal(1))
+
+	out.Reset()
+	g.Expect(FormatReport(out, &Report{HostedCluster: "clusters/hc"})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("No known failure signature was found."))
+
+	encoded, err := json.Marshal(report)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(encoded)).To(ContainSubstring(`"severity":"Error","check":"MachineFailed"`))
+}
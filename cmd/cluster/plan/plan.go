@@ -0,0 +1,386 @@
+package plan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedcluster"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	supportutil "github.com/openshift/hypershift/support/util"
+)
+
+type Options struct {
+	File                    string
+	HyperShiftNamespace     string
+	HyperShiftOperatorImage string
+}
+
+func NewCommand() *cobra.Command {
+	opts := &Options{
+		HyperShiftNamespace: "hypershift",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Shows what applying a HostedCluster and its NodePools would roll out, without applying anything",
+		Long: `Shows what applying a HostedCluster and its NodePools would roll out, without applying anything.
+
+The proposed HostedCluster and NodePools are validated with a server-side dry-run, and the
+reconcile logic of the HostedCluster and NodePool controllers is run against them to list the
+changed HostedControlPlane fields and the NodePools which get a new version, config, payload
+config hash or machine template.
+
+The kube-scheduler, kube-controller-manager and cluster-policy-controller pod templates are
+rendered with each changed field, the rollout of the other control plane workloads is an
+estimate from the components known to consume the field. NodePool config and payload hashes
+use the core ignition config currently rendered by the control plane. Changes to fields the
+control plane renders into it, such as spec.sshKey, spec.fips or spec.imageContentSources,
+are listed on the NodePools, which roll out once the control plane has updated it.`,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&opts.File, "file", "f", opts.File, "A file with the proposed HostedCluster and optionally its NodePools")
+	cmd.Flags().StringVar(&opts.HyperShiftNamespace, "hypershift-namespace", opts.HyperShiftNamespace, "The namespace of the HyperShift operator, used to look up its image")
+	cmd.Flags().StringVar(&opts.HyperShiftOperatorImage, "hypershift-operator-image", opts.HyperShiftOperatorImage, "The image of the HyperShift operator, looked up from its Deployment by default")
+	_ = cmd.MarkFlagRequired("file")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		content, err := os.ReadFile(opts.File)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", opts.File, err)
+		}
+		hostedCluster, nodePools, err := ParseProposal(content)
+		if err != nil {
+			return err
+		}
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if opts.HyperShiftOperatorImage == "" {
+			if opts.HyperShiftOperatorImage, err = operatorImage(cmd.Context(), c, opts.HyperShiftNamespace); err != nil {
+				return err
+			}
+		}
+		planner := &Planner{
+			Client: c,
+			NodePoolReconciler: &nodepool.NodePoolReconciler{
+				Client:                  c,
+				ReleaseProvider:         &releaseinfo.CachedProvider{Inner: &releaseinfo.RegistryClientProvider{}, Cache: map[string]*releaseinfo.ReleaseImage{}},
+				HypershiftOperatorImage: opts.HyperShiftOperatorImage,
+				ImageMetadataProvider:   &supportutil.RegistryClientImageMetadataProvider{},
+			},
+		}
+		plan, err := planner.Plan(cmd.Context(), hostedCluster, nodePools)
+		if err != nil {
+			log.Log.Error(err, "Failed to plan HostedCluster changes")
+			return err
+		}
+		return FormatPlan(os.Stdout, plan)
+	}
+	return cmd
+}
+
+// ParseProposal reads the proposed HostedCluster and NodePools from a YAML or JSON stream,
+// such as the output of `hypershift create cluster --render`. Other objects are ignored.
+func ParseProposal(content []byte) (*hyperv1.HostedCluster, []*hyperv1.NodePool, error) {
+	var hostedCluster *hyperv1.HostedCluster
+	var nodePools []*hyperv1.NodePool
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+	for {
+		object := &unstructured.Unstructured{}
+		if err := decoder.Decode(&object.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, fmt.Errorf("failed to decode proposal: %w", err)
+		}
+		if len(object.Object) == 0 || object.GroupVersionKind().Group != hyperv1.GroupVersion.Group {
+			continue
+		}
+		switch object.GetKind() {
+		case "HostedCluster":
+			if hostedCluster != nil {
+				return nil, nil, fmt.Errorf("the proposal contains more than one HostedCluster")
+			}
+			hostedCluster = &hyperv1.HostedCluster{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, hostedCluster); err != nil {
+				return nil, nil, fmt.Errorf("failed to decode HostedCluster: %w", err)
+			}
+		case "NodePool":
+			nodePool := &hyperv1.NodePool{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, nodePool); err != nil {
+				return nil, nil, fmt.Errorf("failed to decode NodePool: %w", err)
+			}
+			nodePools = append(nodePools, nodePool)
+		}
+	}
+	if hostedCluster == nil {
+		return nil, nil, fmt.Errorf("the proposal does not contain a HostedCluster")
+	}
+	for _, nodePool := range nodePools {
+		if nodePool.Namespace != hostedCluster.Namespace || nodePool.Spec.ClusterName != hostedCluster.Name {
+			return nil, nil, fmt.Errorf("NodePool %s/%s does not belong to HostedCluster %s/%s", nodePool.Namespace, nodePool.Name, hostedCluster.Namespace, hostedCluster.Name)
+		}
+	}
+	return hostedCluster, nodePools, nil
+}
+
+// Planner computes the Plan of a proposed HostedCluster against the cluster.
+type Planner struct {
+	Client             crclient.Client
+	NodePoolReconciler *nodepool.NodePoolReconciler
+}
+
+// Plan is the impact of applying a proposed HostedCluster and NodePools.
+type Plan struct {
+	HostedCluster       crclient.ObjectKey
+	ControlPlaneChanges []hostedcluster.ControlPlaneChange
+	// ControlPlaneRollouts are the existing control plane workloads of the components rendered
+	// to roll out with ControlPlaneChanges.
+	ControlPlaneRollouts []string
+	// EstimatedControlPlaneRollouts are the existing control plane workloads of the components
+	// which are not rendered and are estimated to roll out with ControlPlaneChanges.
+	EstimatedControlPlaneRollouts []string
+	NodePools                     []NodePoolPlan
+}
+
+// NodePoolPlan is the rollout planned for a single NodePool.
+type NodePoolPlan struct {
+	Name    string
+	Rollout *nodepool.RolloutPlan
+	Error   string
+}
+
+// Plan validates the proposed HostedCluster and NodePools with a server-side dry-run and
+// computes what they would roll out.
+func (p *Planner) Plan(ctx context.Context, proposedHC *hyperv1.HostedCluster, proposedNodePools []*hyperv1.NodePool) (*Plan, error) {
+	currentHC := &hyperv1.HostedCluster{}
+	if err := p.Client.Get(ctx, crclient.ObjectKeyFromObject(proposedHC), currentHC); err != nil {
+		return nil, fmt.Errorf("failed to get HostedCluster %s, only changes to existing HostedClusters can be planned: %w", crclient.ObjectKeyFromObject(proposedHC), err)
+	}
+
+	// The dry-run runs admission and returns the defaulted object, the status is kept from the cluster.
+	proposedHC = proposedHC.DeepCopy()
+	proposedHC.ResourceVersion = currentHC.ResourceVersion
+	if err := p.Client.Update(ctx, proposedHC, crclient.DryRunAll); err != nil {
+		return nil, fmt.Errorf("the proposed HostedCluster would be rejected: %w", err)
+	}
+	proposedHC.Status = currentHC.Status
+
+	plan := &Plan{HostedCluster: crclient.ObjectKeyFromObject(currentHC)}
+	changes, err := hostedcluster.PlanControlPlaneChanges(ctx, currentHC, proposedHC)
+	if err != nil {
+		return nil, err
+	}
+	plan.ControlPlaneChanges = changes
+	if plan.ControlPlaneRollouts, plan.EstimatedControlPlaneRollouts, err = p.controlPlaneRollouts(ctx, currentHC, changes); err != nil {
+		return nil, err
+	}
+	var coreConfigChanges []string
+	for _, change := range changes {
+		if change.NodePoolConfig {
+			coreConfigChanges = append(coreConfigChanges, change.Field)
+		}
+	}
+
+	proposed := map[string]*hyperv1.NodePool{}
+	for _, nodePool := range proposedNodePools {
+		proposed[nodePool.Name] = nodePool
+	}
+	nodePools := &hyperv1.NodePoolList{}
+	if err := p.Client.List(ctx, nodePools, crclient.InNamespace(currentHC.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list NodePools: %w", err)
+	}
+	for i := range nodePools.Items {
+		currentNodePool := &nodePools.Items[i]
+		if currentNodePool.Spec.ClusterName != currentHC.Name {
+			continue
+		}
+		proposedNodePool := currentNodePool
+		if nodePool, ok := proposed[currentNodePool.Name]; ok {
+			proposedNodePool = nodePool.DeepCopy()
+			proposedNodePool.ResourceVersion = currentNodePool.ResourceVersion
+			if err := p.Client.Update(ctx, proposedNodePool, crclient.DryRunAll); err != nil {
+				return nil, fmt.Errorf("the proposed NodePool %s would be rejected: %w", currentNodePool.Name, err)
+			}
+			proposedNodePool.Status = currentNodePool.Status
+		}
+
+		nodePoolPlan := NodePoolPlan{Name: currentNodePool.Name}
+		if nodePoolPlan.Rollout, err = p.NodePoolReconciler.PlanRollout(ctx, currentHC, proposedHC, currentNodePool, proposedNodePool, coreConfigChanges); err != nil {
+			nodePoolPlan.Error = err.Error()
+		}
+		plan.NodePools = append(plan.NodePools, nodePoolPlan)
+	}
+	for name := range proposed {
+		if !sets.New(nodePoolNames(plan.NodePools)...).Has(name) {
+			plan.NodePools = append(plan.NodePools, NodePoolPlan{Name: name, Error: "new NodePools are not planned, only changes to existing NodePools"})
+		}
+	}
+	sort.Slice(plan.NodePools, func(i, j int) bool { return plan.NodePools[i].Name < plan.NodePools[j].Name })
+	return plan, nil
+}
+
+// controlPlaneRollouts resolves the rendered and the estimated components of the control plane
+// changes to the Deployments and StatefulSets which exist in the control plane namespace.
+func (p *Planner) controlPlaneRollouts(ctx context.Context, hcluster *hyperv1.HostedCluster, changes []hostedcluster.ControlPlaneChange) ([]string, []string, error) {
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(hcluster.Namespace, hcluster.Name)
+	deployments := &appsv1.DeploymentList{}
+	if err := p.Client.List(ctx, deployments, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		return nil, nil, fmt.Errorf("failed to list control plane Deployments: %w", err)
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := p.Client.List(ctx, statefulSets, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		return nil, nil, fmt.Errorf("failed to list control plane StatefulSets: %w", err)
+	}
+	// Workloads are keyed by component name, the rendered components are never estimated.
+	workloads := map[string]string{}
+	for _, deployment := range deployments.Items {
+		workloads[deployment.Name] = "deployment/" + deployment.Name
+	}
+	for _, statefulSet := range statefulSets.Items {
+		workloads[statefulSet.Name] = "statefulset/" + statefulSet.Name
+	}
+
+	rendered, estimated := sets.New[string](), sets.New[string]()
+	for _, change := range changes {
+		for _, component := range change.Components {
+			if workload, exists := workloads[component]; exists {
+				rendered.Insert(workload)
+			}
+		}
+		for _, component := range change.EstimatedComponents {
+			for name, workload := range workloads {
+				if (component == hostedcluster.AllControlPlaneComponents || component == name) && !hostedcluster.IsRenderedComponent(name) {
+					estimated.Insert(workload)
+				}
+			}
+		}
+	}
+	return sets.List(rendered), sets.List(estimated), nil
+}
+
+func nodePoolNames(plans []NodePoolPlan) []string {
+	names := make([]string, 0, len(plans))
+	for _, plan := range plans {
+		names = append(names, plan.Name)
+	}
+	return names
+}
+
+// FormatPlan prints a human readable report of the plan.
+func FormatPlan(out io.Writer, plan *Plan) error {
+	fmt.Fprintf(out, "Plan for HostedCluster %s\n\n", plan.HostedCluster)
+
+	fmt.Fprintln(out, "Control plane changes (rendered components; estimated components):")
+	if len(plan.ControlPlaneChanges) == 0 {
+		fmt.Fprintln(out, "  none")
+	}
+	for _, change := range plan.ControlPlaneChanges {
+		rendered := "none"
+		if len(change.Components) > 0 {
+			rendered = strings.Join(change.Components, ", ")
+		}
+		estimated := "none"
+		switch {
+		case change.UnknownImpact:
+			estimated = "unknown impact"
+		case len(change.EstimatedComponents) > 0:
+			estimated = strings.Join(change.EstimatedComponents, ", ")
+		}
+		fmt.Fprintf(out, "  %s: %s; %s\n", change.Field, rendered, estimated)
+	}
+
+	fmt.Fprintln(out, "\nControl plane workloads which roll out (rendered):")
+	printList(out, plan.ControlPlaneRollouts)
+	fmt.Fprintln(out, "\nControl plane workloads expected to roll out (estimated):")
+	printList(out, plan.EstimatedControlPlaneRollouts)
+
+	fmt.Fprintln(out, "\nNodePools:")
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tROLLOUT\tVERSION\tCONFIG HASH\tPAYLOAD CONFIG HASH\tMACHINE TEMPLATE")
+	var coreConfigChanges []string
+	for _, nodePool := range plan.NodePools {
+		if nodePool.Rollout == nil {
+			fmt.Fprintf(w, "  %s\tunknown: %s\t\t\t\t\n", nodePool.Name, nodePool.Error)
+			continue
+		}
+		rollout := nodePool.Rollout
+		coreConfigChanges = rollout.CoreConfigChanges
+		fmt.Fprintf(w, "  %s\t%t\t%s\t%s\t%s\t%s\n",
+			nodePool.Name,
+			rollout.RollsOut(),
+			transition(rollout.Current.Version, rollout.Proposed.Version),
+			transition(rollout.Current.ConfigHash, rollout.Proposed.ConfigHash),
+			transition(rollout.Current.PayloadConfigHash, rollout.Proposed.PayloadConfigHash),
+			transition(rollout.Current.MachineTemplate, rollout.Proposed.MachineTemplate),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(coreConfigChanges) > 0 {
+		fmt.Fprintf(out, "\nThe control plane renders %s into the core ignition config of the NodePools. The NodePool hashes above use the current core ignition config, these NodePools roll out once the control plane has updated it.\n", strings.Join(coreConfigChanges, ", "))
+	}
+	return nil
+}
+
+func printList(out io.Writer, items []string) {
+	if len(items) == 0 {
+		fmt.Fprintln(out, "  none")
+	}
+	for _, item := range items {
+		fmt.Fprintf(out, "  %s\n", item)
+	}
+}
+
+func transition(current, proposed string) string {
+	if current == proposed {
+		if current == "" {
+			return "-"
+		}
+		return "unchanged"
+	}
+	return fmt.Sprintf("%s -> %s", valueOrNone(current), valueOrNone(proposed))
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func operatorImage(ctx context.Context, c crclient.Client, namespace string) (string, error) {
+	deployment := &appsv1.Deployment{}
+	if err := c.Get(ctx, crclient.ObjectKey{Namespace: namespace, Name: "operator"}, deployment); err != nil {
+		return "", fmt.Errorf("failed to get the HyperShift operator Deployment, use --hypershift-operator-image to set its image: %w", err)
+	}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == "operator" {
+			return container.Image, nil
+		}
+	}
+	return "", fmt.Errorf("the HyperShift operator Deployment has no operator container, use --hypershift-operator-image to set its image")
+}
@@ -0,0 +1,155 @@
+package plan
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedcluster"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
+	"github.com/openshift/hypershift/support/api"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const proposal = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: clusters
+---
+apiVersion: hypershift.openshift.io/v1beta1
+kind: HostedCluster
+metadata:
+  name: hc
+  namespace: clusters
+spec:
+  release:
+    image: release:4.15.2
+  configuration:
+    oauth:
+      identityProviders:
+      - name: htpasswd
+---
+apiVersion: hypershift.openshift.io/v1beta1
+kind: NodePool
+metadata:
+  name: workers
+  namespace: clusters
+spec:
+  clusterName: hc
+  release:
+    image: release:4.15.2
+`
+
+func TestParseProposal(t *testing.T) {
+	testCases := []struct {
+		name              string
+		content           string
+		expectedNodePools int
+		expectedErr       string
+	}{
+		{
+			name:              "When the proposal has a HostedCluster and NodePools it should parse them and skip other objects",
+			content:           proposal,
+			expectedNodePools: 1,
+		},
+		{
+			name:        "When the proposal has no HostedCluster it should fail",
+			content:     "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: clusters\n",
+			expectedErr: "the proposal does not contain a HostedCluster",
+		},
+		{
+			name:        "When a NodePool belongs to another HostedCluster it should fail",
+			content:     proposal + "---\napiVersion: hypershift.openshift.io/v1beta1\nkind: NodePool\nmetadata:\n  name: other\n  namespace: clusters\nspec:\n  clusterName: other\n",
+			expectedErr: "NodePool clusters/other does not belong to HostedCluster clusters/hc",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			hostedCluster, nodePools, err := ParseProposal([]byte(tc.content))
+			if tc.expectedErr != "" {
+				g.Expect(err).To(MatchError(tc.expectedErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(hostedCluster.Name).To(Equal("hc"))
+			g.Expect(hostedCluster.Spec.Configuration.OAuth.IdentityProviders).To(HaveLen(1))
+			g.Expect(nodePools).To(HaveLen(tc.expectedNodePools))
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	current := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"},
+		Spec: hyperv1.HostedClusterSpec{
+			Release: hyperv1.Release{Image: "release:4.15.2"},
+		},
+	}
+	deployment := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: name}}
+	}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(
+		current,
+		deployment("kube-apiserver"),
+		deployment("oauth-openshift"),
+	).Build()
+
+	proposed := current.DeepCopy()
+	proposed.ResourceVersion = ""
+	proposed.Spec.Configuration = &hyperv1.ClusterConfiguration{
+		OAuth: &configv1.OAuthSpec{IdentityProviders: []configv1.IdentityProvider{{Name: "htpasswd"}}},
+	}
+
+	plan, err := (&Planner{Client: c}).Plan(ctx, proposed, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(plan.ControlPlaneChanges).To(HaveLen(1))
+	g.Expect(plan.ControlPlaneChanges[0].Field).To(Equal("spec.configuration.oauth"))
+	g.Expect(plan.ControlPlaneRollouts).To(BeEmpty())
+	g.Expect(plan.EstimatedControlPlaneRollouts).To(Equal([]string{"deployment/oauth-openshift"}))
+	g.Expect(plan.NodePools).To(BeEmpty())
+
+	// The dry-run must leave the HostedCluster untouched.
+	hc := &hyperv1.HostedCluster{}
+	g.Expect(c.Get(ctx, crclient.ObjectKeyFromObject(current), hc)).To(Succeed())
+	g.Expect(hc.Spec.Configuration).To(BeNil())
+
+	out := &bytes.Buffer{}
+	g.Expect(FormatPlan(out, plan)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("spec.configuration.oauth: none; oauth-openshift"))
+	g.Expect(out.String()).To(ContainSubstring("deployment/oauth-openshift"))
+}
+
+func TestFormatPlanCoreConfigChanges(t *testing.T) {
+	g := NewWithT(t)
+	unchanged := nodepool.RolloutTarget{Version: "4.15.2", ConfigHash: "config", PayloadConfigHash: "payload"}
+	plan := &Plan{
+		HostedCluster: crclient.ObjectKey{Namespace: "clusters", Name: "hc"},
+		ControlPlaneChanges: []hostedcluster.ControlPlaneChange{
+			{Field: "spec.sshKey", NodePoolConfig: true},
+		},
+		NodePools: []NodePoolPlan{{
+			Name: "workers",
+			Rollout: &nodepool.RolloutPlan{
+				Current:           unchanged,
+				Proposed:          unchanged,
+				CoreConfigChanges: []string{"spec.sshKey"},
+			},
+		}},
+	}
+
+	out := &bytes.Buffer{}
+	g.Expect(FormatPlan(out, plan)).To(Succeed())
+	g.Expect(plan.NodePools[0].Rollout.RollsOut()).To(BeTrue())
+	g.Expect(out.String()).To(MatchRegexp(`workers\s+true\s+unchanged`))
+	g.Expect(out.String()).To(ContainSubstring("The control plane renders spec.sshKey into the core ignition config"))
+}
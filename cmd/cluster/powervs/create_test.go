@@ -8,8 +8,8 @@ import (
 	"testing"
 
 	"github.com/openshift/hypershift/cmd/cluster/core"
-	powervsinfra "github.com/openshift/hypershift/cmd/infra/powervs"
 	"github.com/openshift/hypershift/support/certs"
+	powervsinfra "github.com/openshift/hypershift/support/infra/powervs"
 	"github.com/openshift/hypershift/support/testutil"
 	"github.com/openshift/hypershift/test/integration/framework"
 	"github.com/spf13/pflag"
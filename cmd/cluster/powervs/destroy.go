@@ -12,8 +12,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/openshift/hypershift/cmd/cluster/core"
-	powervsinfra "github.com/openshift/hypershift/cmd/infra/powervs"
 	"github.com/openshift/hypershift/cmd/log"
+	powervsinfra "github.com/openshift/hypershift/support/infra/powervs"
 )
 
 func NewDestroyCommand(opts *core.DestroyOptions) *cobra.Command {
@@ -0,0 +1,421 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+)
+
+// clearScreen moves the cursor home and clears the terminal before each refresh of --watch.
+const clearScreen = "\033[H\033[2J"
+
+// keyConditions are the HostedCluster conditions shown in the status, in order.
+var keyConditions = []hyperv1.ConditionType{
+	hyperv1.HostedClusterAvailable,
+	hyperv1.HostedClusterProgressing,
+	hyperv1.HostedClusterDegraded,
+	hyperv1.ClusterVersionAvailable,
+	hyperv1.ClusterVersionProgressing,
+	hyperv1.ClusterVersionSucceeding,
+	hyperv1.ReconciliationActive,
+	hyperv1.ReconciliationSucceeded,
+}
+
+type Options struct {
+	Namespace string
+	Name      string
+	Output    string
+	Watch     bool
+	Interval  time.Duration
+}
+
+func NewCommand() *cobra.Command {
+	opts := &Options{
+		Namespace: "clusters",
+		Output:    "text",
+		Interval:  5 * time.Second,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Shows the readiness and rollout progress of a HostedCluster",
+		Long: `Shows the readiness and rollout progress of a HostedCluster.
+
+The status summarizes the key conditions and the version history of the HostedCluster, the
+readiness of the Deployments and StatefulSets of its control plane and the replicas and update
+progress of its NodePools. With --watch, the status is refreshed until interrupted, and failures
+to get it are logged and retried at the next refresh.`,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the HostedCluster")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the HostedCluster")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "The format of the status: text or json. With --watch, json prints one status per line")
+	cmd.Flags().BoolVarP(&opts.Watch, "watch", "w", opts.Watch, "If true, the status is refreshed until interrupted")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", opts.Interval, "How often the status is refreshed with --watch")
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if opts.Output != "text" && opts.Output != "json" {
+			return fmt.Errorf("unsupported output format %q, must be text or json", opts.Output)
+		}
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := opts.Run(cmd.Context(), c, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+			log.Log.Error(err, "Failed to get HostedCluster status")
+			return err
+		}
+		return nil
+	}
+	return cmd
+}
+
+// Run prints the status of the HostedCluster, and with Watch, refreshes it every Interval until the
+// context is done. While watching, failures to get the status, e.g. because the management cluster is
+// briefly unreachable, are logged and retried at the next refresh.
+func (o *Options) Run(ctx context.Context, c crclient.Client, out io.Writer) error {
+	for {
+		status, err := GetStatus(ctx, c, o.Namespace, o.Name)
+		switch {
+		case err != nil && !o.Watch:
+			return err
+		case err != nil && ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			log.Log.Error(err, "Failed to get HostedCluster status, retrying", "interval", o.Interval)
+		case o.Output == "json" && o.Watch:
+			if err := json.NewEncoder(out).Encode(status); err != nil {
+				return err
+			}
+		case o.Output == "json":
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(status); err != nil {
+				return err
+			}
+		default:
+			if o.Watch {
+				fmt.Fprint(out, clearScreen)
+			}
+			if err := FormatStatus(out, status); err != nil {
+				return err
+			}
+		}
+		if !o.Watch {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(o.Interval):
+		}
+	}
+}
+
+// Status is the summary of a HostedCluster shown by `hypershift cluster status`.
+type Status struct {
+	HostedCluster string               `json:"hostedCluster"`
+	Platform      hyperv1.PlatformType `json:"platform"`
+	ReleaseImage  string               `json:"releaseImage"`
+	// Version is the current version, empty until the first rollout completed.
+	Version string `json:"version,omitempty"`
+	// DesiredVersion is the version being rolled out.
+	DesiredVersion string            `json:"desiredVersion,omitempty"`
+	History        []VersionHistory  `json:"history,omitempty"`
+	Conditions     []ConditionStatus `json:"conditions"`
+	ControlPlane   []ComponentStatus `json:"controlPlane"`
+	NodePools      []NodePoolStatus  `json:"nodePools"`
+	Time           metav1.Time       `json:"time"`
+}
+
+// VersionHistory is an entry of the version history of the HostedCluster, the most recent first.
+type VersionHistory struct {
+	Version        string       `json:"version"`
+	State          string       `json:"state"`
+	StartedTime    metav1.Time  `json:"startedTime"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+type ConditionStatus struct {
+	Type    string                 `json:"type"`
+	Status  metav1.ConditionStatus `json:"status"`
+	Reason  string                 `json:"reason,omitempty"`
+	Message string                 `json:"message,omitempty"`
+}
+
+// ComponentStatus is the readiness of a Deployment or StatefulSet of the control plane.
+type ComponentStatus struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Desired  int32  `json:"desired"`
+	Ready    int32  `json:"ready"`
+	UpToDate int32  `json:"upToDate"`
+}
+
+// Available reports whether every desired replica is ready and up to date.
+func (c ComponentStatus) Available() bool {
+	return c.Ready >= c.Desired && c.UpToDate >= c.Desired
+}
+
+// NodePoolStatus is the replicas and update progress of a NodePool.
+type NodePoolStatus struct {
+	Name string `json:"name"`
+	// Desired is the desired number of replicas, nil when autoscaling.
+	Desired     *int32 `json:"desired,omitempty"`
+	Autoscaling string `json:"autoscaling,omitempty"`
+	Current     int32  `json:"current"`
+	// Ready and UpToDate are the ready and up to date Machines of the NodePool.
+	Ready    int32  `json:"ready"`
+	UpToDate *int32 `json:"upToDate,omitempty"`
+	Version  string `json:"version,omitempty"`
+	// Updating are the messages of the updating conditions of the NodePool, by condition type.
+	Updating map[string]string `json:"updating,omitempty"`
+}
+
+// GetStatus collects the Status of a HostedCluster.
+func GetStatus(ctx context.Context, c crclient.Client, namespace, name string) (*Status, error) {
+	hcluster := &hyperv1.HostedCluster{}
+	if err := c.Get(ctx, crclient.ObjectKey{Namespace: namespace, Name: name}, hcluster); err != nil {
+		return nil, fmt.Errorf("failed to get HostedCluster %s/%s: %w", namespace, name, err)
+	}
+	status := &Status{
+		HostedCluster: crclient.ObjectKeyFromObject(hcluster).String(),
+		Platform:      hcluster.Spec.Platform.Type,
+		ReleaseImage:  hcluster.Spec.Release.Image,
+		Conditions:    []ConditionStatus{},
+		ControlPlane:  []ComponentStatus{},
+		NodePools:     []NodePoolStatus{},
+		Time:          metav1.Now(),
+	}
+	if version := hcluster.Status.Version; version != nil {
+		status.DesiredVersion = version.Desired.Version
+		for _, entry := range version.History {
+			if status.Version == "" && entry.State == configv1.CompletedUpdate {
+				status.Version = entry.Version
+			}
+			status.History = append(status.History, VersionHistory{
+				Version:        entry.Version,
+				State:          string(entry.State),
+				StartedTime:    entry.StartedTime,
+				CompletionTime: entry.CompletionTime,
+			})
+		}
+	}
+	for _, conditionType := range keyConditions {
+		if condition := meta.FindStatusCondition(hcluster.Status.Conditions, string(conditionType)); condition != nil {
+			status.Conditions = append(status.Conditions, ConditionStatus{
+				Type:    condition.Type,
+				Status:  condition.Status,
+				Reason:  condition.Reason,
+				Message: condition.Message,
+			})
+		}
+	}
+
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(namespace, name)
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list control plane Deployments: %w", err)
+	}
+	for _, deployment := range deployments.Items {
+		status.ControlPlane = append(status.ControlPlane, ComponentStatus{
+			Kind:     "Deployment",
+			Name:     deployment.Name,
+			Desired:  replicas(deployment.Spec.Replicas),
+			Ready:    deployment.Status.ReadyReplicas,
+			UpToDate: deployment.Status.UpdatedReplicas,
+		})
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSets, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list control plane StatefulSets: %w", err)
+	}
+	for _, statefulSet := range statefulSets.Items {
+		status.ControlPlane = append(status.ControlPlane, ComponentStatus{
+			Kind:     "StatefulSet",
+			Name:     statefulSet.Name,
+			Desired:  replicas(statefulSet.Spec.Replicas),
+			Ready:    statefulSet.Status.ReadyReplicas,
+			UpToDate: statefulSet.Status.UpdatedReplicas,
+		})
+	}
+	sort.SliceStable(status.ControlPlane, func(i, j int) bool { return status.ControlPlane[i].Name < status.ControlPlane[j].Name })
+
+	nodePools := &hyperv1.NodePoolList{}
+	if err := c.List(ctx, nodePools, crclient.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list NodePools: %w", err)
+	}
+	for i := range nodePools.Items {
+		nodePool := &nodePools.Items[i]
+		if nodePool.Spec.ClusterName != name {
+			continue
+		}
+		nodePoolStatus, err := getNodePoolStatus(ctx, c, nodePool, controlPlaneNamespace)
+		if err != nil {
+			return nil, err
+		}
+		status.NodePools = append(status.NodePools, *nodePoolStatus)
+	}
+	sort.Slice(status.NodePools, func(i, j int) bool { return status.NodePools[i].Name < status.NodePools[j].Name })
+	return status, nil
+}
+
+// getNodePoolStatus reads the progress of the Machines of a NodePool from its MachineDeployment, or
+// its MachineSet with the InPlace upgrade type. Both are named after the NodePool.
+func getNodePoolStatus(ctx context.Context, c crclient.Client, nodePool *hyperv1.NodePool, controlPlaneNamespace string) (*NodePoolStatus, error) {
+	status := &NodePoolStatus{
+		Name:    nodePool.Name,
+		Desired: nodePool.Spec.Replicas,
+		Current: nodePool.Status.Replicas,
+		Version: nodePool.Status.Version,
+	}
+	if autoScaling := nodePool.Spec.AutoScaling; autoScaling != nil {
+		status.Desired = nil
+		status.Autoscaling = fmt.Sprintf("%d-%d", autoScaling.Min, autoScaling.Max)
+	}
+	for _, condition := range nodePool.Status.Conditions {
+		switch condition.Type {
+		case hyperv1.NodePoolUpdatingVersionConditionType, hyperv1.NodePoolUpdatingConfigConditionType, hyperv1.NodePoolUpdatingPlatformMachineTemplateConditionType:
+			if condition.Status == corev1.ConditionTrue {
+				if status.Updating == nil {
+					status.Updating = map[string]string{}
+				}
+				status.Updating[condition.Type] = condition.Message
+			}
+		}
+	}
+
+	key := crclient.ObjectKey{Namespace: controlPlaneNamespace, Name: nodePool.Name}
+	machineDeployment := &capiv1.MachineDeployment{}
+	if err := c.Get(ctx, key, machineDeployment); err == nil {
+		status.Ready = machineDeployment.Status.ReadyReplicas
+		status.UpToDate = &machineDeployment.Status.UpdatedReplicas
+		return status, nil
+	} else if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("failed to get MachineDeployment %s: %w", key, err)
+	}
+	machineSet := &capiv1.MachineSet{}
+	if err := c.Get(ctx, key, machineSet); err == nil {
+		status.Ready = machineSet.Status.ReadyReplicas
+	} else if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("failed to get MachineSet %s: %w", key, err)
+	}
+	return status, nil
+}
+
+func replicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// FormatStatus prints a human readable summary of the status.
+func FormatStatus(out io.Writer, status *Status) error {
+	fmt.Fprintf(out, "HostedCluster %s (%s)\n", status.HostedCluster, status.Platform)
+	version := valueOrNone(status.Version)
+	if status.DesiredVersion != "" && status.DesiredVersion != status.Version {
+		version = fmt.Sprintf("%s, updating to %s", version, status.DesiredVersion)
+	}
+	fmt.Fprintf(out, "Version:       %s\n", version)
+	fmt.Fprintf(out, "Release image: %s\n", valueOrNone(status.ReleaseImage))
+	fmt.Fprintf(out, "Updated at:    %s\n", status.Time.UTC().Format(time.RFC3339))
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\nConditions:")
+	if len(status.Conditions) == 0 {
+		fmt.Fprintln(w, "  none")
+	} else {
+		fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tMESSAGE")
+	}
+	for _, condition := range status.Conditions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", condition.Type, condition.Status, valueOrNone(condition.Reason), firstLine(condition.Message))
+	}
+
+	available := 0
+	for _, component := range status.ControlPlane {
+		if component.Available() {
+			available++
+		}
+	}
+	fmt.Fprintf(w, "\nControl plane: %d/%d components available\n", available, len(status.ControlPlane))
+	if len(status.ControlPlane) > 0 {
+		fmt.Fprintln(w, "  COMPONENT\tREADY\tUP-TO-DATE")
+	}
+	for _, component := range status.ControlPlane {
+		fmt.Fprintf(w, "  %s/%s\t%d/%d\t%d\n", strings.ToLower(component.Kind), component.Name, component.Ready, component.Desired, component.UpToDate)
+	}
+
+	fmt.Fprintln(w, "\nVersion history:")
+	if len(status.History) == 0 {
+		fmt.Fprintln(w, "  none")
+	} else {
+		fmt.Fprintln(w, "  VERSION\tSTATE\tSTARTED\tCOMPLETED")
+	}
+	for _, entry := range status.History {
+		completed := "-"
+		if entry.CompletionTime != nil {
+			completed = entry.CompletionTime.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", entry.Version, entry.State, entry.StartedTime.UTC().Format(time.RFC3339), completed)
+	}
+
+	fmt.Fprintln(w, "\nNodePools:")
+	if len(status.NodePools) == 0 {
+		fmt.Fprintln(w, "  none")
+	} else {
+		fmt.Fprintln(w, "  NAME\tDESIRED\tCURRENT\tREADY\tUP-TO-DATE\tVERSION\tUPDATING")
+	}
+	for _, nodePool := range status.NodePools {
+		desired := nodePool.Autoscaling
+		if nodePool.Desired != nil {
+			desired = fmt.Sprintf("%d", *nodePool.Desired)
+		}
+		upToDate := "-"
+		if nodePool.UpToDate != nil {
+			upToDate = fmt.Sprintf("%d", *nodePool.UpToDate)
+		}
+		updating := make([]string, 0, len(nodePool.Updating))
+		for conditionType := range nodePool.Updating {
+			updating = append(updating, strings.TrimPrefix(conditionType, "Updating"))
+		}
+		sort.Strings(updating)
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%d\t%s\t%s\t%s\n", nodePool.Name, valueOrNone(desired), nodePool.Current, nodePool.Ready, upToDate, valueOrNone(nodePool.Version), valueOrNone(strings.Join(updating, ",")))
+	}
+	return w.Flush()
+}
+
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,205 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var (
+	started   = metav1.NewTime(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC))
+	completed = metav1.NewTime(time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC))
+)
+
+func objects() []crclient.Object {
+	return []crclient.Object{
+		&hyperv1.HostedCluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"},
+			Spec: hyperv1.HostedClusterSpec{
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform},
+				Release:  hyperv1.Release{Image: "release:4.15.3"},
+			},
+			Status: hyperv1.HostedClusterStatus{
+				Version: &hyperv1.ClusterVersionStatus{
+					Desired: configv1.Release{Version: "4.15.3"},
+					History: []configv1.UpdateHistory{
+						{State: configv1.PartialUpdate, Version: "4.15.3", StartedTime: completed},
+						{State: configv1.CompletedUpdate, Version: "4.15.2", StartedTime: started, CompletionTime: &completed},
+					},
+				},
+				Conditions: []metav1.Condition{
+					{Type: string(hyperv1.HostedClusterProgressing), Status: metav1.ConditionTrue, Reason: "Rollout", Message: "updating\nto 4.15.3"},
+					{Type: string(hyperv1.HostedClusterAvailable), Status: metav1.ConditionTrue, Reason: "AsExpected"},
+					{Type: string(hyperv1.ValidOIDCConfiguration), Status: metav1.ConditionTrue},
+				},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "kube-apiserver"},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](3)},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 3, UpdatedReplicas: 1},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "etcd"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To[int32](3)},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 3, UpdatedReplicas: 3},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "ignored"},
+		},
+		&hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "workers"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "hc", Replicas: ptr.To[int32](2)},
+			Status: hyperv1.NodePoolStatus{Replicas: 2, Version: "4.15.2", Conditions: []hyperv1.NodePoolCondition{
+				{Type: hyperv1.NodePoolUpdatingVersionConditionType, Status: corev1.ConditionTrue, Message: "Updating version in progress. Target version: 4.15.3"},
+				{Type: hyperv1.NodePoolUpdatingConfigConditionType, Status: corev1.ConditionFalse},
+			}},
+		},
+		&capiv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "workers"},
+			Status:     capiv1.MachineDeploymentStatus{ReadyReplicas: 2, UpdatedReplicas: 1},
+		},
+		&hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "inplace"},
+			Spec: hyperv1.NodePoolSpec{
+				ClusterName: "hc",
+				AutoScaling: &hyperv1.NodePoolAutoScaling{Min: 1, Max: 3},
+				Management:  hyperv1.NodePoolManagement{UpgradeType: hyperv1.UpgradeTypeInPlace},
+			},
+			Status: hyperv1.NodePoolStatus{Replicas: 1, Version: "4.15.2"},
+		},
+		&capiv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "inplace"},
+			Status:     capiv1.MachineSetStatus{ReadyReplicas: 1},
+		},
+		&hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "other"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "other"},
+		},
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	g := NewWithT(t)
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects()...).Build()
+
+	status, err := GetStatus(context.Background(), c, "clusters", "hc")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.HostedCluster).To(Equal("clusters/hc"))
+	g.Expect(status.Version).To(Equal("4.15.2"))
+	g.Expect(status.DesiredVersion).To(Equal("4.15.3"))
+	g.Expect(status.History).To(HaveLen(2))
+	g.Expect(status.Conditions).To(Equal([]ConditionStatus{
+		{Type: "Available", Status: metav1.ConditionTrue, Reason: "AsExpected"},
+		{Type: "Progressing", Status: metav1.ConditionTrue, Reason: "Rollout", Message: "updating\nto 4.15.3"},
+	}))
+	g.Expect(status.ControlPlane).To(Equal([]ComponentStatus{
+		{Kind: "StatefulSet", Name: "etcd", Desired: 3, Ready: 3, UpToDate: 3},
+		{Kind: "Deployment", Name: "kube-apiserver", Desired: 3, Ready: 3, UpToDate: 1},
+	}))
+	g.Expect(status.NodePools).To(Equal([]NodePoolStatus{
+		{Name: "inplace", Autoscaling: "1-3", Current: 1, Ready: 1, Version: "4.15.2"},
+		{Name: "workers", Desired: ptr.To[int32](2), Current: 2, Ready: 2, UpToDate: ptr.To[int32](1), Version: "4.15.2", Updating: map[string]string{
+			hyperv1.NodePoolUpdatingVersionConditionType: "Updating version in progress. Target version: 4.15.3",
+		}},
+	}))
+
+	_, err = GetStatus(context.Background(), c, "clusters", "missing")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestFormatStatus(t *testing.T) {
+	g := NewWithT(t)
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects()...).Build()
+	status, err := GetStatus(context.Background(), c, "clusters", "hc")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	out := &bytes.Buffer{}
+	g.Expect(FormatStatus(out, status)).To(Succeed())
+	for _, expected := range []string{
+		"HostedCluster clusters/hc (AWS)",
+		"Version:       4.15.2, updating to 4.15.3",
+		"  Progressing  True    Rollout     updating\n",
+		"Control plane: 1/2 components available",
+		"  deployment/kube-apiserver  3/3    1",
+		"  4.15.2   Completed  2024-03-01T10:00:00Z  2024-03-01T10:30:00Z",
+		"  4.15.3   Partial    2024-03-01T10:30:00Z  -",
+		"  inplace  1-3      1        1      -           4.15.2   -",
+		"  workers  2        2        2      1           4.15.2   Version",
+	} {
+		g.Expect(out.String()).To(ContainSubstring(expected))
+	}
+}
+
+func TestRun(t *testing.T) {
+	g := NewWithT(t)
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects()...).Build()
+
+	out := &bytes.Buffer{}
+	opts := &Options{Namespace: "clusters", Name: "hc", Output: "json"}
+	g.Expect(opts.Run(context.Background(), c, out)).To(Succeed())
+	status := &Status{}
+	g.Expect(json.Unmarshal(out.Bytes(), status)).To(Succeed())
+	g.Expect(status.NodePools).To(HaveLen(2))
+
+	// watching prints one status per line until the context is done
+	out.Reset()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	opts.Watch = true
+	opts.Interval = 10 * time.Millisecond
+	g.Expect(opts.Run(ctx, c, out)).To(MatchError(context.DeadlineExceeded))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	g.Expect(len(lines)).To(BeNumerically(">", 1))
+	for _, line := range lines {
+		g.Expect(json.Unmarshal([]byte(line), &Status{})).To(Succeed())
+	}
+}
+
+func TestRunWatchRetriesErrors(t *testing.T) {
+	g := NewWithT(t)
+	failures := 1
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects()...).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, client crclient.WithWatch, key crclient.ObjectKey, obj crclient.Object, opts ...crclient.GetOption) error {
+			if _, ok := obj.(*hyperv1.HostedCluster); ok && failures > 0 {
+				failures--
+				return errors.New("connection refused")
+			}
+			return client.Get(ctx, key, obj, opts...)
+		},
+	}).Build()
+
+	// without watching, the first failure is returned
+	out := &bytes.Buffer{}
+	opts := &Options{Namespace: "clusters", Name: "hc", Output: "json"}
+	g.Expect(opts.Run(context.Background(), c, out)).To(MatchError(ContainSubstring("connection refused")))
+	g.Expect(out.String()).To(BeEmpty())
+
+	// watching keeps refreshing after a failure
+	failures = 1
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	opts.Watch = true
+	opts.Interval = 10 * time.Millisecond
+	g.Expect(opts.Run(ctx, c, out)).To(MatchError(context.DeadlineExceeded))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	g.Expect(lines).ToNot(BeEmpty())
+	g.Expect(json.Unmarshal([]byte(lines[0]), &Status{})).To(Succeed())
+}
@@ -6,13 +6,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
 	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
 	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
@@ -98,7 +101,7 @@ func (o *ConsoleLogOpts) Run(ctx context.Context) error {
 	return nil
 }
 
-func getEC2Instances(ctx context.Context, ec2Client *ec2.EC2, infraID string) (map[string]string, error) {
+func getEC2Instances(ctx context.Context, ec2Client ec2iface.EC2API, infraID string) (map[string]string, error) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 	clusterTagFilter := fmt.Sprintf("tag:kubernetes.io/cluster/%s", infraID)
@@ -131,22 +134,13 @@ func getEC2Instances(ctx context.Context, ec2Client *ec2.EC2, infraID string) (m
 	return instances, nil
 }
 
-func getInstanceConsoleOutput(ctx context.Context, ec2Client *ec2.EC2, instances map[string]string, outputDir string) error {
+func getInstanceConsoleOutput(ctx context.Context, ec2Client ec2iface.EC2API, instances map[string]string, outputDir string) error {
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return err
 	}
 	var errs []error
 	for name, instanceID := range instances {
-		ctxWithTimeout, cancel := context.WithTimeout(ctx, 2*time.Minute)
-		defer cancel()
-		output, err := ec2Client.GetConsoleOutputWithContext(ctxWithTimeout, &ec2.GetConsoleOutputInput{
-			InstanceId: aws.String(instanceID),
-		})
-		if err != nil {
-			errs = append(errs, err)
-			continue
-		}
-		logOutput, err := base64.StdEncoding.DecodeString(aws.StringValue(output.Output))
+		logOutput, err := consoleOutput(ctx, ec2Client, instanceID)
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -160,3 +154,50 @@ func getInstanceConsoleOutput(ctx context.Context, ec2Client *ec2.EC2, instances
 	}
 	return nil
 }
+
+// consoleOutput returns the decoded console output of an instance.
+func consoleOutput(ctx context.Context, ec2Client ec2iface.EC2API, instanceID string) ([]byte, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	output, err := ec2Client.GetConsoleOutputWithContext(ctxWithTimeout, &ec2.GetConsoleOutputInput{
+		InstanceId: aws.String(instanceID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(aws.StringValue(output.Output))
+}
+
+// Provider returns the console output of the EC2 instances of Machines.
+type Provider struct {
+	EC2 ec2iface.EC2API
+}
+
+// NewProvider returns a Provider for a region using the default credentials chain.
+func NewProvider(region string) *Provider {
+	awsSession := awsutil.NewSession("cli-console-logs", "", "", "", region)
+	return &Provider{EC2: ec2.New(awsSession, awsutil.NewConfig())}
+}
+
+func (p *Provider) ConsoleLog(ctx context.Context, machine *capiv1.Machine) ([]byte, error) {
+	instanceID, err := instanceIDFromProviderID(machine.Spec.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	return consoleOutput(ctx, p.EC2, instanceID)
+}
+
+// instanceIDFromProviderID returns the instance ID of a provider ID of the form aws:///<zone>/<instance ID>.
+func instanceIDFromProviderID(providerID *string) (string, error) {
+	if providerID == nil || *providerID == "" {
+		return "", fmt.Errorf("machine has no provider ID yet")
+	}
+	if !strings.HasPrefix(*providerID, "aws://") {
+		return "", fmt.Errorf("invalid AWS provider ID %q", *providerID)
+	}
+	instanceID := (*providerID)[strings.LastIndex(*providerID, "/")+1:]
+	if instanceID == "" {
+		return "", fmt.Errorf("invalid AWS provider ID %q", *providerID)
+	}
+	return instanceID, nil
+}
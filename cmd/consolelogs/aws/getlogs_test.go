@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+type fakeEC2 struct {
+	ec2iface.EC2API
+	outputs map[string]string
+}
+
+func (f *fakeEC2) GetConsoleOutputWithContext(_ aws.Context, input *ec2.GetConsoleOutputInput, _ ...request.Option) (*ec2.GetConsoleOutputOutput, error) {
+	return &ec2.GetConsoleOutputOutput{
+		InstanceId: input.InstanceId,
+		Output:     aws.String(base64.StdEncoding.EncodeToString([]byte(f.outputs[aws.StringValue(input.InstanceId)]))),
+	}, nil
+}
+
+func TestProviderConsoleLog(t *testing.T) {
+	testCases := []struct {
+		name        string
+		providerID  *string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:       "When the machine has an instance it should return its decoded console output",
+			providerID: aws.String("aws:///us-east-1a/i-0123456789"),
+			expected:   "booting",
+		},
+		{
+			name:        "When the machine has no provider ID it should fail",
+			expectedErr: true,
+		},
+		{
+			name:        "When the provider ID is not an AWS one it should fail",
+			providerID:  aws.String("azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"),
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			provider := &Provider{EC2: &fakeEC2{outputs: map[string]string{"i-0123456789": "booting"}}}
+			machine := &capiv1.Machine{Spec: capiv1.MachineSpec{ProviderID: tc.providerID}}
+			consoleLog, err := provider.ConsoleLog(context.Background(), machine)
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(consoleLog)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	"k8s.io/utils/ptr"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// VirtualMachinesClient is the subset of armcompute.VirtualMachinesClient used to retrieve boot diagnostics.
+type VirtualMachinesClient interface {
+	RetrieveBootDiagnosticsData(ctx context.Context, resourceGroupName string, vmName string, options *armcompute.VirtualMachinesClientRetrieveBootDiagnosticsDataOptions) (armcompute.VirtualMachinesClientRetrieveBootDiagnosticsDataResponse, error)
+}
+
+// Provider returns the serial console log of the boot diagnostics of the virtual machines of Machines.
+type Provider struct {
+	VirtualMachines VirtualMachinesClient
+	HTTPClient      *http.Client
+}
+
+// NewProvider returns a Provider authenticating with the client secret of a service principal.
+func NewProvider(subscriptionID, tenantID, clientID, clientSecret string) (*Provider, error) {
+	creds, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure creds: %w", err)
+	}
+	vmClient, err := armcompute.NewVirtualMachinesClient(subscriptionID, creds, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create virtual machines client: %w", err)
+	}
+	return &Provider{VirtualMachines: vmClient, HTTPClient: &http.Client{Timeout: 2 * time.Minute}}, nil
+}
+
+func (p *Provider) ConsoleLog(ctx context.Context, machine *capiv1.Machine) ([]byte, error) {
+	resourceGroup, vmName, err := vmFromProviderID(machine.Spec.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	// The SAS URIs only need to be valid for the download below
+	data, err := p.VirtualMachines.RetrieveBootDiagnosticsData(ctx, resourceGroup, vmName, &armcompute.VirtualMachinesClientRetrieveBootDiagnosticsDataOptions{
+		SasURIExpirationTimeInMinutes: ptr.To[int32](5),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve boot diagnostics of virtual machine %s: %w", vmName, err)
+	}
+	blobURI := ptr.Deref(data.SerialConsoleLogBlobURI, "")
+	if blobURI == "" {
+		return nil, fmt.Errorf("virtual machine %s has no serial console log, boot diagnostics may be disabled", vmName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, blobURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download serial console log of virtual machine %s: %w", vmName, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download serial console log of virtual machine %s: %s", vmName, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// vmFromProviderID returns the resource group and the name of the virtual machine of a provider ID of the
+// form azure:///subscriptions/<subscription>/resourceGroups/<resource group>/providers/Microsoft.Compute/virtualMachines/<name>.
+func vmFromProviderID(providerID *string) (string, string, error) {
+	if providerID == nil || *providerID == "" {
+		return "", "", fmt.Errorf("machine has no provider ID yet")
+	}
+	if !strings.HasPrefix(*providerID, "azure://") {
+		return "", "", fmt.Errorf("invalid Azure provider ID %q", *providerID)
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(*providerID, "azure://"), "/"), "/")
+	var resourceGroup, vmName string
+	for i := 0; i+1 < len(segments); i += 2 {
+		switch strings.ToLower(segments[i]) {
+		case "resourcegroups":
+			resourceGroup = segments[i+1]
+		case "virtualmachines":
+			vmName = segments[i+1]
+		}
+	}
+	if resourceGroup == "" || vmName == "" {
+		return "", "", fmt.Errorf("invalid Azure provider ID %q", *providerID)
+	}
+	return resourceGroup, vmName, nil
+}
@@ -0,0 +1,89 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	"k8s.io/utils/ptr"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+type fakeVirtualMachinesClient struct {
+	blobURIs map[string]string
+}
+
+func (f *fakeVirtualMachinesClient) RetrieveBootDiagnosticsData(_ context.Context, resourceGroupName string, vmName string, _ *armcompute.VirtualMachinesClientRetrieveBootDiagnosticsDataOptions) (armcompute.VirtualMachinesClientRetrieveBootDiagnosticsDataResponse, error) {
+	response := armcompute.VirtualMachinesClientRetrieveBootDiagnosticsDataResponse{}
+	if blobURI, ok := f.blobURIs[resourceGroupName+"/"+vmName]; ok {
+		response.SerialConsoleLogBlobURI = ptr.To(blobURI)
+	}
+	return response, nil
+}
+
+func TestProviderConsoleLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/serial.log" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("booting"))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name        string
+		providerID  *string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:       "When the virtual machine has boot diagnostics it should return its serial console log",
+			providerID: ptr.To("azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"),
+			expected:   "booting",
+		},
+		{
+			name:        "When the virtual machine has boot diagnostics disabled it should fail",
+			providerID:  ptr.To("azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/other"),
+			expectedErr: true,
+		},
+		{
+			name:        "When the serial console log can't be downloaded it should fail",
+			providerID:  ptr.To("azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/missing"),
+			expectedErr: true,
+		},
+		{
+			name:        "When the machine has no provider ID it should fail",
+			expectedErr: true,
+		},
+		{
+			name:        "When the provider ID has no virtual machine it should fail",
+			providerID:  ptr.To("azure:///subscriptions/sub/resourceGroups/rg"),
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			provider := &Provider{
+				VirtualMachines: &fakeVirtualMachinesClient{blobURIs: map[string]string{
+					"rg/vm":      server.URL + "/serial.log",
+					"rg/missing": server.URL + "/missing.log",
+				}},
+				HTTPClient: server.Client(),
+			}
+			machine := &capiv1.Machine{Spec: capiv1.MachineSpec{ProviderID: tc.providerID}}
+			consoleLog, err := provider.ConsoleLog(context.Background(), machine)
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(consoleLog)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package kubevirt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Provider returns the serial console log of the VirtualMachineInstances of Machines, which KubeVirt
+// streams from the guest-console-log container of their virt-launcher pod.
+type Provider struct {
+	// Client and KubeClient are clients of the infra cluster running the VirtualMachineInstances.
+	Client     crclient.Client
+	KubeClient kubernetes.Interface
+	// Namespace is the namespace of the VirtualMachineInstances in the infra cluster.
+	Namespace string
+}
+
+func (p *Provider) ConsoleLog(ctx context.Context, machine *capiv1.Machine) ([]byte, error) {
+	vmName, err := vmNameOf(machine)
+	if err != nil {
+		return nil, err
+	}
+	vmi := &kubevirtv1.VirtualMachineInstance{}
+	if err := p.Client.Get(ctx, crclient.ObjectKey{Namespace: p.Namespace, Name: vmName}, vmi); err != nil {
+		return nil, fmt.Errorf("failed to get VirtualMachineInstance %s/%s: %w", p.Namespace, vmName, err)
+	}
+	pods := &corev1.PodList{}
+	if err := p.Client.List(ctx, pods, crclient.InNamespace(p.Namespace), crclient.MatchingLabels{kubevirtv1.CreatedByLabel: string(vmi.UID)}); err != nil {
+		return nil, fmt.Errorf("failed to list virt-launcher pods of VirtualMachineInstance %s/%s: %w", p.Namespace, vmName, err)
+	}
+	for _, pod := range pods.Items {
+		if !hasContainer(&pod, string(kubevirtv1.GuestConsoleLog)) {
+			continue
+		}
+		log, err := p.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: string(kubevirtv1.GuestConsoleLog)}).DoRaw(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get serial console log of VirtualMachineInstance %s/%s: %w", p.Namespace, vmName, err)
+		}
+		return log, nil
+	}
+	return nil, fmt.Errorf("no virt-launcher pod of VirtualMachineInstance %s/%s logs the serial console, logSerialConsole may be disabled", p.Namespace, vmName)
+}
+
+func hasContainer(pod *corev1.Pod, name string) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// vmNameOf returns the name of the VirtualMachineInstance of a Machine from its provider ID of the form
+// kubevirt://<name>. The provider ID is only set once the VirtualMachineInstance is ready, before that
+// the VirtualMachineInstance is named after the KubevirtMachine.
+func vmNameOf(machine *capiv1.Machine) (string, error) {
+	providerID := machine.Spec.ProviderID
+	if providerID == nil || *providerID == "" {
+		if machine.Spec.InfrastructureRef.Kind != "KubevirtMachine" || machine.Spec.InfrastructureRef.Name == "" {
+			return "", fmt.Errorf("machine has no provider ID yet")
+		}
+		return machine.Spec.InfrastructureRef.Name, nil
+	}
+	vmName, found := strings.CutPrefix(*providerID, "kubevirt://")
+	if !found || vmName == "" || strings.Contains(vmName, "/") {
+		return "", fmt.Errorf("invalid KubeVirt provider ID %q", *providerID)
+	}
+	return vmName, nil
+}
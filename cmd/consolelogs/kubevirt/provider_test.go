@@ -0,0 +1,83 @@
+package kubevirt
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/hypershift/support/api"
+)
+
+func TestProviderConsoleLog(t *testing.T) {
+	launcherPod := func(name, vmiUID string, containers ...string) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "clusters-hc",
+			Name:      name,
+			Labels:    map[string]string{kubevirtv1.CreatedByLabel: vmiUID},
+		}}
+		for _, container := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+		}
+		return pod
+	}
+	objects := []crclient.Object{
+		&kubevirtv1.VirtualMachineInstance{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "vm-a", UID: "uid-a"}},
+		&kubevirtv1.VirtualMachineInstance{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "vm-b", UID: "uid-b"}},
+		launcherPod("virt-launcher-vm-a", "uid-a", "compute", string(kubevirtv1.GuestConsoleLog)),
+		launcherPod("virt-launcher-vm-b", "uid-b", "compute"),
+	}
+
+	testCases := []struct {
+		name        string
+		machine     capiv1.Machine
+		expectedErr bool
+	}{
+		{
+			name:    "When the machine has a provider ID it should return the serial console log of its VirtualMachineInstance",
+			machine: capiv1.Machine{Spec: capiv1.MachineSpec{ProviderID: ptr.To("kubevirt://vm-a")}},
+		},
+		{
+			name: "When the machine has no provider ID yet it should use the name of its KubevirtMachine",
+			machine: capiv1.Machine{Spec: capiv1.MachineSpec{
+				InfrastructureRef: corev1.ObjectReference{Kind: "KubevirtMachine", Name: "vm-a"},
+			}},
+		},
+		{
+			name:        "When the serial console is not logged it should fail",
+			machine:     capiv1.Machine{Spec: capiv1.MachineSpec{ProviderID: ptr.To("kubevirt://vm-b")}},
+			expectedErr: true,
+		},
+		{
+			name:        "When the VirtualMachineInstance doesn't exist it should fail",
+			machine:     capiv1.Machine{Spec: capiv1.MachineSpec{ProviderID: ptr.To("kubevirt://vm-c")}},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			provider := &Provider{
+				Client:     fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects...).Build(),
+				KubeClient: fakekubeclient.NewSimpleClientset(launcherPod("virt-launcher-vm-a", "uid-a", string(kubevirtv1.GuestConsoleLog))),
+				Namespace:  "clusters-hc",
+			}
+			consoleLog, err := provider.ConsoleLog(context.Background(), &tc.machine)
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(consoleLog)).To(Equal("fake logs"))
+		})
+	}
+}
@@ -14,5 +14,6 @@ func NewCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(aws.NewCommand())
+	cmd.AddCommand(NewMachinesCommand())
 	return cmd
 }
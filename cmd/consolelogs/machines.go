@@ -0,0 +1,109 @@
+package consolelogs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+)
+
+type MachinesOpts struct {
+	Name            string
+	Namespace       string
+	OutputDir       string
+	WithoutNodeOnly bool
+}
+
+func NewMachinesCommand() *cobra.Command {
+	opts := &MachinesOpts{
+		Namespace: "clusters",
+	}
+
+	cmd := &cobra.Command{
+		Use:          "machines",
+		Short:        "Get the console logs of the Machines of a cluster on any platform",
+		Long:         "Get the console logs of the Machines of a cluster: the console output on AWS, the boot diagnostics serial log on Azure, the serial console log of the VirtualMachineInstances on KubeVirt and how to open the console of the instances on PowerVS. The platform credentials are read from the secrets of the HostedCluster, on AWS the default credentials chain is used.",
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace, "A cluster namespace")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "A cluster name")
+	cmd.Flags().StringVar(&opts.OutputDir, "output-dir", opts.OutputDir, "Directory where to place console logs (required)")
+	cmd.Flags().BoolVar(&opts.WithoutNodeOnly, "without-node-only", opts.WithoutNodeOnly, "Only get the console logs of the Machines which never became a Node")
+
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("output-dir")
+
+	logger := log.Log
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Run(cmd.Context()); err != nil {
+			logger.Error(err, "Failed to get console logs")
+			return err
+		}
+		logger.Info("Successfully retrieved console logs")
+		return nil
+	}
+
+	return cmd
+}
+
+func (o *MachinesOpts) Run(ctx context.Context) error {
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+	restConfig, err := util.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	hostedCluster := &hyperv1.HostedCluster{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, hostedCluster); err != nil {
+		return fmt.Errorf("failed to get hostedcluster: %w", err)
+	}
+	provider, err := NewProvider(ctx, c, restConfig, hostedCluster)
+	if err != nil {
+		return err
+	}
+
+	machines := &capiv1.MachineList{}
+	if err := c.List(ctx, machines, crclient.InNamespace(manifests.HostedControlPlaneNamespace(o.Namespace, o.Name))); err != nil {
+		return fmt.Errorf("failed to list machines: %w", err)
+	}
+	items := machines.Items
+	if o.WithoutNodeOnly {
+		items = MachinesWithoutNode(items)
+	}
+	return WriteConsoleLogs(ctx, provider, items, o.OutputDir)
+}
+
+// WriteConsoleLogs writes the console log of every Machine to <outputDir>/<machine name>.log.
+func WriteConsoleLogs(ctx context.Context, provider Provider, machines []capiv1.Machine, outputDir string) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	var errs []error
+	for i := range machines {
+		machine := &machines[i]
+		consoleLog, err := provider.ConsoleLog(ctx, machine)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("machine %s: %w", machine.Name, err))
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, machine.Name+".log"), consoleLog, 0644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,42 @@
+package powervs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// Provider describes how to open the console of the PowerVS instances of Machines. PowerVS doesn't expose
+// the console output of instances through its API and its console URLs grant access to the instances, so
+// only the instance is identified and the console has to be opened interactively.
+type Provider struct{}
+
+func (p *Provider) ConsoleLog(ctx context.Context, machine *capiv1.Machine) ([]byte, error) {
+	serviceInstanceID, instanceID, err := parseProviderID(machine.Spec.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf(`The console output of PowerVS instance %[2]s can't be retrieved through the PowerVS API.
+Open its console from the virtual server instances of the workspace %[1]s in the IBM Cloud console, or with the IBM Cloud CLI:
+  ibmcloud pi workspace target <CRN of workspace %[1]s>
+  ibmcloud pi instance get-console %[2]s
+`, serviceInstanceID, instanceID)), nil
+}
+
+// parseProviderID returns the service instance ID and the instance ID of a provider ID of the form
+// ibmpowervs://<region>/<zone>/<service instance ID>/<instance ID>.
+func parseProviderID(providerID *string) (string, string, error) {
+	if providerID == nil || *providerID == "" {
+		return "", "", fmt.Errorf("machine has no provider ID yet")
+	}
+	if !strings.HasPrefix(*providerID, "ibmpowervs://") {
+		return "", "", fmt.Errorf("invalid PowerVS provider ID %q", *providerID)
+	}
+	segments := strings.Split(strings.TrimPrefix(*providerID, "ibmpowervs://"), "/")
+	if len(segments) != 4 || segments[2] == "" || segments[3] == "" {
+		return "", "", fmt.Errorf("invalid PowerVS provider ID %q", *providerID)
+	}
+	return segments[2], segments[3], nil
+}
@@ -0,0 +1,52 @@
+package powervs
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/utils/ptr"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestProviderConsoleLog(t *testing.T) {
+	testCases := []struct {
+		name        string
+		providerID  *string
+		expected    []string
+		expectedErr bool
+	}{
+		{
+			name:       "When the machine has an instance it should describe how to open its console",
+			providerID: ptr.To("ibmpowervs://us-south/dal10/service-instance/instance-id"),
+			expected:   []string{"workspace service-instance", "ibmcloud pi instance get-console instance-id"},
+		},
+		{
+			name:        "When the machine has no provider ID it should fail",
+			expectedErr: true,
+		},
+		{
+			name:        "When the provider ID is incomplete it should fail",
+			providerID:  ptr.To("ibmpowervs://us-south/dal10/service-instance"),
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			provider := &Provider{}
+			machine := &capiv1.Machine{Spec: capiv1.MachineSpec{ProviderID: tc.providerID}}
+			consoleLog, err := provider.ConsoleLog(context.Background(), machine)
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			for _, expected := range tc.expected {
+				g.Expect(string(consoleLog)).To(ContainSubstring(expected))
+			}
+			g.Expect(string(consoleLog)).ToNot(ContainSubstring("https://"))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package consolelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/consolelogs/aws"
+	"github.com/openshift/hypershift/cmd/consolelogs/azure"
+	"github.com/openshift/hypershift/cmd/consolelogs/kubevirt"
+	"github.com/openshift/hypershift/cmd/consolelogs/powervs"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	kvinfra "github.com/openshift/hypershift/kubevirtexternalinfra"
+	"github.com/openshift/hypershift/support/api"
+)
+
+// Provider returns the console log of the instance backing a Machine.
+type Provider interface {
+	ConsoleLog(ctx context.Context, machine *capiv1.Machine) ([]byte, error)
+}
+
+// ErrPlatformNotSupported is returned by NewProvider for platforms without console logs, e.g. Agent.
+var ErrPlatformNotSupported = errors.New("console logs are not supported on this platform")
+
+var (
+	_ Provider = &aws.Provider{}
+	_ Provider = &azure.Provider{}
+	_ Provider = &kubevirt.Provider{}
+	_ Provider = &powervs.Provider{}
+)
+
+// NewProvider returns the Provider of the platform of a HostedCluster. The credentials are read from the
+// secrets referenced by the HostedCluster, except on AWS where the default credentials chain is used and
+// on PowerVS where none are needed.
+// restConfig is the config of the management cluster c is a client of.
+func NewProvider(ctx context.Context, c crclient.Client, restConfig *restclient.Config, hcluster *hyperv1.HostedCluster) (Provider, error) {
+	switch hcluster.Spec.Platform.Type {
+	case hyperv1.AWSPlatform:
+		return aws.NewProvider(hcluster.Spec.Platform.AWS.Region), nil
+	case hyperv1.AzurePlatform:
+		secret, err := credentialsSecret(ctx, c, hcluster.Namespace, hcluster.Spec.Platform.Azure.Credentials.Name)
+		if err != nil {
+			return nil, err
+		}
+		subscriptionID := hcluster.Spec.Platform.Azure.SubscriptionID
+		if subscriptionID == "" {
+			subscriptionID = string(secret.Data["AZURE_SUBSCRIPTION_ID"])
+		}
+		return azure.NewProvider(subscriptionID, string(secret.Data["AZURE_TENANT_ID"]), string(secret.Data["AZURE_CLIENT_ID"]), string(secret.Data["AZURE_CLIENT_SECRET"]))
+	case hyperv1.PowerVSPlatform:
+		return &powervs.Provider{}, nil
+	case hyperv1.KubevirtPlatform:
+		return newKubevirtProvider(ctx, c, restConfig, hcluster)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPlatformNotSupported, hcluster.Spec.Platform.Type)
+	}
+}
+
+// newKubevirtProvider returns a KubeVirt Provider for the external infra cluster of a HostedCluster if it
+// has one, for the control plane namespace of the management cluster otherwise.
+func newKubevirtProvider(ctx context.Context, c crclient.Client, restConfig *restclient.Config, hcluster *hyperv1.HostedCluster) (Provider, error) {
+	namespace := manifests.HostedControlPlaneNamespace(hcluster.Namespace, hcluster.Name)
+	if kubevirtPlatform := hcluster.Spec.Platform.Kubevirt; kubevirtPlatform != nil && kubevirtPlatform.Credentials != nil && kubevirtPlatform.Credentials.InfraKubeConfigSecret != nil {
+		kubeconfig, err := kvinfra.GetKubeConfig(ctx, c, hcluster.Namespace, kubevirtPlatform.Credentials.InfraKubeConfigSecret.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get KubeVirt external infra-cluster: %w", err)
+		}
+		restConfig, err = clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("can't get infra cluster rest config: %w", err)
+		}
+		c, err = crclient.New(restConfig, crclient.Options{Scheme: api.Scheme})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create infra cluster client: %w", err)
+		}
+		namespace = kubevirtPlatform.Credentials.InfraNamespace
+	}
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a kubernetes client: %w", err)
+	}
+	return &kubevirt.Provider{Client: c, KubeClient: kubeClient, Namespace: namespace}, nil
+}
+
+func credentialsSecret(ctx context.Context, c crclient.Client, namespace, name string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, crclient.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret %s/%s: %w", namespace, name, err)
+	}
+	return secret, nil
+}
+
+// MachinesWithoutNode returns the Machines which never became a Node.
+func MachinesWithoutNode(machines []capiv1.Machine) []capiv1.Machine {
+	var result []capiv1.Machine
+	for _, machine := range machines {
+		if machine.Status.NodeRef == nil {
+			result = append(result, machine)
+		}
+	}
+	return result
+}
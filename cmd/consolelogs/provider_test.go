@@ -0,0 +1,112 @@
+package consolelogs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	restclient "k8s.io/client-go/rest"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/consolelogs/aws"
+	"github.com/openshift/hypershift/cmd/consolelogs/kubevirt"
+	"github.com/openshift/hypershift/cmd/consolelogs/powervs"
+	"github.com/openshift/hypershift/support/api"
+)
+
+type fakeProvider struct{}
+
+func (fakeProvider) ConsoleLog(_ context.Context, machine *capiv1.Machine) ([]byte, error) {
+	if machine.Spec.ProviderID == nil {
+		return nil, errors.New("machine has no provider ID yet")
+	}
+	return []byte("console of " + *machine.Spec.ProviderID), nil
+}
+
+func machine(name string, providerID *string, hasNode bool) capiv1.Machine {
+	m := capiv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: name},
+		Spec:       capiv1.MachineSpec{ProviderID: providerID},
+	}
+	if hasNode {
+		m.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: name}
+	}
+	return m
+}
+
+func TestMachinesWithoutNode(t *testing.T) {
+	g := NewWithT(t)
+	machines := []capiv1.Machine{machine("joined", nil, true), machine("stuck", nil, false)}
+	result := MachinesWithoutNode(machines)
+	g.Expect(result).To(HaveLen(1))
+	g.Expect(result[0].Name).To(Equal("stuck"))
+}
+
+func TestWriteConsoleLogs(t *testing.T) {
+	g := NewWithT(t)
+	outputDir := t.TempDir()
+	providerID := "fake://a"
+	machines := []capiv1.Machine{machine("a", &providerID, false), machine("b", nil, false)}
+
+	err := WriteConsoleLogs(context.Background(), fakeProvider{}, machines, outputDir)
+	g.Expect(err).To(MatchError(ContainSubstring("machine b")))
+	content, err := os.ReadFile(filepath.Join(outputDir, "a.log"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal("console of fake://a"))
+	g.Expect(filepath.Join(outputDir, "b.log")).ToNot(BeAnExistingFile())
+}
+
+func TestNewProvider(t *testing.T) {
+	testCases := []struct {
+		name        string
+		platform    hyperv1.PlatformSpec
+		expected    Provider
+		expectedErr error
+	}{
+		{
+			name:     "When the platform is AWS it should return an AWS provider",
+			platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform, AWS: &hyperv1.AWSPlatformSpec{Region: "us-east-1"}},
+			expected: &aws.Provider{},
+		},
+		{
+			name:     "When the platform is PowerVS it should return a PowerVS provider without reading credentials",
+			platform: hyperv1.PlatformSpec{Type: hyperv1.PowerVSPlatform, PowerVS: &hyperv1.PowerVSPlatformSpec{}},
+			expected: &powervs.Provider{},
+		},
+		{
+			name:     "When the platform is KubeVirt it should return a KubeVirt provider for the control plane namespace",
+			platform: hyperv1.PlatformSpec{Type: hyperv1.KubevirtPlatform, Kubevirt: &hyperv1.KubevirtPlatformSpec{}},
+			expected: &kubevirt.Provider{},
+		},
+		{
+			name:        "When the platform has no console logs it should fail with ErrPlatformNotSupported",
+			platform:    hyperv1.PlatformSpec{Type: hyperv1.AgentPlatform},
+			expectedErr: ErrPlatformNotSupported,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).Build()
+			hcluster := &hyperv1.HostedCluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"},
+				Spec:       hyperv1.HostedClusterSpec{Platform: tc.platform},
+			}
+			provider, err := NewProvider(context.Background(), c, &restclient.Config{Host: "https://localhost"}, hcluster)
+			if tc.expectedErr != nil {
+				g.Expect(err).To(MatchError(tc.expectedErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(provider).To(BeAssignableToTypeOf(tc.expected))
+		})
+	}
+}
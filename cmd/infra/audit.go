@@ -0,0 +1,19 @@
+package infra
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/hypershift/cmd/infra/aws"
+)
+
+func NewAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "audit",
+		Short:        "Commands for finding HyperShift infra resources left behind by deleted HostedClusters",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(aws.NewAuditCommand())
+
+	return cmd
+}
@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/hypershift/cmd/log"
+	awsinfra "github.com/openshift/hypershift/support/infra/aws"
+)
+
+func NewAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "Finds AWS resources of infra IDs that no longer have a HostedCluster",
+		Long: `Finds AWS resources of infra IDs that no longer have a HostedCluster.
+
+Resources are attributed to an infra ID by their kubernetes.io/cluster/<infra ID> tag, private hosted
+zones by the VPC of the infra ID they are associated with, the wildcard ingress record by the cluster
+domain of those private hosted zones and OIDC documents by their key in the OIDC bucket. Infra IDs of all
+HostedClusters of the management cluster, and the infrastructure name of the management cluster itself, are
+considered in use. Clusters that are not managed by this management cluster, such as other standalone
+OpenShift clusters or HostedClusters of other management clusters, use the same tags: use --exclude-infra-ids
+or --infra-ids to leave them alone. --delete only destroys the infrastructure of the infra IDs explicitly
+listed with --infra-ids, after they were reviewed in the output of a previous audit.`,
+		SilenceUsage: true,
+	}
+
+	opts := awsinfra.AuditInfraOptions{
+		Region:              "us-east-1",
+		AwsInfraGracePeriod: 10 * time.Minute,
+		Log:                 log.Log,
+	}
+
+	cmd.Flags().StringVar(&opts.Region, "region", opts.Region, "Region of the infrastructure to audit")
+	cmd.Flags().StringSliceVar(&opts.InfraIDs, "infra-ids", opts.InfraIDs, "If set, only these infra IDs are audited")
+	cmd.Flags().StringSliceVar(&opts.ExcludeInfraIDs, "exclude-infra-ids", opts.ExcludeInfraIDs, "Infra IDs that are never reported nor deleted, e.g. of clusters not managed by this management cluster")
+	cmd.Flags().StringVar(&opts.OIDCBucketName, "oidc-storage-provider-s3-bucket-name", opts.OIDCBucketName, "Name of the OIDC bucket of the HyperShift operator, if set the OIDC documents of the infra IDs are audited too")
+	cmd.Flags().BoolVar(&opts.Delete, "delete", opts.Delete, "If enabled, the infrastructure of the orphaned infra IDs is destroyed. Requires --infra-ids")
+	cmd.Flags().DurationVar(&opts.AwsInfraGracePeriod, "aws-infra-grace-period", opts.AwsInfraGracePeriod, "Timeout for destroying the infrastructure of each orphaned infra ID")
+
+	opts.AWSCredentialsOpts.BindFlags(cmd.Flags())
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		if err := opts.Run(cmd.Context(), os.Stdout); err != nil {
+			opts.Log.Error(err, "Failed to audit infrastructure")
+			return err
+		}
+		return nil
+	}
+
+	return cmd
+}
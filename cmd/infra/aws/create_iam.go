@@ -1,57 +1,13 @@
 package aws
 
 import (
-	"context"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"os"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/iam"
-	"github.com/go-logr/logr"
 	"github.com/spf13/cobra"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	utilerrors "k8s.io/apimachinery/pkg/util/errors"
-
-	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 
-	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
-	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
 	"github.com/openshift/hypershift/cmd/log"
 	"github.com/openshift/hypershift/cmd/util"
+	awsinfra "github.com/openshift/hypershift/support/infra/aws"
 )
 
-type CreateIAMOptions struct {
-	Region                          string
-	AWSCredentialsOpts              awsutil.AWSCredentialsOptions
-	OIDCStorageProviderS3BucketName string
-	OIDCStorageProviderS3Region     string
-	PublicZoneID                    string
-	PrivateZoneID                   string
-	LocalZoneID                     string
-	InfraID                         string
-	IssuerURL                       string
-	OutputFile                      string
-	KMSKeyARN                       string
-	AdditionalTags                  []string
-
-	CredentialsSecretData *util.CredentialsSecretData
-
-	additionalIAMTags []*iam.Tag
-}
-
-type CreateIAMOutput struct {
-	Region             string              `json:"region"`
-	ProfileName        string              `json:"profileName"`
-	InfraID            string              `json:"infraID"`
-	IssuerURL          string              `json:"issuerURL"`
-	Roles              hyperv1.AWSRolesRef `json:"roles"`
-	KMSKeyARN          string              `json:"kmsKeyARN"`
-	KMSProviderRoleARN string              `json:"kmsProviderRoleARN"`
-}
-
 func NewCreateIAMCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "aws",
@@ -59,7 +15,7 @@ func NewCreateIAMCommand() *cobra.Command {
 		SilenceUsage: true,
 	}
 
-	opts := CreateIAMOptions{
+	opts := awsinfra.CreateIAMOptions{
 		Region:  "us-east-1",
 		InfraID: "",
 	}
@@ -67,7 +23,7 @@ func NewCreateIAMCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.InfraID, "infra-id", opts.InfraID, "Infrastructure ID to use for AWS resources.")
 	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3BucketName, "oidc-storage-provider-s3-bucket-name", "", "The name of the bucket in which the OIDC discovery document is stored")
 	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3Region, "oidc-storage-provider-s3-region", "", "The region of the bucket in which the OIDC discovery document is stored")
-	cmd.Flags().StringVar(&opts.IssuerURL, "oidc-issuer-url", "", "The OIDC provider issuer URL")
+	cmd.Flags().StringVar(&opts.IssuerURL, "oidc-issuer-url", "", "The OIDC provider issuer URL. Its IAM OIDC provider must exist. Required when the HyperShift operator does not store the OIDC documents in S3, e.g. https://<oidc-discovery route host>/<infra id> for the in-cluster storage provider")
 	cmd.Flags().StringVar(&opts.Region, "region", opts.Region, "Region where cluster infra should be created")
 	cmd.Flags().StringVar(&opts.OutputFile, "output-file", opts.OutputFile, "Path to file that will contain output information from infra resources (optional)")
 	cmd.Flags().StringVar(&opts.PublicZoneID, "public-zone-id", opts.PublicZoneID, "The id of the clusters public route53 zone")
@@ -75,6 +31,8 @@ func NewCreateIAMCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.LocalZoneID, "local-zone-id", opts.LocalZoneID, "The id of the clusters local route53 zone")
 	cmd.Flags().StringVar(&opts.KMSKeyARN, "kms-key-arn", opts.KMSKeyARN, "The ARN of the KMS key to use for Etcd encryption. If not supplied, etcd encryption will default to using a generated AESCBC key.")
 	cmd.Flags().StringSliceVar(&opts.AdditionalTags, "additional-tags", opts.AdditionalTags, "Additional tags to set on AWS resources")
+	cmd.Flags().BoolVar(&opts.PrintPolicies, "print-policies", opts.PrintPolicies, "Print the permissions policies of the roles as JSON instead of creating them")
+	cmd.Flags().StringVar(&opts.ValidateRolesFile, "validate-roles-file", opts.ValidateRolesFile, "Path to a file with the roles of a cluster, in the format of the output file, whose policies are checked for missing permissions instead of creating the roles")
 
 	opts.AWSCredentialsOpts.BindFlags(cmd.Flags())
 
@@ -87,10 +45,16 @@ func NewCreateIAMCommand() *cobra.Command {
 
 	logger := log.Log
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if opts.PrintPolicies {
+			return opts.OutputPolicies()
+		}
 		err := opts.AWSCredentialsOpts.Validate()
 		if err != nil {
 			return err
 		}
+		if opts.ValidateRolesFile != "" {
+			return opts.RunValidateRoles(cmd.Context(), logger)
+		}
 		client, err := util.GetClient()
 		if err != nil {
 			logger.Error(err, "failed to create client")
@@ -105,106 +69,3 @@ func NewCreateIAMCommand() *cobra.Command {
 
 	return cmd
 }
-
-func (o *CreateIAMOptions) Run(ctx context.Context, client crclient.Client, logger logr.Logger) error {
-	results, err := o.CreateIAM(ctx, client, logger)
-	if err != nil {
-		return err
-	}
-	return o.Output(results)
-}
-
-func (o *CreateIAMOptions) Output(results *CreateIAMOutput) error {
-	// Write out stateful information
-	out := os.Stdout
-	if len(o.OutputFile) > 0 {
-		var err error
-		out, err = os.Create(o.OutputFile)
-		if err != nil {
-			return fmt.Errorf("cannot create output file: %w", err)
-		}
-		defer out.Close()
-	}
-	outputBytes, err := json.MarshalIndent(results, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to serialize result: %w", err)
-	}
-	_, err = out.Write(outputBytes)
-	if err != nil {
-		return fmt.Errorf("failed to write result: %w", err)
-	}
-	return nil
-}
-
-func (o *CreateIAMOptions) CreateIAM(ctx context.Context, client crclient.Client, logger logr.Logger) (*CreateIAMOutput, error) {
-	var err error
-	if err = o.ParseAdditionalTags(); err != nil {
-		return nil, err
-	}
-	if o.OIDCStorageProviderS3BucketName == "" || o.OIDCStorageProviderS3Region == "" {
-		cm := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-public", Name: "oidc-storage-provider-s3-config"},
-		}
-		if err := client.Get(ctx, crclient.ObjectKeyFromObject(cm), cm); err != nil {
-			return nil, fmt.Errorf("failed to discover OIDC bucket configuration: failed to get the %s/%s configmap: %w", cm.Namespace, cm.Name, err)
-		}
-		// Set both, doesn't make sense to only get one from the configmap
-		o.OIDCStorageProviderS3BucketName = cm.Data["name"]
-		o.OIDCStorageProviderS3Region = cm.Data["region"]
-	}
-
-	var errs []error
-	if o.OIDCStorageProviderS3BucketName == "" {
-		errs = append(errs, errors.New("mandatory --oidc-storage-provider-s3-bucket-name could not be discovered from the cluster's ConfigMap in 'kube-public' and wasn't excplicitly passed either"))
-	}
-	if o.OIDCStorageProviderS3Region == "" {
-		errs = append(errs, errors.New("mandatory --oidc-storage-provider-s3-region could not be discovered from cluster's  ConfigMap in 'kube-public' and wasn't explicitly passed either"))
-	}
-	if err := utilerrors.NewAggregate(errs); err != nil {
-		return nil, err
-	}
-
-	awsSession, err := o.AWSCredentialsOpts.GetSession("cli-create-iam", o.CredentialsSecretData, o.Region)
-	if err != nil {
-		return nil, err
-	}
-
-	awsConfig := awsutil.NewConfig()
-	iamClient := iam.New(awsSession, awsConfig)
-
-	results, err := o.CreateOIDCResources(iamClient, logger)
-	if err != nil {
-		return nil, err
-	}
-	profileName := DefaultProfileName(o.InfraID)
-	results.ProfileName = profileName
-	results.KMSKeyARN = o.KMSKeyARN
-	err = o.CreateWorkerInstanceProfile(iamClient, profileName, logger)
-	if err != nil {
-		return nil, err
-	}
-	logger.Info("Created IAM profile", "name", profileName, "region", o.Region)
-
-	return results, nil
-}
-
-func (o *CreateIAMOptions) ParseAdditionalTags() error {
-	parsed, err := util.ParseAWSTags(o.AdditionalTags)
-	if err != nil {
-		return err
-	}
-	for k, v := range parsed {
-		o.additionalIAMTags = append(o.additionalIAMTags, &iam.Tag{
-			Key:   aws.String(k),
-			Value: aws.String(v),
-		})
-	}
-	return nil
-}
-
-func oidcDiscoveryURL(bucketName, region, infraID string) string {
-	if bucketName == "" || region == "" || infraID == "" {
-		panic(fmt.Sprintf("bucket: %q, region: %q, infraID: %q", bucketName, region, infraID))
-	}
-	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucketName, region, infraID)
-}
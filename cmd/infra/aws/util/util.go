@@ -73,6 +73,11 @@ func (opts *AWSCredentialsOptions) GetSession(agent string, secretData *util.Cre
 			secretData.AWSSecretAccessKey,
 			secretData.AWSSessionToken,
 		)
+		if opts.RoleArn == "" {
+			awsSession := NewSession(agent, "", "", "", region)
+			awsSession.Config.Credentials = creds
+			return awsSession, nil
+		}
 		return NewSTSSession(agent, opts.RoleArn, region, creds)
 	}
 
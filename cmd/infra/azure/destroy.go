@@ -1,28 +1,12 @@
 package azure
 
 import (
-	"context"
-	"fmt"
+	"github.com/spf13/cobra"
 
-	"github.com/go-logr/logr"
 	"github.com/openshift/hypershift/cmd/log"
-	"github.com/openshift/hypershift/cmd/util"
-
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
-
-	"github.com/spf13/cobra"
+	azureinfra "github.com/openshift/hypershift/support/infra/azure"
 )
 
-type DestroyInfraOptions struct {
-	Name              string
-	Location          string
-	InfraID           string
-	CredentialsFile   string
-	Credentials       *util.AzureCreds
-	ResourceGroupName string
-}
-
 func NewDestroyCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "azure",
@@ -30,7 +14,7 @@ func NewDestroyCommand() *cobra.Command {
 		SilenceUsage: true,
 	}
 
-	opts := DestroyInfraOptions{
+	opts := azureinfra.DestroyInfraOptions{
 		Location: "eastus",
 	}
 
@@ -57,37 +41,3 @@ func NewDestroyCommand() *cobra.Command {
 	return cmd
 
 }
-
-func (o *DestroyInfraOptions) Run(ctx context.Context, logger logr.Logger) error {
-	var destroyFuture *runtime.Poller[armresources.ResourceGroupsClientDeleteResponse]
-
-	// Setup subscription ID and Azure credential information
-	subscriptionID, azureCreds, err := util.SetupAzureCredentials(logger, o.Credentials, o.CredentialsFile)
-	if err != nil {
-		return fmt.Errorf("failed to setup Azure credentials: %w", err)
-	}
-
-	// Setup Azure resource group client
-	resourceGroupClient, err := armresources.NewResourceGroupsClient(subscriptionID, azureCreds, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create new resource groups client: %w", err)
-	}
-
-	destroyFuture, err = resourceGroupClient.BeginDelete(ctx, o.GetResourceGroupName(), nil)
-	if err != nil {
-		return fmt.Errorf("failed to start deletion: %w", err)
-	}
-
-	if _, err = destroyFuture.PollUntilDone(ctx, nil); err != nil {
-		return fmt.Errorf("failed to wait for resourceGroup deletion: %w", err)
-	}
-
-	return nil
-}
-
-func (o *DestroyInfraOptions) GetResourceGroupName() string {
-	if len(o.ResourceGroupName) > 0 {
-		return o.ResourceGroupName
-	}
-	return o.Name + "-" + o.InfraID
-}
@@ -0,0 +1,18 @@
+package infra
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCommand returns the commands maintaining the infra resources of existing and deleted HostedClusters.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "infra",
+		Short:        "Inspect and maintain HyperShift infra resources",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(NewAuditCommand())
+
+	return cmd
+}
@@ -26,6 +26,7 @@ import (
 	"k8s.io/utils/ptr"
 
 	"github.com/google/uuid"
+	routev1 "github.com/openshift/api/route/v1"
 	prometheusoperatorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	cdicore "kubevirt.io/containerized-data-importer-api/pkg/apis/core"
 )
@@ -358,6 +359,8 @@ type HyperShiftOperatorDeployment struct {
 	OIDCBucketRegion                        string
 	OIDCStorageProviderS3Secret             *corev1.Secret
 	OIDCStorageProviderS3SecretKey          string
+	OIDCStorageProviderS3Endpoint           string
+	OIDCStorageProviderInCluster            bool
 	MetricsSet                              metrics.MetricsSet
 	IncludeVersion                          bool
 	UWMTelemetry                            bool
@@ -438,6 +441,9 @@ func (o HyperShiftOperatorDeployment) Build() *appsv1.Deployment {
 			"--oidc-storage-provider-s3-region="+o.OIDCBucketRegion,
 			"--oidc-storage-provider-s3-credentials=/etc/oidc-storage-provider-s3-creds/"+o.OIDCStorageProviderS3SecretKey,
 		)
+		if len(o.OIDCStorageProviderS3Endpoint) > 0 {
+			args = append(args, "--oidc-storage-provider-s3-endpoint="+o.OIDCStorageProviderS3Endpoint)
+		}
 		volumeMounts = append(volumeMounts, corev1.VolumeMount{
 			Name:      "oidc-storage-provider-s3-creds",
 			MountPath: "/etc/oidc-storage-provider-s3-creds",
@@ -452,6 +458,30 @@ func (o HyperShiftOperatorDeployment) Build() *appsv1.Deployment {
 		})
 	}
 
+	ports := []corev1.ContainerPort{
+		{
+			Name:          "metrics",
+			ContainerPort: 9000,
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "manager",
+			ContainerPort: 9443,
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+	if o.OIDCStorageProviderInCluster {
+		args = append(args,
+			"--oidc-storage-provider=in-cluster",
+			fmt.Sprintf("--oidc-storage-provider-in-cluster-addr=:%d", oidcDiscoveryPort),
+		)
+		ports = append(ports, corev1.ContainerPort{
+			Name:          "oidc-discovery",
+			ContainerPort: oidcDiscoveryPort,
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+
 	if o.UWMTelemetry {
 		args = append(args, "--enable-uwm-telemetry-remote-write")
 	}
@@ -674,18 +704,7 @@ func (o HyperShiftOperatorDeployment) Build() *appsv1.Deployment {
 								FailureThreshold:    int32(3),
 								TimeoutSeconds:      int32(5),
 							},
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          "metrics",
-									ContainerPort: 9000,
-									Protocol:      corev1.ProtocolTCP,
-								},
-								{
-									Name:          "manager",
-									ContainerPort: 9443,
-									Protocol:      corev1.ProtocolTCP,
-								},
-							},
+							Ports: ports,
 							Resources: corev1.ResourceRequirements{
 								Requests: corev1.ResourceList{
 									corev1.ResourceMemory: resource.MustParse("150Mi"),
@@ -792,6 +811,75 @@ func (o HyperShiftOperatorService) Build() *corev1.Service {
 	}
 }
 
+const oidcDiscoveryPort = 9080
+
+type HyperShiftOperatorOIDCDiscoveryService struct {
+	Namespace *corev1.Namespace
+}
+
+func (o HyperShiftOperatorOIDCDiscoveryService) Build() *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: o.Namespace.Name,
+			Name:      "oidc-discovery",
+			Labels: map[string]string{
+				"name": HypershiftOperatorName,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{
+				"name": HypershiftOperatorName,
+			},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "oidc-discovery",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       8080,
+					TargetPort: intstr.FromString("oidc-discovery"),
+				},
+			},
+		},
+	}
+}
+
+// HyperShiftOperatorOIDCDiscoveryRoute exposes the OIDC documents served by the operator. The
+// issuer URL of a cluster is https://<route host>/<infra id>.
+type HyperShiftOperatorOIDCDiscoveryRoute struct {
+	Namespace *corev1.Namespace
+	Service   *corev1.Service
+}
+
+func (o HyperShiftOperatorOIDCDiscoveryRoute) Build() *routev1.Route {
+	return &routev1.Route{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Route",
+			APIVersion: routev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: o.Namespace.Name,
+			Name:      o.Service.Name,
+		},
+		Spec: routev1.RouteSpec{
+			To: routev1.RouteTargetReference{
+				Kind: "Service",
+				Name: o.Service.Name,
+			},
+			Port: &routev1.RoutePort{
+				TargetPort: intstr.FromString("oidc-discovery"),
+			},
+			TLS: &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationEdge,
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
+			},
+		},
+	}
+}
+
 type ExternalDNSServiceAccount struct {
 	Namespace *corev1.Namespace
 }
@@ -1127,6 +1215,12 @@ func (o HyperShiftOperatorClusterRole) Build() *rbacv1.ClusterRole {
 				},
 				Verbs: []string{rbacv1.VerbAll},
 			},
+			{
+				// the sizing controller reads the usage of control plane components
+				APIGroups: []string{"metrics.k8s.io"},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get", "list"},
+			},
 			{
 				APIGroups:     []string{"admissionregistration.k8s.io"},
 				Resources:     []string{"validatingwebhookconfigurations"},
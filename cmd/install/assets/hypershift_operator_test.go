@@ -152,6 +152,36 @@ func TestHyperShiftOperatorDeployment_Build(t *testing.T) {
 				},
 			},
 		},
+		"in-cluster oidc storage provider serves the oidc documents from the operator": {
+			inputBuildParameters: HyperShiftOperatorDeployment{
+				Namespace: &corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: testNamespace,
+					},
+				},
+				OperatorImage: testOperatorImage,
+				ServiceAccount: &corev1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "hypershift",
+					},
+				},
+				Replicas:                     3,
+				PrivatePlatform:              string(hyperv1.NonePlatform),
+				OIDCStorageProviderInCluster: true,
+			},
+			expectedArgs: []string{
+				"run",
+				"--namespace=$(MY_NAMESPACE)",
+				"--pod-name=$(MY_NAME)",
+				"--metrics-addr=:9000",
+				fmt.Sprintf("--enable-dedicated-request-serving-isolation=%t", false),
+				fmt.Sprintf("--enable-ocp-cluster-monitoring=%t", false),
+				fmt.Sprintf("--enable-ci-debug-output=%t", false),
+				fmt.Sprintf("--private-platform=%s", string(hyperv1.NonePlatform)),
+				"--oidc-storage-provider=in-cluster",
+				"--oidc-storage-provider-in-cluster-addr=:9080",
+			},
+		},
 		"specify aws private creds and oidc parameters result in appropriate volumes and volumeMounts": {
 			inputBuildParameters: HyperShiftOperatorDeployment{
 				Namespace: &corev1.Namespace{
@@ -80,6 +80,8 @@ type Options struct {
 	OIDCStorageProviderS3Credentials          string
 	OIDCStorageProviderS3CredentialsSecret    string
 	OIDCStorageProviderS3CredentialsSecretKey string
+	OIDCStorageProviderS3Endpoint             string
+	OIDCStorageProviderInCluster              bool
 	ExternalDNSProvider                       string
 	ExternalDNSCredentials                    string
 	ExternalDNSCredentialsSecret              string
@@ -122,6 +124,9 @@ func (o *Options) Validate() error {
 		(len(o.OIDCStorageProviderS3BucketName) == 0 || len(o.OIDCStorageProviderS3Region) == 0 || len(o.OIDCStorageProviderS3CredentialsSecretKey) == 0) {
 		errs = append(errs, fmt.Errorf("all required oidc information is not set"))
 	}
+	if o.OIDCStorageProviderInCluster && len(o.OIDCStorageProviderS3BucketName) > 0 {
+		errs = append(errs, fmt.Errorf("only one of --oidc-storage-provider-in-cluster or --oidc-storage-provider-s3-bucket-name is supported"))
+	}
 	if strings.Contains(o.OIDCStorageProviderS3BucketName, ".") {
 		errs = append(errs, fmt.Errorf("oidc bucket name must not contain dots (.); see the notes on HTTPS at https://docs.aws.amazon.com/AmazonS3/latest/userguide/bucketnamingrules.html"))
 	}
@@ -207,6 +212,8 @@ func NewCommand() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&opts.OIDCStorageProviderS3Credentials, "oidc-storage-provider-s3-credentials", opts.OIDCStorageProviderS3Credentials, "Credentials to use for writing the OIDC documents into the S3 bucket. Required for AWS guest clusters")
 	cmd.PersistentFlags().StringVar(&opts.OIDCStorageProviderS3CredentialsSecret, "oidc-storage-provider-s3-secret", "", "Name of an existing secret containing the OIDC S3 credentials.")
 	cmd.PersistentFlags().StringVar(&opts.OIDCStorageProviderS3CredentialsSecretKey, "oidc-storage-provider-s3-secret-key", "credentials", "Name of the secret key containing the OIDC S3 credentials.")
+	cmd.PersistentFlags().StringVar(&opts.OIDCStorageProviderS3Endpoint, "oidc-storage-provider-s3-endpoint", opts.OIDCStorageProviderS3Endpoint, "Endpoint of an S3 compatible object storage holding the OIDC bucket. Defaults to AWS S3")
+	cmd.PersistentFlags().BoolVar(&opts.OIDCStorageProviderInCluster, "oidc-storage-provider-in-cluster", opts.OIDCStorageProviderInCluster, "If true, the operator serves the clusters OIDC discovery information itself through the oidc-discovery Route in its namespace, instead of storing it in a bucket. The issuer URL of a cluster is https://<route host>/<infra id> and must be passed with --oidc-issuer-url when creating it")
 	cmd.PersistentFlags().StringVar(&opts.ExternalDNSProvider, "external-dns-provider", opts.ExternalDNSProvider, "Provider to use for managing DNS records using external-dns")
 	cmd.PersistentFlags().StringVar(&opts.ExternalDNSCredentials, "external-dns-credentials", opts.OIDCStorageProviderS3Credentials, "Credentials to use for managing DNS records using external-dns")
 	cmd.PersistentFlags().StringVar(&opts.ExternalDNSCredentialsSecret, "external-dns-secret", "", "Name of an existing secret containing the external-dns credentials.")
@@ -686,6 +693,8 @@ func hyperShiftOperatorManifests(opts Options) ([]crclient.Object, []crclient.Ob
 		OIDCBucketRegion:                        opts.OIDCStorageProviderS3Region,
 		OIDCStorageProviderS3Secret:             oidcSecret,
 		OIDCStorageProviderS3SecretKey:          opts.OIDCStorageProviderS3CredentialsSecretKey,
+		OIDCStorageProviderS3Endpoint:           opts.OIDCStorageProviderS3Endpoint,
+		OIDCStorageProviderInCluster:            opts.OIDCStorageProviderInCluster,
 		Images:                                  images,
 		MetricsSet:                              opts.MetricsSet,
 		IncludeVersion:                          !opts.Template,
@@ -705,6 +714,19 @@ func hyperShiftOperatorManifests(opts Options) ([]crclient.Object, []crclient.Ob
 	}.Build()
 	objects = append(objects, operatorService)
 
+	if opts.OIDCStorageProviderInCluster {
+		oidcDiscoveryService := assets.HyperShiftOperatorOIDCDiscoveryService{
+			Namespace: operatorNamespace,
+		}.Build()
+		objects = append(objects, oidcDiscoveryService)
+
+		oidcDiscoveryRoute := assets.HyperShiftOperatorOIDCDiscoveryRoute{
+			Namespace: operatorNamespace,
+			Service:   oidcDiscoveryService,
+		}.Build()
+		objects = append(objects, oidcDiscoveryRoute)
+	}
+
 	prometheusRole := assets.HyperShiftPrometheusRole{
 		Namespace: operatorNamespace,
 	}.Build()
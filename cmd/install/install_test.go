@@ -73,6 +73,14 @@ func TestOptions_Validate(t *testing.T) {
 			},
 			expectError: true,
 		},
+		"when the in-cluster OIDC storage provider is set with an OIDC bucket it errors": {
+			inputOptions: Options{
+				PrivatePlatform:                 string(hyperv1.NonePlatform),
+				OIDCStorageProviderInCluster:    true,
+				OIDCStorageProviderS3BucketName: "mybucket",
+			},
+			expectError: true,
+		},
 		"when all data specified there is no error": {
 			inputOptions: Options{
 				PrivatePlatform:                           string(hyperv1.NonePlatform),
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
@@ -17,6 +18,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/tunnel"
 	"github.com/openshift/hypershift/cmd/util"
 )
 
@@ -35,11 +37,36 @@ HostedCluster following the pattern:
 
 The kubeconfig for each cluster is based on the secret referenced by the status
 of the HostedCluster itself.
+
+With --short-lived, a single cluster must be specified and, instead of copying
+the admin kubeconfig, a client certificate for the current user is requested
+from the cluster's break-glass signer, approved, and embedded in the printed
+kubeconfig. The certificate expires after --expiration. It carries no groups
+unless --groups is set; requesting the cluster-admin group system:masters also
+requires --allow-system-masters.
+
+With --exec-plugin, the printed kubeconfig instead configures an exec credential
+plugin which re-runs this command to issue a fresh short-lived certificate every
+time the previous one expires, so the kubeconfig can be kept indefinitely.
+
+With --via-port-forward, a single cluster must be specified and the printed
+kubeconfig points at a local port forwarded to the kube-apiserver of the cluster
+through the management cluster. The command keeps the port-forward, reconnecting
+when the kube-apiserver pods restart, until interrupted. This allows reaching
+clusters whose API server is not exposed, see also the tunnel command.
 `
 
 type options struct {
 	namespace string
 	name      string
+
+	shortLived     bool
+	execPlugin     bool
+	execCredential bool
+	shortLivedOpts ShortLivedOptions
+
+	viaPortForward bool
+	tunnelOpts     tunnel.Options
 }
 
 // NewCreateCommand returns a command which can render kubeconfigs for HostedCluster
@@ -53,14 +80,29 @@ func NewCreateCommand() *cobra.Command {
 	}
 
 	opts := options{
-		namespace: "clusters",
+		namespace:      "clusters",
+		shortLivedOpts: DefaultShortLivedOptions(),
+		tunnelOpts:     tunnel.DefaultOptions(),
 	}
 
 	cmd.Flags().StringVar(&opts.namespace, "namespace", opts.namespace, "A HostedCluster namespace. Defaults to 'clusters'.")
 	cmd.Flags().StringVar(&opts.name, "name", opts.name, "A HostedCluster name.")
+	cmd.Flags().BoolVar(&opts.shortLived, "short-lived", opts.shortLived, "Issue a short-lived client certificate for the current user instead of copying the admin kubeconfig. Requires --name.")
+	cmd.Flags().BoolVar(&opts.execPlugin, "exec-plugin", opts.execPlugin, "Render a kubeconfig which issues short-lived client certificates on demand through an exec credential plugin. Requires --name.")
+	cmd.Flags().BoolVar(&opts.execCredential, "exec-credential", opts.execCredential, "Issue a short-lived client certificate and print it as an ExecCredential. Used by the exec credential plugin.")
+	cmd.Flags().StringVar(&opts.shortLivedOpts.Signer, "signer", opts.shortLivedOpts.Signer, "The break-glass signer used to sign short-lived client certificates.")
+	cmd.Flags().StringVar(&opts.shortLivedOpts.Username, "username", opts.shortLivedOpts.Username, "The username recorded in short-lived client certificates. Defaults to the current user.")
+	cmd.Flags().StringSliceVar(&opts.shortLivedOpts.Groups, "groups", opts.shortLivedOpts.Groups, "The groups recorded in short-lived client certificates. None by default.")
+	cmd.Flags().BoolVar(&opts.shortLivedOpts.AllowSystemMasters, "allow-system-masters", opts.shortLivedOpts.AllowSystemMasters, "Allow --groups to include system:masters, which makes short-lived client certificates cluster-admin.")
+	cmd.Flags().DurationVar(&opts.shortLivedOpts.Expiration, "expiration", opts.shortLivedOpts.Expiration, "The requested validity of short-lived client certificates.")
+	cmd.Flags().DurationVar(&opts.shortLivedOpts.Timeout, "timeout", opts.shortLivedOpts.Timeout, "How long to wait for a short-lived client certificate to be approved and signed.")
+	cmd.Flags().BoolVar(&opts.viaPortForward, "via-port-forward", opts.viaPortForward, "Render a kubeconfig pointing at a local port forwarded to the kube-apiserver through the management cluster and keep the port-forward until interrupted. Requires --name.")
+	tunnel.BindOptions(&opts.tunnelOpts, cmd)
+	_ = cmd.Flags().MarkHidden("exec-credential")
+	cmd.MarkFlagsMutuallyExclusive("via-port-forward", "short-lived", "exec-plugin", "exec-credential")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if err := Render(cmd.Context(), opts.namespace, opts.name); err != nil {
+		if err := opts.run(cmd); err != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 			return err
 		}
@@ -70,6 +112,56 @@ func NewCreateCommand() *cobra.Command {
 	return cmd
 }
 
+func (o *options) run(cmd *cobra.Command) error {
+	if (o.shortLived || o.execPlugin || o.execCredential) && len(o.name) == 0 {
+		return fmt.Errorf("--name is required to issue short-lived credentials")
+	}
+	if o.viaPortForward && len(o.name) == 0 {
+		return fmt.Errorf("--name is required to forward a port to the kube-apiserver")
+	}
+	switch {
+	case o.viaPortForward:
+		o.tunnelOpts.Namespace = o.namespace
+		o.tunnelOpts.Name = o.name
+		return tunnel.Run(cmd.Context(), o.tunnelOpts)
+	case o.execCredential:
+		return RenderExecCredential(cmd.Context(), o.namespace, o.name, o.shortLivedOpts)
+	case o.execPlugin:
+		command, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to determine the path of this binary: %w", err)
+		}
+		return RenderExecPlugin(cmd.Context(), o.namespace, o.name, o.shortLivedOpts, command, o.execPluginArgs(cmd))
+	case o.shortLived:
+		return RenderShortLived(cmd.Context(), o.namespace, o.name, o.shortLivedOpts)
+	default:
+		return Render(cmd.Context(), o.namespace, o.name)
+	}
+}
+
+// execPluginArgs returns the arguments the exec credential plugin invokes this
+// command with to issue a new certificate.
+func (o *options) execPluginArgs(cmd *cobra.Command) []string {
+	// drop the binary name, the plugin command is the absolute path to it
+	args := strings.Fields(cmd.CommandPath())[1:]
+	args = append(args,
+		"--namespace", o.namespace,
+		"--name", o.name,
+		"--exec-credential",
+		"--signer", o.shortLivedOpts.Signer,
+		"--username", o.shortLivedOpts.Username,
+		"--expiration", o.shortLivedOpts.Expiration.String(),
+		"--timeout", o.shortLivedOpts.Timeout.String(),
+	)
+	if len(o.shortLivedOpts.Groups) > 0 {
+		args = append(args, "--groups", strings.Join(o.shortLivedOpts.Groups, ","))
+	}
+	if o.shortLivedOpts.AllowSystemMasters {
+		args = append(args, "--allow-system-masters")
+	}
+	return args
+}
+
 // Render builds the kubeconfig and prints it to stdout.
 func Render(ctx context.Context, namespace string, name string) error {
 	scheme := runtime.NewScheme()
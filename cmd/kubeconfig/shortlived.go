@@ -0,0 +1,385 @@
+package kubeconfig
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"slices"
+	"strings"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/certificate/csr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	certificatesv1alpha1 "github.com/openshift/hypershift/api/certificates/v1alpha1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/control-plane-pki-operator/certificates"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	"github.com/openshift/hypershift/support/certs"
+)
+
+// ShortLivedOptions configure the issuance of a short-lived client certificate
+// through the break-glass CertificateSigningRequest flow of the control-plane
+// PKI operator.
+type ShortLivedOptions struct {
+	// Signer is the break-glass signer class used to sign the certificate.
+	Signer string
+	// Username is appended to the signer's common name prefix to identify the
+	// requesting user in the hosted cluster.
+	Username string
+	// Groups are set as the organizations of the certificate subject.
+	Groups []string
+	// AllowSystemMasters allows requesting the system:masters group, which makes
+	// the certificate cluster-admin.
+	AllowSystemMasters bool
+	// Expiration is the requested validity of the certificate. The signer may
+	// clamp it to its own limits.
+	Expiration time.Duration
+	// Timeout bounds how long to wait for the request to be approved and signed.
+	Timeout time.Duration
+}
+
+// DefaultShortLivedOptions returns the defaults used by the CLI.
+func DefaultShortLivedOptions() ShortLivedOptions {
+	return ShortLivedOptions{
+		Signer:     string(certificates.CustomerBreakGlassSigner),
+		Username:   currentUsername(),
+		Expiration: 8 * time.Hour,
+		Timeout:    5 * time.Minute,
+	}
+}
+
+// Validate ensures the options can produce a valid certificate request.
+func (o *ShortLivedOptions) Validate() error {
+	if !certificates.ValidSignerClass(o.Signer) {
+		return fmt.Errorf("invalid signer %q, must be one of %q or %q", o.Signer, certificates.CustomerBreakGlassSigner, certificates.SREBreakGlassSigner)
+	}
+	if len(o.Username) == 0 {
+		return fmt.Errorf("a username is required")
+	}
+	// the requesting user approves their own request, so cluster-admin must be asked for explicitly
+	if slices.Contains(o.Groups, systemMastersGroup) && !o.AllowSystemMasters {
+		return fmt.Errorf("the %s group grants cluster-admin, pass --allow-system-masters to request it", systemMastersGroup)
+	}
+	// the API server rejects CertificateSigningRequests asking for less than ten minutes
+	if o.Expiration < 10*time.Minute {
+		return fmt.Errorf("expiration must be at least 10m, got %s", o.Expiration)
+	}
+	return nil
+}
+
+// systemMastersGroup is the group bound to cluster-admin.
+const systemMastersGroup = "system:masters"
+
+// csrPollInterval is how often the CertificateSigningRequest is checked for a
+// signed certificate.
+var csrPollInterval = 2 * time.Second
+
+// ClientCertificate is a signed client certificate with its private key.
+type ClientCertificate struct {
+	Certificate []byte
+	Key         []byte
+	NotAfter    time.Time
+}
+
+// RenderShortLived issues a short-lived client certificate for the HostedCluster
+// and prints a kubeconfig using it to stdout.
+func RenderShortLived(ctx context.Context, namespace, name string, opts ShortLivedOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+
+	var cluster hyperv1.HostedCluster
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cluster); err != nil {
+		return err
+	}
+	adminConfig, err := adminKubeConfig(ctx, c, &cluster)
+	if err != nil {
+		return err
+	}
+	clientCert, err := IssueClientCertificate(ctx, c, &cluster, opts)
+	if err != nil {
+		return err
+	}
+	kubeConfig := shortLivedKubeConfig(cluster.Namespace+"-"+cluster.Name, opts.Username, adminConfig, clientcmdapiv1.AuthInfo{
+		ClientCertificateData: clientCert.Certificate,
+		ClientKeyData:         clientCert.Key,
+	})
+	return writeKubeConfig(os.Stdout, kubeConfig)
+}
+
+// RenderExecPlugin prints a kubeconfig for the HostedCluster which delegates
+// authentication to an exec credential plugin. The plugin re-invokes this
+// binary to issue a fresh short-lived certificate whenever the previous one
+// has expired.
+func RenderExecPlugin(ctx context.Context, namespace, name string, opts ShortLivedOptions, command string, args []string) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+
+	var cluster hyperv1.HostedCluster
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cluster); err != nil {
+		return err
+	}
+	adminConfig, err := adminKubeConfig(ctx, c, &cluster)
+	if err != nil {
+		return err
+	}
+	kubeConfig := shortLivedKubeConfig(cluster.Namespace+"-"+cluster.Name, opts.Username, adminConfig, clientcmdapiv1.AuthInfo{
+		Exec: &clientcmdapiv1.ExecConfig{
+			APIVersion:      clientauthenticationv1.SchemeGroupVersion.String(),
+			Command:         command,
+			Args:            args,
+			Env:             managementKubeConfigEnv(),
+			InteractiveMode: clientcmdapiv1.NeverExecInteractiveMode,
+			InstallHint:     "The hypershift CLI is required to issue short-lived credentials for this cluster.",
+		},
+	})
+	return writeKubeConfig(os.Stdout, kubeConfig)
+}
+
+// RenderExecCredential issues a short-lived client certificate for the
+// HostedCluster and prints it as an ExecCredential, for consumption by
+// client-go exec credential plugins.
+func RenderExecCredential(ctx context.Context, namespace, name string, opts ShortLivedOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+
+	var cluster hyperv1.HostedCluster
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cluster); err != nil {
+		return err
+	}
+	clientCert, err := IssueClientCertificate(ctx, c, &cluster, opts)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(execCredential(clientCert))
+}
+
+// IssueClientCertificate generates a private key, requests a client certificate
+// for it from the HostedCluster's break-glass signer, approves the request
+// through a CertificateSigningRequestApproval, and waits for it to be signed.
+func IssueClientCertificate(ctx context.Context, c client.Client, hc *hyperv1.HostedCluster, opts ShortLivedOptions) (*ClientCertificate, error) {
+	signer := certificates.SignerClass(opts.Signer)
+	hcpNamespace := manifests.HostedControlPlaneNamespace(hc.Namespace, hc.Name)
+
+	key, err := certs.PrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+	request, err := cert.MakeCSR(key, &pkix.Name{
+		CommonName:   certificates.CommonNamePrefix(signer) + opts.Username,
+		Organization: opts.Groups,
+	}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+
+	csrObj := &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: hcpNamespace + "-" + string(signer) + "-",
+		},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:           request,
+			SignerName:        certificates.SignerNameForHC(hc, signer),
+			Usages:            []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth, certificatesv1.UsageDigitalSignature, certificatesv1.UsageKeyEncipherment},
+			ExpirationSeconds: csr.DurationToExpirationSeconds(opts.Expiration),
+		},
+	}
+	if err := c.Create(ctx, csrObj); err != nil {
+		return nil, fmt.Errorf("failed to create CertificateSigningRequest: %w", err)
+	}
+	defer func() {
+		// every invocation of the exec plugin creates a new request, so they
+		// must not outlive the certificate being read from them
+		if err := c.Delete(context.Background(), csrObj); err != nil && !apierrors.IsNotFound(err) {
+			fmt.Fprintf(os.Stderr, "failed to clean up CertificateSigningRequest %s: %v\n", csrObj.Name, err)
+		}
+	}()
+
+	// by convention, an approval is tied to a CertificateSigningRequest by name
+	approval := &certificatesv1alpha1.CertificateSigningRequestApproval{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: hcpNamespace,
+			Name:      csrObj.Name,
+		},
+	}
+	if err := c.Create(ctx, approval); err != nil {
+		return nil, fmt.Errorf("failed to create CertificateSigningRequestApproval: %w", err)
+	}
+	defer func() {
+		// the approval is only needed until the request has been approved
+		if err := c.Delete(context.Background(), approval); err != nil && !apierrors.IsNotFound(err) {
+			fmt.Fprintf(os.Stderr, "failed to clean up CertificateSigningRequestApproval %s: %v\n", client.ObjectKeyFromObject(approval), err)
+		}
+	}()
+
+	var signed []byte
+	if err := wait.PollUntilContextTimeout(ctx, csrPollInterval, opts.Timeout, true, func(ctx context.Context) (bool, error) {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(csrObj), csrObj); err != nil {
+			return false, err
+		}
+		if err := csrFailure(csrObj); err != nil {
+			return false, err
+		}
+		if len(csrObj.Status.Certificate) == 0 {
+			return false, nil
+		}
+		signed = csrObj.Status.Certificate
+		return true, nil
+	}); err != nil {
+		return nil, fmt.Errorf("CertificateSigningRequest %s was not signed: %w", csrObj.Name, err)
+	}
+
+	parsed, err := certs.PemToCertificate(signed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signed certificate: %w", err)
+	}
+	return &ClientCertificate{
+		Certificate: signed,
+		Key:         certs.PrivateKeyToPem(key),
+		NotAfter:    parsed.NotAfter,
+	}, nil
+}
+
+// csrFailure returns an error if the CertificateSigningRequest was denied or
+// could not be signed.
+func csrFailure(csrObj *certificatesv1.CertificateSigningRequest) error {
+	for _, condition := range csrObj.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue && len(condition.Status) != 0 {
+			continue
+		}
+		switch condition.Type {
+		case certificatesv1.CertificateDenied, certificatesv1.CertificateFailed:
+			return fmt.Errorf("request %s: %s: %s", strings.ToLower(string(condition.Type)), condition.Reason, condition.Message)
+		}
+	}
+	return nil
+}
+
+// adminKubeConfig loads the admin kubeconfig of the HostedCluster, which is
+// used as the source of the server endpoint and trust bundle.
+func adminKubeConfig(ctx context.Context, c client.Client, cluster *hyperv1.HostedCluster) (*clientcmdapiv1.Config, error) {
+	if cluster.Status.KubeConfig == nil {
+		return nil, fmt.Errorf("cluster doesn't report a kubeconfig")
+	}
+	kubeConfigSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Status.KubeConfig.Name,
+		},
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(&kubeConfigSecret), &kubeConfigSecret); err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig secret %s: %w", client.ObjectKeyFromObject(&kubeConfigSecret), err)
+	}
+	data, hasData := kubeConfigSecret.Data["kubeconfig"]
+	if !hasData || len(data) == 0 {
+		return nil, fmt.Errorf("kubeconfig secret has no kubeconfig")
+	}
+	var kubeConfig clientcmdapiv1.Config
+	if err := yaml.Unmarshal(data, &kubeConfig); err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	if len(kubeConfig.Clusters) == 0 {
+		return nil, fmt.Errorf("kubeconfig secret has no clusters")
+	}
+	return &kubeConfig, nil
+}
+
+// shortLivedKubeConfig builds a kubeconfig with a single context, pointing at
+// the first cluster of the admin kubeconfig and authenticating with authInfo.
+func shortLivedKubeConfig(name, username string, adminConfig *clientcmdapiv1.Config, authInfo clientcmdapiv1.AuthInfo) *clientcmdapiv1.Config {
+	userName := name + "-" + username
+	return &clientcmdapiv1.Config{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []clientcmdapiv1.NamedCluster{{
+			Name:    name,
+			Cluster: adminConfig.Clusters[0].Cluster,
+		}},
+		AuthInfos: []clientcmdapiv1.NamedAuthInfo{{
+			Name:     userName,
+			AuthInfo: authInfo,
+		}},
+		Contexts: []clientcmdapiv1.NamedContext{{
+			Name: name,
+			Context: clientcmdapiv1.Context{
+				Cluster:   name,
+				AuthInfo:  userName,
+				Namespace: "default",
+			},
+		}},
+		CurrentContext: name,
+	}
+}
+
+func execCredential(clientCert *ClientCertificate) *clientauthenticationv1.ExecCredential {
+	expiration := metav1.NewTime(clientCert.NotAfter)
+	return &clientauthenticationv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clientauthenticationv1.SchemeGroupVersion.String(),
+			Kind:       "ExecCredential",
+		},
+		Status: &clientauthenticationv1.ExecCredentialStatus{
+			ExpirationTimestamp:   &expiration,
+			ClientCertificateData: string(clientCert.Certificate),
+			ClientKeyData:         string(clientCert.Key),
+		},
+	}
+}
+
+func writeKubeConfig(w io.Writer, kubeConfig *clientcmdapiv1.Config) error {
+	out, err := yaml.Marshal(kubeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal kubeconfig: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// managementKubeConfigEnv pins the plugin to the management cluster kubeconfig in
+// use now, so that it isn't confused by $KUBECONFIG pointing at the hosted cluster
+// when the plugin is invoked.
+func managementKubeConfigEnv() []clientcmdapiv1.ExecEnvVar {
+	path := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(path) == 0 {
+		path = clientcmd.RecommendedHomeFile
+	}
+	return []clientcmdapiv1.ExecEnvVar{{Name: clientcmd.RecommendedConfigPathEnvVar, Value: path}}
+}
+
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,165 @@
+package kubeconfig
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	certificatesv1alpha1 "github.com/openshift/hypershift/api/certificates/v1alpha1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/control-plane-pki-operator/certificates"
+	"github.com/openshift/hypershift/support/api"
+	"github.com/openshift/hypershift/support/certs"
+)
+
+func TestIssueClientCertificate(t *testing.T) {
+	csrPollInterval = 10 * time.Millisecond
+
+	_, signed, err := certs.GenerateSelfSignedCertificate(&certs.CertCfg{
+		Subject:      pkix.Name{CommonName: "system:customer-break-glass:alice", OrganizationalUnit: []string{"test"}},
+		KeyUsages:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		Validity:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		// sign mutates the request once it has been approved, standing in for
+		// the control-plane PKI operator
+		sign        func(*certificatesv1.CertificateSigningRequest)
+		expectedErr bool
+	}{
+		{
+			name: "When the request is approved and signed it should return the certificate",
+			sign: func(csr *certificatesv1.CertificateSigningRequest) {
+				csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+					Type:   certificatesv1.CertificateApproved,
+					Status: corev1.ConditionTrue,
+				})
+				csr.Status.Certificate = certs.CertToPem(signed)
+			},
+		},
+		{
+			name: "When the request is denied it should fail",
+			sign: func(csr *certificatesv1.CertificateSigningRequest) {
+				csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+					Type:    certificatesv1.CertificateDenied,
+					Status:  corev1.ConditionTrue,
+					Reason:  "Denied",
+					Message: "not allowed",
+				})
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			hc := &hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"}}
+
+			var created, approved bool
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c crclient.WithWatch, obj crclient.Object, opts ...crclient.CreateOption) error {
+					if err := c.Create(ctx, obj, opts...); err != nil {
+						return err
+					}
+					switch o := obj.(type) {
+					case *certificatesv1.CertificateSigningRequest:
+						created = true
+						g.Expect(o.Spec.SignerName).To(Equal(certificates.SignerNameForHC(hc, certificates.CustomerBreakGlassSigner)))
+					case *certificatesv1alpha1.CertificateSigningRequestApproval:
+						approved = true
+						csr := &certificatesv1.CertificateSigningRequest{}
+						if err := c.Get(ctx, crclient.ObjectKey{Name: o.Name}, csr); err != nil {
+							return err
+						}
+						tc.sign(csr)
+						return c.Status().Update(ctx, csr)
+					}
+					return nil
+				},
+			}).Build()
+
+			opts := DefaultShortLivedOptions()
+			opts.Username = "alice"
+			opts.Timeout = 5 * time.Second
+			clientCert, err := IssueClientCertificate(context.Background(), c, hc, opts)
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(clientCert.Certificate).To(Equal(certs.CertToPem(signed)))
+				g.Expect(clientCert.NotAfter).To(BeTemporally("~", signed.NotAfter))
+				_, err := certs.PemToPrivateKey(clientCert.Key)
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(created).To(BeTrue())
+			g.Expect(approved).To(BeTrue())
+
+			// neither the request nor its approval may be left behind
+			csrs := &certificatesv1.CertificateSigningRequestList{}
+			g.Expect(c.List(context.Background(), csrs)).To(Succeed())
+			g.Expect(csrs.Items).To(BeEmpty())
+			approvals := &certificatesv1alpha1.CertificateSigningRequestApprovalList{}
+			g.Expect(c.List(context.Background(), approvals)).To(Succeed())
+			g.Expect(approvals.Items).To(BeEmpty())
+		})
+	}
+}
+
+func TestShortLivedOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mutate      func(*ShortLivedOptions)
+		expectedErr bool
+	}{
+		{
+			name:   "When the defaults are used it should request no groups",
+			mutate: func(o *ShortLivedOptions) {},
+		},
+		{
+			name:   "When groups other than system:masters are requested it should succeed",
+			mutate: func(o *ShortLivedOptions) { o.Groups = []string{"developers"} },
+		},
+		{
+			name:        "When system:masters is requested without opting in it should fail",
+			mutate:      func(o *ShortLivedOptions) { o.Groups = []string{"developers", "system:masters"} },
+			expectedErr: true,
+		},
+		{
+			name: "When system:masters is requested with the opt-in it should succeed",
+			mutate: func(o *ShortLivedOptions) {
+				o.Groups = []string{"system:masters"}
+				o.AllowSystemMasters = true
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			opts := DefaultShortLivedOptions()
+			opts.Username = "alice"
+			g.Expect(opts.Groups).To(BeEmpty())
+			tc.mutate(&opts)
+			if tc.expectedErr {
+				g.Expect(opts.Validate()).ToNot(Succeed())
+			} else {
+				g.Expect(opts.Validate()).To(Succeed())
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package nodepool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+type GetOptions struct {
+	Namespace   string
+	Name        string
+	ClusterName string
+	Output      string
+}
+
+func (o *GetOptions) Validate() error {
+	switch o.Output {
+	case OutputTable, OutputJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output %q, must be one of %s, %s", o.Output, OutputTable, OutputJSON)
+	}
+}
+
+func NewListCommand() *cobra.Command {
+	opts := &GetOptions{
+		Namespace: "clusters",
+		Output:    OutputTable,
+	}
+
+	cmd := &cobra.Command{
+		Use:          "list",
+		Short:        "Lists NodePools and their rollout state",
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace, "The namespace of the NodePools")
+	cmd.Flags().StringVar(&opts.ClusterName, "cluster-name", opts.ClusterName, "Only list the NodePools of this HostedCluster")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "Output format, one of table, json")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := ListNodePools(cmd.Context(), c, os.Stdout, opts); err != nil {
+			log.Log.Error(err, "Failed to list NodePools")
+			return err
+		}
+		return nil
+	}
+	return cmd
+}
+
+func NewDescribeCommand() *cobra.Command {
+	opts := &GetOptions{
+		Namespace: "clusters",
+		Output:    OutputTable,
+	}
+
+	cmd := &cobra.Command{
+		Use:          "describe",
+		Short:        "Describes the spec and conditions of a NodePool",
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace, "The namespace of the NodePool")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the NodePool")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "Output format, one of table, json")
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := DescribeNodePool(cmd.Context(), c, os.Stdout, opts); err != nil {
+			log.Log.Error(err, "Failed to describe NodePool")
+			return err
+		}
+		return nil
+	}
+	return cmd
+}
+
+// ListNodePools prints the NodePools of a namespace, optionally only those of a HostedCluster.
+func ListNodePools(ctx context.Context, c crclient.Client, out io.Writer, opts *GetOptions) error {
+	nodePools := &hyperv1.NodePoolList{}
+	if err := c.List(ctx, nodePools, crclient.InNamespace(opts.Namespace)); err != nil {
+		return fmt.Errorf("failed to list NodePools: %w", err)
+	}
+	if opts.ClusterName != "" {
+		var filtered []hyperv1.NodePool
+		for _, nodePool := range nodePools.Items {
+			if nodePool.Spec.ClusterName == opts.ClusterName {
+				filtered = append(filtered, nodePool)
+			}
+		}
+		nodePools.Items = filtered
+	}
+
+	if opts.Output == OutputJSON {
+		nodePools.APIVersion = hyperv1.GroupVersion.String()
+		nodePools.Kind = "NodePoolList"
+		for i := range nodePools.Items {
+			setTypeMeta(&nodePools.Items[i])
+		}
+		return printJSON(out, nodePools)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCLUSTER\tDESIRED\tCURRENT\tAUTOSCALING\tVERSION\tUPDATING\tMACHINES READY\tPAUSED\tMESSAGE")
+	for _, nodePool := range nodePools.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			nodePool.Name,
+			nodePool.Spec.ClusterName,
+			desiredReplicas(&nodePool),
+			nodePool.Status.Replicas,
+			autoScalingRange(&nodePool),
+			valueOrNone(nodePool.Status.Version),
+			updating(&nodePool),
+			conditionStatus(&nodePool, hyperv1.NodePoolAllMachinesReadyConditionType),
+			pausedUntil(&nodePool),
+			readyMessage(&nodePool),
+		)
+	}
+	return w.Flush()
+}
+
+// DescribeNodePool prints the spec summary and the conditions of a NodePool.
+func DescribeNodePool(ctx context.Context, c crclient.Client, out io.Writer, opts *GetOptions) error {
+	nodePool := &hyperv1.NodePool{}
+	key := types.NamespacedName{Namespace: opts.Namespace, Name: opts.Name}
+	if err := c.Get(ctx, key, nodePool); err != nil {
+		return fmt.Errorf("failed to get NodePool %s: %w", key, err)
+	}
+
+	if opts.Output == OutputJSON {
+		setTypeMeta(nodePool)
+		return printJSON(out, nodePool)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", nodePool.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", nodePool.Namespace)
+	fmt.Fprintf(w, "Cluster:\t%s\n", nodePool.Spec.ClusterName)
+	fmt.Fprintf(w, "Platform:\t%s\n", nodePool.Spec.Platform.Type)
+	fmt.Fprintf(w, "Release Image:\t%s\n", nodePool.Spec.Release.Image)
+	fmt.Fprintf(w, "Version:\t%s\n", valueOrNone(nodePool.Status.Version))
+	fmt.Fprintf(w, "Upgrade Type:\t%s\n", nodePool.Spec.Management.UpgradeType)
+	fmt.Fprintf(w, "Desired Nodes:\t%s\n", desiredReplicas(nodePool))
+	fmt.Fprintf(w, "Current Nodes:\t%d\n", nodePool.Status.Replicas)
+	fmt.Fprintf(w, "Autoscaling:\t%s\n", autoScalingRange(nodePool))
+	fmt.Fprintf(w, "Auto Repair:\t%t\n", nodePool.Spec.Management.AutoRepair)
+	fmt.Fprintf(w, "Paused Until:\t%s\n", pausedUntil(nodePool))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "\nConditions:")
+	w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tLAST TRANSITION\tMESSAGE")
+	for _, condition := range nodePool.Status.Conditions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n",
+			condition.Type,
+			condition.Status,
+			valueOrNone(condition.Reason),
+			condition.LastTransitionTime.UTC().Format("2006-01-02T15:04:05Z"),
+			oneLine(condition.Message),
+		)
+	}
+	return w.Flush()
+}
+
+func setTypeMeta(nodePool *hyperv1.NodePool) {
+	nodePool.APIVersion = hyperv1.GroupVersion.String()
+	nodePool.Kind = "NodePool"
+}
+
+func printJSON(out io.Writer, obj interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(obj)
+}
+
+func desiredReplicas(nodePool *hyperv1.NodePool) string {
+	if nodePool.Spec.Replicas == nil {
+		return "-"
+	}
+	return fmt.Sprint(*nodePool.Spec.Replicas)
+}
+
+func autoScalingRange(nodePool *hyperv1.NodePool) string {
+	if nodePool.Spec.AutoScaling == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d-%d", nodePool.Spec.AutoScaling.Min, nodePool.Spec.AutoScaling.Max)
+}
+
+// updating summarizes which kind of rollout the NodePool is going through.
+func updating(nodePool *hyperv1.NodePool) string {
+	var rollouts []string
+	if conditionStatus(nodePool, hyperv1.NodePoolUpdatingVersionConditionType) == corev1.ConditionTrue {
+		rollouts = append(rollouts, "Version")
+	}
+	if conditionStatus(nodePool, hyperv1.NodePoolUpdatingConfigConditionType) == corev1.ConditionTrue {
+		rollouts = append(rollouts, "Config")
+	}
+	if conditionStatus(nodePool, hyperv1.NodePoolUpdatingPlatformMachineTemplateConditionType) == corev1.ConditionTrue {
+		rollouts = append(rollouts, "MachineTemplate")
+	}
+	if len(rollouts) == 0 {
+		return "-"
+	}
+	return strings.Join(rollouts, ",")
+}
+
+func pausedUntil(nodePool *hyperv1.NodePool) string {
+	if nodePool.Spec.PausedUntil == nil {
+		return "-"
+	}
+	return *nodePool.Spec.PausedUntil
+}
+
+func readyMessage(nodePool *hyperv1.NodePool) string {
+	if condition := findCondition(nodePool.Status.Conditions, hyperv1.NodePoolReadyConditionType); condition != nil {
+		return oneLine(condition.Message)
+	}
+	return ""
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func oneLine(message string) string {
+	return strings.Join(strings.Fields(message), " ")
+}
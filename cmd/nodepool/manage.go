@@ -0,0 +1,438 @@
+package nodepool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/support/thirdparty/library-go/pkg/image/reference"
+	supportutil "github.com/openshift/hypershift/support/util"
+)
+
+// ManageOptions are the options shared by the commands changing an existing NodePool.
+type ManageOptions struct {
+	Namespace string
+	Name      string
+	Wait      bool
+	Timeout   time.Duration
+}
+
+func (o *ManageOptions) BindFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "The namespace of the NodePool")
+	flags.StringVar(&o.Name, "name", o.Name, "The name of the NodePool")
+	flags.BoolVar(&o.Wait, "wait", o.Wait, "Wait until the NodePool reports the change as complete")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "How long to wait for the change to complete when --wait is set")
+}
+
+func defaultManageOptions() ManageOptions {
+	return ManageOptions{
+		Namespace: "clusters",
+		Timeout:   30 * time.Minute,
+	}
+}
+
+func (o *ManageOptions) key() types.NamespacedName {
+	return types.NamespacedName{Namespace: o.Namespace, Name: o.Name}
+}
+
+// run applies a change to the NodePool and optionally waits until the NodePool
+// reports the change as complete.
+func (o *ManageOptions) run(ctx context.Context, action string, change func(context.Context, crclient.Client) (*hyperv1.NodePool, nodePoolDoneFunc, error)) error {
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+	nodePool, done, err := change(ctx, c)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("NodePool %s/%s %s\n", nodePool.Namespace, nodePool.Name, action)
+	if !o.Wait {
+		return nil
+	}
+	return WaitForNodePool(ctx, c, o.key(), o.Timeout, done)
+}
+
+func NewScaleCommand() *cobra.Command {
+	opts := defaultManageOptions()
+	var replicas int32 = -1
+
+	cmd := &cobra.Command{
+		Use:          "scale",
+		Short:        "Sets the number of nodes of a NodePool",
+		SilenceUsage: true,
+	}
+	opts.BindFlags(cmd.Flags())
+	cmd.Flags().Int32Var(&replicas, "replicas", replicas, "The desired number of nodes")
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("replicas")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		err := opts.run(cmd.Context(), fmt.Sprintf("scaled to %d replicas", replicas), func(ctx context.Context, c crclient.Client) (*hyperv1.NodePool, nodePoolDoneFunc, error) {
+			nodePool, err := ScaleNodePool(ctx, c, opts.key(), replicas)
+			return nodePool, scaledTo(replicas), err
+		})
+		if err != nil {
+			log.Log.Error(err, "Failed to scale NodePool")
+		}
+		return err
+	}
+	return cmd
+}
+
+func NewUpgradeCommand() *cobra.Command {
+	opts := defaultManageOptions()
+	var releaseImage string
+
+	cmd := &cobra.Command{
+		Use:          "upgrade",
+		Short:        "Sets the release image of a NodePool",
+		SilenceUsage: true,
+	}
+	opts.BindFlags(cmd.Flags())
+	cmd.Flags().StringVar(&releaseImage, "release-image", releaseImage, "The release image to roll the nodes of the NodePool to")
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("release-image")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		err := opts.run(cmd.Context(), fmt.Sprintf("upgrading to %s", releaseImage), func(ctx context.Context, c crclient.Client) (*hyperv1.NodePool, nodePoolDoneFunc, error) {
+			nodePool, err := UpgradeNodePool(ctx, c, opts.key(), releaseImage)
+			return nodePool, upgraded, err
+		})
+		if err != nil {
+			log.Log.Error(err, "Failed to upgrade NodePool")
+		}
+		return err
+	}
+	return cmd
+}
+
+func NewPauseCommand() *cobra.Command {
+	opts := defaultManageOptions()
+	until := "true"
+
+	cmd := &cobra.Command{
+		Use:          "pause",
+		Short:        "Pauses reconciliation of a NodePool",
+		SilenceUsage: true,
+	}
+	opts.BindFlags(cmd.Flags())
+	cmd.Flags().StringVar(&until, "until", until, "Either 'true' to pause until resumed, or an RFC3339 date to pause until")
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		err := opts.run(cmd.Context(), fmt.Sprintf("paused until %s", until), func(ctx context.Context, c crclient.Client) (*hyperv1.NodePool, nodePoolDoneFunc, error) {
+			nodePool, err := PauseNodePool(ctx, c, opts.key(), until)
+			return nodePool, reconciliationActive(false), err
+		})
+		if err != nil {
+			log.Log.Error(err, "Failed to pause NodePool")
+		}
+		return err
+	}
+	return cmd
+}
+
+func NewResumeCommand() *cobra.Command {
+	opts := defaultManageOptions()
+
+	cmd := &cobra.Command{
+		Use:          "resume",
+		Short:        "Resumes reconciliation of a paused NodePool",
+		SilenceUsage: true,
+	}
+	opts.BindFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		err := opts.run(cmd.Context(), "resumed", func(ctx context.Context, c crclient.Client) (*hyperv1.NodePool, nodePoolDoneFunc, error) {
+			nodePool, err := ResumeNodePool(ctx, c, opts.key())
+			return nodePool, reconciliationActive(true), err
+		})
+		if err != nil {
+			log.Log.Error(err, "Failed to resume NodePool")
+		}
+		return err
+	}
+	return cmd
+}
+
+func NewAutoscaleCommand() *cobra.Command {
+	opts := defaultManageOptions()
+	var minNodes, maxNodes int32
+	var disable bool
+	var replicas int32 = -1
+
+	cmd := &cobra.Command{
+		Use:          "autoscale",
+		Short:        "Enables, changes or disables autoscaling of a NodePool",
+		SilenceUsage: true,
+	}
+	opts.BindFlags(cmd.Flags())
+	cmd.Flags().Int32Var(&minNodes, "min", minNodes, "The minimum number of nodes")
+	cmd.Flags().Int32Var(&maxNodes, "max", maxNodes, "The maximum number of nodes")
+	cmd.Flags().BoolVar(&disable, "disable", disable, "Disable autoscaling, keeping the current number of nodes unless --replicas is set")
+	cmd.Flags().Int32Var(&replicas, "replicas", replicas, "The number of nodes to keep when disabling autoscaling")
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var err error
+		if disable {
+			err = opts.run(cmd.Context(), "autoscaling disabled", func(ctx context.Context, c crclient.Client) (*hyperv1.NodePool, nodePoolDoneFunc, error) {
+				var desired *int32
+				if cmd.Flags().Changed("replicas") {
+					desired = &replicas
+				}
+				nodePool, err := DisableNodePoolAutoscaling(ctx, c, opts.key(), desired)
+				if err != nil {
+					return nil, nil, err
+				}
+				return nodePool, scaledTo(*nodePool.Spec.Replicas), nil
+			})
+		} else {
+			err = opts.run(cmd.Context(), fmt.Sprintf("autoscaling between %d and %d nodes", minNodes, maxNodes), func(ctx context.Context, c crclient.Client) (*hyperv1.NodePool, nodePoolDoneFunc, error) {
+				nodePool, err := AutoscaleNodePool(ctx, c, opts.key(), minNodes, maxNodes)
+				return nodePool, autoscaling, err
+			})
+		}
+		if err != nil {
+			log.Log.Error(err, "Failed to change NodePool autoscaling")
+		}
+		return err
+	}
+	return cmd
+}
+
+// ScaleNodePool sets the replicas of a NodePool which is not autoscaled.
+func ScaleNodePool(ctx context.Context, c crclient.Client, key types.NamespacedName, replicas int32) (*hyperv1.NodePool, error) {
+	if replicas < 0 {
+		return nil, fmt.Errorf("replicas must be 0 or greater")
+	}
+	return updateNodePool(ctx, c, key, func(nodePool *hyperv1.NodePool) error {
+		if autoScaling := nodePool.Spec.AutoScaling; autoScaling != nil {
+			return fmt.Errorf("NodePool %s is autoscaled between %d and %d nodes, disable autoscaling first", key, autoScaling.Min, autoScaling.Max)
+		}
+		nodePool.Spec.Replicas = &replicas
+		return nil
+	})
+}
+
+// UpgradeNodePool sets the release image of a NodePool.
+func UpgradeNodePool(ctx context.Context, c crclient.Client, key types.NamespacedName, releaseImage string) (*hyperv1.NodePool, error) {
+	if _, err := reference.Parse(releaseImage); err != nil {
+		return nil, fmt.Errorf("invalid release image %q: %w", releaseImage, err)
+	}
+	return updateNodePool(ctx, c, key, func(nodePool *hyperv1.NodePool) error {
+		if nodePool.Spec.Release.Image == releaseImage {
+			return fmt.Errorf("NodePool %s already uses release image %s", key, releaseImage)
+		}
+		nodePool.Spec.Release.Image = releaseImage
+		return nil
+	})
+}
+
+// PauseNodePool pauses reconciliation of a NodePool until it is resumed, when
+// until is "true", or until the given RFC3339 date.
+func PauseNodePool(ctx context.Context, c crclient.Client, key types.NamespacedName, until string) (*hyperv1.NodePool, error) {
+	paused, _, err := supportutil.ProcessPausedUntilField(&until, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !paused {
+		return nil, fmt.Errorf("pausing until %q would not pause the NodePool, use 'true' or a date in the future", until)
+	}
+	return updateNodePool(ctx, c, key, func(nodePool *hyperv1.NodePool) error {
+		nodePool.Spec.PausedUntil = &until
+		return nil
+	})
+}
+
+// ResumeNodePool resumes reconciliation of a paused NodePool.
+func ResumeNodePool(ctx context.Context, c crclient.Client, key types.NamespacedName) (*hyperv1.NodePool, error) {
+	return updateNodePool(ctx, c, key, func(nodePool *hyperv1.NodePool) error {
+		if nodePool.Spec.PausedUntil == nil {
+			return fmt.Errorf("NodePool %s is not paused", key)
+		}
+		nodePool.Spec.PausedUntil = nil
+		return nil
+	})
+}
+
+// AutoscaleNodePool enables autoscaling of a NodePool between minNodes and maxNodes.
+func AutoscaleNodePool(ctx context.Context, c crclient.Client, key types.NamespacedName, minNodes, maxNodes int32) (*hyperv1.NodePool, error) {
+	if minNodes < 1 {
+		return nil, fmt.Errorf("min must be 1 or greater")
+	}
+	if maxNodes < minNodes {
+		return nil, fmt.Errorf("max must be equal or greater than min")
+	}
+	return updateNodePool(ctx, c, key, func(nodePool *hyperv1.NodePool) error {
+		nodePool.Spec.Replicas = nil
+		nodePool.Spec.AutoScaling = &hyperv1.NodePoolAutoScaling{Min: minNodes, Max: maxNodes}
+		return nil
+	})
+}
+
+// DisableNodePoolAutoscaling disables autoscaling of a NodePool and sets its
+// replicas, by default to the current number of nodes.
+func DisableNodePoolAutoscaling(ctx context.Context, c crclient.Client, key types.NamespacedName, replicas *int32) (*hyperv1.NodePool, error) {
+	if replicas != nil && *replicas < 0 {
+		return nil, fmt.Errorf("replicas must be 0 or greater")
+	}
+	return updateNodePool(ctx, c, key, func(nodePool *hyperv1.NodePool) error {
+		if nodePool.Spec.AutoScaling == nil {
+			return fmt.Errorf("NodePool %s is not autoscaled", key)
+		}
+		desired := nodePool.Status.Replicas
+		if replicas != nil {
+			desired = *replicas
+		}
+		nodePool.Spec.AutoScaling = nil
+		nodePool.Spec.Replicas = &desired
+		return nil
+	})
+}
+
+func updateNodePool(ctx context.Context, c crclient.Client, key types.NamespacedName, mutate func(*hyperv1.NodePool) error) (*hyperv1.NodePool, error) {
+	nodePool := &hyperv1.NodePool{}
+	if err := c.Get(ctx, key, nodePool); err != nil {
+		return nil, fmt.Errorf("failed to get NodePool %s: %w", key, err)
+	}
+	original := nodePool.DeepCopy()
+	if err := mutate(nodePool); err != nil {
+		return nil, err
+	}
+	if err := c.Patch(ctx, nodePool, crclient.MergeFrom(original)); err != nil {
+		return nil, fmt.Errorf("failed to update NodePool %s: %w", key, err)
+	}
+	return nodePool, nil
+}
+
+// nodePoolDoneFunc reports whether a change to a NodePool is complete and, if
+// not, what the NodePool is waiting for.
+type nodePoolDoneFunc func(nodePool *hyperv1.NodePool) (bool, string)
+
+// WaitForNodePool polls a NodePool until done reports the change as complete.
+func WaitForNodePool(ctx context.Context, c crclient.Client, key types.NamespacedName, timeout time.Duration, done nodePoolDoneFunc) error {
+	var lastMessage string
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		nodePool := &hyperv1.NodePool{}
+		if err := c.Get(ctx, key, nodePool); err != nil {
+			return false, fmt.Errorf("failed to get NodePool %s: %w", key, err)
+		}
+		complete, message := done(nodePool)
+		if !complete && message != lastMessage {
+			fmt.Fprintf(os.Stderr, "Waiting for NodePool %s: %s\n", key, message)
+			lastMessage = message
+		}
+		return complete, nil
+	})
+	if err != nil {
+		if lastMessage != "" {
+			return fmt.Errorf("NodePool %s did not complete the change: %s: %w", key, lastMessage, err)
+		}
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "NodePool %s is ready\n", key)
+	return nil
+}
+
+// observedCondition returns a condition of the NodePool, if it reflects the
+// current generation of the NodePool.
+func observedCondition(nodePool *hyperv1.NodePool, conditionType string) *hyperv1.NodePoolCondition {
+	condition := findCondition(nodePool.Status.Conditions, conditionType)
+	if condition == nil || condition.ObservedGeneration < nodePool.Generation {
+		return nil
+	}
+	return condition
+}
+
+func findCondition(conditions []hyperv1.NodePoolCondition, conditionType string) *hyperv1.NodePoolCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func conditionStatus(nodePool *hyperv1.NodePool, conditionType string) corev1.ConditionStatus {
+	if condition := findCondition(nodePool.Status.Conditions, conditionType); condition != nil {
+		return condition.Status
+	}
+	return corev1.ConditionUnknown
+}
+
+func allMachinesReady(nodePool *hyperv1.NodePool) (bool, string) {
+	condition := observedCondition(nodePool, hyperv1.NodePoolAllMachinesReadyConditionType)
+	if condition == nil {
+		return false, "machines status not observed yet"
+	}
+	if condition.Status != corev1.ConditionTrue {
+		return false, fmt.Sprintf("machines are not ready: %s", condition.Message)
+	}
+	return true, ""
+}
+
+func scaledTo(replicas int32) nodePoolDoneFunc {
+	return func(nodePool *hyperv1.NodePool) (bool, string) {
+		if nodePool.Status.Replicas != replicas {
+			return false, fmt.Sprintf("%d of %d nodes", nodePool.Status.Replicas, replicas)
+		}
+		return allMachinesReady(nodePool)
+	}
+}
+
+func upgraded(nodePool *hyperv1.NodePool) (bool, string) {
+	if condition := observedCondition(nodePool, hyperv1.NodePoolValidReleaseImageConditionType); condition != nil && condition.Status != corev1.ConditionTrue {
+		return false, fmt.Sprintf("invalid release image: %s", condition.Message)
+	}
+	condition := observedCondition(nodePool, hyperv1.NodePoolUpdatingVersionConditionType)
+	if condition == nil {
+		return false, "upgrade not observed yet"
+	}
+	if condition.Status == corev1.ConditionTrue {
+		return false, fmt.Sprintf("updating version: %s", condition.Message)
+	}
+	return allMachinesReady(nodePool)
+}
+
+func reconciliationActive(active bool) nodePoolDoneFunc {
+	expected := corev1.ConditionFalse
+	if active {
+		expected = corev1.ConditionTrue
+	}
+	return func(nodePool *hyperv1.NodePool) (bool, string) {
+		condition := observedCondition(nodePool, hyperv1.NodePoolReconciliationActiveConditionType)
+		if condition == nil || condition.Status != expected {
+			return false, "reconciliation state not observed yet"
+		}
+		return true, ""
+	}
+}
+
+func autoscaling(nodePool *hyperv1.NodePool) (bool, string) {
+	condition := observedCondition(nodePool, hyperv1.NodePoolAutoscalingEnabledConditionType)
+	if condition == nil || condition.Status != corev1.ConditionTrue {
+		return false, "autoscaling not enabled yet"
+	}
+	autoScaling := nodePool.Spec.AutoScaling
+	if autoScaling == nil {
+		return false, "autoscaling not set in the NodePool spec"
+	}
+	if nodePool.Status.Replicas < autoScaling.Min || nodePool.Status.Replicas > autoScaling.Max {
+		return false, fmt.Sprintf("%d nodes, autoscaling between %d and %d", nodePool.Status.Replicas, autoScaling.Min, autoScaling.Max)
+	}
+	return allMachinesReady(nodePool)
+}
@@ -0,0 +1,265 @@
+package nodepool
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+)
+
+func TestManageNodePool(t *testing.T) {
+	key := types.NamespacedName{Namespace: "clusters", Name: "np"}
+	newNodePool := func(mutate func(*hyperv1.NodePool)) *hyperv1.NodePool {
+		nodePool := &hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Spec: hyperv1.NodePoolSpec{
+				ClusterName: "example",
+				Replicas:    ptr.To[int32](2),
+				Release:     hyperv1.Release{Image: "quay.io/openshift-release-dev/ocp-release:4.16.0-x86_64"},
+			},
+			Status: hyperv1.NodePoolStatus{Replicas: 2},
+		}
+		if mutate != nil {
+			mutate(nodePool)
+		}
+		return nodePool
+	}
+
+	testCases := []struct {
+		name          string
+		nodePool      *hyperv1.NodePool
+		change        func(context.Context, crclient.Client) error
+		expectedError string
+		expected      func(*WithT, *hyperv1.NodePool)
+	}{
+		{
+			name:     "When scaling it should set replicas",
+			nodePool: newNodePool(nil),
+			change: func(ctx context.Context, c crclient.Client) error {
+				_, err := ScaleNodePool(ctx, c, key, 5)
+				return err
+			},
+			expected: func(g *WithT, nodePool *hyperv1.NodePool) {
+				g.Expect(*nodePool.Spec.Replicas).To(Equal(int32(5)))
+			},
+		},
+		{
+			name: "When scaling an autoscaled NodePool it should fail",
+			nodePool: newNodePool(func(nodePool *hyperv1.NodePool) {
+				nodePool.Spec.Replicas = nil
+				nodePool.Spec.AutoScaling = &hyperv1.NodePoolAutoScaling{Min: 1, Max: 3}
+			}),
+			change: func(ctx context.Context, c crclient.Client) error {
+				_, err := ScaleNodePool(ctx, c, key, 5)
+				return err
+			},
+			expectedError: "disable autoscaling first",
+		},
+		{
+			name:     "When enabling autoscaling it should drop replicas",
+			nodePool: newNodePool(nil),
+			change: func(ctx context.Context, c crclient.Client) error {
+				_, err := AutoscaleNodePool(ctx, c, key, 1, 3)
+				return err
+			},
+			expected: func(g *WithT, nodePool *hyperv1.NodePool) {
+				g.Expect(nodePool.Spec.Replicas).To(BeNil())
+				g.Expect(nodePool.Spec.AutoScaling).To(Equal(&hyperv1.NodePoolAutoScaling{Min: 1, Max: 3}))
+			},
+		},
+		{
+			name: "When disabling autoscaling it should keep the current number of nodes",
+			nodePool: newNodePool(func(nodePool *hyperv1.NodePool) {
+				nodePool.Spec.Replicas = nil
+				nodePool.Spec.AutoScaling = &hyperv1.NodePoolAutoScaling{Min: 1, Max: 3}
+			}),
+			change: func(ctx context.Context, c crclient.Client) error {
+				_, err := DisableNodePoolAutoscaling(ctx, c, key, nil)
+				return err
+			},
+			expected: func(g *WithT, nodePool *hyperv1.NodePool) {
+				g.Expect(nodePool.Spec.AutoScaling).To(BeNil())
+				g.Expect(*nodePool.Spec.Replicas).To(Equal(int32(2)))
+			},
+		},
+		{
+			name:     "When upgrading it should set the release image",
+			nodePool: newNodePool(nil),
+			change: func(ctx context.Context, c crclient.Client) error {
+				_, err := UpgradeNodePool(ctx, c, key, "quay.io/openshift-release-dev/ocp-release:4.16.1-x86_64")
+				return err
+			},
+			expected: func(g *WithT, nodePool *hyperv1.NodePool) {
+				g.Expect(nodePool.Spec.Release.Image).To(Equal("quay.io/openshift-release-dev/ocp-release:4.16.1-x86_64"))
+			},
+		},
+		{
+			name:     "When pausing it should set pausedUntil",
+			nodePool: newNodePool(nil),
+			change: func(ctx context.Context, c crclient.Client) error {
+				_, err := PauseNodePool(ctx, c, key, "true")
+				return err
+			},
+			expected: func(g *WithT, nodePool *hyperv1.NodePool) {
+				g.Expect(nodePool.Spec.PausedUntil).To(Equal(ptr.To("true")))
+			},
+		},
+		{
+			name:     "When resuming a NodePool which is not paused it should fail",
+			nodePool: newNodePool(nil),
+			change: func(ctx context.Context, c crclient.Client) error {
+				_, err := ResumeNodePool(ctx, c, key)
+				return err
+			},
+			expectedError: "is not paused",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.nodePool).Build()
+
+			err := tc.change(ctx, c)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			nodePool := &hyperv1.NodePool{}
+			g.Expect(c.Get(ctx, key, nodePool)).To(Succeed())
+			tc.expected(g, nodePool)
+		})
+	}
+}
+
+func TestNodePoolDoneFuncs(t *testing.T) {
+	condition := func(conditionType string, status corev1.ConditionStatus, generation int64) hyperv1.NodePoolCondition {
+		return hyperv1.NodePoolCondition{Type: conditionType, Status: status, ObservedGeneration: generation}
+	}
+	nodePool := func(replicas int32, conditions ...hyperv1.NodePoolCondition) *hyperv1.NodePool {
+		return &hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Status:     hyperv1.NodePoolStatus{Replicas: replicas, Conditions: conditions},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		done     nodePoolDoneFunc
+		nodePool *hyperv1.NodePool
+		expected bool
+	}{
+		{
+			name:     "When the NodePool has not scaled yet it should not be done",
+			done:     scaledTo(3),
+			nodePool: nodePool(2, condition(hyperv1.NodePoolAllMachinesReadyConditionType, corev1.ConditionTrue, 2)),
+		},
+		{
+			name:     "When the NodePool has scaled and machines are ready it should be done",
+			done:     scaledTo(3),
+			nodePool: nodePool(3, condition(hyperv1.NodePoolAllMachinesReadyConditionType, corev1.ConditionTrue, 2)),
+			expected: true,
+		},
+		{
+			name: "When the upgrade has not been observed yet it should not be done",
+			done: upgraded,
+			nodePool: nodePool(3,
+				condition(hyperv1.NodePoolUpdatingVersionConditionType, corev1.ConditionFalse, 1),
+				condition(hyperv1.NodePoolAllMachinesReadyConditionType, corev1.ConditionTrue, 2),
+			),
+		},
+		{
+			name: "When the version is still updating it should not be done",
+			done: upgraded,
+			nodePool: nodePool(3,
+				condition(hyperv1.NodePoolUpdatingVersionConditionType, corev1.ConditionTrue, 2),
+				condition(hyperv1.NodePoolAllMachinesReadyConditionType, corev1.ConditionTrue, 2),
+			),
+		},
+		{
+			name: "When the version is updated and machines are ready it should be done",
+			done: upgraded,
+			nodePool: nodePool(3,
+				condition(hyperv1.NodePoolUpdatingVersionConditionType, corev1.ConditionFalse, 2),
+				condition(hyperv1.NodePoolAllMachinesReadyConditionType, corev1.ConditionTrue, 2),
+			),
+			expected: true,
+		},
+		{
+			name:     "When reconciliation is paused it should be done pausing",
+			done:     reconciliationActive(false),
+			nodePool: nodePool(3, condition(hyperv1.NodePoolReconciliationActiveConditionType, corev1.ConditionFalse, 2)),
+			expected: true,
+		},
+		{
+			name:     "When autoscaling is enabled but no longer set in the spec it should not be done",
+			done:     autoscaling,
+			nodePool: nodePool(3, condition(hyperv1.NodePoolAutoscalingEnabledConditionType, corev1.ConditionTrue, 2), condition(hyperv1.NodePoolAllMachinesReadyConditionType, corev1.ConditionTrue, 2)),
+		},
+		{
+			name: "When autoscaling is enabled within bounds and machines are ready it should be done",
+			done: autoscaling,
+			nodePool: func() *hyperv1.NodePool {
+				np := nodePool(3, condition(hyperv1.NodePoolAutoscalingEnabledConditionType, corev1.ConditionTrue, 2), condition(hyperv1.NodePoolAllMachinesReadyConditionType, corev1.ConditionTrue, 2))
+				np.Spec.AutoScaling = &hyperv1.NodePoolAutoScaling{Min: 2, Max: 5}
+				return np
+			}(),
+			expected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			done, _ := tc.done(tc.nodePool)
+			g.Expect(done).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestListNodePools(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(
+		&hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "workers"},
+			Spec: hyperv1.NodePoolSpec{
+				ClusterName: "example",
+				AutoScaling: &hyperv1.NodePoolAutoScaling{Min: 1, Max: 3},
+			},
+			Status: hyperv1.NodePoolStatus{
+				Replicas: 2,
+				Version:  "4.16.0",
+				Conditions: []hyperv1.NodePoolCondition{
+					{Type: hyperv1.NodePoolUpdatingConfigConditionType, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Now())},
+				},
+			},
+		},
+		&hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "other"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "other"},
+		},
+	).Build()
+
+	out := &bytes.Buffer{}
+	g.Expect(ListNodePools(ctx, c, out, &GetOptions{Namespace: "clusters", ClusterName: "example", Output: OutputTable})).To(Succeed())
+	g.Expect(out.String()).To(MatchRegexp(`workers\s+example\s+-\s+2\s+1-3\s+4.16.0\s+Config\s+Unknown`))
+	g.Expect(out.String()).ToNot(ContainSubstring("other"))
+
+	out.Reset()
+	g.Expect(ListNodePools(ctx, c, out, &GetOptions{Namespace: "clusters", Output: OutputJSON})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring(`"kind": "NodePoolList"`))
+	g.Expect(out.String()).To(ContainSubstring(`"name": "other"`))
+}
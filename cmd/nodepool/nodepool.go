@@ -0,0 +1,27 @@
+package nodepool
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/hypershift/cmd/nodepool/payload"
+)
+
+// NewCommand returns the day-2 commands operating on existing NodePools.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "nodepool",
+		Short:        "Inspect and operate existing HyperShift NodePools",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(NewListCommand())
+	cmd.AddCommand(NewDescribeCommand())
+	cmd.AddCommand(NewScaleCommand())
+	cmd.AddCommand(NewAutoscaleCommand())
+	cmd.AddCommand(NewUpgradeCommand())
+	cmd.AddCommand(NewPauseCommand())
+	cmd.AddCommand(NewResumeCommand())
+	cmd.AddCommand(payload.NewDiffCommand())
+
+	return cmd
+}
@@ -0,0 +1,281 @@
+package payload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	"github.com/openshift/hypershift/ignition-server/controllers"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	supportutil "github.com/openshift/hypershift/support/util"
+)
+
+const (
+	// This mirrors the NodePool controller annotation holding the payload config
+	// hash the NodePool is currently running.
+	nodePoolAnnotationCurrentConfigVersion = "hypershift.openshift.io/nodePoolCurrentConfigVersion"
+)
+
+const diffDescription = `
+This command shows what changes in the ignition payload of a NodePool's nodes
+when the NodePool rolls out a new payload config hash.
+
+By default the payload of the current config version of the NodePool is compared
+with the payload of its target config version. Both payloads are rendered from
+the token Secrets the NodePool controller keeps in the control plane namespace:
+the payload cached in the Secret is used when present, otherwise it is rendered
+locally from the Secret's release image and MachineConfigs, which requires the
+MCO binaries of the release to run on this host and --feature-gate-manifest.
+
+With --release-image, the target payload is rendered from the current
+MachineConfigs with the given release image instead, so the effect of an
+upgrade can be inspected before it is requested.
+
+Changes are reported per file, link, systemd unit, unit drop-in and user.
+Contents of pull secrets, kubeconfigs, keys and user credentials are redacted,
+as are certificates, keys, URL credentials (e.g. of proxies) and registry auths
+found in any other file or unit.
+`
+
+type DiffOptions struct {
+	Namespace           string
+	Name                string
+	FromConfigVersion   string
+	ToConfigVersion     string
+	ReleaseImage        string
+	FeatureGateManifest string
+	WorkDir             string
+}
+
+func NewDiffCommand() *cobra.Command {
+	opts := &DiffOptions{
+		Namespace: "clusters",
+	}
+
+	cmd := &cobra.Command{
+		Use:          "diff-payload",
+		Short:        "Show the file and unit level changes between the current and target ignition payloads of a NodePool",
+		Long:         diffDescription,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace, "The namespace of the NodePool")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the NodePool")
+	cmd.Flags().StringVar(&opts.FromConfigVersion, "from-config-version", opts.FromConfigVersion, "The payload config hash to compare from. Defaults to the current config version of the NodePool.")
+	cmd.Flags().StringVar(&opts.ToConfigVersion, "to-config-version", opts.ToConfigVersion, "The payload config hash to compare to. Defaults to the target config version of the NodePool.")
+	cmd.Flags().StringVar(&opts.ReleaseImage, "release-image", opts.ReleaseImage, "Render the target payload from the current config with this release image instead.")
+	cmd.Flags().StringVar(&opts.FeatureGateManifest, "feature-gate-manifest", opts.FeatureGateManifest, "Path to a rendered featuregates.config.openshift.io/v1 manifest, required to render payloads locally")
+	cmd.Flags().StringVar(&opts.WorkDir, "dir", opts.WorkDir, "Working directory used to render payloads (default: temporary dir)")
+
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Run(cmd.Context()); err != nil {
+			log.Log.Error(err, "Failed to diff NodePool payloads")
+			return err
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+func (o *DiffOptions) Run(ctx context.Context) error {
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+
+	nodePool := &hyperv1.NodePool{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, nodePool); err != nil {
+		return fmt.Errorf("failed to get NodePool: %w", err)
+	}
+	hostedCluster := &hyperv1.HostedCluster{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: nodePool.Spec.ClusterName}, hostedCluster); err != nil {
+		return fmt.Errorf("failed to get HostedCluster: %w", err)
+	}
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(hostedCluster.Namespace, hostedCluster.Name)
+
+	fromConfigVersion, toConfigVersion, err := o.configVersions(ctx, c, nodePool, controlPlaneNamespace)
+	if err != nil {
+		return err
+	}
+	if fromConfigVersion == toConfigVersion && o.ReleaseImage == "" {
+		fmt.Fprintf(os.Stderr, "NodePool %s/%s is not rolling out, its current and target payload config version is %s\n", o.Namespace, o.Name, fromConfigVersion)
+		return nil
+	}
+
+	renderer := &payloadRenderer{
+		client:              c,
+		namespace:           controlPlaneNamespace,
+		nodePool:            nodePool.Name,
+		platform:            hostedCluster.Spec.Platform.Type,
+		featureGateManifest: o.FeatureGateManifest,
+		workDir:             o.WorkDir,
+	}
+	defer renderer.cleanup()
+	fromPayload, err := renderer.payload(ctx, fromConfigVersion, "")
+	if err != nil {
+		return fmt.Errorf("failed to get payload for config version %s: %w", fromConfigVersion, err)
+	}
+	toPayload, err := renderer.payload(ctx, toConfigVersion, o.ReleaseImage)
+	if err != nil {
+		return fmt.Errorf("failed to get payload for config version %s: %w", toConfigVersion, err)
+	}
+
+	changes, err := controllers.DiffPayloads(fromPayload, toPayload)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Payload changes for NodePool %s/%s from config version %s to %s", o.Namespace, o.Name, fromConfigVersion, toConfigVersion)
+	if o.ReleaseImage != "" {
+		fmt.Printf(" with release image %s", o.ReleaseImage)
+	}
+	fmt.Printf(":\n\n")
+	controllers.FormatPayloadChanges(os.Stdout, changes)
+	return nil
+}
+
+// configVersions returns the payload config hashes to compare, defaulting to the
+// current config version of the NodePool and to the config version it is rolling
+// out to.
+func (o *DiffOptions) configVersions(ctx context.Context, c crclient.Client, nodePool *hyperv1.NodePool, controlPlaneNamespace string) (string, string, error) {
+	fromConfigVersion := o.FromConfigVersion
+	if fromConfigVersion == "" {
+		fromConfigVersion = nodePool.Annotations[nodePoolAnnotationCurrentConfigVersion]
+	}
+	toConfigVersion := o.ToConfigVersion
+	switch {
+	case o.ReleaseImage != "" && toConfigVersion == "":
+		toConfigVersion = fromConfigVersion
+	case toConfigVersion == "":
+		var err error
+		toConfigVersion, err = targetConfigVersion(ctx, c, nodePool, controlPlaneNamespace)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if fromConfigVersion == "" || toConfigVersion == "" {
+		return "", "", fmt.Errorf("NodePool %s/%s has no payload config version yet", nodePool.Namespace, nodePool.Name)
+	}
+	return fromConfigVersion, toConfigVersion, nil
+}
+
+// targetConfigVersion returns the payload config hash the NodePool is rolling out to.
+// The NodePool controller keeps a token Secret per config version, and marks all
+// but the one of the target config version to expire, see nodepool.TokenSecret.
+func targetConfigVersion(ctx context.Context, c crclient.Client, nodePool *hyperv1.NodePool, controlPlaneNamespace string) (string, error) {
+	tokenSecrets := &corev1.SecretList{}
+	if err := c.List(ctx, tokenSecrets, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		return "", fmt.Errorf("failed to list token Secrets: %w", err)
+	}
+	prefix := fmt.Sprintf("token-%s-", nodePool.Name)
+	var target *corev1.Secret
+	for i := range tokenSecrets.Items {
+		tokenSecret := &tokenSecrets.Items[i]
+		if tokenSecret.Annotations[controllers.TokenSecretNodePoolAnnotation] != crclient.ObjectKeyFromObject(nodePool).String() {
+			continue
+		}
+		if _, expiring := tokenSecret.Annotations[hyperv1.IgnitionServerTokenExpirationTimestampAnnotation]; expiring {
+			continue
+		}
+		if !strings.HasPrefix(tokenSecret.Name, prefix) {
+			continue
+		}
+		if target == nil || target.CreationTimestamp.Before(&tokenSecret.CreationTimestamp) {
+			target = tokenSecret
+		}
+	}
+	if target == nil {
+		return "", nil
+	}
+	return strings.TrimPrefix(target.Name, prefix), nil
+}
+
+// payloadRenderer returns the ignition payload of a NodePool config version.
+type payloadRenderer struct {
+	client              crclient.Client
+	namespace           string
+	nodePool            string
+	platform            hyperv1.PlatformType
+	featureGateManifest string
+	workDir             string
+
+	provider *controllers.LocalIgnitionProvider
+	cacheDir string
+}
+
+// payload returns the payload cached in the token Secret of a config version,
+// or renders it locally when it's not cached or a different release image is requested.
+func (r *payloadRenderer) payload(ctx context.Context, configVersion string, releaseImage string) ([]byte, error) {
+	tokenSecret := &corev1.Secret{}
+	// Token Secrets are named after the NodePool and the payload config hash, see nodepool.TokenSecret.
+	name := fmt.Sprintf("token-%s-%s", r.nodePool, configVersion)
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: r.namespace, Name: name}, tokenSecret); err != nil {
+		return nil, fmt.Errorf("failed to get token Secret: %w", err)
+	}
+
+	if cached, ok := tokenSecret.Data[controllers.TokenSecretPayloadKey]; ok && len(cached) > 0 && releaseImage == "" {
+		return cached, nil
+	}
+
+	if releaseImage == "" {
+		releaseImage = string(tokenSecret.Data[controllers.TokenSecretReleaseKey])
+	}
+	config, err := supportutil.DecodeAndDecompress(tokenSecret.Data[controllers.TokenSecretConfigKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	provider, err := r.localProvider()
+	if err != nil {
+		return nil, err
+	}
+	log.Log.Info("Rendering payload", "configVersion", configVersion, "releaseImage", releaseImage)
+	return provider.GetPayload(ctx, releaseImage, config.String(), "", "")
+}
+
+func (r *payloadRenderer) localProvider() (*controllers.LocalIgnitionProvider, error) {
+	if r.provider != nil {
+		return r.provider, nil
+	}
+	if r.featureGateManifest == "" {
+		return nil, fmt.Errorf("--feature-gate-manifest is required to render payloads which are not cached in their token Secret")
+	}
+
+	cacheDir, err := os.MkdirTemp("", "cache")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	r.cacheDir = cacheDir
+	imageFileCache, err := controllers.NewImageFileCache(cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create image file cache: %w", err)
+	}
+	r.provider = &controllers.LocalIgnitionProvider{
+		Client:              r.client,
+		ReleaseProvider:     &releaseinfo.RegistryClientProvider{},
+		CloudProvider:       r.platform,
+		Namespace:           r.namespace,
+		WorkDir:             r.workDir,
+		ImageFileCache:      imageFileCache,
+		FeatureGateManifest: r.featureGateManifest,
+	}
+	return r.provider, nil
+}
+
+func (r *payloadRenderer) cleanup() {
+	if r.cacheDir != "" {
+		_ = os.RemoveAll(r.cacheDir)
+	}
+}
@@ -0,0 +1,108 @@
+package payload
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/ignition-server/controllers"
+	"github.com/openshift/hypershift/support/api"
+)
+
+func TestConfigVersions(t *testing.T) {
+	const controlPlaneNamespace = "clusters-example"
+	now := time.Now()
+
+	nodePool := &hyperv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "clusters",
+			Name:      "np",
+			Annotations: map[string]string{
+				nodePoolAnnotationCurrentConfigVersion: "current",
+			},
+		},
+	}
+	tokenSecret := func(name, nodePool string, created time.Time, expiring bool) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         controlPlaneNamespace,
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+				Annotations: map[string]string{
+					controllers.TokenSecretAnnotation:         "true",
+					controllers.TokenSecretNodePoolAnnotation: nodePool,
+				},
+			},
+		}
+		if expiring {
+			secret.Annotations[hyperv1.IgnitionServerTokenExpirationTimestampAnnotation] = now.Add(time.Hour).Format(time.RFC3339)
+		}
+		return secret
+	}
+
+	testCases := []struct {
+		name         string
+		opts         DiffOptions
+		tokenSecrets []crclient.Object
+		expectedFrom string
+		expectedTo   string
+		expectedErr  bool
+	}{
+		{
+			name: "When the NodePool is rolling out it should default to the config version of the active token Secret",
+			tokenSecrets: []crclient.Object{
+				tokenSecret("token-np-current", "clusters/np", now.Add(-time.Hour), true),
+				tokenSecret("token-np-target", "clusters/np", now, false),
+				tokenSecret("token-np-other-newer", "clusters/np-other", now.Add(time.Minute), false),
+			},
+			expectedFrom: "current",
+			expectedTo:   "target",
+		},
+		{
+			name: "When the NodePool is not rolling out it should default to its current config version",
+			tokenSecrets: []crclient.Object{
+				tokenSecret("token-np-current", "clusters/np", now.Add(-time.Hour), false),
+			},
+			expectedFrom: "current",
+			expectedTo:   "current",
+		},
+		{
+			name:         "When config versions are given it should use them",
+			opts:         DiffOptions{FromConfigVersion: "a", ToConfigVersion: "b"},
+			expectedFrom: "a",
+			expectedTo:   "b",
+		},
+		{
+			name:         "When a release image is given it should compare the current config version to itself",
+			opts:         DiffOptions{ReleaseImage: "quay.io/openshift-release-dev/ocp-release:4.16.0-x86_64"},
+			expectedFrom: "current",
+			expectedTo:   "current",
+		},
+		{
+			name:        "When there is no token Secret it should fail",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.tokenSecrets...).Build()
+
+			from, to, err := tc.opts.configVersions(context.Background(), c, nodePool, controlPlaneNamespace)
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(from).To(Equal(tc.expectedFrom))
+			g.Expect(to).To(Equal(tc.expectedTo))
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	kubeclient "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	cpomanifests "github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	supportutil "github.com/openshift/hypershift/support/util"
+)
+
+const Description = `
+This command keeps a port-forward to the kube-apiserver of a HostedCluster through the
+management cluster and renders a kubeconfig pointing at the local end of it, so that
+clusters whose API server is not exposed, e.g. private clusters, can be administered from
+any machine with access to the management cluster.
+
+The kubeconfig is printed to stdout or written to --kubeconfig-file, and embeds the admin
+credentials of the cluster. The port-forward is re-established on another kube-apiserver
+pod when the forwarded pod restarts or the connection is lost, until interrupted. The
+command fails if the first port-forward cannot be established, e.g. when the HostedCluster
+does not exist.
+`
+
+type Options struct {
+	Namespace      string
+	Name           string
+	LocalPort      int
+	KubeconfigFile string
+	RetryInterval  time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Namespace:     "clusters",
+		RetryInterval: 5 * time.Second,
+	}
+}
+
+// BindOptions binds the flags shared by the tunnel command and `create kubeconfig --via-port-forward`.
+func BindOptions(opts *Options, cmd *cobra.Command) {
+	cmd.Flags().IntVar(&opts.LocalPort, "local-port", opts.LocalPort, "The local port forwarded to the kube-apiserver. A free port is picked by default.")
+	cmd.Flags().DurationVar(&opts.RetryInterval, "retry-interval", opts.RetryInterval, "How often the forwarded kube-apiserver pod is checked and the port-forward re-established once lost.")
+}
+
+func NewCommand() *cobra.Command {
+	opts := DefaultOptions()
+	cmd := &cobra.Command{
+		Use:          "tunnel",
+		Short:        "Forwards a local port to the kube-apiserver of a HostedCluster through the management cluster",
+		Long:         Description,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "A HostedCluster namespace.")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "A HostedCluster name.")
+	cmd.Flags().StringVar(&opts.KubeconfigFile, "kubeconfig-file", opts.KubeconfigFile, "The file the kubeconfig pointing at the local port is written to. Printed to stdout by default.")
+	BindOptions(&opts, cmd)
+	_ = cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := Run(cmd.Context(), opts); err != nil {
+			log.Log.Error(err, "Failed to run the tunnel")
+			return err
+		}
+		return nil
+	}
+	return cmd
+}
+
+// Run renders the kubeconfig pointing at the local port and keeps the port-forward until the
+// context is done.
+func Run(ctx context.Context, opts Options) error {
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+	restConfig, err := util.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get a config for management cluster: %w", err)
+	}
+	if opts.LocalPort == 0 {
+		if opts.LocalPort, err = freePort(); err != nil {
+			return err
+		}
+	}
+	forward, err := NewForwarder(restConfig)
+	if err != nil {
+		return err
+	}
+
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(opts.Namespace, opts.Name)
+	kubeconfig, err := LocalKubeconfig(ctx, c, controlPlaneNamespace, opts.LocalPort)
+	if err != nil {
+		return err
+	}
+	if opts.KubeconfigFile == "" {
+		if _, err := os.Stdout.Write(kubeconfig); err != nil {
+			return err
+		}
+	} else {
+		if err := os.WriteFile(opts.KubeconfigFile, kubeconfig, 0600); err != nil {
+			return fmt.Errorf("failed to write kubeconfig: %w", err)
+		}
+		log.Log.Info("Wrote kubeconfig", "file", opts.KubeconfigFile)
+	}
+
+	tunnel := &Tunnel{
+		Client:        c,
+		Forward:       forward,
+		Namespace:     opts.Namespace,
+		Name:          opts.Name,
+		LocalPort:     opts.LocalPort,
+		RetryInterval: opts.RetryInterval,
+		Log:           log.Log,
+	}
+	return tunnel.Run(ctx)
+}
+
+// LocalKubeconfig returns the localhost kubeconfig of the control plane, which is valid for
+// localhost, with its server pointing at the local port.
+func LocalKubeconfig(ctx context.Context, c crclient.Client, controlPlaneNamespace string, localPort int) ([]byte, error) {
+	secret := cpomanifests.KASLocalhostKubeconfigSecret(controlPlaneNamespace)
+	if err := c.Get(ctx, crclient.ObjectKeyFromObject(secret), secret); err != nil {
+		return nil, fmt.Errorf("failed to get hostedcluster localhost kubeconfig: %w", err)
+	}
+	kubeconfig, err := clientcmd.Load(secret.Data["kubeconfig"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse localhost kubeconfig: %w", err)
+	}
+	if len(kubeconfig.Clusters) == 0 {
+		return nil, fmt.Errorf("no clusters found in localhost kubeconfig")
+	}
+	for k := range kubeconfig.Clusters {
+		kubeconfig.Clusters[k].Server = fmt.Sprintf("https://localhost:%d", localPort)
+	}
+	data, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize localhost kubeconfig: %w", err)
+	}
+	return data, nil
+}
+
+// ForwardFunc forwards a local port to a port of a pod. Once the forwarding is ready, it returns a
+// function stopping it and a channel receiving the result of the forwarding when it ends.
+type ForwardFunc func(pod *corev1.Pod, localPort, podPort int) (stop func(), done <-chan error, err error)
+
+// NewForwarder returns a ForwardFunc port-forwarding through the API server of the management cluster.
+func NewForwarder(restConfig *restclient.Config) (ForwardFunc, error) {
+	kubeClient, err := kubeclient.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a kubernetes client: %w", err)
+	}
+	return func(pod *corev1.Pod, localPort, podPort int) (func(), <-chan error, error) {
+		output := &startupOutput{}
+		forwarder := util.PortForwarder{
+			Namespace: pod.Namespace,
+			PodName:   pod.Name,
+			Config:    restConfig,
+			Client:    kubeClient,
+			Out:       output,
+			ErrOut:    output,
+		}
+		stopChan := make(chan struct{})
+		done, err := forwarder.Start([]string{fmt.Sprintf("%d:%d", localPort, podPort)}, stopChan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot forward kube apiserver port: %w, output: %s", err, output.String())
+		}
+		// the output of a long-running port-forward is not needed and would grow unbounded
+		output.discard()
+		return func() { close(stopChan) }, done, nil
+	}, nil
+}
+
+// startupOutput keeps the output of a port-forward until it is discarded once the port-forward started.
+type startupOutput struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	discarded bool
+}
+
+func (o *startupOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.discarded {
+		return len(p), nil
+	}
+	return o.buf.Write(p)
+}
+
+func (o *startupOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+func (o *startupOutput) discard() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.discarded = true
+	o.buf = bytes.Buffer{}
+}
+
+// Tunnel keeps a local port forwarded to a running kube-apiserver pod of a HostedCluster.
+type Tunnel struct {
+	Client        crclient.Client
+	Forward       ForwardFunc
+	Namespace     string
+	Name          string
+	LocalPort     int
+	RetryInterval time.Duration
+	Log           logr.Logger
+}
+
+// Run forwards the local port until the context is done. The port-forward is re-established when
+// the forwarded pod goes away or the connection to it is lost. An error is returned if the first
+// port-forward cannot be established.
+func (t *Tunnel) Run(ctx context.Context) error {
+	connected := false
+	for {
+		forwarded, err := t.forwardOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		connected = connected || forwarded
+		if !connected {
+			return err
+		}
+		t.Log.Info("Port-forward to the kube-apiserver ended, reconnecting", "reason", err.Error(), "retryInterval", t.RetryInterval.String())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(t.RetryInterval):
+		}
+	}
+}
+
+// forwardOnce forwards the local port to a running kube-apiserver pod until the pod goes away, the
+// connection is lost or the context is done. It reports whether the port-forward was established.
+func (t *Tunnel) forwardOnce(ctx context.Context) (bool, error) {
+	hcluster := &hyperv1.HostedCluster{}
+	if err := t.Client.Get(ctx, types.NamespacedName{Namespace: t.Namespace, Name: t.Name}, hcluster); err != nil {
+		return false, fmt.Errorf("failed to get hosted cluster %s/%s: %w", t.Namespace, t.Name, err)
+	}
+	pod, err := t.kubeAPIServerPod(ctx)
+	if err != nil {
+		return false, err
+	}
+	podPort := int(supportutil.KASPodPortFromHostedCluster(hcluster))
+	stop, done, err := t.Forward(pod, t.LocalPort, podPort)
+	if err != nil {
+		return false, err
+	}
+	defer stop()
+	t.Log.Info("Forwarding to the kube-apiserver", "pod", pod.Name, "localPort", t.LocalPort, "podPort", podPort)
+
+	ticker := time.NewTicker(t.RetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case err := <-done:
+			if err == nil {
+				return true, fmt.Errorf("port-forward to pod %s stopped", pod.Name)
+			}
+			return true, fmt.Errorf("port-forward to pod %s failed: %w", pod.Name, err)
+		case <-ticker.C:
+			// the connection is not always closed when the pod is deleted, e.g. while it terminates
+			current := &corev1.Pod{}
+			if err := t.Client.Get(ctx, crclient.ObjectKeyFromObject(pod), current); err != nil {
+				if apierrors.IsNotFound(err) {
+					return true, fmt.Errorf("pod %s was deleted", pod.Name)
+				}
+				t.Log.Error(err, "Failed to check the forwarded kube-apiserver pod", "pod", pod.Name)
+				continue
+			}
+			if current.UID != pod.UID || current.DeletionTimestamp != nil || current.Status.Phase != corev1.PodRunning {
+				return true, fmt.Errorf("pod %s is no longer running", pod.Name)
+			}
+		}
+	}
+}
+
+// kubeAPIServerPod returns a running kube-apiserver pod, preferably a ready one.
+func (t *Tunnel) kubeAPIServerPod(ctx context.Context) (*corev1.Pod, error) {
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(t.Namespace, t.Name)
+	pods := &corev1.PodList{}
+	if err := t.Client.List(ctx, pods, crclient.InNamespace(controlPlaneNamespace), crclient.MatchingLabels{"app": "kube-apiserver", hyperv1.ControlPlaneComponent: "kube-apiserver"}); err != nil {
+		return nil, fmt.Errorf("failed to list kube-apiserver pods in control plane namespace: %w", err)
+	}
+	var running *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if isReady(pod) {
+			return pod, nil
+		}
+		if running == nil {
+			running = pod
+		}
+	}
+	if running == nil {
+		return nil, fmt.Errorf("did not find running kube-apiserver pod for guest cluster")
+	}
+	return running, nil
+}
+
+func isReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// freePort returns a local port which is free at the time of the call.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free local port: %w", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
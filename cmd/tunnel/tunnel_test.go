@@ -0,0 +1,224 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func kubeAPIServerPod(name string, ready bool) *corev1.Pod {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "clusters-hc",
+			Name:      name,
+			UID:       types.UID(name),
+			Labels:    map[string]string{"app": "kube-apiserver", hyperv1.ControlPlaneComponent: "kube-apiserver"},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: readyStatus}},
+		},
+	}
+}
+
+func TestLocalKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters["cluster"] = &clientcmdapi.Cluster{Server: "https://localhost:6443", CertificateAuthorityData: []byte("ca")}
+	data, err := clientcmd.Write(*kubeconfig)
+	g.Expect(err).ToNot(HaveOccurred())
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "localhost-kubeconfig"},
+		Data:       map[string][]byte{"kubeconfig": data},
+	}).Build()
+
+	result, err := LocalKubeconfig(context.Background(), c, "clusters-hc", 40000)
+	g.Expect(err).ToNot(HaveOccurred())
+	parsed, err := clientcmd.Load(result)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(parsed.Clusters["cluster"].Server).To(Equal("https://localhost:40000"))
+	g.Expect(parsed.Clusters["cluster"].CertificateAuthorityData).To(Equal([]byte("ca")))
+
+	_, err = LocalKubeconfig(context.Background(), c, "other", 40000)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestKubeAPIServerPod(t *testing.T) {
+	testCases := []struct {
+		name        string
+		pods        []crclient.Object
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "When a pod is ready it should be preferred",
+			pods:     []crclient.Object{kubeAPIServerPod("kas-a", false), kubeAPIServerPod("kas-b", true)},
+			expected: "kas-b",
+		},
+		{
+			name:     "When no pod is ready it should fall back to a running one",
+			pods:     []crclient.Object{kubeAPIServerPod("kas-a", false)},
+			expected: "kas-a",
+		},
+		{
+			name: "When no pod is running it should fail",
+			pods: []crclient.Object{func() *corev1.Pod {
+				pod := kubeAPIServerPod("kas-a", false)
+				pod.Status.Phase = corev1.PodPending
+				return pod
+			}()},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			tunnel := &Tunnel{
+				Client:    fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.pods...).Build(),
+				Namespace: "clusters",
+				Name:      "hc",
+			}
+			pod, err := tunnel.kubeAPIServerPod(context.Background())
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(pod.Name).To(Equal(tc.expected))
+		})
+	}
+}
+
+// fakeForwarder records the forwarded pods and lets the test end the forwarding.
+type fakeForwarder struct {
+	mu        sync.Mutex
+	forwarded []string
+	stopped   int
+	done      chan error
+}
+
+func (f *fakeForwarder) forward(pod *corev1.Pod, localPort, podPort int) (func(), <-chan error, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forwarded = append(f.forwarded, pod.Name)
+	f.done = make(chan error, 1)
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.stopped++
+	}, f.done, nil
+}
+
+func (f *fakeForwarder) forwardedPods() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.forwarded...)
+}
+
+func (f *fakeForwarder) lose() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.done <- errors.New("lost connection to pod")
+}
+
+func TestTunnelRun(t *testing.T) {
+	g := NewWithT(t)
+	hc := &hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"}}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(hc, kubeAPIServerPod("kas-a", true)).Build()
+	forwarder := &fakeForwarder{}
+	tunnel := &Tunnel{
+		Client:        c,
+		Forward:       forwarder.forward,
+		Namespace:     "clusters",
+		Name:          "hc",
+		LocalPort:     40000,
+		RetryInterval: 10 * time.Millisecond,
+		Log:           zap.New(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- tunnel.Run(ctx) }()
+	g.Eventually(forwarder.forwardedPods).Should(Equal([]string{"kas-a"}))
+
+	// when the forwarded pod is replaced, it should forward to the new one
+	g.Expect(c.Delete(ctx, kubeAPIServerPod("kas-a", true))).To(Succeed())
+	g.Expect(c.Create(ctx, kubeAPIServerPod("kas-b", true))).To(Succeed())
+	g.Eventually(forwarder.forwardedPods).Should(Equal([]string{"kas-a", "kas-b"}))
+
+	// when the connection is lost, it should reconnect
+	forwarder.lose()
+	g.Eventually(forwarder.forwardedPods).Should(Equal([]string{"kas-a", "kas-b", "kas-b"}))
+
+	cancel()
+	g.Eventually(result).Should(Receive(BeNil()))
+	g.Expect(forwarder.stopped).To(Equal(3))
+}
+
+func TestTunnelRunFirstAttemptFails(t *testing.T) {
+	testCases := []struct {
+		name          string
+		objects       []crclient.Object
+		expectedError string
+	}{
+		{
+			name:          "When the hosted cluster does not exist it should fail",
+			objects:       []crclient.Object{kubeAPIServerPod("kas-a", true)},
+			expectedError: "failed to get hosted cluster clusters/hc",
+		},
+		{
+			name:          "When no kube-apiserver pod is running it should fail",
+			objects:       []crclient.Object{&hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"}}},
+			expectedError: "did not find running kube-apiserver pod",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.objects...).Build()
+			forwarder := &fakeForwarder{}
+			tunnel := &Tunnel{
+				Client:        c,
+				Forward:       forwarder.forward,
+				Namespace:     "clusters",
+				Name:          "hc",
+				LocalPort:     40000,
+				RetryInterval: 10 * time.Millisecond,
+				Log:           zap.New(),
+			}
+			g.Expect(tunnel.Run(context.Background())).To(MatchError(ContainSubstring(tc.expectedError)))
+			g.Expect(forwarder.forwardedPods()).To(BeEmpty())
+		})
+	}
+}
+
+func TestStartupOutput(t *testing.T) {
+	g := NewWithT(t)
+	output := &startupOutput{}
+	_, err := output.Write([]byte("error: unable to listen on port"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(output.String()).To(Equal("error: unable to listen on port"))
+
+	output.discard()
+	n, err := output.Write([]byte("Handling connection for 40000\n"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(n).To(Equal(len("Handling connection for 40000\n")))
+	g.Expect(output.String()).To(BeEmpty())
+}
@@ -1,5 +1,5 @@
 // source: https://github.com/openshift/oc/blob/bc2163c506ff27cda7ab907a715aeb1815389ead/pkg/cli/rsync/forwarder.go
-package core
+package util
 
 import (
 	"io"
@@ -11,8 +11,8 @@ import (
 	"k8s.io/client-go/transport/spdy"
 )
 
-// portForwarder starts port forwarding to a given pod
-type portForwarder struct {
+// PortForwarder starts port forwarding to a given pod
+type PortForwarder struct {
 	Namespace string
 	PodName   string
 	Client    kubernetes.Interface
@@ -23,7 +23,15 @@ type portForwarder struct {
 
 // ForwardPorts will forward a set of ports from a pod, the stopChan will stop the forwarding
 // when it's closed or receives a struct{}
-func (f *portForwarder) ForwardPorts(ports []string, stopChan <-chan struct{}) error {
+func (f *PortForwarder) ForwardPorts(ports []string, stopChan <-chan struct{}) error {
+	_, err := f.Start(ports, stopChan)
+	return err
+}
+
+// Start forwards a set of ports from a pod like ForwardPorts. Once the forwarding is ready, the
+// returned channel receives the result of the forwarding when it ends, either because the stopChan
+// was closed or because the connection to the pod was lost.
+func (f *PortForwarder) Start(ports []string, stopChan <-chan struct{}) (<-chan error, error) {
 	req := f.Client.CoreV1().RESTClient().Post().
 		Resource("pods").
 		Namespace(f.Namespace).
@@ -32,21 +40,21 @@ func (f *portForwarder) ForwardPorts(ports []string, stopChan <-chan struct{}) e
 
 	transport, upgrader, err := spdy.RoundTripperFor(f.Config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
 
 	readyChan := make(chan struct{})
 	fw, err := portforward.New(dialer, ports, stopChan, readyChan, f.Out, f.ErrOut)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	errChan := make(chan error)
+	errChan := make(chan error, 1)
 	go func() { errChan <- fw.ForwardPorts() }()
 	select {
 	case <-readyChan:
-		return nil
+		return errChan, nil
 	case err = <-errChan:
-		return err
+		return nil, err
 	}
 }
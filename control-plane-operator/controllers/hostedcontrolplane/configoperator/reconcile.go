@@ -423,6 +423,10 @@ func buildHCCContainerMain(image, hcpName, openShiftVersion, kubeVersion string,
 				Name:  "OPERATE_ON_RELEASE_IMAGE",
 				Value: releaseImage,
 			},
+			{
+				Name:  "CONTROL_PLANE_OPERATOR_IMAGE",
+				Value: image,
+			},
 			{
 				Name:  "OPENSHIFT_IMG_OVERRIDES",
 				Value: util.ConvertOpenShiftImageRegistryOverridesToCommandLineFlag(openShiftImageRegistryOverrides),
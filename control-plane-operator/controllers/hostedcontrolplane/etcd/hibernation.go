@@ -0,0 +1,124 @@
+package etcd
+
+import (
+	"fmt"
+
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/pki"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	hibernationSnapshotDir  = "/var/lib/etcd-snapshot"
+	hibernationSnapshotFile = "snapshot.db"
+)
+
+// ReconcileHibernationSnapshotPVC reconciles the volume the final etcd snapshot is saved to before
+// the control plane is hibernated. It is sized and classed like the etcd data volumes.
+func ReconcileHibernationSnapshotPVC(pvc *corev1.PersistentVolumeClaim, p *EtcdParams) error {
+	p.OwnerRef.ApplyTo(pvc)
+	// The spec of a bound claim is immutable
+	if !pvc.CreationTimestamp.IsZero() {
+		return nil
+	}
+	if p.StorageSpec.PersistentVolume == nil {
+		return fmt.Errorf("etcd does not use persistent volumes")
+	}
+	pvc.Spec = corev1.PersistentVolumeClaimSpec{
+		StorageClassName: p.StorageSpec.PersistentVolume.StorageClassName,
+		AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		Resources: corev1.VolumeResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceStorage: *p.StorageSpec.PersistentVolume.Size,
+			},
+		},
+	}
+	return nil
+}
+
+// ReconcileHibernationSnapshotJob reconciles the job which saves the final etcd snapshot
+// in the hibernation snapshot volume, replacing the snapshot of a previous hibernation.
+func ReconcileHibernationSnapshotJob(job *batchv1.Job, p *EtcdParams) error {
+	p.OwnerRef.ApplyTo(job)
+	// The pod template of a job is immutable
+	if !job.CreationTimestamp.IsZero() {
+		return nil
+	}
+	job.Spec = batchv1.JobSpec{
+		BackoffLimit: pointer.Int32(3),
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				RestartPolicy:                corev1.RestartPolicyNever,
+				AutomountServiceAccountToken: pointer.Bool(false),
+				PriorityClassName:            p.DeploymentConfig.Scheduling.PriorityClass,
+				Containers: []corev1.Container{
+					{
+						Name:            "etcd-snapshot",
+						Image:           p.EtcdImage,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"/bin/bash", "-c", hibernationSnapshotScript},
+						VolumeMounts: []corev1.VolumeMount{
+							{
+								Name:      "client-tls",
+								MountPath: "/etc/etcd/tls/client",
+							},
+							{
+								Name:      "etcd-ca",
+								MountPath: "/etc/etcd/tls/etcd-ca",
+							},
+							{
+								Name:      "snapshot",
+								MountPath: hibernationSnapshotDir,
+							},
+						},
+					},
+				},
+				Volumes: []corev1.Volume{
+					{
+						Name: "client-tls",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName:  manifests.EtcdClientSecret("").Name,
+								DefaultMode: pointer.Int32(420),
+							},
+						},
+					},
+					{
+						Name: "etcd-ca",
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: manifests.EtcdSignerCAConfigMap("").Name,
+								},
+							},
+						},
+					},
+					{
+						Name: "snapshot",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+								ClaimName: manifests.EtcdHibernationSnapshotPVC("").Name,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return nil
+}
+
+var hibernationSnapshotScript = fmt.Sprintf(`
+set -euo pipefail
+
+export ETCDCTL_API=3
+export ETCDCTL_CACERT=/etc/etcd/tls/etcd-ca/ca.crt
+export ETCDCTL_CERT=/etc/etcd/tls/client/%[1]s
+export ETCDCTL_KEY=/etc/etcd/tls/client/%[2]s
+export ETCDCTL_ENDPOINTS=https://etcd-client:2379
+
+etcdctl snapshot save %[3]s/%[4]s.partial
+mv %[3]s/%[4]s.partial %[3]s/%[4]s
+`, pki.EtcdClientCrtKey, pki.EtcdClientKeyKey, hibernationSnapshotDir, hibernationSnapshotFile)
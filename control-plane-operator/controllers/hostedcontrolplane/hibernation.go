@@ -0,0 +1,297 @@
+package hostedcontrolplane
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/etcd"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/imageprovider"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// hibernationReplicasAnnotation records the replicas of a workload scaled down for hibernation.
+	hibernationReplicasAnnotation = "hypershift.openshift.io/hibernation-replicas"
+
+	// controlPlaneOperatorDeploymentName is never scaled down, it drives the resume.
+	controlPlaneOperatorDeploymentName = "control-plane-operator"
+
+	hibernationRequeueInterval = 15 * time.Second
+)
+
+// hibernationWorkload is a Deployment or StatefulSet of the control plane namespace.
+type hibernationWorkload struct {
+	object          client.Object
+	replicas        **int32
+	currentReplicas int32
+}
+
+// hibernationStage returns when a workload is scaled down: first the components which depend on
+// the kube-apiserver, then the kube-apiserver and finally etcd. The regular reconciliation brings
+// them back in the opposite order.
+func hibernationStage(name string) int {
+	switch name {
+	case manifests.KASDeployment("").Name:
+		return 1
+	case manifests.EtcdStatefulSet("").Name:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// reconcileHibernation scales the control plane down after a final etcd snapshot while the
+// HostedControlPlane has the HibernateAnnotation, and reports it in the Hibernating condition.
+// It returns true while the control plane is hibernated, in which case the rest of the control plane
+// must not be reconciled.
+//
+// On resume the regular reconciliation renews the certificates which expired while hibernated and
+// brings etcd, the kube-apiserver and the remaining components back in that order. Once the control
+// plane is ready the workloads which are not reconciled by the control plane operator are restored.
+func (r *HostedControlPlaneReconciler) reconcileHibernation(ctx context.Context, hcp *hyperv1.HostedControlPlane, releaseImage *releaseinfo.ReleaseImage) (bool, ctrl.Result, error) {
+	condition := metav1.Condition{
+		Type:               string(hyperv1.Hibernating),
+		ObservedGeneration: hcp.Generation,
+	}
+	var hibernated bool
+	var result ctrl.Result
+	var err error
+	if hcp.Annotations[hyperv1.HibernateAnnotation] == "true" {
+		hibernated = true
+		result, err = r.hibernate(ctx, hcp, releaseImage, &condition)
+	} else {
+		existing := meta.FindStatusCondition(hcp.Status.Conditions, string(hyperv1.Hibernating))
+		if existing == nil || existing.Reason == hyperv1.AsExpectedReason {
+			return false, ctrl.Result{}, nil
+		}
+		result, err = r.resume(ctx, hcp, &condition)
+	}
+	if err != nil {
+		return false, ctrl.Result{}, err
+	}
+
+	original := hcp.DeepCopy()
+	meta.SetStatusCondition(&hcp.Status.Conditions, condition)
+	if err := r.Client.Status().Patch(ctx, hcp, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return false, ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+	return hibernated, result, nil
+}
+
+func (r *HostedControlPlaneReconciler) hibernate(ctx context.Context, hcp *hyperv1.HostedControlPlane, releaseImage *releaseinfo.ReleaseImage, condition *metav1.Condition) (ctrl.Result, error) {
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = hyperv1.HibernationInProgressReason
+
+	workloads, err := r.listHibernationWorkloads(ctx, hcp.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if hcp.Spec.Etcd.ManagementType == hyperv1.Managed {
+		etcdScaledDown := false
+		for _, workload := range workloads {
+			if workload.object.GetName() == manifests.EtcdStatefulSet("").Name {
+				_, etcdScaledDown = workload.object.GetAnnotations()[hibernationReplicasAnnotation]
+			}
+		}
+		if !etcdScaledDown {
+			completed, err := r.reconcileHibernationEtcdSnapshot(ctx, hcp, releaseImage)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !completed {
+				condition.Message = "Waiting for the final etcd snapshot"
+				return ctrl.Result{RequeueAfter: hibernationRequeueInterval}, nil
+			}
+		}
+	}
+
+	for stage := 0; stage <= hibernationStage(manifests.EtcdStatefulSet("").Name); stage++ {
+		var running []string
+		for _, workload := range workloads {
+			if hibernationStage(workload.object.GetName()) != stage {
+				continue
+			}
+			if err := r.scaleDownForHibernation(ctx, workload); err != nil {
+				return ctrl.Result{}, err
+			}
+			if workload.currentReplicas > 0 {
+				running = append(running, workload.object.GetName())
+			}
+		}
+		if len(running) > 0 {
+			condition.Message = fmt.Sprintf("Waiting for %v to scale down", running)
+			return ctrl.Result{RequeueAfter: hibernationRequeueInterval}, nil
+		}
+	}
+
+	condition.Reason = hyperv1.HibernatedReason
+	condition.Message = "The control plane is scaled down"
+	if hcp.Spec.Etcd.ManagementType == hyperv1.Managed {
+		condition.Message += fmt.Sprintf(", the final etcd snapshot is kept in the %s volume", manifests.EtcdHibernationSnapshotPVC("").Name)
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *HostedControlPlaneReconciler) resume(ctx context.Context, hcp *hyperv1.HostedControlPlane, condition *metav1.Condition) (ctrl.Result, error) {
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = hyperv1.ResumingReason
+	if !hcp.Status.Ready {
+		condition.Message = "Waiting for the control plane to become ready"
+		return ctrl.Result{RequeueAfter: hibernationRequeueInterval}, nil
+	}
+
+	workloads, err := r.listHibernationWorkloads(ctx, hcp.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	for _, workload := range workloads {
+		if err := r.restoreFromHibernation(ctx, workload); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	job := manifests.EtcdHibernationSnapshotJob(hcp.Namespace)
+	if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("failed to delete etcd hibernation snapshot job: %w", err)
+	}
+
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = hyperv1.AsExpectedReason
+	condition.Message = "The control plane is running"
+	return ctrl.Result{}, nil
+}
+
+// reconcileHibernationEtcdSnapshot runs the job which saves the final etcd snapshot and
+// returns true once it completed.
+func (r *HostedControlPlaneReconciler) reconcileHibernationEtcdSnapshot(ctx context.Context, hcp *hyperv1.HostedControlPlane, releaseImage *releaseinfo.ReleaseImage) (bool, error) {
+	p, err := etcd.NewEtcdParams(hcp, imageprovider.New(releaseImage))
+	if err != nil {
+		return false, fmt.Errorf("error creating etcd params: %w", err)
+	}
+	createOrUpdate := r.createOrUpdate(hcp)
+
+	pvc := manifests.EtcdHibernationSnapshotPVC(hcp.Namespace)
+	if _, err := createOrUpdate(ctx, r, pvc, func() error {
+		return etcd.ReconcileHibernationSnapshotPVC(pvc, p)
+	}); err != nil {
+		return false, fmt.Errorf("failed to reconcile etcd hibernation snapshot volume: %w", err)
+	}
+
+	job := manifests.EtcdHibernationSnapshotJob(hcp.Namespace)
+	if _, err := createOrUpdate(ctx, r, job, func() error {
+		return etcd.ReconcileHibernationSnapshotJob(job, p)
+	}); err != nil {
+		return false, fmt.Errorf("failed to reconcile etcd hibernation snapshot job: %w", err)
+	}
+
+	for _, jobCondition := range job.Status.Conditions {
+		if jobCondition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch jobCondition.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			// The control plane is left running, the snapshot is retried with a new job.
+			if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+				return false, fmt.Errorf("failed to delete failed etcd hibernation snapshot job: %w", err)
+			}
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *HostedControlPlaneReconciler) listHibernationWorkloads(ctx context.Context, namespace string) ([]hibernationWorkload, error) {
+	var workloads []hibernationWorkload
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if deployment.Name == controlPlaneOperatorDeploymentName {
+			continue
+		}
+		workloads = append(workloads, hibernationWorkload{
+			object:          deployment,
+			replicas:        &deployment.Spec.Replicas,
+			currentReplicas: deployment.Status.Replicas,
+		})
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		workloads = append(workloads, hibernationWorkload{
+			object:          statefulSet,
+			replicas:        &statefulSet.Spec.Replicas,
+			currentReplicas: statefulSet.Status.Replicas,
+		})
+	}
+	return workloads, nil
+}
+
+func (r *HostedControlPlaneReconciler) scaleDownForHibernation(ctx context.Context, workload hibernationWorkload) error {
+	if *workload.replicas != nil && **workload.replicas == 0 {
+		return nil
+	}
+	annotations := workload.object.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if _, recorded := annotations[hibernationReplicasAnnotation]; !recorded {
+		replicas := int32(1)
+		if *workload.replicas != nil {
+			replicas = **workload.replicas
+		}
+		annotations[hibernationReplicasAnnotation] = strconv.Itoa(int(replicas))
+		workload.object.SetAnnotations(annotations)
+	}
+	*workload.replicas = pointer.Int32(0)
+	if err := r.Update(ctx, workload.object); err != nil {
+		return fmt.Errorf("failed to scale down %s for hibernation: %w", workload.object.GetName(), err)
+	}
+	return nil
+}
+
+// restoreFromHibernation restores the replicas of a workload which was scaled down for hibernation
+// and not brought back by the regular reconciliation.
+func (r *HostedControlPlaneReconciler) restoreFromHibernation(ctx context.Context, workload hibernationWorkload) error {
+	annotations := workload.object.GetAnnotations()
+	value, recorded := annotations[hibernationReplicasAnnotation]
+	if !recorded {
+		return nil
+	}
+	if *workload.replicas == nil || **workload.replicas == 0 {
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid %s annotation on %s: %w", hibernationReplicasAnnotation, workload.object.GetName(), err)
+		}
+		*workload.replicas = pointer.Int32(int32(replicas))
+	}
+	delete(annotations, hibernationReplicasAnnotation)
+	workload.object.SetAnnotations(annotations)
+	if err := r.Update(ctx, workload.object); err != nil {
+		return fmt.Errorf("failed to restore %s from hibernation: %w", workload.object.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package hostedcontrolplane
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	imagev1 "github.com/openshift/api/image/v1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/api/util/ipnet"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/support/api"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestReconcileHibernation(t *testing.T) {
+	const namespace = "clusters-hc"
+	releaseImage := &releaseinfo.ReleaseImage{ImageStream: &imagev1.ImageStream{}}
+	deployment := func(name string, replicas int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(replicas)},
+			Status:     appsv1.DeploymentStatus{Replicas: replicas},
+		}
+	}
+	statefulSet := func(name string, replicas int32) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To(replicas)},
+			Status:     appsv1.StatefulSetStatus{Replicas: replicas},
+		}
+	}
+	replicasOf := func(g Gomega, c client.Client, obj client.Object) int32 {
+		g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			return ptr.Deref(o.Spec.Replicas, 1)
+		case *appsv1.StatefulSet:
+			return ptr.Deref(o.Spec.Replicas, 1)
+		}
+		return -1
+	}
+
+	testCases := []struct {
+		name               string
+		etcdManagement     hyperv1.EtcdManagementType
+		hibernate          bool
+		ready              bool
+		existingCondition  *metav1.Condition
+		objects            []client.Object
+		expectedHibernated bool
+		expectedReason     string
+		expectedReplicas   map[string]int32
+		expectJob          bool
+	}{
+		{
+			name:           "When the control plane is not hibernated it should leave it alone",
+			etcdManagement: hyperv1.Managed,
+			objects: []client.Object{
+				deployment("kube-apiserver", 3),
+			},
+			expectedReplicas: map[string]int32{"kube-apiserver": 3},
+		},
+		{
+			name:           "When etcd is managed it should wait for the final snapshot before scaling down",
+			etcdManagement: hyperv1.Managed,
+			hibernate:      true,
+			objects: []client.Object{
+				deployment("kube-apiserver", 3),
+				statefulSet("etcd", 3),
+			},
+			expectedHibernated: true,
+			expectedReason:     hyperv1.HibernationInProgressReason,
+			expectedReplicas:   map[string]int32{"kube-apiserver": 3, "etcd": 3},
+			expectJob:          true,
+		},
+		{
+			name:           "When components are running it should scale them down before the kube-apiserver",
+			etcdManagement: hyperv1.Unmanaged,
+			hibernate:      true,
+			objects: []client.Object{
+				deployment("control-plane-operator", 1),
+				deployment("openshift-apiserver", 2),
+				deployment("kube-apiserver", 3),
+			},
+			expectedHibernated: true,
+			expectedReason:     hyperv1.HibernationInProgressReason,
+			expectedReplicas:   map[string]int32{"control-plane-operator": 1, "openshift-apiserver": 0, "kube-apiserver": 3},
+		},
+		{
+			name:           "When everything is scaled down it should report the control plane as hibernated",
+			etcdManagement: hyperv1.Unmanaged,
+			hibernate:      true,
+			objects: []client.Object{
+				deployment("control-plane-operator", 1),
+				deployment("openshift-apiserver", 0),
+				deployment("kube-apiserver", 0),
+			},
+			expectedHibernated: true,
+			expectedReason:     hyperv1.HibernatedReason,
+			expectedReplicas:   map[string]int32{"control-plane-operator": 1},
+		},
+		{
+			name:              "When resuming it should wait for the control plane to become ready",
+			etcdManagement:    hyperv1.Unmanaged,
+			existingCondition: &metav1.Condition{Type: string(hyperv1.Hibernating), Status: metav1.ConditionTrue, Reason: hyperv1.HibernatedReason},
+			objects: []client.Object{
+				func() client.Object {
+					d := deployment("cluster-api", 0)
+					d.Annotations = map[string]string{hibernationReplicasAnnotation: "1"}
+					return d
+				}(),
+			},
+			expectedReason:   hyperv1.ResumingReason,
+			expectedReplicas: map[string]int32{"cluster-api": 0},
+		},
+		{
+			name:              "When the control plane is ready it should restore the remaining workloads",
+			etcdManagement:    hyperv1.Unmanaged,
+			ready:             true,
+			existingCondition: &metav1.Condition{Type: string(hyperv1.Hibernating), Status: metav1.ConditionTrue, Reason: hyperv1.ResumingReason},
+			objects: []client.Object{
+				func() client.Object {
+					d := deployment("cluster-api", 0)
+					d.Annotations = map[string]string{hibernationReplicasAnnotation: "1"}
+					return d
+				}(),
+				func() client.Object {
+					d := deployment("kube-apiserver", 3)
+					d.Annotations = map[string]string{hibernationReplicasAnnotation: "3"}
+					return d
+				}(),
+			},
+			expectedReason:   hyperv1.AsExpectedReason,
+			expectedReplicas: map[string]int32{"cluster-api": 1, "kube-apiserver": 3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			hcp := &hyperv1.HostedControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "hc"},
+				Spec: hyperv1.HostedControlPlaneSpec{
+					Etcd: hyperv1.EtcdSpec{ManagementType: tc.etcdManagement},
+					Networking: hyperv1.ClusterNetworking{
+						ClusterNetwork: []hyperv1.ClusterNetworkEntry{{CIDR: *ipnet.MustParseCIDR("10.132.0.0/14")}},
+					},
+				},
+				Status: hyperv1.HostedControlPlaneStatus{Ready: tc.ready},
+			}
+			if tc.hibernate {
+				hcp.Annotations = map[string]string{hyperv1.HibernateAnnotation: "true"}
+			}
+			if tc.existingCondition != nil {
+				hcp.Status.Conditions = []metav1.Condition{*tc.existingCondition}
+			}
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).
+				WithObjects(append(tc.objects, hcp)...).
+				WithStatusSubresource(&hyperv1.HostedControlPlane{}).
+				Build()
+			r := &HostedControlPlaneReconciler{
+				Client: c,
+				Log:    ctrl.LoggerFrom(ctx),
+			}
+			r.setup(controllerutil.CreateOrUpdate)
+
+			hibernated, _, err := r.reconcileHibernation(ctx, hcp, releaseImage)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(hibernated).To(Equal(tc.expectedHibernated))
+
+			updated := &hyperv1.HostedControlPlane{}
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(hcp), updated)).To(Succeed())
+			condition := meta.FindStatusCondition(updated.Status.Conditions, string(hyperv1.Hibernating))
+			if tc.expectedReason == "" {
+				g.Expect(condition).To(BeNil())
+			} else {
+				g.Expect(condition).ToNot(BeNil())
+				g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+			}
+
+			for name, replicas := range tc.expectedReplicas {
+				var obj client.Object = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+				if name == "etcd" {
+					obj = &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+				}
+				g.Expect(replicasOf(g, c, obj)).To(Equal(replicas), name)
+			}
+
+			job := manifests.EtcdHibernationSnapshotJob(namespace)
+			err = c.Get(ctx, client.ObjectKeyFromObject(job), job)
+			g.Expect(err == nil).To(Equal(tc.expectJob))
+			if tc.expectJob {
+				g.Expect(c.Get(ctx, client.ObjectKeyFromObject(manifests.EtcdHibernationSnapshotPVC(namespace)), &corev1.PersistentVolumeClaim{})).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestReconcileHibernationEtcdSnapshot(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	const namespace = "clusters-hc"
+
+	hcp := &hyperv1.HostedControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "hc", Annotations: map[string]string{hyperv1.HibernateAnnotation: "true"}},
+		Spec: hyperv1.HostedControlPlaneSpec{
+			Etcd: hyperv1.EtcdSpec{ManagementType: hyperv1.Managed},
+			Networking: hyperv1.ClusterNetworking{
+				ClusterNetwork: []hyperv1.ClusterNetworkEntry{{CIDR: *ipnet.MustParseCIDR("10.132.0.0/14")}},
+			},
+		},
+	}
+	etcd := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "etcd"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To[int32](3)},
+		Status:     appsv1.StatefulSetStatus{Replicas: 3},
+	}
+	job := manifests.EtcdHibernationSnapshotJob(namespace)
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).
+		WithObjects(hcp, etcd, job).
+		WithStatusSubresource(&hyperv1.HostedControlPlane{}).
+		Build()
+	r := &HostedControlPlaneReconciler{
+		Client: c,
+		Log:    ctrl.LoggerFrom(ctx),
+	}
+	r.setup(controllerutil.CreateOrUpdate)
+
+	// Once the snapshot completed etcd is scaled down, keeping the snapshot job until the resume.
+	_, _, err := r.reconcileHibernation(ctx, hcp, &releaseinfo.ReleaseImage{ImageStream: &imagev1.ImageStream{}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(etcd), etcd)).To(Succeed())
+	g.Expect(*etcd.Spec.Replicas).To(BeZero())
+	g.Expect(etcd.Annotations).To(HaveKeyWithValue(hibernationReplicasAnnotation, "3"))
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(job), job)).To(Succeed())
+}
@@ -745,6 +745,14 @@ func (r *HostedControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 	}
 
+	// Scale the control plane down while hibernated
+	if hibernated, result, err := r.reconcileHibernation(ctx, hostedControlPlane, releaseImage); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile hibernation: %w", err)
+	} else if hibernated {
+		r.Log.Info("Control plane is hibernated")
+		return result, nil
+	}
+
 	// Perform the hosted control plane reconciliation
 	result, err := r.update(ctx, hostedControlPlane, releaseImage)
 	if err != nil {
@@ -2822,6 +2830,10 @@ func (r *HostedControlPlaneReconciler) reconcileKubeAPIServer(ctx context.Contex
 	if err := r.Get(ctx, client.ObjectKeyFromObject(bootstrapClientCertSecret), bootstrapClientCertSecret); err != nil {
 		return fmt.Errorf("failed to get bootstrap client cert secret: %w", err)
 	}
+	serviceAccountSigningKeySecret := manifests.ServiceAccountSigningKeySecret(hcp.Namespace)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(serviceAccountSigningKeySecret), serviceAccountSigningKeySecret); err != nil {
+		return fmt.Errorf("failed to get service account signing key secret: %w", err)
+	}
 
 	serviceKubeconfigSecret := manifests.KASServiceKubeconfigSecret(hcp.Namespace)
 	if _, err := createOrUpdate(ctx, r, serviceKubeconfigSecret, func() error {
@@ -3061,6 +3073,7 @@ func (r *HostedControlPlaneReconciler) reconcileKubeAPIServer(ctx context.Contex
 			kubeAPIServerConfig,
 			kubeAPIServerAuditConfig,
 			kubeAPIServerAuthConfig,
+			serviceAccountSigningKeySecret,
 			p.AuditWebhookRef,
 			aesCBCActiveKey,
 			aesCBCBackupKey,
@@ -3101,6 +3114,11 @@ func (r *HostedControlPlaneReconciler) reconcileKubeControllerManager(ctx contex
 		}
 	}
 
+	serviceAccountSigningKeySecret := manifests.ServiceAccountSigningKeySecret(hcp.Namespace)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(serviceAccountSigningKeySecret), serviceAccountSigningKeySecret); err != nil {
+		return fmt.Errorf("failed to get service account signing key secret: %w", err)
+	}
+
 	recyclerConfig := manifests.RecyclerConfigMap(hcp.Namespace)
 	if _, err := createOrUpdate(ctx, r, recyclerConfig, func() error {
 		return kcm.ReconcileRecyclerConfig(recyclerConfig, p.OwnerRef, releaseImageProvider)
@@ -3143,7 +3161,7 @@ func (r *HostedControlPlaneReconciler) reconcileKubeControllerManager(ctx contex
 	}
 
 	if _, err := createOrUpdate(ctx, r, kcmDeployment, func() error {
-		return kcm.ReconcileDeployment(kcmDeployment, kcmConfig, rootCAConfigMap, serviceServingCA, p, hcp.Spec.Platform.Type)
+		return kcm.ReconcileDeployment(kcmDeployment, kcmConfig, rootCAConfigMap, serviceServingCA, serviceAccountSigningKeySecret, p, hcp.Spec.Platform.Type)
 	}); err != nil {
 		return fmt.Errorf("failed to reconcile kcm deployment: %w", err)
 	}
@@ -4762,11 +4780,16 @@ func healthCheckIdentityProvider(ctx context.Context, hcp *hyperv1.HostedControl
 				return
 			}
 
+			message := awsErr.Code()
+			// Point at the missing permission when the role of the control-plane-operator doesn't allow the call.
+			if awsErr.Code() == "UnauthorizedOperation" || awsErr.Code() == "AccessDenied" {
+				message = fmt.Sprintf("%s: the control plane operator role is missing the ec2:DescribeVpcEndpoints permission, its policies can be checked with `hypershift create iam aws --validate-roles-file`", awsErr.Code())
+			}
 			condition := metav1.Condition{
 				Type:               string(hyperv1.ValidAWSIdentityProvider),
 				ObservedGeneration: hcp.Generation,
 				Status:             metav1.ConditionUnknown,
-				Message:            awsErr.Code(),
+				Message:            message,
 				Reason:             hyperv1.AWSErrorReason,
 			}
 			meta.SetStatusCondition(&hcp.Status.Conditions, condition)
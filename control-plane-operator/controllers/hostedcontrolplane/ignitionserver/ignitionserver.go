@@ -27,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilpointer "k8s.io/utils/pointer"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -153,7 +154,7 @@ func ReconcileIgnitionServer(ctx context.Context,
 
 	role := ignitionserver.Role(controlPlaneNamespace)
 	if result, err := createOrUpdate(ctx, c, role, func() error {
-		return reconcileRole(role)
+		return reconcileRole(role, tokenBindingEnabled(hcp))
 	}); err != nil {
 		return fmt.Errorf("failed to reconcile ignition server role: %w", err)
 	} else {
@@ -398,7 +399,12 @@ func reconcileServingCertSecret(servingCertSecret *corev1.Secret, caCertSecret *
 	)
 }
 
-func reconcileRole(role *rbacv1.Role) error {
+// tokenBindingEnabled returns true if the ignition server should bind payload requests to Machine enrollments.
+func tokenBindingEnabled(hcp *hyperv1.HostedControlPlane) bool {
+	return hcp.Annotations[hyperv1.IgnitionServerTokenBindingAnnotation] == "true"
+}
+
+func reconcileRole(role *rbacv1.Role, tokenBinding bool) error {
 	role.Rules = []rbacv1.PolicyRule{
 		{
 			APIGroups: []string{""},
@@ -440,6 +446,23 @@ func reconcileRole(role *rbacv1.Role) error {
 			Verbs: []string{"*"},
 		},
 	}
+	if tokenBinding {
+		// Machines are read to mint enrollments and patched to point at their own
+		// user data Secret, which is created for them, and to record the claims.
+		role.Rules = append(role.Rules, rbacv1.PolicyRule{
+			APIGroups: []string{capiv1.GroupVersion.Group},
+			Resources: []string{
+				"machines",
+			},
+			Verbs: []string{"get", "list", "watch", "patch"},
+		}, rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{
+				"secrets",
+			},
+			Verbs: []string{"create"},
+		})
+	}
 	return nil
 }
 
@@ -712,6 +735,15 @@ func reconcileDeployment(deployment *appsv1.Deployment,
 		deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "MIRRORED_RELEASE_IMAGE", Value: mirroredReleaseImage})
 	}
 
+	if tokenBindingEnabled(hcp) {
+		deployment.Spec.Template.Spec.Containers[0].Command = append(deployment.Spec.Template.Spec.Containers[0].Command, "--token-binding")
+	}
+
+	if hcp.Spec.Platform.Type != hyperv1.IBMCloudPlatform {
+		// Requests go through the ignition server proxy, which records the client address in X-Forwarded-For.
+		deployment.Spec.Template.Spec.Containers[0].Command = append(deployment.Spec.Template.Spec.Containers[0].Command, "--trust-forwarded-for")
+	}
+
 	if hcp.Spec.AdditionalTrustBundle != nil {
 		// Add trusted-ca mount with optional configmap
 		util.DeploymentAddTrustBundleVolume(hcp.Spec.AdditionalTrustBundle, deployment)
@@ -751,6 +783,7 @@ cat /etc/ssl/serving-cert/tls.crt /etc/ssl/serving-cert/tls.key > /tmp/tls.pem
 cat <<EOF > /tmp/haproxy.conf
 defaults
   mode http
+  option forwardfor
   timeout connect 5s
   timeout client 30s
   timeout server 30s
@@ -32,6 +32,7 @@ const (
 	authConfigHashAnnotation                   = "kube-apiserver.hypershift.openshift.io/auth-config-hash"
 	auditConfigHashAnnotation                  = "kube-apiserver.hypershift.openshift.io/audit-config-hash"
 	configHashAnnotation                       = "kube-apiserver.hypershift.openshift.io/config-hash"
+	serviceAccountSigningKeyHashAnnotation     = "kube-apiserver.hypershift.openshift.io/service-account-signing-key-hash"
 	awsPodIdentityWebhookServingCertVolumeName = "aws-pod-identity-webhook-serving-certs"
 	awsPodIdentityWebhookKubeconfigVolumeName  = "aws-pod-identity-webhook-kubeconfig"
 )
@@ -110,6 +111,7 @@ func ReconcileKubeAPIServerDeployment(deployment *appsv1.Deployment,
 	config *corev1.ConfigMap,
 	auditConfig *corev1.ConfigMap,
 	authConfig *corev1.ConfigMap,
+	serviceAccountSigningKey *corev1.Secret,
 	auditWebhookRef *corev1.LocalObjectReference,
 	aesCBCActiveKey []byte,
 	aesCBCBackupKey []byte,
@@ -186,6 +188,8 @@ func ReconcileKubeAPIServerDeployment(deployment *appsv1.Deployment,
 				configHashAnnotation:      configHash,
 				auditConfigHashAnnotation: auditConfigHash,
 				authConfigHashAnnotation:  authConfigHash,
+				// The public keys change during a service account signing key rotation
+				serviceAccountSigningKeyHashAnnotation: util.HashSimple(serviceAccountSigningKey.Data),
 			},
 		},
 		Spec: corev1.PodSpec{
@@ -459,19 +463,7 @@ func buildKASContainerMain(image string, port int32, noProxyCIDRs []string, hcp
 		// https://github.com/kubernetes/kubernetes/blob/ab13c85316015cf9f115e29923ba9740bd1564fd/staging/src/k8s.io/apimachinery/pkg/util/net/http.go#L112-L114
 		proxy.SetEnvVars(&c.Env, noProxyCIDRs...)
 
-		if hcp.Annotations[hyperv1.KubeAPIServerGOGCAnnotation] != "" {
-			c.Env = append(c.Env, corev1.EnvVar{
-				Name:  "GOGC",
-				Value: hcp.Annotations[hyperv1.KubeAPIServerGOGCAnnotation],
-			})
-		}
-
-		if hcp.Annotations[hyperv1.KubeAPIServerGOMemoryLimitAnnotation] != "" {
-			c.Env = append(c.Env, corev1.EnvVar{
-				Name:  "GOMEMLIMIT",
-				Value: hcp.Annotations[hyperv1.KubeAPIServerGOMemoryLimitAnnotation],
-			})
-		}
+		// The GOGC and GOMEMLIMIT annotations are applied with the component overrides of the DeploymentConfig.
 
 		c.WorkingDir = volumeMounts.Path(c.Name, kasVolumeWorkLogs().Name)
 		c.VolumeMounts = volumeMounts.ContainerMounts(c.Name)
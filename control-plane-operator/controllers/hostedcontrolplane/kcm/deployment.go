@@ -25,10 +25,11 @@ import (
 )
 
 const (
-	AWSCloudProviderCredsKey = "credentials"
-	configHashAnnotation     = "kube-controller-manager.hypershift.openshift.io/config-hash"
-	serviceCAHashAnnotation  = "kube-controller-manager.hypershift.openshift.io/service-ca-hash"
-	rootCAHashAnnotation     = "kube-controller-manager.hypershift.openshift.io/root-ca-hash"
+	AWSCloudProviderCredsKey    = "credentials"
+	configHashAnnotation        = "kube-controller-manager.hypershift.openshift.io/config-hash"
+	serviceCAHashAnnotation     = "kube-controller-manager.hypershift.openshift.io/service-ca-hash"
+	rootCAHashAnnotation        = "kube-controller-manager.hypershift.openshift.io/root-ca-hash"
+	serviceSignerHashAnnotation = "kube-controller-manager.hypershift.openshift.io/service-signer-hash"
 )
 
 var (
@@ -64,7 +65,7 @@ func kcmLabels() map[string]string {
 	}
 }
 
-func ReconcileDeployment(deployment *appsv1.Deployment, config, rootCA, serviceServingCA *corev1.ConfigMap, p *KubeControllerManagerParams, platformType hyperv1.PlatformType) error {
+func ReconcileDeployment(deployment *appsv1.Deployment, config, rootCA, serviceServingCA *corev1.ConfigMap, serviceSigner *corev1.Secret, p *KubeControllerManagerParams, platformType hyperv1.PlatformType) error {
 	// preserve existing resource requirements for main KCM container
 	mainContainer := util.FindContainer(kcmContainerMain().Name, deployment.Spec.Template.Spec.Containers)
 	if mainContainer != nil {
@@ -100,6 +101,7 @@ func ReconcileDeployment(deployment *appsv1.Deployment, config, rootCA, serviceS
 	}
 	deployment.Spec.Template.ObjectMeta.Annotations[configHashAnnotation] = util.ComputeHash(configBytes)
 	deployment.Spec.Template.ObjectMeta.Annotations[rootCAHashAnnotation] = util.HashSimple(rootCA.Data)
+	deployment.Spec.Template.ObjectMeta.Annotations[serviceSignerHashAnnotation] = util.HashSimple(serviceSigner.Data)
 
 	deployment.Spec.Template.Spec = corev1.PodSpec{
 		AutomountServiceAccountToken: pointer.Bool(false),
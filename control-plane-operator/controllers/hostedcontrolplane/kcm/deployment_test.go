@@ -103,7 +103,7 @@ func TestKubeControllerManagerDeploymentNoChanges(t *testing.T) {
 		rootCAConfigMap := manifests.RootCAConfigMap(hcp.Namespace)
 		serviceServingCA := manifests.ServiceServingCA(hcp.Namespace)
 
-		err := ReconcileDeployment(kcmDeployment, &tc.cm, rootCAConfigMap, serviceServingCA, &tc.params, hyperv1.IBMCloudPlatform)
+		err := ReconcileDeployment(kcmDeployment, &tc.cm, rootCAConfigMap, serviceServingCA, manifests.ServiceAccountSigningKeySecret(hcp.Namespace), &tc.params, hyperv1.IBMCloudPlatform)
 		g.Expect(err).To(BeNil())
 		g.Expect(expectedMinReadySeconds).To(Equal(kcmDeployment.Spec.MinReadySeconds))
 	}
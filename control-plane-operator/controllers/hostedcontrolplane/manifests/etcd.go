@@ -104,3 +104,21 @@ func EtcdBackupCronJob(hcpNamespace string) *batchv1.CronJob {
 		},
 	}
 }
+
+func EtcdHibernationSnapshotPVC(hcpNamespace string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "etcd-hibernation-snapshot",
+			Namespace: hcpNamespace,
+		},
+	}
+}
+
+func EtcdHibernationSnapshotJob(hcpNamespace string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "etcd-hibernation-snapshot",
+			Namespace: hcpNamespace,
+		},
+	}
+}
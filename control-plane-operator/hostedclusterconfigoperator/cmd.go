@@ -283,26 +283,27 @@ func (o *HostedClusterConfigOperator) Run(ctx context.Context) error {
 			APIClient: apiReadingClient,
 		},
 
-		Config:                cpConfig,
-		TargetConfig:          cfg,
-		KubevirtInfraConfig:   kubevirtInfraConfig,
-		Manager:               mgr,
-		Namespace:             o.Namespace,
-		HCPName:               o.HostedControlPlaneName,
-		InitialCA:             string(o.initialCA),
-		ClusterSignerCA:       string(o.clusterSignerCA),
-		ControllerFuncs:       controllersToRun,
-		Versions:              versions,
-		PlatformType:          hyperv1.PlatformType(o.platformType),
-		CPCluster:             cpCluster,
-		Logger:                ctrl.Log.WithName("hypershift-operator"),
-		ReleaseProvider:       releaseProvider,
-		KonnectivityAddress:   o.KonnectivityAddress,
-		KonnectivityPort:      o.KonnectivityPort,
-		OAuthAddress:          o.OAuthAddress,
-		OAuthPort:             o.OAuthPort,
-		OperateOnReleaseImage: os.Getenv("OPERATE_ON_RELEASE_IMAGE"),
-		EnableCIDebugOutput:   o.enableCIDebugOutput,
+		Config:                    cpConfig,
+		TargetConfig:              cfg,
+		KubevirtInfraConfig:       kubevirtInfraConfig,
+		Manager:                   mgr,
+		Namespace:                 o.Namespace,
+		HCPName:                   o.HostedControlPlaneName,
+		InitialCA:                 string(o.initialCA),
+		ClusterSignerCA:           string(o.clusterSignerCA),
+		ControllerFuncs:           controllersToRun,
+		Versions:                  versions,
+		PlatformType:              hyperv1.PlatformType(o.platformType),
+		CPCluster:                 cpCluster,
+		Logger:                    ctrl.Log.WithName("hypershift-operator"),
+		ReleaseProvider:           releaseProvider,
+		KonnectivityAddress:       o.KonnectivityAddress,
+		KonnectivityPort:          o.KonnectivityPort,
+		OAuthAddress:              o.OAuthAddress,
+		OAuthPort:                 o.OAuthPort,
+		OperateOnReleaseImage:     os.Getenv("OPERATE_ON_RELEASE_IMAGE"),
+		ControlPlaneOperatorImage: os.Getenv("CONTROL_PLANE_OPERATOR_IMAGE"),
+		EnableCIDebugOutput:       o.enableCIDebugOutput,
 	}
 	configmetrics.Register(mgr.GetCache())
 	return operatorConfig.Start(ctx)
@@ -0,0 +1,133 @@
+package hcpstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/manifests"
+	guestprober "github.com/openshift/hypershift/guest-prober"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// guestProbesInterval is how often the guest probe results are read back.
+	guestProbesInterval = time.Minute
+	// guestProbesStaleAfter is the age after which a probe result is not trusted anymore,
+	// e.g. because the prober is not running.
+	guestProbesStaleAfter = 5 * time.Minute
+)
+
+var guestProbeConditions = map[string]hyperv1.ConditionType{
+	guestprober.ProbeDNS:            hyperv1.GuestDNSAvailable,
+	guestprober.ProbeServiceNetwork: hyperv1.GuestServiceNetworkAvailable,
+	guestprober.ProbeIngress:        hyperv1.GuestIngressAvailable,
+	guestprober.ProbeKonnectivity:   hyperv1.GuestKonnectivityAvailable,
+}
+
+// reconcileGuestProbes reports the results written by the guest prober as conditions of the HostedControlPlane.
+// It returns whether the probes are enabled and the results need to be read again later.
+func (h *hcpStatusReconciler) reconcileGuestProbes(ctx context.Context, hcp *hyperv1.HostedControlPlane, now time.Time) bool {
+	if hcp.Annotations[hyperv1.GuestProbesAnnotation] != "true" {
+		for _, conditionType := range guestProbeConditions {
+			meta.RemoveStatusCondition(&hcp.Status.Conditions, string(conditionType))
+		}
+		return false
+	}
+
+	results := map[string]guestprober.Result{}
+	var unknownMessage string
+	configMap := manifests.GuestProberResultsConfigMap()
+	if err := h.hostedClusterClient.Get(ctx, crclient.ObjectKeyFromObject(configMap), configMap); err != nil {
+		unknownMessage = fmt.Sprintf("failed to get guest probe results: %v", err)
+	} else {
+		results, unknownMessage = parseGuestProbeResults(configMap)
+	}
+	// The prober runs the control-plane-operator image in the guest cluster, pulled with the pull secret of the
+	// guest cluster. When results are missing or stale because that pull fails, the pull failure is reported instead.
+	var pullFailure *string
+	imagePullFailure := func() string {
+		if pullFailure == nil {
+			failure := h.guestProberImagePullFailure(ctx)
+			pullFailure = &failure
+		}
+		return *pullFailure
+	}
+
+	for probe, conditionType := range guestProbeConditions {
+		condition := metav1.Condition{
+			Type:               string(conditionType),
+			Status:             metav1.ConditionUnknown,
+			Reason:             hyperv1.StatusUnknownReason,
+			Message:            unknownMessage,
+			ObservedGeneration: hcp.Generation,
+		}
+		result, found := results[probe]
+		switch {
+		case unknownMessage != "":
+		case !found:
+			condition.Message = fmt.Sprintf("No result reported for the %s probe.", probe)
+		case now.Sub(result.Time.Time) > guestProbesStaleAfter:
+			condition.Message = fmt.Sprintf("The last result of the %s probe is from %s.", probe, result.Time.UTC().Format(time.RFC3339))
+		case result.Success:
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = hyperv1.AsExpectedReason
+			condition.Message = hyperv1.AllIsWellMessage
+		default:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = hyperv1.GuestProbeFailedReason
+			condition.Message = result.Message
+		}
+		if condition.Status == metav1.ConditionUnknown {
+			if failure := imagePullFailure(); failure != "" {
+				condition.Message = failure
+			}
+		}
+		meta.SetStatusCondition(&hcp.Status.Conditions, condition)
+	}
+	return true
+}
+
+// guestProberImagePullFailure returns why the guest prober pods cannot pull their image, if they cannot.
+func (h *hcpStatusReconciler) guestProberImagePullFailure(ctx context.Context) string {
+	if h.hostedClusterReader == nil {
+		return ""
+	}
+	pods := &corev1.PodList{}
+	if err := h.hostedClusterReader.List(ctx, pods, crclient.InNamespace(manifests.GuestProberNamespace().Name), crclient.MatchingLabels(manifests.GuestProberPodLabels())); err != nil {
+		return ""
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting == nil {
+				continue
+			}
+			switch status.State.Waiting.Reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+				return fmt.Sprintf("The guest prober cannot pull its image %s: %s. The pull secret of the guest cluster must grant access to the control-plane-operator image.", status.Image, status.State.Waiting.Message)
+			}
+		}
+	}
+	return ""
+}
+
+func parseGuestProbeResults(configMap *corev1.ConfigMap) (map[string]guestprober.Result, string) {
+	data, ok := configMap.Data[guestprober.ResultsKey]
+	if !ok {
+		return nil, "The guest prober has not reported results yet."
+	}
+	var results []guestprober.Result
+	if err := json.Unmarshal([]byte(data), &results); err != nil {
+		return nil, fmt.Sprintf("failed to parse guest probe results: %v", err)
+	}
+	resultsByProbe := make(map[string]guestprober.Result, len(results))
+	for _, result := range results {
+		resultsByProbe[result.Probe] = result
+	}
+	return resultsByProbe, ""
+}
@@ -0,0 +1,164 @@
+package hcpstatus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/manifests"
+	guestprober "github.com/openshift/hypershift/guest-prober"
+	"github.com/openshift/hypershift/support/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileGuestProbes(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	resultsConfigMap := func(results ...guestprober.Result) crclient.Object {
+		configMap := manifests.GuestProberResultsConfigMap()
+		data, err := json.Marshal(results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configMap.Data = map[string]string{guestprober.ResultsKey: string(data)}
+		return configMap
+	}
+	recent := metav1.NewTime(now.Add(-time.Minute))
+	pullFailingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: manifests.GuestProberNamespace().Name,
+			Name:      "guest-prober-abc",
+			Labels:    manifests.GuestProberPodLabels(),
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "guest-prober",
+				Image: "quay.io/hypershift/control-plane-operator:latest",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+					Reason:  "ImagePullBackOff",
+					Message: "unauthorized",
+				}},
+			}},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		enabled            bool
+		existingConditions []metav1.Condition
+		objects            []crclient.Object
+		expectedRequeue    bool
+		expectedConditions map[hyperv1.ConditionType]metav1.ConditionStatus
+		expectedReasons    map[hyperv1.ConditionType]string
+		expectedMessages   map[hyperv1.ConditionType]string
+	}{
+		{
+			name:               "When the probes are disabled it should remove the conditions",
+			existingConditions: []metav1.Condition{{Type: string(hyperv1.GuestDNSAvailable), Status: metav1.ConditionTrue}},
+			expectedConditions: map[hyperv1.ConditionType]metav1.ConditionStatus{},
+		},
+		{
+			name:            "When the results are not created yet it should report unknown conditions",
+			enabled:         true,
+			expectedRequeue: true,
+			expectedConditions: map[hyperv1.ConditionType]metav1.ConditionStatus{
+				hyperv1.GuestDNSAvailable:            metav1.ConditionUnknown,
+				hyperv1.GuestServiceNetworkAvailable: metav1.ConditionUnknown,
+				hyperv1.GuestIngressAvailable:        metav1.ConditionUnknown,
+				hyperv1.GuestKonnectivityAvailable:   metav1.ConditionUnknown,
+			},
+		},
+		{
+			name:    "When probes succeed and fail it should report them",
+			enabled: true,
+			objects: []crclient.Object{resultsConfigMap(
+				guestprober.Result{Probe: guestprober.ProbeDNS, Success: true, Time: recent},
+				guestprober.Result{Probe: guestprober.ProbeServiceNetwork, Success: true, Time: recent},
+				guestprober.Result{Probe: guestprober.ProbeIngress, Success: false, Message: "request to https://canary failed", Time: recent},
+				guestprober.Result{Probe: guestprober.ProbeKonnectivity, Success: true, Time: metav1.NewTime(now.Add(-time.Hour))},
+			)},
+			expectedRequeue: true,
+			expectedConditions: map[hyperv1.ConditionType]metav1.ConditionStatus{
+				hyperv1.GuestDNSAvailable:            metav1.ConditionTrue,
+				hyperv1.GuestServiceNetworkAvailable: metav1.ConditionTrue,
+				hyperv1.GuestIngressAvailable:        metav1.ConditionFalse,
+				hyperv1.GuestKonnectivityAvailable:   metav1.ConditionUnknown,
+			},
+			expectedReasons: map[hyperv1.ConditionType]string{
+				hyperv1.GuestDNSAvailable:          hyperv1.AsExpectedReason,
+				hyperv1.GuestIngressAvailable:      hyperv1.GuestProbeFailedReason,
+				hyperv1.GuestKonnectivityAvailable: hyperv1.StatusUnknownReason,
+			},
+		},
+		{
+			name:            "When the prober cannot pull its image it should report the pull failure in the unknown conditions",
+			enabled:         true,
+			objects:         []crclient.Object{pullFailingPod},
+			expectedRequeue: true,
+			expectedConditions: map[hyperv1.ConditionType]metav1.ConditionStatus{
+				hyperv1.GuestDNSAvailable:            metav1.ConditionUnknown,
+				hyperv1.GuestServiceNetworkAvailable: metav1.ConditionUnknown,
+				hyperv1.GuestIngressAvailable:        metav1.ConditionUnknown,
+				hyperv1.GuestKonnectivityAvailable:   metav1.ConditionUnknown,
+			},
+			expectedMessages: map[hyperv1.ConditionType]string{
+				hyperv1.GuestDNSAvailable: "The guest prober cannot pull its image quay.io/hypershift/control-plane-operator:latest: unauthorized.",
+			},
+		},
+		{
+			name:    "When a new prober pod cannot pull its image but results are recent it should report the results",
+			enabled: true,
+			objects: []crclient.Object{pullFailingPod, resultsConfigMap(
+				guestprober.Result{Probe: guestprober.ProbeDNS, Success: true, Time: recent},
+				guestprober.Result{Probe: guestprober.ProbeServiceNetwork, Success: true, Time: recent},
+				guestprober.Result{Probe: guestprober.ProbeIngress, Success: true, Time: recent},
+				guestprober.Result{Probe: guestprober.ProbeKonnectivity, Success: true, Time: recent},
+			)},
+			expectedRequeue: true,
+			expectedConditions: map[hyperv1.ConditionType]metav1.ConditionStatus{
+				hyperv1.GuestDNSAvailable:            metav1.ConditionTrue,
+				hyperv1.GuestServiceNetworkAvailable: metav1.ConditionTrue,
+				hyperv1.GuestIngressAvailable:        metav1.ConditionTrue,
+				hyperv1.GuestKonnectivityAvailable:   metav1.ConditionTrue,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			hcp := &hyperv1.HostedControlPlane{
+				Status: hyperv1.HostedControlPlaneStatus{Conditions: tc.existingConditions},
+			}
+			if tc.enabled {
+				hcp.Annotations = map[string]string{hyperv1.GuestProbesAnnotation: "true"}
+			}
+			client := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.objects...).Build()
+			h := &hcpStatusReconciler{
+				hostedClusterClient: client,
+				hostedClusterReader: client,
+			}
+
+			requeue := h.reconcileGuestProbes(context.Background(), hcp, now)
+			g.Expect(requeue).To(Equal(tc.expectedRequeue))
+
+			g.Expect(hcp.Status.Conditions).To(HaveLen(len(tc.expectedConditions)))
+			for conditionType, status := range tc.expectedConditions {
+				condition := meta.FindStatusCondition(hcp.Status.Conditions, string(conditionType))
+				g.Expect(condition).ToNot(BeNil(), string(conditionType))
+				g.Expect(condition.Status).To(Equal(status), string(conditionType))
+				if reason, ok := tc.expectedReasons[conditionType]; ok {
+					g.Expect(condition.Reason).To(Equal(reason), string(conditionType))
+				}
+				if message, ok := tc.expectedMessages[conditionType]; ok {
+					g.Expect(condition.Message).To(HavePrefix(message), string(conditionType))
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
 	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
@@ -26,6 +27,7 @@ func Setup(ctx context.Context, opts *operator.HostedClusterConfigOperatorConfig
 	r := &hcpStatusReconciler{
 		mgtClusterClient:    opts.CPCluster.GetClient(),
 		hostedClusterClient: opts.Manager.GetClient(),
+		hostedClusterReader: opts.Manager.GetAPIReader(),
 		releaseProvider:     opts.ReleaseProvider,
 	}
 	c, err := controller.New(ControllerName, opts.Manager, controller.Options{Reconciler: r})
@@ -49,6 +51,8 @@ func Setup(ctx context.Context, opts *operator.HostedClusterConfigOperatorConfig
 type hcpStatusReconciler struct {
 	mgtClusterClient    crclient.Client
 	hostedClusterClient crclient.Client
+	// hostedClusterReader reads guest cluster objects the cache of the manager does not hold, e.g. pods.
+	hostedClusterReader crclient.Reader
 	releaseProvider     releaseinfo.Provider
 }
 
@@ -61,6 +65,10 @@ func (h *hcpStatusReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 	if err := h.reconcile(ctx, hcp); err != nil {
 		return reconcile.Result{}, err
 	}
+	var result reconcile.Result
+	if h.reconcileGuestProbes(ctx, hcp, time.Now()) {
+		result.RequeueAfter = guestProbesInterval
+	}
 
 	if !reflect.DeepEqual(hcp.Status, originalHCP.Status) {
 		if err := h.mgtClusterClient.Status().Update(ctx, hcp); err != nil {
@@ -68,7 +76,7 @@ func (h *hcpStatusReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 		}
 	}
 
-	return reconcile.Result{}, nil
+	return result, nil
 }
 
 // findClusterOperatorStatusCondition is identical to meta.FindStatusCondition except that it works on config1.ClusterOperatorStatusCondition instead of
@@ -0,0 +1,224 @@
+package guestprober
+
+import (
+	"fmt"
+
+	prometheusoperatorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/manifests"
+	guestprober "github.com/openshift/hypershift/guest-prober"
+)
+
+const (
+	containerName = "guest-prober"
+	portName      = "http"
+)
+
+var selectorLabels = manifests.GuestProberPodLabels()
+
+func ReconcileNamespace(ns *corev1.Namespace) {
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	ns.Labels["openshift.io/cluster-monitoring"] = "true"
+	ns.Labels["security.openshift.io/scc.podSecurityLabelSync"] = "false"
+	ns.Labels["pod-security.kubernetes.io/enforce"] = "restricted"
+	ns.Labels["pod-security.kubernetes.io/audit"] = "restricted"
+	ns.Labels["pod-security.kubernetes.io/warn"] = "restricted"
+}
+
+func ReconcileRole(role *rbacv1.Role) {
+	role.Rules = []rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{manifests.GuestProberResultsConfigMap().Name},
+			Verbs:         []string{"get", "update"},
+		},
+		{
+			// The konnectivity probe requests the prober pod through the kube-apiserver
+			APIGroups: []string{""},
+			Resources: []string{"pods/proxy"},
+			Verbs:     []string{"get"},
+		},
+	}
+}
+
+func ReconcileIngressCanaryRole(role *rbacv1.Role) {
+	role.Rules = []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{"route.openshift.io"},
+			Resources: []string{"routes"},
+			Verbs:     []string{"get"},
+		},
+	}
+}
+
+func ReconcilePrometheusRole(role *rbacv1.Role) {
+	role.Rules = []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"services", "endpoints", "pods"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+	}
+}
+
+func ReconcileRoleBinding(binding *rbacv1.RoleBinding) {
+	reconcileServiceAccountRoleBinding(binding, manifests.GuestProberRole().Name)
+}
+
+func ReconcileIngressCanaryRoleBinding(binding *rbacv1.RoleBinding) {
+	reconcileServiceAccountRoleBinding(binding, manifests.GuestProberIngressCanaryRole().Name)
+}
+
+func reconcileServiceAccountRoleBinding(binding *rbacv1.RoleBinding, roleName string) {
+	serviceAccount := manifests.GuestProberServiceAccount()
+	binding.RoleRef = rbacv1.RoleRef{
+		APIGroup: rbacv1.SchemeGroupVersion.Group,
+		Kind:     "Role",
+		Name:     roleName,
+	}
+	binding.Subjects = []rbacv1.Subject{
+		{
+			Kind:      "ServiceAccount",
+			Name:      serviceAccount.Name,
+			Namespace: serviceAccount.Namespace,
+		},
+	}
+}
+
+func ReconcilePrometheusRoleBinding(binding *rbacv1.RoleBinding) {
+	binding.RoleRef = rbacv1.RoleRef{
+		APIGroup: rbacv1.SchemeGroupVersion.Group,
+		Kind:     "Role",
+		Name:     manifests.GuestProberPrometheusRole().Name,
+	}
+	binding.Subjects = []rbacv1.Subject{
+		{
+			Kind:      "ServiceAccount",
+			Name:      "prometheus-k8s",
+			Namespace: "openshift-monitoring",
+		},
+	}
+}
+
+func ReconcileService(service *corev1.Service) {
+	service.Spec.Selector = selectorLabels
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       portName,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       guestprober.Port,
+			TargetPort: intstr.FromString(portName),
+		},
+	}
+	if service.Labels == nil {
+		service.Labels = map[string]string{}
+	}
+	for k, v := range selectorLabels {
+		service.Labels[k] = v
+	}
+}
+
+func ReconcileServiceMonitor(serviceMonitor *prometheusoperatorv1.ServiceMonitor) {
+	serviceMonitor.Spec.NamespaceSelector = prometheusoperatorv1.NamespaceSelector{
+		MatchNames: []string{serviceMonitor.Namespace},
+	}
+	serviceMonitor.Spec.Selector = metav1.LabelSelector{
+		MatchLabels: selectorLabels,
+	}
+	serviceMonitor.Spec.Endpoints = []prometheusoperatorv1.Endpoint{
+		{
+			Scheme: "http",
+			Port:   portName,
+			Path:   "/metrics",
+		},
+	}
+}
+
+// ReconcileDeployment runs the guest-prober subcommand of the control-plane-operator image in the guest cluster.
+func ReconcileDeployment(deployment *appsv1.Deployment, image string) {
+	service := manifests.GuestProberService()
+	deployment.Spec.Replicas = ptr.To[int32](1)
+	deployment.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: selectorLabels,
+	}
+	deployment.Spec.Template.ObjectMeta.Labels = selectorLabels
+	deployment.Spec.Template.Spec = corev1.PodSpec{
+		ServiceAccountName:           manifests.GuestProberServiceAccount().Name,
+		AutomountServiceAccountToken: ptr.To(true),
+		PriorityClassName:            "openshift-user-critical",
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot: ptr.To(true),
+			SeccompProfile: &corev1.SeccompProfile{
+				Type: corev1.SeccompProfileTypeRuntimeDefault,
+			},
+		},
+		Containers: []corev1.Container{
+			{
+				Name:            containerName,
+				Image:           image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/usr/bin/control-plane-operator"},
+				Args: []string{
+					"guest-prober",
+					fmt.Sprintf("--results-configmap=%s", manifests.GuestProberResultsConfigMap().Name),
+					fmt.Sprintf("--service-url=http://%s.%s.svc:%d/healthz", service.Name, service.Namespace, guestprober.Port),
+				},
+				Env: []corev1.EnvVar{
+					{
+						Name: "POD_NAME",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+						},
+					},
+					{
+						Name: "POD_NAMESPACE",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+						},
+					},
+				},
+				Ports: []corev1.ContainerPort{
+					{
+						Name:          portName,
+						ContainerPort: guestprober.Port,
+						Protocol:      corev1.ProtocolTCP,
+					},
+				},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{
+						HTTPGet: &corev1.HTTPGetAction{
+							Path:   "/healthz",
+							Port:   intstr.FromString(portName),
+							Scheme: corev1.URISchemeHTTP,
+						},
+					},
+					PeriodSeconds: 10,
+				},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("10m"),
+						corev1.ResourceMemory: resource.MustParse("30Mi"),
+					},
+				},
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: ptr.To(false),
+					Capabilities: &corev1.Capabilities{
+						Drop: []corev1.Capability{"ALL"},
+					},
+				},
+				TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			},
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package manifests
+
+import (
+	prometheusoperatorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	guestProberNamespace = "openshift-hypershift-guest-prober"
+	guestProberName      = "guest-prober"
+)
+
+func GuestProberNamespace() *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: guestProberNamespace,
+		},
+	}
+}
+
+func GuestProberServiceAccount() *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      guestProberName,
+			Namespace: guestProberNamespace,
+		},
+	}
+}
+
+func GuestProberRole() *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      guestProberName,
+			Namespace: guestProberNamespace,
+		},
+	}
+}
+
+func GuestProberRoleBinding() *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      guestProberName,
+			Namespace: guestProberNamespace,
+		},
+	}
+}
+
+func GuestProberIngressCanaryRole() *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      guestProberName,
+			Namespace: "openshift-ingress-canary",
+		},
+	}
+}
+
+func GuestProberIngressCanaryRoleBinding() *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      guestProberName,
+			Namespace: "openshift-ingress-canary",
+		},
+	}
+}
+
+func GuestProberPrometheusRole() *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prometheus-k8s",
+			Namespace: guestProberNamespace,
+		},
+	}
+}
+
+func GuestProberPrometheusRoleBinding() *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prometheus-k8s",
+			Namespace: guestProberNamespace,
+		},
+	}
+}
+
+// GuestProberPodLabels are the labels of the guest prober pods.
+func GuestProberPodLabels() map[string]string {
+	return map[string]string{"app": guestProberName}
+}
+
+func GuestProberDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      guestProberName,
+			Namespace: guestProberNamespace,
+		},
+	}
+}
+
+func GuestProberService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      guestProberName,
+			Namespace: guestProberNamespace,
+		},
+	}
+}
+
+func GuestProberServiceMonitor() *prometheusoperatorv1.ServiceMonitor {
+	return &prometheusoperatorv1.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      guestProberName,
+			Namespace: guestProberNamespace,
+		},
+	}
+}
+
+func GuestProberResultsConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "guest-prober-results",
+			Namespace: guestProberNamespace,
+		},
+	}
+}
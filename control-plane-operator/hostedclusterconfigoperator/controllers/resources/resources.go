@@ -52,6 +52,7 @@ import (
 	alerts "github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/alerts"
 	ccm "github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/cloudcontrollermanager/azure"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/crd"
+	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/guestprober"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/ingress"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/kas"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources/konnectivity"
@@ -117,6 +118,7 @@ type reconciler struct {
 	oauthPort                 int32
 	versions                  map[string]string
 	operateOnReleaseImage     string
+	guestProberImage          string
 }
 
 // eventHandler is the handler used throughout. As this controller reconciles all kind of different resources
@@ -174,6 +176,7 @@ func Setup(ctx context.Context, opts *operator.HostedClusterConfigOperatorConfig
 		oauthPort:                 opts.OAuthPort,
 		versions:                  opts.Versions,
 		operateOnReleaseImage:     opts.OperateOnReleaseImage,
+		guestProberImage:          opts.ControlPlaneOperatorImage,
 	}})
 	if err != nil {
 		return fmt.Errorf("failed to construct controller: %w", err)
@@ -387,6 +390,11 @@ func (r *reconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result
 		errs = append(errs, fmt.Errorf("failed to reconcile konnectivity agent: %w", err))
 	}
 
+	log.Info("reconciling guest prober")
+	if err := r.reconcileGuestProber(ctx, hcp); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reconcile guest prober: %w", err))
+	}
+
 	log.Info("reconciling openshift apiserver apiservices")
 	if err := r.reconcileOpenshiftAPIServerAPIServices(ctx, hcp); err != nil {
 		errs = append(errs, fmt.Errorf("failed to reconcile openshift apiserver service: %w", err))
@@ -1112,6 +1120,110 @@ func (r *reconciler) reconcileKonnectivityAgent(ctx context.Context, hcp *hyperv
 	return errors.NewAggregate(errs)
 }
 
+// reconcileGuestProber runs the guest prober in the guest cluster when the GuestProbesAnnotation is set,
+// and removes it otherwise.
+func (r *reconciler) reconcileGuestProber(ctx context.Context, hcp *hyperv1.HostedControlPlane) error {
+	if hcp.Annotations[hyperv1.GuestProbesAnnotation] != "true" {
+		var errs []error
+		for _, obj := range []client.Object{
+			manifests.GuestProberIngressCanaryRoleBinding(),
+			manifests.GuestProberIngressCanaryRole(),
+			manifests.GuestProberNamespace(),
+		} {
+			if _, err := util.DeleteIfNeeded(ctx, r.client, obj); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete %T %s: %w", obj, client.ObjectKeyFromObject(obj), err))
+			}
+		}
+		return errors.NewAggregate(errs)
+	}
+
+	namespace := manifests.GuestProberNamespace()
+	if _, err := r.CreateOrUpdate(ctx, r.client, namespace, func() error {
+		guestprober.ReconcileNamespace(namespace)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile guest prober namespace: %w", err)
+	}
+
+	var errs []error
+	serviceAccount := manifests.GuestProberServiceAccount()
+	if _, err := r.CreateOrUpdate(ctx, r.client, serviceAccount, func() error { return nil }); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reconcile guest prober service account: %w", err))
+	}
+
+	// The results are written by the prober, the configmap is only created here
+	resultsConfigMap := manifests.GuestProberResultsConfigMap()
+	if _, err := r.CreateOrUpdate(ctx, r.client, resultsConfigMap, func() error { return nil }); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reconcile guest prober results configmap: %w", err))
+	}
+
+	for _, rbac := range []struct {
+		role             *rbacv1.Role
+		binding          *rbacv1.RoleBinding
+		reconcileRole    func(*rbacv1.Role)
+		reconcileBinding func(*rbacv1.RoleBinding)
+	}{
+		{
+			role:             manifests.GuestProberRole(),
+			binding:          manifests.GuestProberRoleBinding(),
+			reconcileRole:    guestprober.ReconcileRole,
+			reconcileBinding: guestprober.ReconcileRoleBinding,
+		},
+		{
+			role:             manifests.GuestProberIngressCanaryRole(),
+			binding:          manifests.GuestProberIngressCanaryRoleBinding(),
+			reconcileRole:    guestprober.ReconcileIngressCanaryRole,
+			reconcileBinding: guestprober.ReconcileIngressCanaryRoleBinding,
+		},
+		{
+			role:             manifests.GuestProberPrometheusRole(),
+			binding:          manifests.GuestProberPrometheusRoleBinding(),
+			reconcileRole:    guestprober.ReconcilePrometheusRole,
+			reconcileBinding: guestprober.ReconcilePrometheusRoleBinding,
+		},
+	} {
+		role, binding := rbac.role, rbac.binding
+		if _, err := r.CreateOrUpdate(ctx, r.client, role, func() error {
+			rbac.reconcileRole(role)
+			return nil
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconcile role %s: %w", client.ObjectKeyFromObject(role), err))
+		}
+		if _, err := r.CreateOrUpdate(ctx, r.client, binding, func() error {
+			rbac.reconcileBinding(binding)
+			return nil
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconcile role binding %s: %w", client.ObjectKeyFromObject(binding), err))
+		}
+	}
+
+	service := manifests.GuestProberService()
+	if _, err := r.CreateOrUpdate(ctx, r.client, service, func() error {
+		guestprober.ReconcileService(service)
+		return nil
+	}); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reconcile guest prober service: %w", err))
+	}
+
+	serviceMonitor := manifests.GuestProberServiceMonitor()
+	if _, err := r.CreateOrUpdate(ctx, r.client, serviceMonitor, func() error {
+		guestprober.ReconcileServiceMonitor(serviceMonitor)
+		return nil
+	}); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reconcile guest prober service monitor: %w", err))
+	}
+
+	deployment := manifests.GuestProberDeployment()
+	if _, err := r.CreateOrUpdate(ctx, r.client, deployment, func() error {
+		guestprober.ReconcileDeployment(deployment, r.guestProberImage)
+		return nil
+	}); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reconcile guest prober deployment: %w", err))
+	}
+
+	return errors.NewAggregate(errs)
+}
+
 func (r *reconciler) reconcileClusterVersion(ctx context.Context, hcp *hyperv1.HostedControlPlane) error {
 	clusterVersion := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}}
 	if _, err := r.CreateOrUpdate(ctx, r.client, clusterVersion, func() error {
@@ -60,6 +60,10 @@ var initialObjects = []client.Object{
 	manifests.NamespaceKubeSystem(),
 	&configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}},
 	fakeOperatorHub(),
+	// The guest prober is disabled, its objects are only deleted
+	manifests.GuestProberNamespace(),
+	manifests.GuestProberIngressCanaryRole(),
+	manifests.GuestProberIngressCanaryRoleBinding(),
 }
 
 func shouldNotError(key client.ObjectKey) bool {
@@ -69,6 +69,7 @@ type HostedClusterConfigOperatorConfig struct {
 	OAuthAddress                 string
 	OAuthPort                    int32
 	OperateOnReleaseImage        string
+	ControlPlaneOperatorImage    string
 	EnableCIDebugOutput          bool
 
 	kubeClient kubeclient.Interface
@@ -15,6 +15,7 @@ import (
 	"github.com/openshift/hypershift/dnsresolver"
 	etcdbackup "github.com/openshift/hypershift/etcd-backup"
 	etcddefrag "github.com/openshift/hypershift/etcd-defrag"
+	guestprober "github.com/openshift/hypershift/guest-prober"
 	ignitionserver "github.com/openshift/hypershift/ignition-server/cmd"
 	konnectivityhttpsproxy "github.com/openshift/hypershift/konnectivity-https-proxy"
 	konnectivitysocks5proxy "github.com/openshift/hypershift/konnectivity-socks5-proxy"
@@ -133,6 +134,7 @@ func defaultCommand() *cobra.Command {
 	cmd.AddCommand(konnectivitysocks5proxy.NewStartCommand())
 	cmd.AddCommand(konnectivityhttpsproxy.NewStartCommand())
 	cmd.AddCommand(availabilityprober.NewStartCommand())
+	cmd.AddCommand(guestprober.NewStartCommand())
 	cmd.AddCommand(tokenminter.NewStartCommand())
 	cmd.AddCommand(ignitionserver.NewStartCommand())
 	cmd.AddCommand(etcddefrag.NewStartCommand())
@@ -0,0 +1,291 @@
+package guestprober
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/openshift/hypershift/pkg/version"
+	hyperapi "github.com/openshift/hypershift/support/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	ProbeDNS            = "dns"
+	ProbeServiceNetwork = "service-network"
+	ProbeIngress        = "ingress"
+	ProbeKonnectivity   = "konnectivity"
+
+	// ResultsKey is the key of the results ConfigMap holding the JSON encoded list of Results.
+	ResultsKey = "results"
+
+	// Port is the port the prober serves its health and metrics endpoints on.
+	Port = 8080
+)
+
+// Result is the outcome of the last run of a probe.
+type Result struct {
+	Probe   string      `json:"probe"`
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+	Time    metav1.Time `json:"time"`
+}
+
+var (
+	probeSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hypershift_guest_probe_success",
+		Help: "Whether the last run of the guest cluster probe succeeded (1) or failed (0).",
+	}, []string{"probe"})
+	probeDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hypershift_guest_probe_duration_seconds",
+		Help: "The duration of the last run of the guest cluster probe.",
+	}, []string{"probe"})
+)
+
+type options struct {
+	namespace             string
+	podName               string
+	resultsConfigMap      string
+	dnsName               string
+	serviceURL            string
+	ingressRouteNamespace string
+	ingressRouteName      string
+	interval              time.Duration
+	timeout               time.Duration
+}
+
+func NewStartCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "guest-prober",
+		Short: "Periodically probes the data path of the guest cluster it runs in",
+	}
+	opts := options{
+		namespace: os.Getenv("POD_NAMESPACE"),
+		podName:   os.Getenv("POD_NAME"),
+	}
+	cmd.Flags().StringVar(&opts.namespace, "namespace", opts.namespace, "The namespace of the prober")
+	cmd.Flags().StringVar(&opts.podName, "pod-name", opts.podName, "The name of the prober pod, probed through the kube-apiserver to check the konnectivity tunnel")
+	cmd.Flags().StringVar(&opts.resultsConfigMap, "results-configmap", "guest-prober-results", "The ConfigMap in the namespace of the prober the results are written to")
+	cmd.Flags().StringVar(&opts.dnsName, "dns-name", "kubernetes.default.svc.cluster.local", "The name resolved to check DNS resolution")
+	cmd.Flags().StringVar(&opts.serviceURL, "service-url", "", "The health endpoint of the prober service, requested to check service connectivity")
+	cmd.Flags().StringVar(&opts.ingressRouteNamespace, "ingress-route-namespace", "openshift-ingress-canary", "The namespace of the route requested to check the default ingress")
+	cmd.Flags().StringVar(&opts.ingressRouteName, "ingress-route-name", "canary", "The name of the route requested to check the default ingress")
+	cmd.Flags().DurationVar(&opts.interval, "interval", time.Minute, "The interval between probe runs")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 10*time.Second, "The timeout of each probe")
+
+	log := zap.New(zap.UseDevMode(true), zap.JSONEncoder(func(o *zapcore.EncoderConfig) {
+		o.EncodeTime = zapcore.RFC3339TimeEncoder
+	}))
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		log.Info("Starting guest-prober", "version", version.String())
+		if opts.namespace == "" || opts.podName == "" || opts.serviceURL == "" {
+			log.Info("--namespace, --pod-name and --service-url are required")
+			os.Exit(1)
+		}
+		if err := run(ctrl.SetupSignalHandler(), log, opts); err != nil {
+			log.Error(err, "guest-prober failed")
+			os.Exit(1)
+		}
+	}
+
+	return cmd
+}
+
+func run(ctx context.Context, log logr.Logger, opts options) error {
+	restConfig, err := ctrl.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+	kubeClient, err := crclient.New(restConfig, crclient.Options{Scheme: hyperapi.Scheme})
+	if err != nil {
+		return fmt.Errorf("failed to construct controller-runtime client: %w", err)
+	}
+	kubeClientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to construct kubernetes client: %w", err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(probeSuccess, probeDuration)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", Port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error(err, "failed to serve")
+			os.Exit(1)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	p := &prober{
+		opts:       opts,
+		kubeClient: kubeClient,
+		podProxyGet: func(ctx context.Context) error {
+			_, err := kubeClientset.CoreV1().Pods(opts.namespace).ProxyGet("http", opts.podName, fmt.Sprint(Port), "healthz", nil).DoRaw(ctx)
+			return err
+		},
+		httpClient: &http.Client{
+			Timeout: opts.timeout,
+			Transport: &http.Transport{
+				// The probes check reachability, the certificates of the endpoints are not verified.
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		resolver: net.DefaultResolver,
+	}
+	for {
+		results := p.probe(ctx)
+		for _, result := range results {
+			if !result.Success {
+				log.Info("Probe failed", "probe", result.Probe, "message", result.Message)
+			}
+		}
+		if err := p.writeResults(ctx, results); err != nil {
+			log.Error(err, "failed to write results")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(opts.interval):
+		}
+	}
+}
+
+// resolver resolves host names, it is satisfied by *net.Resolver.
+type resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type prober struct {
+	opts        options
+	kubeClient  crclient.Client
+	podProxyGet func(ctx context.Context) error
+	httpClient  *http.Client
+	resolver    resolver
+}
+
+// probe runs every probe once and records the results in the metrics.
+func (p *prober) probe(ctx context.Context) []Result {
+	probes := []struct {
+		name  string
+		probe func(ctx context.Context) error
+	}{
+		{name: ProbeDNS, probe: p.probeDNS},
+		{name: ProbeServiceNetwork, probe: p.probeServiceNetwork},
+		{name: ProbeIngress, probe: p.probeIngress},
+		{name: ProbeKonnectivity, probe: p.podProxyGet},
+	}
+	var results []Result
+	for _, probe := range probes {
+		probeCtx, cancel := context.WithTimeout(ctx, p.opts.timeout)
+		start := time.Now()
+		err := probe.probe(probeCtx)
+		cancel()
+		probeDuration.WithLabelValues(probe.name).Set(time.Since(start).Seconds())
+
+		result := Result{Probe: probe.name, Success: err == nil, Time: metav1.Now()}
+		if err != nil {
+			result.Message = err.Error()
+			probeSuccess.WithLabelValues(probe.name).Set(0)
+		} else {
+			probeSuccess.WithLabelValues(probe.name).Set(1)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (p *prober) probeDNS(ctx context.Context) error {
+	addresses, err := p.resolver.LookupHost(ctx, p.opts.dnsName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", p.opts.dnsName, err)
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("no address found for %s", p.opts.dnsName)
+	}
+	return nil
+}
+
+func (p *prober) probeServiceNetwork(ctx context.Context) error {
+	return p.get(ctx, p.opts.serviceURL)
+}
+
+func (p *prober) probeIngress(ctx context.Context) error {
+	route := &routev1.Route{}
+	if err := p.kubeClient.Get(ctx, crclient.ObjectKey{Namespace: p.opts.ingressRouteNamespace, Name: p.opts.ingressRouteName}, route); err != nil {
+		return fmt.Errorf("failed to get route %s/%s: %w", p.opts.ingressRouteNamespace, p.opts.ingressRouteName, err)
+	}
+	if route.Spec.Host == "" {
+		return fmt.Errorf("route %s/%s has no host", p.opts.ingressRouteNamespace, p.opts.ingressRouteName)
+	}
+	scheme := "http"
+	if route.Spec.TLS != nil {
+		scheme = "https"
+	}
+	return p.get(ctx, fmt.Sprintf("%s://%s", scheme, route.Spec.Host))
+}
+
+func (p *prober) get(ctx context.Context, url string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := p.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("request to %s returned status code %d", url, response.StatusCode)
+	}
+	return nil
+}
+
+// writeResults stores the results in the results ConfigMap, which is created by the
+// hosted cluster config operator and read back to report them on the HostedCluster.
+func (p *prober) writeResults(ctx context.Context, results []Result) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := p.kubeClient.Get(ctx, crclient.ObjectKey{Namespace: p.opts.namespace, Name: p.opts.resultsConfigMap}, configMap); err != nil {
+		return fmt.Errorf("failed to get results configmap: %w", err)
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[ResultsKey] = string(data)
+	if err := p.kubeClient.Update(ctx, configMap); err != nil {
+		return fmt.Errorf("failed to update results configmap: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package guestprober
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/openshift/hypershift/support/api"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeResolver struct {
+	addresses []string
+	err       error
+}
+
+func (r *fakeResolver) LookupHost(_ context.Context, _ string) ([]string, error) {
+	return r.addresses, r.err
+}
+
+func TestProbe(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	route := func(host string) *routev1.Route {
+		return &routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-canary", Name: "canary"},
+			Spec:       routev1.RouteSpec{Host: host},
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		resolver         *fakeResolver
+		serviceURL       string
+		route            *routev1.Route
+		podProxyErr      error
+		expectedFailures map[string]string
+	}{
+		{
+			name:        "When every endpoint is reachable it should report every probe as successful",
+			resolver:    &fakeResolver{addresses: []string{"172.30.0.1"}},
+			serviceURL:  healthy.URL,
+			route:       route(strings.TrimPrefix(healthy.URL, "http://")),
+			podProxyErr: nil,
+		},
+		{
+			name:        "When the name does not resolve it should report the dns probe as failed",
+			resolver:    &fakeResolver{err: errors.New("no such host")},
+			serviceURL:  healthy.URL,
+			route:       route(strings.TrimPrefix(healthy.URL, "http://")),
+			podProxyErr: nil,
+			expectedFailures: map[string]string{
+				ProbeDNS: "no such host",
+			},
+		},
+		{
+			name:        "When the name resolves to no address it should report the dns probe as failed",
+			resolver:    &fakeResolver{},
+			serviceURL:  healthy.URL,
+			route:       route(strings.TrimPrefix(healthy.URL, "http://")),
+			podProxyErr: nil,
+			expectedFailures: map[string]string{
+				ProbeDNS: "no address found",
+			},
+		},
+		{
+			name:        "When the service returns an error status it should report the service network probe as failed",
+			resolver:    &fakeResolver{addresses: []string{"172.30.0.1"}},
+			serviceURL:  unhealthy.URL,
+			route:       route(strings.TrimPrefix(healthy.URL, "http://")),
+			podProxyErr: nil,
+			expectedFailures: map[string]string{
+				ProbeServiceNetwork: "returned status code 503",
+			},
+		},
+		{
+			name:        "When the ingress route does not exist it should report the ingress probe as failed",
+			resolver:    &fakeResolver{addresses: []string{"172.30.0.1"}},
+			serviceURL:  healthy.URL,
+			podProxyErr: nil,
+			expectedFailures: map[string]string{
+				ProbeIngress: "failed to get route openshift-ingress-canary/canary",
+			},
+		},
+		{
+			name:        "When the ingress route has no host it should report the ingress probe as failed",
+			resolver:    &fakeResolver{addresses: []string{"172.30.0.1"}},
+			serviceURL:  healthy.URL,
+			route:       route(""),
+			podProxyErr: nil,
+			expectedFailures: map[string]string{
+				ProbeIngress: "has no host",
+			},
+		},
+		{
+			name:        "When the ingress returns an error status it should report the ingress probe as failed",
+			resolver:    &fakeResolver{addresses: []string{"172.30.0.1"}},
+			serviceURL:  healthy.URL,
+			route:       route(strings.TrimPrefix(unhealthy.URL, "http://")),
+			podProxyErr: nil,
+			expectedFailures: map[string]string{
+				ProbeIngress: "returned status code 503",
+			},
+		},
+		{
+			name:        "When the pod cannot be reached through the kube-apiserver it should report the konnectivity probe as failed",
+			resolver:    &fakeResolver{addresses: []string{"172.30.0.1"}},
+			serviceURL:  healthy.URL,
+			route:       route(strings.TrimPrefix(healthy.URL, "http://")),
+			podProxyErr: errors.New("dial timeout"),
+			expectedFailures: map[string]string{
+				ProbeKonnectivity: "dial timeout",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			clientBuilder := fake.NewClientBuilder().WithScheme(api.Scheme)
+			if tc.route != nil {
+				clientBuilder = clientBuilder.WithObjects(tc.route)
+			}
+			p := &prober{
+				opts: options{
+					dnsName:               "kubernetes.default.svc.cluster.local",
+					serviceURL:            tc.serviceURL,
+					ingressRouteNamespace: "openshift-ingress-canary",
+					ingressRouteName:      "canary",
+					timeout:               5 * time.Second,
+				},
+				kubeClient: clientBuilder.Build(),
+				podProxyGet: func(context.Context) error {
+					return tc.podProxyErr
+				},
+				httpClient: &http.Client{},
+				resolver:   tc.resolver,
+			}
+
+			results := p.probe(context.Background())
+
+			var probes []string
+			for _, result := range results {
+				probes = append(probes, result.Probe)
+				expectedMessage, shouldFail := tc.expectedFailures[result.Probe]
+				g.Expect(result.Success).To(Equal(!shouldFail), "probe %s", result.Probe)
+				if shouldFail {
+					g.Expect(result.Message).To(ContainSubstring(expectedMessage))
+					g.Expect(testutil.ToFloat64(probeSuccess.WithLabelValues(result.Probe))).To(Equal(0.0))
+				} else {
+					g.Expect(result.Message).To(BeEmpty())
+					g.Expect(testutil.ToFloat64(probeSuccess.WithLabelValues(result.Probe))).To(Equal(1.0))
+				}
+			}
+			g.Expect(probes).To(Equal([]string{ProbeDNS, ProbeServiceNetwork, ProbeIngress, ProbeKonnectivity}))
+		})
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+	results := []Result{
+		{Probe: ProbeDNS, Success: true, Time: metav1.Now()},
+		{Probe: ProbeIngress, Success: false, Message: "request failed", Time: metav1.Now()},
+	}
+
+	testCases := []struct {
+		name        string
+		configMap   *corev1.ConfigMap
+		expectedErr bool
+	}{
+		{
+			name: "When the results configmap exists it should store the results in it",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-hypershift-guest-prober", Name: "guest-prober-results"},
+			},
+		},
+		{
+			name: "When the results configmap holds previous results it should replace them",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-hypershift-guest-prober", Name: "guest-prober-results"},
+				Data:       map[string]string{ResultsKey: "[]"},
+			},
+		},
+		{
+			name:        "When the results configmap does not exist it should return an error",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			clientBuilder := fake.NewClientBuilder().WithScheme(api.Scheme)
+			if tc.configMap != nil {
+				clientBuilder = clientBuilder.WithObjects(tc.configMap)
+			}
+			p := &prober{
+				opts: options{
+					namespace:        "openshift-hypershift-guest-prober",
+					resultsConfigMap: "guest-prober-results",
+				},
+				kubeClient: clientBuilder.Build(),
+			}
+
+			err := p.writeResults(context.Background(), results)
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			configMap := &corev1.ConfigMap{}
+			g.Expect(p.kubeClient.Get(context.Background(), crclient.ObjectKeyFromObject(tc.configMap), configMap)).To(Succeed())
+			var written []Result
+			g.Expect(json.Unmarshal([]byte(configMap.Data[ResultsKey]), &written)).To(Succeed())
+			g.Expect(written).To(HaveLen(len(results)))
+			for i := range results {
+				g.Expect(written[i].Probe).To(Equal(results[i].Probe))
+				g.Expect(written[i].Success).To(Equal(results[i].Success))
+				g.Expect(written[i].Message).To(Equal(results[i].Message))
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package hostedcluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// The scale of a NodePool before hibernation is kept in these annotations until it is resumed.
+	nodePoolHibernationReplicasAnnotation    = "hypershift.openshift.io/hibernation-replicas"
+	nodePoolHibernationAutoScalingAnnotation = "hypershift.openshift.io/hibernation-autoscaling"
+
+	hibernationRequeueInterval = 30 * time.Second
+)
+
+// reconcileHibernation hibernates the HostedCluster while it has the HibernateAnnotation: NodePools are
+// scaled to zero first, then the control plane is hibernated by the control plane operator. On resume the
+// NodePools are restored once the control plane is running again. The progress is reported in the
+// Hibernating condition.
+// It returns whether the HibernateAnnotation must be set on the HostedControlPlane, and when to check
+// the progress again.
+func (r *HostedClusterReconciler) reconcileHibernation(ctx context.Context, hcluster *hyperv1.HostedCluster, hcp *hyperv1.HostedControlPlane) (bool, time.Duration, error) {
+	hibernate := hcluster.Annotations[hyperv1.HibernateAnnotation] == "true"
+	existing := meta.FindStatusCondition(hcluster.Status.Conditions, string(hyperv1.Hibernating))
+	if !hibernate && (existing == nil || existing.Reason == hyperv1.AsExpectedReason) {
+		return false, 0, nil
+	}
+
+	var hcpHibernating bool
+	var hcpCondition *metav1.Condition
+	if hcp != nil {
+		hcpHibernating = hcp.Annotations[hyperv1.HibernateAnnotation] == "true"
+		hcpCondition = meta.FindStatusCondition(hcp.Status.Conditions, string(hyperv1.Hibernating))
+	}
+
+	nodePools, err := listNodePools(ctx, r.Client, hcluster.Namespace, hcluster.Name)
+	if err != nil {
+		return false, 0, err
+	}
+
+	condition := metav1.Condition{
+		Type:               string(hyperv1.Hibernating),
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: hcluster.Generation,
+	}
+	var hibernateControlPlane bool
+	var requeueAfter time.Duration
+	if hibernate {
+		condition.Reason = hyperv1.HibernationInProgressReason
+		var running []string
+		for i := range nodePools {
+			nodePool := &nodePools[i]
+			if hibernateNodePool(nodePool) {
+				if err := r.Client.Update(ctx, nodePool); err != nil {
+					return false, 0, fmt.Errorf("failed to scale down nodepool %s for hibernation: %w", nodePool.Name, err)
+				}
+			}
+			if nodePool.Status.Replicas > 0 {
+				running = append(running, nodePool.Name)
+			}
+		}
+		// Once the control plane hibernates it stays hibernated until the HostedCluster is resumed.
+		hibernateControlPlane = hcpHibernating || len(running) == 0
+		switch {
+		case !hibernateControlPlane:
+			condition.Message = fmt.Sprintf("Waiting for NodePools %v to scale down", running)
+			requeueAfter = hibernationRequeueInterval
+		case hcpHibernating && hcpCondition != nil && hcpCondition.Status == metav1.ConditionTrue:
+			condition.Reason = hcpCondition.Reason
+			condition.Message = hcpCondition.Message
+		default:
+			condition.Message = "Waiting for the control plane to hibernate"
+		}
+	} else {
+		condition.Reason = hyperv1.ResumingReason
+		if hcp == nil || hcpHibernating || (hcpCondition != nil && hcpCondition.Status == metav1.ConditionTrue) {
+			condition.Message = "Waiting for the control plane to resume"
+			if hcpCondition != nil && hcpCondition.Reason == hyperv1.ResumingReason {
+				condition.Message = hcpCondition.Message
+			}
+		} else {
+			for i := range nodePools {
+				nodePool := &nodePools[i]
+				restored, err := resumeNodePool(nodePool)
+				if err != nil {
+					return false, 0, err
+				}
+				if restored {
+					if err := r.Client.Update(ctx, nodePool); err != nil {
+						return false, 0, fmt.Errorf("failed to restore nodepool %s from hibernation: %w", nodePool.Name, err)
+					}
+				}
+			}
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = hyperv1.AsExpectedReason
+			condition.Message = "The hosted cluster is running"
+		}
+	}
+
+	if existing == nil || existing.Status != condition.Status || existing.Reason != condition.Reason ||
+		existing.Message != condition.Message || existing.ObservedGeneration != condition.ObservedGeneration {
+		meta.SetStatusCondition(&hcluster.Status.Conditions, condition)
+		if err := r.Client.Status().Update(ctx, hcluster); err != nil {
+			return false, 0, fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+	return hibernateControlPlane, requeueAfter, nil
+}
+
+// hibernateNodePool scales the NodePool to zero, recording its replicas or autoscaling
+// in annotations. It returns whether the NodePool changed.
+func hibernateNodePool(nodePool *hyperv1.NodePool) bool {
+	if _, recorded := nodePool.Annotations[nodePoolHibernationReplicasAnnotation]; recorded &&
+		nodePool.Spec.AutoScaling == nil && ptr.Deref(nodePool.Spec.Replicas, 0) == 0 {
+		return false
+	}
+	if nodePool.Annotations == nil {
+		nodePool.Annotations = map[string]string{}
+	}
+	if _, recorded := nodePool.Annotations[nodePoolHibernationReplicasAnnotation]; !recorded {
+		nodePool.Annotations[nodePoolHibernationReplicasAnnotation] = strconv.Itoa(int(ptr.Deref(nodePool.Spec.Replicas, 0)))
+		if nodePool.Spec.AutoScaling != nil {
+			autoScaling, _ := json.Marshal(nodePool.Spec.AutoScaling)
+			nodePool.Annotations[nodePoolHibernationAutoScalingAnnotation] = string(autoScaling)
+		}
+	}
+	nodePool.Spec.AutoScaling = nil
+	nodePool.Spec.Replicas = ptr.To[int32](0)
+	return true
+}
+
+// resumeNodePool restores the replicas or autoscaling the NodePool had before hibernation.
+// It returns whether the NodePool changed.
+func resumeNodePool(nodePool *hyperv1.NodePool) (bool, error) {
+	value, recorded := nodePool.Annotations[nodePoolHibernationReplicasAnnotation]
+	if !recorded {
+		return false, nil
+	}
+	if autoScalingValue, autoScaled := nodePool.Annotations[nodePoolHibernationAutoScalingAnnotation]; autoScaled {
+		autoScaling := &hyperv1.NodePoolAutoScaling{}
+		if err := json.Unmarshal([]byte(autoScalingValue), autoScaling); err != nil {
+			return false, fmt.Errorf("invalid %s annotation on nodepool %s: %w", nodePoolHibernationAutoScalingAnnotation, nodePool.Name, err)
+		}
+		nodePool.Spec.AutoScaling = autoScaling
+		nodePool.Spec.Replicas = nil
+	} else {
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s annotation on nodepool %s: %w", nodePoolHibernationReplicasAnnotation, nodePool.Name, err)
+		}
+		nodePool.Spec.Replicas = ptr.To(int32(replicas))
+	}
+	delete(nodePool.Annotations, nodePoolHibernationReplicasAnnotation)
+	delete(nodePool.Annotations, nodePoolHibernationAutoScalingAnnotation)
+	return true, nil
+}
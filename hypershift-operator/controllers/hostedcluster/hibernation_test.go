@@ -0,0 +1,163 @@
+package hostedcluster
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHibernateAndResumeNodePool(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec hyperv1.NodePoolSpec
+	}{
+		{
+			name: "When the NodePool has replicas it should restore them",
+			spec: hyperv1.NodePoolSpec{Replicas: ptr.To[int32](3)},
+		},
+		{
+			name: "When the NodePool is autoscaled it should restore the autoscaling",
+			spec: hyperv1.NodePoolSpec{AutoScaling: &hyperv1.NodePoolAutoScaling{Min: 2, Max: 5}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			nodePool := &hyperv1.NodePool{Spec: tc.spec}
+
+			g.Expect(hibernateNodePool(nodePool)).To(BeTrue())
+			g.Expect(nodePool.Spec.Replicas).To(Equal(ptr.To[int32](0)))
+			g.Expect(nodePool.Spec.AutoScaling).To(BeNil())
+			g.Expect(hibernateNodePool(nodePool)).To(BeFalse())
+
+			restored, err := resumeNodePool(nodePool)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(restored).To(BeTrue())
+			g.Expect(nodePool.Spec).To(Equal(tc.spec))
+			g.Expect(nodePool.Annotations).To(BeEmpty())
+
+			restored, err = resumeNodePool(nodePool)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(restored).To(BeFalse())
+		})
+	}
+}
+
+func TestReconcileHibernation(t *testing.T) {
+	hibernatingCondition := func(status metav1.ConditionStatus, reason string) []metav1.Condition {
+		return []metav1.Condition{{Type: string(hyperv1.Hibernating), Status: status, Reason: reason}}
+	}
+	testCases := []struct {
+		name                          string
+		hibernate                     bool
+		hcConditions                  []metav1.Condition
+		hcp                           *hyperv1.HostedControlPlane
+		nodePoolStatusReplicas        int32
+		expectedHibernateControlPlane bool
+		expectedReason                string
+		expectedNodePoolReplicas      int32
+	}{
+		{
+			name:                     "When the HostedCluster is not hibernated it should leave the NodePools alone",
+			hcp:                      &hyperv1.HostedControlPlane{},
+			nodePoolStatusReplicas:   2,
+			expectedNodePoolReplicas: 2,
+		},
+		{
+			name:                     "When NodePools are running it should scale them down before the control plane",
+			hibernate:                true,
+			hcp:                      &hyperv1.HostedControlPlane{},
+			nodePoolStatusReplicas:   2,
+			expectedReason:           hyperv1.HibernationInProgressReason,
+			expectedNodePoolReplicas: 0,
+		},
+		{
+			name:                          "When NodePools are scaled down it should hibernate the control plane",
+			hibernate:                     true,
+			hcp:                           &hyperv1.HostedControlPlane{},
+			expectedHibernateControlPlane: true,
+			expectedReason:                hyperv1.HibernationInProgressReason,
+			expectedNodePoolReplicas:      0,
+		},
+		{
+			name:      "When the control plane is hibernated it should report it",
+			hibernate: true,
+			hcp: &hyperv1.HostedControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{hyperv1.HibernateAnnotation: "true"}},
+				Status:     hyperv1.HostedControlPlaneStatus{Conditions: hibernatingCondition(metav1.ConditionTrue, hyperv1.HibernatedReason)},
+			},
+			expectedHibernateControlPlane: true,
+			expectedReason:                hyperv1.HibernatedReason,
+			expectedNodePoolReplicas:      0,
+		},
+		{
+			name:         "When resuming it should wait for the control plane before restoring the NodePools",
+			hcConditions: hibernatingCondition(metav1.ConditionTrue, hyperv1.HibernatedReason),
+			hcp: &hyperv1.HostedControlPlane{
+				Status: hyperv1.HostedControlPlaneStatus{Conditions: hibernatingCondition(metav1.ConditionTrue, hyperv1.ResumingReason)},
+			},
+			expectedReason:           hyperv1.ResumingReason,
+			expectedNodePoolReplicas: 0,
+		},
+		{
+			name:         "When the control plane resumed it should restore the NodePools",
+			hcConditions: hibernatingCondition(metav1.ConditionTrue, hyperv1.ResumingReason),
+			hcp: &hyperv1.HostedControlPlane{
+				Status: hyperv1.HostedControlPlaneStatus{Conditions: hibernatingCondition(metav1.ConditionFalse, hyperv1.AsExpectedReason)},
+			},
+			expectedReason:           hyperv1.AsExpectedReason,
+			expectedNodePoolReplicas: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			hcluster := &hyperv1.HostedCluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"},
+				Status:     hyperv1.HostedClusterStatus{Conditions: tc.hcConditions},
+			}
+			if tc.hibernate {
+				hcluster.Annotations = map[string]string{hyperv1.HibernateAnnotation: "true"}
+			}
+			nodePool := &hyperv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "workers"},
+				Spec:       hyperv1.NodePoolSpec{ClusterName: "hc", Replicas: ptr.To[int32](2)},
+				Status:     hyperv1.NodePoolStatus{Replicas: tc.nodePoolStatusReplicas},
+			}
+			if len(tc.hcConditions) > 0 {
+				// The NodePool was scaled down by the hibernation
+				nodePool.Spec.Replicas = ptr.To[int32](0)
+				nodePool.Annotations = map[string]string{nodePoolHibernationReplicasAnnotation: "2"}
+			}
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(hcluster, nodePool).WithStatusSubresource(hcluster).Build()
+			r := &HostedClusterReconciler{Client: c}
+
+			hibernateControlPlane, _, err := r.reconcileHibernation(ctx, hcluster, tc.hcp)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(hibernateControlPlane).To(Equal(tc.expectedHibernateControlPlane))
+
+			updated := &hyperv1.HostedCluster{}
+			g.Expect(c.Get(ctx, crclient.ObjectKeyFromObject(hcluster), updated)).To(Succeed())
+			condition := meta.FindStatusCondition(updated.Status.Conditions, string(hyperv1.Hibernating))
+			if tc.expectedReason == "" {
+				g.Expect(condition).To(BeNil())
+			} else {
+				g.Expect(condition).ToNot(BeNil())
+				g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+			}
+
+			g.Expect(c.Get(ctx, crclient.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+			g.Expect(nodePool.Spec.Replicas).To(Equal(ptr.To(tc.expectedNodePoolReplicas)))
+		})
+	}
+}
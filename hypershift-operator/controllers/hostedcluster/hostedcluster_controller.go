@@ -28,12 +28,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/blang/semver"
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
@@ -58,6 +55,7 @@ import (
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/clock"
 	k8sutilspointer "k8s.io/utils/pointer"
@@ -109,6 +107,7 @@ import (
 	"github.com/openshift/hypershift/support/supportedversion"
 	"github.com/openshift/hypershift/support/upsert"
 	hyperutil "github.com/openshift/hypershift/support/util"
+	"github.com/openshift/hypershift/support/validations"
 )
 
 const (
@@ -168,8 +167,9 @@ type HostedClusterReconciler struct {
 
 	PrivatePlatform hyperv1.PlatformType
 
-	OIDCStorageProviderS3BucketName string
-	S3Client                        s3iface.S3API
+	// OIDCStorage publishes the OIDC discovery documents of HostedClusters, it is nil when the
+	// operator was not configured with an OIDC storage provider.
+	OIDCStorage oidc.Storage
 
 	MetricsSet    metrics.MetricsSet
 	SREConfigHash string
@@ -187,6 +187,13 @@ type HostedClusterReconciler struct {
 	CertRotationScale time.Duration
 
 	EnableCVOManagementClusterMetricsAccess bool
+
+	recorder record.EventRecorder
+
+	// upgradePolicyBlocked holds, per HostedCluster, the automatic upgrade last
+	// reported as blocked so the event is only emitted when it changes. Entries
+	// are dropped once the HostedCluster is deleted.
+	upgradePolicyBlocked sync.Map
 }
 
 // +kubebuilder:rbac:groups=hypershift.openshift.io,resources=hostedclusters,verbs=get;list;watch;create;update;patch;delete
@@ -200,6 +207,7 @@ func (r *HostedClusterReconciler) SetupWithManager(mgr ctrl.Manager, createOrUpd
 		r.now = metav1.Now
 	}
 	r.createOrUpdate = createOrUpdateWithAnnotationFactory(createOrUpdate)
+	r.recorder = mgr.GetEventRecorderFor("hostedcluster-controller")
 	// Set up watches for resource types the controller manages. The list basically
 	// tracks types of the resources in the clusterapi, controlplaneoperator, and
 	// ignitionserver manifests packages. Since we're receiving watch events across
@@ -214,6 +222,7 @@ func (r *HostedClusterReconciler) SetupWithManager(mgr ctrl.Manager, createOrUpd
 	for _, managedResource := range r.managedResources() {
 		bldr.Watches(managedResource, handler.EnqueueRequestsFromMapFunc(enqueueHostedClustersFunc(metricsSet, operatorNamespace, mgr.GetClient())), builder.WithPredicates(hyperutil.PredicatesForHostedClusterAnnotationScoping(mgr.GetClient())))
 	}
+	bldr.Watches(&hyperv1.HostedClusterInfrastructure{}, handler.EnqueueRequestsFromMapFunc(enqueueHostedClustersOfInfrastructure(mgr.GetClient())))
 
 	// Set based on SCC capability
 	// When SCC is available (OpenShift), the container's security context and UID range is automatically set
@@ -302,6 +311,7 @@ func (r *HostedClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("hostedcluster not found, aborting reconcile", "name", req.NamespacedName)
+			r.upgradePolicyBlocked.Delete(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, fmt.Errorf("failed to get cluster %q: %w", req.NamespacedName, err)
@@ -456,6 +466,7 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 
 	// If deleted, clean up and return early.
 	if !hcluster.DeletionTimestamp.IsZero() {
+		r.upgradePolicyBlocked.Delete(req.NamespacedName)
 		// This new condition is necessary for OCM personnel to report any cloud dangling objects to the user.
 		// The grace period is customizable using an annotation called HCDestroyGracePeriodAnnotation. It's a time.Duration annotation.
 		// This annotation will create a new condition called HostedClusterDestroyed which in conjuntion with CloudResourcesDestroyed
@@ -734,6 +745,25 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	// Copy the guest probe conditions from the hostedcontrolplane, they are only reported while the probes are enabled
+	for _, conditionType := range []hyperv1.ConditionType{
+		hyperv1.GuestDNSAvailable,
+		hyperv1.GuestServiceNetworkAvailable,
+		hyperv1.GuestIngressAvailable,
+		hyperv1.GuestKonnectivityAvailable,
+	} {
+		var condition *metav1.Condition
+		if hcp != nil && hcluster.Annotations[hyperv1.GuestProbesAnnotation] == "true" {
+			condition = meta.FindStatusCondition(hcp.Status.Conditions, string(conditionType))
+		}
+		if condition == nil {
+			meta.RemoveStatusCondition(&hcluster.Status.Conditions, string(conditionType))
+			continue
+		}
+		condition.ObservedGeneration = hcluster.Generation
+		meta.SetStatusCondition(&hcluster.Status.Conditions, *condition)
+	}
+
 	// Copy the platform status from the hostedcontrolplane
 	if hcp != nil {
 		hcluster.Status.Platform = hcp.Status.Platform
@@ -1073,10 +1103,27 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{RequeueAfter: duration}, nil
 	}
 
+	// Take the infraID and the cloud resources of the HostedClusterInfrastructure, if any, before
+	// defaulting the infraID.
+	if err := r.reconcileHostedClusterInfrastructure(ctx, hcluster); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if err := r.defaultClusterIDsIfNeeded(ctx, hcluster); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Apply the newest recommended release allowed by the upgrade policy, if any.
+	// The spec update triggers a new reconciliation with the new release.
+	if upgraded, err := r.reconcileUpgradePolicy(ctx, hcluster); err != nil {
+		return ctrl.Result{}, err
+	} else if upgraded {
+		return ctrl.Result{}, nil
+	}
+	if err := r.reconcileNodePoolUpgradePolicy(ctx, hcluster); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if err = r.reconcileCLISecrets(ctx, createOrUpdate, hcluster); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to reconcile the CLI secrets: %w", err)
 	}
@@ -1421,6 +1468,12 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	// Rotate the service account signing key if requested
+	serviceAccountKeyRotationRequeue, err := r.reconcileServiceAccountSigningKeyRotation(ctx, hcluster, controlPlaneNamespace.Name)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to rotate service account signing key: %w", err)
+	}
+
 	// Reconcile etcd client MTLS secret if the control plane is using an unmanaged etcd cluster
 	if hcluster.Spec.Etcd.ManagementType == hyperv1.Unmanaged {
 		unmanagedEtcdTLSClientSecret := &corev1.Secret{
@@ -1498,6 +1551,12 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	// Scale the NodePools down while hibernated, the control plane hibernates after them
+	hibernateControlPlane, hibernationRequeue, err := r.reconcileHibernation(ctx, hcluster, hcp)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile hibernation: %w", err)
+	}
+
 	// Reconcile the HostedControlPlane
 	isAutoscalingNeeded, err := r.isAutoscalingNeeded(ctx, hcluster)
 	if err != nil {
@@ -1505,7 +1564,13 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 	}
 	hcp = controlplaneoperator.HostedControlPlane(controlPlaneNamespace.Name, hcluster.Name)
 	_, err = createOrUpdate(ctx, r.Client, hcp, func() error {
-		return reconcileHostedControlPlane(hcp, hcluster, isAutoscalingNeeded)
+		if err := reconcileHostedControlPlane(hcp, hcluster, isAutoscalingNeeded); err != nil {
+			return err
+		}
+		if hibernateControlPlane {
+			hcp.Annotations[hyperv1.HibernateAnnotation] = "true"
+		}
+		return nil
 	})
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to reconcile hostedcontrolplane: %w", err)
@@ -1640,8 +1705,9 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
-	// Disable machine management components if enabled
-	if _, exists := hcluster.Annotations[hyperv1.DisableMachineManagement]; !exists {
+	// Disable machine management components if enabled. They are scaled down by the
+	// control plane operator while the control plane is hibernated.
+	if _, exists := hcluster.Annotations[hyperv1.DisableMachineManagement]; !exists && !hibernateControlPlane {
 		// Reconcile the CAPI manager components
 		err = r.reconcileCAPIManager(ctx, createOrUpdate, hcluster, hcp, pullSecretBytes, &releaseProvider)
 		if err != nil {
@@ -1739,10 +1805,9 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, fmt.Errorf("failed to reconcile network policies: %w", err)
 	}
 
-	// Reconcile the AWS OIDC discovery
-	switch hcluster.Spec.Platform.Type {
-	case hyperv1.AWSPlatform:
-		if err := r.reconcileAWSOIDCDocuments(ctx, log, hcluster, hcp); err != nil {
+	// Reconcile the OIDC discovery
+	if hasManagedOIDCDocuments(hcluster) {
+		if err := r.reconcileOIDCDocuments(ctx, log, hcluster, hcp); err != nil {
 			meta.SetStatusCondition(&hcluster.Status.Conditions, metav1.Condition{
 				Type:               string(hyperv1.ValidOIDCConfiguration),
 				Status:             metav1.ConditionFalse,
@@ -1751,9 +1816,9 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 				Message:            err.Error(),
 			})
 			if statusErr := r.Client.Status().Update(ctx, hcluster); statusErr != nil {
-				return ctrl.Result{}, fmt.Errorf("failed to reconcile AWS OIDC documents: %s, failed to update status: %w", err, statusErr)
+				return ctrl.Result{}, fmt.Errorf("failed to reconcile OIDC documents: %s, failed to update status: %w", err, statusErr)
 			}
-			return ctrl.Result{}, fmt.Errorf("failed to reconcile the AWS OIDC documents: %w", err)
+			return ctrl.Result{}, fmt.Errorf("failed to reconcile the OIDC documents: %w", err)
 		}
 		meta.SetStatusCondition(&hcluster.Status.Conditions, metav1.Condition{
 			Type:               string(hyperv1.ValidOIDCConfiguration),
@@ -1767,8 +1832,13 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	requeueAfter := serviceAccountKeyRotationRequeue
+	if hibernationRequeue > 0 && (requeueAfter == 0 || hibernationRequeue < requeueAfter) {
+		requeueAfter = hibernationRequeue
+	}
+
 	log.Info("successfully reconciled")
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // reconcileHostedControlPlane reconciles the given HostedControlPlane, which
@@ -1810,6 +1880,8 @@ func reconcileHostedControlPlane(hcp *hyperv1.HostedControlPlane, hcluster *hype
 		hyperv1.AWSLoadBalancerSubnetsAnnotation,
 		hyperv1.ManagementPlatformAnnotation,
 		hyperv1.KubeAPIServerVerbosityLevelAnnotation,
+		hyperv1.IgnitionServerTokenBindingAnnotation,
+		hyperv1.GuestProbesAnnotation,
 	}
 	for _, key := range mirroredAnnotations {
 		val, hasVal := hcluster.Annotations[key]
@@ -1893,6 +1965,7 @@ func reconcileHostedControlPlane(hcp *hyperv1.HostedControlPlane, hcluster *hype
 	hcp.Spec.Autoscaling = hcluster.Spec.Autoscaling
 	hcp.Spec.NodeSelector = hcluster.Spec.NodeSelector
 	hcp.Spec.Tolerations = hcluster.Spec.Tolerations
+	hcp.Spec.ControlPlaneComponentOverrides = hcluster.Spec.ControlPlaneComponentOverrides
 
 	// Pass through Platform spec.
 	hcp.Spec.Platform = *hcluster.Spec.Platform.DeepCopy()
@@ -3964,19 +4037,16 @@ func (r *HostedClusterReconciler) validateConfigAndClusterCapabilities(ctx conte
 		errs = append(errs, err)
 	}
 
-	if err := validateClusterID(hc); err != nil {
+	if err := r.validateHostedClusterInfrastructure(ctx, hc); err != nil {
 		errs = append(errs, err)
 	}
 
-	if err := r.validatePublishingStrategyMapping(hc); err != nil {
+	if err := validations.ValidateHostedCluster(hc).ToAggregate(); err != nil {
 		errs = append(errs, err)
 	}
 
-	// TODO(IBM): Revisit after fleets no longer use conflicting network CIDRs
-	if hc.Spec.Platform.Type != hyperv1.IBMCloudPlatform {
-		if err := r.validateNetworks(hc); err != nil {
-			errs = append(errs, err)
-		}
+	if err := validateNetworks(hc).ToAggregate(); err != nil {
+		errs = append(errs, err)
 	}
 
 	if err := r.validateUserCAConfigMaps(ctx, hc); err != nil {
@@ -4211,33 +4281,6 @@ func (r *HostedClusterReconciler) validateKubevirtConfig(ctx context.Context, hc
 	return kvinfra.ValidateClusterVersions(ctx, kvInfraClient)
 }
 
-// validatePublishingStrategyMapping validates that each published serviceType has a unique hostname.
-func (r *HostedClusterReconciler) validatePublishingStrategyMapping(hc *hyperv1.HostedCluster) error {
-	hostnameServiceMap := make(map[string]string, len(hc.Spec.Services))
-	for _, svc := range hc.Spec.Services {
-		hostname := ""
-		if svc.Type == hyperv1.LoadBalancer && svc.LoadBalancer != nil {
-			hostname = svc.LoadBalancer.Hostname
-		}
-		if svc.Type == hyperv1.Route && svc.Route != nil {
-			hostname = svc.Route.Hostname
-		}
-
-		if hostname == "" {
-			continue
-		}
-
-		serviceType, exists := hostnameServiceMap[hostname]
-		if exists {
-			return fmt.Errorf("service type %s can't be published with the same hostname %s as service type %s", svc.Service, hostname, serviceType)
-		}
-
-		hostnameServiceMap[hostname] = string(svc.Service)
-	}
-
-	return nil
-}
-
 func (r *HostedClusterReconciler) validateAzureConfig(ctx context.Context, hc *hyperv1.HostedCluster) error {
 	if hc.Spec.Platform.Type != hyperv1.AzurePlatform {
 		return nil
@@ -4320,14 +4363,27 @@ func (r *HostedClusterReconciler) validateHostedClusterSupport(hc *hyperv1.Hoste
 	return nil
 }
 
-func (r *HostedClusterReconciler) validateNetworks(hc *hyperv1.HostedCluster) error {
+// validateNetworks validates the networks of the HostedCluster are in the same stack,
+// do not overlap each other and do not overlap the API server addresses. It is shared
+// by the HostedCluster admission webhook and controller.
+func validateNetworks(hc *hyperv1.HostedCluster) field.ErrorList {
+	// TODO(IBM): Revisit after fleets no longer use conflicting network CIDRs
+	if hc.Spec.Platform.Type == hyperv1.IBMCloudPlatform {
+		return nil
+	}
+	// The cluster network is defaulted by the API, the primary network stack can not be
+	// determined without it.
+	if len(hc.Spec.Networking.ClusterNetwork) == 0 {
+		return nil
+	}
+
 	var errs field.ErrorList
 	errs = append(errs, validateNetworkStackAddresses(hc)...)
 	errs = append(errs, validateSliceNetworkCIDRs(hc)...)
 	errs = append(errs, checkAdvertiseAddressOverlapping(hc)...)
 	errs = append(errs, validateNodePortVsServiceNetwork(hc)...)
 
-	return errs.ToAggregate()
+	return errs
 }
 
 // findAdvertiseAddress function returns a string and an error indicating the AdvertiseAddress for the hostedcluster.
@@ -4541,9 +4597,14 @@ type NodeClientCert struct {
 }
 
 const (
+	// oidcDocumentsFinalizer is set once the OIDC documents are published, it keeps its original
+	// name from when documents were only published for AWS clusters.
 	oidcDocumentsFinalizer         = "hypershift.io/aws-oidc-discovery"
 	serviceAccountSigningKeySecret = "sa-signing-key"
 	serviceSignerPublicKey         = "service-account.pub"
+	// oidcDocumentsPublicKeysHashAnnotation is set on the signing key secret to the hash of the
+	// public keys in the published OIDC documents.
+	oidcDocumentsPublicKeysHashAnnotation = "hypershift.openshift.io/oidc-documents-public-keys-hash"
 )
 
 func oidcDocumentGenerators() map[string]oidc.OIDCDocumentGeneratorFunc {
@@ -4553,7 +4614,7 @@ func oidcDocumentGenerators() map[string]oidc.OIDCDocumentGeneratorFunc {
 	}
 }
 
-func (r *HostedClusterReconciler) reconcileAWSOIDCDocuments(ctx context.Context, log logr.Logger, hcluster *hyperv1.HostedCluster, hcp *hyperv1.HostedControlPlane) error {
+func (r *HostedClusterReconciler) reconcileOIDCDocuments(ctx context.Context, log logr.Logger, hcluster *hyperv1.HostedCluster, hcp *hyperv1.HostedControlPlane) error {
 	if hcp.Status.KubeConfig == nil {
 		return nil
 	}
@@ -4565,16 +4626,6 @@ func (r *HostedClusterReconciler) reconcileAWSOIDCDocuments(ctx context.Context,
 		return nil
 	}
 
-	// We use the presence of the finalizer to short-circuit the document upload to avoid
-	// constantly re-uploading it.
-	if controllerutil.ContainsFinalizer(hcluster, oidcDocumentsFinalizer) {
-		return nil
-	}
-
-	if r.OIDCStorageProviderS3BucketName == "" || r.S3Client == nil {
-		return errors.New("hypershift wasn't configured with a S3 bucket or credentials, this makes it unable to set up OIDC for AWS clusters. Please install hypershift with the --oidc-storage-provider-s3-bucket-name, --oidc-storage-provider-s3-region and --oidc-storage-provider-s3-credentials flags set. The bucket must pre-exist and the credentials must be authorized to write into it")
-	}
-
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: hcp.Namespace,
@@ -4589,6 +4640,24 @@ func (r *HostedClusterReconciler) reconcileAWSOIDCDocuments(ctx context.Context,
 		return fmt.Errorf("controlplane service account signing key secret %q missing required key %s", client.ObjectKeyFromObject(secret), serviceSignerPublicKey)
 	}
 
+	// We use the presence of the finalizer and the hash of the published public keys to short-circuit
+	// the document upload to avoid constantly re-uploading it. The documents are only published again
+	// when the public keys change during a service account signing key rotation.
+	publicKeysHash := hyperutil.HashSimple(secret.Data[serviceSignerPublicKey])
+	if controllerutil.ContainsFinalizer(hcluster, oidcDocumentsFinalizer) {
+		switch secret.Annotations[oidcDocumentsPublicKeysHashAnnotation] {
+		case publicKeysHash:
+			return nil
+		case "":
+			// The documents were published before their public keys were tracked, when keys were never rotated.
+			return r.setOIDCDocumentsPublicKeysHash(ctx, secret, publicKeysHash)
+		}
+	}
+
+	if r.OIDCStorage == nil {
+		return errors.New("hypershift wasn't configured with an OIDC storage provider, this makes it unable to publish the OIDC documents of the cluster. Please install hypershift either with the --oidc-storage-provider-s3-bucket-name, --oidc-storage-provider-s3-region and --oidc-storage-provider-s3-credentials flags set to publish the documents to a bucket, or with --oidc-storage-provider-in-cluster to serve them from the management cluster")
+	}
+
 	params := oidc.ODICGeneratorParams{
 		IssuerURL: hcp.Spec.IssuerURL,
 		PubKey:    secret.Data[serviceSignerPublicKey],
@@ -4599,63 +4668,64 @@ func (r *HostedClusterReconciler) reconcileAWSOIDCDocuments(ctx context.Context,
 		if err != nil {
 			return fmt.Errorf("failed to generate OIDC document %s: %w", path, err)
 		}
-		_, err = r.S3Client.PutObject(&s3.PutObjectInput{
-			Body:   bodyReader,
-			Bucket: aws.String(r.OIDCStorageProviderS3BucketName),
-			Key:    aws.String(hcluster.Spec.InfraID + path),
-		})
-		if err != nil {
-			wrapped := fmt.Errorf("failed to upload %s to the %s s3 bucket", path, r.OIDCStorageProviderS3BucketName)
-			if awsErr := awserr.Error(nil); errors.As(err, &awsErr) {
-				switch awsErr.Code() {
-				case s3.ErrCodeNoSuchBucket:
-					wrapped = fmt.Errorf("%w: %s: this could be a misconfiguration of the hypershift operator; check the --oidc-storage-provider-s3-bucket-name flag", wrapped, awsErr.Code())
-				default:
-					// Generally, the underlying message from AWS has unique per-request
-					// info not suitable for publishing as condition messages, so just
-					// return the code. If other specific error types can be handled, add
-					// new switch cases and try to provide more actionable info to the
-					// user.
-					wrapped = fmt.Errorf("%w: aws returned an error: %s", wrapped, awsErr.Code())
-				}
-			}
-			return wrapped
+		if err := r.OIDCStorage.Put(ctx, hcluster.Spec.InfraID+path, bodyReader); err != nil {
+			return fmt.Errorf("failed to publish the OIDC documents of issuer %s to %s: %w", hcp.Spec.IssuerURL, r.OIDCStorage, err)
 		}
 	}
 
-	hcluster.Finalizers = append(hcluster.Finalizers, oidcDocumentsFinalizer)
-	if err := r.Client.Update(ctx, hcluster); err != nil {
-		return fmt.Errorf("failed to update the hosted cluster after adding the %s finalizer: %w", oidcDocumentsFinalizer, err)
+	if !controllerutil.ContainsFinalizer(hcluster, oidcDocumentsFinalizer) {
+		hcluster.Finalizers = append(hcluster.Finalizers, oidcDocumentsFinalizer)
+		if err := r.Client.Update(ctx, hcluster); err != nil {
+			return fmt.Errorf("failed to update the hosted cluster after adding the %s finalizer: %w", oidcDocumentsFinalizer, err)
+		}
+	}
+	if err := r.setOIDCDocumentsPublicKeysHash(ctx, secret, publicKeysHash); err != nil {
+		return err
 	}
 
-	log.Info("Successfully uploaded the OIDC documents to the S3 bucket")
+	log.Info("Successfully uploaded the OIDC documents", "storage", r.OIDCStorage.String())
 
 	return nil
 }
 
+func (r *HostedClusterReconciler) setOIDCDocumentsPublicKeysHash(ctx context.Context, secret *corev1.Secret, publicKeysHash string) error {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[oidcDocumentsPublicKeysHashAnnotation] = publicKeysHash
+	if err := r.Client.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to record the public keys of the published OIDC documents: %w", err)
+	}
+	return nil
+}
+
+// hasManagedOIDCDocuments returns true when the operator publishes the OIDC discovery documents
+// of the HostedCluster. AWS clusters always need them, on other platforms they are only published
+// when the cluster opts in with the PublishOIDCDocumentsAnnotation and its issuer URL is not the
+// in-cluster default, so clusters which publish their own documents are left alone.
+func hasManagedOIDCDocuments(hcluster *hyperv1.HostedCluster) bool {
+	if hcluster.Spec.Platform.Type == hyperv1.AWSPlatform {
+		return true
+	}
+	return hcluster.Annotations[hyperv1.PublishOIDCDocumentsAnnotation] == "true" &&
+		hcluster.Spec.IssuerURL != "" && hcluster.Spec.IssuerURL != config.DefaultServiceAccountIssuer
+}
+
 func (r *HostedClusterReconciler) cleanupOIDCBucketData(ctx context.Context, log logr.Logger, hcluster *hyperv1.HostedCluster) error {
 	if !controllerutil.ContainsFinalizer(hcluster, oidcDocumentsFinalizer) {
 		return nil
 	}
 
-	if r.OIDCStorageProviderS3BucketName == "" || r.S3Client == nil {
-		return fmt.Errorf("hypershift wasn't configured with AWS credentials and a bucket, can not clean up OIDC documents from bucket. Please either set those up or clean up manually and then remove the %s finalizer from the hosted cluster", oidcDocumentsFinalizer)
+	if r.OIDCStorage == nil {
+		return fmt.Errorf("hypershift wasn't configured with an OIDC storage provider, can not clean up OIDC documents. Please either set it up or clean up manually and then remove the %s finalizer from the hosted cluster", oidcDocumentsFinalizer)
 	}
 
-	var objectsToDelete []*s3.ObjectIdentifier
+	var keys []string
 	for path := range oidcDocumentGenerators() {
-		objectsToDelete = append(objectsToDelete, &s3.ObjectIdentifier{
-			Key: aws.String(hcluster.Spec.InfraID + path),
-		})
+		keys = append(keys, hcluster.Spec.InfraID+path)
 	}
-
-	if _, err := r.S3Client.DeleteObjects(&s3.DeleteObjectsInput{
-		Bucket: aws.String(r.OIDCStorageProviderS3BucketName),
-		Delete: &s3.Delete{Objects: objectsToDelete},
-	}); err != nil {
-		if awsErr := awserr.Error(nil); !errors.As(err, &awsErr) || awsErr.Code() != s3.ErrCodeNoSuchBucket {
-			return fmt.Errorf("failed to delete OIDC objects from %s S3 bucket: %w", r.OIDCStorageProviderS3BucketName, err)
-		}
+	if err := r.OIDCStorage.Delete(ctx, keys...); err != nil {
+		return err
 	}
 
 	controllerutil.RemoveFinalizer(hcluster, oidcDocumentsFinalizer)
@@ -4663,7 +4733,7 @@ func (r *HostedClusterReconciler) cleanupOIDCBucketData(ctx context.Context, log
 		return fmt.Errorf("failed to update hostedcluster after removing %s finalizer: %w", oidcDocumentsFinalizer, err)
 	}
 
-	log.Info("Successfully deleted the OIDC documents from the S3 bucket")
+	log.Info("Successfully deleted the OIDC documents", "storage", r.OIDCStorage.String())
 	return nil
 }
 
@@ -4746,6 +4816,16 @@ func (r *HostedClusterReconciler) isAutoscalingNeeded(ctx context.Context, hclus
 	return false, nil
 }
 
+// UpgradeStatus returns the version status of a HostedCluster the way the HostedCluster controller
+// computes it, or the error which blocks the upgrade to the release of its spec. Controllers which
+// drive upgrades use it to follow them without re-implementing upgrade detection.
+func UpgradeStatus(clock clock.WithTickerAndDelayedExecution, hcluster *hyperv1.HostedCluster, releaseImage *releaseinfo.ReleaseImage) (*hyperv1.ClusterVersionStatus, error) {
+	if _, _, err := isUpgrading(hcluster, releaseImage); err != nil {
+		return nil, err
+	}
+	return computeClusterVersionStatus(clock, hcluster, nil), nil
+}
+
 // isUpgrading returns
 // 1) bool indicating whether the HostedCluster is upgrading
 // 2) non-error message about the condition of the upgrade
@@ -4832,15 +4912,6 @@ func (r *HostedClusterReconciler) defaultClusterIDsIfNeeded(ctx context.Context,
 	return nil
 }
 
-func validateClusterID(hc *hyperv1.HostedCluster) error {
-	if len(hc.Spec.ClusterID) > 0 {
-		_, err := uuid.Parse(hc.Spec.ClusterID)
-		if err != nil {
-			return fmt.Errorf("cannot parse cluster ID %q: %w", hc.Spec.ClusterID, err)
-		}
-	}
-	return nil
-}
 func (r *HostedClusterReconciler) reconcileServiceAccountSigningKey(ctx context.Context, hc *hyperv1.HostedCluster, targetNamespace string, createOrUpdate upsert.CreateOrUpdateFN) error {
 	privateBytes, publicBytes, err := r.serviceAccountSigningKeyBytes(ctx, hc)
 	if err != nil {
@@ -1394,7 +1394,7 @@ func TestValidateConfigAndClusterCapabilities(t *testing.T) {
 						ClusterNetwork: clusterNet,
 					},
 				}},
-			expectedResult: errors.New(`cannot parse cluster ID "foobar": invalid UUID length: 6`),
+			expectedResult: errors.New(`spec.clusterID: Invalid value: "foobar": cannot parse cluster ID: invalid UUID length: 6`),
 		},
 		{
 			name: "Setting Service network CIDR and NodePort IP overlapping, not allowed",
@@ -1467,7 +1467,7 @@ func TestValidateConfigAndClusterCapabilities(t *testing.T) {
 						ClusterNetwork: clusterNet,
 					},
 				}},
-			expectedResult:                errors.New(`service type OAuthServer can't be published with the same hostname api.example.com as service type APIServer`),
+			expectedResult:                errors.New(`spec.services[1].servicePublishingStrategy.route.hostname: Invalid value: "api.example.com": service type OAuthServer can't be published with the same hostname api.example.com as service type APIServer`),
 			managementClusterCapabilities: &fakecapabilities.FakeSupportAllCapabilities{},
 		},
 		{
@@ -3518,3 +3518,52 @@ func TestKubevirtETCDEncKey(t *testing.T) {
 		)
 	}
 }
+
+func TestHasManagedOIDCDocuments(t *testing.T) {
+	testCases := []struct {
+		name        string
+		platform    hyperv1.PlatformType
+		issuerURL   string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name:     "When the cluster is on AWS it should always publish the documents",
+			platform: hyperv1.AWSPlatform,
+			expected: true,
+		},
+		{
+			name:      "When a cluster on another platform has a custom issuer URL without opting in it should not publish the documents",
+			platform:  hyperv1.KubevirtPlatform,
+			issuerURL: "https://issuer.example.com",
+			expected:  false,
+		},
+		{
+			name:        "When a cluster on another platform opts in with a custom issuer URL it should publish the documents",
+			platform:    hyperv1.KubevirtPlatform,
+			issuerURL:   "https://issuer.example.com",
+			annotations: map[string]string{hyperv1.PublishOIDCDocumentsAnnotation: "true"},
+			expected:    true,
+		},
+		{
+			name:        "When a cluster on another platform opts in with the default issuer URL it should not publish the documents",
+			platform:    hyperv1.KubevirtPlatform,
+			issuerURL:   config.DefaultServiceAccountIssuer,
+			annotations: map[string]string{hyperv1.PublishOIDCDocumentsAnnotation: "true"},
+			expected:    false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			hcluster := &hyperv1.HostedCluster{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Spec: hyperv1.HostedClusterSpec{
+					Platform:  hyperv1.PlatformSpec{Type: tc.platform},
+					IssuerURL: tc.issuerURL,
+				},
+			}
+			g.Expect(hasManagedOIDCDocuments(hcluster)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/go-logr/logr"
 	"github.com/openshift/hypershift/cmd/cluster/core"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,6 +20,7 @@ import (
 	"github.com/openshift/hypershift/hypershift-operator/conversion"
 	"github.com/openshift/hypershift/support/supportedversion"
 	hyperutil "github.com/openshift/hypershift/support/util"
+	"github.com/openshift/hypershift/support/validations"
 )
 
 type hostedClusterDefaulter struct {
@@ -159,6 +161,12 @@ func (v hostedClusterValidator) ValidateCreate(ctx context.Context, obj runtime.
 		return nil, fmt.Errorf("wrong type %T for validation, instead of HostedCluster", obj)
 	}
 
+	errs := validations.ValidateHostedCluster(hc)
+	errs = append(errs, validateNetworks(hc)...)
+	if len(errs) > 0 {
+		return nil, apierrors.NewInvalid(hyperv1.GroupVersion.WithKind("HostedCluster").GroupKind(), hc.Name, errs)
+	}
+
 	switch hc.Spec.Platform.Type {
 	case hyperv1.KubevirtPlatform:
 		return v.validateCreateKubevirtHostedCluster(ctx, hc)
@@ -178,6 +186,15 @@ func (v hostedClusterValidator) ValidateUpdate(ctx context.Context, oldHC, newHC
 		return nil, fmt.Errorf("wrong type %T for validation, instead of HostedCluster", oldHC)
 	}
 
+	errs := validations.ValidateHostedClusterUpdate(hcOld, hc)
+	// As with the other fields, the networks are only validated when the spec changes.
+	if !equality.Semantic.DeepEqual(hcOld.Spec, hc.Spec) {
+		errs = append(errs, validateNetworks(hc)...)
+	}
+	if len(errs) > 0 {
+		return nil, apierrors.NewInvalid(hyperv1.GroupVersion.WithKind("HostedCluster").GroupKind(), hc.Name, errs)
+	}
+
 	switch hc.Spec.Platform.Type {
 	case hyperv1.KubevirtPlatform:
 		err := v.validateUpdateKubevirtHostedCluster(ctx, hcOld, hc)
@@ -226,6 +243,10 @@ func (v nodePoolValidator) ValidateCreate(ctx context.Context, obj runtime.Objec
 		return nil, fmt.Errorf("wrong type %T for validation, instead of NodePool", obj)
 	}
 
+	if errs := validations.ValidateNodePool(np); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(hyperv1.GroupVersion.WithKind("NodePool").GroupKind(), np.Name, errs)
+	}
+
 	switch np.Spec.Platform.Type {
 	case hyperv1.KubevirtPlatform:
 		return v.validateCreateKubevirtNodePool(ctx, np)
@@ -245,6 +266,10 @@ func (v nodePoolValidator) ValidateUpdate(ctx context.Context, oldNP, newNP runt
 		return nil, fmt.Errorf("wrong type %T for validation, instead of NodePool", npOld)
 	}
 
+	if errs := validations.ValidateNodePoolUpdate(npOld, npNew); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(hyperv1.GroupVersion.WithKind("NodePool").GroupKind(), npNew.Name, errs)
+	}
+
 	switch npNew.Spec.Platform.Type {
 	case hyperv1.KubevirtPlatform:
 		err := v.validateUpdateKubevirtNodePool(ctx, npOld, npNew)
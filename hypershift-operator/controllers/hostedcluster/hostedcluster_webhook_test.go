@@ -10,6 +10,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/api/util/ipnet"
 )
 
 func TestValidateKVHostedClusterCreate(t *testing.T) {
@@ -75,6 +76,30 @@ func TestValidateKVHostedClusterCreate(t *testing.T) {
 			expectError:  true,
 			imageVersion: "4.16.0",
 		},
+		{
+			name: "overlapping networks",
+			hc: &v1beta1.HostedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-under-test",
+					Namespace: "myns",
+				},
+				Spec: v1beta1.HostedClusterSpec{
+					Platform: v1beta1.PlatformSpec{
+						Type:     v1beta1.KubevirtPlatform,
+						Kubevirt: &v1beta1.KubevirtPlatformSpec{},
+					},
+					Networking: v1beta1.ClusterNetworking{
+						ClusterNetwork: []v1beta1.ClusterNetworkEntry{{CIDR: *ipnet.MustParseCIDR("10.132.0.0/14")}},
+						ServiceNetwork: []v1beta1.ServiceNetworkEntry{{CIDR: *ipnet.MustParseCIDR("10.132.0.0/16")}},
+					},
+					Release: v1beta1.Release{
+						Image: "image-4.16.0",
+					},
+				},
+			},
+			expectError:  true,
+			imageVersion: "4.16.0",
+		},
 	} {
 		t.Run(testCase.name, func(tt *testing.T) {
 			hcVal := &hostedClusterValidator{}
@@ -107,6 +132,10 @@ func TestValidateKVHostedClusterUpdate(t *testing.T) {
 			name: "happy case - versions are valid",
 			oldHC: &v1beta1.HostedCluster{
 				Spec: v1beta1.HostedClusterSpec{
+					Platform: v1beta1.PlatformSpec{
+						Type:     v1beta1.KubevirtPlatform,
+						Kubevirt: &v1beta1.KubevirtPlatformSpec{},
+					},
 					Release: v1beta1.Release{
 						Image: "image-4.14.0",
 					},
@@ -137,6 +166,10 @@ func TestValidateKVHostedClusterUpdate(t *testing.T) {
 			name: "wrong json",
 			oldHC: &v1beta1.HostedCluster{
 				Spec: v1beta1.HostedClusterSpec{
+					Platform: v1beta1.PlatformSpec{
+						Type:     v1beta1.KubevirtPlatform,
+						Kubevirt: &v1beta1.KubevirtPlatformSpec{},
+					},
 					Release: v1beta1.Release{
 						Image: "image-4.14.0",
 					},
@@ -166,6 +199,36 @@ func TestValidateKVHostedClusterUpdate(t *testing.T) {
 			expectError:  true,
 			imageVersion: "4.16.0",
 		},
+		{
+			name: "platform type changed",
+			oldHC: &v1beta1.HostedCluster{
+				Spec: v1beta1.HostedClusterSpec{
+					Platform: v1beta1.PlatformSpec{
+						Type: v1beta1.NonePlatform,
+					},
+					Release: v1beta1.Release{
+						Image: "image-4.16.0",
+					},
+				},
+			},
+			newHC: &v1beta1.HostedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-under-test",
+					Namespace: "myns",
+				},
+				Spec: v1beta1.HostedClusterSpec{
+					Platform: v1beta1.PlatformSpec{
+						Type:     v1beta1.KubevirtPlatform,
+						Kubevirt: &v1beta1.KubevirtPlatformSpec{},
+					},
+					Release: v1beta1.Release{
+						Image: "image-4.16.0",
+					},
+				},
+			},
+			expectError:  true,
+			imageVersion: "4.16.0",
+		},
 	} {
 		t.Run(testCase.name, func(tt *testing.T) {
 			hcVal := &hostedClusterValidator{}
@@ -0,0 +1,231 @@
+package hostedcluster
+
+import (
+	"context"
+	"fmt"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// defaultIssuerURL is the default of spec.issuerURL, which is replaced with the issuer of the IAM resources
+// of an AWS infrastructure.
+const defaultIssuerURL = "https://kubernetes.default.svc"
+
+// hostedClusterInfrastructure returns the HostedClusterInfrastructure named in the annotation of the
+// HostedCluster, nil if the HostedCluster has no annotation.
+func (r *HostedClusterReconciler) hostedClusterInfrastructure(ctx context.Context, hcluster *hyperv1.HostedCluster) (*hyperv1.HostedClusterInfrastructure, error) {
+	name, ok := hcluster.Annotations[hyperv1.HostedClusterInfrastructureAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	infra := &hyperv1.HostedClusterInfrastructure{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: hcluster.Namespace, Name: name}, infra); err != nil {
+		return nil, err
+	}
+	return infra, nil
+}
+
+// reconcileHostedClusterInfrastructure sets the unset fields of the spec of a HostedCluster from the
+// HostedClusterInfrastructure named in its annotation. The infraID is set right away, so that it is not
+// defaulted, the other fields once the infrastructure is available.
+func (r *HostedClusterReconciler) reconcileHostedClusterInfrastructure(ctx context.Context, hcluster *hyperv1.HostedCluster) error {
+	infra, err := r.hostedClusterInfrastructure(ctx, hcluster)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// reported by the ValidConfiguration condition
+			return nil
+		}
+		return fmt.Errorf("failed to get hostedclusterinfrastructure: %w", err)
+	}
+	if infra == nil {
+		return nil
+	}
+
+	original := hcluster.DeepCopy()
+	if hcluster.Spec.InfraID == "" {
+		hcluster.Spec.InfraID = infra.Spec.InfraID
+	}
+	if infrastructureAvailable(infra) {
+		applyInfrastructureStatus(hcluster, infra)
+	}
+	if !equality.Semantic.DeepEqual(original.Spec, hcluster.Spec) {
+		ctrl.LoggerFrom(ctx).Info("Setting the resources of the hostedclusterinfrastructure", "hostedclusterinfrastructure", infra.Name)
+		if err := r.Update(ctx, hcluster); err != nil {
+			return fmt.Errorf("failed to update hostedcluster with the resources of its hostedclusterinfrastructure: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateHostedClusterInfrastructure returns an error while the HostedClusterInfrastructure named in the
+// annotation of the HostedCluster is not available, or when the HostedCluster uses other resources.
+func (r *HostedClusterReconciler) validateHostedClusterInfrastructure(ctx context.Context, hcluster *hyperv1.HostedCluster) error {
+	infra, err := r.hostedClusterInfrastructure(ctx, hcluster)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("hostedclusterinfrastructure %s not found", hcluster.Annotations[hyperv1.HostedClusterInfrastructureAnnotation])
+		}
+		return fmt.Errorf("failed to get hostedclusterinfrastructure: %w", err)
+	}
+	if infra == nil {
+		return nil
+	}
+	if infra.Spec.Platform.Type != hcluster.Spec.Platform.Type {
+		return fmt.Errorf("hostedclusterinfrastructure %s is for platform %s, not %s", infra.Name, infra.Spec.Platform.Type, hcluster.Spec.Platform.Type)
+	}
+	if !infrastructureAvailable(infra) {
+		message := "it was not synced yet"
+		if condition := meta.FindStatusCondition(infra.Status.Conditions, string(hyperv1.InfrastructureAvailable)); condition != nil && condition.Message != "" {
+			message = condition.Message
+		}
+		return fmt.Errorf("hostedclusterinfrastructure %s is not available: %s", infra.Name, message)
+	}
+	if err := utilerrors.NewAggregate(infrastructureConflicts(hcluster, infra)); err != nil {
+		return fmt.Errorf("hostedcluster does not match hostedclusterinfrastructure %s: %w", infra.Name, err)
+	}
+	return nil
+}
+
+// infrastructureAvailable returns whether the resources of the current spec of the infrastructure were synced.
+func infrastructureAvailable(infra *hyperv1.HostedClusterInfrastructure) bool {
+	return infra.Status.ObservedGeneration == infra.Generation &&
+		meta.IsStatusConditionTrue(infra.Status.Conditions, string(hyperv1.InfrastructureAvailable))
+}
+
+// applyInfrastructureStatus sets the unset fields of the spec of the HostedCluster from the resources
+// reported in the status of the infrastructure.
+func applyInfrastructureStatus(hcluster *hyperv1.HostedCluster, infra *hyperv1.HostedClusterInfrastructure) {
+	setIfEmpty := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	spec := &hcluster.Spec
+	platform := &spec.Platform
+
+	switch {
+	case platform.AWS != nil && infra.Status.AWS != nil:
+		status := infra.Status.AWS
+		setIfEmpty(&spec.DNS.PublicZoneID, status.PublicZoneID)
+		setIfEmpty(&spec.DNS.PrivateZoneID, status.PrivateZoneID)
+		if platform.AWS.CloudProviderConfig == nil && status.VPCID != "" {
+			platform.AWS.CloudProviderConfig = &hyperv1.AWSCloudProviderConfig{VPC: status.VPCID}
+			if len(status.Zones) > 0 {
+				platform.AWS.CloudProviderConfig.Zone = status.Zones[0].Name
+				platform.AWS.CloudProviderConfig.Subnet = &hyperv1.AWSResourceReference{ID: ptr.To(status.Zones[0].SubnetID)}
+			}
+		}
+		if status.IAM != nil {
+			if spec.IssuerURL == "" || spec.IssuerURL == defaultIssuerURL {
+				spec.IssuerURL = status.IAM.IssuerURL
+			}
+			roles, infraRoles := &platform.AWS.RolesRef, status.IAM.Roles
+			setIfEmpty(&roles.IngressARN, infraRoles.IngressARN)
+			setIfEmpty(&roles.ImageRegistryARN, infraRoles.ImageRegistryARN)
+			setIfEmpty(&roles.StorageARN, infraRoles.StorageARN)
+			setIfEmpty(&roles.NetworkARN, infraRoles.NetworkARN)
+			setIfEmpty(&roles.KubeCloudControllerARN, infraRoles.KubeCloudControllerARN)
+			setIfEmpty(&roles.NodePoolManagementARN, infraRoles.NodePoolManagementARN)
+			setIfEmpty(&roles.ControlPlaneOperatorARN, infraRoles.ControlPlaneOperatorARN)
+		}
+
+	case platform.Azure != nil && infra.Status.Azure != nil:
+		status := infra.Status.Azure
+		setIfEmpty(&spec.DNS.PublicZoneID, status.PublicZoneID)
+		setIfEmpty(&spec.DNS.PrivateZoneID, status.PrivateZoneID)
+		setIfEmpty(&platform.Azure.VnetID, status.VnetID)
+		setIfEmpty(&platform.Azure.SecurityGroupID, status.SecurityGroupID)
+		setIfEmpty(&platform.Azure.MachineIdentityID, status.MachineIdentityID)
+
+	case platform.PowerVS != nil && infra.Status.PowerVS != nil:
+		status := infra.Status.PowerVS
+		setIfEmpty(&spec.DNS.PublicZoneID, status.CISDomainID)
+		setIfEmpty(&spec.DNS.PrivateZoneID, status.CISDomainID)
+		setIfEmpty(&platform.PowerVS.AccountID, status.AccountID)
+		setIfEmpty(&platform.PowerVS.CISInstanceCRN, status.CISInstanceCRN)
+		setIfEmpty(&platform.PowerVS.ServiceInstanceID, status.ServiceInstanceID)
+		if platform.PowerVS.Subnet == nil {
+			platform.PowerVS.Subnet = status.Subnet.DeepCopy()
+		}
+		if platform.PowerVS.VPC == nil {
+			platform.PowerVS.VPC = status.VPC.DeepCopy()
+		}
+		credentials, infraCredentials := platform.PowerVS, status.Credentials
+		setIfEmpty(&credentials.KubeCloudControllerCreds.Name, infraCredentials.KubeCloudControllerCreds.Name)
+		setIfEmpty(&credentials.NodePoolManagementCreds.Name, infraCredentials.NodePoolManagementCreds.Name)
+		setIfEmpty(&credentials.IngressOperatorCloudCreds.Name, infraCredentials.IngressOperatorCloudCreds.Name)
+		setIfEmpty(&credentials.StorageOperatorCloudCreds.Name, infraCredentials.StorageOperatorCloudCreds.Name)
+		setIfEmpty(&credentials.ImageRegistryOperatorCloudCreds.Name, infraCredentials.ImageRegistryOperatorCloudCreds.Name)
+	}
+}
+
+// infrastructureConflicts returns an error for every field of the spec of the HostedCluster which is set
+// to another resource than the one reported by the infrastructure.
+func infrastructureConflicts(hcluster *hyperv1.HostedCluster, infra *hyperv1.HostedClusterInfrastructure) []error {
+	var errs []error
+	check := func(field, value, infraValue string) {
+		if value != "" && infraValue != "" && value != infraValue {
+			errs = append(errs, fmt.Errorf("%s is %q, not %q", field, value, infraValue))
+		}
+	}
+	spec := &hcluster.Spec
+	platform := &spec.Platform
+
+	check("spec.infraID", spec.InfraID, infra.Spec.InfraID)
+	switch {
+	case platform.AWS != nil && infra.Status.AWS != nil:
+		status := infra.Status.AWS
+		check("spec.platform.aws.region", platform.AWS.Region, infra.Spec.Platform.AWS.Region)
+		check("spec.dns.privateZoneID", spec.DNS.PrivateZoneID, status.PrivateZoneID)
+		if platform.AWS.CloudProviderConfig != nil {
+			check("spec.platform.aws.cloudProviderConfig.vpc", platform.AWS.CloudProviderConfig.VPC, status.VPCID)
+		}
+
+	case platform.Azure != nil && infra.Status.Azure != nil:
+		status := infra.Status.Azure
+		check("spec.platform.azure.location", platform.Azure.Location, infra.Spec.Platform.Azure.Location)
+		check("spec.platform.azure.subscriptionID", platform.Azure.SubscriptionID, status.SubscriptionID)
+		check("spec.platform.azure.resourceGroup", platform.Azure.ResourceGroupName, status.ResourceGroupName)
+		check("spec.platform.azure.vnetID", platform.Azure.VnetID, status.VnetID)
+		check("spec.platform.azure.subnetID", platform.Azure.SubnetID, status.SubnetID)
+		check("spec.platform.azure.securityGroupID", platform.Azure.SecurityGroupID, status.SecurityGroupID)
+
+	case platform.PowerVS != nil && infra.Status.PowerVS != nil:
+		status := infra.Status.PowerVS
+		check("spec.platform.powervs.region", platform.PowerVS.Region, infra.Spec.Platform.PowerVS.Region)
+		check("spec.platform.powervs.zone", platform.PowerVS.Zone, infra.Spec.Platform.PowerVS.Zone)
+		check("spec.platform.powervs.serviceInstanceID", platform.PowerVS.ServiceInstanceID, status.ServiceInstanceID)
+		if platform.PowerVS.VPC != nil {
+			check("spec.platform.powervs.vpc.name", platform.PowerVS.VPC.Name, status.VPC.Name)
+		}
+	}
+	return errs
+}
+
+// enqueueHostedClustersOfInfrastructure enqueues the HostedClusters naming a HostedClusterInfrastructure
+// in their annotation.
+func enqueueHostedClustersOfInfrastructure(c client.Client) func(context.Context, client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		hostedClusters := &hyperv1.HostedClusterList{}
+		if err := c.List(ctx, hostedClusters, client.InNamespace(obj.GetNamespace())); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to list hosted clusters of hostedclusterinfrastructure", "hostedclusterinfrastructure", client.ObjectKeyFromObject(obj))
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, hcluster := range hostedClusters.Items {
+			if hcluster.Annotations[hyperv1.HostedClusterInfrastructureAnnotation] == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&hcluster)})
+			}
+		}
+		return requests
+	}
+}
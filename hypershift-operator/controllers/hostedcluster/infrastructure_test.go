@@ -0,0 +1,157 @@
+package hostedcluster
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func awsHostedClusterInfrastructure(available bool) *hyperv1.HostedClusterInfrastructure {
+	infra := &hyperv1.HostedClusterInfrastructure{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "infra", Generation: 1},
+		Spec: hyperv1.HostedClusterInfrastructureSpec{
+			InfraID: "infra-1",
+			Platform: hyperv1.HostedClusterInfrastructurePlatform{
+				Type: hyperv1.AWSPlatform,
+				AWS:  &hyperv1.AWSInfrastructureSpec{Region: "us-east-1"},
+			},
+		},
+	}
+	if available {
+		infra.Status = hyperv1.HostedClusterInfrastructureStatus{
+			AWS: &hyperv1.AWSInfrastructureStatus{
+				VPCID:         "vpc-1",
+				Zones:         []hyperv1.AWSInfrastructureZone{{Name: "us-east-1a", SubnetID: "subnet-1"}},
+				PublicZoneID:  "Z1",
+				PrivateZoneID: "Z2",
+				IAM: &hyperv1.AWSInfrastructureIAMStatus{
+					IssuerURL: "https://oidc.example.com/infra-1",
+					Roles: hyperv1.AWSRolesRef{
+						IngressARN:            "arn:aws:iam::1:role/infra-1-ingress",
+						NodePoolManagementARN: "arn:aws:iam::1:role/infra-1-node-pool",
+					},
+				},
+			},
+			ObservedGeneration: 1,
+			Conditions: []metav1.Condition{{
+				Type:   string(hyperv1.InfrastructureAvailable),
+				Status: metav1.ConditionTrue,
+				Reason: hyperv1.InfrastructureSyncedReason,
+			}},
+		}
+	}
+	return infra
+}
+
+func TestReconcileHostedClusterInfrastructure(t *testing.T) {
+	testCases := []struct {
+		name            string
+		infra           *hyperv1.HostedClusterInfrastructure
+		spec            hyperv1.HostedClusterSpec
+		expectedSpec    hyperv1.HostedClusterSpec
+		expectedInvalid string
+	}{
+		{
+			name:  "When the infrastructure is not available it should only set the infraID and be invalid",
+			infra: awsHostedClusterInfrastructure(false),
+			spec: hyperv1.HostedClusterSpec{
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform, AWS: &hyperv1.AWSPlatformSpec{Region: "us-east-1"}},
+			},
+			expectedSpec: hyperv1.HostedClusterSpec{
+				InfraID:  "infra-1",
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform, AWS: &hyperv1.AWSPlatformSpec{Region: "us-east-1"}},
+			},
+			expectedInvalid: "hostedclusterinfrastructure infra is not available: it was not synced yet",
+		},
+		{
+			name:  "When the infrastructure is available it should set the unset fields",
+			infra: awsHostedClusterInfrastructure(true),
+			spec: hyperv1.HostedClusterSpec{
+				IssuerURL: defaultIssuerURL,
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform, AWS: &hyperv1.AWSPlatformSpec{
+					Region:   "us-east-1",
+					RolesRef: hyperv1.AWSRolesRef{IngressARN: "arn:aws:iam::1:role/custom-ingress"},
+				}},
+			},
+			expectedSpec: hyperv1.HostedClusterSpec{
+				InfraID:   "infra-1",
+				IssuerURL: "https://oidc.example.com/infra-1",
+				DNS:       hyperv1.DNSSpec{PublicZoneID: "Z1", PrivateZoneID: "Z2"},
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform, AWS: &hyperv1.AWSPlatformSpec{
+					Region: "us-east-1",
+					CloudProviderConfig: &hyperv1.AWSCloudProviderConfig{
+						VPC:    "vpc-1",
+						Zone:   "us-east-1a",
+						Subnet: &hyperv1.AWSResourceReference{ID: ptr.To("subnet-1")},
+					},
+					RolesRef: hyperv1.AWSRolesRef{
+						IngressARN:            "arn:aws:iam::1:role/custom-ingress",
+						NodePoolManagementARN: "arn:aws:iam::1:role/infra-1-node-pool",
+					},
+				}},
+			},
+		},
+		{
+			name:  "When the hosted cluster uses another VPC it should be invalid",
+			infra: awsHostedClusterInfrastructure(true),
+			spec: hyperv1.HostedClusterSpec{
+				InfraID:   "infra-1",
+				IssuerURL: "https://oidc.example.com/infra-1",
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform, AWS: &hyperv1.AWSPlatformSpec{
+					Region:              "us-east-1",
+					CloudProviderConfig: &hyperv1.AWSCloudProviderConfig{VPC: "vpc-2"},
+				}},
+			},
+			expectedInvalid: `hostedcluster does not match hostedclusterinfrastructure infra: spec.platform.aws.cloudProviderConfig.vpc is "vpc-2", not "vpc-1"`,
+		},
+		{
+			name: "When the infrastructure does not exist it should be invalid",
+			spec: hyperv1.HostedClusterSpec{
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform, AWS: &hyperv1.AWSPlatformSpec{Region: "us-east-1"}},
+			},
+			expectedInvalid: "hostedclusterinfrastructure infra not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			hcluster := &hyperv1.HostedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "clusters",
+					Name:        "example",
+					Annotations: map[string]string{hyperv1.HostedClusterInfrastructureAnnotation: "infra"},
+				},
+				Spec: tc.spec,
+			}
+			objects := []client.Object{hcluster}
+			if tc.infra != nil {
+				objects = append(objects, tc.infra)
+			}
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects...).Build()
+			r := &HostedClusterReconciler{Client: c}
+
+			g.Expect(r.reconcileHostedClusterInfrastructure(ctx, hcluster)).To(Succeed())
+			if tc.expectedSpec.Platform.Type != "" {
+				g.Expect(c.Get(ctx, client.ObjectKeyFromObject(hcluster), hcluster)).To(Succeed())
+				g.Expect(hcluster.Spec).To(Equal(tc.expectedSpec))
+			}
+
+			err := r.validateHostedClusterInfrastructure(ctx, hcluster)
+			if tc.expectedInvalid == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(tc.expectedInvalid))
+			}
+		})
+	}
+}
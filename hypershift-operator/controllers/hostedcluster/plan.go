@@ -0,0 +1,305 @@
+package hostedcluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/clusterpolicy"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/imageprovider"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/kcm"
+	cpomanifests "github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/scheduler"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests/controlplaneoperator"
+	"github.com/openshift/hypershift/support/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// AllControlPlaneComponents is the component listed for changes which roll out every control plane component.
+const AllControlPlaneComponents = "*"
+
+// ControlPlaneChange is a HostedControlPlane field which changes with a proposed HostedCluster.
+type ControlPlaneChange struct {
+	// Field is the path of the changed field of the HostedControlPlane.
+	Field string
+	// Components are the rendered control plane components whose pod template changes when
+	// only this field is set to its proposed value.
+	Components []string
+	// EstimatedComponents are the components which the plan does not render and which are known
+	// to consume the field. AllControlPlaneComponents stands for every component which is not rendered.
+	EstimatedComponents []string
+	// UnknownImpact is true when the impact of the field on the components which are not rendered is not known.
+	UnknownImpact bool
+	// NodePoolConfig is true when the field is rendered by the control plane into the core
+	// ignition config of the NodePools, which then roll out once the control plane has updated it.
+	NodePoolConfig bool
+}
+
+type controlPlaneImpact struct {
+	components     []string
+	nodePoolConfig bool
+}
+
+type componentRenderer func(ctx context.Context, hcp *hyperv1.HostedControlPlane, images *imageprovider.ReleaseImageProvider) (*corev1.PodTemplateSpec, error)
+
+// componentRenderers render the pod template of the control plane components which only depend
+// on the HostedControlPlane, the same way the control plane operator does.
+var componentRenderers = map[string]componentRenderer{
+	cpomanifests.SchedulerDeployment("").Name:               renderKubeScheduler,
+	cpomanifests.KCMDeployment("").Name:                     renderKubeControllerManager,
+	cpomanifests.ClusterPolicyControllerDeployment("").Name: renderClusterPolicyController,
+}
+
+// IsRenderedComponent returns true when the plan renders the pod template of the component
+// rather than estimating its rollout.
+func IsRenderedComponent(component string) bool {
+	_, rendered := componentRenderers[component]
+	return rendered
+}
+
+var (
+	allComponents = []string{AllControlPlaneComponents}
+	// tlsProfileConsumers are the components which are not rendered and serve with the TLS security
+	// profile of the APIServer config.
+	tlsProfileConsumers = []string{
+		"kube-apiserver", "openshift-apiserver", "openshift-oauth-apiserver", "oauth-openshift",
+		"openshift-controller-manager", "openshift-route-controller-manager",
+	}
+)
+
+// controlPlaneImpactByField maps HostedControlPlane spec fields to the components which are not
+// rendered by the plan and consume them. It only estimates their rollout, the rendered components
+// are compared instead. Configuration is listed per API so a change to a single cluster config
+// only lists the components which consume that config.
+var controlPlaneImpactByField = map[string]controlPlaneImpact{
+	"spec.releaseImage":                                     {components: allComponents},
+	"spec.controlPlaneReleaseImage":                         {components: allComponents},
+	"spec.pullSecret":                                       {components: allComponents},
+	"spec.issuerURL":                                        {components: []string{"kube-apiserver"}},
+	"spec.serviceAccountSigningKey":                         {components: []string{"kube-apiserver"}},
+	"spec.networking":                                       {components: []string{"kube-apiserver", "cluster-network-operator"}},
+	"spec.sshKey":                                           {nodePoolConfig: true},
+	"spec.fips":                                             {components: allComponents, nodePoolConfig: true},
+	"spec.platform":                                         {components: allComponents},
+	"spec.controllerAvailabilityPolicy":                     {components: allComponents},
+	"spec.infrastructureAvailabilityPolicy":                 {},
+	"spec.kubeconfig":                                       {components: []string{"kube-apiserver"}},
+	"spec.services":                                         {components: []string{"kube-apiserver", "oauth-openshift", "ignition-server", "konnectivity-agent"}},
+	"spec.auditWebhook":                                     {components: []string{"kube-apiserver"}},
+	"spec.etcd":                                             {components: []string{"etcd", "kube-apiserver"}},
+	"spec.imageContentSources":                              {components: []string{"openshift-apiserver", "openshift-controller-manager", "ignition-server"}, nodePoolConfig: true},
+	"spec.additionalTrustBundle":                            {components: allComponents},
+	"spec.secretEncryption":                                 {components: []string{"kube-apiserver", "openshift-apiserver", "openshift-oauth-apiserver"}},
+	"spec.autoscaling":                                      {components: []string{"cluster-autoscaler"}},
+	"spec.nodeSelector":                                     {components: allComponents},
+	"spec.tolerations":                                      {components: allComponents},
+	"spec.configuration.apiServer":                          {components: tlsProfileConsumers},
+	"spec.configuration.authentication":                     {components: []string{"kube-apiserver", "openshift-oauth-apiserver", "oauth-openshift"}},
+	"spec.configuration.oauth":                              {components: []string{"oauth-openshift"}},
+	"spec.configuration.featureGate":                        {components: allComponents},
+	"spec.configuration.image":                              {components: []string{"kube-apiserver", "openshift-apiserver", "openshift-controller-manager"}},
+	"spec.configuration.network":                            {components: []string{"kube-apiserver", "cluster-network-operator"}},
+	"spec.configuration.proxy":                              {components: allComponents},
+	"spec.configuration.scheduler":                          {},
+	"spec.configuration.ingress":                            {components: []string{"ingress-operator", "oauth-openshift"}},
+	"metadata.annotations." + hyperv1.RestartDateAnnotation: {components: allComponents},
+}
+
+// PlanControlPlaneChanges renders the HostedControlPlane of the current and the proposed
+// HostedCluster the same way reconcile does and returns the fields which change. For each
+// field, the components which can be rendered from the HostedControlPlane alone are rendered
+// with only that field changed, the rollout of the other components is estimated.
+func PlanControlPlaneChanges(ctx context.Context, current, proposed *hyperv1.HostedCluster) ([]ControlPlaneChange, error) {
+	currentHCP, err := renderHostedControlPlane(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the HostedControlPlane of the current spec: %w", err)
+	}
+	proposedHCP, err := renderHostedControlPlane(proposed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the HostedControlPlane of the proposed spec: %w", err)
+	}
+
+	var fields []string
+	for key := range sets.KeySet(currentHCP.Annotations).Union(sets.KeySet(proposedHCP.Annotations)) {
+		if currentHCP.Annotations[key] != proposedHCP.Annotations[key] {
+			fields = append(fields, "metadata.annotations."+key)
+		}
+	}
+
+	currentSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&currentHCP.Spec)
+	if err != nil {
+		return nil, err
+	}
+	proposedSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&proposedHCP.Spec)
+	if err != nil {
+		return nil, err
+	}
+	fields = append(fields, changedFields("spec", currentSpec, proposedSpec)...)
+	sort.Strings(fields)
+
+	currentTemplates, err := renderComponents(ctx, currentHCP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the control plane components of the current spec: %w", err)
+	}
+	changes := make([]ControlPlaneChange, 0, len(fields))
+	for _, field := range fields {
+		fieldHCP, err := withProposedField(currentHCP, proposedHCP, currentSpec, proposedSpec, field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply the proposed %s: %w", field, err)
+		}
+		fieldTemplates, err := renderComponents(ctx, fieldHCP)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render the control plane components with the proposed %s: %w", field, err)
+		}
+		var components []string
+		for component, template := range fieldTemplates {
+			if !equality.Semantic.DeepEqual(currentTemplates[component], template) {
+				components = append(components, component)
+			}
+		}
+		sort.Strings(components)
+
+		impact, known := controlPlaneImpactByField[field]
+		changes = append(changes, ControlPlaneChange{
+			Field:               field,
+			Components:          components,
+			EstimatedComponents: impact.components,
+			UnknownImpact:       !known,
+			NodePoolConfig:      impact.nodePoolConfig,
+		})
+	}
+	return changes, nil
+}
+
+func renderHostedControlPlane(hcluster *hyperv1.HostedCluster) (*hyperv1.HostedControlPlane, error) {
+	hcp := controlplaneoperator.HostedControlPlane("", hcluster.Name)
+	// Autoscaling depends on the NodePools, not on the HostedCluster spec being planned.
+	if err := reconcileHostedControlPlane(hcp, hcluster, false); err != nil {
+		return nil, err
+	}
+	return hcp, nil
+}
+
+// withProposedField returns the current HostedControlPlane with only the given field set to its proposed value.
+func withProposedField(current, proposed *hyperv1.HostedControlPlane, currentSpec, proposedSpec map[string]interface{}, field string) (*hyperv1.HostedControlPlane, error) {
+	hcp := current.DeepCopy()
+	if key, isAnnotation := strings.CutPrefix(field, "metadata.annotations."); isAnnotation {
+		value, found := proposed.Annotations[key]
+		if !found {
+			delete(hcp.Annotations, key)
+			return hcp, nil
+		}
+		if hcp.Annotations == nil {
+			hcp.Annotations = map[string]string{}
+		}
+		hcp.Annotations[key] = value
+		return hcp, nil
+	}
+
+	spec := runtime.DeepCopyJSON(currentSpec)
+	path := strings.Split(strings.TrimPrefix(field, "spec."), ".")
+	value, found, err := unstructured.NestedFieldCopy(proposedSpec, path...)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		if err := unstructured.SetNestedField(spec, value, path...); err != nil {
+			return nil, err
+		}
+	} else {
+		unstructured.RemoveNestedField(spec, path...)
+	}
+	hcp.Spec = hyperv1.HostedControlPlaneSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &hcp.Spec); err != nil {
+		return nil, err
+	}
+	return hcp, nil
+}
+
+// renderComponents renders the pod template of every component in componentRenderers. The
+// images are named after the control plane release image, so they change with it.
+func renderComponents(ctx context.Context, hcp *hyperv1.HostedControlPlane) (map[string]*corev1.PodTemplateSpec, error) {
+	releaseImage := hcp.Spec.ReleaseImage
+	if hcp.Spec.ControlPlaneReleaseImage != nil {
+		releaseImage = *hcp.Spec.ControlPlaneReleaseImage
+	}
+	templates := make(map[string]*corev1.PodTemplateSpec, len(componentRenderers))
+	for component, render := range componentRenderers {
+		images := imageprovider.NewFromImages(map[string]string{
+			"hyperkube":                      releaseImage + "/hyperkube",
+			"token-minter":                   releaseImage + "/token-minter",
+			"cluster-policy-controller":      releaseImage + "/cluster-policy-controller",
+			util.AvailabilityProberImageName: "availability-prober",
+		})
+		template, err := render(ctx, hcp, images)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", component, err)
+		}
+		templates[component] = template
+	}
+	return templates, nil
+}
+
+func renderKubeScheduler(ctx context.Context, hcp *hyperv1.HostedControlPlane, images *imageprovider.ReleaseImageProvider) (*corev1.PodTemplateSpec, error) {
+	params := scheduler.NewKubeSchedulerParams(ctx, hcp, images, false)
+	schedulerConfig := cpomanifests.SchedulerConfig(hcp.Namespace)
+	if err := scheduler.ReconcileConfig(schedulerConfig, params.OwnerRef, params.SchedulerProfile()); err != nil {
+		return nil, err
+	}
+	deployment := cpomanifests.SchedulerDeployment(hcp.Namespace)
+	if err := scheduler.ReconcileDeployment(deployment, params.OwnerRef, params.DeploymentConfig, params.HyperkubeImage, params.FeatureGates(), params.SchedulerPolicy(), params.AvailabilityProberImage, params.CipherSuites(), params.MinTLSVersion(), params.DisableProfiling, schedulerConfig, hcp.Spec.Platform.Type); err != nil {
+		return nil, err
+	}
+	return &deployment.Spec.Template, nil
+}
+
+func renderKubeControllerManager(ctx context.Context, hcp *hyperv1.HostedControlPlane, images *imageprovider.ReleaseImageProvider) (*corev1.PodTemplateSpec, error) {
+	params := kcm.NewKubeControllerManagerParams(ctx, hcp, images, false)
+	// The CAs and the service account signing key are generated by the control plane, not set in the spec.
+	rootCA := cpomanifests.RootCAConfigMap(hcp.Namespace)
+	serviceSigner := cpomanifests.ServiceAccountSigningKeySecret(hcp.Namespace)
+	kcmConfig := cpomanifests.KCMConfig(hcp.Namespace)
+	if err := kcm.ReconcileConfig(kcmConfig, nil, params.OwnerRef); err != nil {
+		return nil, err
+	}
+	deployment := cpomanifests.KCMDeployment(hcp.Namespace)
+	if err := kcm.ReconcileDeployment(deployment, kcmConfig, rootCA, nil, serviceSigner, params, hcp.Spec.Platform.Type); err != nil {
+		return nil, err
+	}
+	return &deployment.Spec.Template, nil
+}
+
+func renderClusterPolicyController(ctx context.Context, hcp *hyperv1.HostedControlPlane, images *imageprovider.ReleaseImageProvider) (*corev1.PodTemplateSpec, error) {
+	params := clusterpolicy.NewClusterPolicyControllerParams(hcp, images, false)
+	deployment := cpomanifests.ClusterPolicyControllerDeployment(hcp.Namespace)
+	if err := clusterpolicy.ReconcileDeployment(deployment, params.OwnerRef, params.Image, params.DeploymentConfig, params.AvailabilityProberImage, hcp.Spec.Platform.Type); err != nil {
+		return nil, err
+	}
+	return &deployment.Spec.Template, nil
+}
+
+// changedFields returns the paths of the top level fields which differ, descending into the
+// configuration so each cluster config is reported on its own.
+func changedFields(path string, current, proposed map[string]interface{}) []string {
+	var fields []string
+	for key := range sets.KeySet(current).Union(sets.KeySet(proposed)) {
+		fieldPath := path + "." + key
+		if equality.Semantic.DeepEqual(current[key], proposed[key]) {
+			continue
+		}
+		if fieldPath == "spec.configuration" {
+			currentConfig, _ := current[key].(map[string]interface{})
+			proposedConfig, _ := proposed[key].(map[string]interface{})
+			fields = append(fields, changedFields(fieldPath, currentConfig, proposedConfig)...)
+			continue
+		}
+		fields = append(fields, fieldPath)
+	}
+	return fields
+}
@@ -0,0 +1,174 @@
+package hostedcluster
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestPlanControlPlaneChanges(t *testing.T) {
+	current := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"},
+		Spec: hyperv1.HostedClusterSpec{
+			Release:  hyperv1.Release{Image: "release:4.15.2"},
+			Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform},
+			Configuration: &hyperv1.ClusterConfiguration{
+				Ingress: &configv1.IngressSpec{Domain: "apps.example.com"},
+			},
+		},
+	}
+	renderedComponents := []string{"cluster-policy-controller", "kube-controller-manager", "kube-scheduler"}
+
+	testCases := []struct {
+		name     string
+		mutate   func(*hyperv1.HostedCluster)
+		expected []ControlPlaneChange
+	}{
+		{
+			name:     "When nothing changes it should plan no changes",
+			mutate:   func(*hyperv1.HostedCluster) {},
+			expected: []ControlPlaneChange{},
+		},
+		{
+			name: "When the release changes it should roll out all components",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.Release.Image = "release:4.15.5"
+			},
+			expected: []ControlPlaneChange{
+				{Field: "spec.releaseImage", Components: renderedComponents, EstimatedComponents: []string{AllControlPlaneComponents}},
+			},
+		},
+		{
+			name: "When a single cluster config changes it should only list the components consuming it",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.Configuration.OAuth = &configv1.OAuthSpec{
+					IdentityProviders: []configv1.IdentityProvider{{Name: "htpasswd"}},
+				}
+			},
+			expected: []ControlPlaneChange{
+				{Field: "spec.configuration.oauth", EstimatedComponents: []string{"oauth-openshift"}},
+			},
+		},
+		{
+			name: "When the scheduler profile changes it should render the kube-scheduler rollout",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.Configuration.Scheduler = &configv1.SchedulerSpec{Profile: configv1.HighNodeUtilization}
+			},
+			expected: []ControlPlaneChange{
+				{Field: "spec.configuration.scheduler", Components: []string{"kube-scheduler"}},
+			},
+		},
+		{
+			name: "When the TLS security profile changes it should render the rollout of the components serving with it",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.Configuration.APIServer = &configv1.APIServerSpec{
+					TLSSecurityProfile: &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+				}
+			},
+			expected: []ControlPlaneChange{
+				{Field: "spec.configuration.apiServer", Components: []string{"kube-controller-manager", "kube-scheduler"}, EstimatedComponents: tlsProfileConsumers},
+			},
+		},
+		{
+			name: "When fips changes it should flag the NodePool config",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.FIPS = true
+			},
+			expected: []ControlPlaneChange{
+				{Field: "spec.fips", EstimatedComponents: []string{AllControlPlaneComponents}, NodePoolConfig: true},
+			},
+		},
+		{
+			name: "When the ssh key changes it should only flag the NodePool config",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.SSHKey = corev1.LocalObjectReference{Name: "ssh-key"}
+			},
+			expected: []ControlPlaneChange{
+				{Field: "spec.sshKey", NodePoolConfig: true},
+			},
+		},
+		{
+			name: "When a restart is requested it should roll out all components",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Annotations = map[string]string{hyperv1.RestartDateAnnotation: "2024-01-01T00:00:00Z"}
+			},
+			expected: []ControlPlaneChange{
+				{Field: "metadata.annotations." + hyperv1.RestartDateAnnotation, Components: renderedComponents, EstimatedComponents: []string{AllControlPlaneComponents}},
+			},
+		},
+		{
+			name: "When a field with an unknown impact changes it should flag it",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.Channel = "stable-4.15"
+			},
+			expected: []ControlPlaneChange{
+				{Field: "spec.channel", UnknownImpact: true},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			proposed := current.DeepCopy()
+			tc.mutate(proposed)
+
+			changes, err := PlanControlPlaneChanges(context.Background(), current, proposed)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(changes).To(Equal(tc.expected))
+		})
+	}
+}
+
+// unknownImpactFields are the HostedControlPlane spec fields which are deliberately left out of
+// controlPlaneImpactByField, the plan reports them with an unknown impact.
+var unknownImpactFields = sets.New[string](
+	"spec.updateService",
+	"spec.channel",
+	"spec.clusterID",
+	"spec.infraID",
+	"spec.dns",
+	"spec.configuration.operatorhub",
+	"spec.pausedUntil",
+	"spec.olmCatalogPlacement",
+	"spec.controlPlaneComponentOverrides",
+)
+
+func TestControlPlaneImpactByField(t *testing.T) {
+	g := NewWithT(t)
+	var fields []string
+	for _, field := range jsonFields(reflect.TypeOf(hyperv1.HostedControlPlaneSpec{})) {
+		if field == "configuration" {
+			for _, config := range jsonFields(reflect.TypeOf(hyperv1.ClusterConfiguration{})) {
+				fields = append(fields, "spec.configuration."+config)
+			}
+			continue
+		}
+		fields = append(fields, "spec."+field)
+	}
+	var missing []string
+	for _, field := range fields {
+		if _, listed := controlPlaneImpactByField[field]; !listed && !unknownImpactFields.Has(field) {
+			missing = append(missing, field)
+		}
+	}
+	g.Expect(missing).To(BeEmpty(), "fields missing from controlPlaneImpactByField")
+}
+
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,210 @@
+package hostedcluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests/controlplaneoperator"
+	"github.com/openshift/hypershift/support/certs"
+	hyperutil "github.com/openshift/hypershift/support/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultServiceAccountSigningKeyRotationOverlap = 24 * time.Hour
+	// serviceAccountKeyPublishRetryInterval is how often a rotation waiting for the new public key
+	// to be published checks again.
+	serviceAccountKeyPublishRetryInterval = time.Minute
+
+	// The state of the rotation is kept on the control plane signing key secret, along with
+	// the ServiceAccountSigningKeyRotationAnnotation of the last rotation.
+	serviceAccountKeyRotationPhaseAnnotation     = "hypershift.openshift.io/service-account-signing-key-rotation-phase"
+	serviceAccountKeyRotationPhaseTimeAnnotation = "hypershift.openshift.io/service-account-signing-key-rotation-phase-time"
+
+	serviceAccountKeyRotationPublishing = "Publishing"
+	serviceAccountKeyRotationSigning    = "Signing"
+	serviceAccountKeyRotationComplete   = "Complete"
+)
+
+// reconcileServiceAccountSigningKeyRotation advances a rotation of the service account signing key
+// requested through the ServiceAccountSigningKeyRotationAnnotation and reports it in the
+// ServiceAccountSigningKeyRotating condition. It returns when the next step of the rotation is due.
+func (r *HostedClusterReconciler) reconcileServiceAccountSigningKeyRotation(ctx context.Context, hcluster *hyperv1.HostedCluster, controlPlaneNamespace string) (time.Duration, error) {
+	request := hcluster.Annotations[hyperv1.ServiceAccountSigningKeyRotationAnnotation]
+	if request == "" {
+		return 0, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               string(hyperv1.ServiceAccountSigningKeyRotating),
+		Status:             metav1.ConditionFalse,
+		Reason:             hyperv1.InvalidConfigurationReason,
+		ObservedGeneration: hcluster.Generation,
+	}
+	var requeueAfter time.Duration
+	overlap, err := serviceAccountSigningKeyRotationOverlap(hcluster)
+	switch {
+	case hcluster.Spec.ServiceAccountSigningKey != nil && hcluster.Spec.ServiceAccountSigningKey.Name != "":
+		condition.Message = "Only service account signing keys generated by HyperShift can be rotated, the key set in spec.serviceAccountSigningKey must be rotated where its public keys are published"
+	case err != nil:
+		condition.Message = err.Error()
+	default:
+		secret := controlplaneoperator.ServiceAccountSigningKeySecret(controlPlaneNamespace)
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				// The control plane operator has not generated the key yet.
+				return time.Minute, nil
+			}
+			return 0, fmt.Errorf("failed to get service account signing key secret: %w", err)
+		}
+		// The new key may only sign tokens once the OIDC documents with its public key are published,
+		// otherwise consumers of the issuer reject the tokens it signs.
+		published := !hasManagedOIDCDocuments(hcluster) ||
+			secret.Annotations[oidcDocumentsPublicKeysHashAnnotation] == hyperutil.HashSimple(secret.Data[serviceSignerPublicKey])
+		original := secret.DeepCopy()
+		if requeueAfter, err = rotateServiceAccountSigningKey(secret, request, overlap, published, r.Clock.Now()); err != nil {
+			return 0, err
+		}
+		if !equality.Semantic.DeepEqual(original, secret) {
+			if err := r.Client.Update(ctx, secret); err != nil {
+				return 0, fmt.Errorf("failed to update service account signing key secret: %w", err)
+			}
+			ctrl.LoggerFrom(ctx).Info("Advanced service account signing key rotation", "rotation", request, "phase", secret.Annotations[serviceAccountKeyRotationPhaseAnnotation])
+		}
+		condition = serviceAccountSigningKeyRotationCondition(secret, overlap, published, r.Clock.Now(), hcluster.Generation)
+	}
+
+	if existing := meta.FindStatusCondition(hcluster.Status.Conditions, condition.Type); existing == nil ||
+		existing.Status != condition.Status || existing.Reason != condition.Reason || existing.Message != condition.Message || existing.ObservedGeneration != condition.ObservedGeneration {
+		meta.SetStatusCondition(&hcluster.Status.Conditions, condition)
+		if err := r.Client.Status().Update(ctx, hcluster); err != nil {
+			return 0, fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+	return requeueAfter, nil
+}
+
+func serviceAccountSigningKeyRotationOverlap(hcluster *hyperv1.HostedCluster) (time.Duration, error) {
+	value, ok := hcluster.Annotations[hyperv1.ServiceAccountSigningKeyRotationOverlapAnnotation]
+	if !ok {
+		return defaultServiceAccountSigningKeyRotationOverlap, nil
+	}
+	overlap, err := time.ParseDuration(value)
+	if err != nil || overlap <= 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q, must be a positive duration", hyperv1.ServiceAccountSigningKeyRotationOverlapAnnotation, value)
+	}
+	return overlap, nil
+}
+
+// rotateServiceAccountSigningKey advances the rotation state kept on the signing key secret:
+//   - a new request adds a new key and publishes its public key next to the current one,
+//   - after the overlap, once the public keys are published, the new key becomes the signing key,
+//   - after another overlap the public key of the old key is retired.
+//
+// It returns the time left until the next step.
+func rotateServiceAccountSigningKey(secret *corev1.Secret, request string, overlap time.Duration, published bool, now time.Time) (time.Duration, error) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	phase := secret.Annotations[serviceAccountKeyRotationPhaseAnnotation]
+	if phase == serviceAccountKeyRotationPublishing || phase == serviceAccountKeyRotationSigning {
+		phaseTime, err := time.Parse(time.RFC3339, secret.Annotations[serviceAccountKeyRotationPhaseTimeAnnotation])
+		if err != nil {
+			return 0, fmt.Errorf("invalid service account signing key rotation time: %w", err)
+		}
+		if remaining := phaseTime.Add(overlap).Sub(now); remaining > 0 {
+			return remaining, nil
+		}
+	}
+
+	switch phase {
+	case serviceAccountKeyRotationPublishing:
+		if !published {
+			return serviceAccountKeyPublishRetryInterval, nil
+		}
+		nextKey, hasKey := secret.Data[controlplaneoperator.ServiceSignerNextPrivateKey]
+		if !hasKey {
+			return 0, fmt.Errorf("service account signing key secret is missing the new key %s", controlplaneoperator.ServiceSignerNextPrivateKey)
+		}
+		secret.Data[controlplaneoperator.ServiceSignerPrivateKey] = nextKey
+		delete(secret.Data, controlplaneoperator.ServiceSignerNextPrivateKey)
+		setServiceAccountKeyRotationPhase(secret, serviceAccountKeyRotationSigning, now)
+		return overlap, nil
+
+	case serviceAccountKeyRotationSigning:
+		privateKey, err := certs.PemToPrivateKey(secret.Data[controlplaneoperator.ServiceSignerPrivateKey])
+		if err != nil {
+			return 0, fmt.Errorf("cannot decode service account signing key: %w", err)
+		}
+		publicKey, err := certs.PublicKeyToPem(&privateKey.PublicKey)
+		if err != nil {
+			return 0, fmt.Errorf("cannot serialize service account public key: %w", err)
+		}
+		secret.Data[controlplaneoperator.ServiceSignerPublicKey] = publicKey
+		setServiceAccountKeyRotationPhase(secret, serviceAccountKeyRotationComplete, now)
+		return 0, nil
+
+	default:
+		if secret.Annotations[hyperv1.ServiceAccountSigningKeyRotationAnnotation] == request {
+			return 0, nil
+		}
+		key, err := certs.PrivateKey()
+		if err != nil {
+			return 0, fmt.Errorf("failed generating a private key: %w", err)
+		}
+		publicKey, err := certs.PublicKeyToPem(&key.PublicKey)
+		if err != nil {
+			return 0, fmt.Errorf("failed to generate public key from private key: %w", err)
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		currentPublicKeys := bytes.TrimSpace(secret.Data[controlplaneoperator.ServiceSignerPublicKey])
+		secret.Data[controlplaneoperator.ServiceSignerNextPrivateKey] = certs.PrivateKeyToPem(key)
+		secret.Data[controlplaneoperator.ServiceSignerPublicKey] = bytes.Join([][]byte{currentPublicKeys, publicKey}, []byte("\n"))
+		secret.Annotations[hyperv1.ServiceAccountSigningKeyRotationAnnotation] = request
+		setServiceAccountKeyRotationPhase(secret, serviceAccountKeyRotationPublishing, now)
+		return overlap, nil
+	}
+}
+
+func setServiceAccountKeyRotationPhase(secret *corev1.Secret, phase string, now time.Time) {
+	secret.Annotations[serviceAccountKeyRotationPhaseAnnotation] = phase
+	secret.Annotations[serviceAccountKeyRotationPhaseTimeAnnotation] = now.UTC().Format(time.RFC3339)
+}
+
+func serviceAccountSigningKeyRotationCondition(secret *corev1.Secret, overlap time.Duration, published bool, now time.Time, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               string(hyperv1.ServiceAccountSigningKeyRotating),
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+	}
+	request := secret.Annotations[hyperv1.ServiceAccountSigningKeyRotationAnnotation]
+	phaseTime, _ := time.Parse(time.RFC3339, secret.Annotations[serviceAccountKeyRotationPhaseTimeAnnotation])
+	nextStep := phaseTime.Add(overlap).UTC().Format(time.RFC3339)
+	switch secret.Annotations[serviceAccountKeyRotationPhaseAnnotation] {
+	case serviceAccountKeyRotationPublishing:
+		condition.Reason = hyperv1.PublishingNewServiceAccountKeyReason
+		condition.Message = fmt.Sprintf("Rotation %q publishes the new public key, service account tokens are signed with the new key from %s", request, nextStep)
+		if !published && !now.Before(phaseTime.Add(overlap)) {
+			condition.Message = fmt.Sprintf("Rotation %q waits for the OIDC documents with the new public key to be published before signing service account tokens with the new key", request)
+		}
+	case serviceAccountKeyRotationSigning:
+		condition.Reason = hyperv1.SigningWithNewServiceAccountKeyReason
+		condition.Message = fmt.Sprintf("Rotation %q signs service account tokens with the new key, the old public key is retired at %s", request, nextStep)
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hyperv1.AsExpectedReason
+		condition.Message = fmt.Sprintf("Rotation %q is complete", request)
+	}
+	return condition
+}
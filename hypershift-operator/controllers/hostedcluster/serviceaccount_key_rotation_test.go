@@ -0,0 +1,235 @@
+package hostedcluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests/controlplaneoperator"
+	"github.com/openshift/hypershift/support/api"
+	"github.com/openshift/hypershift/support/certs"
+	hyperutil "github.com/openshift/hypershift/support/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testServiceAccountSigningKeySecret(t *testing.T, namespace string) *corev1.Secret {
+	key, err := certs.PrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	publicKey, err := certs.PublicKeyToPem(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to serialize public key: %v", err)
+	}
+	secret := controlplaneoperator.ServiceAccountSigningKeySecret(namespace)
+	secret.Data = map[string][]byte{
+		controlplaneoperator.ServiceSignerPrivateKey: certs.PrivateKeyToPem(key),
+		controlplaneoperator.ServiceSignerPublicKey:  publicKey,
+	}
+	return secret
+}
+
+func countPEMBlocks(data []byte) int {
+	count := 0
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		count++
+	}
+	return count
+}
+
+func TestRotateServiceAccountSigningKey(t *testing.T) {
+	g := NewWithT(t)
+	overlap := time.Hour
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	secret := testServiceAccountSigningKeySecret(t, "clusters-hc")
+	originalKey := secret.Data[controlplaneoperator.ServiceSignerPrivateKey]
+	originalPublicKey := secret.Data[controlplaneoperator.ServiceSignerPublicKey]
+
+	// A new request publishes a new public key next to the current one.
+	requeueAfter, err := rotateServiceAccountSigningKey(secret, "1", overlap, true, start)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requeueAfter).To(Equal(overlap))
+	g.Expect(secret.Annotations[serviceAccountKeyRotationPhaseAnnotation]).To(Equal(serviceAccountKeyRotationPublishing))
+	g.Expect(secret.Data[controlplaneoperator.ServiceSignerPrivateKey]).To(Equal(originalKey))
+	g.Expect(secret.Data).To(HaveKey(controlplaneoperator.ServiceSignerNextPrivateKey))
+	g.Expect(countPEMBlocks(secret.Data[controlplaneoperator.ServiceSignerPublicKey])).To(Equal(2))
+	g.Expect(bytes.HasPrefix(secret.Data[controlplaneoperator.ServiceSignerPublicKey], bytes.TrimSpace(originalPublicKey))).To(BeTrue())
+	nextKey := secret.Data[controlplaneoperator.ServiceSignerNextPrivateKey]
+
+	// Nothing changes before the overlap has passed.
+	requeueAfter, err = rotateServiceAccountSigningKey(secret, "1", overlap, true, start.Add(15*time.Minute))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requeueAfter).To(Equal(45 * time.Minute))
+	g.Expect(secret.Annotations[serviceAccountKeyRotationPhaseAnnotation]).To(Equal(serviceAccountKeyRotationPublishing))
+
+	// The new key does not sign tokens until the public keys have been published.
+	requeueAfter, err = rotateServiceAccountSigningKey(secret, "1", overlap, false, start.Add(overlap))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requeueAfter).To(Equal(serviceAccountKeyPublishRetryInterval))
+	g.Expect(secret.Annotations[serviceAccountKeyRotationPhaseAnnotation]).To(Equal(serviceAccountKeyRotationPublishing))
+	g.Expect(secret.Data[controlplaneoperator.ServiceSignerPrivateKey]).To(Equal(originalKey))
+
+	// After the overlap tokens are signed with the new key.
+	requeueAfter, err = rotateServiceAccountSigningKey(secret, "1", overlap, true, start.Add(overlap))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requeueAfter).To(Equal(overlap))
+	g.Expect(secret.Annotations[serviceAccountKeyRotationPhaseAnnotation]).To(Equal(serviceAccountKeyRotationSigning))
+	g.Expect(secret.Data[controlplaneoperator.ServiceSignerPrivateKey]).To(Equal(nextKey))
+	g.Expect(secret.Data).ToNot(HaveKey(controlplaneoperator.ServiceSignerNextPrivateKey))
+	g.Expect(countPEMBlocks(secret.Data[controlplaneoperator.ServiceSignerPublicKey])).To(Equal(2))
+
+	// After another overlap the old public key is retired.
+	requeueAfter, err = rotateServiceAccountSigningKey(secret, "1", overlap, true, start.Add(2*overlap))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requeueAfter).To(BeZero())
+	g.Expect(secret.Annotations[serviceAccountKeyRotationPhaseAnnotation]).To(Equal(serviceAccountKeyRotationComplete))
+	g.Expect(countPEMBlocks(secret.Data[controlplaneoperator.ServiceSignerPublicKey])).To(Equal(1))
+	g.Expect(secret.Data[controlplaneoperator.ServiceSignerPublicKey]).ToNot(Equal(originalPublicKey))
+
+	// The same request is not rotated again, a new one starts a new rotation.
+	completed := secret.DeepCopy()
+	_, err = rotateServiceAccountSigningKey(secret, "1", overlap, true, start.Add(3*overlap))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(secret).To(Equal(completed))
+	_, err = rotateServiceAccountSigningKey(secret, "2", overlap, true, start.Add(3*overlap))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(secret.Annotations[serviceAccountKeyRotationPhaseAnnotation]).To(Equal(serviceAccountKeyRotationPublishing))
+}
+
+func TestReconcileServiceAccountSigningKeyRotation(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// publishing returns a signing key secret whose new public key was added an overlap ago,
+	// with the OIDC documents published for publishedKeys.
+	publishing := func(publishedKeys func(*corev1.Secret) []byte) func(*testing.T, *corev1.Secret) {
+		return func(t *testing.T, secret *corev1.Secret) {
+			if _, err := rotateServiceAccountSigningKey(secret, "1", defaultServiceAccountSigningKeyRotationOverlap, true, now.Add(-defaultServiceAccountSigningKeyRotationOverlap)); err != nil {
+				t.Fatalf("failed to start rotation: %v", err)
+			}
+			secret.Annotations[oidcDocumentsPublicKeysHashAnnotation] = hyperutil.HashSimple(publishedKeys(secret))
+		}
+	}
+
+	testCases := []struct {
+		name                string
+		annotations         map[string]string
+		platform            hyperv1.PlatformType
+		serviceAccountKey   *corev1.LocalObjectReference
+		mutateSecret        func(*testing.T, *corev1.Secret)
+		expectedReason      string
+		expectedStatus      metav1.ConditionStatus
+		expectedPhase       string
+		expectedRequeue     time.Duration
+		expectSecretChanged bool
+	}{
+		{
+			name:            "When no rotation is requested it should not set the condition",
+			expectedRequeue: 0,
+		},
+		{
+			name:                "When a rotation is requested it should publish a new key",
+			annotations:         map[string]string{hyperv1.ServiceAccountSigningKeyRotationAnnotation: "1"},
+			expectedReason:      hyperv1.PublishingNewServiceAccountKeyReason,
+			expectedStatus:      metav1.ConditionTrue,
+			expectedRequeue:     defaultServiceAccountSigningKeyRotationOverlap,
+			expectSecretChanged: true,
+		},
+		{
+			name:        "When the overlap has passed but publishing the new public key failed it should not sign with the new key",
+			annotations: map[string]string{hyperv1.ServiceAccountSigningKeyRotationAnnotation: "1"},
+			platform:    hyperv1.AWSPlatform,
+			mutateSecret: publishing(func(secret *corev1.Secret) []byte {
+				// Only the public key from before the rotation is published.
+				return bytes.SplitN(secret.Data[controlplaneoperator.ServiceSignerPublicKey], []byte("\n-----BEGIN"), 2)[0]
+			}),
+			expectedReason:      hyperv1.PublishingNewServiceAccountKeyReason,
+			expectedStatus:      metav1.ConditionTrue,
+			expectedPhase:       serviceAccountKeyRotationPublishing,
+			expectedRequeue:     serviceAccountKeyPublishRetryInterval,
+			expectSecretChanged: true,
+		},
+		{
+			name:        "When the overlap has passed and the new public key is published it should sign with the new key",
+			annotations: map[string]string{hyperv1.ServiceAccountSigningKeyRotationAnnotation: "1"},
+			platform:    hyperv1.AWSPlatform,
+			mutateSecret: publishing(func(secret *corev1.Secret) []byte {
+				return secret.Data[controlplaneoperator.ServiceSignerPublicKey]
+			}),
+			expectedReason:  hyperv1.SigningWithNewServiceAccountKeyReason,
+			expectedStatus:  metav1.ConditionTrue,
+			expectedPhase:   serviceAccountKeyRotationSigning,
+			expectedRequeue: defaultServiceAccountSigningKeyRotationOverlap,
+		},
+		{
+			name: "When the overlap is invalid it should report it",
+			annotations: map[string]string{
+				hyperv1.ServiceAccountSigningKeyRotationAnnotation:        "1",
+				hyperv1.ServiceAccountSigningKeyRotationOverlapAnnotation: "-1h",
+			},
+			expectedReason: hyperv1.InvalidConfigurationReason,
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:              "When the signing key is provided by the user it should refuse to rotate it",
+			annotations:       map[string]string{hyperv1.ServiceAccountSigningKeyRotationAnnotation: "1"},
+			serviceAccountKey: &corev1.LocalObjectReference{Name: "my-key"},
+			expectedReason:    hyperv1.InvalidConfigurationReason,
+			expectedStatus:    metav1.ConditionFalse,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			hcluster := &hyperv1.HostedCluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc", Annotations: tc.annotations},
+				Spec: hyperv1.HostedClusterSpec{
+					Platform:                 hyperv1.PlatformSpec{Type: tc.platform},
+					ServiceAccountSigningKey: tc.serviceAccountKey,
+				},
+			}
+			secret := testServiceAccountSigningKeySecret(t, "clusters-hc")
+			if tc.mutateSecret != nil {
+				tc.mutateSecret(t, secret)
+			}
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(hcluster, secret).WithStatusSubresource(hcluster).Build()
+			r := &HostedClusterReconciler{
+				Client: c,
+				Clock:  clocktesting.NewFakeClock(now),
+			}
+
+			requeueAfter, err := r.reconcileServiceAccountSigningKeyRotation(ctx, hcluster, "clusters-hc")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(requeueAfter).To(Equal(tc.expectedRequeue))
+
+			updated := &hyperv1.HostedCluster{}
+			g.Expect(c.Get(ctx, crclient.ObjectKeyFromObject(hcluster), updated)).To(Succeed())
+			condition := meta.FindStatusCondition(updated.Status.Conditions, string(hyperv1.ServiceAccountSigningKeyRotating))
+			if tc.expectedReason == "" {
+				g.Expect(condition).To(BeNil())
+			} else {
+				g.Expect(condition).ToNot(BeNil())
+				g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+				g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+			}
+
+			updatedSecret := &corev1.Secret{}
+			g.Expect(c.Get(ctx, crclient.ObjectKeyFromObject(secret), updatedSecret)).To(Succeed())
+			g.Expect(updatedSecret.Data).ToNot(BeNil())
+			_, hasNextKey := updatedSecret.Data[controlplaneoperator.ServiceSignerNextPrivateKey]
+			g.Expect(hasNextKey).To(Equal(tc.expectSecretChanged))
+			if tc.expectedPhase != "" {
+				g.Expect(updatedSecret.Annotations[serviceAccountKeyRotationPhaseAnnotation]).To(Equal(tc.expectedPhase))
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package hostedcluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blang/semver"
+	configv1 "github.com/openshift/api/config/v1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	automaticUpgradeReason         = "AutomaticUpgrade"
+	automaticUpgradeBlockedReason  = "AutomaticUpgradeBlocked"
+	automaticNodePoolUpgradeReason = "AutomaticNodePoolUpgrade"
+)
+
+// upgradePolicy returns the upgrade policy type of the HostedCluster, Manual when unset.
+func upgradePolicy(hcluster *hyperv1.HostedCluster) hyperv1.UpgradePolicyType {
+	if hcluster.Spec.UpgradePolicy == nil || hcluster.Spec.UpgradePolicy.Type == "" {
+		return hyperv1.UpgradePolicyManual
+	}
+	return hcluster.Spec.UpgradePolicy.Type
+}
+
+// reconcileUpgradePolicy applies the newest recommended update allowed by the
+// upgrade policy of the HostedCluster. It returns true when spec.release.image
+// was changed, in which case the caller should stop and let the update trigger
+// a new reconciliation.
+func (r *HostedClusterReconciler) reconcileUpgradePolicy(ctx context.Context, hcluster *hyperv1.HostedCluster) (bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	policy := upgradePolicy(hcluster)
+	if policy == hyperv1.UpgradePolicyManual {
+		return false, nil
+	}
+
+	// Only select a new release once the control plane has rolled out the
+	// release which is currently requested.
+	if !isVersionRolledOut(hcluster) {
+		return false, nil
+	}
+	// An explicit control plane release is a deliberate pin and is never overridden.
+	if hcluster.Spec.ControlPlaneRelease != nil {
+		return false, nil
+	}
+	// A HostedClusterUpgradePlan which selects the HostedCluster owns its release until it completes.
+	plan, err := r.activeUpgradePlan(ctx, hcluster)
+	if err != nil {
+		return false, err
+	}
+	if plan != "" {
+		r.reportBlockedUpgrade(hcluster, fmt.Sprintf("%s/plan/%s", policy, plan), "Not applying upgrade policy %s while HostedClusterUpgradePlan %s upgrades the cluster", policy, plan)
+		return false, nil
+	}
+
+	current, err := semver.Parse(hcluster.Status.Version.Desired.Version)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse current version %q: %w", hcluster.Status.Version.Desired.Version, err)
+	}
+	update := selectPolicyUpdate(policy, current, hcluster.Status.Version.AvailableUpdates)
+	if update == nil {
+		r.upgradePolicyBlocked.Delete(client.ObjectKeyFromObject(hcluster))
+		return false, nil
+	}
+	// The ClusterVersionUpgradeable condition already records why the cluster
+	// is not upgradeable, so only report when the blocked upgrade changes.
+	if condition := meta.FindStatusCondition(hcluster.Status.Conditions, string(hyperv1.ClusterVersionUpgradeable)); condition != nil && condition.Status == metav1.ConditionFalse {
+		r.reportBlockedUpgrade(hcluster, fmt.Sprintf("%s/%s/%s", policy, update.Version, condition.Reason), "Not upgrading to %s under policy %s because the cluster is not upgradeable: %s", update.Version, policy, condition.Reason)
+		return false, nil
+	}
+	r.upgradePolicyBlocked.Delete(client.ObjectKeyFromObject(hcluster))
+
+	original := hcluster.DeepCopy()
+	hcluster.Spec.Release.Image = update.Image
+	if err := r.Patch(ctx, hcluster, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return false, fmt.Errorf("failed to apply automatic upgrade to %s: %w", update.Version, err)
+	}
+	log.Info("Applied automatic upgrade", "policy", policy, "from", current.String(), "to", update.Version, "image", update.Image)
+	r.recorder.Eventf(hcluster, corev1.EventTypeNormal, automaticUpgradeReason, "Upgrading from %s to recommended release %s (%s) under policy %s", current.String(), update.Version, update.Image, policy)
+	return true, nil
+}
+
+// selectPolicyUpdate returns the newest update the policy allows.
+func selectPolicyUpdate(policy hyperv1.UpgradePolicyType, current semver.Version, updates []configv1.Release) *configv1.Release {
+	var update *configv1.Release
+	var newest *semver.Version
+	for i := range updates {
+		candidate := updates[i]
+		if candidate.Image == "" {
+			continue
+		}
+		version, err := semver.Parse(candidate.Version)
+		if err != nil || !version.GT(current) || version.Major != current.Major {
+			continue
+		}
+		switch {
+		case version.Minor == current.Minor:
+		case policy == hyperv1.UpgradePolicyAutoMinor && version.Minor == current.Minor+1:
+		default:
+			continue
+		}
+		if newest == nil || version.GT(*newest) {
+			newest = &version
+			update = &updates[i]
+		}
+	}
+	return update
+}
+
+// reportBlockedUpgrade emits an event about an automatic upgrade which is held back,
+// unless the same state was already reported.
+func (r *HostedClusterReconciler) reportBlockedUpgrade(hcluster *hyperv1.HostedCluster, state string, messageFmt string, args ...interface{}) {
+	if previous, loaded := r.upgradePolicyBlocked.Swap(client.ObjectKeyFromObject(hcluster), state); loaded && previous == state {
+		return
+	}
+	r.recorder.Eventf(hcluster, corev1.EventTypeNormal, automaticUpgradeBlockedReason, messageFmt, args...)
+}
+
+// activeUpgradePlan returns the name of a HostedClusterUpgradePlan which selects the
+// HostedCluster and has not completed, if any.
+func (r *HostedClusterReconciler) activeUpgradePlan(ctx context.Context, hcluster *hyperv1.HostedCluster) (string, error) {
+	plans := &hyperv1.HostedClusterUpgradePlanList{}
+	if err := r.List(ctx, plans, client.InNamespace(hcluster.Namespace)); err != nil {
+		return "", fmt.Errorf("failed to list upgrade plans: %w", err)
+	}
+	for _, plan := range plans.Items {
+		selector, err := metav1.LabelSelectorAsSelector(&plan.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(hcluster.Labels)) {
+			continue
+		}
+		completed := plan.Status.ObservedGeneration == plan.Generation && plan.Status.CurrentWave == "" && len(plan.Status.Waves) > 0
+		if !completed {
+			return plan.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// isVersionRolledOut returns true when the control plane has completed the
+// rollout of the release requested by the HostedCluster.
+func isVersionRolledOut(hcluster *hyperv1.HostedCluster) bool {
+	version := hcluster.Status.Version
+	if version == nil || len(version.History) == 0 {
+		return false
+	}
+	return version.Desired.Image == hcluster.Spec.Release.Image &&
+		version.History[0].Image == hcluster.Spec.Release.Image &&
+		version.History[0].State == configv1.CompletedUpdate
+}
+
+// reconcileNodePoolUpgradePolicy rolls the NodePools of the HostedCluster which
+// ran a release previously run by the control plane to the HostedCluster release,
+// once the control plane has completed its upgrade.
+func (r *HostedClusterReconciler) reconcileNodePoolUpgradePolicy(ctx context.Context, hcluster *hyperv1.HostedCluster) error {
+	if upgradePolicy(hcluster) == hyperv1.UpgradePolicyManual || !hcluster.Spec.UpgradePolicy.NodePools || !isVersionRolledOut(hcluster) {
+		return nil
+	}
+
+	previousImages := sets.New[string]()
+	for _, history := range hcluster.Status.Version.History[1:] {
+		previousImages.Insert(history.Image)
+	}
+
+	nodePools, err := listNodePools(ctx, r.Client, hcluster.Namespace, hcluster.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list NodePools: %w", err)
+	}
+	for i := range nodePools {
+		nodePool := &nodePools[i]
+		if nodePool.Spec.Release.Image == hcluster.Spec.Release.Image || !previousImages.Has(nodePool.Spec.Release.Image) {
+			continue
+		}
+		original := nodePool.DeepCopy()
+		nodePool.Spec.Release.Image = hcluster.Spec.Release.Image
+		if err := r.Patch(ctx, nodePool, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+			return fmt.Errorf("failed to upgrade NodePool %s: %w", nodePool.Name, err)
+		}
+		r.recorder.Eventf(nodePool, corev1.EventTypeNormal, automaticNodePoolUpgradeReason, "Upgrading from %s to %s following HostedCluster %s", original.Spec.Release.Image, hcluster.Spec.Release.Image, hcluster.Name)
+		r.recorder.Eventf(hcluster, corev1.EventTypeNormal, automaticNodePoolUpgradeReason, "Upgrading NodePool %s to %s", nodePool.Name, hcluster.Spec.Release.Image)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package hostedcluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver"
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSelectPolicyUpdate(t *testing.T) {
+	updates := []configv1.Release{
+		{Version: "4.15.3", Image: "release:4.15.3"},
+		{Version: "4.15.5", Image: "release:4.15.5"},
+		{Version: "4.16.1", Image: "release:4.16.1"},
+		{Version: "4.17.0", Image: "release:4.17.0"},
+		{Version: "4.15.9"},
+	}
+
+	testCases := []struct {
+		name           string
+		policy         hyperv1.UpgradePolicyType
+		updates        []configv1.Release
+		expectedUpdate string
+	}{
+		{
+			name:           "When policy is AutoPatch it should pick the newest patch update",
+			policy:         hyperv1.UpgradePolicyAutoPatch,
+			updates:        updates,
+			expectedUpdate: "4.15.5",
+		},
+		{
+			name:           "When policy is AutoMinor it should pick the newest update of the next minor",
+			policy:         hyperv1.UpgradePolicyAutoMinor,
+			updates:        updates,
+			expectedUpdate: "4.16.1",
+		},
+		{
+			name:    "When there are no newer updates it should pick nothing",
+			policy:  hyperv1.UpgradePolicyAutoMinor,
+			updates: []configv1.Release{{Version: "4.15.0", Image: "release:4.15.0"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			update := selectPolicyUpdate(tc.policy, semver.MustParse("4.15.2"), tc.updates)
+			if tc.expectedUpdate == "" {
+				g.Expect(update).To(BeNil())
+			} else {
+				g.Expect(update).ToNot(BeNil())
+				g.Expect(update.Version).To(Equal(tc.expectedUpdate))
+			}
+		})
+	}
+}
+
+func TestReconcileUpgradePolicy(t *testing.T) {
+	hostedCluster := func(policy hyperv1.UpgradePolicyType, historyState configv1.UpdateState) *hyperv1.HostedCluster {
+		return &hyperv1.HostedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "clusters",
+				Name:      "hc",
+				Labels:    map[string]string{"fleet": "canary"},
+			},
+			Spec: hyperv1.HostedClusterSpec{
+				Release:       hyperv1.Release{Image: "release:4.15.2"},
+				UpgradePolicy: &hyperv1.UpgradePolicy{Type: policy, NodePools: true},
+			},
+			Status: hyperv1.HostedClusterStatus{
+				Version: &hyperv1.ClusterVersionStatus{
+					Desired: configv1.Release{Version: "4.15.2", Image: "release:4.15.2"},
+					History: []configv1.UpdateHistory{
+						{Version: "4.15.2", Image: "release:4.15.2", State: historyState},
+						{Version: "4.15.1", Image: "release:4.15.1", State: configv1.CompletedUpdate},
+					},
+					AvailableUpdates: []configv1.Release{{Version: "4.15.5", Image: "release:4.15.5"}},
+					ConditionalUpdates: []configv1.ConditionalUpdate{
+						{Release: configv1.Release{Version: "4.15.7", Image: "release:4.15.7"}},
+					},
+				},
+			},
+		}
+	}
+	nodePool := func(name, image string) *hyperv1.NodePool {
+		return &hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: name},
+			Spec: hyperv1.NodePoolSpec{
+				ClusterName: "hc",
+				Release:     hyperv1.Release{Image: image},
+			},
+		}
+	}
+
+	plan := func(name string, completed bool) *hyperv1.HostedClusterUpgradePlan {
+		p := &hyperv1.HostedClusterUpgradePlan{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: name},
+			Spec: hyperv1.HostedClusterUpgradePlanSpec{
+				Release:  hyperv1.Release{Image: "release:4.15.9"},
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"fleet": "canary"}},
+				Waves:    []hyperv1.HostedClusterUpgradeWave{{Name: "all"}},
+			},
+		}
+		if completed {
+			p.Status.Waves = []hyperv1.HostedClusterUpgradeWaveStatus{{Name: "all", Phase: hyperv1.HostedClusterUpgradeWaveCompleted}}
+		} else {
+			p.Status.CurrentWave = "all"
+		}
+		return p
+	}
+
+	testCases := []struct {
+		name             string
+		hostedCluster    *hyperv1.HostedCluster
+		plans            []client.Object
+		expectedUpgraded bool
+		expectedImage    string
+	}{
+		{
+			name:          "When policy is Manual it should not upgrade",
+			hostedCluster: hostedCluster(hyperv1.UpgradePolicyManual, configv1.CompletedUpdate),
+			expectedImage: "release:4.15.2",
+		},
+		{
+			name:          "When the current release is still rolling out it should not upgrade",
+			hostedCluster: hostedCluster(hyperv1.UpgradePolicyAutoPatch, configv1.PartialUpdate),
+			expectedImage: "release:4.15.2",
+		},
+		{
+			name: "When the cluster is not upgradeable it should not apply patch updates either",
+			hostedCluster: func() *hyperv1.HostedCluster {
+				hc := hostedCluster(hyperv1.UpgradePolicyAutoPatch, configv1.CompletedUpdate)
+				hc.Status.Conditions = []metav1.Condition{{Type: string(hyperv1.ClusterVersionUpgradeable), Status: metav1.ConditionFalse, Reason: "AdminAckRequired"}}
+				return hc
+			}(),
+			expectedImage: "release:4.15.2",
+		},
+		{
+			name:             "When policy is AutoPatch it should upgrade to the recommended update and skip conditional updates",
+			hostedCluster:    hostedCluster(hyperv1.UpgradePolicyAutoPatch, configv1.CompletedUpdate),
+			expectedUpgraded: true,
+			expectedImage:    "release:4.15.5",
+		},
+		{
+			name:          "When an upgrade plan which selects the cluster is in progress it should leave the release to the plan",
+			hostedCluster: hostedCluster(hyperv1.UpgradePolicyAutoPatch, configv1.CompletedUpdate),
+			plans:         []client.Object{plan("canary", false)},
+			expectedImage: "release:4.15.2",
+		},
+		{
+			name:             "When the upgrade plan which selects the cluster completed it should upgrade",
+			hostedCluster:    hostedCluster(hyperv1.UpgradePolicyAutoPatch, configv1.CompletedUpdate),
+			plans:            []client.Object{plan("canary", true)},
+			expectedUpgraded: true,
+			expectedImage:    "release:4.15.5",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.hostedCluster).WithObjects(tc.plans...).Build()
+			r := &HostedClusterReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+
+			upgraded, err := r.reconcileUpgradePolicy(ctx, tc.hostedCluster)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(upgraded).To(Equal(tc.expectedUpgraded))
+
+			hc := &hyperv1.HostedCluster{}
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(tc.hostedCluster), hc)).To(Succeed())
+			g.Expect(hc.Spec.Release.Image).To(Equal(tc.expectedImage))
+		})
+	}
+
+	t.Run("When the upgrade is blocked it should only report it when the blocked upgrade changes", func(t *testing.T) {
+		g := NewWithT(t)
+		ctx := context.Background()
+		hc := hostedCluster(hyperv1.UpgradePolicyAutoMinor, configv1.CompletedUpdate)
+		hc.Status.Version.AvailableUpdates = []configv1.Release{{Version: "4.16.1", Image: "release:4.16.1"}}
+		hc.Status.Conditions = []metav1.Condition{{
+			Type:   string(hyperv1.ClusterVersionUpgradeable),
+			Status: metav1.ConditionFalse,
+			Reason: "AdminAckRequired",
+		}}
+		c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(hc).Build()
+		recorder := record.NewFakeRecorder(10)
+		r := &HostedClusterReconciler{Client: c, recorder: recorder}
+
+		for i := 0; i < 3; i++ {
+			upgraded, err := r.reconcileUpgradePolicy(ctx, hc)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(upgraded).To(BeFalse())
+		}
+		g.Expect(recorder.Events).To(HaveLen(1))
+		g.Expect(<-recorder.Events).To(ContainSubstring("AdminAckRequired"))
+
+		hc.Status.Conditions[0].Reason = "ClusterOperatorsNotUpgradeable"
+		_, err := r.reconcileUpgradePolicy(ctx, hc)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(recorder.Events).To(HaveLen(1), "a new reason should be reported")
+		<-recorder.Events
+
+		hc.Status.Version.AvailableUpdates = []configv1.Release{{Version: "4.16.2", Image: "release:4.16.2"}}
+		_, err = r.reconcileUpgradePolicy(ctx, hc)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(recorder.Events).To(HaveLen(1), "a new blocked upgrade should be reported")
+		<-recorder.Events
+
+		// The reported state is dropped with the HostedCluster.
+		g.Expect(c.Delete(ctx, hc)).To(Succeed())
+		_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(hc)})
+		g.Expect(err).ToNot(HaveOccurred())
+		_, loaded := r.upgradePolicyBlocked.Load(client.ObjectKeyFromObject(hc))
+		g.Expect(loaded).To(BeFalse())
+	})
+
+	t.Run("When NodePools follow the HostedCluster it should only upgrade NodePools which ran a previous control plane release", func(t *testing.T) {
+		g := NewWithT(t)
+		ctx := context.Background()
+		hc := hostedCluster(hyperv1.UpgradePolicyAutoPatch, configv1.CompletedUpdate)
+		c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(
+			hc,
+			nodePool("following", "release:4.15.1"),
+			nodePool("pinned", "release:4.14.9"),
+		).Build()
+		r := &HostedClusterReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+
+		g.Expect(r.reconcileNodePoolUpgradePolicy(ctx, hc)).To(Succeed())
+
+		np := &hyperv1.NodePool{}
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "clusters", Name: "following"}, np)).To(Succeed())
+		g.Expect(np.Spec.Release.Image).To(Equal("release:4.15.2"))
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "clusters", Name: "pinned"}, np)).To(Succeed())
+		g.Expect(np.Spec.Release.Image).To(Equal("release:4.14.9"))
+	})
+}
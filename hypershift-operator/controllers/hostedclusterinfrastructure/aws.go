@@ -0,0 +1,227 @@
+package hostedclusterinfrastructure
+
+import (
+	"context"
+	"fmt"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	"github.com/openshift/hypershift/cmd/util"
+	awsinfra "github.com/openshift/hypershift/support/infra/aws"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The keys of the AWS credentials secret, the same as those of the secret used by the `hypershift` CLI.
+const (
+	accessKeyIDSecretKey     = "aws_access_key_id"
+	secretAccessKeySecretKey = "aws_secret_access_key"
+	sessionTokenSecretKey    = "aws_session_token"
+)
+
+// awsInfrastructure creates, verifies and destroys AWS resources. It is implemented by the logic of the
+// `hypershift create/destroy infra aws` and `hypershift create/destroy iam aws` commands, which look up
+// the resources of an infra ID by their tags and only create the missing ones, so that running them
+// again repairs drift.
+type awsInfrastructure interface {
+	CreateInfra(ctx context.Context, opts *awsinfra.CreateInfraOptions) (*awsinfra.CreateInfraOutput, error)
+	CreateIAM(ctx context.Context, opts *awsinfra.CreateIAMOptions) (*awsinfra.CreateIAMOutput, error)
+	VerifyInfra(ctx context.Context, opts *awsinfra.CreateInfraOptions, result *awsinfra.CreateInfraOutput) error
+	VerifyIAM(ctx context.Context, opts *awsinfra.CreateIAMOptions, result *awsinfra.CreateIAMOutput) error
+	DestroyInfra(ctx context.Context, opts *awsinfra.DestroyInfraOptions) error
+	DestroyIAM(ctx context.Context, opts *awsinfra.DestroyIAMOptions) error
+}
+
+type cliAWSInfrastructure struct {
+	client client.Client
+}
+
+func (c *cliAWSInfrastructure) CreateInfra(ctx context.Context, opts *awsinfra.CreateInfraOptions) (*awsinfra.CreateInfraOutput, error) {
+	return opts.CreateInfra(ctx, ctrl.LoggerFrom(ctx))
+}
+
+func (c *cliAWSInfrastructure) CreateIAM(ctx context.Context, opts *awsinfra.CreateIAMOptions) (*awsinfra.CreateIAMOutput, error) {
+	return opts.CreateIAM(ctx, c.client, ctrl.LoggerFrom(ctx))
+}
+
+func (c *cliAWSInfrastructure) VerifyInfra(ctx context.Context, opts *awsinfra.CreateInfraOptions, result *awsinfra.CreateInfraOutput) error {
+	return opts.VerifyInfra(ctx, result)
+}
+
+func (c *cliAWSInfrastructure) VerifyIAM(ctx context.Context, opts *awsinfra.CreateIAMOptions, result *awsinfra.CreateIAMOutput) error {
+	return opts.VerifyIAM(ctx, result)
+}
+
+func (c *cliAWSInfrastructure) DestroyInfra(ctx context.Context, opts *awsinfra.DestroyInfraOptions) error {
+	return opts.DestroyInfra(ctx)
+}
+
+func (c *cliAWSInfrastructure) DestroyIAM(ctx context.Context, opts *awsinfra.DestroyIAMOptions) error {
+	return opts.DestroyIAM(ctx)
+}
+
+func (r *Reconciler) syncAWS(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure, status *hyperv1.HostedClusterInfrastructureStatus) error {
+	credentials, err := r.awsCredentials(ctx, infra)
+	if err != nil {
+		return &conditionReasonError{reason: hyperv1.InfrastructureCredentialsErrorReason, err: err}
+	}
+
+	createOpts := createInfraOptions(infra, credentials)
+	if err := createOpts.Validate(); err != nil {
+		return &conditionReasonError{reason: hyperv1.InfrastructureInvalidConfigReason, err: err}
+	}
+	output, err := r.aws.CreateInfra(ctx, createOpts)
+	if err != nil {
+		return fmt.Errorf("failed to sync infrastructure: %w", err)
+	}
+	awsStatus := &hyperv1.AWSInfrastructureStatus{
+		VPCID:         output.VPCID,
+		MachineCIDR:   output.MachineCIDR,
+		MachineCIDRv6: output.MachineCIDRv6,
+		PublicZoneID:  output.PublicZoneID,
+		PrivateZoneID: output.PrivateZoneID,
+		LocalZoneID:   output.LocalZoneID,
+	}
+	for _, zone := range output.Zones {
+		awsStatus.Zones = append(awsStatus.Zones, hyperv1.AWSInfrastructureZone{Name: zone.Name, SubnetID: zone.SubnetID})
+	}
+
+	if infra.Spec.Platform.AWS.IAM != nil {
+		iamOutput, err := r.aws.CreateIAM(ctx, createIAMOptions(infra, credentials, output))
+		if err != nil {
+			return fmt.Errorf("failed to sync IAM resources: %w", err)
+		}
+		awsStatus.IAM = &hyperv1.AWSInfrastructureIAMStatus{
+			IssuerURL:       iamOutput.IssuerURL,
+			InstanceProfile: iamOutput.ProfileName,
+			Roles:           iamOutput.Roles,
+		}
+	}
+	status.AWS = awsStatus
+	return nil
+}
+
+func (r *Reconciler) verifyAWS(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) error {
+	credentials, err := r.awsCredentials(ctx, infra)
+	if err != nil {
+		return err
+	}
+
+	awsStatus := infra.Status.AWS
+	output := &awsinfra.CreateInfraOutput{
+		VPCID:         awsStatus.VPCID,
+		PublicZoneID:  awsStatus.PublicZoneID,
+		PrivateZoneID: awsStatus.PrivateZoneID,
+		LocalZoneID:   awsStatus.LocalZoneID,
+	}
+	for _, zone := range awsStatus.Zones {
+		output.Zones = append(output.Zones, &awsinfra.CreateInfraOutputZone{Name: zone.Name, SubnetID: zone.SubnetID})
+	}
+	if err := r.aws.VerifyInfra(ctx, createInfraOptions(infra, credentials), output); err != nil {
+		return err
+	}
+
+	if infra.Spec.Platform.AWS.IAM != nil {
+		if awsStatus.IAM == nil {
+			return fmt.Errorf("no IAM resources reported")
+		}
+		if err := r.aws.VerifyIAM(ctx, createIAMOptions(infra, credentials, output), &awsinfra.CreateIAMOutput{
+			ProfileName: awsStatus.IAM.InstanceProfile,
+			Roles:       awsStatus.IAM.Roles,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteAWS deletes the AWS resources of the infrastructure. Adopted VPCs and their subnets are kept.
+func (r *Reconciler) deleteAWS(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) error {
+	credentials, err := r.awsCredentials(ctx, infra)
+	if err != nil {
+		return err
+	}
+
+	log := ctrl.LoggerFrom(ctx)
+	if err := r.aws.DestroyInfra(ctx, &awsinfra.DestroyInfraOptions{
+		Region:                infra.Spec.Platform.AWS.Region,
+		InfraID:               infra.Spec.InfraID,
+		Name:                  infra.Name,
+		BaseDomain:            infra.Spec.DNS.BaseDomain,
+		BaseDomainPrefix:      ptr.Deref(infra.Spec.DNS.BaseDomainPrefix, ""),
+		AWSCredentialsOpts:    &awsinfra.DelegatedAWSCredentialOptions{AWSCredentialsOpts: &awsutil.AWSCredentialsOptions{}},
+		CredentialsSecretData: credentials,
+		Log:                   log,
+	}); err != nil {
+		return fmt.Errorf("failed to destroy infrastructure: %w", err)
+	}
+	if infra.Spec.Platform.AWS.IAM != nil {
+		if err := r.aws.DestroyIAM(ctx, &awsinfra.DestroyIAMOptions{
+			Region:                infra.Spec.Platform.AWS.Region,
+			InfraID:               infra.Spec.InfraID,
+			CredentialsSecretData: credentials,
+			Log:                   log,
+		}); err != nil {
+			return fmt.Errorf("failed to destroy IAM resources: %w", err)
+		}
+	}
+	return nil
+}
+
+// awsCredentials reads the AWS credentials of the infrastructure from its credentials secret.
+func (r *Reconciler) awsCredentials(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) (*util.CredentialsSecretData, error) {
+	data, err := r.credentials(ctx, infra, infra.Spec.Platform.AWS.CredentialsSecret, accessKeyIDSecretKey, secretAccessKeySecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &util.CredentialsSecretData{
+		AWSAccessKeyID:     string(data[accessKeyIDSecretKey]),
+		AWSSecretAccessKey: string(data[secretAccessKeySecretKey]),
+		AWSSessionToken:    string(data[sessionTokenSecretKey]),
+	}, nil
+}
+
+func createInfraOptions(infra *hyperv1.HostedClusterInfrastructure, credentials *util.CredentialsSecretData) *awsinfra.CreateInfraOptions {
+	spec := infra.Spec.Platform.AWS
+	opts := &awsinfra.CreateInfraOptions{
+		CredentialsSecretData: credentials,
+		Region:                spec.Region,
+		InfraID:               infra.Spec.InfraID,
+		Name:                  infra.Name,
+		BaseDomain:            infra.Spec.DNS.BaseDomain,
+		BaseDomainPrefix:      ptr.Deref(infra.Spec.DNS.BaseDomainPrefix, ""),
+		Zones:                 spec.Zones,
+		SingleNATGateway:      spec.SingleNATGateway,
+		DualStack:             spec.DualStack,
+		AdditionalTags:        resourceTags(spec.ResourceTags),
+	}
+	if spec.ExistingVPC != nil {
+		opts.VPCID = spec.ExistingVPC.ID
+		opts.PrivateSubnetIDs = spec.ExistingVPC.PrivateSubnetIDs
+		opts.PublicSubnetIDs = spec.ExistingVPC.PublicSubnetIDs
+	}
+	return opts
+}
+
+func createIAMOptions(infra *hyperv1.HostedClusterInfrastructure, credentials *util.CredentialsSecretData, output *awsinfra.CreateInfraOutput) *awsinfra.CreateIAMOptions {
+	spec := infra.Spec.Platform.AWS
+	return &awsinfra.CreateIAMOptions{
+		CredentialsSecretData: credentials,
+		Region:                spec.Region,
+		InfraID:               infra.Spec.InfraID,
+		IssuerURL:             spec.IAM.IssuerURL,
+		PublicZoneID:          output.PublicZoneID,
+		PrivateZoneID:         output.PrivateZoneID,
+		LocalZoneID:           output.LocalZoneID,
+		AdditionalTags:        resourceTags(spec.ResourceTags),
+	}
+}
+
+func resourceTags(tags []hyperv1.AWSResourceTag) []string {
+	var result []string
+	for _, tag := range tags {
+		result = append(result, fmt.Sprintf("%s=%s", tag.Key, tag.Value))
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package hostedclusterinfrastructure
+
+import (
+	"context"
+	"fmt"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/util"
+	azureinfra "github.com/openshift/hypershift/support/infra/azure"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// The keys of the Azure credentials secret, the same as those of the credentials secret of an Azure
+// HostedCluster.
+const (
+	azureSubscriptionIDSecretKey = "AZURE_SUBSCRIPTION_ID"
+	azureTenantIDSecretKey       = "AZURE_TENANT_ID"
+	azureClientIDSecretKey       = "AZURE_CLIENT_ID"
+	azureClientSecretSecretKey   = "AZURE_CLIENT_SECRET"
+)
+
+// azureInfrastructure creates, verifies and destroys Azure resources. It is implemented by the logic of
+// the `hypershift create/destroy infra azure` commands, which create or update the resources of a
+// resource group by their names, so that running them again repairs drift.
+type azureInfrastructure interface {
+	CreateInfra(ctx context.Context, opts *azureinfra.CreateInfraOptions) (*azureinfra.CreateInfraOutput, error)
+	VerifyInfra(ctx context.Context, opts *azureinfra.CreateInfraOptions, result *azureinfra.CreateInfraOutput) error
+	DestroyInfra(ctx context.Context, opts *azureinfra.DestroyInfraOptions) error
+}
+
+type cliAzureInfrastructure struct{}
+
+func (c *cliAzureInfrastructure) CreateInfra(ctx context.Context, opts *azureinfra.CreateInfraOptions) (*azureinfra.CreateInfraOutput, error) {
+	return opts.Run(ctx, ctrl.LoggerFrom(ctx))
+}
+
+func (c *cliAzureInfrastructure) VerifyInfra(ctx context.Context, opts *azureinfra.CreateInfraOptions, result *azureinfra.CreateInfraOutput) error {
+	return opts.VerifyInfra(ctx, ctrl.LoggerFrom(ctx), result)
+}
+
+func (c *cliAzureInfrastructure) DestroyInfra(ctx context.Context, opts *azureinfra.DestroyInfraOptions) error {
+	return opts.Run(ctx, ctrl.LoggerFrom(ctx))
+}
+
+func (r *Reconciler) syncAzure(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure, status *hyperv1.HostedClusterInfrastructureStatus) error {
+	credentials, err := r.azureCredentials(ctx, infra)
+	if err != nil {
+		return &conditionReasonError{reason: hyperv1.InfrastructureCredentialsErrorReason, err: err}
+	}
+
+	createOpts := azureCreateInfraOptions(infra, credentials)
+	// the RHCOS image is large and immutable, so it is only uploaded once
+	if infra.Status.Azure != nil {
+		createOpts.BootImageID = infra.Status.Azure.BootImageID
+	}
+	output, err := r.azure.CreateInfra(ctx, createOpts)
+	if err != nil {
+		return fmt.Errorf("failed to sync infrastructure: %w", err)
+	}
+	status.Azure = &hyperv1.AzureInfrastructureStatus{
+		SubscriptionID:    credentials.SubscriptionID,
+		ResourceGroupName: output.ResourceGroupName,
+		VnetID:            output.VNetID,
+		SubnetID:          output.SubnetID,
+		SecurityGroupID:   output.SecurityGroupID,
+		MachineIdentityID: output.MachineIdentityID,
+		BootImageID:       output.BootImageID,
+		PublicZoneID:      output.PublicZoneID,
+		PrivateZoneID:     output.PrivateZoneID,
+	}
+	return nil
+}
+
+func (r *Reconciler) verifyAzure(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) error {
+	credentials, err := r.azureCredentials(ctx, infra)
+	if err != nil {
+		return err
+	}
+
+	azureStatus := infra.Status.Azure
+	return r.azure.VerifyInfra(ctx, azureCreateInfraOptions(infra, credentials), &azureinfra.CreateInfraOutput{
+		ResourceGroupName: azureStatus.ResourceGroupName,
+		VNetID:            azureStatus.VnetID,
+		SubnetID:          azureStatus.SubnetID,
+		SecurityGroupID:   azureStatus.SecurityGroupID,
+		MachineIdentityID: azureStatus.MachineIdentityID,
+		BootImageID:       azureStatus.BootImageID,
+		PublicZoneID:      azureStatus.PublicZoneID,
+		PrivateZoneID:     azureStatus.PrivateZoneID,
+	})
+}
+
+// deleteAzure deletes the resource group of the infrastructure with all its resources. An existing
+// resource group is kept.
+func (r *Reconciler) deleteAzure(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) error {
+	if len(infra.Spec.Platform.Azure.ResourceGroupName) > 0 {
+		ctrl.LoggerFrom(ctx).Info("Keeping existing resource group", "name", infra.Spec.Platform.Azure.ResourceGroupName)
+		return nil
+	}
+	credentials, err := r.azureCredentials(ctx, infra)
+	if err != nil {
+		return err
+	}
+
+	if err := r.azure.DestroyInfra(ctx, &azureinfra.DestroyInfraOptions{
+		Name:        infra.Name,
+		Location:    infra.Spec.Platform.Azure.Location,
+		InfraID:     infra.Spec.InfraID,
+		Credentials: credentials,
+	}); err != nil {
+		return fmt.Errorf("failed to destroy infrastructure: %w", err)
+	}
+	return nil
+}
+
+// azureCredentials reads the Azure credentials of the infrastructure from its credentials secret.
+func (r *Reconciler) azureCredentials(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) (*util.AzureCreds, error) {
+	data, err := r.credentials(ctx, infra, infra.Spec.Platform.Azure.CredentialsSecret,
+		azureSubscriptionIDSecretKey, azureTenantIDSecretKey, azureClientIDSecretKey, azureClientSecretSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &util.AzureCreds{
+		SubscriptionID: string(data[azureSubscriptionIDSecretKey]),
+		TenantID:       string(data[azureTenantIDSecretKey]),
+		ClientID:       string(data[azureClientIDSecretKey]),
+		ClientSecret:   string(data[azureClientSecretSecretKey]),
+	}, nil
+}
+
+func azureCreateInfraOptions(infra *hyperv1.HostedClusterInfrastructure, credentials *util.AzureCreds) *azureinfra.CreateInfraOptions {
+	spec := infra.Spec.Platform.Azure
+	return &azureinfra.CreateInfraOptions{
+		Name:                   infra.Name,
+		BaseDomain:             infra.Spec.DNS.BaseDomain,
+		Location:               spec.Location,
+		InfraID:                infra.Spec.InfraID,
+		Credentials:            credentials,
+		RHCOSImage:             spec.RHCOSImage,
+		ResourceGroupName:      spec.ResourceGroupName,
+		VnetID:                 spec.VnetID,
+		NetworkSecurityGroupID: spec.NetworkSecurityGroupID,
+		ResourceGroupTags:      spec.ResourceGroupTags,
+		SubnetID:               spec.SubnetID,
+	}
+}
@@ -0,0 +1,252 @@
+package hostedclusterinfrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	ControllerName = "hostedclusterinfrastructure"
+
+	// finalizer is set on HostedClusterInfrastructures until their cloud resources are deleted.
+	finalizer = "hypershift.openshift.io/hostedcluster-infrastructure"
+
+	defaultResyncInterval = 10 * time.Minute
+	// inUseRequeueInterval is how often the deletion of an infrastructure still used by a HostedCluster is retried.
+	inUseRequeueInterval = 30 * time.Second
+)
+
+// Reconciler creates or adopts the cloud resources of HostedClusterInfrastructures, checks them for drift
+// every resync interval, repairs them when they drifted and deletes them once the
+// HostedClusterInfrastructure is deleted.
+type Reconciler struct {
+	client.Client
+
+	// Clock is used to determine the time in a testable way.
+	Clock clock.PassiveClock
+
+	aws     awsInfrastructure
+	azure   azureInfrastructure
+	powervs powerVSInfrastructure
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
+	if r.aws == nil {
+		r.aws = &cliAWSInfrastructure{client: mgr.GetClient()}
+	}
+	if r.azure == nil {
+		r.azure = &cliAzureInfrastructure{}
+	}
+	if r.powervs == nil {
+		r.powervs = &cliPowerVSInfrastructure{}
+	}
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&hyperv1.HostedClusterInfrastructure{}).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+	return nil
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	infra := &hyperv1.HostedClusterInfrastructure{}
+	if err := r.Get(ctx, req.NamespacedName, infra); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get infrastructure: %w", err)
+	}
+
+	if !infra.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(infra, finalizer) {
+			return ctrl.Result{}, nil
+		}
+		// never delete the cloud resources from under a HostedCluster
+		hcluster, err := r.hostedClusterUsing(ctx, infra)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if hcluster != nil {
+			log.Info("Waiting for the hosted cluster using the infrastructure to be deleted", "hostedcluster", client.ObjectKeyFromObject(hcluster))
+			return ctrl.Result{RequeueAfter: inUseRequeueInterval}, nil
+		}
+		if err := r.delete(ctx, infra); err != nil {
+			return ctrl.Result{}, err
+		}
+		original := infra.DeepCopy()
+		controllerutil.RemoveFinalizer(infra, finalizer)
+		if err := r.Patch(ctx, infra, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to remove finalizer: %w", err)
+		}
+		log.Info("Deleted infrastructure", "infraID", infra.Spec.InfraID)
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(infra, finalizer) {
+		original := infra.DeepCopy()
+		controllerutil.AddFinalizer(infra, finalizer)
+		if err := r.Patch(ctx, infra, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+
+	status := infra.Status.DeepCopy()
+	condition := metav1.Condition{
+		Type:               string(hyperv1.InfrastructureAvailable),
+		Status:             metav1.ConditionTrue,
+		Reason:             hyperv1.InfrastructureSyncedReason,
+		Message:            "The cloud resources of the infrastructure are in sync",
+		ObservedGeneration: infra.Generation,
+	}
+
+	// A full sync looks up or creates every resource and takes minutes, so resources which were synced
+	// for the current spec are only checked for drift, and synced again once they drifted.
+	var syncErr error
+	synced := false
+	if status.ObservedGeneration == infra.Generation && meta.IsStatusConditionTrue(status.Conditions, string(hyperv1.InfrastructureAvailable)) {
+		if err := r.verify(ctx, infra); err != nil {
+			log.Info("Cloud resources drifted, syncing them", "reason", err.Error())
+		} else {
+			synced = true
+		}
+	}
+	if !synced {
+		syncErr = r.sync(ctx, infra, status)
+	}
+	if syncErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hyperv1.InfrastructureSyncFailedReason
+		var reasonErr *conditionReasonError
+		if errors.As(syncErr, &reasonErr) {
+			condition.Reason = reasonErr.reason
+		}
+		condition.Message = syncErr.Error()
+	} else {
+		status.LastSyncTime = &metav1.Time{Time: r.Clock.Now()}
+		status.ObservedGeneration = infra.Generation
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	if !equality.Semantic.DeepEqual(*status, infra.Status) {
+		infra.Status = *status
+		if err := r.Status().Update(ctx, infra); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+	if syncErr != nil {
+		return ctrl.Result{}, syncErr
+	}
+	resyncInterval := infra.Spec.ResyncInterval.Duration
+	if resyncInterval <= 0 {
+		resyncInterval = defaultResyncInterval
+	}
+	return ctrl.Result{RequeueAfter: resyncInterval}, nil
+}
+
+// hostedClusterUsing returns a HostedCluster using the infrastructure, either by naming it in its
+// annotation or by using its infraID in any namespace, or nil if there is none.
+func (r *Reconciler) hostedClusterUsing(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) (*hyperv1.HostedCluster, error) {
+	hostedClusters := &hyperv1.HostedClusterList{}
+	if err := r.List(ctx, hostedClusters); err != nil {
+		return nil, fmt.Errorf("failed to list hosted clusters: %w", err)
+	}
+	for i := range hostedClusters.Items {
+		hcluster := &hostedClusters.Items[i]
+		if hcluster.Spec.InfraID == infra.Spec.InfraID ||
+			(hcluster.Namespace == infra.Namespace && hcluster.Annotations[hyperv1.HostedClusterInfrastructureAnnotation] == infra.Name) {
+			return hcluster, nil
+		}
+	}
+	return nil, nil
+}
+
+// conditionReasonError is an error surfaced in the Available condition with a specific reason.
+type conditionReasonError struct {
+	reason string
+	err    error
+}
+
+func (e *conditionReasonError) Error() string {
+	return e.err.Error()
+}
+
+func (e *conditionReasonError) Unwrap() error {
+	return e.err
+}
+
+// sync creates the missing cloud resources of the infrastructure and reports them in the status.
+func (r *Reconciler) sync(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure, status *hyperv1.HostedClusterInfrastructureStatus) error {
+	switch {
+	case infra.Spec.Platform.Type == hyperv1.AWSPlatform && infra.Spec.Platform.AWS != nil:
+		return r.syncAWS(ctx, infra, status)
+	case infra.Spec.Platform.Type == hyperv1.AzurePlatform && infra.Spec.Platform.Azure != nil:
+		return r.syncAzure(ctx, infra, status)
+	case infra.Spec.Platform.Type == hyperv1.PowerVSPlatform && infra.Spec.Platform.PowerVS != nil:
+		return r.syncPowerVS(ctx, infra, status)
+	default:
+		return &conditionReasonError{reason: hyperv1.InfrastructureInvalidConfigReason, err: fmt.Errorf("unsupported platform %s", infra.Spec.Platform.Type)}
+	}
+}
+
+// verify checks that the cloud resources reported in the status of the infrastructure still exist.
+func (r *Reconciler) verify(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) error {
+	switch {
+	case infra.Spec.Platform.AWS != nil && infra.Status.AWS != nil:
+		return r.verifyAWS(ctx, infra)
+	case infra.Spec.Platform.Azure != nil && infra.Status.Azure != nil:
+		return r.verifyAzure(ctx, infra)
+	case infra.Spec.Platform.PowerVS != nil && infra.Status.PowerVS != nil:
+		return r.verifyPowerVS(ctx, infra)
+	default:
+		return errors.New("no cloud resources reported")
+	}
+}
+
+// delete deletes the cloud resources of the infrastructure. Adopted resources are kept.
+func (r *Reconciler) delete(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) error {
+	switch {
+	case infra.Spec.Platform.AWS != nil:
+		return r.deleteAWS(ctx, infra)
+	case infra.Spec.Platform.Azure != nil:
+		return r.deleteAzure(ctx, infra)
+	case infra.Spec.Platform.PowerVS != nil:
+		return r.deletePowerVS(ctx, infra)
+	default:
+		return nil
+	}
+}
+
+// credentials reads the given keys of a credentials secret of the infrastructure, all of the required keys
+// must be set.
+func (r *Reconciler) credentials(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure, secretRef corev1.LocalObjectReference, requiredKeys ...string) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: infra.Namespace, Name: secretRef.Name}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret %s: %w", key, err)
+	}
+	for _, secretKey := range requiredKeys {
+		if len(secret.Data[secretKey]) == 0 {
+			return nil, fmt.Errorf("credentials secret %s has no %q key", key, secretKey)
+		}
+	}
+	return secret.Data, nil
+}
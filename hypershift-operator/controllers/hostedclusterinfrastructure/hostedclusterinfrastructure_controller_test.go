@@ -0,0 +1,516 @@
+package hostedclusterinfrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/support/api"
+	awsinfra "github.com/openshift/hypershift/support/infra/aws"
+	azureinfra "github.com/openshift/hypershift/support/infra/azure"
+	powervsinfra "github.com/openshift/hypershift/support/infra/powervs"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeAWSInfrastructure struct {
+	createInfraErr error
+	verifyInfraErr error
+
+	createInfraOpts  *awsinfra.CreateInfraOptions
+	createIAMOpts    *awsinfra.CreateIAMOptions
+	verifiedInfra    *awsinfra.CreateInfraOutput
+	verifiedIAM      *awsinfra.CreateIAMOutput
+	destroyInfraOpts *awsinfra.DestroyInfraOptions
+	destroyIAMOpts   *awsinfra.DestroyIAMOptions
+}
+
+func (f *fakeAWSInfrastructure) CreateInfra(_ context.Context, opts *awsinfra.CreateInfraOptions) (*awsinfra.CreateInfraOutput, error) {
+	f.createInfraOpts = opts
+	if f.createInfraErr != nil {
+		return nil, f.createInfraErr
+	}
+	return &awsinfra.CreateInfraOutput{
+		VPCID:         "vpc-1",
+		MachineCIDR:   "10.0.0.0/16",
+		Zones:         []*awsinfra.CreateInfraOutputZone{{Name: "us-east-1a", SubnetID: "subnet-1"}},
+		PublicZoneID:  "Z1",
+		PrivateZoneID: "Z2",
+		LocalZoneID:   "Z3",
+	}, nil
+}
+
+func (f *fakeAWSInfrastructure) CreateIAM(_ context.Context, opts *awsinfra.CreateIAMOptions) (*awsinfra.CreateIAMOutput, error) {
+	f.createIAMOpts = opts
+	return &awsinfra.CreateIAMOutput{
+		IssuerURL:   "https://oidc.example.com/infra-1",
+		ProfileName: "infra-1-worker",
+		Roles:       hyperv1.AWSRolesRef{NodePoolManagementARN: "arn:aws:iam::1:role/infra-1-node-pool"},
+	}, nil
+}
+
+func (f *fakeAWSInfrastructure) VerifyInfra(_ context.Context, _ *awsinfra.CreateInfraOptions, result *awsinfra.CreateInfraOutput) error {
+	f.verifiedInfra = result
+	return f.verifyInfraErr
+}
+
+func (f *fakeAWSInfrastructure) VerifyIAM(_ context.Context, _ *awsinfra.CreateIAMOptions, result *awsinfra.CreateIAMOutput) error {
+	f.verifiedIAM = result
+	return nil
+}
+
+func (f *fakeAWSInfrastructure) DestroyInfra(_ context.Context, opts *awsinfra.DestroyInfraOptions) error {
+	f.destroyInfraOpts = opts
+	return nil
+}
+
+func (f *fakeAWSInfrastructure) DestroyIAM(_ context.Context, opts *awsinfra.DestroyIAMOptions) error {
+	f.destroyIAMOpts = opts
+	return nil
+}
+
+type fakeAzureInfrastructure struct {
+	createInfraOpts  *azureinfra.CreateInfraOptions
+	destroyInfraOpts *azureinfra.DestroyInfraOptions
+}
+
+func (f *fakeAzureInfrastructure) CreateInfra(_ context.Context, opts *azureinfra.CreateInfraOptions) (*azureinfra.CreateInfraOutput, error) {
+	f.createInfraOpts = opts
+	return &azureinfra.CreateInfraOutput{
+		ResourceGroupName: "example-infra-1",
+		VNetID:            "vnet-1",
+		SubnetID:          "subnet-1",
+		SecurityGroupID:   "nsg-1",
+		MachineIdentityID: "identity-1",
+		BootImageID:       "image-1",
+		PublicZoneID:      "zone-1",
+		PrivateZoneID:     "zone-2",
+	}, nil
+}
+
+func (f *fakeAzureInfrastructure) VerifyInfra(_ context.Context, _ *azureinfra.CreateInfraOptions, _ *azureinfra.CreateInfraOutput) error {
+	return nil
+}
+
+func (f *fakeAzureInfrastructure) DestroyInfra(_ context.Context, opts *azureinfra.DestroyInfraOptions) error {
+	f.destroyInfraOpts = opts
+	return nil
+}
+
+type fakePowerVSInfrastructure struct {
+	setupInfraOpts *powervsinfra.CreateInfraOptions
+}
+
+func (f *fakePowerVSInfrastructure) SetupInfra(_ context.Context, opts *powervsinfra.CreateInfraOptions, infra *powervsinfra.Infra) error {
+	f.setupInfraOpts = opts
+	infra.AccountID = "account-1"
+	infra.CloudInstanceID = "instance-1"
+	infra.VPCName = "infra-1-vpc"
+	infra.VPCID = "vpc-1"
+	if !opts.SkipSecrets {
+		secret := func(name string) *corev1.Secret {
+			return &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: opts.Namespace, Name: opts.Name + "-" + name},
+				StringData: map[string]string{"ibmcloud_api_key": name},
+			}
+		}
+		infra.Secrets = powervsinfra.Secrets{
+			KubeCloudControllerManager: secret("cloud-controller-creds"),
+			NodePoolManagement:         secret("node-management-creds"),
+			IngressOperator:            secret("ingress-creds"),
+			StorageOperator:            secret("storage-creds"),
+			ImageRegistryOperator:      secret("image-registry-creds"),
+		}
+	}
+	return nil
+}
+
+func (f *fakePowerVSInfrastructure) VerifyInfra(_ context.Context, _ *powervsinfra.CreateInfraOptions, _ *powervsinfra.Infra) error {
+	return nil
+}
+
+func (f *fakePowerVSInfrastructure) DestroyInfra(_ context.Context, _ *powervsinfra.DestroyInfraOptions, _ *powervsinfra.Infra) error {
+	return nil
+}
+
+func infrastructure() *hyperv1.HostedClusterInfrastructure {
+	return &hyperv1.HostedClusterInfrastructure{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "clusters",
+			Name:       "example",
+			Generation: 2,
+			Finalizers: []string{finalizer},
+		},
+		Spec: hyperv1.HostedClusterInfrastructureSpec{
+			InfraID: "infra-1",
+			DNS:     hyperv1.HostedClusterInfrastructureDNS{BaseDomain: "example.com", BaseDomainPrefix: ptr.To("prefix")},
+			Platform: hyperv1.HostedClusterInfrastructurePlatform{
+				Type: hyperv1.AWSPlatform,
+				AWS: &hyperv1.AWSInfrastructureSpec{
+					Region:            "us-east-1",
+					CredentialsSecret: corev1.LocalObjectReference{Name: "aws-credentials"},
+					ResourceTags:      []hyperv1.AWSResourceTag{{Key: "team", Value: "hypershift"}},
+					IAM:               &hyperv1.AWSInfrastructureIAMSpec{},
+				},
+			},
+			ResyncInterval: metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+}
+
+func credentialsSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "aws-credentials"},
+		Data: map[string][]byte{
+			accessKeyIDSecretKey:     []byte("id"),
+			secretAccessKeySecretKey: []byte("secret"),
+		},
+	}
+}
+
+// syncedStatus is the status of infrastructure() once it was synced.
+func syncedStatus() *hyperv1.HostedClusterInfrastructureStatus {
+	return &hyperv1.HostedClusterInfrastructureStatus{
+		AWS: &hyperv1.AWSInfrastructureStatus{
+			VPCID:         "vpc-1",
+			MachineCIDR:   "10.0.0.0/16",
+			Zones:         []hyperv1.AWSInfrastructureZone{{Name: "us-east-1a", SubnetID: "subnet-1"}},
+			PublicZoneID:  "Z1",
+			PrivateZoneID: "Z2",
+			LocalZoneID:   "Z3",
+			IAM: &hyperv1.AWSInfrastructureIAMStatus{
+				IssuerURL:       "https://oidc.example.com/infra-1",
+				InstanceProfile: "infra-1-worker",
+				Roles:           hyperv1.AWSRolesRef{NodePoolManagementARN: "arn:aws:iam::1:role/infra-1-node-pool"},
+			},
+		},
+		ObservedGeneration: 2,
+		Conditions: []metav1.Condition{{
+			Type:   string(hyperv1.InfrastructureAvailable),
+			Status: metav1.ConditionTrue,
+			Reason: hyperv1.InfrastructureSyncedReason,
+		}},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		objects        []client.Object
+		status         *hyperv1.HostedClusterInfrastructureStatus
+		createInfraErr error
+		verifyInfraErr error
+
+		expectedResult    ctrl.Result
+		expectSync        bool
+		expectErr         bool
+		expectedCondition metav1.Condition
+		expectedStatus    *hyperv1.AWSInfrastructureStatus
+	}{
+		{
+			name:           "synced infrastructure reports its resources",
+			objects:        []client.Object{credentialsSecret()},
+			expectedResult: ctrl.Result{RequeueAfter: 5 * time.Minute},
+			expectSync:     true,
+			expectedCondition: metav1.Condition{
+				Type:   string(hyperv1.InfrastructureAvailable),
+				Status: metav1.ConditionTrue,
+				Reason: hyperv1.InfrastructureSyncedReason,
+			},
+			expectedStatus: &hyperv1.AWSInfrastructureStatus{
+				VPCID:         "vpc-1",
+				MachineCIDR:   "10.0.0.0/16",
+				Zones:         []hyperv1.AWSInfrastructureZone{{Name: "us-east-1a", SubnetID: "subnet-1"}},
+				PublicZoneID:  "Z1",
+				PrivateZoneID: "Z2",
+				LocalZoneID:   "Z3",
+				IAM: &hyperv1.AWSInfrastructureIAMStatus{
+					IssuerURL:       "https://oidc.example.com/infra-1",
+					InstanceProfile: "infra-1-worker",
+					Roles:           hyperv1.AWSRolesRef{NodePoolManagementARN: "arn:aws:iam::1:role/infra-1-node-pool"},
+				},
+			},
+		},
+		{
+			name:           "failed sync is reported",
+			objects:        []client.Object{credentialsSecret()},
+			createInfraErr: errors.New("nat gateway quota exceeded"),
+			expectErr:      true,
+			expectSync:     true,
+			expectedCondition: metav1.Condition{
+				Type:    string(hyperv1.InfrastructureAvailable),
+				Status:  metav1.ConditionFalse,
+				Reason:  hyperv1.InfrastructureSyncFailedReason,
+				Message: "failed to sync infrastructure: nat gateway quota exceeded",
+			},
+		},
+		{
+			name:           "infrastructure synced for its generation is only verified",
+			objects:        []client.Object{credentialsSecret()},
+			status:         syncedStatus(),
+			expectedResult: ctrl.Result{RequeueAfter: 5 * time.Minute},
+			expectedCondition: metav1.Condition{
+				Type:   string(hyperv1.InfrastructureAvailable),
+				Status: metav1.ConditionTrue,
+				Reason: hyperv1.InfrastructureSyncedReason,
+			},
+			expectedStatus: syncedStatus().AWS,
+		},
+		{
+			name:           "drifted infrastructure is synced again",
+			objects:        []client.Object{credentialsSecret()},
+			status:         syncedStatus(),
+			verifyInfraErr: errors.New("found 0 of the 1 NAT gateways of VPC vpc-1"),
+			expectedResult: ctrl.Result{RequeueAfter: 5 * time.Minute},
+			expectSync:     true,
+			expectedCondition: metav1.Condition{
+				Type:   string(hyperv1.InfrastructureAvailable),
+				Status: metav1.ConditionTrue,
+				Reason: hyperv1.InfrastructureSyncedReason,
+			},
+			expectedStatus: syncedStatus().AWS,
+		},
+		{
+			name:      "missing credentials are reported",
+			expectErr: true,
+			expectedCondition: metav1.Condition{
+				Type:   string(hyperv1.InfrastructureAvailable),
+				Status: metav1.ConditionFalse,
+				Reason: hyperv1.InfrastructureCredentialsErrorReason,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			infra := infrastructure()
+			if tc.status != nil {
+				infra.Status = *tc.status
+			}
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(append(tc.objects, infra)...).WithStatusSubresource(infra).Build()
+			aws := &fakeAWSInfrastructure{createInfraErr: tc.createInfraErr, verifyInfraErr: tc.verifyInfraErr}
+			r := &Reconciler{Client: c, Clock: clocktesting.NewFakePassiveClock(now), aws: aws}
+
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(infra)})
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(result).To(Equal(tc.expectedResult))
+
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(infra), infra)).To(Succeed())
+			condition := meta.FindStatusCondition(infra.Status.Conditions, string(hyperv1.InfrastructureAvailable))
+			g.Expect(condition).ToNot(BeNil())
+			g.Expect(condition.Status).To(Equal(tc.expectedCondition.Status))
+			g.Expect(condition.Reason).To(Equal(tc.expectedCondition.Reason))
+			if tc.expectedCondition.Message != "" {
+				g.Expect(condition.Message).To(Equal(tc.expectedCondition.Message))
+			}
+			g.Expect(infra.Status.AWS).To(Equal(tc.expectedStatus))
+			if tc.expectedStatus != nil {
+				g.Expect(infra.Status.LastSyncTime.Time.Equal(now)).To(BeTrue())
+				g.Expect(infra.Status.ObservedGeneration).To(Equal(int64(2)))
+			}
+
+			g.Expect(aws.createInfraOpts != nil).To(Equal(tc.expectSync))
+			if tc.status != nil {
+				g.Expect(aws.verifiedInfra.VPCID).To(Equal("vpc-1"))
+				g.Expect(aws.verifiedInfra.Zones).To(HaveLen(1))
+			}
+			if aws.createInfraOpts != nil {
+				g.Expect(aws.createInfraOpts.CredentialsSecretData).To(Equal(&util.CredentialsSecretData{AWSAccessKeyID: "id", AWSSecretAccessKey: "secret"}))
+				g.Expect(aws.createInfraOpts.BaseDomainPrefix).To(Equal("prefix"))
+				g.Expect(aws.createInfraOpts.AdditionalTags).To(Equal([]string{"team=hypershift"}))
+			}
+		})
+	}
+}
+
+func TestReconcileDeletion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	infra := infrastructure()
+	infra.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	annotatedCluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "clusters",
+			Name:        "example",
+			Annotations: map[string]string{hyperv1.HostedClusterInfrastructureAnnotation: "example"},
+		},
+	}
+	otherNamespaceCluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "example"},
+		Spec:       hyperv1.HostedClusterSpec{InfraID: "infra-1"},
+	}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(infra, annotatedCluster, otherNamespaceCluster, credentialsSecret()).Build()
+	aws := &fakeAWSInfrastructure{}
+	r := &Reconciler{Client: c, Clock: clocktesting.NewFakePassiveClock(time.Now()), aws: aws}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(infra)}
+
+	// the infrastructure is kept while a hosted cluster of any namespace uses it
+	for _, hcluster := range []*hyperv1.HostedCluster{annotatedCluster, otherNamespaceCluster} {
+		result, err := r.Reconcile(ctx, req)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.RequeueAfter).To(Equal(inUseRequeueInterval))
+		g.Expect(aws.destroyInfraOpts).To(BeNil())
+		g.Expect(c.Delete(ctx, hcluster)).To(Succeed())
+	}
+
+	result, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+	g.Expect(aws.destroyInfraOpts).ToNot(BeNil())
+	g.Expect(aws.destroyInfraOpts.InfraID).To(Equal("infra-1"))
+	g.Expect(aws.destroyInfraOpts.BaseDomainPrefix).To(Equal("prefix"))
+	g.Expect(aws.destroyInfraOpts.CredentialsSecretData.AWSAccessKeyID).To(Equal("id"))
+	g.Expect(aws.destroyIAMOpts).ToNot(BeNil())
+	g.Expect(aws.destroyIAMOpts.CredentialsSecretData.AWSAccessKeyID).To(Equal("id"))
+
+	// removing the finalizer lets the fake client delete the infrastructure
+	err = c.Get(ctx, req.NamespacedName, &hyperv1.HostedClusterInfrastructure{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
+
+func TestReconcileAzure(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	infra := infrastructure()
+	infra.Spec.Platform = hyperv1.HostedClusterInfrastructurePlatform{
+		Type: hyperv1.AzurePlatform,
+		Azure: &hyperv1.AzureInfrastructureSpec{
+			Location:          "eastus",
+			CredentialsSecret: corev1.LocalObjectReference{Name: "azure-credentials"},
+			RHCOSImage:        "https://rhcos.blob.core.windows.net/imagebucket/rhcos.vhd",
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "azure-credentials"},
+		Data: map[string][]byte{
+			azureSubscriptionIDSecretKey: []byte("subscription-1"),
+			azureTenantIDSecretKey:       []byte("tenant-1"),
+			azureClientIDSecretKey:       []byte("client-1"),
+			azureClientSecretSecretKey:   []byte("secret"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(infra, secret).WithStatusSubresource(infra).Build()
+	azure := &fakeAzureInfrastructure{}
+	r := &Reconciler{Client: c, Clock: clocktesting.NewFakePassiveClock(time.Now()), azure: azure}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(infra)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(azure.createInfraOpts.Credentials).To(Equal(&util.AzureCreds{SubscriptionID: "subscription-1", TenantID: "tenant-1", ClientID: "client-1", ClientSecret: "secret"}))
+	g.Expect(azure.createInfraOpts.BootImageID).To(BeEmpty())
+	g.Expect(c.Get(ctx, req.NamespacedName, infra)).To(Succeed())
+	g.Expect(meta.IsStatusConditionTrue(infra.Status.Conditions, string(hyperv1.InfrastructureAvailable))).To(BeTrue())
+	g.Expect(infra.Status.Azure).To(Equal(&hyperv1.AzureInfrastructureStatus{
+		SubscriptionID:    "subscription-1",
+		ResourceGroupName: "example-infra-1",
+		VnetID:            "vnet-1",
+		SubnetID:          "subnet-1",
+		SecurityGroupID:   "nsg-1",
+		MachineIdentityID: "identity-1",
+		BootImageID:       "image-1",
+		PublicZoneID:      "zone-1",
+		PrivateZoneID:     "zone-2",
+	}))
+
+	// a changed spec is synced again, without uploading the boot image again
+	infra.Spec.Platform.Azure.ResourceGroupTags = map[string]string{"team": "hypershift"}
+	// the fake client does not bump the generation
+	infra.Generation++
+	g.Expect(c.Update(ctx, infra)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(azure.createInfraOpts.BootImageID).To(Equal("image-1"))
+
+	// the created resource group is deleted
+	g.Expect(c.Delete(ctx, infra)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(azure.destroyInfraOpts.GetResourceGroupName()).To(Equal("example-infra-1"))
+}
+
+func TestReconcilePowerVS(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	infra := infrastructure()
+	infra.Spec.Platform = hyperv1.HostedClusterInfrastructurePlatform{
+		Type: hyperv1.PowerVSPlatform,
+		PowerVS: &hyperv1.PowerVSInfrastructureSpec{
+			ResourceGroup:     "hypershift",
+			Region:            "us-south",
+			Zone:              "us-south",
+			VPCRegion:         "us-south",
+			CredentialsSecret: corev1.LocalObjectReference{Name: "powervs-credentials"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "powervs-credentials"},
+		Data:       map[string][]byte{powerVSAPIKeySecretKey: []byte("api-key")},
+	}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(infra, secret).WithStatusSubresource(infra).Build()
+	powervs := &fakePowerVSInfrastructure{}
+	r := &Reconciler{Client: c, Clock: clocktesting.NewFakePassiveClock(time.Now()), powervs: powervs}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(infra)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(powervs.setupInfraOpts.APIKey).To(Equal("api-key"))
+	g.Expect(powervs.setupInfraOpts.SkipSecrets).To(BeFalse())
+	g.Expect(c.Get(ctx, req.NamespacedName, infra)).To(Succeed())
+	g.Expect(infra.Status.PowerVS.ServiceInstanceID).To(Equal("instance-1"))
+	g.Expect(infra.Status.PowerVS.VPC.Name).To(Equal("infra-1-vpc"))
+	g.Expect(infra.Status.PowerVS.Credentials.NodePoolManagementCreds.Name).To(Equal("example-node-management-creds"))
+
+	// the secrets of the service IDs are saved and owned by the infrastructure
+	nodePoolSecret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "clusters", Name: "example-node-management-creds"}, nodePoolSecret)).To(Succeed())
+	g.Expect(nodePoolSecret.Data).To(HaveKeyWithValue("ibmcloud_api_key", []byte("node-management-creds")))
+	g.Expect(nodePoolSecret.OwnerReferences).To(HaveLen(1))
+	g.Expect(nodePoolSecret.OwnerReferences[0].Name).To(Equal("example"))
+
+	// the service IDs are kept when the infrastructure is synced again
+	infra.Spec.Platform.PowerVS.TransitGatewayLocation = "us-east"
+	// the fake client does not bump the generation
+	infra.Generation++
+	g.Expect(c.Update(ctx, infra)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(powervs.setupInfraOpts.SkipSecrets).To(BeTrue())
+	g.Expect(powervs.setupInfraOpts.SkipDNSRecordCheck).To(BeTrue())
+	g.Expect(c.Get(ctx, req.NamespacedName, infra)).To(Succeed())
+	g.Expect(infra.Status.PowerVS.Credentials.NodePoolManagementCreds.Name).To(Equal("example-node-management-creds"))
+
+	// and recreated once one of their secrets is lost
+	g.Expect(c.Delete(ctx, nodePoolSecret)).To(Succeed())
+	infra.Spec.Platform.PowerVS.TransitGatewayLocation = "us-south"
+	// the fake client does not bump the generation
+	infra.Generation++
+	g.Expect(c.Update(ctx, infra)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(powervs.setupInfraOpts.SkipSecrets).To(BeFalse())
+	g.Expect(powervs.setupInfraOpts.RecreateSecrets).To(BeTrue())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "clusters", Name: "example-node-management-creds"}, nodePoolSecret)).To(Succeed())
+}
@@ -0,0 +1,244 @@
+package hostedclusterinfrastructure
+
+import (
+	"context"
+	"fmt"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	powervsinfra "github.com/openshift/hypershift/support/infra/powervs"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// powerVSAPIKeySecretKey is the key of the IBM Cloud API key in the PowerVS credentials secret.
+const powerVSAPIKeySecretKey = "ibmcloud_api_key"
+
+// powerVSInfrastructure sets up, verifies and destroys IBM Cloud PowerVS resources. It is implemented by
+// the logic of the `hypershift create/destroy infra powervs` commands, which look up the resources of an
+// infra ID by their names and only create the missing ones, so that running them again repairs drift.
+type powerVSInfrastructure interface {
+	SetupInfra(ctx context.Context, opts *powervsinfra.CreateInfraOptions, infra *powervsinfra.Infra) error
+	VerifyInfra(ctx context.Context, opts *powervsinfra.CreateInfraOptions, infra *powervsinfra.Infra) error
+	DestroyInfra(ctx context.Context, opts *powervsinfra.DestroyInfraOptions, infra *powervsinfra.Infra) error
+}
+
+type cliPowerVSInfrastructure struct{}
+
+func (c *cliPowerVSInfrastructure) SetupInfra(ctx context.Context, opts *powervsinfra.CreateInfraOptions, infra *powervsinfra.Infra) error {
+	return infra.SetupInfra(ctx, ctrl.LoggerFrom(ctx), opts)
+}
+
+func (c *cliPowerVSInfrastructure) VerifyInfra(ctx context.Context, opts *powervsinfra.CreateInfraOptions, infra *powervsinfra.Infra) error {
+	return opts.VerifyInfra(ctx, ctrl.LoggerFrom(ctx), infra)
+}
+
+func (c *cliPowerVSInfrastructure) DestroyInfra(ctx context.Context, opts *powervsinfra.DestroyInfraOptions, infra *powervsinfra.Infra) error {
+	return opts.DestroyInfra(ctx, ctrl.LoggerFrom(ctx), infra)
+}
+
+func (r *Reconciler) syncPowerVS(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure, status *hyperv1.HostedClusterInfrastructureStatus) error {
+	apiKey, err := r.powerVSAPIKey(ctx, infra)
+	if err != nil {
+		return &conditionReasonError{reason: hyperv1.InfrastructureCredentialsErrorReason, err: err}
+	}
+
+	createOpts := powerVSCreateInfraOptions(infra, apiKey)
+	if err := createOpts.Validate(); err != nil {
+		return &conditionReasonError{reason: hyperv1.InfrastructureInvalidConfigReason, err: err}
+	}
+	if infra.Status.PowerVS != nil {
+		// the records of the cluster using the infrastructure exist by now
+		createOpts.SkipDNSRecordCheck = true
+		// the API keys of the service IDs can only be read when they are created, so the service IDs
+		// are only recreated when one of their secrets is lost
+		createOpts.SkipSecrets, err = r.powerVSSecretsExist(ctx, infra)
+		if err != nil {
+			return err
+		}
+	}
+	createOpts.RecreateSecrets = !createOpts.SkipSecrets
+
+	output := &powervsinfra.Infra{
+		ID:            infra.Spec.InfraID,
+		BaseDomain:    infra.Spec.DNS.BaseDomain,
+		ResourceGroup: createOpts.ResourceGroup,
+		Region:        createOpts.Region,
+		Zone:          createOpts.Zone,
+		VPCRegion:     createOpts.VPCRegion,
+	}
+	if err := r.powervs.SetupInfra(ctx, createOpts, output); err != nil {
+		return fmt.Errorf("failed to sync infrastructure: %w", err)
+	}
+
+	powerVSStatus := &hyperv1.PowerVSInfrastructureStatus{
+		AccountID:         output.AccountID,
+		CISInstanceCRN:    output.CISCRN,
+		CISDomainID:       output.CISDomainID,
+		ServiceInstanceID: output.CloudInstanceID,
+		DHCPID:            output.DHCPID,
+		Subnet: hyperv1.PowerVSResourceReference{
+			ID:   &output.DHCPSubnetID,
+			Name: &output.DHCPSubnet,
+		},
+		VPC: hyperv1.PowerVSVPC{
+			Name:   output.VPCName,
+			Region: output.VPCRegion,
+			Subnet: output.VPCSubnetName,
+		},
+		VPCID:       output.VPCID,
+		VPCSubnetID: output.VPCSubnetID,
+	}
+	if createOpts.SkipSecrets {
+		powerVSStatus.Credentials = infra.Status.PowerVS.Credentials
+	} else {
+		secrets := output.Secrets
+		for _, secret := range []*corev1.Secret{secrets.KubeCloudControllerManager, secrets.NodePoolManagement, secrets.IngressOperator, secrets.StorageOperator, secrets.ImageRegistryOperator} {
+			if err := r.reconcilePowerVSSecret(ctx, infra, secret); err != nil {
+				return err
+			}
+		}
+		powerVSStatus.Credentials = hyperv1.PowerVSInfrastructureCredentials{
+			KubeCloudControllerCreds:        corev1.LocalObjectReference{Name: secrets.KubeCloudControllerManager.Name},
+			NodePoolManagementCreds:         corev1.LocalObjectReference{Name: secrets.NodePoolManagement.Name},
+			IngressOperatorCloudCreds:       corev1.LocalObjectReference{Name: secrets.IngressOperator.Name},
+			StorageOperatorCloudCreds:       corev1.LocalObjectReference{Name: secrets.StorageOperator.Name},
+			ImageRegistryOperatorCloudCreds: corev1.LocalObjectReference{Name: secrets.ImageRegistryOperator.Name},
+		}
+	}
+	status.PowerVS = powerVSStatus
+	return nil
+}
+
+func (r *Reconciler) verifyPowerVS(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) error {
+	apiKey, err := r.powerVSAPIKey(ctx, infra)
+	if err != nil {
+		return err
+	}
+	exist, err := r.powerVSSecretsExist(ctx, infra)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("credentials secrets not found")
+	}
+
+	powerVSStatus := infra.Status.PowerVS
+	return r.powervs.VerifyInfra(ctx, powerVSCreateInfraOptions(infra, apiKey), &powervsinfra.Infra{
+		AccountID:       powerVSStatus.AccountID,
+		CloudInstanceID: powerVSStatus.ServiceInstanceID,
+		DHCPID:          powerVSStatus.DHCPID,
+		VPCID:           powerVSStatus.VPCID,
+		VPCSubnetID:     powerVSStatus.VPCSubnetID,
+	})
+}
+
+// deletePowerVS deletes the IBM Cloud resources of the infrastructure and the service IDs of its
+// credentials secrets. The secrets themselves are garbage collected with the infrastructure.
+func (r *Reconciler) deletePowerVS(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) error {
+	apiKey, err := r.powerVSAPIKey(ctx, infra)
+	if err != nil {
+		return err
+	}
+
+	spec := infra.Spec.Platform.PowerVS
+	destroyOpts := &powervsinfra.DestroyInfraOptions{
+		Name:                   infra.Name,
+		Namespace:              infra.Namespace,
+		InfraID:                infra.Spec.InfraID,
+		BaseDomain:             infra.Spec.DNS.BaseDomain,
+		ResourceGroup:          spec.ResourceGroup,
+		Region:                 spec.Region,
+		Zone:                   spec.Zone,
+		VPCRegion:              spec.VPCRegion,
+		PER:                    spec.PowerEdgeRouter,
+		TransitGatewayLocation: spec.TransitGatewayLocation,
+		APIKey:                 apiKey,
+	}
+	var output *powervsinfra.Infra
+	if powerVSStatus := infra.Status.PowerVS; powerVSStatus != nil {
+		destroyOpts.CISCRN = powerVSStatus.CISInstanceCRN
+		destroyOpts.CISDomainID = powerVSStatus.CISDomainID
+		destroyOpts.DHCPID = powerVSStatus.DHCPID
+		output = &powervsinfra.Infra{
+			CloudInstanceID: powerVSStatus.ServiceInstanceID,
+			VPCID:           powerVSStatus.VPCID,
+			VPCSubnetID:     powerVSStatus.VPCSubnetID,
+		}
+	}
+	if err := r.powervs.DestroyInfra(ctx, destroyOpts, output); err != nil {
+		return fmt.Errorf("failed to destroy infrastructure: %w", err)
+	}
+	return nil
+}
+
+// powerVSAPIKey reads the IBM Cloud API key of the infrastructure from its credentials secret.
+func (r *Reconciler) powerVSAPIKey(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) (string, error) {
+	data, err := r.credentials(ctx, infra, infra.Spec.Platform.PowerVS.CredentialsSecret, powerVSAPIKeySecretKey)
+	if err != nil {
+		return "", err
+	}
+	return string(data[powerVSAPIKeySecretKey]), nil
+}
+
+// powerVSSecretsExist returns whether all the credentials secrets reported in the status exist.
+func (r *Reconciler) powerVSSecretsExist(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure) (bool, error) {
+	credentials := infra.Status.PowerVS.Credentials
+	for _, ref := range []corev1.LocalObjectReference{credentials.KubeCloudControllerCreds, credentials.NodePoolManagementCreds, credentials.IngressOperatorCloudCreds, credentials.StorageOperatorCloudCreds, credentials.ImageRegistryOperatorCloudCreds} {
+		if len(ref.Name) == 0 {
+			return false, nil
+		}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: infra.Namespace, Name: ref.Name}, &corev1.Secret{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to get secret %s: %w", ref.Name, err)
+		}
+	}
+	return true, nil
+}
+
+// reconcilePowerVSSecret creates or updates a credentials secret of a service ID, owned by the
+// infrastructure.
+func (r *Reconciler) reconcilePowerVSSecret(ctx context.Context, infra *hyperv1.HostedClusterInfrastructure, secret *corev1.Secret) error {
+	if secret == nil {
+		return fmt.Errorf("credentials secret not set up")
+	}
+	data := map[string][]byte{}
+	for key, value := range secret.StringData {
+		data[key] = []byte(value)
+	}
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	existing := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: infra.Namespace, Name: secret.Name}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, existing, func() error {
+		existing.Type = corev1.SecretTypeOpaque
+		existing.Data = data
+		return controllerutil.SetOwnerReference(infra, existing, r.Scheme())
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile secret %s: %w", existing.Name, err)
+	}
+	return nil
+}
+
+func powerVSCreateInfraOptions(infra *hyperv1.HostedClusterInfrastructure, apiKey string) *powervsinfra.CreateInfraOptions {
+	spec := infra.Spec.Platform.PowerVS
+	return &powervsinfra.CreateInfraOptions{
+		Name:                        infra.Name,
+		Namespace:                   infra.Namespace,
+		BaseDomain:                  infra.Spec.DNS.BaseDomain,
+		ResourceGroup:               spec.ResourceGroup,
+		InfraID:                     infra.Spec.InfraID,
+		Region:                      spec.Region,
+		Zone:                        spec.Zone,
+		VPCRegion:                   spec.VPCRegion,
+		PER:                         spec.PowerEdgeRouter,
+		TransitGatewayLocation:      spec.TransitGatewayLocation,
+		TransitGatewayGlobalRouting: spec.TransitGatewayGlobalRouting,
+		APIKey:                      apiKey,
+	}
+}
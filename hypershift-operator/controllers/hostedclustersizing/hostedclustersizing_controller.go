@@ -35,13 +35,15 @@ func newReconciler(
 	hypershiftClient hypershiftclient.Interface,
 	lister client.Client,
 	now func() time.Time,
+	usageFor usageFunc,
 	hypershiftOperatorImage string,
 	releaseProvider *releaseinfo.ProviderWithOpenShiftImageRegistryOverridesDecorator,
 	imageMetadataProvider *hyperutil.RegistryClientImageMetadataProvider,
 ) *reconciler {
 	return &reconciler{
-		client: hypershiftClient,
-		now:    now,
+		client:   hypershiftClient,
+		now:      now,
+		usageFor: usageFor,
 
 		getClusterSizingConfiguration: func(ctx context.Context) (*schedulingv1alpha1.ClusterSizingConfiguration, error) {
 			config := schedulingv1alpha1.ClusterSizingConfiguration{}
@@ -110,6 +112,8 @@ type reconciler struct {
 
 	now func() time.Time
 
+	usageFor usageFunc
+
 	getClusterSizingConfiguration      func(context.Context) (*schedulingv1alpha1.ClusterSizingConfiguration, error)
 	getHostedCluster                   func(context.Context, types.NamespacedName) (*hypershiftv1beta1.HostedCluster, error)
 	listHostedClusters                 func(context.Context) (*hypershiftv1beta1.HostedClusterList, error)
@@ -141,12 +145,16 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	}
 	if action != nil {
 		if action.applyCfg != nil {
+			fieldManager := ControllerName
+			if action.fieldManager != "" {
+				fieldManager = action.fieldManager
+			}
 			if action.applyCfg.Status != nil {
-				if _, err := r.client.HypershiftV1beta1().HostedClusters(request.Namespace).ApplyStatus(ctx, action.applyCfg, metav1.ApplyOptions{FieldManager: ControllerName}); err != nil {
+				if _, err := r.client.HypershiftV1beta1().HostedClusters(request.Namespace).ApplyStatus(ctx, action.applyCfg, metav1.ApplyOptions{FieldManager: fieldManager, Force: action.force}); err != nil {
 					return reconcile.Result{}, err
 				}
 			} else {
-				if _, err := r.client.HypershiftV1beta1().HostedClusters(request.Namespace).Apply(ctx, action.applyCfg, metav1.ApplyOptions{FieldManager: ControllerName}); err != nil {
+				if _, err := r.client.HypershiftV1beta1().HostedClusters(request.Namespace).Apply(ctx, action.applyCfg, metav1.ApplyOptions{FieldManager: fieldManager, Force: action.force}); err != nil {
 					return reconcile.Result{}, err
 				}
 			}
@@ -162,6 +170,10 @@ type ignoreError error
 type action struct {
 	requeueAfter time.Duration
 	applyCfg     *hypershiftv1beta1applyconfigurations.HostedClusterApplyConfiguration
+	// fieldManager overrides the field manager used to apply the configuration
+	fieldManager string
+	// force takes ownership of fields set by other field managers
+	force bool
 }
 
 func (r *reconciler) reconcile(
@@ -256,7 +268,7 @@ func (r *reconciler) reconcile(
 		if cfg != nil {
 			return &action{applyCfg: cfg}, nil
 		}
-		return nil, nil
+		return r.reconcileUsage(ctx, config, hostedCluster, *lastTransitionTime)
 	}
 
 	previousMinimumSize := uint32(0)
@@ -428,17 +440,20 @@ func (r *reconciler) determineNodeCount(ctx context.Context, hostedCluster *hype
 }
 
 // transitionsWithinSlidingWindow determines the number of hosted clusters that have transitioned within the sliding
-// window from now; returning both the count of transitions and the duration until the count will change next
+// window from now; returning both the count of transitions and the duration until the count will change next.
+// Changes to resource requests sized from usage count as transitions, too.
 func transitionsWithinSlidingWindow(hostedClusters *hypershiftv1beta1.HostedClusterList, slidingWindow time.Duration, now time.Time) (int, time.Duration) {
 	cutoff := now.Add(-slidingWindow)
 	var withinWindow int
 	oldestTransition := now
 	for _, hostedCluster := range hostedClusters.Items {
 		lastTransitionTime, _ := previousTransitionFor(&hostedCluster)
-		if lastTransitionTime != nil && (*lastTransitionTime).After(cutoff) {
-			withinWindow++
-			if (*lastTransitionTime).Before(oldestTransition) {
-				oldestTransition = *lastTransitionTime
+		for _, transitionTime := range []*time.Time{lastTransitionTime, previousResourceRequestsTransitionFor(&hostedCluster)} {
+			if transitionTime != nil && (*transitionTime).After(cutoff) {
+				withinWindow++
+				if (*transitionTime).Before(oldestTransition) {
+					oldestTransition = *transitionTime
+				}
 			}
 		}
 	}
@@ -492,7 +507,7 @@ func conditionPresent(hostedCluster *hypershiftv1beta1.HostedCluster, conditionT
 	return false
 }
 
-var managedConditions = sets.New[string](hypershiftv1beta1.ClusterSizeComputed, hypershiftv1beta1.ClusterSizeTransitionPending, hypershiftv1beta1.ClusterSizeTransitionRequired)
+var managedConditions = sets.New[string](hypershiftv1beta1.ClusterSizeComputed, hypershiftv1beta1.ClusterSizeTransitionPending, hypershiftv1beta1.ClusterSizeTransitionRequired, hypershiftv1beta1.ClusterResourceRequestsComputed)
 
 // conditions provides the full list of conditions that we need to send with each SSA call -
 // if one field manager sets some conditions in one call, and another set in a second, any conditions
@@ -3,6 +3,7 @@ package hostedclustersizing
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	hypershiftv1beta1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
@@ -14,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -42,6 +44,11 @@ func SetupWithManager(ctx context.Context, mgr ctrl.Manager, hypershiftOperatorI
 		return err
 	}
 
+	kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+
 	if _, err := hypershiftClient.SchedulingV1alpha1().ClusterSizingConfigurations().Get(ctx, "cluster", metav1.GetOptions{}); err != nil {
 		if !errors.IsNotFound(err) {
 			return fmt.Errorf("failed to get sizing configuration: %w", err)
@@ -125,6 +132,7 @@ func SetupWithManager(ctx context.Context, mgr ctrl.Manager, hypershiftOperatorI
 			RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(1*time.Second, 10*time.Second),
 		}).Complete(newReconciler(
 		hypershiftClient, mgr.GetClient(), time.Now,
+		newUsageFunc(kubeClient.CoreV1().RESTClient(), &http.Client{Timeout: 30 * time.Second}),
 		hypershiftOperatorImage, releaseProvider, imageMetadataProvider,
 	)); err != nil {
 		return fmt.Errorf("failed to set up %s controller: %w", ControllerName, err)
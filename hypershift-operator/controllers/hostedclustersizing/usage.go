@@ -0,0 +1,348 @@
+package hostedclustersizing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	hypershiftv1beta1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	schedulingv1alpha1 "github.com/openshift/hypershift/api/scheduling/v1alpha1"
+	hypershiftv1beta1applyconfigurations "github.com/openshift/hypershift/client/applyconfiguration/hypershift/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1applyconfigurations "k8s.io/client-go/applyconfigurations/meta/v1"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// usageFieldManager owns the resource request annotations set from observed usage. It must differ from the
+	// field manager used for the size label, as each apply removes the fields its manager previously set but omits.
+	usageFieldManager = ControllerName + "-usage"
+
+	// usageResyncInterval is how often the usage of control plane components is re-evaluated.
+	usageResyncInterval = 5 * time.Minute
+
+	// defaultPrometheusWindow is the period over which the peak usage is taken when none is configured.
+	defaultPrometheusWindow = time.Hour
+)
+
+// usageFunc returns the usage of the container of a control plane component in the given namespace.
+type usageFunc func(ctx context.Context, config *schedulingv1alpha1.UsageBasedSizingConfiguration, namespace string, component schedulingv1alpha1.UsageBasedSizingComponent) (corev1.ResourceList, error)
+
+func newUsageFunc(metricsClient rest.Interface, httpClient *http.Client) usageFunc {
+	return func(ctx context.Context, config *schedulingv1alpha1.UsageBasedSizingConfiguration, namespace string, component schedulingv1alpha1.UsageBasedSizingComponent) (corev1.ResourceList, error) {
+		switch config.Source {
+		case schedulingv1alpha1.MetricsAPIUsageSource:
+			return metricsAPIUsage(ctx, metricsClient, namespace, component)
+		case schedulingv1alpha1.PrometheusUsageSource:
+			if config.Prometheus == nil {
+				return nil, fmt.Errorf("prometheus configuration is required for the %s usage source", config.Source)
+			}
+			return prometheusUsage(ctx, httpClient, config.Prometheus, namespace, component)
+		default:
+			return nil, fmt.Errorf("unsupported usage source %q", config.Source)
+		}
+	}
+}
+
+// podMetricsList is the subset of the metrics.k8s.io/v1beta1 PodMetricsList that we need.
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Containers []struct {
+			Name  string              `json:"name"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// metricsAPIUsage returns the highest current usage of the container across the pods of the component.
+func metricsAPIUsage(ctx context.Context, metricsClient rest.Interface, namespace string, component schedulingv1alpha1.UsageBasedSizingComponent) (corev1.ResourceList, error) {
+	raw, err := metricsClient.Get().AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %w", err)
+	}
+	var metrics podMetricsList
+	if err := json.Unmarshal(raw, &metrics); err != nil {
+		return nil, fmt.Errorf("failed to decode pod metrics: %w", err)
+	}
+	usage := corev1.ResourceList{}
+	for _, pod := range metrics.Items {
+		if !strings.HasPrefix(pod.Metadata.Name, component.DeploymentName+"-") {
+			continue
+		}
+		for _, container := range pod.Containers {
+			if container.Name != component.ContainerName {
+				continue
+			}
+			for _, name := range []corev1.ResourceName{corev1.ResourceMemory, corev1.ResourceCPU} {
+				value, ok := container.Usage[name]
+				if !ok {
+					continue
+				}
+				if current, ok := usage[name]; !ok || value.Cmp(current) > 0 {
+					usage[name] = value
+				}
+			}
+		}
+	}
+	if len(usage) == 0 {
+		return nil, fmt.Errorf("no usage reported for container %s of %s", component.ContainerName, component.DeploymentName)
+	}
+	return usage, nil
+}
+
+// prometheusQueryResponse is the subset of the Prometheus HTTP API instant query response that we need.
+type prometheusQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Value []interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// prometheusUsage returns the peak usage of the container across the pods of the component over the configured window.
+func prometheusUsage(ctx context.Context, httpClient *http.Client, config *schedulingv1alpha1.PrometheusUsageSourceConfiguration, namespace string, component schedulingv1alpha1.UsageBasedSizingComponent) (corev1.ResourceList, error) {
+	window := defaultPrometheusWindow
+	if config.Window.Duration > 0 {
+		window = config.Window.Duration
+	}
+	selector := fmt.Sprintf(`namespace=%q,pod=~%q,container=%q`, namespace, component.DeploymentName+"-.*", component.ContainerName)
+	rangeSelector := prometheusDuration(window)
+
+	memory, err := queryPrometheus(ctx, httpClient, config.URL, fmt.Sprintf(`max(max_over_time(container_memory_working_set_bytes{%s}[%s]))`, selector, rangeSelector))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memory usage: %w", err)
+	}
+	cpu, err := queryPrometheus(ctx, httpClient, config.URL, fmt.Sprintf(`max(max_over_time(rate(container_cpu_usage_seconds_total{%s}[5m])[%s:1m]))`, selector, rangeSelector))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cpu usage: %w", err)
+	}
+	return corev1.ResourceList{
+		corev1.ResourceMemory: *resource.NewQuantity(int64(memory), resource.BinarySI),
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(int64(cpu*1000), resource.DecimalSI),
+	}, nil
+}
+
+func queryPrometheus(ctx context.Context, httpClient *http.Client, baseURL, query string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/api/v1/query?"+url.Values{"query": []string{query}}.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+	var result prometheusQueryResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Status != "success" {
+		return 0, fmt.Errorf("query failed: %s", result.Error)
+	}
+	if len(result.Data.Result) == 0 || len(result.Data.Result[0].Value) != 2 {
+		return 0, fmt.Errorf("no usage reported for query %s", query)
+	}
+	value, ok := result.Data.Result[0].Value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected value %v in response", result.Data.Result[0].Value[1])
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// prometheusDuration formats a duration in the format Prometheus expects in range selectors.
+func prometheusDuration(duration time.Duration) string {
+	return fmt.Sprintf("%ds", int64(duration.Seconds()))
+}
+
+// reconcileUsage sizes the resource requests of control plane components from their observed usage, once the
+// hosted cluster has settled in its t-shirt size. Requests are set through the same override annotations the
+// t-shirt sizes use, and changes are subject to the transition delays and concurrency limits of the configuration.
+func (r *reconciler) reconcileUsage(
+	ctx context.Context,
+	config *schedulingv1alpha1.ClusterSizingConfiguration, hostedCluster *hypershiftv1beta1.HostedCluster,
+	lastTransitionTime time.Time,
+) (*action, error) {
+	usageConfig := config.Spec.UsageBasedSizing
+	if usageConfig == nil {
+		return nil, nil
+	}
+	logger := ctrl.LoggerFrom(ctx)
+
+	namespace := manifests.HostedControlPlaneNamespace(hostedCluster.Namespace, hostedCluster.Name)
+	desired := map[string]string{}
+	var increasing, changed bool
+	for _, component := range usageConfig.Components {
+		key := resourceRequestOverrideAnnotation(component)
+		current := parseResourceRequests(hostedCluster.Annotations[key])
+		usage, err := r.usageFor(ctx, usageConfig, namespace, component)
+		if err != nil {
+			// usage may not be reported yet, e.g. while the component is rolling out; the requests we set
+			// previously must still be sent with the next apply or they would be removed
+			logger.Info("Could not determine usage, keeping current resource requests", "deployment", component.DeploymentName, "container", component.ContainerName, "error", err.Error())
+			if value, ok := hostedCluster.Annotations[key]; ok {
+				desired[key] = value
+			}
+			continue
+		}
+		recommended := recommendResourceRequests(usage, current, usageConfig.HeadroomPercent, usageConfig.HysteresisPercent)
+		for name, value := range recommended {
+			if currentValue, ok := current[name]; !ok || value.Cmp(currentValue) != 0 {
+				changed = true
+				if !ok || value.Cmp(currentValue) > 0 {
+					increasing = true
+				}
+			}
+		}
+		desired[key] = formatResourceRequests(recommended)
+	}
+
+	if !changed {
+		// the requests we want are in place, record them so the time of the change can be used to delay the next one
+		cfg := applyCfgFor(hostedCluster,
+			metav1applyconfigurations.Condition().
+				WithType(hypershiftv1beta1.ClusterResourceRequestsComputed).
+				WithStatus(metav1.ConditionTrue).
+				WithReason(hypershiftv1beta1.AsExpectedReason).
+				WithMessage(resourceRequestsMessage(desired)).
+				WithLastTransitionTime(metav1.NewTime(r.now())),
+		)
+		return &action{applyCfg: cfg, requeueAfter: usageResyncInterval}, nil
+	}
+
+	delayStart := lastTransitionTime
+	if lastComputedTime := previousResourceRequestsTransitionFor(hostedCluster); lastComputedTime != nil && lastComputedTime.After(delayStart) {
+		delayStart = *lastComputedTime
+	}
+	delay := config.Spec.TransitionDelay.Decrease.Duration
+	if increasing {
+		delay = config.Spec.TransitionDelay.Increase.Duration
+	}
+	if r.now().Sub(delayStart) < delay {
+		logger.Info("Delaying resource request change", "delay", delay.String())
+		return &action{requeueAfter: delayStart.Add(delay).Sub(r.now())}, nil
+	}
+
+	if scheduled := hostedCluster.Annotations[hypershiftv1beta1.HostedClusterScheduledAnnotation]; scheduled == "true" {
+		hostedClusters, err := r.listHostedClusters(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list hosted clusters when calculating concurrency: %w", err)
+		}
+		if changes, durationUntilChanges := transitionsWithinSlidingWindow(hostedClusters, config.Spec.Concurrency.SlidingWindow.Duration, r.now()); int32(changes) >= config.Spec.Concurrency.Limit {
+			logger.Info("Delaying resource request change, concurrency limit reached", "transitions", changes)
+			return &action{requeueAfter: durationUntilChanges}, nil
+		}
+	}
+
+	// the t-shirt size scheduler may have set these annotations, so we must take ownership of them
+	return &action{
+		applyCfg: hypershiftv1beta1applyconfigurations.HostedCluster(hostedCluster.Name, hostedCluster.Namespace).
+			WithAnnotations(desired),
+		fieldManager: usageFieldManager,
+		force:        true,
+	}, nil
+}
+
+// recommendResourceRequests adds headroom to the usage, keeping the current requests when the recommendation is
+// within the hysteresis threshold of them.
+func recommendResourceRequests(usage, current corev1.ResourceList, headroomPercent, hysteresisPercent int32) corev1.ResourceList {
+	recommended := corev1.ResourceList{}
+	if memory, ok := usage[corev1.ResourceMemory]; ok {
+		bytes := memory.Value() * int64(100+headroomPercent) / 100
+		mebibytes := (bytes + (1 << 20) - 1) >> 20
+		recommended[corev1.ResourceMemory] = resource.MustParse(fmt.Sprintf("%dMi", mebibytes))
+	}
+	if cpu, ok := usage[corev1.ResourceCPU]; ok {
+		millicores := (cpu.MilliValue()*int64(100+headroomPercent) + 99) / 100
+		recommended[corev1.ResourceCPU] = resource.MustParse(fmt.Sprintf("%dm", millicores))
+	}
+	for name, value := range recommended {
+		currentValue, ok := current[name]
+		if !ok {
+			continue
+		}
+		difference := value.MilliValue() - currentValue.MilliValue()
+		if difference < 0 {
+			difference = -difference
+		}
+		if difference*100 <= currentValue.MilliValue()*int64(hysteresisPercent) {
+			recommended[name] = currentValue
+		}
+	}
+	return recommended
+}
+
+func resourceRequestOverrideAnnotation(component schedulingv1alpha1.UsageBasedSizingComponent) string {
+	return fmt.Sprintf("%s/%s.%s", hypershiftv1beta1.ResourceRequestOverrideAnnotationPrefix, component.DeploymentName, component.ContainerName)
+}
+
+// parseResourceRequests parses the value of a resource request override annotation, e.g. memory=1Gi,cpu=500m.
+func parseResourceRequests(value string) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, request := range strings.Split(value, ",") {
+		parts := strings.SplitN(request, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(parts[1])
+		if err != nil {
+			continue
+		}
+		requests[corev1.ResourceName(parts[0])] = quantity
+	}
+	return requests
+}
+
+func formatResourceRequests(requests corev1.ResourceList) string {
+	var parts []string
+	if memory, ok := requests[corev1.ResourceMemory]; ok {
+		parts = append(parts, fmt.Sprintf("memory=%s", memory.String()))
+	}
+	if cpu, ok := requests[corev1.ResourceCPU]; ok {
+		parts = append(parts, fmt.Sprintf("cpu=%s", cpu.String()))
+	}
+	return strings.Join(parts, ",")
+}
+
+func resourceRequestsMessage(annotations map[string]string) string {
+	var requests []string
+	for key, value := range annotations {
+		requests = append(requests, fmt.Sprintf("%s: %s", strings.TrimPrefix(key, hypershiftv1beta1.ResourceRequestOverrideAnnotationPrefix+"/"), value))
+	}
+	if len(requests) == 0 {
+		return "No usage has been reported for control plane components."
+	}
+	sort.Strings(requests)
+	return fmt.Sprintf("Resource requests are sized from observed usage: %s.", strings.Join(requests, "; "))
+}
+
+func previousResourceRequestsTransitionFor(hostedCluster *hypershiftv1beta1.HostedCluster) *time.Time {
+	for i, condition := range hostedCluster.Status.Conditions {
+		if condition.Type == hypershiftv1beta1.ClusterResourceRequestsComputed && condition.Status == metav1.ConditionTrue {
+			return &hostedCluster.Status.Conditions[i].LastTransitionTime.Time
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package hostedclustersizing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	hypershiftv1beta1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	schedulingv1alpha1 "github.com/openshift/hypershift/api/scheduling/v1alpha1"
+	hypershiftv1beta1applyconfigurations "github.com/openshift/hypershift/client/applyconfiguration/hypershift/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1applyconfigurations "k8s.io/client-go/applyconfigurations/meta/v1"
+	testingclock "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestPrometheusUsage(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+		value := "0.25"
+		if strings.Contains(query, "container_memory_working_set_bytes") {
+			value = "1073741824"
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,%q]}]}}`, value)
+	}))
+	defer server.Close()
+
+	usage, err := prometheusUsage(context.Background(), server.Client(), &schedulingv1alpha1.PrometheusUsageSourceConfiguration{URL: server.URL + "/"}, "clusters-hc", schedulingv1alpha1.UsageBasedSizingComponent{DeploymentName: "kube-apiserver", ContainerName: "kube-apiserver"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+		corev1.ResourceCPU:    resource.MustParse("250m"),
+	}
+	for name, value := range expected {
+		if actual := usage[name]; actual.Cmp(value) != 0 {
+			t.Errorf("expected %s usage %s, got %s", name, value.String(), actual.String())
+		}
+	}
+	expectedQueries := []string{
+		`max(max_over_time(container_memory_working_set_bytes{namespace="clusters-hc",pod=~"kube-apiserver-.*",container="kube-apiserver"}[3600s]))`,
+		`max(max_over_time(rate(container_cpu_usage_seconds_total{namespace="clusters-hc",pod=~"kube-apiserver-.*",container="kube-apiserver"}[5m])[3600s:1m]))`,
+	}
+	if diff := cmp.Diff(expectedQueries, queries); diff != "" {
+		t.Errorf("got incorrect queries: %v", diff)
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	}))
+	defer empty.Close()
+	if _, err := prometheusUsage(context.Background(), empty.Client(), &schedulingv1alpha1.PrometheusUsageSourceConfiguration{URL: empty.URL}, "clusters-hc", schedulingv1alpha1.UsageBasedSizingComponent{DeploymentName: "etcd", ContainerName: "etcd"}); err == nil {
+		t.Errorf("expected an error when no usage is reported")
+	}
+}
+
+func TestRecommendResourceRequests(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		usage    corev1.ResourceList
+		current  corev1.ResourceList
+		expected string
+	}{
+		{
+			name:     "headroom is added and rounded up",
+			usage:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1000Mi"), corev1.ResourceCPU: resource.MustParse("333m")},
+			expected: "memory=1200Mi,cpu=400m",
+		},
+		{
+			name:     "within hysteresis, keep current requests",
+			usage:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1000Mi"), corev1.ResourceCPU: resource.MustParse("500m")},
+			current:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1150Mi"), corev1.ResourceCPU: resource.MustParse("1")},
+			expected: "memory=1150Mi,cpu=600m",
+		},
+		{
+			name:     "only reported resources are recommended",
+			usage:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")},
+			expected: "memory=120Mi",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := formatResourceRequests(recommendResourceRequests(testCase.usage, testCase.current, 20, 10)); actual != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSizingController_ReconcileUsage(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log)
+
+	theTime, err := time.Parse(time.RFC3339Nano, "2006-01-02T15:04:05.000000000Z")
+	if err != nil {
+		t.Fatalf("could not parse time: %v", err)
+	}
+	fakeClock := testingclock.NewFakeClock(theTime)
+
+	config := &schedulingv1alpha1.ClusterSizingConfiguration{
+		Spec: schedulingv1alpha1.ClusterSizingConfigurationSpec{
+			Concurrency: schedulingv1alpha1.ConcurrencyConfiguration{
+				SlidingWindow: metav1.Duration{Duration: 10 * time.Minute},
+				Limit:         1,
+			},
+			TransitionDelay: schedulingv1alpha1.TransitionDelayConfiguration{
+				Increase: metav1.Duration{Duration: 30 * time.Second},
+				Decrease: metav1.Duration{Duration: 10 * time.Minute},
+			},
+			UsageBasedSizing: &schedulingv1alpha1.UsageBasedSizingConfiguration{
+				Source:            schedulingv1alpha1.MetricsAPIUsageSource,
+				HeadroomPercent:   20,
+				HysteresisPercent: 10,
+				Components: []schedulingv1alpha1.UsageBasedSizingComponent{
+					{DeploymentName: "kube-apiserver", ContainerName: "kube-apiserver"},
+				},
+			},
+		},
+	}
+	annotation := "resource-request-override.hypershift.openshift.io/kube-apiserver.kube-apiserver"
+	usage := func(memory, cpu string) usageFunc {
+		return func(_ context.Context, _ *schedulingv1alpha1.UsageBasedSizingConfiguration, namespace string, _ schedulingv1alpha1.UsageBasedSizingComponent) (corev1.ResourceList, error) {
+			if namespace != "ns-hc" {
+				return nil, fmt.Errorf("unexpected namespace %s", namespace)
+			}
+			return corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory), corev1.ResourceCPU: resource.MustParse(cpu)}, nil
+		}
+	}
+	hostedCluster := func(annotations map[string]string, conditions ...metav1.Condition) *hypershiftv1beta1.HostedCluster {
+		return &hypershiftv1beta1.HostedCluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "hc", Annotations: annotations},
+			Status:     hypershiftv1beta1.HostedClusterStatus{Conditions: conditions},
+		}
+	}
+	computed := func(message string, transition time.Time) metav1.Condition {
+		return metav1.Condition{
+			Type:               hypershiftv1beta1.ClusterResourceRequestsComputed,
+			Status:             metav1.ConditionTrue,
+			Reason:             hypershiftv1beta1.AsExpectedReason,
+			Message:            message,
+			LastTransitionTime: metav1.NewTime(transition),
+		}
+	}
+
+	for _, testCase := range []struct {
+		name string
+
+		config             *schedulingv1alpha1.ClusterSizingConfiguration
+		hostedCluster      *hypershiftv1beta1.HostedCluster
+		lastTransitionTime time.Time
+		usageFor           usageFunc
+		listHostedClusters func(context.Context) (*hypershiftv1beta1.HostedClusterList, error)
+
+		expected *action
+	}{
+		{
+			name:          "usage based sizing disabled, do nothing",
+			config:        &schedulingv1alpha1.ClusterSizingConfiguration{},
+			hostedCluster: hostedCluster(nil),
+		},
+		{
+			name:               "requests differ from usage, apply annotations",
+			config:             config,
+			hostedCluster:      hostedCluster(map[string]string{annotation: "memory=2Gi,cpu=1"}),
+			lastTransitionTime: fakeClock.Now().Add(-1 * time.Hour),
+			usageFor:           usage("1000Mi", "500m"),
+			expected: &action{
+				applyCfg: hypershiftv1beta1applyconfigurations.HostedCluster("hc", "ns").
+					WithAnnotations(map[string]string{annotation: "memory=1200Mi,cpu=600m"}),
+				fieldManager: usageFieldManager,
+				force:        true,
+			},
+		},
+		{
+			name:               "requests applied, record the change",
+			config:             config,
+			hostedCluster:      hostedCluster(map[string]string{annotation: "memory=1200Mi,cpu=600m"}),
+			lastTransitionTime: fakeClock.Now().Add(-1 * time.Hour),
+			usageFor:           usage("1000Mi", "500m"),
+			expected: &action{
+				requeueAfter: usageResyncInterval,
+				applyCfg: hypershiftv1beta1applyconfigurations.HostedCluster("hc", "ns").
+					WithStatus(hypershiftv1beta1applyconfigurations.HostedClusterStatus().WithConditions(
+						metav1applyconfigurations.Condition().
+							WithType(hypershiftv1beta1.ClusterResourceRequestsComputed).
+							WithStatus(metav1.ConditionTrue).
+							WithReason(hypershiftv1beta1.AsExpectedReason).
+							WithMessage("Resource requests are sized from observed usage: kube-apiserver.kube-apiserver: memory=1200Mi,cpu=600m.").
+							WithLastTransitionTime(metav1.NewTime(fakeClock.Now())),
+					)),
+			},
+		},
+		{
+			name:   "requests within hysteresis, no-op",
+			config: config,
+			hostedCluster: hostedCluster(map[string]string{annotation: "memory=1200Mi,cpu=600m"},
+				computed("Resource requests are sized from observed usage: kube-apiserver.kube-apiserver: memory=1200Mi,cpu=600m.", fakeClock.Now().Add(-1*time.Hour)),
+			),
+			lastTransitionTime: fakeClock.Now().Add(-1 * time.Hour),
+			usageFor:           usage("1050Mi", "520m"),
+			expected:           &action{requeueAfter: usageResyncInterval},
+		},
+		{
+			name:   "decrease within transition delay, wait",
+			config: config,
+			hostedCluster: hostedCluster(map[string]string{annotation: "memory=2Gi,cpu=1"},
+				computed("Resource requests are sized from observed usage: kube-apiserver.kube-apiserver: memory=2Gi,cpu=1.", fakeClock.Now().Add(-1*time.Minute)),
+			),
+			lastTransitionTime: fakeClock.Now().Add(-1 * time.Hour),
+			usageFor:           usage("1000Mi", "500m"),
+			expected:           &action{requeueAfter: 9 * time.Minute},
+		},
+		{
+			name:               "increase after a recent size transition, wait",
+			config:             config,
+			hostedCluster:      hostedCluster(map[string]string{annotation: "memory=1Gi,cpu=500m"}),
+			lastTransitionTime: fakeClock.Now().Add(-10 * time.Second),
+			usageFor:           usage("2Gi", "1"),
+			expected:           &action{requeueAfter: 20 * time.Second},
+		},
+		{
+			name:   "concurrency limit reached for scheduled cluster, wait",
+			config: config,
+			hostedCluster: hostedCluster(map[string]string{
+				annotation: "memory=1Gi,cpu=500m",
+				hypershiftv1beta1.HostedClusterScheduledAnnotation: "true",
+			}),
+			lastTransitionTime: fakeClock.Now().Add(-1 * time.Hour),
+			usageFor:           usage("2Gi", "1"),
+			listHostedClusters: func(_ context.Context) (*hypershiftv1beta1.HostedClusterList, error) {
+				return &hypershiftv1beta1.HostedClusterList{Items: []hypershiftv1beta1.HostedCluster{
+					*hostedCluster(nil, computed("", fakeClock.Now().Add(-5*time.Minute))),
+				}}, nil
+			},
+			expected: &action{requeueAfter: 5 * time.Minute},
+		},
+		{
+			name:               "usage not reported, keep current requests",
+			config:             config,
+			hostedCluster:      hostedCluster(map[string]string{annotation: "memory=1Gi,cpu=500m"}),
+			lastTransitionTime: fakeClock.Now().Add(-1 * time.Hour),
+			usageFor: func(_ context.Context, _ *schedulingv1alpha1.UsageBasedSizingConfiguration, _ string, _ schedulingv1alpha1.UsageBasedSizingComponent) (corev1.ResourceList, error) {
+				return nil, fmt.Errorf("no usage reported")
+			},
+			expected: &action{
+				requeueAfter: usageResyncInterval,
+				applyCfg: hypershiftv1beta1applyconfigurations.HostedCluster("hc", "ns").
+					WithStatus(hypershiftv1beta1applyconfigurations.HostedClusterStatus().WithConditions(
+						metav1applyconfigurations.Condition().
+							WithType(hypershiftv1beta1.ClusterResourceRequestsComputed).
+							WithStatus(metav1.ConditionTrue).
+							WithReason(hypershiftv1beta1.AsExpectedReason).
+							WithMessage("Resource requests are sized from observed usage: kube-apiserver.kube-apiserver: memory=1Gi,cpu=500m.").
+							WithLastTransitionTime(metav1.NewTime(fakeClock.Now())),
+					)),
+			},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := &reconciler{
+				now:                fakeClock.Now,
+				usageFor:           testCase.usageFor,
+				listHostedClusters: testCase.listHostedClusters,
+			}
+			action, err := r.reconcileUsage(ctx, testCase.config, testCase.hostedCluster, testCase.lastTransitionTime)
+			if err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+			if diff := cmp.Diff(action, testCase.expected, compareActions()...); diff != "" {
+				t.Fatalf("got incorrect action: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTransitionsWithinSlidingWindowCountsResourceRequestChanges(t *testing.T) {
+	now := time.Now()
+	hostedCluster := hostedClusterWithTransition("hc", now.Add(-time.Minute))
+	hostedCluster.Status.Conditions = append(hostedCluster.Status.Conditions, metav1.Condition{
+		Type:               hypershiftv1beta1.ClusterResourceRequestsComputed,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(now.Add(-2 * time.Minute)),
+	})
+	changes, until := transitionsWithinSlidingWindow(&hypershiftv1beta1.HostedClusterList{Items: []hypershiftv1beta1.HostedCluster{hostedCluster}}, 10*time.Minute, now)
+	if changes != 2 {
+		t.Errorf("expected 2 transitions, got %d", changes)
+	}
+	if until != 8*time.Minute {
+		t.Errorf("expected the count to change in 8m, got %s", until)
+	}
+}
@@ -0,0 +1,350 @@
+package hostedclusterupgradeplan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedcluster"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	hyperutil "github.com/openshift/hypershift/support/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const ControllerName = "hostedclusterupgradeplan"
+
+// Reconciler upgrades the HostedClusters selected by a HostedClusterUpgradePlan to its release,
+// one wave after the other. The upgrade of each HostedCluster is driven by the HostedCluster
+// controller; the plan only sets spec.release and follows the upgrade the way the HostedCluster
+// controller determines it.
+type Reconciler struct {
+	client.Client
+
+	// ReleaseProvider looks up the release of the plan, to tell whether the HostedCluster
+	// controller lets an upgrade proceed.
+	ReleaseProvider releaseinfo.Provider
+
+	// Clock is used to determine the time in a testable way.
+	Clock clock.WithTickerAndDelayedExecution
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&hyperv1.HostedClusterUpgradePlan{}).
+		Watches(&hyperv1.HostedCluster{}, handler.EnqueueRequestsFromMapFunc(r.plansForHostedCluster)).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+	return nil
+}
+
+// plansForHostedCluster enqueues the plans in the namespace of the HostedCluster which select it.
+func (r *Reconciler) plansForHostedCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	plans := &hyperv1.HostedClusterUpgradePlanList{}
+	if err := r.List(ctx, plans, client.InNamespace(obj.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list upgrade plans")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, plan := range plans.Items {
+		selector, err := metav1.LabelSelectorAsSelector(&plan.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&plan)})
+	}
+	return requests
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	plan := &hyperv1.HostedClusterUpgradePlan{}
+	if err := r.Get(ctx, req.NamespacedName, plan); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get upgrade plan: %w", err)
+	}
+	if !plan.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&plan.Spec.Selector)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("invalid selector: %w", err)
+	}
+	hostedClusters := &hyperv1.HostedClusterList{}
+	if err := r.List(ctx, hostedClusters, client.InNamespace(plan.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list hosted clusters: %w", err)
+	}
+	waves, err := assignWaves(plan.Spec.Waves, hostedClusters.Items)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	now := metav1.NewTime(r.Clock.Now())
+	previousClusters := map[string]hyperv1.HostedClusterUpgradeStatus{}
+	for _, cluster := range plan.Status.Clusters {
+		previousClusters[cluster.Name] = cluster
+	}
+	previousWaves := map[string]hyperv1.HostedClusterUpgradeWaveStatus{}
+	for _, wave := range plan.Status.Waves {
+		previousWaves[wave.Name] = wave
+	}
+
+	status := hyperv1.HostedClusterUpgradePlanStatus{
+		ObservedGeneration: plan.Generation,
+		Conditions:         plan.Status.Conditions,
+	}
+	clusters := make([][]hyperv1.HostedClusterUpgradeStatus, len(waves))
+	var failed []string
+	for i := range waves {
+		for _, hcluster := range waves[i] {
+			cluster, err := r.clusterUpgradeStatus(ctx, hcluster, plan.Spec.Release.Image, previousClusters[hcluster.Name], now)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			cluster.Wave = plan.Spec.Waves[i].Name
+			if cluster.Phase == hyperv1.HostedClusterUpgradeFailed {
+				failed = append(failed, fmt.Sprintf("%s: %s", cluster.Name, cluster.Message))
+			}
+			clusters[i] = append(clusters[i], cluster)
+		}
+	}
+	halted := len(failed) > 0
+
+	// Walk the waves in order: completed waves are skipped, and the first one which is not
+	// completed is the current wave, whose pending HostedClusters are started as capacity allows.
+	var requeueAfter time.Duration
+	for i, wave := range plan.Spec.Waves {
+		waveStatus := hyperv1.HostedClusterUpgradeWaveStatus{Name: wave.Name, Phase: hyperv1.HostedClusterUpgradeWavePending}
+		if status.CurrentWave != "" {
+			status.Waves = append(status.Waves, waveStatus)
+			continue
+		}
+
+		var upgrading int32
+		completed := true
+		for _, cluster := range clusters[i] {
+			switch cluster.Phase {
+			case hyperv1.HostedClusterUpgradeCompleted:
+			case hyperv1.HostedClusterUpgradePending:
+				completed = false
+			default:
+				completed = false
+				upgrading++
+			}
+		}
+
+		if completed {
+			waveStatus.CompletionTime = previousWaves[wave.Name].CompletionTime
+			if waveStatus.CompletionTime == nil {
+				waveStatus.CompletionTime = &now
+			}
+			waveStatus.Phase = hyperv1.HostedClusterUpgradeWaveCompleted
+			if soakEnd := waveStatus.CompletionTime.Add(wave.SoakDuration.Duration); now.Time.Before(soakEnd) {
+				waveStatus.Phase = hyperv1.HostedClusterUpgradeWaveSoaking
+				status.CurrentWave = wave.Name
+				requeueAfter = soakEnd.Sub(now.Time)
+			}
+			status.Waves = append(status.Waves, waveStatus)
+			continue
+		}
+
+		waveStatus.Phase = hyperv1.HostedClusterUpgradeWaveUpgrading
+		status.CurrentWave = wave.Name
+		status.Waves = append(status.Waves, waveStatus)
+		if halted || plan.Spec.Paused {
+			continue
+		}
+
+		maxConcurrency := wave.MaxConcurrency
+		if maxConcurrency < 1 {
+			maxConcurrency = 1
+		}
+		for j := range clusters[i] {
+			if upgrading >= maxConcurrency {
+				break
+			}
+			cluster := &clusters[i][j]
+			if cluster.Phase != hyperv1.HostedClusterUpgradePending {
+				continue
+			}
+			hcluster := waves[i][j]
+			original := hcluster.DeepCopy()
+			hcluster.Spec.Release.Image = plan.Spec.Release.Image
+			if err := r.Patch(ctx, hcluster, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to upgrade hosted cluster %s: %w", hcluster.Name, err)
+			}
+			log.Info("Started hosted cluster upgrade", "hostedcluster", hcluster.Name, "wave", wave.Name, "image", plan.Spec.Release.Image)
+			cluster.Phase = hyperv1.HostedClusterUpgradeUpgrading
+			cluster.StartedTime = &now
+			upgrading++
+		}
+	}
+	for i := range clusters {
+		status.Clusters = append(status.Clusters, clusters[i]...)
+	}
+
+	haltedCondition := metav1.Condition{
+		Type:               string(hyperv1.UpgradePlanHalted),
+		Status:             metav1.ConditionFalse,
+		Reason:             hyperv1.AsExpectedReason,
+		ObservedGeneration: plan.Generation,
+	}
+	progressingCondition := metav1.Condition{
+		Type:               string(hyperv1.UpgradePlanProgressing),
+		Status:             metav1.ConditionTrue,
+		Reason:             hyperv1.AsExpectedReason,
+		ObservedGeneration: plan.Generation,
+	}
+	switch {
+	case halted:
+		haltedCondition.Status = metav1.ConditionTrue
+		haltedCondition.Reason = hyperv1.UpgradePlanClusterFailedReason
+		haltedCondition.Message = fmt.Sprintf("Upgrade of HostedClusters failed: %s", strings.Join(failed, "; "))
+		progressingCondition.Status = metav1.ConditionFalse
+		progressingCondition.Reason = hyperv1.UpgradePlanClusterFailedReason
+		progressingCondition.Message = "No further HostedCluster upgrades are started until the failed ones recover"
+	case status.CurrentWave == "":
+		progressingCondition.Status = metav1.ConditionFalse
+		progressingCondition.Reason = hyperv1.UpgradePlanCompletedReason
+		progressingCondition.Message = fmt.Sprintf("All HostedClusters are upgraded to %s", plan.Spec.Release.Image)
+	case plan.Spec.Paused:
+		progressingCondition.Status = metav1.ConditionFalse
+		progressingCondition.Reason = hyperv1.UpgradePlanPausedReason
+		progressingCondition.Message = fmt.Sprintf("Paused in wave %s", status.CurrentWave)
+	case requeueAfter > 0:
+		progressingCondition.Reason = hyperv1.UpgradePlanSoakingReason
+		progressingCondition.Message = fmt.Sprintf("Soaking wave %s for %s", status.CurrentWave, requeueAfter.Round(time.Second))
+	default:
+		progressingCondition.Message = fmt.Sprintf("Upgrading wave %s", status.CurrentWave)
+	}
+	status.Conditions = append([]metav1.Condition(nil), status.Conditions...)
+	meta.SetStatusCondition(&status.Conditions, haltedCondition)
+	meta.SetStatusCondition(&status.Conditions, progressingCondition)
+
+	if !equality.Semantic.DeepEqual(status, plan.Status) {
+		plan.Status = status
+		if err := r.Status().Update(ctx, plan); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// assignWaves returns the HostedClusters of each wave, sorted by name. A HostedCluster belongs
+// to the first wave which selects it.
+func assignWaves(waves []hyperv1.HostedClusterUpgradeWave, hostedClusters []hyperv1.HostedCluster) ([][]*hyperv1.HostedCluster, error) {
+	selectors := make([]labels.Selector, len(waves))
+	for i, wave := range waves {
+		selectors[i] = labels.Everything()
+		if wave.Selector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(wave.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector for wave %s: %w", wave.Name, err)
+			}
+			selectors[i] = selector
+		}
+	}
+
+	sort.Slice(hostedClusters, func(i, j int) bool { return hostedClusters[i].Name < hostedClusters[j].Name })
+	result := make([][]*hyperv1.HostedCluster, len(waves))
+	for i := range hostedClusters {
+		hcluster := &hostedClusters[i]
+		for j := range selectors {
+			if selectors[j].Matches(labels.Set(hcluster.Labels)) {
+				result[j] = append(result[j], hcluster)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// clusterUpgradeStatus determines the upgrade progress of a HostedCluster from its version status
+// and whether its upgrade is blocked, as computed by the HostedCluster controller. A HostedCluster
+// counts as failed when its upgrade is blocked, when the CVO reports it is failing, or when it is
+// degraded.
+func (r *Reconciler) clusterUpgradeStatus(ctx context.Context, hcluster *hyperv1.HostedCluster, image string, previous hyperv1.HostedClusterUpgradeStatus, now metav1.Time) (hyperv1.HostedClusterUpgradeStatus, error) {
+	status := hyperv1.HostedClusterUpgradeStatus{
+		Name:        hcluster.Name,
+		Phase:       hyperv1.HostedClusterUpgradePending,
+		StartedTime: previous.StartedTime,
+	}
+	if hcluster.Spec.Release.Image != image {
+		status.StartedTime = nil
+		return status, nil
+	}
+
+	if condition := meta.FindStatusCondition(hcluster.Status.Conditions, string(hyperv1.ClusterVersionFailing)); condition != nil && condition.Status == metav1.ConditionTrue {
+		status.Phase = hyperv1.HostedClusterUpgradeFailed
+		status.Message = condition.Message
+		return status, nil
+	}
+	if condition := meta.FindStatusCondition(hcluster.Status.Conditions, string(hyperv1.HostedClusterDegraded)); condition != nil && condition.Status == metav1.ConditionTrue {
+		status.Phase = hyperv1.HostedClusterUpgradeFailed
+		status.Message = condition.Message
+		return status, nil
+	}
+
+	releaseImage, err := r.lookupReleaseImage(ctx, hcluster)
+	if err != nil {
+		return status, err
+	}
+	version, err := hostedcluster.UpgradeStatus(r.Clock, hcluster, releaseImage)
+	if err != nil {
+		status.Phase = hyperv1.HostedClusterUpgradeFailed
+		status.Message = err.Error()
+		return status, nil
+	}
+	if latest := version.History[0]; latest.Image == image && latest.State == configv1.CompletedUpdate {
+		status.Phase = hyperv1.HostedClusterUpgradeCompleted
+		status.CompletionTime = latest.CompletionTime
+		if status.CompletionTime == nil {
+			status.CompletionTime = &now
+		}
+		return status, nil
+	}
+
+	status.Phase = hyperv1.HostedClusterUpgradeUpgrading
+	if status.StartedTime == nil {
+		status.StartedTime = &now
+	}
+	return status, nil
+}
+
+func (r *Reconciler) lookupReleaseImage(ctx context.Context, hcluster *hyperv1.HostedCluster) (*releaseinfo.ReleaseImage, error) {
+	pullSecret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: hcluster.Namespace, Name: hcluster.Spec.PullSecret.Name}, pullSecret); err != nil {
+		return nil, fmt.Errorf("failed to get pull secret of hosted cluster %s: %w", hcluster.Name, err)
+	}
+	pullSecretBytes, ok := pullSecret.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return nil, fmt.Errorf("expected %s key in pull secret of hosted cluster %s", corev1.DockerConfigJsonKey, hcluster.Name)
+	}
+	releaseImage, err := r.ReleaseProvider.Lookup(ctx, hyperutil.HCControlPlaneReleaseImage(hcluster), pullSecretBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up release image of hosted cluster %s: %w", hcluster.Name, err)
+	}
+	return releaseImage, nil
+}
@@ -0,0 +1,302 @@
+package hostedclusterupgradeplan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+	fakereleaseprovider "github.com/openshift/hypershift/support/releaseinfo/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	oldImage    = "quay.io/openshift-release-dev/ocp-release:4.15.1-x86_64"
+	targetImage = "quay.io/openshift-release-dev/ocp-release:4.15.2-x86_64"
+	minorImage  = "quay.io/openshift-release-dev/ocp-release:4.16.0-x86_64"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	type hostedClusterState int
+	const (
+		notStarted hostedClusterState = iota
+		upgrading
+		upgraded
+		failing
+		degraded
+		notUpgradeable
+	)
+	hostedCluster := func(name, wave string, state hostedClusterState) *hyperv1.HostedCluster {
+		hc := &hyperv1.HostedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "clusters",
+				Name:      name,
+				Labels:    map[string]string{"fleet": "prod", "wave": wave},
+			},
+			Spec: hyperv1.HostedClusterSpec{
+				Release:    hyperv1.Release{Image: targetImage},
+				PullSecret: corev1.LocalObjectReference{Name: "pull-secret"},
+			},
+			Status: hyperv1.HostedClusterStatus{
+				Version: &hyperv1.ClusterVersionStatus{
+					Desired: configv1.Release{Image: targetImage},
+					History: []configv1.UpdateHistory{{Image: targetImage, State: configv1.PartialUpdate}},
+				},
+			},
+		}
+		switch state {
+		case notStarted:
+			hc.Spec.Release.Image = oldImage
+			hc.Status.Version.Desired.Image = oldImage
+			hc.Status.Version.History = []configv1.UpdateHistory{{Image: oldImage, State: configv1.CompletedUpdate}}
+		case upgraded:
+			hc.Status.Version.History[0].State = configv1.CompletedUpdate
+			hc.Status.Version.History[0].CompletionTime = &metav1.Time{Time: now.Add(-time.Hour)}
+		case failing:
+			hc.Status.Conditions = []metav1.Condition{{Type: string(hyperv1.ClusterVersionFailing), Status: metav1.ConditionTrue, Message: "operator etcd is degraded"}}
+		case degraded:
+			hc.Status.Conditions = []metav1.Condition{{Type: string(hyperv1.HostedClusterDegraded), Status: metav1.ConditionTrue, Message: "kube-apiserver is unavailable"}}
+		case notUpgradeable:
+			// The HostedCluster controller has not accepted the new release yet.
+			hc.Status.Version.Desired = configv1.Release{Image: oldImage, Version: "4.15.1"}
+			hc.Status.Version.History = []configv1.UpdateHistory{{Image: oldImage, State: configv1.CompletedUpdate}}
+			hc.Status.Conditions = []metav1.Condition{{Type: string(hyperv1.ClusterVersionUpgradeable), Status: metav1.ConditionFalse, Reason: "AdminAckRequired", Message: "cluster version is not upgradeable"}}
+		}
+		return hc
+	}
+	waves := []hyperv1.HostedClusterUpgradeWave{
+		{
+			Name:           "canary",
+			Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"wave": "canary"}},
+			MaxConcurrency: 2,
+			SoakDuration:   metav1.Duration{Duration: time.Hour},
+		},
+		{
+			Name:           "rest",
+			MaxConcurrency: 1,
+		},
+	}
+
+	testCases := []struct {
+		name                 string
+		planImage            string
+		hostedClusters       []*hyperv1.HostedCluster
+		previousStatus       hyperv1.HostedClusterUpgradePlanStatus
+		paused               bool
+		expectedImages       map[string]string
+		expectedCurrentWave  string
+		expectedProgressing  string
+		expectedHalted       metav1.ConditionStatus
+		expectedRequeueAfter time.Duration
+	}{
+		{
+			name: "When the plan starts it should upgrade the first wave up to its max concurrency",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", notStarted),
+				hostedCluster("b", "canary", notStarted),
+				hostedCluster("c", "canary", notStarted),
+				hostedCluster("d", "other", notStarted),
+			},
+			expectedImages:      map[string]string{"a": targetImage, "b": targetImage, "c": oldImage, "d": oldImage},
+			expectedCurrentWave: "canary",
+			expectedProgressing: hyperv1.AsExpectedReason,
+			expectedHalted:      metav1.ConditionFalse,
+		},
+		{
+			name: "When a cluster of the wave upgrades it should start the next one as capacity frees up",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", upgraded),
+				hostedCluster("b", "canary", upgrading),
+				hostedCluster("c", "canary", notStarted),
+			},
+			expectedImages:      map[string]string{"c": targetImage},
+			expectedCurrentWave: "canary",
+			expectedProgressing: hyperv1.AsExpectedReason,
+			expectedHalted:      metav1.ConditionFalse,
+		},
+		{
+			name: "When the CVO reports a failing upgrade it should halt the plan",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", failing),
+				hostedCluster("b", "canary", upgraded),
+				hostedCluster("c", "canary", notStarted),
+			},
+			expectedImages:      map[string]string{"c": oldImage},
+			expectedCurrentWave: "canary",
+			expectedProgressing: hyperv1.UpgradePlanClusterFailedReason,
+			expectedHalted:      metav1.ConditionTrue,
+		},
+		{
+			name: "When an upgraded cluster degrades during the soak it should halt the plan",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", degraded),
+				hostedCluster("d", "other", notStarted),
+			},
+			expectedImages:      map[string]string{"d": oldImage},
+			expectedCurrentWave: "canary",
+			expectedProgressing: hyperv1.UpgradePlanClusterFailedReason,
+			expectedHalted:      metav1.ConditionTrue,
+		},
+		{
+			name:      "When the upgrade of a cluster is blocked it should halt the plan",
+			planImage: minorImage,
+			hostedClusters: []*hyperv1.HostedCluster{
+				withImage(hostedCluster("a", "canary", notUpgradeable), minorImage),
+				hostedCluster("c", "canary", notStarted),
+			},
+			expectedImages:      map[string]string{"c": oldImage},
+			expectedCurrentWave: "canary",
+			expectedProgressing: hyperv1.UpgradePlanClusterFailedReason,
+			expectedHalted:      metav1.ConditionTrue,
+		},
+		{
+			name: "When a cluster is not upgradeable but the upgrade is a z-stream it should keep upgrading",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", notUpgradeable),
+				hostedCluster("c", "canary", notStarted),
+			},
+			expectedImages:      map[string]string{"c": targetImage},
+			expectedCurrentWave: "canary",
+			expectedProgressing: hyperv1.AsExpectedReason,
+			expectedHalted:      metav1.ConditionFalse,
+		},
+		{
+			name: "When the HostedCluster controller has not observed the new release it should not count the cluster as upgraded",
+			hostedClusters: []*hyperv1.HostedCluster{
+				withImage(hostedCluster("a", "canary", notStarted), targetImage),
+				hostedCluster("b", "canary", upgraded),
+				hostedCluster("c", "canary", notStarted),
+			},
+			expectedImages:      map[string]string{"c": targetImage},
+			expectedCurrentWave: "canary",
+			expectedProgressing: hyperv1.AsExpectedReason,
+			expectedHalted:      metav1.ConditionFalse,
+		},
+		{
+			name: "When the wave completed it should soak before starting the next wave",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", upgraded),
+				hostedCluster("d", "other", notStarted),
+			},
+			previousStatus: hyperv1.HostedClusterUpgradePlanStatus{
+				Waves: []hyperv1.HostedClusterUpgradeWaveStatus{{Name: "canary", Phase: hyperv1.HostedClusterUpgradeWaveSoaking, CompletionTime: &metav1.Time{Time: now.Add(-10 * time.Minute)}}},
+			},
+			expectedImages:       map[string]string{"d": oldImage},
+			expectedCurrentWave:  "canary",
+			expectedProgressing:  hyperv1.UpgradePlanSoakingReason,
+			expectedHalted:       metav1.ConditionFalse,
+			expectedRequeueAfter: 50 * time.Minute,
+		},
+		{
+			name: "When the soak duration elapsed it should start the next wave",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", upgraded),
+				hostedCluster("d", "other", notStarted),
+			},
+			previousStatus: hyperv1.HostedClusterUpgradePlanStatus{
+				Waves: []hyperv1.HostedClusterUpgradeWaveStatus{{Name: "canary", Phase: hyperv1.HostedClusterUpgradeWaveSoaking, CompletionTime: &metav1.Time{Time: now.Add(-2 * time.Hour)}}},
+			},
+			expectedImages:      map[string]string{"d": targetImage},
+			expectedCurrentWave: "rest",
+			expectedProgressing: hyperv1.AsExpectedReason,
+			expectedHalted:      metav1.ConditionFalse,
+		},
+		{
+			name: "When the plan is paused it should not start upgrades",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", notStarted),
+			},
+			paused:              true,
+			expectedImages:      map[string]string{"a": oldImage},
+			expectedCurrentWave: "canary",
+			expectedProgressing: hyperv1.UpgradePlanPausedReason,
+			expectedHalted:      metav1.ConditionFalse,
+		},
+		{
+			name: "When every wave completed it should report the plan as completed",
+			hostedClusters: []*hyperv1.HostedCluster{
+				hostedCluster("a", "canary", upgraded),
+				hostedCluster("d", "other", upgraded),
+			},
+			previousStatus: hyperv1.HostedClusterUpgradePlanStatus{
+				Waves: []hyperv1.HostedClusterUpgradeWaveStatus{{Name: "canary", Phase: hyperv1.HostedClusterUpgradeWaveCompleted, CompletionTime: &metav1.Time{Time: now.Add(-2 * time.Hour)}}},
+			},
+			expectedProgressing: hyperv1.UpgradePlanCompletedReason,
+			expectedHalted:      metav1.ConditionFalse,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			planImage := targetImage
+			if tc.planImage != "" {
+				planImage = tc.planImage
+			}
+			plan := &hyperv1.HostedClusterUpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "fleet"},
+				Spec: hyperv1.HostedClusterUpgradePlanSpec{
+					Release:  hyperv1.Release{Image: planImage},
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"fleet": "prod"}},
+					Waves:    waves,
+					Paused:   tc.paused,
+				},
+				Status: tc.previousStatus,
+			}
+			objects := []client.Object{plan, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "pull-secret"},
+				Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+			}}
+			for _, hc := range tc.hostedClusters {
+				objects = append(objects, hc)
+			}
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects...).WithStatusSubresource(plan).Build()
+			r := &Reconciler{
+				Client: c,
+				ReleaseProvider: &fakereleaseprovider.FakeReleaseProvider{ImageVersion: map[string]string{
+					oldImage:    "4.15.1",
+					targetImage: "4.15.2",
+					minorImage:  "4.16.0",
+				}},
+				Clock: clocktesting.NewFakeClock(now),
+			}
+
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(plan)})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result.RequeueAfter).To(Equal(tc.expectedRequeueAfter))
+
+			for name, image := range tc.expectedImages {
+				hc := &hyperv1.HostedCluster{}
+				g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "clusters", Name: name}, hc)).To(Succeed())
+				g.Expect(hc.Spec.Release.Image).To(Equal(image), name)
+			}
+
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(plan), plan)).To(Succeed())
+			g.Expect(plan.Status.CurrentWave).To(Equal(tc.expectedCurrentWave))
+			g.Expect(plan.Status.Clusters).To(HaveLen(len(tc.hostedClusters)))
+			progressing := meta.FindStatusCondition(plan.Status.Conditions, string(hyperv1.UpgradePlanProgressing))
+			g.Expect(progressing).ToNot(BeNil())
+			g.Expect(progressing.Reason).To(Equal(tc.expectedProgressing))
+			halted := meta.FindStatusCondition(plan.Status.Conditions, string(hyperv1.UpgradePlanHalted))
+			g.Expect(halted).ToNot(BeNil())
+			g.Expect(halted.Status).To(Equal(tc.expectedHalted))
+		})
+	}
+}
+
+func withImage(hc *hyperv1.HostedCluster, image string) *hyperv1.HostedCluster {
+	hc.Spec.Release.Image = image
+	return hc
+}
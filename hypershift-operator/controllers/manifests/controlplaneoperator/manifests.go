@@ -13,6 +13,9 @@ import (
 const (
 	ServiceSignerPrivateKey = "service-account.key"
 	ServiceSignerPublicKey  = "service-account.pub"
+	// ServiceSignerNextPrivateKey holds the new signing key while its public key is published
+	// during a service account signing key rotation.
+	ServiceSignerNextPrivateKey = "service-account-next.key"
 )
 
 func OperatorDeployment(controlPlaneOperatorNamespace string) *appsv1.Deployment {
@@ -19,7 +19,7 @@ import (
 func (r *NodePoolReconciler) reconcileMachineSet(ctx context.Context,
 	machineSet *capiv1.MachineSet,
 	nodePool *hyperv1.NodePool,
-	userDataSecret *corev1.Secret,
+	bootstrapDataSecretName string,
 	machineTemplateCR client.Object,
 	CAPIClusterName string,
 	targetVersion,
@@ -111,10 +111,10 @@ func (r *NodePoolReconciler) reconcileMachineSet(ctx context.Context,
 
 	isUpdating := false
 	// Propagate version and userData Secret to the MachineSet.
-	if userDataSecret.Name != k8sutilspointer.StringDeref(machineSet.Spec.Template.Spec.Bootstrap.DataSecretName, "") {
+	if bootstrapDataSecretName != k8sutilspointer.StringDeref(machineSet.Spec.Template.Spec.Bootstrap.DataSecretName, "") {
 		log.Info("New user data Secret has been generated",
 			"current", machineSet.Spec.Template.Spec.Bootstrap.DataSecretName,
-			"target", userDataSecret.Name)
+			"target", bootstrapDataSecretName)
 
 		// TODO (alberto): possibly compare with NodePool here instead so we don't rely on impl details to drive decisions.
 		if targetVersion != k8sutilspointer.StringDeref(machineSet.Spec.Template.Spec.Version, "") {
@@ -127,7 +127,7 @@ func (r *NodePoolReconciler) reconcileMachineSet(ctx context.Context,
 				"current", nodePool.Annotations[nodePoolAnnotationCurrentConfig], "target", targetConfigHash)
 		}
 		machineSet.Spec.Template.Spec.Version = &targetVersion
-		machineSet.Spec.Template.Spec.Bootstrap.DataSecretName = k8sutilspointer.String(userDataSecret.Name)
+		machineSet.Spec.Template.Spec.Bootstrap.DataSecretName = k8sutilspointer.String(bootstrapDataSecretName)
 
 		// Signal in-place upgrade request.
 		machineSet.Annotations[nodePoolAnnotationTargetConfigVersion] = targetConfigVersionHash
@@ -39,6 +39,7 @@ import (
 	"github.com/openshift/hypershift/support/supportedversion"
 	"github.com/openshift/hypershift/support/upsert"
 	supportutil "github.com/openshift/hypershift/support/util"
+	"github.com/openshift/hypershift/support/validations"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -931,13 +932,20 @@ func (r *NodePoolReconciler) reconcile(ctx context.Context, hcluster *hyperv1.Ho
 		})
 	}
 
+	// In token binding mode, Machines are created with user data that doesn't exist until the
+	// ignition server binds it to them, so that no instance is launched with the NodePool user data.
+	bootstrapDataSecretName := userDataSecret.Name
+	if hcluster.Annotations[hyperv1.IgnitionServerTokenBindingAnnotation] == "true" {
+		bootstrapDataSecretName = ignserver.UnboundUserDataSecretName(userDataSecret.Name)
+	}
+
 	if nodePool.Spec.Management.UpgradeType == hyperv1.UpgradeTypeInPlace {
 		ms := machineSet(nodePool, controlPlaneNamespace)
 		if result, err := controllerutil.CreateOrPatch(ctx, r.Client, ms, func() error {
 			return r.reconcileMachineSet(
 				ctx,
 				ms, nodePool,
-				userDataSecret,
+				bootstrapDataSecretName,
 				template,
 				infraID,
 				targetVersion, targetConfigHash, targetPayloadConfigHash, machineTemplateSpecJSON)
@@ -955,7 +963,7 @@ func (r *NodePoolReconciler) reconcile(ctx context.Context, hcluster *hyperv1.Ho
 			return r.reconcileMachineDeployment(
 				log,
 				md, nodePool,
-				userDataSecret,
+				bootstrapDataSecretName,
 				template,
 				infraID,
 				targetVersion, targetConfigHash, targetPayloadConfigHash, machineTemplateSpecJSON)
@@ -1718,7 +1726,7 @@ func reconcileTokenSecret(tokenSecret *corev1.Secret, nodePool *hyperv1.NodePool
 func (r *NodePoolReconciler) reconcileMachineDeployment(log logr.Logger,
 	machineDeployment *capiv1.MachineDeployment,
 	nodePool *hyperv1.NodePool,
-	userDataSecret *corev1.Secret,
+	bootstrapDataSecretName string,
 	machineTemplateCR client.Object,
 	CAPIClusterName string,
 	targetVersion,
@@ -1850,10 +1858,10 @@ func (r *NodePoolReconciler) reconcileMachineDeployment(log logr.Logger,
 
 	isUpdating := false
 	// Propagate version and userData Secret to the machineDeployment.
-	if userDataSecret.Name != k8sutilspointer.StringDeref(machineDeployment.Spec.Template.Spec.Bootstrap.DataSecretName, "") {
+	if bootstrapDataSecretName != k8sutilspointer.StringDeref(machineDeployment.Spec.Template.Spec.Bootstrap.DataSecretName, "") {
 		log.Info("New user data Secret has been generated",
 			"current", machineDeployment.Spec.Template.Spec.Bootstrap.DataSecretName,
-			"target", userDataSecret.Name)
+			"target", bootstrapDataSecretName)
 
 		if targetVersion != k8sutilspointer.StringDeref(machineDeployment.Spec.Template.Spec.Version, "") {
 			log.Info("Starting version update: Propagating new version to the MachineDeployment",
@@ -1865,7 +1873,7 @@ func (r *NodePoolReconciler) reconcileMachineDeployment(log logr.Logger,
 				"current", nodePool.Annotations[nodePoolAnnotationCurrentConfig], "target", targetConfigHash)
 		}
 		machineDeployment.Spec.Template.Spec.Version = &targetVersion
-		machineDeployment.Spec.Template.Spec.Bootstrap.DataSecretName = k8sutilspointer.String(userDataSecret.Name)
+		machineDeployment.Spec.Template.Spec.Bootstrap.DataSecretName = k8sutilspointer.String(bootstrapDataSecretName)
 		isUpdating = true
 	}
 
@@ -2310,32 +2318,7 @@ func validateTuningConfigManifest(manifest []byte) ([]byte, []byte, error) {
 // validateManagement does additional backend validation. API validation/default should
 // prevent this from ever fail.
 func validateManagement(nodePool *hyperv1.NodePool) error {
-	// TODO actually validate the inplace upgrade type
-	if nodePool.Spec.Management.UpgradeType == hyperv1.UpgradeTypeInPlace {
-		return nil
-	}
-
-	// Only upgradeType "Replace" is supported atm.
-	if nodePool.Spec.Management.UpgradeType != hyperv1.UpgradeTypeReplace ||
-		nodePool.Spec.Management.Replace == nil {
-		return fmt.Errorf("this is unsupported. %q upgrade type and a strategy: %q or %q are required",
-			hyperv1.UpgradeTypeReplace, hyperv1.UpgradeStrategyRollingUpdate, hyperv1.UpgradeStrategyOnDelete)
-	}
-
-	if nodePool.Spec.Management.Replace.Strategy != hyperv1.UpgradeStrategyRollingUpdate &&
-		nodePool.Spec.Management.Replace.Strategy != hyperv1.UpgradeStrategyOnDelete {
-		return fmt.Errorf("this is unsupported. %q upgrade type only support strategies %q and %q",
-			hyperv1.UpgradeTypeReplace, hyperv1.UpgradeStrategyOnDelete, hyperv1.UpgradeStrategyRollingUpdate)
-	}
-
-	// RollingUpdate strategy requires MaxUnavailable and MaxSurge
-	if nodePool.Spec.Management.Replace.Strategy == hyperv1.UpgradeStrategyRollingUpdate &&
-		nodePool.Spec.Management.Replace.RollingUpdate == nil {
-		return fmt.Errorf("this is unsupported. %q upgrade type with strategy %q require a MaxUnavailable and MaxSurge",
-			hyperv1.UpgradeTypeReplace, hyperv1.UpgradeStrategyRollingUpdate)
-	}
-
-	return nil
+	return validations.ValidateNodePoolManagement(nodePool).ToAggregate()
 }
 
 func defaultAndValidateConfigManifest(manifest []byte) ([]byte, *MirrorConfig, error) {
@@ -0,0 +1,184 @@
+package nodepool
+
+import (
+	"context"
+	"fmt"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool/kubevirt"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	supportutil "github.com/openshift/hypershift/support/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RolloutTarget is the version, config and machine template a NodePool rolls out to.
+type RolloutTarget struct {
+	Version string
+	// ConfigHash is the hash of the NodePool config which triggers a config update.
+	ConfigHash string
+	// PayloadConfigHash is the hash which names the token and user data Secrets of the ignition payload.
+	PayloadConfigHash string
+	// MachineTemplate is the name of the platform machine template, empty for platforms without one.
+	MachineTemplate string
+}
+
+// RolloutPlan compares the rollout targets of a NodePool for its current and a proposed spec.
+type RolloutPlan struct {
+	Current  RolloutTarget
+	Proposed RolloutTarget
+	// CoreConfigChanges are the changed HostedCluster fields which the control plane renders into
+	// the core ignition config. The targets are computed from the core ignition config currently
+	// rendered by the control plane, so these changes are not part of their hashes.
+	CoreConfigChanges []string
+}
+
+// UpdatingVersion returns true when the proposed spec changes the NodePool version.
+func (p *RolloutPlan) UpdatingVersion() bool {
+	return p.Current.Version != p.Proposed.Version
+}
+
+// UpdatingConfig returns true when the proposed spec triggers a config update of the NodePool.
+func (p *RolloutPlan) UpdatingConfig() bool {
+	return p.Current.ConfigHash != p.Proposed.ConfigHash
+}
+
+// UpdatingPayload returns true when the proposed spec generates a new ignition payload.
+func (p *RolloutPlan) UpdatingPayload() bool {
+	return p.Current.PayloadConfigHash != p.Proposed.PayloadConfigHash
+}
+
+// UpdatingMachineTemplate returns true when the proposed spec generates a new platform machine template.
+func (p *RolloutPlan) UpdatingMachineTemplate() bool {
+	return p.Current.MachineTemplate != p.Proposed.MachineTemplate
+}
+
+// UpdatingCoreConfig returns true when the proposed HostedCluster changes the core ignition config,
+// which rolls out the NodePool once the control plane has rendered it.
+func (p *RolloutPlan) UpdatingCoreConfig() bool {
+	return len(p.CoreConfigChanges) > 0
+}
+
+// RollsOut returns true when the proposed spec replaces or reconfigures the NodePool Machines.
+func (p *RolloutPlan) RollsOut() bool {
+	return p.UpdatingVersion() || p.UpdatingConfig() || p.UpdatingPayload() || p.UpdatingMachineTemplate() || p.UpdatingCoreConfig()
+}
+
+// PlanRollout runs the reconcile logic which decides the rollout of a NodePool for its current
+// HostedCluster and NodePool and for the proposed ones, without applying anything. Writes done
+// while gathering the NodePool config are sent as server-side dry-run requests.
+// Both targets are computed from the core ignition config currently rendered by the control
+// plane, the coreConfigChanges of the proposed HostedCluster to that config are recorded in
+// the plan instead and roll out the NodePool.
+func (r *NodePoolReconciler) PlanRollout(ctx context.Context, currentHC, proposedHC *hyperv1.HostedCluster, currentNodePool, proposedNodePool *hyperv1.NodePool, coreConfigChanges []string) (*RolloutPlan, error) {
+	planner := &NodePoolReconciler{
+		Client:                  client.NewDryRunClient(r.Client),
+		ReleaseProvider:         r.ReleaseProvider,
+		HypershiftOperatorImage: r.HypershiftOperatorImage,
+		ImageMetadataProvider:   r.ImageMetadataProvider,
+	}
+
+	current, err := planner.rolloutTarget(ctx, currentHC, currentNodePool.DeepCopy())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the rollout target of the current spec: %w", err)
+	}
+	proposed, err := planner.rolloutTarget(ctx, proposedHC, proposedNodePool.DeepCopy())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the rollout target of the proposed spec: %w", err)
+	}
+	return &RolloutPlan{Current: *current, Proposed: *proposed, CoreConfigChanges: coreConfigChanges}, nil
+}
+
+// rolloutTarget computes the rollout target the same way reconcile does.
+func (r *NodePoolReconciler) rolloutTarget(ctx context.Context, hcluster *hyperv1.HostedCluster, nodePool *hyperv1.NodePool) (*RolloutTarget, error) {
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(hcluster.Namespace, hcluster.Name)
+
+	releaseImage, err := r.getReleaseImage(ctx, hcluster, nodePool.Status.Version, nodePool.Spec.Release.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedCoreConfigResources := expectedCoreConfigResourcesForHostedCluster(hcluster)
+	config, _, missingConfigs, err := r.getConfig(ctx, nodePool, expectedCoreConfigResources, controlPlaneNamespace, releaseImage, hcluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	if missingConfigs {
+		return nil, fmt.Errorf("core ignition config has not been created yet")
+	}
+
+	pullSecretName, err := r.getPullSecretName(ctx, hcluster)
+	if err != nil {
+		return nil, err
+	}
+	globalConfig, err := globalConfigString(hcluster)
+	if err != nil {
+		return nil, err
+	}
+
+	targetVersion := releaseImage.Version()
+	target := &RolloutTarget{
+		Version:           targetVersion,
+		ConfigHash:        supportutil.HashSimple(config + pullSecretName),
+		PayloadConfigHash: payloadConfigHash(config, targetVersion, pullSecretName, globalConfig),
+	}
+
+	if isAutomatedMachineManagement(nodePool) {
+		target.MachineTemplate, err = r.machineTemplateName(ctx, hcluster, nodePool, releaseImage, controlPlaneNamespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return target, nil
+}
+
+// machineTemplateName looks up the platform boot image and returns the name of the machine
+// template the NodePool would use.
+func (r *NodePoolReconciler) machineTemplateName(ctx context.Context, hcluster *hyperv1.HostedCluster, nodePool *hyperv1.NodePool, releaseImage *releaseinfo.ReleaseImage, controlPlaneNamespace string) (string, error) {
+	var ami, powervsBootImage string
+	var kubevirtBootImage kubevirt.BootImage
+	var err error
+	switch nodePool.Spec.Platform.Type {
+	case hyperv1.AWSPlatform:
+		if hcluster.Spec.Platform.AWS == nil {
+			return "", fmt.Errorf("the HostedCluster for this NodePool has no .Spec.Platform.AWS, this is unsupported")
+		}
+		ami = nodePool.Spec.Platform.AWS.AMI
+		if ami == "" {
+			ami, err = defaultNodePoolAMI(hcluster.Spec.Platform.AWS.Region, nodePool.Spec.Arch, releaseImage)
+			if err != nil {
+				return "", fmt.Errorf("couldn't discover an AMI for release image: %w", err)
+			}
+		}
+	case hyperv1.PowerVSPlatform:
+		coreOSPowerVSImage, _, err := getPowerVSImage(hcluster.Spec.Platform.PowerVS.Region, releaseImage)
+		if err != nil {
+			return "", fmt.Errorf("couldn't discover a PowerVS Image for release image: %w", err)
+		}
+		powervsBootImage = coreOSPowerVSImage.Release
+	case hyperv1.KubevirtPlatform:
+		infraNS := controlPlaneNamespace
+		if hcluster.Spec.Platform.Kubevirt != nil &&
+			hcluster.Spec.Platform.Kubevirt.Credentials != nil &&
+			len(hcluster.Spec.Platform.Kubevirt.Credentials.InfraNamespace) > 0 {
+			infraNS = hcluster.Spec.Platform.Kubevirt.Credentials.InfraNamespace
+		}
+		kubevirtBootImage, err = kubevirt.GetImage(nodePool, releaseImage, infraNS)
+		if err != nil {
+			return "", fmt.Errorf("couldn't discover a KubeVirt Image in release payload image: %w", err)
+		}
+	}
+
+	cpoCapabilities, err := r.detectCPOCapabilities(ctx, hcluster)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect CPO capabilities: %w", err)
+	}
+	template, _, _, err := machineTemplateBuilders(hcluster, nodePool, hcluster.Spec.InfraID, ami, powervsBootImage, kubevirtBootImage, cpoCapabilities.CreateDefaultAWSSecurityGroup)
+	if err != nil {
+		return "", fmt.Errorf("failed to build machine template: %w", err)
+	}
+	if template == nil {
+		return "", nil
+	}
+	return template.GetName(), nil
+}
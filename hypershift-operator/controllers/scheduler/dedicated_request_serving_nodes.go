@@ -776,6 +776,10 @@ func (r *DedicatedServingComponentSchedulerAndSizer) updateHostedCluster(ctx con
 		resourceRequestAnnotations = resourceRequestsToOverrideAnnotations(sizeConfig.Effects.ResourceRequests)
 	}
 	for k, v := range resourceRequestAnnotations {
+		if _, exists := hc.Annotations[k]; exists && sizedFromUsage(config, k) {
+			// the sizing controller owns requests sized from usage once they have been set
+			continue
+		}
 		hc.Annotations[k] = v
 	}
 
@@ -1005,6 +1009,19 @@ func resourceRequestsToOverrideAnnotations(requests []schedulingv1alpha1.Resourc
 	return annotations
 }
 
+// sizedFromUsage determines if the resource request override annotation is for a component sized from usage.
+func sizedFromUsage(config *schedulingv1alpha1.ClusterSizingConfiguration, annotation string) bool {
+	if config.Spec.UsageBasedSizing == nil {
+		return false
+	}
+	for _, component := range config.Spec.UsageBasedSizing.Components {
+		if annotation == fmt.Sprintf("%s/%s.%s", hyperv1.ResourceRequestOverrideAnnotationPrefix, component.DeploymentName, component.ContainerName) {
+			return true
+		}
+	}
+	return false
+}
+
 func configHasMHCTimeout(config *schedulingv1alpha1.ClusterSizingConfiguration) bool {
 	for _, size := range config.Spec.Sizes {
 		if size.Effects != nil && size.Effects.MachineHealthCheckTimeout != nil {
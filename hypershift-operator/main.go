@@ -21,6 +21,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -28,10 +29,13 @@ import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
 	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	cmdutil "github.com/openshift/hypershift/cmd/util"
 	pkiconfig "github.com/openshift/hypershift/control-plane-pki-operator/config"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedcluster"
 	hcmetrics "github.com/openshift/hypershift/hypershift-operator/controllers/hostedcluster/metrics"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedclusterinfrastructure"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedclustersizing"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedclusterupgradeplan"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
 	npmetrics "github.com/openshift/hypershift/hypershift-operator/controllers/nodepool/metrics"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/platform/aws"
@@ -47,6 +51,7 @@ import (
 	"github.com/openshift/hypershift/support/config"
 	"github.com/openshift/hypershift/support/globalconfig"
 	"github.com/openshift/hypershift/support/metrics"
+	"github.com/openshift/hypershift/support/oidc"
 	"github.com/openshift/hypershift/support/releaseinfo"
 	"github.com/openshift/hypershift/support/upsert"
 	hyperutil "github.com/openshift/hypershift/support/util"
@@ -106,6 +111,11 @@ type StartOptions struct {
 	OIDCStorageProviderS3BucketName        string
 	OIDCStorageProviderS3Region            string
 	OIDCStorageProviderS3Credentials       string
+	OIDCStorageProviderS3Endpoint          string
+	OIDCStorageProvider                    string
+	OIDCStorageProviderAzureBlobURL        string
+	OIDCStorageProviderAzureCredentials    string
+	OIDCStorageProviderInClusterAddr       string
 	EnableUWMTelemetryRemoteWrite          bool
 	EnableValidatingWebhook                bool
 	EnableDedicatedRequestServingIsolation bool
@@ -127,6 +137,7 @@ func NewStartCommand() *cobra.Command {
 		PrivatePlatform:                  string(hyperv1.NonePlatform),
 		OIDCStorageProviderS3Region:      "",
 		OIDCStorageProviderS3Credentials: "",
+		OIDCStorageProviderInClusterAddr: ":9080",
 	}
 
 	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace this operator lives in")
@@ -142,6 +153,11 @@ func NewStartCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3BucketName, "oidc-storage-provider-s3-bucket-name", "", "Name of the bucket in which to store the clusters OIDC discovery information. Required for AWS guest clusters")
 	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3Region, "oidc-storage-provider-s3-region", opts.OIDCStorageProviderS3Region, "Region in which the OIDC bucket is located. Required for AWS guest clusters")
 	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3Credentials, "oidc-storage-provider-s3-credentials", opts.OIDCStorageProviderS3Credentials, "Location of the credentials file for the OIDC bucket. Required for AWS guest clusters.")
+	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3Endpoint, "oidc-storage-provider-s3-endpoint", opts.OIDCStorageProviderS3Endpoint, "Endpoint of an S3 compatible object storage holding the OIDC bucket, the bucket is addressed path-style. Defaults to AWS S3")
+	cmd.Flags().StringVar(&opts.OIDCStorageProvider, "oidc-storage-provider", opts.OIDCStorageProvider, fmt.Sprintf("Where to publish the OIDC discovery documents of hosted clusters (supports %q, %q or %q). Defaults to %q when --oidc-storage-provider-s3-bucket-name is set", oidc.StorageProviderS3, oidc.StorageProviderAzureBlob, oidc.StorageProviderInCluster, oidc.StorageProviderS3))
+	cmd.Flags().StringVar(&opts.OIDCStorageProviderAzureBlobURL, "oidc-storage-provider-azure-blob-container-url", opts.OIDCStorageProviderAzureBlobURL, "URL of the Azure Blob container in which to store the OIDC documents, usually the $web container of a storage account with static websites enabled")
+	cmd.Flags().StringVar(&opts.OIDCStorageProviderAzureCredentials, "oidc-storage-provider-azure-blob-credentials", opts.OIDCStorageProviderAzureCredentials, "Location of the Azure credentials file authorized to write into the OIDC Azure Blob container")
+	cmd.Flags().StringVar(&opts.OIDCStorageProviderInClusterAddr, "oidc-storage-provider-in-cluster-addr", opts.OIDCStorageProviderInClusterAddr, "The address the in-cluster OIDC discovery server binds to")
 	cmd.Flags().BoolVar(&opts.EnableUWMTelemetryRemoteWrite, "enable-uwm-telemetry-remote-write", opts.EnableUWMTelemetryRemoteWrite, "If true, enables a controller that ensures user workload monitoring is enabled and that it is configured to remote write telemetry metrics from control planes")
 	cmd.Flags().BoolVar(&opts.EnableValidatingWebhook, "enable-validating-webhook", false, "Enable webhook for validating hypershift API types")
 	cmd.Flags().BoolVar(&opts.EnableDedicatedRequestServingIsolation, "enable-dedicated-request-serving-isolation", true, "If true, enables scheduling of request serving components to dedicated nodes")
@@ -330,12 +346,8 @@ func run(ctx context.Context, opts *StartOptions, log logr.Logger) error {
 		CertRotationScale:                       certRotationScale,
 		EnableCVOManagementClusterMetricsAccess: enableCVOManagementClusterMetricsAccess,
 	}
-	if opts.OIDCStorageProviderS3BucketName != "" {
-		awsSession := awsutil.NewSession("hypershift-operator-oidc-bucket", opts.OIDCStorageProviderS3Credentials, "", "", opts.OIDCStorageProviderS3Region)
-		awsConfig := awsutil.NewConfig()
-		s3Client := s3.New(awsSession, awsConfig)
-		hostedClusterReconciler.S3Client = s3Client
-		hostedClusterReconciler.OIDCStorageProviderS3BucketName = opts.OIDCStorageProviderS3BucketName
+	if hostedClusterReconciler.OIDCStorage, err = setupOIDCStorage(mgr, opts); err != nil {
+		return err
 	}
 	if err := hostedClusterReconciler.SetupWithManager(mgr, createOrUpdate, metricsSet, opts.Namespace); err != nil {
 		return fmt.Errorf("unable to create controller: %w", err)
@@ -417,6 +429,21 @@ func run(ctx context.Context, opts *StartOptions, log logr.Logger) error {
 		}
 	}
 
+	// Start controller to upgrade fleets of HostedClusters following HostedClusterUpgradePlans
+	if err := (&hostedclusterupgradeplan.Reconciler{
+		Client:          mgr.GetClient(),
+		ReleaseProvider: releaseProviderWithOpenShiftImageRegistryOverrides,
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to create hosted cluster upgrade plan controller: %w", err)
+	}
+
+	// Start controller to create and repair the cloud resources of HostedClusterInfrastructures
+	if err := (&hostedclusterinfrastructure.Reconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to create hosted cluster infrastructure controller: %w", err)
+	}
+
 	// Start controller to manage supported versions configmap
 	if err := supportedversion.New(mgr.GetClient(), createOrUpdate, opts.Namespace).
 		SetupWithManager(mgr); err != nil {
@@ -533,3 +560,53 @@ func run(ctx context.Context, opts *StartOptions, log logr.Logger) error {
 	log.Info("starting manager")
 	return mgr.Start(ctx)
 }
+
+// setupOIDCStorage returns the storage the OIDC discovery documents of hosted clusters are
+// published to, or nil when no OIDC storage provider is configured.
+func setupOIDCStorage(mgr ctrl.Manager, opts *StartOptions) (oidc.Storage, error) {
+	provider := opts.OIDCStorageProvider
+	if provider == "" && opts.OIDCStorageProviderS3BucketName != "" {
+		provider = oidc.StorageProviderS3
+	}
+	switch provider {
+	case "":
+		return nil, nil
+	case oidc.StorageProviderS3:
+		if opts.OIDCStorageProviderS3BucketName == "" {
+			return nil, fmt.Errorf("--oidc-storage-provider-s3-bucket-name is required for the %s OIDC storage provider", provider)
+		}
+		awsSession := awsutil.NewSession("hypershift-operator-oidc-bucket", opts.OIDCStorageProviderS3Credentials, "", "", opts.OIDCStorageProviderS3Region)
+		awsConfig := awsutil.NewConfig()
+		if opts.OIDCStorageProviderS3Endpoint != "" {
+			awsConfig = awsConfig.WithEndpoint(opts.OIDCStorageProviderS3Endpoint).WithS3ForcePathStyle(true)
+		}
+		return &oidc.S3Storage{
+			Client: s3.New(awsSession, awsConfig),
+			Bucket: opts.OIDCStorageProviderS3BucketName,
+		}, nil
+	case oidc.StorageProviderAzureBlob:
+		if opts.OIDCStorageProviderAzureBlobURL == "" || opts.OIDCStorageProviderAzureCredentials == "" {
+			return nil, fmt.Errorf("--oidc-storage-provider-azure-blob-container-url and --oidc-storage-provider-azure-blob-credentials are required for the %s OIDC storage provider", provider)
+		}
+		creds, err := cmdutil.ReadCredentials(opts.OIDCStorageProviderAzureCredentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the OIDC Azure Blob credentials: %w", err)
+		}
+		credential, err := azidentity.NewClientSecretCredential(creds.TenantID, creds.ClientID, creds.ClientSecret, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the OIDC Azure Blob credentials: %w", err)
+		}
+		return oidc.NewAzureBlobStorage(opts.OIDCStorageProviderAzureBlobURL, credential)
+	case oidc.StorageProviderInCluster:
+		storage := &oidc.ConfigMapStorage{
+			Client:    mgr.GetClient(),
+			Namespace: opts.Namespace,
+		}
+		if err := mgr.Add(&oidc.DiscoveryServer{Addr: opts.OIDCStorageProviderInClusterAddr, Storage: storage}); err != nil {
+			return nil, fmt.Errorf("failed to add the OIDC discovery server: %w", err)
+		}
+		return storage, nil
+	default:
+		return nil, fmt.Errorf("unsupported OIDC storage provider %q", provider)
+	}
+}
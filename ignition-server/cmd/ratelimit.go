@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	rejectReasonNotFound            = "NotFound"
+	rejectReasonSourceRateLimited   = "SourceRateLimited"
+	rejectReasonNodePoolRateLimited = "NodePoolRateLimited"
+	rejectReasonUnauthorized        = "Unauthorized"
+	rejectReasonTokenNotFound       = "TokenNotFound"
+	rejectReasonEnrollmentRefused   = "EnrollmentRefused"
+
+	// idleLimiterTTL is how long a limiter is kept around after its last request.
+	idleLimiterTTL = 10 * time.Minute
+	// maxLimiterKeys bounds the number of limiters. Requests for new keys beyond it
+	// share a single overflow limiter.
+	maxLimiterKeys     = 10000
+	overflowLimiterKey = ""
+)
+
+var (
+	rejectedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ign_server_rejected_request_total",
+		Help: "Number of ignition payload requests which were not served, by reason.",
+	}, []string{"reason"})
+	unauthorizedRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ign_server_unauthorized_request_total",
+		Help: "Number of ignition payload requests with a missing, malformed or unknown token.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		rejectedRequests,
+		unauthorizedRequests,
+	)
+}
+
+// keyedRateLimiter keeps a token bucket per key, e.g. per source IP or per NodePool.
+// A zero limit disables rate limiting.
+type keyedRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	limiters  map[string]*limiterEntry
+	lastSweep time.Time
+	sync.Mutex
+}
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newKeyedRateLimiter(limit float64, burst int) *keyedRateLimiter {
+	return &keyedRateLimiter{
+		limit:    rate.Limit(limit),
+		burst:    burst,
+		limiters: make(map[string]*limiterEntry),
+	}
+}
+
+// Allow reports whether a request for the given key may be served now.
+func (l *keyedRateLimiter) Allow(key string, now time.Time) bool {
+	if l.limit <= 0 {
+		return true
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	// Drop idle limiters so that keys controlled by clients can't grow the map forever.
+	if now.Sub(l.lastSweep) > idleLimiterTTL {
+		for k, entry := range l.limiters {
+			if now.Sub(entry.lastSeen) > idleLimiterTTL {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	entry, ok := l.limiters[key]
+	if !ok && len(l.limiters) >= maxLimiterKeys {
+		key = overflowLimiterKey
+		entry, ok = l.limiters[key]
+	}
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
+
+// sourceIP returns the address of the client which sent the request.
+// When trustForwardedFor is set, the address appended to X-Forwarded-For by the
+// ignition server proxy in front of this server is used instead of the peer address.
+func sourceIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// payloadRequestAudit records the outcome of a payload request as a structured
+// audit log entry, and counts rejected requests.
+type payloadRequestAudit struct {
+	SourceIP   string
+	NodePool   string
+	ConfigHash string
+	UserAgent  string
+	Path       string
+	Outcome    string
+	Status     int
+	Message    string
+}
+
+func newPayloadRequestAudit(r *http.Request, trustForwardedFor bool) *payloadRequestAudit {
+	return &payloadRequestAudit{
+		SourceIP:   sourceIP(r, trustForwardedFor),
+		NodePool:   r.Header.Get("NodePool"),
+		ConfigHash: r.Header.Get("TargetConfigVersionHash"),
+		UserAgent:  r.Header.Get("User-Agent"),
+		Path:       r.URL.Path,
+		Outcome:    "Served",
+		Status:     http.StatusOK,
+	}
+}
+
+// reject marks the request as not served for the given reason.
+func (a *payloadRequestAudit) reject(reason string, status int, message string) {
+	a.Outcome = reason
+	a.Status = status
+	a.Message = message
+	rejectedRequests.WithLabelValues(reason).Inc()
+	switch reason {
+	case rejectReasonUnauthorized, rejectReasonTokenNotFound:
+		unauthorizedRequests.Inc()
+	}
+}
+
+func (a *payloadRequestAudit) log(logger logr.Logger) {
+	logger.Info("payload request",
+		"sourceIP", a.SourceIP,
+		"nodePool", a.NodePool,
+		"configHash", a.ConfigHash,
+		"userAgent", a.UserAgent,
+		"path", a.Path,
+		"outcome", a.Outcome,
+		"status", a.Status,
+		"message", a.Message,
+	)
+}
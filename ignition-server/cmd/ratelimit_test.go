@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestKeyedRateLimiter(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+
+	limiter := newKeyedRateLimiter(1, 2)
+	g.Expect(limiter.Allow("a", now)).To(BeTrue())
+	g.Expect(limiter.Allow("a", now)).To(BeTrue())
+	g.Expect(limiter.Allow("a", now)).To(BeFalse(), "burst should be exhausted")
+	g.Expect(limiter.Allow("b", now)).To(BeTrue(), "keys should be limited independently")
+	g.Expect(limiter.Allow("a", now.Add(time.Second))).To(BeTrue(), "tokens should be refilled over time")
+
+	// Idle limiters are dropped.
+	g.Expect(limiter.Allow("c", now.Add(2*idleLimiterTTL))).To(BeTrue())
+	g.Expect(limiter.limiters).To(HaveLen(1))
+
+	// Keys beyond the limit share an overflow limiter.
+	bounded := newKeyedRateLimiter(1, 1)
+	for i := 0; i < maxLimiterKeys; i++ {
+		g.Expect(bounded.Allow(fmt.Sprintf("key-%d", i), now)).To(BeTrue())
+	}
+	g.Expect(bounded.Allow("new-a", now)).To(BeTrue())
+	g.Expect(bounded.Allow("new-b", now)).To(BeFalse(), "new keys should share the overflow limiter")
+	g.Expect(bounded.limiters).To(HaveLen(maxLimiterKeys + 1))
+
+	disabled := newKeyedRateLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		g.Expect(disabled.Allow("a", now)).To(BeTrue())
+	}
+}
+
+func TestSourceIP(t *testing.T) {
+	testCases := []struct {
+		name              string
+		remoteAddr        string
+		forwardedFor      []string
+		trustForwardedFor bool
+		expected          string
+	}{
+		{
+			name:       "When X-Forwarded-For is not trusted it should use the peer address",
+			remoteAddr: "10.0.0.1:1234",
+			forwardedFor: []string{
+				"192.168.0.1",
+			},
+			expected: "10.0.0.1",
+		},
+		{
+			name:              "When X-Forwarded-For is trusted it should use the address appended by the proxy",
+			remoteAddr:        "10.0.0.1:1234",
+			forwardedFor:      []string{"1.2.3.4, 192.168.0.1", "192.168.0.2"},
+			trustForwardedFor: true,
+			expected:          "192.168.0.2",
+		},
+		{
+			name:              "When X-Forwarded-For is trusted but missing it should use the peer address",
+			remoteAddr:        "10.0.0.1:1234",
+			trustForwardedFor: true,
+			expected:          "10.0.0.1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			r := httptest.NewRequest("GET", "/ignition", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			g.Expect(sourceIP(r, tc.trustForwardedFor)).To(Equal(tc.expected))
+		})
+	}
+}
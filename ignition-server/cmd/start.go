@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 	"go.uber.org/zap/zapcore"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
@@ -60,6 +61,13 @@ type Options struct {
 	WorkDir             string
 	MetricsAddr         string
 	FeatureGateManifest string
+	TokenBinding        bool
+	TokenBindingTTL     time.Duration
+	SourceRateLimit     float64
+	SourceRateBurst     int
+	NodePoolRateLimit   float64
+	NodePoolRateBurst   int
+	TrustForwardedFor   bool
 }
 
 // This is a https server that enable us to satisfy
@@ -83,6 +91,12 @@ func NewStartCommand() *cobra.Command {
 		KeyFile:           "/var/run/secrets/ignition/serving-cert/tls.key",
 		WorkDir:           "/payloads",
 		RegistryOverrides: map[string]string{},
+		TokenBindingTTL:   time.Hour,
+		// Nodes behind a NAT gateway share a source IP, so these leave room for large scale ups.
+		SourceRateLimit:   5,
+		SourceRateBurst:   50,
+		NodePoolRateLimit: 20,
+		NodePoolRateBurst: 200,
 	}
 
 	cmd.Flags().StringVar(&opts.Addr, "addr", opts.Addr, "Listen address")
@@ -93,6 +107,13 @@ func NewStartCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.WorkDir, "work-dir", opts.WorkDir, "Directory in which to store transient working data")
 	cmd.Flags().StringVar(&opts.MetricsAddr, "metrics-addr", opts.MetricsAddr, "The address the metric endpoint binds to.")
 	cmd.Flags().StringVar(&opts.FeatureGateManifest, "feature-gate-manifest", opts.FeatureGateManifest, "Path to a rendered featuregates.config.openshift.io/v1 file")
+	cmd.Flags().BoolVar(&opts.TokenBinding, "token-binding", opts.TokenBinding, "Bind every payload request to a single-use enrollment of a Machine waiting for its payload, refusing any other request")
+	cmd.Flags().DurationVar(&opts.TokenBindingTTL, "token-binding-ttl", opts.TokenBindingTTL, "How long after its creation a Machine can claim its enrollment when token binding is enabled")
+	cmd.Flags().Float64Var(&opts.SourceRateLimit, "source-rate-limit", opts.SourceRateLimit, "Maximum sustained payload requests per second from a single source IP. Zero disables the limit.")
+	cmd.Flags().IntVar(&opts.SourceRateBurst, "source-rate-burst", opts.SourceRateBurst, "Maximum burst of payload requests from a single source IP")
+	cmd.Flags().Float64Var(&opts.NodePoolRateLimit, "nodepool-rate-limit", opts.NodePoolRateLimit, "Maximum sustained authorized payload requests per second for a single NodePool. Zero disables the limit.")
+	cmd.Flags().IntVar(&opts.NodePoolRateBurst, "nodepool-rate-burst", opts.NodePoolRateBurst, "Maximum burst of authorized payload requests for a single NodePool")
+	cmd.Flags().BoolVar(&opts.TrustForwardedFor, "trust-forwarded-for", opts.TrustForwardedFor, "Identify the source of requests by the X-Forwarded-For header set by the ignition server proxy")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -115,7 +136,7 @@ func NewStartCommand() *cobra.Command {
 
 // setUpPayloadStoreReconciler sets up manager with a TokenSecretReconciler controller
 // to keep the PayloadStore up to date.
-func setUpPayloadStoreReconciler(ctx context.Context, registryOverrides map[string]string, cloudProvider hyperv1.PlatformType, cacheDir string, metricsAddr string, featureGateManifest string) (ctrl.Manager, error) {
+func setUpPayloadStoreReconciler(ctx context.Context, registryOverrides map[string]string, cloudProvider hyperv1.PlatformType, cacheDir string, metricsAddr string, featureGateManifest string, machineEnrollments *controllers.MachineEnrollments) (ctrl.Manager, error) {
 	if os.Getenv(namespaceEnvVariableName) == "" {
 		return nil, fmt.Errorf("environment variable %s is empty, this is not supported", namespaceEnvVariableName)
 	}
@@ -170,6 +191,7 @@ func setUpPayloadStoreReconciler(ctx context.Context, registryOverrides map[stri
 			ImageFileCache:      imageFileCache,
 			FeatureGateManifest: featureGateManifest,
 		},
+		MachineEnrollments: machineEnrollments,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		return nil, fmt.Errorf("unable to create controller: %w", err)
 	}
@@ -189,7 +211,12 @@ func run(ctx context.Context, opts Options) error {
 		return fmt.Errorf("failed to load serving cert: %w", err)
 	}
 
-	mgr, err := setUpPayloadStoreReconciler(ctx, opts.RegistryOverrides, hyperv1.PlatformType(opts.Platform), opts.WorkDir, opts.MetricsAddr, opts.FeatureGateManifest)
+	var machineEnrollments *controllers.MachineEnrollments
+	if opts.TokenBinding {
+		machineEnrollments = &controllers.MachineEnrollments{TTL: opts.TokenBindingTTL}
+	}
+
+	mgr, err := setUpPayloadStoreReconciler(ctx, opts.RegistryOverrides, hyperv1.PlatformType(opts.Platform), opts.WorkDir, opts.MetricsAddr, opts.FeatureGateManifest, machineEnrollments)
 	if err != nil {
 		return fmt.Errorf("error setting up manager: %w", err)
 	}
@@ -200,19 +227,33 @@ func run(ctx context.Context, opts Options) error {
 
 	mgr.GetLogger().Info("Using opts", "opts", fmt.Sprintf("%+v", opts))
 	eventRecorder := mgr.GetEventRecorderFor("ignition-server")
+	auditLogger := mgr.GetLogger().WithName("audit")
+	sourceRateLimiter := newKeyedRateLimiter(opts.SourceRateLimit, opts.SourceRateBurst)
+	nodePoolRateLimiter := newKeyedRateLimiter(opts.NodePoolRateLimit, opts.NodePoolRateBurst)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("User Agent: %s. Requested: %s", r.Header.Get("User-Agent"), r.URL.Path)
+		audit := newPayloadRequestAudit(r, opts.TrustForwardedFor)
+		defer audit.log(auditLogger)
 
 		tokenSecret := nodepool.TokenSecret(os.Getenv(namespaceEnvVariableName),
 			util.ParseNamespacedName(r.Header.Get("NodePool")).Name,
 			r.Header.Get("TargetConfigVersionHash"))
 
+		// We return a 5xx on rate limiting so ignition backs off and retries.
+		if !sourceRateLimiter.Allow(audit.SourceIP, time.Now()) {
+			log.Printf("Rate limited source %s for NodePool %s", audit.SourceIP, audit.NodePool)
+			http.Error(w, "Too many requests", http.StatusServiceUnavailable)
+			audit.reject(rejectReasonSourceRateLimited, http.StatusServiceUnavailable, "source rate limit exceeded")
+			return
+		}
+
 		if !ignPathPattern.MatchString(r.URL.Path) {
 			// No pattern matched; send 404 response.
 			log.Printf("Path not found: %s", r.URL.Path)
 			http.NotFound(w, r)
+			audit.reject(rejectReasonNotFound, http.StatusNotFound, "path not found")
 			return
 		}
 
@@ -224,6 +265,7 @@ func run(ctx context.Context, opts Options) error {
 			log.Printf("Invalid Authorization header value prefix")
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", "Bad header")
+			audit.reject(rejectReasonUnauthorized, http.StatusUnauthorized, "bad authorization header")
 			return
 		}
 		encodedToken := auth[n:]
@@ -232,6 +274,7 @@ func run(ctx context.Context, opts Options) error {
 			log.Printf("Invalid token value")
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", "Token invalid")
+			audit.reject(rejectReasonUnauthorized, http.StatusUnauthorized, "token invalid")
 			return
 		}
 
@@ -243,9 +286,37 @@ func run(ctx context.Context, opts Options) error {
 			log.Printf("Token not found")
 			http.Error(w, "Token not found", http.StatusNetworkAuthenticationRequired)
 			eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", "Token not found in cache")
+			audit.reject(rejectReasonTokenNotFound, http.StatusNetworkAuthenticationRequired, "token not found in cache")
+			return
+		}
+
+		// The NodePool limit only applies to authorized requests, so that unauthorized
+		// clients can't exhaust the budget of a legitimate NodePool.
+		// The token Secret identifies the NodePool, unlike the NodePool header which is set by the client.
+		if !nodePoolRateLimiter.Allow(value.SecretName, time.Now()) {
+			log.Printf("Rate limited NodePool %s", audit.NodePool)
+			http.Error(w, "Too many requests", http.StatusServiceUnavailable)
+			eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", "NodePool rate limit exceeded")
+			audit.reject(rejectReasonNodePoolRateLimited, http.StatusServiceUnavailable, "nodepool rate limit exceeded")
 			return
 		}
 
+		// In token binding mode the shared token alone is not enough: the request must also
+		// present the token from the user data of a Machine which is waiting for its payload.
+		// The NodePool is the one of the token Secret, not the one of the header set by the client.
+		if machineEnrollments != nil {
+			machine, err := claimMachineEnrollment(ctx, mgr.GetClient(), machineEnrollments, value.SecretName, r.Header.Get(controllers.MachineTokenHeader))
+			if err != nil {
+				log.Printf("Refusing payload request: %s", err)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadRefused", fmt.Sprintf("Unexpected payload request: %s", err))
+				audit.reject(rejectReasonEnrollmentRefused, http.StatusForbidden, err.Error())
+				return
+			}
+			eventRecorder.Eventf(tokenSecret, corev1.EventTypeNormal, "MachineEnrolled", "Payload bound to Machine %s", machine.Name)
+			audit.Message = fmt.Sprintf("bound to Machine %s", machine.Name)
+		}
+
 		w.WriteHeader(http.StatusOK)
 		w.Write(value.Payload)
 
@@ -301,3 +372,14 @@ func run(ctx context.Context, opts Options) error {
 	}
 	return nil
 }
+
+// claimMachineEnrollment claims the enrollment of the Machine whose token was presented, among the
+// Machines of the NodePool of the token Secret the request was authorized with.
+func claimMachineEnrollment(ctx context.Context, c client.Client, enrollments *controllers.MachineEnrollments, tokenSecretName, machineToken string) (types.NamespacedName, error) {
+	namespace := os.Getenv(namespaceEnvVariableName)
+	tokenSecret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: tokenSecretName}, tokenSecret); err != nil {
+		return types.NamespacedName{}, fmt.Errorf("failed to get token secret %s: %w", tokenSecretName, err)
+	}
+	return enrollments.Claim(ctx, c, namespace, tokenSecret.Annotations[controllers.TokenSecretNodePoolAnnotation], machineToken, time.Now())
+}
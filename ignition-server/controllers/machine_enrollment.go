@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	ignitionapi "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// TokenSecretNodePoolAnnotation holds the namespaced name of the NodePool owning a token Secret or a Machine.
+	TokenSecretNodePoolAnnotation = "hypershift.openshift.io/nodePool"
+	// MachineEnrollmentAnnotation is set on a Machine once it has fetched its ignition payload
+	// in token binding mode. The value is the time the enrollment was claimed.
+	MachineEnrollmentAnnotation = "hypershift.openshift.io/ignition-enrollment"
+	// MachineUserDataSourceAnnotation is set on the user data Secret of a Machine in token
+	// binding mode. The value is the name of the NodePool user data Secret it is rendered from.
+	MachineUserDataSourceAnnotation = "hypershift.openshift.io/ignition-user-data-source"
+	// MachineUserDataLabel marks the user data Secrets of single Machines in token binding mode.
+	MachineUserDataLabel = "hypershift.openshift.io/ignition-machine-user-data"
+	// MachineTokenHeader is the ignition request header carrying the enrollment token of a Machine.
+	MachineTokenHeader = "Machine-Token"
+	// MachineTokenKey is the key of the enrollment token in the user data Secret of a Machine.
+	MachineTokenKey = "machine-token"
+
+	// unboundUserDataSuffix is appended to the NodePool user data Secret name in the Machine
+	// templates of NodePools in token binding mode.
+	unboundUserDataSuffix = "-unbound"
+)
+
+var (
+	EnrollmentClaimedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ign_server_enrollment_claimed_total",
+	})
+
+	EnrollmentRejectedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ign_server_enrollment_rejected_total",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		EnrollmentClaimedTotal,
+		EnrollmentRejectedTotal,
+	)
+}
+
+// UnboundUserDataSecretName is the bootstrap data Secret name set in the Machine templates of a
+// NodePool in token binding mode. No Secret is ever created with this name, so infrastructure
+// providers can't launch an instance until the ignition server has bound the user data to the
+// Machine and pointed the Machine at it.
+func UnboundUserDataSecretName(userDataSecretName string) string {
+	return userDataSecretName + unboundUserDataSuffix
+}
+
+// MachineEnrollments binds payload requests to single-use enrollments of the Machines waiting
+// for their payload. Every Machine gets its own user data carrying a random enrollment token,
+// and a payload request must present it along with the shared NodePool token. The tokens and
+// the claims are stored in the user data Secrets and on the Machines, so that every ignition
+// server replica sees the same enrollments.
+type MachineEnrollments struct {
+	// TTL is how long after its creation a Machine can claim its enrollment.
+	TTL time.Duration
+}
+
+// Claim binds a payload request for a NodePool to the pending Machine whose enrollment token was
+// presented. The claim is recorded on the Machine with an optimistic lock so concurrent ignition
+// server replicas can't hand out the same enrollment twice.
+func (e *MachineEnrollments) Claim(ctx context.Context, c client.Client, namespace, nodePool, machineToken string, now time.Time) (types.NamespacedName, error) {
+	machine, err := e.claim(ctx, c, namespace, nodePool, machineToken, now)
+	if err != nil {
+		EnrollmentRejectedTotal.Inc()
+		return types.NamespacedName{}, err
+	}
+	EnrollmentClaimedTotal.Inc()
+	return machine, nil
+}
+
+func (e *MachineEnrollments) claim(ctx context.Context, c client.Client, namespace, nodePool, machineToken string, now time.Time) (types.NamespacedName, error) {
+	if machineToken == "" {
+		return types.NamespacedName{}, fmt.Errorf("request for NodePool %s has no %s header", nodePool, MachineTokenHeader)
+	}
+	userDataSecrets := &corev1.SecretList{}
+	if err := c.List(ctx, userDataSecrets, client.InNamespace(namespace), client.MatchingLabels{MachineUserDataLabel: "true"}); err != nil {
+		return types.NamespacedName{}, fmt.Errorf("failed to list machine user data secrets: %w", err)
+	}
+
+	var owner *metav1.OwnerReference
+	for _, secret := range userDataSecrets.Items {
+		if subtle.ConstantTimeCompare(secret.Data[MachineTokenKey], []byte(machineToken)) != 1 {
+			continue
+		}
+		for _, ref := range secret.OwnerReferences {
+			if ref.Kind == "Machine" {
+				owner = ref.DeepCopy()
+			}
+		}
+		break
+	}
+	if owner == nil {
+		return types.NamespacedName{}, fmt.Errorf("no Machine of NodePool %s matches the presented token", nodePool)
+	}
+
+	key := types.NamespacedName{Namespace: namespace, Name: owner.Name}
+	machine := &capiv1.Machine{}
+	if err := c.Get(ctx, key, machine); err != nil {
+		return types.NamespacedName{}, fmt.Errorf("failed to get Machine %s: %w", key, err)
+	}
+	if machine.UID != owner.UID || machine.Annotations[TokenSecretNodePoolAnnotation] != nodePool {
+		return types.NamespacedName{}, fmt.Errorf("no Machine of NodePool %s matches the presented token", nodePool)
+	}
+	if !e.pending(machine, now) {
+		return types.NamespacedName{}, fmt.Errorf("enrollment of Machine %s was already claimed or has expired", key)
+	}
+
+	patch := client.MergeFromWithOptions(machine.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if machine.Annotations == nil {
+		machine.Annotations = map[string]string{}
+	}
+	machine.Annotations[MachineEnrollmentAnnotation] = now.UTC().Format(time.RFC3339)
+	if err := c.Patch(ctx, machine, patch); err != nil {
+		if apierrors.IsConflict(err) {
+			// Somebody else claimed or changed the Machine, the request can be retried.
+			return types.NamespacedName{}, fmt.Errorf("enrollment of Machine %s changed concurrently: %w", key, err)
+		}
+		return types.NamespacedName{}, err
+	}
+	return key, nil
+}
+
+// pending returns true if the Machine has neither joined the cluster nor fetched its payload yet,
+// and was created within the enrollment TTL.
+func (e *MachineEnrollments) pending(machine *capiv1.Machine, now time.Time) bool {
+	if _, claimed := machine.Annotations[MachineEnrollmentAnnotation]; claimed {
+		return false
+	}
+	if machine.Status.NodeRef != nil || !machine.DeletionTimestamp.IsZero() {
+		return false
+	}
+	return now.Before(machine.CreationTimestamp.Add(e.TTL))
+}
+
+// reconcileMachineEnrollments binds the user data of every pending Machine of the token Secret's
+// NodePool to an enrollment of its own.
+func (r *TokenSecretReconciler) reconcileMachineEnrollments(ctx context.Context, tokenSecret *corev1.Secret) error {
+	nodePool := tokenSecret.Annotations[TokenSecretNodePoolAnnotation]
+	if nodePool == "" {
+		return nil
+	}
+
+	machines := &capiv1.MachineList{}
+	if err := r.Client.List(ctx, machines, client.InNamespace(tokenSecret.Namespace)); err != nil {
+		return fmt.Errorf("failed to list machines: %w", err)
+	}
+
+	now := time.Now()
+	for i := range machines.Items {
+		machine := &machines.Items[i]
+		if machine.Annotations[TokenSecretNodePoolAnnotation] != nodePool || !r.MachineEnrollments.pending(machine, now) {
+			continue
+		}
+		if err := r.reconcileMachineUserData(ctx, machine); err != nil {
+			return fmt.Errorf("failed to reconcile user data of machine %s: %w", machine.Name, err)
+		}
+	}
+	return nil
+}
+
+// MachineUserDataSecret is the user data Secret of a single Machine in token binding mode.
+func MachineUserDataSecret(machine *capiv1.Machine) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: machine.Namespace,
+			Name:      machine.Name + "-user-data",
+		},
+	}
+}
+
+// reconcileMachineUserData renders the user data of a Machine from the NodePool user data,
+// adding the Machine's enrollment token to the ignition request, and points the Machine's
+// bootstrap data at it. Machines are created pointing at the unbound user data Secret, which
+// doesn't exist, so their instance is only launched once it is bound.
+// Machines created before token binding was enabled were launched from the NodePool user data,
+// can't present an enrollment token and are left alone.
+func (r *TokenSecretReconciler) reconcileMachineUserData(ctx context.Context, machine *capiv1.Machine) error {
+	log := ctrl.LoggerFrom(ctx)
+	userDataSecret := MachineUserDataSecret(machine)
+	machineToken := uuid.New().String()
+
+	source := ptr.Deref(machine.Spec.Bootstrap.DataSecretName, "")
+	switch {
+	case source == userDataSecret.Name:
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(userDataSecret), userDataSecret); err != nil {
+			return err
+		}
+		// Keep following the NodePool user data, e.g. across token rotations, with the same token.
+		source = userDataSecret.Annotations[MachineUserDataSourceAnnotation]
+		machineToken = string(userDataSecret.Data[MachineTokenKey])
+	case strings.HasSuffix(source, unboundUserDataSuffix):
+		source = strings.TrimSuffix(source, unboundUserDataSuffix)
+	default:
+		log.Info("Machine was created with unbound user data, its payload requests will be refused", "machine", machine.Name)
+		return nil
+	}
+
+	sourceSecret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: machine.Namespace, Name: source}, sourceSecret); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The NodePool is rolling out new user data, the Machine will be replaced.
+			return nil
+		}
+		return fmt.Errorf("failed to get user data secret %s: %w", source, err)
+	}
+	value, err := bindUserData(sourceSecret.Data["value"], machineToken)
+	if err != nil {
+		return err
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, userDataSecret, func() error {
+		if userDataSecret.Labels == nil {
+			userDataSecret.Labels = map[string]string{}
+		}
+		userDataSecret.Labels[MachineUserDataLabel] = "true"
+		if userDataSecret.Annotations == nil {
+			userDataSecret.Annotations = map[string]string{}
+		}
+		userDataSecret.Annotations[MachineUserDataSourceAnnotation] = source
+		userDataSecret.Data = map[string][]byte{}
+		for k, v := range sourceSecret.Data {
+			userDataSecret.Data[k] = v
+		}
+		userDataSecret.Data["value"] = value
+		userDataSecret.Data[MachineTokenKey] = []byte(machineToken)
+		return controllerutil.SetOwnerReference(machine, userDataSecret, r.Client.Scheme())
+	}); err != nil {
+		return err
+	}
+
+	if ptr.Deref(machine.Spec.Bootstrap.DataSecretName, "") == userDataSecret.Name {
+		return nil
+	}
+	patch := client.MergeFromWithOptions(machine.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	machine.Spec.Bootstrap.DataSecretName = ptr.To(userDataSecret.Name)
+	return r.Client.Patch(ctx, machine, patch)
+}
+
+// bindUserData adds the enrollment token of a Machine to every ignition config
+// merged by the user data which authenticates with a shared NodePool token.
+func bindUserData(value []byte, machineToken string) ([]byte, error) {
+	config := ignitionapi.Config{}
+	if err := json.Unmarshal(value, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ignition config: %w", err)
+	}
+
+	const bearerPrefix = "Bearer "
+	bound := false
+	for i, merge := range config.Ignition.Config.Merge {
+		authenticated := false
+		var headers []ignitionapi.HTTPHeader
+		for _, header := range merge.HTTPHeaders {
+			switch header.Name {
+			case "Authorization":
+				encodedToken := strings.TrimPrefix(ptr.Deref(header.Value, ""), bearerPrefix)
+				if _, err := base64.StdEncoding.DecodeString(encodedToken); err != nil {
+					return nil, fmt.Errorf("invalid token in ignition config: %w", err)
+				}
+				authenticated = encodedToken != ""
+			case MachineTokenHeader:
+				continue
+			}
+			headers = append(headers, header)
+		}
+		if !authenticated {
+			continue
+		}
+		config.Ignition.Config.Merge[i].HTTPHeaders = append(headers, ignitionapi.HTTPHeader{
+			Name:  MachineTokenHeader,
+			Value: ptr.To(machineToken),
+		})
+		bound = true
+	}
+	if !bound {
+		return nil, fmt.Errorf("ignition config has no token to bind")
+	}
+	return json.Marshal(config)
+}
+
+// tokenSecretsForMachine maps a Machine to the token Secrets of its NodePool so
+// the user data of Machines is bound as soon as they are created.
+func (r *TokenSecretReconciler) tokenSecretsForMachine(ctx context.Context, obj client.Object) []reconcile.Request {
+	nodePool := obj.GetAnnotations()[TokenSecretNodePoolAnnotation]
+	if nodePool == "" {
+		return nil
+	}
+	secrets := &corev1.SecretList{}
+	if err := r.Client.List(ctx, secrets, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, secret := range secrets.Items {
+		if _, ok := secret.Annotations[TokenSecretAnnotation]; !ok {
+			continue
+		}
+		if secret.Annotations[TokenSecretNodePoolAnnotation] == nodePool {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&secret)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	ignitionapi "github.com/coreos/ignition/v2/config/v3_2/types"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/hypershift/support/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMachineEnrollments(t *testing.T) {
+	const nodePool = "clusters/np"
+	now := time.Now()
+
+	machine := func(name string, created time.Time, mutate ...func(*capiv1.Machine)) *capiv1.Machine {
+		m := &capiv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "hcp",
+				UID:               types.UID(name + "-uid"),
+				CreationTimestamp: metav1.NewTime(created),
+				Annotations: map[string]string{
+					TokenSecretNodePoolAnnotation: nodePool,
+				},
+			},
+			Spec: capiv1.MachineSpec{
+				Bootstrap: capiv1.Bootstrap{DataSecretName: ptr.To(UnboundUserDataSecretName("user-data"))},
+			},
+		}
+		for _, f := range mutate {
+			f(m)
+		}
+		return m
+	}
+
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "token",
+			Namespace: "hcp",
+			Annotations: map[string]string{
+				TokenSecretAnnotation:         "true",
+				TokenSecretNodePoolAnnotation: nodePool,
+			},
+		},
+		Data: map[string][]byte{
+			TokenSecretTokenKey: []byte("shared-token"),
+		},
+	}
+	userData, err := json.Marshal(ignitionapi.Config{
+		Ignition: ignitionapi.Ignition{
+			Version: "3.2.0",
+			Config: ignitionapi.IgnitionConfig{
+				Merge: []ignitionapi.Resource{{
+					Source: ptr.To("https://ignition/ignition"),
+					HTTPHeaders: []ignitionapi.HTTPHeader{
+						{Name: "Authorization", Value: ptr.To("Bearer " + base64.StdEncoding.EncodeToString([]byte("shared-token")))},
+						{Name: "NodePool", Value: ptr.To(nodePool)},
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal user data: %v", err)
+	}
+	userDataSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "user-data",
+			Namespace: "hcp",
+		},
+		Data: map[string][]byte{
+			"disableTemplating": []byte("dHJ1ZQ=="),
+			"value":             userData,
+		},
+	}
+
+	objects := []client.Object{
+		tokenSecret,
+		userDataSecret,
+		machine("waiting-newer", now.Add(-1*time.Minute)),
+		machine("waiting-older", now.Add(-10*time.Minute)),
+		machine("expired", now.Add(-2*time.Hour)),
+		machine("joined", now.Add(-5*time.Minute), func(m *capiv1.Machine) {
+			m.Status.NodeRef = &corev1.ObjectReference{Name: "node"}
+		}),
+		machine("claimed", now.Add(-5*time.Minute), func(m *capiv1.Machine) {
+			m.Annotations[MachineEnrollmentAnnotation] = now.Format(time.RFC3339)
+		}),
+		machine("other-nodepool", now.Add(-5*time.Minute), func(m *capiv1.Machine) {
+			m.Annotations[TokenSecretNodePoolAnnotation] = "clusters/other"
+		}),
+		machine("launched-before-binding", now.Add(-5*time.Minute), func(m *capiv1.Machine) {
+			m.Spec.Bootstrap.DataSecretName = ptr.To("user-data")
+		}),
+	}
+
+	g := NewWithT(t)
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects...).Build()
+	enrollments := &MachineEnrollments{TTL: time.Hour}
+	r := TokenSecretReconciler{
+		Client:             c,
+		MachineEnrollments: enrollments,
+	}
+
+	g.Expect(r.reconcileMachineEnrollments(ctx, tokenSecret)).To(Succeed())
+
+	// Only pending Machines created with the unbound user data get their own user data.
+	bootstrapDataSecretName := func(name string) string {
+		m := &capiv1.Machine{}
+		g.Expect(c.Get(ctx, types.NamespacedName{Namespace: "hcp", Name: name}, m)).To(Succeed())
+		return ptr.Deref(m.Spec.Bootstrap.DataSecretName, "")
+	}
+	g.Expect(bootstrapDataSecretName("expired")).To(Equal(UnboundUserDataSecretName("user-data")))
+	g.Expect(bootstrapDataSecretName("joined")).To(Equal(UnboundUserDataSecretName("user-data")))
+	g.Expect(bootstrapDataSecretName("other-nodepool")).To(Equal(UnboundUserDataSecretName("user-data")))
+	g.Expect(bootstrapDataSecretName("launched-before-binding")).To(Equal("user-data"))
+
+	// Every pending Machine gets its own user data, carrying a random enrollment token.
+	machineToken := func(name string) string {
+		g.Expect(bootstrapDataSecretName(name)).To(Equal(name + "-user-data"))
+
+		secret := &corev1.Secret{}
+		g.Expect(c.Get(ctx, types.NamespacedName{Namespace: "hcp", Name: name + "-user-data"}, secret)).To(Succeed())
+		g.Expect(secret.Labels[MachineUserDataLabel]).To(Equal("true"))
+		g.Expect(secret.Annotations[MachineUserDataSourceAnnotation]).To(Equal("user-data"))
+		g.Expect(secret.Data["disableTemplating"]).To(Equal(userDataSecret.Data["disableTemplating"]))
+		config := ignitionapi.Config{}
+		g.Expect(json.Unmarshal(secret.Data["value"], &config)).To(Succeed())
+		headers := config.Ignition.Config.Merge[0].HTTPHeaders
+		g.Expect(headers).To(HaveLen(3))
+		g.Expect(headers[2].Name).To(Equal(MachineTokenHeader))
+		g.Expect(*headers[2].Value).To(Equal(string(secret.Data[MachineTokenKey])))
+		return *headers[2].Value
+	}
+	olderToken, newerToken := machineToken("waiting-older"), machineToken("waiting-newer")
+	g.Expect(olderToken).ToNot(BeEmpty())
+	g.Expect(olderToken).ToNot(Equal(newerToken))
+
+	// Rendering the user data again keeps the token without piling up headers.
+	g.Expect(r.reconcileMachineEnrollments(ctx, tokenSecret)).To(Succeed())
+	g.Expect(machineToken("waiting-older")).To(Equal(olderToken))
+
+	// No token, an unknown token, or the token of a Machine of another NodePool is refused.
+	_, err = enrollments.Claim(ctx, c, "hcp", nodePool, "", now)
+	g.Expect(err).To(HaveOccurred())
+	_, err = enrollments.Claim(ctx, c, "hcp", nodePool, "unknown", now)
+	g.Expect(err).To(HaveOccurred())
+	_, err = enrollments.Claim(ctx, c, "hcp", "clusters/other", newerToken, now)
+	g.Expect(err).To(HaveOccurred())
+
+	// Each Machine claims its own enrollment, regardless of the order.
+	claimed, err := enrollments.Claim(ctx, c, "hcp", nodePool, newerToken, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed.Name).To(Equal("waiting-newer"))
+
+	claimedMachine := &capiv1.Machine{}
+	g.Expect(c.Get(ctx, claimed, claimedMachine)).To(Succeed())
+	g.Expect(claimedMachine.Annotations).To(HaveKey(MachineEnrollmentAnnotation))
+
+	// An enrollment can only be claimed once.
+	_, err = enrollments.Claim(ctx, c, "hcp", nodePool, newerToken, now)
+	g.Expect(err).To(HaveOccurred())
+
+	claimed, err = enrollments.Claim(ctx, c, "hcp", nodePool, olderToken, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed.Name).To(Equal("waiting-older"))
+}
+
+func TestMachineEnrollmentExpiry(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+	m := &capiv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "m",
+			Namespace:         "hcp",
+			UID:               "m-uid",
+			CreationTimestamp: metav1.NewTime(now),
+			Annotations:       map[string]string{TokenSecretNodePoolAnnotation: "clusters/np"},
+		},
+	}
+	userDataSecret := MachineUserDataSecret(m)
+	userDataSecret.Labels = map[string]string{MachineUserDataLabel: "true"}
+	userDataSecret.OwnerReferences = []metav1.OwnerReference{{APIVersion: capiv1.GroupVersion.String(), Kind: "Machine", Name: "m", UID: "m-uid"}}
+	userDataSecret.Data = map[string][]byte{MachineTokenKey: []byte("machine-token")}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(m, userDataSecret).Build()
+	enrollments := &MachineEnrollments{TTL: time.Hour}
+
+	_, err := enrollments.Claim(context.Background(), c, "hcp", "clusters/np", "machine-token", now.Add(2*time.Hour))
+	g.Expect(err).To(HaveOccurred())
+	_, err = enrollments.Claim(context.Background(), c, "hcp", "clusters/np", "machine-token", now.Add(time.Minute))
+	g.Expect(err).ToNot(HaveOccurred())
+}
@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	ignitionapi "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/vincent-petithory/dataurl"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	PayloadChangeAdded    = "Added"
+	PayloadChangeRemoved  = "Removed"
+	PayloadChangeModified = "Modified"
+
+	PayloadKindFile   = "file"
+	PayloadKindLink   = "link"
+	PayloadKindUnit   = "unit"
+	PayloadKindDropin = "dropin"
+	PayloadKindUser   = "user"
+
+	redacted = "<redacted>"
+)
+
+// sensitivePayloadFiles are files of an ignition payload which hold credentials.
+// Their contents are never printed, only whether they changed.
+var sensitivePayloadFiles = sets.New[string](
+	// The global pull secret.
+	"/var/lib/kubelet/config.json",
+	// The MCD's copy of the whole MachineConfig, which embeds the pull secret.
+	"/etc/mcs-machine-config-content.json",
+	"/etc/kubernetes/kubeconfig",
+	"/var/lib/kubelet/kubeconfig",
+)
+
+var (
+	// pemBlockRegexp matches certificates and keys embedded in any file or unit.
+	pemBlockRegexp = regexp.MustCompile(`(?s)-----BEGIN ([A-Z0-9 ]+)-----.*?-----END ([A-Z0-9 ]+)-----`)
+	// urlUserinfoRegexp matches the credentials of URLs, e.g. in proxy settings.
+	urlUserinfoRegexp = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s@'"]+@`)
+	// dockerAuthRegexp matches registry credentials outside of the known pull secret files.
+	dockerAuthRegexp = regexp.MustCompile(`("auth"\s*:\s*")[^"]*`)
+)
+
+// redactPayloadContents redacts credentials found in the contents of any file, unit or
+// drop-in, regardless of its path: certificates and keys, URL userinfo and registry auths.
+func redactPayloadContents(contents string) string {
+	contents = pemBlockRegexp.ReplaceAllString(contents, "-----BEGIN $1-----"+redacted+"-----END $2-----")
+	contents = urlUserinfoRegexp.ReplaceAllString(contents, "${1}"+redacted+"@")
+	contents = dockerAuthRegexp.ReplaceAllString(contents, "${1}"+redacted)
+	return contents
+}
+
+// isSensitivePayloadFile returns true for files whose contents must be redacted:
+// pull secrets, kubeconfigs and private keys.
+func isSensitivePayloadFile(filePath string) bool {
+	if sensitivePayloadFiles.Has(filePath) {
+		return true
+	}
+	base := path.Base(filePath)
+	switch {
+	case strings.Contains(base, "kubeconfig"),
+		strings.HasSuffix(base, ".key"),
+		strings.Contains(base, "pull-secret"),
+		strings.Contains(base, "auth.json"),
+		strings.HasPrefix(filePath, "/home/core/.ssh/"):
+		return true
+	}
+	return false
+}
+
+// PayloadChange is a single file, link, systemd unit, unit drop-in or user
+// which differs between two ignition payloads.
+type PayloadChange struct {
+	Kind   string
+	Name   string
+	Action string
+	// Details is a unified diff of the contents for text files and units, or a
+	// short description of what changed otherwise.
+	Details string
+}
+
+// DiffPayloads compares two rendered ignition payloads and returns the
+// file/unit-level changes needed to go from one to the other, sorted by kind and name.
+// Contents of files holding credentials are redacted, as are certificates, keys,
+// URL credentials and registry auths found in any other file or unit.
+func DiffPayloads(from, to []byte) ([]PayloadChange, error) {
+	fromConfig, err := parsePayload(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse previous payload: %w", err)
+	}
+	toConfig, err := parsePayload(to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new payload: %w", err)
+	}
+
+	var changes []PayloadChange
+	fileChanges, err := diffFiles(fromConfig.Storage.Files, toConfig.Storage.Files)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, fileChanges...)
+	changes = append(changes, diffLinks(fromConfig.Storage.Links, toConfig.Storage.Links)...)
+	changes = append(changes, diffUnits(fromConfig.Systemd.Units, toConfig.Systemd.Units)...)
+	changes = append(changes, diffUsers(fromConfig.Passwd.Users, toConfig.Passwd.Users)...)
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// FormatPayloadChanges writes a human readable report of payload changes.
+func FormatPayloadChanges(w io.Writer, changes []PayloadChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+	for _, change := range changes {
+		fmt.Fprintf(w, "%s %s %s\n", change.Action, change.Kind, change.Name)
+		if change.Details != "" {
+			for _, line := range strings.Split(strings.TrimSuffix(change.Details, "\n"), "\n") {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+	}
+}
+
+func parsePayload(payload []byte) (*ignitionapi.Config, error) {
+	config := &ignitionapi.Config{}
+	if err := json.Unmarshal(payload, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func diffFiles(from, to []ignitionapi.File) ([]PayloadChange, error) {
+	fromFiles := make(map[string]ignitionapi.File, len(from))
+	for _, file := range from {
+		fromFiles[file.Path] = file
+	}
+	toFiles := make(map[string]ignitionapi.File, len(to))
+	for _, file := range to {
+		toFiles[file.Path] = file
+	}
+
+	var changes []PayloadChange
+	for name, fromFile := range fromFiles {
+		if _, exists := toFiles[name]; !exists {
+			changes = append(changes, PayloadChange{Kind: PayloadKindFile, Name: name, Action: PayloadChangeRemoved})
+			continue
+		}
+		toFile := toFiles[name]
+		fromContents, err := fileContents(fromFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read previous contents of %s: %w", name, err)
+		}
+		toContents, err := fileContents(toFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read new contents of %s: %w", name, err)
+		}
+
+		var details []string
+		if fromMode, toMode := fileMode(fromFile), fileMode(toFile); fromMode != toMode {
+			details = append(details, fmt.Sprintf("mode: %s -> %s", fromMode, toMode))
+		}
+		if !bytes.Equal(fromContents, toContents) {
+			details = append(details, contentsDiff(name, fromContents, toContents))
+		}
+		if len(details) > 0 {
+			changes = append(changes, PayloadChange{Kind: PayloadKindFile, Name: name, Action: PayloadChangeModified, Details: strings.Join(details, "\n")})
+		}
+	}
+	for name, toFile := range toFiles {
+		if _, exists := fromFiles[name]; exists {
+			continue
+		}
+		toContents, err := fileContents(toFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read new contents of %s: %w", name, err)
+		}
+		changes = append(changes, PayloadChange{Kind: PayloadKindFile, Name: name, Action: PayloadChangeAdded, Details: contentsDiff(name, nil, toContents)})
+	}
+	return changes, nil
+}
+
+func diffLinks(from, to []ignitionapi.Link) []PayloadChange {
+	fromLinks := make(map[string]string, len(from))
+	for _, link := range from {
+		fromLinks[link.Path] = link.Target
+	}
+	toLinks := make(map[string]string, len(to))
+	for _, link := range to {
+		toLinks[link.Path] = link.Target
+	}
+
+	var changes []PayloadChange
+	for name, fromTarget := range fromLinks {
+		toTarget, exists := toLinks[name]
+		switch {
+		case !exists:
+			changes = append(changes, PayloadChange{Kind: PayloadKindLink, Name: name, Action: PayloadChangeRemoved})
+		case fromTarget != toTarget:
+			changes = append(changes, PayloadChange{Kind: PayloadKindLink, Name: name, Action: PayloadChangeModified, Details: fmt.Sprintf("target: %s -> %s", fromTarget, toTarget)})
+		}
+	}
+	for name, toTarget := range toLinks {
+		if _, exists := fromLinks[name]; !exists {
+			changes = append(changes, PayloadChange{Kind: PayloadKindLink, Name: name, Action: PayloadChangeAdded, Details: fmt.Sprintf("target: %s", toTarget)})
+		}
+	}
+	return changes
+}
+
+func diffUnits(from, to []ignitionapi.Unit) []PayloadChange {
+	fromUnits := make(map[string]ignitionapi.Unit, len(from))
+	for _, unit := range from {
+		fromUnits[unit.Name] = unit
+	}
+	toUnits := make(map[string]ignitionapi.Unit, len(to))
+	for _, unit := range to {
+		toUnits[unit.Name] = unit
+	}
+
+	var changes []PayloadChange
+	for name, fromUnit := range fromUnits {
+		toUnit, exists := toUnits[name]
+		if !exists {
+			changes = append(changes, PayloadChange{Kind: PayloadKindUnit, Name: name, Action: PayloadChangeRemoved})
+			continue
+		}
+		var details []string
+		if fromEnabled, toEnabled := boolString(fromUnit.Enabled), boolString(toUnit.Enabled); fromEnabled != toEnabled {
+			details = append(details, fmt.Sprintf("enabled: %s -> %s", fromEnabled, toEnabled))
+		}
+		if fromMask, toMask := boolString(fromUnit.Mask), boolString(toUnit.Mask); fromMask != toMask {
+			details = append(details, fmt.Sprintf("mask: %s -> %s", fromMask, toMask))
+		}
+		if fromContents, toContents := stringValue(fromUnit.Contents), stringValue(toUnit.Contents); fromContents != toContents {
+			details = append(details, redactedDiff(name, fromContents, toContents))
+		}
+		if len(details) > 0 {
+			changes = append(changes, PayloadChange{Kind: PayloadKindUnit, Name: name, Action: PayloadChangeModified, Details: strings.Join(details, "\n")})
+		}
+		changes = append(changes, diffDropins(name, fromUnit.Dropins, toUnit.Dropins)...)
+	}
+	for name, toUnit := range toUnits {
+		if _, exists := fromUnits[name]; exists {
+			continue
+		}
+		changes = append(changes, PayloadChange{Kind: PayloadKindUnit, Name: name, Action: PayloadChangeAdded, Details: redactedDiff(name, "", stringValue(toUnit.Contents))})
+		changes = append(changes, diffDropins(name, nil, toUnit.Dropins)...)
+	}
+	return changes
+}
+
+func diffDropins(unitName string, from, to []ignitionapi.Dropin) []PayloadChange {
+	fromDropins := make(map[string]string, len(from))
+	for _, dropin := range from {
+		fromDropins[dropin.Name] = stringValue(dropin.Contents)
+	}
+	toDropins := make(map[string]string, len(to))
+	for _, dropin := range to {
+		toDropins[dropin.Name] = stringValue(dropin.Contents)
+	}
+
+	var changes []PayloadChange
+	for name, fromContents := range fromDropins {
+		dropinName := unitName + "/" + name
+		toContents, exists := toDropins[name]
+		switch {
+		case !exists:
+			changes = append(changes, PayloadChange{Kind: PayloadKindDropin, Name: dropinName, Action: PayloadChangeRemoved})
+		case fromContents != toContents:
+			changes = append(changes, PayloadChange{Kind: PayloadKindDropin, Name: dropinName, Action: PayloadChangeModified, Details: redactedDiff(dropinName, fromContents, toContents)})
+		}
+	}
+	for name, toContents := range toDropins {
+		if _, exists := fromDropins[name]; !exists {
+			dropinName := unitName + "/" + name
+			changes = append(changes, PayloadChange{Kind: PayloadKindDropin, Name: dropinName, Action: PayloadChangeAdded, Details: redactedDiff(dropinName, "", toContents)})
+		}
+	}
+	return changes
+}
+
+// diffUsers compares users without ever printing their credentials.
+func diffUsers(from, to []ignitionapi.PasswdUser) []PayloadChange {
+	fromUsers := make(map[string]ignitionapi.PasswdUser, len(from))
+	for _, user := range from {
+		fromUsers[user.Name] = user
+	}
+	toUsers := make(map[string]ignitionapi.PasswdUser, len(to))
+	for _, user := range to {
+		toUsers[user.Name] = user
+	}
+
+	var changes []PayloadChange
+	for name, fromUser := range fromUsers {
+		toUser, exists := toUsers[name]
+		if !exists {
+			changes = append(changes, PayloadChange{Kind: PayloadKindUser, Name: name, Action: PayloadChangeRemoved})
+			continue
+		}
+		var details []string
+		if fmt.Sprint(fromUser.SSHAuthorizedKeys) != fmt.Sprint(toUser.SSHAuthorizedKeys) {
+			details = append(details, fmt.Sprintf("sshAuthorizedKeys: %d -> %d keys %s", len(fromUser.SSHAuthorizedKeys), len(toUser.SSHAuthorizedKeys), redacted))
+		}
+		if stringValue(fromUser.PasswordHash) != stringValue(toUser.PasswordHash) {
+			details = append(details, fmt.Sprintf("passwordHash: %s", redacted))
+		}
+		if len(details) > 0 {
+			changes = append(changes, PayloadChange{Kind: PayloadKindUser, Name: name, Action: PayloadChangeModified, Details: strings.Join(details, "\n")})
+		}
+	}
+	for name := range toUsers {
+		if _, exists := fromUsers[name]; !exists {
+			changes = append(changes, PayloadChange{Kind: PayloadKindUser, Name: name, Action: PayloadChangeAdded})
+		}
+	}
+	return changes
+}
+
+// fileContents returns the decoded contents of a file, which are embedded in
+// the payload as an optionally gzipped data URL.
+func fileContents(file ignitionapi.File) ([]byte, error) {
+	source := stringValue(file.Contents.Source)
+	if source == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(source, "data:") {
+		// Remote contents are fetched by ignition on the node, compare their location only.
+		return []byte(source), nil
+	}
+	decoded, err := dataurl.DecodeString(source)
+	if err != nil {
+		return nil, err
+	}
+	if stringValue(file.Contents.Compression) == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(decoded.Data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+	return decoded.Data, nil
+}
+
+func contentsDiff(name string, from, to []byte) string {
+	switch {
+	case isSensitivePayloadFile(name):
+		return fmt.Sprintf("contents: %s", redacted)
+	case !utf8.Valid(from) || !utf8.Valid(to):
+		return fmt.Sprintf("binary contents: %d -> %d bytes", len(from), len(to))
+	}
+	return redactedDiff(name, string(from), string(to))
+}
+
+// redactedDiff returns the unified diff of the contents once redacted. When only
+// redacted values changed, it says so instead of returning an empty diff.
+func redactedDiff(name, from, to string) string {
+	redactedFrom, redactedTo := redactPayloadContents(from), redactPayloadContents(to)
+	if redactedFrom == redactedTo {
+		return fmt.Sprintf("contents: %s", redacted)
+	}
+	return unifiedDiff(name, redactedFrom, redactedTo)
+}
+
+func unifiedDiff(name, from, to string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: "a" + path.Join("/", name),
+		ToFile:   "b" + path.Join("/", name),
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Sprintf("failed to compute diff: %v", err)
+	}
+	return diff
+}
+
+func fileMode(file ignitionapi.File) string {
+	if file.Mode == nil {
+		return "default"
+	}
+	return fmt.Sprintf("%#o", *file.Mode)
+}
+
+func boolString(b *bool) string {
+	if b == nil {
+		return "default"
+	}
+	return fmt.Sprint(*b)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -15,9 +15,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -100,6 +103,10 @@ type TokenSecretReconciler struct {
 	client.Client
 	IgnitionProvider IgnitionProvider
 	PayloadStore     *ExpiringCache
+	// MachineEnrollments enables token binding when set: the user data of every
+	// Machine waiting for its payload is bound to an enrollment of its own and
+	// payloads are only served against them.
+	MachineEnrollments *MachineEnrollments
 }
 
 func tokenSecretAnnotationPredicate(ctx context.Context) predicate.Predicate {
@@ -121,9 +128,12 @@ func tokenSecretAnnotationPredicate(ctx context.Context) predicate.Predicate {
 func (r *TokenSecretReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	log := ctrl.Log.WithName("secret-token-controller")
 	log.Info("SetupWithManager", "ns", os.Getenv("MY_NAMESPACE"))
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Secret{}).WithEventFilter(tokenSecretAnnotationPredicate(ctx)).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Secret{}, builder.WithPredicates(tokenSecretAnnotationPredicate(ctx)))
+	if r.MachineEnrollments != nil {
+		b = b.Watches(&capiv1.Machine{}, handler.EnqueueRequestsFromMapFunc(r.tokenSecretsForMachine))
+	}
+	return b.Complete(r)
 }
 
 func processIfMatchesAnnotation(logger logr.Logger, obj client.Object) bool {
@@ -213,6 +223,12 @@ func (r *TokenSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	if r.MachineEnrollments != nil {
+		if err := r.reconcileMachineEnrollments(ctx, tokenSecret); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Otherwise proceed to generate the payload and cache the content.
 	// Rotate the Token if necessary.
 	now := time.Now()
@@ -26,11 +26,15 @@ import (
 	"github.com/spf13/cobra"
 	"go.uber.org/zap/zapcore"
 
+	clustercmd "github.com/openshift/hypershift/cmd/cluster"
 	"github.com/openshift/hypershift/cmd/consolelogs"
 	createcmd "github.com/openshift/hypershift/cmd/create"
 	destroycmd "github.com/openshift/hypershift/cmd/destroy"
 	dumpcmd "github.com/openshift/hypershift/cmd/dump"
+	infracmd "github.com/openshift/hypershift/cmd/infra"
 	installcmd "github.com/openshift/hypershift/cmd/install"
+	nodepoolcmd "github.com/openshift/hypershift/cmd/nodepool"
+	tunnelcmd "github.com/openshift/hypershift/cmd/tunnel"
 	cliversion "github.com/openshift/hypershift/cmd/version"
 	"github.com/openshift/hypershift/pkg/version"
 
@@ -65,6 +69,10 @@ func main() {
 	cmd.AddCommand(destroycmd.NewCommand())
 	cmd.AddCommand(dumpcmd.NewCommand())
 	cmd.AddCommand(consolelogs.NewCommand())
+	cmd.AddCommand(clustercmd.NewCommand())
+	cmd.AddCommand(infracmd.NewCommand())
+	cmd.AddCommand(nodepoolcmd.NewCommand())
+	cmd.AddCommand(tunnelcmd.NewCommand())
 	cmd.AddCommand(cliversion.NewVersionCommand())
 
 	sigs := make(chan os.Signal, 1)
@@ -24,6 +24,7 @@ func NewCreateCommands() *cobra.Command {
 	}
 
 	core.BindOptions(opts, cmd.PersistentFlags())
+	core.BindFromFileOption(cmd)
 
 	cmd.MarkFlagsMutuallyExclusive("service-cidr", "default-dual")
 	cmd.MarkFlagsMutuallyExclusive("cluster-cidr", "default-dual")
@@ -43,7 +43,7 @@ type DeploymentConfig struct {
 	ReadinessProbes           ReadinessProbes
 	Resources                 ResourcesSpec
 	DebugDeployments          sets.String
-	ResourceRequestOverrides  ResourceOverrides
+	ComponentOverrides        ComponentOverrides
 	IsolateAsRequestServing   bool
 	RevisionHistoryLimit      int
 
@@ -76,16 +76,17 @@ func (c *DeploymentConfig) SetReleaseImageAnnotation(releaseImage string) {
 }
 
 func (c *DeploymentConfig) ApplyTo(deployment *appsv1.Deployment) {
+	replicas := c.ComponentOverrides.Replicas(deployment.Name, c.Replicas)
 	if c.DebugDeployments != nil && c.DebugDeployments.Has(deployment.Name) {
 		deployment.Spec.Replicas = pointer.Int32(0)
 	} else {
-		deployment.Spec.Replicas = pointer.Int32(int32(c.Replicas))
+		deployment.Spec.Replicas = pointer.Int32(int32(replicas))
 	}
 	// there are two standard cases currently with hypershift: HA mode where there are 3 replicas spread across
 	// zones and then non ha with one replica. When only 3 zones are available you need to be able to set maxUnavailable
 	// in order to progress the rollout. However, you do not want to set that in the single replica case because it will
 	// result in downtime.
-	if c.Replicas > 1 {
+	if replicas > 1 {
 		maxSurge := intstr.FromInt(0)
 		maxUnavailable := intstr.FromInt(1)
 		if deployment.Spec.Strategy.RollingUpdate == nil {
@@ -136,7 +137,7 @@ func (c *DeploymentConfig) ApplyTo(deployment *appsv1.Deployment) {
 	c.LivenessProbes.ApplyTo(&deployment.Spec.Template.Spec)
 	c.ReadinessProbes.ApplyTo(&deployment.Spec.Template.Spec)
 	c.Resources.ApplyTo(&deployment.Spec.Template.Spec)
-	c.ResourceRequestOverrides.ApplyRequestsTo(deployment.Name, &deployment.Spec.Template.Spec)
+	c.ComponentOverrides.ApplyTo(deployment.Name, &deployment.Spec.Template)
 	c.AdditionalAnnotations.ApplyTo(&deployment.Spec.Template.ObjectMeta)
 }
 
@@ -148,19 +149,19 @@ func (c *DeploymentConfig) ApplyToDaemonSet(daemonset *appsv1.DaemonSet) {
 	c.LivenessProbes.ApplyTo(&daemonset.Spec.Template.Spec)
 	c.ReadinessProbes.ApplyTo(&daemonset.Spec.Template.Spec)
 	c.Resources.ApplyTo(&daemonset.Spec.Template.Spec)
-	c.ResourceRequestOverrides.ApplyRequestsTo(daemonset.Name, &daemonset.Spec.Template.Spec)
+	c.ComponentOverrides.ApplyTo(daemonset.Name, &daemonset.Spec.Template)
 	c.AdditionalAnnotations.ApplyTo(&daemonset.Spec.Template.ObjectMeta)
 }
 
 func (c *DeploymentConfig) ApplyToStatefulSet(sts *appsv1.StatefulSet) {
-	sts.Spec.Replicas = pointer.Int32(int32(c.Replicas))
+	sts.Spec.Replicas = pointer.Int32(int32(c.ComponentOverrides.Replicas(sts.Name, c.Replicas)))
 	c.Scheduling.ApplyTo(&sts.Spec.Template.Spec)
 	c.AdditionalLabels.ApplyTo(&sts.Spec.Template.ObjectMeta)
 	c.SecurityContexts.ApplyTo(&sts.Spec.Template.Spec)
 	c.LivenessProbes.ApplyTo(&sts.Spec.Template.Spec)
 	c.ReadinessProbes.ApplyTo(&sts.Spec.Template.Spec)
 	c.Resources.ApplyTo(&sts.Spec.Template.Spec)
-	c.ResourceRequestOverrides.ApplyRequestsTo(sts.Name, &sts.Spec.Template.Spec)
+	c.ComponentOverrides.ApplyTo(sts.Name, &sts.Spec.Template)
 	c.AdditionalAnnotations.ApplyTo(&sts.Spec.Template.ObjectMeta)
 }
 
@@ -402,7 +403,7 @@ func (c *DeploymentConfig) SetDefaults(hcp *hyperv1.HostedControlPlane, multiZon
 		c.Replicas = *replicas
 	}
 	c.DebugDeployments = debugDeployments(hcp)
-	c.ResourceRequestOverrides = resourceRequestOverrides(hcp)
+	c.ComponentOverrides = componentOverrides(hcp)
 	c.RevisionHistoryLimit = 2
 
 	c.setLocation(hcp, multiZoneSpreadLabels)
@@ -0,0 +1,368 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+)
+
+// appliedComponentOverridesAnnotation records the changes ApplyTo made to the containers of a pod template by
+// container name, so they can be undone once they are not overridden anymore.
+const appliedComponentOverridesAnnotation = "hypershift.openshift.io/applied-component-overrides"
+
+// extraArgsAllowedByComponent are the flags which can be appended to the containers of a component with ExtraArgs.
+// They only tune the performance and the verbosity of the components, flags which change their behavior or
+// security are not allowed.
+var extraArgsAllowedByComponent = map[string]sets.Set[string]{
+	"etcd": sets.New(
+		"--quota-backend-bytes", "--snapshot-count", "--heartbeat-interval", "--election-timeout", "--log-level",
+	),
+	"kube-apiserver": sets.New(
+		"--max-requests-inflight", "--max-mutating-requests-inflight", "--goaway-chance", "--default-watch-cache-size",
+		"--watch-cache-sizes", "--event-ttl", "--min-request-timeout", "--request-timeout", "--delete-collection-workers", "--v",
+	),
+	"kube-controller-manager": sets.New(
+		"--kube-api-qps", "--kube-api-burst", "--concurrent-deployment-syncs", "--concurrent-replicaset-syncs",
+		"--concurrent-gc-syncs", "--concurrent-namespace-syncs", "--concurrent-service-endpoint-syncs",
+		"--concurrent-statefulset-syncs", "--v",
+	),
+	"kube-scheduler": sets.New(
+		"--kube-api-qps", "--kube-api-burst", "--v",
+	),
+	"openshift-apiserver": sets.New(
+		"--max-requests-inflight", "--max-mutating-requests-inflight", "--v",
+	),
+	"openshift-oauth-apiserver": sets.New(
+		"--max-requests-inflight", "--max-mutating-requests-inflight", "--v",
+	),
+}
+
+// ExtraArgAllowed returns true when the flag of a --flag=value arg can be appended to the containers of the component.
+func ExtraArgAllowed(component, arg string) bool {
+	flag, _, _ := strings.Cut(arg, "=")
+	return extraArgsAllowedByComponent[component].Has(flag)
+}
+
+// ComponentOverrides are the ControlPlaneComponentOverrides of a HostedControlPlane by component name.
+type ComponentOverrides map[string]hyperv1.ControlPlaneComponentOverride
+
+// componentOverrides returns the ControlPlaneComponentOverrides of the HostedControlPlane merged over the overrides
+// of the ResourceRequestOverrideAnnotationPrefix, KubeAPIServerGOGCAnnotation and KubeAPIServerGOMemoryLimitAnnotation
+// annotations which predate them.
+func componentOverrides(hcp *hyperv1.HostedControlPlane) ComponentOverrides {
+	result := ComponentOverrides{}
+	for component, containers := range resourceRequestOverrides(hcp) {
+		for container, requirements := range containers {
+			result.mergeContainer(component, hyperv1.ControlPlaneContainerOverride{
+				Name:      container,
+				Resources: &hyperv1.ControlPlaneContainerResources{Requests: requirements.Requests},
+			})
+		}
+	}
+	goRuntime := hyperv1.GoRuntimeOverride{
+		GOGC:          hcp.Annotations[hyperv1.KubeAPIServerGOGCAnnotation],
+		GOMemoryLimit: hcp.Annotations[hyperv1.KubeAPIServerGOMemoryLimitAnnotation],
+	}
+	if goRuntime != (hyperv1.GoRuntimeOverride{}) {
+		result.mergeContainer("kube-apiserver", hyperv1.ControlPlaneContainerOverride{Name: "kube-apiserver", GoRuntime: &goRuntime})
+	}
+
+	for _, override := range hcp.Spec.ControlPlaneComponentOverrides {
+		name := string(override.Name)
+		merged := result[name]
+		merged.Name = override.Name
+		if override.Replicas != nil {
+			merged.Replicas = ptr.To(*override.Replicas)
+		}
+		if override.PriorityClassName != "" {
+			merged.PriorityClassName = override.PriorityClassName
+		}
+		result[name] = merged
+		for _, container := range override.Containers {
+			result.mergeContainer(name, container)
+		}
+	}
+	return result
+}
+
+// mergeContainer merges the container override over the override of the same container of the component.
+func (o ComponentOverrides) mergeContainer(component string, container hyperv1.ControlPlaneContainerOverride) {
+	override := o[component]
+	override.Name = hyperv1.ControlPlaneComponentName(component)
+	for i := range override.Containers {
+		if override.Containers[i].Name == container.Name {
+			override.Containers[i] = mergeContainerOverride(override.Containers[i], container)
+			o[component] = override
+			return
+		}
+	}
+	override.Containers = append(override.Containers, *container.DeepCopy())
+	o[component] = override
+}
+
+func mergeContainerOverride(base, override hyperv1.ControlPlaneContainerOverride) hyperv1.ControlPlaneContainerOverride {
+	merged := *base.DeepCopy()
+	if override.Resources != nil {
+		if merged.Resources == nil {
+			merged.Resources = &hyperv1.ControlPlaneContainerResources{}
+		}
+		merged.Resources.Requests = mergeResourceLists(merged.Resources.Requests, override.Resources.Requests)
+		merged.Resources.Limits = mergeResourceLists(merged.Resources.Limits, override.Resources.Limits)
+	}
+	if override.GoRuntime != nil {
+		if merged.GoRuntime == nil {
+			merged.GoRuntime = &hyperv1.GoRuntimeOverride{}
+		}
+		if override.GoRuntime.GOGC != "" {
+			merged.GoRuntime.GOGC = override.GoRuntime.GOGC
+		}
+		if override.GoRuntime.GOMemoryLimit != "" {
+			merged.GoRuntime.GOMemoryLimit = override.GoRuntime.GOMemoryLimit
+		}
+	}
+	merged.ExtraArgs = append(merged.ExtraArgs, override.ExtraArgs...)
+	return merged
+}
+
+func mergeResourceLists(base, override corev1.ResourceList) corev1.ResourceList {
+	if len(override) == 0 {
+		return base
+	}
+	merged := base.DeepCopy()
+	if merged == nil {
+		merged = corev1.ResourceList{}
+	}
+	for name, value := range override {
+		merged[name] = value
+	}
+	return merged
+}
+
+// Replicas returns the replicas of the named component, taking the override into account.
+func (o ComponentOverrides) Replicas(name string, replicas int) int {
+	if override, exists := o[name]; exists && override.Replicas != nil {
+		return int(*override.Replicas)
+	}
+	return replicas
+}
+
+// appliedContainerOverride records the changes an override made to a container.
+type appliedContainerOverride struct {
+	Args     []string                             `json:"args,omitempty"`
+	Env      map[string]appliedValue              `json:"env,omitempty"`
+	Requests map[corev1.ResourceName]appliedValue `json:"requests,omitempty"`
+	Limits   map[corev1.ResourceName]appliedValue `json:"limits,omitempty"`
+}
+
+func (a appliedContainerOverride) isEmpty() bool {
+	return len(a.Args) == 0 && len(a.Env) == 0 && len(a.Requests) == 0 && len(a.Limits) == 0
+}
+
+// appliedValue is a value set by an override.
+type appliedValue struct {
+	// From is the value before the override, nil when it was not set.
+	From *string `json:"from,omitempty"`
+	// To is the value set by the override.
+	To string `json:"to"`
+}
+
+// ApplyTo applies the override of the named component to its pod template. The changes of a previous ApplyTo
+// are undone first, as the pod template may be kept from a previous reconciliation.
+func (o ComponentOverrides) ApplyTo(name string, template *corev1.PodTemplateSpec) {
+	undoAppliedOverrides(template)
+	override, exists := o[name]
+	if !exists {
+		return
+	}
+	podSpec := &template.Spec
+	if override.PriorityClassName != "" {
+		podSpec.PriorityClassName = override.PriorityClassName
+	}
+	applied := map[string]appliedContainerOverride{}
+	for _, containerOverride := range override.Containers {
+		for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+			for i := range containers {
+				if containers[i].Name == containerOverride.Name {
+					applied[containerOverride.Name] = applyContainerOverride(name, &containers[i], containerOverride)
+				}
+			}
+		}
+	}
+	recordAppliedOverrides(template, applied)
+}
+
+// undoAppliedOverrides undoes the changes recorded by a previous ApplyTo. Values which have changed since they
+// were overridden are kept, as they were set by the component itself.
+func undoAppliedOverrides(template *corev1.PodTemplateSpec) {
+	data, exists := template.Annotations[appliedComponentOverridesAnnotation]
+	if !exists {
+		return
+	}
+	delete(template.Annotations, appliedComponentOverridesAnnotation)
+	applied := map[string]appliedContainerOverride{}
+	if err := json.Unmarshal([]byte(data), &applied); err != nil {
+		return
+	}
+	for _, containers := range [][]corev1.Container{template.Spec.InitContainers, template.Spec.Containers} {
+		for i := range containers {
+			containerApplied, exists := applied[containers[i].Name]
+			if !exists {
+				continue
+			}
+			container := &containers[i]
+			for _, arg := range containerApplied.Args {
+				container.Args = removeLastString(container.Args, arg)
+			}
+			for name, value := range containerApplied.Env {
+				restoreEnvVar(container, name, value)
+			}
+			container.Resources.Requests = restoreResources(container.Resources.Requests, containerApplied.Requests)
+			container.Resources.Limits = restoreResources(container.Resources.Limits, containerApplied.Limits)
+		}
+	}
+}
+
+func recordAppliedOverrides(template *corev1.PodTemplateSpec, applied map[string]appliedContainerOverride) {
+	for name, containerApplied := range applied {
+		if containerApplied.isEmpty() {
+			delete(applied, name)
+		}
+	}
+	if len(applied) == 0 {
+		return
+	}
+	data, err := json.Marshal(applied)
+	if err != nil {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[appliedComponentOverridesAnnotation] = string(data)
+}
+
+// applyContainerOverride applies the override to the container of the component and returns the changes it made.
+func applyContainerOverride(component string, container *corev1.Container, override hyperv1.ControlPlaneContainerOverride) appliedContainerOverride {
+	applied := appliedContainerOverride{}
+	if override.Resources != nil {
+		container.Resources.Requests, applied.Requests = overrideResources(container.Resources.Requests, override.Resources.Requests)
+		container.Resources.Limits, applied.Limits = overrideResources(container.Resources.Limits, override.Resources.Limits)
+	}
+	if override.GoRuntime != nil {
+		applied.Env = map[string]appliedValue{}
+		if override.GoRuntime.GOGC != "" {
+			applied.Env["GOGC"] = setEnvVar(container, "GOGC", override.GoRuntime.GOGC)
+		}
+		if override.GoRuntime.GOMemoryLimit != "" {
+			applied.Env["GOMEMLIMIT"] = setEnvVar(container, "GOMEMLIMIT", override.GoRuntime.GOMemoryLimit)
+		}
+	}
+	// Args which are not allowed for the component are rejected by the HostedCluster validation and never applied.
+	// Args the container already has are not appended again, nor recorded as applied.
+	for _, arg := range override.ExtraArgs {
+		if ExtraArgAllowed(component, arg) && !containsString(container.Args, arg) {
+			container.Args = append(container.Args, arg)
+			applied.Args = append(applied.Args, arg)
+		}
+	}
+	return applied
+}
+
+// overrideResources returns a copy of the resource list with the overrides set, so resource lists shared with the
+// defaults of the component are not changed.
+func overrideResources(list, overrides corev1.ResourceList) (corev1.ResourceList, map[corev1.ResourceName]appliedValue) {
+	if len(overrides) == 0 {
+		return list, nil
+	}
+	result := list.DeepCopy()
+	if result == nil {
+		result = corev1.ResourceList{}
+	}
+	applied := map[corev1.ResourceName]appliedValue{}
+	for name, value := range overrides {
+		var from *string
+		if current, exists := result[name]; exists {
+			from = ptr.To(current.String())
+		}
+		result[name] = value
+		applied[name] = appliedValue{From: from, To: value.String()}
+	}
+	return result, applied
+}
+
+func restoreResources(list corev1.ResourceList, applied map[corev1.ResourceName]appliedValue) corev1.ResourceList {
+	if len(applied) == 0 {
+		return list
+	}
+	result := list.DeepCopy()
+	for name, value := range applied {
+		current, exists := result[name]
+		if !exists || current.String() != value.To {
+			continue
+		}
+		if value.From == nil {
+			delete(result, name)
+			continue
+		}
+		if quantity, err := resource.ParseQuantity(*value.From); err == nil {
+			result[name] = quantity
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func setEnvVar(container *corev1.Container, name, value string) appliedValue {
+	for i := range container.Env {
+		if container.Env[i].Name == name {
+			applied := appliedValue{To: value}
+			if container.Env[i].ValueFrom == nil {
+				applied.From = ptr.To(container.Env[i].Value)
+			}
+			container.Env[i] = corev1.EnvVar{Name: name, Value: value}
+			return applied
+		}
+	}
+	container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: value})
+	return appliedValue{To: value}
+}
+
+func restoreEnvVar(container *corev1.Container, name string, value appliedValue) {
+	for i := range container.Env {
+		if container.Env[i].Name != name || container.Env[i].ValueFrom != nil || container.Env[i].Value != value.To {
+			continue
+		}
+		if value.From == nil {
+			container.Env = append(container.Env[:i:i], container.Env[i+1:]...)
+			return
+		}
+		container.Env[i].Value = *value.From
+		return
+	}
+}
+
+// removeLastString removes the last occurrence of value, which is where ExtraArgs are appended.
+func removeLastString(values []string, value string) []string {
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i] == value {
+			return append(values[:i:i], values[i+1:]...)
+		}
+	}
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
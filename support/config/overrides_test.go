@@ -0,0 +1,236 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestApplyComponentOverrides(t *testing.T) {
+	deployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "kube-apiserver",
+								Args: []string{"--v=2"},
+								Env:  []corev1.EnvVar{{Name: "GOGC", Value: "100"}},
+							},
+							{Name: "audit-logs"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		overrides []hyperv1.ControlPlaneComponentOverride
+		expected  func(d *appsv1.Deployment)
+	}{
+		{
+			name: "When there is no override for the component it should keep the defaults",
+			overrides: []hyperv1.ControlPlaneComponentOverride{
+				{Name: "etcd", PriorityClassName: "custom"},
+			},
+			expected: func(d *appsv1.Deployment) {},
+		},
+		{
+			name: "When the component is overridden it should override replicas, priority class, resources, Go runtime and args",
+			overrides: []hyperv1.ControlPlaneComponentOverride{
+				{
+					Name:              "kube-apiserver",
+					Replicas:          ptr.To[int32](2),
+					PriorityClassName: "custom",
+					Containers: []hyperv1.ControlPlaneContainerOverride{
+						{
+							Name: "kube-apiserver",
+							Resources: &hyperv1.ControlPlaneContainerResources{
+								Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+								Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+							},
+							GoRuntime: &hyperv1.GoRuntimeOverride{GOGC: "50", GOMemoryLimit: "6GiB"},
+							ExtraArgs: []string{"--v=2", "--max-requests-inflight=5000", "--anonymous-auth=true"},
+						},
+					},
+				},
+			},
+			expected: func(d *appsv1.Deployment) {
+				d.Spec.Replicas = ptr.To[int32](2)
+				d.Spec.Template.Spec.PriorityClassName = "custom"
+				container := &d.Spec.Template.Spec.Containers[0]
+				container.Resources = corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi"), corev1.ResourceCPU: resource.MustParse("2")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+				}
+				container.Env = []corev1.EnvVar{{Name: "GOGC", Value: "50"}, {Name: "GOMEMLIMIT", Value: "6GiB"}}
+				container.Args = []string{"--v=2", "--max-requests-inflight=5000"}
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			hcp := &hyperv1.HostedControlPlane{
+				Spec: hyperv1.HostedControlPlaneSpec{ControlPlaneComponentOverrides: tc.overrides},
+			}
+			cfg := &DeploymentConfig{
+				Resources: ResourcesSpec{
+					"kube-apiserver": {Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}},
+				},
+			}
+			cfg.SetDefaults(hcp, nil, nil)
+
+			actual := deployment()
+			cfg.ApplyTo(actual)
+			// Applying the config again must not change the result
+			cfg.ApplyTo(actual)
+
+			expected := deployment()
+			expected.Spec.Replicas = ptr.To[int32](1)
+			expected.Spec.Template.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+			tc.expected(expected)
+			g.Expect(actual.Spec.Replicas).To(Equal(expected.Spec.Replicas))
+			g.Expect(actual.Spec.Template.Spec.PriorityClassName).To(Equal(expected.Spec.Template.Spec.PriorityClassName))
+			g.Expect(actual.Spec.Template.Spec.Containers).To(Equal(expected.Spec.Template.Spec.Containers))
+		})
+	}
+}
+
+func TestApplyComponentOverridesUndoesRemovedOverrides(t *testing.T) {
+	g := NewWithT(t)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "kube-apiserver",
+						Args: []string{"--v=2"},
+						Env:  []corev1.EnvVar{{Name: "GOGC", Value: "100"}},
+					}},
+				},
+			},
+		},
+	}
+	apply := func(container *hyperv1.ControlPlaneContainerOverride) {
+		hcp := &hyperv1.HostedControlPlane{}
+		if container != nil {
+			container.Name = "kube-apiserver"
+			hcp.Spec.ControlPlaneComponentOverrides = []hyperv1.ControlPlaneComponentOverride{{
+				Name:       "kube-apiserver",
+				Containers: []hyperv1.ControlPlaneContainerOverride{*container},
+			}}
+		}
+		// The component preserves the resources of the existing container, as the kube-controller-manager does.
+		cfg := &DeploymentConfig{Resources: ResourcesSpec{}}
+		cfg.SetContainerResourcesIfPresent(&deployment.Spec.Template.Spec.Containers[0])
+		cfg.SetDefaults(hcp, nil, nil)
+		cfg.ApplyTo(deployment)
+	}
+	container := func() corev1.Container {
+		return deployment.Spec.Template.Spec.Containers[0]
+	}
+
+	apply(&hyperv1.ControlPlaneContainerOverride{
+		Resources: &hyperv1.ControlPlaneContainerResources{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+		},
+		GoRuntime: &hyperv1.GoRuntimeOverride{GOGC: "50", GOMemoryLimit: "6GiB"},
+		ExtraArgs: []string{"--v=2", "--max-requests-inflight=5000", "--goaway-chance=0.001"},
+	})
+	g.Expect(container().Args).To(Equal([]string{"--v=2", "--max-requests-inflight=5000", "--goaway-chance=0.001"}))
+	g.Expect(container().Env).To(Equal([]corev1.EnvVar{{Name: "GOGC", Value: "50"}, {Name: "GOMEMLIMIT", Value: "6GiB"}}))
+	g.Expect(container().Resources.Limits).To(HaveKey(corev1.ResourceMemory))
+
+	apply(&hyperv1.ControlPlaneContainerOverride{
+		GoRuntime: &hyperv1.GoRuntimeOverride{GOMemoryLimit: "4GiB"},
+		ExtraArgs: []string{"--max-requests-inflight=3000"},
+	})
+	g.Expect(container().Args).To(Equal([]string{"--v=2", "--max-requests-inflight=3000"}))
+	g.Expect(container().Env).To(Equal([]corev1.EnvVar{{Name: "GOGC", Value: "100"}, {Name: "GOMEMLIMIT", Value: "4GiB"}}))
+	g.Expect(container().Resources.Limits).To(BeEmpty())
+
+	apply(nil)
+	g.Expect(container().Args).To(Equal([]string{"--v=2"}))
+	g.Expect(container().Env).To(Equal([]corev1.EnvVar{{Name: "GOGC", Value: "100"}}))
+	g.Expect(deployment.Spec.Template.Annotations).ToNot(HaveKey(appliedComponentOverridesAnnotation))
+}
+
+func TestComponentOverridesFromAnnotations(t *testing.T) {
+	g := NewWithT(t)
+	hcp := &hyperv1.HostedControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				hyperv1.ResourceRequestOverrideAnnotationPrefix + "/kube-apiserver.kube-apiserver": "memory=2Gi,cpu=1",
+				hyperv1.KubeAPIServerGOGCAnnotation:                                                "75",
+				hyperv1.KubeAPIServerGOMemoryLimitAnnotation:                                       "6GiB",
+			},
+		},
+		Spec: hyperv1.HostedControlPlaneSpec{
+			ControlPlaneComponentOverrides: []hyperv1.ControlPlaneComponentOverride{{
+				Name: "kube-apiserver",
+				Containers: []hyperv1.ControlPlaneContainerOverride{{
+					Name:      "kube-apiserver",
+					Resources: &hyperv1.ControlPlaneContainerResources{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+					GoRuntime: &hyperv1.GoRuntimeOverride{GOGC: "50"},
+				}},
+			}},
+		},
+	}
+
+	// The typed overrides take precedence over the annotations.
+	overrides := componentOverrides(hcp)
+	g.Expect(overrides["kube-apiserver"].Containers).To(Equal([]hyperv1.ControlPlaneContainerOverride{{
+		Name: "kube-apiserver",
+		Resources: &hyperv1.ControlPlaneContainerResources{Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+			corev1.ResourceCPU:    resource.MustParse("2"),
+		}},
+		GoRuntime: &hyperv1.GoRuntimeOverride{GOGC: "50", GOMemoryLimit: "6GiB"},
+	}}))
+}
+
+func TestExtraArgAllowed(t *testing.T) {
+	testCases := []struct {
+		name      string
+		component string
+		arg       string
+		expected  bool
+	}{
+		{
+			name:      "When the flag tunes the component it should be allowed",
+			component: "kube-apiserver",
+			arg:       "--max-requests-inflight=5000",
+			expected:  true,
+		},
+		{
+			name:      "When the flag changes the security of the component it should not be allowed",
+			component: "kube-apiserver",
+			arg:       "--anonymous-auth=true",
+			expected:  false,
+		},
+		{
+			name:      "When the flag is allowed for another component it should not be allowed",
+			component: "oauth-openshift",
+			arg:       "--max-requests-inflight=5000",
+			expected:  false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(ExtraArgAllowed(tc.component, tc.arg)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -19,27 +19,6 @@ func (s ResourcesSpec) ApplyTo(podSpec *corev1.PodSpec) {
 	}
 }
 
-func (s ResourcesSpec) ApplyRequestsOverrideTo(podSpec *corev1.PodSpec) {
-	for i, c := range podSpec.InitContainers {
-		if res, ok := s[c.Name]; ok {
-			for name, value := range res.Requests {
-				podSpec.InitContainers[i].Resources.Requests[name] = value
-			}
-		}
-	}
-	for i, c := range podSpec.Containers {
-		if res, ok := s[c.Name]; ok {
-			for name, value := range res.Requests {
-				podSpec.Containers[i].Resources.Requests[name] = value
-			}
-		}
-	}
-}
-
+// ResourceOverrides are the resource requests of the ResourceRequestOverrideAnnotationPrefix annotations by
+// component name, they are applied as ComponentOverrides.
 type ResourceOverrides map[string]ResourcesSpec
-
-func (o ResourceOverrides) ApplyRequestsTo(name string, podSpec *corev1.PodSpec) {
-	if spec, exists := o[name]; exists {
-		spec.ApplyRequestsOverrideTo(podSpec)
-	}
-}
@@ -2,10 +2,12 @@ package config
 
 import (
 	"bytes"
-
+	"fmt"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -288,9 +290,27 @@ func TestApplyResourceRequestOverrides(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			g := NewWithT(t)
-			input := test.input
-			test.overrides.ApplyRequestsTo(deploymentName, &input)
-			g.Expect(input).To(Equal(test.expected))
+			// The annotations are applied as component overrides.
+			hcp := &hyperv1.HostedControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Annotations: resourceRequestOverrideAnnotations(test.overrides)},
+			}
+			template := corev1.PodTemplateSpec{Spec: test.input}
+			componentOverrides(hcp).ApplyTo(deploymentName, &template)
+			g.Expect(template.Spec).To(Equal(test.expected))
 		})
 	}
 }
+
+func resourceRequestOverrideAnnotations(overrides ResourceOverrides) map[string]string {
+	annotations := map[string]string{}
+	for deployment, containers := range overrides {
+		for container, requirements := range containers {
+			var requests []string
+			for name, quantity := range requirements.Requests {
+				requests = append(requests, fmt.Sprintf("%s=%s", name, quantity.String()))
+			}
+			annotations[fmt.Sprintf("%s/%s.%s", hyperv1.ResourceRequestOverrideAnnotationPrefix, deployment, container)] = strings.Join(requests, ",")
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,507 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+const (
+	// elbDescribeTagsLimit is the maximum number of load balancers whose tags can be described at once.
+	elbDescribeTagsLimit = 20
+)
+
+// oidcDocumentPaths are the paths, relative to the infra ID, of the OIDC documents the HyperShift operator
+// publishes in the OIDC bucket for AWS clusters.
+var oidcDocumentPaths = []string{"/.well-known/openid-configuration", "/openid/v1/jwks"}
+
+type AuditInfraOptions struct {
+	AWSCredentialsOpts  awsutil.AWSCredentialsOptions
+	Region              string
+	InfraIDs            []string
+	ExcludeInfraIDs     []string
+	OIDCBucketName      string
+	Delete              bool
+	AwsInfraGracePeriod time.Duration
+	Log                 logr.Logger
+}
+
+// OrphanedResource is a cloud resource of an infra ID for which there is no HostedCluster.
+type OrphanedResource struct {
+	InfraID string
+	Kind    string
+	ID      string
+
+	// publicZoneID and recordName locate the wildcard ingress record, which is not tagged with the infra ID
+	// and cannot be found by destroying the infrastructure of the infra ID.
+	publicZoneID string
+	recordName   string
+}
+
+const wildcardRecordKind = "Route53 wildcard record"
+
+func (o *AuditInfraOptions) Validate() error {
+	if err := o.AWSCredentialsOpts.Validate(); err != nil {
+		return err
+	}
+	// the tags of clusters which are not managed by this management cluster cannot be told apart from the tags
+	// of orphaned infra IDs, so only infra IDs which were reviewed are destroyed
+	if o.Delete && len(o.InfraIDs) == 0 {
+		return fmt.Errorf("--delete requires the infra IDs to destroy to be listed with --infra-ids")
+	}
+	return nil
+}
+
+func (o *AuditInfraOptions) Run(ctx context.Context, out io.Writer) error {
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+	inUse, err := InUseInfraIDs(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	awsSession, err := o.AWSCredentialsOpts.GetSession("cli-audit-infra", nil, o.Region)
+	if err != nil {
+		return err
+	}
+	awsConfig := awsutil.NewConfig()
+	ec2Client := ec2.New(awsSession, awsConfig)
+	elbClient := elb.New(awsSession, awsConfig)
+	elbv2Client := elbv2.New(awsSession, awsConfig)
+	route53Client := route53.New(awsSession, awsutil.NewAWSRoute53Config())
+	s3Client := s3.New(awsSession, awsConfig)
+
+	resources, err := o.FindOrphanedResources(ctx, inUse, ec2Client, elbClient, elbv2Client, route53Client, s3Client)
+	if err != nil {
+		return err
+	}
+	if err := printOrphanedResources(out, resources); err != nil {
+		return err
+	}
+	if !o.Delete {
+		return nil
+	}
+
+	for _, infraID := range orphanedInfraIDs(resources) {
+		destroyOpts := &DestroyInfraOptions{
+			InfraID: infraID,
+			Region:  o.Region,
+			Log:     o.Log.WithValues("infraID", infraID),
+		}
+		if err := o.deleteWildcardRecords(ctx, route53Client, resources, infraID); err != nil {
+			return err
+		}
+		destroyCtx, cancel := context.WithTimeout(ctx, o.AwsInfraGracePeriod)
+		err := wait.PollImmediateUntil(5*time.Second, func() (bool, error) {
+			if err := destroyOpts.destroyInfra(destroyCtx, ec2Client, elbClient, elbv2Client, route53Client, s3Client); err != nil {
+				if !awsutil.IsErrorRetryable(err) {
+					return false, err
+				}
+				destroyOpts.Log.Info("WARNING: error during destroy, will retry", "error", err.Error())
+				return false, nil
+			}
+			return true, nil
+		}, destroyCtx.Done())
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to destroy infrastructure of %s: %w", infraID, err)
+		}
+		if err := o.deleteOIDCDocuments(ctx, s3Client, infraID); err != nil {
+			return err
+		}
+		o.Log.Info("Destroyed orphaned infrastructure", "infraID", infraID)
+	}
+	return nil
+}
+
+// InUseInfraIDs returns the infra IDs of all HostedClusters of the management cluster and the infrastructure
+// name of the management cluster itself when it is an OpenShift cluster.
+func InUseInfraIDs(ctx context.Context, c crclient.Client) (sets.Set[string], error) {
+	hostedClusters := &hyperv1.HostedClusterList{}
+	if err := c.List(ctx, hostedClusters); err != nil {
+		return nil, fmt.Errorf("failed to list HostedClusters: %w", err)
+	}
+	inUse := sets.New[string]()
+	for _, hc := range hostedClusters.Items {
+		if hc.Spec.InfraID != "" {
+			inUse.Insert(hc.Spec.InfraID)
+		}
+	}
+
+	infrastructure := &configv1.Infrastructure{}
+	if err := c.Get(ctx, crclient.ObjectKey{Name: "cluster"}, infrastructure); err != nil {
+		if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return nil, fmt.Errorf("failed to get the infrastructure of the management cluster: %w", err)
+		}
+	} else if infrastructure.Status.InfrastructureName != "" {
+		inUse.Insert(infrastructure.Status.InfrastructureName)
+	}
+	return inUse, nil
+}
+
+// FindOrphanedResources lists the resources of all infra IDs that are not in use and that are selected by the
+// options, sorted by infra ID, kind and ID.
+func (o *AuditInfraOptions) FindOrphanedResources(ctx context.Context, inUse sets.Set[string], ec2Client ec2iface.EC2API, elbClient elbiface.ELBAPI, elbv2Client elbv2iface.ELBV2API, route53Client route53iface.Route53API, s3Client s3iface.S3API) ([]OrphanedResource, error) {
+	selected := sets.New(o.InfraIDs...)
+	excluded := sets.New(o.ExcludeInfraIDs...)
+	isOrphaned := func(infraID string) bool {
+		return infraID != "" && !inUse.Has(infraID) && !excluded.Has(infraID) && (selected.Len() == 0 || selected.Has(infraID))
+	}
+
+	var resources []OrphanedResource
+	ec2Resources, err := taggedEC2Resources(ctx, ec2Client, isOrphaned)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, ec2Resources...)
+
+	elbResources, err := taggedV1ELBs(ctx, elbClient, isOrphaned)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, elbResources...)
+
+	elbv2Resources, err := taggedV2ELBs(ctx, elbv2Client, isOrphaned)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, elbv2Resources...)
+
+	// private hosted zones are not tagged, they are found through the VPCs they are associated with
+	clusterDomains := map[string]string{}
+	for _, resource := range ec2Resources {
+		if resource.Kind != ec2ResourceKind(ec2.ResourceTypeVpc) {
+			continue
+		}
+		output, err := route53Client.ListHostedZonesByVPCWithContext(ctx, &route53.ListHostedZonesByVPCInput{
+			VPCId:     aws.String(resource.ID),
+			VPCRegion: aws.String(o.Region),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list hosted zones of vpc %s: %w", resource.ID, err)
+		}
+		for _, zone := range output.HostedZoneSummaries {
+			name := strings.TrimSuffix(aws.StringValue(zone.Name), ".")
+			resources = append(resources, OrphanedResource{
+				InfraID: resource.InfraID,
+				Kind:    "Route53 private hosted zone",
+				ID:      fmt.Sprintf("%s (%s)", cleanZoneID(aws.StringValue(zone.HostedZoneId)), name),
+			})
+			if !strings.HasSuffix(name, "."+hypershiftLocalZoneName) {
+				clusterDomains[name] = resource.InfraID
+			}
+		}
+	}
+
+	recordResources, err := wildcardRecords(ctx, route53Client, clusterDomains)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, recordResources...)
+
+	if o.OIDCBucketName != "" {
+		oidcResources, err := oidcDocuments(ctx, s3Client, o.OIDCBucketName, isOrphaned)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, oidcResources...)
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].InfraID != resources[j].InfraID {
+			return resources[i].InfraID < resources[j].InfraID
+		}
+		if resources[i].Kind != resources[j].Kind {
+			return resources[i].Kind < resources[j].Kind
+		}
+		return resources[i].ID < resources[j].ID
+	})
+	return resources, nil
+}
+
+func taggedEC2Resources(ctx context.Context, client ec2iface.EC2API, isOrphaned func(string) bool) ([]OrphanedResource, error) {
+	var resources []OrphanedResource
+	err := client.DescribeTagsPagesWithContext(ctx, &ec2.DescribeTagsInput{
+		Filters: []*ec2.Filter{{
+			Name:   aws.String("key"),
+			Values: []*string{aws.String(clusterTag("*"))},
+		}},
+	}, func(out *ec2.DescribeTagsOutput, _ bool) bool {
+		for _, tag := range out.Tags {
+			infraID := infraIDFromTagKey(aws.StringValue(tag.Key))
+			if !isOrphaned(infraID) {
+				continue
+			}
+			resources = append(resources, OrphanedResource{
+				InfraID: infraID,
+				Kind:    ec2ResourceKind(aws.StringValue(tag.ResourceType)),
+				ID:      aws.StringValue(tag.ResourceId),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tagged EC2 resources: %w", err)
+	}
+	return resources, nil
+}
+
+func taggedV1ELBs(ctx context.Context, client elbiface.ELBAPI, isOrphaned func(string) bool) ([]OrphanedResource, error) {
+	var names []*string
+	err := client.DescribeLoadBalancersPagesWithContext(ctx, &elb.DescribeLoadBalancersInput{}, func(out *elb.DescribeLoadBalancersOutput, _ bool) bool {
+		for _, lb := range out.LoadBalancerDescriptions {
+			names = append(names, lb.LoadBalancerName)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classic load balancers: %w", err)
+	}
+
+	var resources []OrphanedResource
+	for start := 0; start < len(names); start += elbDescribeTagsLimit {
+		end := min(start+elbDescribeTagsLimit, len(names))
+		output, err := client.DescribeTagsWithContext(ctx, &elb.DescribeTagsInput{LoadBalancerNames: names[start:end]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe tags of classic load balancers: %w", err)
+		}
+		for _, description := range output.TagDescriptions {
+			for _, tag := range description.Tags {
+				if infraID := infraIDFromTagKey(aws.StringValue(tag.Key)); isOrphaned(infraID) {
+					resources = append(resources, OrphanedResource{
+						InfraID: infraID,
+						Kind:    "Classic load balancer",
+						ID:      aws.StringValue(description.LoadBalancerName),
+					})
+				}
+			}
+		}
+	}
+	return resources, nil
+}
+
+func taggedV2ELBs(ctx context.Context, client elbv2iface.ELBV2API, isOrphaned func(string) bool) ([]OrphanedResource, error) {
+	var arns []*string
+	kinds := map[string]string{}
+	err := client.DescribeLoadBalancersPagesWithContext(ctx, &elbv2.DescribeLoadBalancersInput{}, func(out *elbv2.DescribeLoadBalancersOutput, _ bool) bool {
+		for _, lb := range out.LoadBalancers {
+			arns = append(arns, lb.LoadBalancerArn)
+			switch aws.StringValue(lb.Type) {
+			case elbv2.LoadBalancerTypeEnumApplication:
+				kinds[aws.StringValue(lb.LoadBalancerArn)] = "Application load balancer"
+			case elbv2.LoadBalancerTypeEnumGateway:
+				kinds[aws.StringValue(lb.LoadBalancerArn)] = "Gateway load balancer"
+			default:
+				kinds[aws.StringValue(lb.LoadBalancerArn)] = "Network load balancer"
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list load balancers: %w", err)
+	}
+
+	var resources []OrphanedResource
+	for start := 0; start < len(arns); start += elbDescribeTagsLimit {
+		end := min(start+elbDescribeTagsLimit, len(arns))
+		output, err := client.DescribeTagsWithContext(ctx, &elbv2.DescribeTagsInput{ResourceArns: arns[start:end]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe tags of load balancers: %w", err)
+		}
+		for _, description := range output.TagDescriptions {
+			for _, tag := range description.Tags {
+				if infraID := infraIDFromTagKey(aws.StringValue(tag.Key)); isOrphaned(infraID) {
+					resources = append(resources, OrphanedResource{
+						InfraID: infraID,
+						Kind:    kinds[aws.StringValue(description.ResourceArn)],
+						ID:      aws.StringValue(description.ResourceArn),
+					})
+				}
+			}
+		}
+	}
+	return resources, nil
+}
+
+// wildcardRecords finds the wildcard ingress records of the cluster domains, by infra ID, in the public hosted zones
+// of their base domains.
+func wildcardRecords(ctx context.Context, client route53iface.Route53API, clusterDomains map[string]string) ([]OrphanedResource, error) {
+	if len(clusterDomains) == 0 {
+		return nil, nil
+	}
+	publicZones := map[string]string{}
+	err := client.ListHostedZonesPagesWithContext(ctx, &route53.ListHostedZonesInput{}, func(out *route53.ListHostedZonesOutput, _ bool) bool {
+		for _, zone := range out.HostedZones {
+			if zone.Config == nil || !aws.BoolValue(zone.Config.PrivateZone) {
+				publicZones[strings.TrimSuffix(aws.StringValue(zone.Name), ".")] = cleanZoneID(aws.StringValue(zone.Id))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosted zones: %w", err)
+	}
+
+	var resources []OrphanedResource
+	for domain, infraID := range clusterDomains {
+		// the base domain is the cluster domain itself when the cluster has no base domain prefix, or its parent
+		zoneID, found := publicZones[domain]
+		if !found {
+			_, parent, _ := strings.Cut(domain, ".")
+			if zoneID, found = publicZones[parent]; !found {
+				continue
+			}
+		}
+		recordName := fmt.Sprintf("*.apps.%s", domain)
+		if _, err := findRecord(ctx, client, zoneID, recordName, "A"); err != nil {
+			if isRoute53RecordNotFoundErr(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to find record %s in hosted zone %s: %w", recordName, zoneID, err)
+		}
+		resources = append(resources, OrphanedResource{
+			InfraID:      infraID,
+			Kind:         wildcardRecordKind,
+			ID:           fmt.Sprintf("%s (%s)", zoneID, recordName),
+			publicZoneID: zoneID,
+			recordName:   recordName,
+		})
+	}
+	return resources, nil
+}
+
+func (o *AuditInfraOptions) deleteWildcardRecords(ctx context.Context, client route53iface.Route53API, resources []OrphanedResource, infraID string) error {
+	for _, resource := range resources {
+		if resource.InfraID != infraID || resource.Kind != wildcardRecordKind {
+			continue
+		}
+		if err := deleteRecord(ctx, client, resource.publicZoneID, resource.recordName); err != nil && !isRoute53RecordNotFoundErr(err) {
+			return fmt.Errorf("failed to delete wildcard record %s from public zone %s: %w", resource.recordName, resource.publicZoneID, err)
+		}
+		o.Log.Info("Deleted wildcard record from public hosted zone", "id", resource.publicZoneID, "name", resource.recordName)
+	}
+	return nil
+}
+
+func oidcDocuments(ctx context.Context, client s3iface.S3API, bucket string, isOrphaned func(string) bool) ([]OrphanedResource, error) {
+	var resources []OrphanedResource
+	err := client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{Bucket: aws.String(bucket)}, func(out *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range out.Contents {
+			key := aws.StringValue(object.Key)
+			for _, path := range oidcDocumentPaths {
+				infraID, found := strings.CutSuffix(key, path)
+				if found && isOrphaned(infraID) {
+					resources = append(resources, OrphanedResource{
+						InfraID: infraID,
+						Kind:    "S3 OIDC document",
+						ID:      fmt.Sprintf("s3://%s/%s", bucket, key),
+					})
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects of OIDC bucket %s: %w", bucket, err)
+	}
+	return resources, nil
+}
+
+func (o *AuditInfraOptions) deleteOIDCDocuments(ctx context.Context, client s3iface.S3API, infraID string) error {
+	if o.OIDCBucketName == "" {
+		return nil
+	}
+	var objects []*s3.ObjectIdentifier
+	for _, path := range oidcDocumentPaths {
+		objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(infraID + path)})
+	}
+	_, err := client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(o.OIDCBucketName),
+		Delete: &s3.Delete{Objects: objects},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete OIDC documents of %s: %w", infraID, err)
+	}
+	o.Log.Info("Deleted OIDC documents", "infraID", infraID, "bucket", o.OIDCBucketName)
+	return nil
+}
+
+func infraIDFromTagKey(key string) string {
+	infraID, found := strings.CutPrefix(key, clusterTag(""))
+	if !found {
+		return ""
+	}
+	return infraID
+}
+
+// ec2ResourceKind returns a readable kind for the resource types of EC2 tags, e.g. "EC2 network-interface".
+func ec2ResourceKind(resourceType string) string {
+	return "EC2 " + resourceType
+}
+
+func orphanedInfraIDs(resources []OrphanedResource) []string {
+	infraIDs := sets.New[string]()
+	for _, resource := range resources {
+		infraIDs.Insert(resource.InfraID)
+	}
+	return sets.List(infraIDs)
+}
+
+func printOrphanedResources(out io.Writer, resources []OrphanedResource) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "INFRA ID\tKIND\tID")
+	for _, resource := range resources {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", resource.InfraID, resource.Kind, resource.ID)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// summarize what kind of resources were left behind by each infra ID
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "INFRA ID\tRESOURCES")
+	for _, infraID := range orphanedInfraIDs(resources) {
+		counts := map[string]int{}
+		for _, resource := range resources {
+			if resource.InfraID == infraID {
+				counts[resource.Kind]++
+			}
+		}
+		var summary []string
+		for _, kind := range sets.List(sets.KeySet(counts)) {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+		fmt.Fprintf(w, "%s\t%s\n", infraID, strings.Join(summary, ", "))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,316 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+)
+
+type fakeEC2Client struct {
+	ec2iface.EC2API
+	tags []*ec2.TagDescription
+}
+
+func (f *fakeEC2Client) DescribeTagsPagesWithContext(_ aws.Context, _ *ec2.DescribeTagsInput, fn func(*ec2.DescribeTagsOutput, bool) bool, _ ...request.Option) error {
+	fn(&ec2.DescribeTagsOutput{Tags: f.tags}, true)
+	return nil
+}
+
+type fakeELBClient struct {
+	elbiface.ELBAPI
+	tags map[string][]*elb.Tag
+}
+
+func (f *fakeELBClient) DescribeLoadBalancersPagesWithContext(_ aws.Context, _ *elb.DescribeLoadBalancersInput, fn func(*elb.DescribeLoadBalancersOutput, bool) bool, _ ...request.Option) error {
+	out := &elb.DescribeLoadBalancersOutput{}
+	for name := range f.tags {
+		out.LoadBalancerDescriptions = append(out.LoadBalancerDescriptions, &elb.LoadBalancerDescription{LoadBalancerName: aws.String(name)})
+	}
+	fn(out, true)
+	return nil
+}
+
+func (f *fakeELBClient) DescribeTagsWithContext(_ aws.Context, input *elb.DescribeTagsInput, _ ...request.Option) (*elb.DescribeTagsOutput, error) {
+	out := &elb.DescribeTagsOutput{}
+	for _, name := range input.LoadBalancerNames {
+		out.TagDescriptions = append(out.TagDescriptions, &elb.TagDescription{LoadBalancerName: name, Tags: f.tags[aws.StringValue(name)]})
+	}
+	return out, nil
+}
+
+type fakeELBV2Client struct {
+	elbv2iface.ELBV2API
+	loadBalancers []*elbv2.LoadBalancer
+	tags          map[string][]*elbv2.Tag
+}
+
+func (f *fakeELBV2Client) DescribeLoadBalancersPagesWithContext(_ aws.Context, _ *elbv2.DescribeLoadBalancersInput, fn func(*elbv2.DescribeLoadBalancersOutput, bool) bool, _ ...request.Option) error {
+	fn(&elbv2.DescribeLoadBalancersOutput{LoadBalancers: f.loadBalancers}, true)
+	return nil
+}
+
+func (f *fakeELBV2Client) DescribeTagsWithContext(_ aws.Context, input *elbv2.DescribeTagsInput, _ ...request.Option) (*elbv2.DescribeTagsOutput, error) {
+	out := &elbv2.DescribeTagsOutput{}
+	for _, arn := range input.ResourceArns {
+		out.TagDescriptions = append(out.TagDescriptions, &elbv2.TagDescription{ResourceArn: arn, Tags: f.tags[aws.StringValue(arn)]})
+	}
+	return out, nil
+}
+
+type fakeRoute53Client struct {
+	route53iface.Route53API
+	zonesByVPC  map[string][]*route53.HostedZoneSummary
+	publicZones []*route53.HostedZone
+	records     map[string][]*route53.ResourceRecordSet
+	deleted     []string
+}
+
+func (f *fakeRoute53Client) ListHostedZonesByVPCWithContext(_ aws.Context, input *route53.ListHostedZonesByVPCInput, _ ...request.Option) (*route53.ListHostedZonesByVPCOutput, error) {
+	return &route53.ListHostedZonesByVPCOutput{HostedZoneSummaries: f.zonesByVPC[aws.StringValue(input.VPCId)]}, nil
+}
+
+func (f *fakeRoute53Client) ListHostedZonesPagesWithContext(_ aws.Context, _ *route53.ListHostedZonesInput, fn func(*route53.ListHostedZonesOutput, bool) bool, _ ...request.Option) error {
+	fn(&route53.ListHostedZonesOutput{HostedZones: f.publicZones}, true)
+	return nil
+}
+
+func (f *fakeRoute53Client) ListResourceRecordSetsPagesWithContext(_ aws.Context, input *route53.ListResourceRecordSetsInput, fn func(*route53.ListResourceRecordSetsOutput, bool) bool, _ ...request.Option) error {
+	out := &route53.ListResourceRecordSetsOutput{}
+	for _, record := range f.records[aws.StringValue(input.HostedZoneId)] {
+		if cleanRecordName(aws.StringValue(record.Name)) == aws.StringValue(input.StartRecordName) {
+			out.ResourceRecordSets = append(out.ResourceRecordSets, record)
+		}
+	}
+	fn(out, true)
+	return nil
+}
+
+func (f *fakeRoute53Client) ChangeResourceRecordSetsWithContext(_ aws.Context, input *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	for _, change := range input.ChangeBatch.Changes {
+		f.deleted = append(f.deleted, aws.StringValue(input.HostedZoneId)+"/"+cleanRecordName(aws.StringValue(change.ResourceRecordSet.Name)))
+	}
+	return &route53.ChangeResourceRecordSetsOutput{}, nil
+}
+
+type fakeS3Client struct {
+	s3iface.S3API
+	keys []string
+}
+
+func (f *fakeS3Client) ListObjectsV2PagesWithContext(_ aws.Context, _ *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+	out := &s3.ListObjectsV2Output{}
+	for _, key := range f.keys {
+		out.Contents = append(out.Contents, &s3.Object{Key: aws.String(key)})
+	}
+	fn(out, true)
+	return nil
+}
+
+func ec2Tag(infraID, resourceType, id string) *ec2.TagDescription {
+	return &ec2.TagDescription{
+		Key:          aws.String(clusterTag(infraID)),
+		Value:        aws.String(clusterTagValue),
+		ResourceType: aws.String(resourceType),
+		ResourceId:   aws.String(id),
+	}
+}
+
+func TestFindOrphanedResources(t *testing.T) {
+	ec2Client := &fakeEC2Client{tags: []*ec2.TagDescription{
+		ec2Tag("live", ec2.ResourceTypeVpc, "vpc-live"),
+		ec2Tag("orphan", ec2.ResourceTypeVpc, "vpc-orphan"),
+		ec2Tag("orphan", ec2.ResourceTypeNetworkInterface, "eni-1"),
+		ec2Tag("orphan", ec2.ResourceTypeElasticIp, "eipalloc-1"),
+		ec2Tag("other", ec2.ResourceTypeInstance, "i-1"),
+	}}
+	elbClient := &fakeELBClient{tags: map[string][]*elb.Tag{
+		"classic-orphan": {{Key: aws.String(clusterTag("orphan")), Value: aws.String(clusterTagValue)}},
+		"classic-live":   {{Key: aws.String(clusterTag("live")), Value: aws.String(clusterTagValue)}},
+		"untagged":       nil,
+	}}
+	elbv2Client := &fakeELBV2Client{
+		loadBalancers: []*elbv2.LoadBalancer{
+			{LoadBalancerArn: aws.String("arn:nlb-orphan"), Type: aws.String(elbv2.LoadBalancerTypeEnumNetwork)},
+			{LoadBalancerArn: aws.String("arn:alb-other"), Type: aws.String(elbv2.LoadBalancerTypeEnumApplication)},
+		},
+		tags: map[string][]*elbv2.Tag{
+			"arn:nlb-orphan": {{Key: aws.String(clusterTag("orphan")), Value: aws.String(clusterTagValue)}},
+			"arn:alb-other":  {{Key: aws.String(clusterTag("other")), Value: aws.String(clusterTagValue)}},
+		},
+	}
+	route53Client := &fakeRoute53Client{
+		zonesByVPC: map[string][]*route53.HostedZoneSummary{
+			"vpc-orphan": {
+				{HostedZoneId: aws.String("/hostedzone/Z1"), Name: aws.String("orphan.hypershift.local.")},
+				{HostedZoneId: aws.String("/hostedzone/Z3"), Name: aws.String("orphan.example.com.")},
+			},
+			"vpc-live": {{HostedZoneId: aws.String("/hostedzone/Z2"), Name: aws.String("live.hypershift.local.")}},
+		},
+		publicZones: []*route53.HostedZone{
+			{Id: aws.String("/hostedzone/ZPUBLIC"), Name: aws.String("example.com."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)}},
+		},
+		records: map[string][]*route53.ResourceRecordSet{
+			"ZPUBLIC": {{Name: aws.String("\\052.apps.orphan.example.com."), Type: aws.String("A")}},
+		},
+	}
+	s3Client := &fakeS3Client{keys: []string{
+		"orphan/.well-known/openid-configuration",
+		"orphan/openid/v1/jwks",
+		"live/openid/v1/jwks",
+		"unrelated/object",
+	}}
+
+	testCases := []struct {
+		name     string
+		opts     AuditInfraOptions
+		expected []OrphanedResource
+	}{
+		{
+			name: "all orphaned infra IDs",
+			opts: AuditInfraOptions{Region: "us-east-1", OIDCBucketName: "oidc"},
+			expected: []OrphanedResource{
+				{InfraID: "orphan", Kind: "Classic load balancer", ID: "classic-orphan"},
+				{InfraID: "orphan", Kind: "EC2 elastic-ip", ID: "eipalloc-1"},
+				{InfraID: "orphan", Kind: "EC2 network-interface", ID: "eni-1"},
+				{InfraID: "orphan", Kind: "EC2 vpc", ID: "vpc-orphan"},
+				{InfraID: "orphan", Kind: "Network load balancer", ID: "arn:nlb-orphan"},
+				{InfraID: "orphan", Kind: "Route53 private hosted zone", ID: "Z1 (orphan.hypershift.local)"},
+				{InfraID: "orphan", Kind: "Route53 private hosted zone", ID: "Z3 (orphan.example.com)"},
+				{InfraID: "orphan", Kind: "Route53 wildcard record", ID: "ZPUBLIC (*.apps.orphan.example.com)", publicZoneID: "ZPUBLIC", recordName: "*.apps.orphan.example.com"},
+				{InfraID: "orphan", Kind: "S3 OIDC document", ID: "s3://oidc/orphan/.well-known/openid-configuration"},
+				{InfraID: "orphan", Kind: "S3 OIDC document", ID: "s3://oidc/orphan/openid/v1/jwks"},
+				{InfraID: "other", Kind: "Application load balancer", ID: "arn:alb-other"},
+				{InfraID: "other", Kind: "EC2 instance", ID: "i-1"},
+			},
+		},
+		{
+			name: "excluded infra IDs are left alone",
+			opts: AuditInfraOptions{Region: "us-east-1", ExcludeInfraIDs: []string{"orphan"}},
+			expected: []OrphanedResource{
+				{InfraID: "other", Kind: "Application load balancer", ID: "arn:alb-other"},
+				{InfraID: "other", Kind: "EC2 instance", ID: "i-1"},
+			},
+		},
+		{
+			name: "only selected infra IDs are audited",
+			opts: AuditInfraOptions{Region: "us-east-1", InfraIDs: []string{"other", "live"}},
+			expected: []OrphanedResource{
+				{InfraID: "other", Kind: "Application load balancer", ID: "arn:alb-other"},
+				{InfraID: "other", Kind: "EC2 instance", ID: "i-1"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			resources, err := tc.opts.FindOrphanedResources(context.Background(), sets.New("live"), ec2Client, elbClient, elbv2Client, route53Client, s3Client)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(resources).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestInUseInfraIDs(t *testing.T) {
+	hostedCluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"},
+		Spec:       hyperv1.HostedClusterSpec{InfraID: "hc-infra"},
+	}
+	testCases := []struct {
+		name     string
+		objects  []crclient.Object
+		expected sets.Set[string]
+	}{
+		{
+			name:     "When the management cluster is an OpenShift cluster it should consider its infrastructure name in use",
+			objects:  []crclient.Object{hostedCluster, &configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.InfrastructureStatus{InfrastructureName: "mgmt-infra"}}},
+			expected: sets.New("hc-infra", "mgmt-infra"),
+		},
+		{
+			name:     "When the management cluster is not an OpenShift cluster it should only consider the HostedClusters in use",
+			objects:  []crclient.Object{hostedCluster},
+			expected: sets.New("hc-infra"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.objects...).Build()
+			inUse, err := InUseInfraIDs(context.Background(), c)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(inUse).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestAuditInfraOptionsValidate(t *testing.T) {
+	g := NewWithT(t)
+	opts := &AuditInfraOptions{Delete: true}
+	opts.AWSCredentialsOpts.AWSCredentialsFile = "credentials"
+	g.Expect(opts.Validate()).ToNot(Succeed())
+	opts.InfraIDs = []string{"orphan"}
+	g.Expect(opts.Validate()).To(Succeed())
+}
+
+func TestDeleteWildcardRecords(t *testing.T) {
+	g := NewWithT(t)
+	route53Client := &fakeRoute53Client{
+		records: map[string][]*route53.ResourceRecordSet{
+			"ZPUBLIC": {
+				{Name: aws.String("\\052.apps.orphan.example.com."), Type: aws.String("A")},
+				{Name: aws.String("\\052.apps.other.example.com."), Type: aws.String("A")},
+			},
+		},
+	}
+	resources := []OrphanedResource{
+		{InfraID: "orphan", Kind: "EC2 vpc", ID: "vpc-orphan"},
+		{InfraID: "orphan", Kind: wildcardRecordKind, ID: "ZPUBLIC (*.apps.orphan.example.com)", publicZoneID: "ZPUBLIC", recordName: "*.apps.orphan.example.com"},
+		{InfraID: "other", Kind: wildcardRecordKind, ID: "ZPUBLIC (*.apps.other.example.com)", publicZoneID: "ZPUBLIC", recordName: "*.apps.other.example.com"},
+	}
+
+	o := &AuditInfraOptions{Log: logr.Discard()}
+	g.Expect(o.deleteWildcardRecords(context.Background(), route53Client, resources, "orphan")).To(Succeed())
+	g.Expect(route53Client.deleted).To(Equal([]string{"ZPUBLIC/*.apps.orphan.example.com."}))
+}
+
+func TestPrintOrphanedResources(t *testing.T) {
+	g := NewWithT(t)
+	out := &bytes.Buffer{}
+	g.Expect(printOrphanedResources(out, []OrphanedResource{
+		{InfraID: "a", Kind: "EC2 vpc", ID: "vpc-1"},
+		{InfraID: "a", Kind: "EC2 subnet", ID: "subnet-1"},
+		{InfraID: "a", Kind: "EC2 subnet", ID: "subnet-2"},
+	})).To(Succeed())
+	g.Expect(out.String()).To(Equal(`INFRA ID  KIND        ID
+a         EC2 vpc     vpc-1
+a         EC2 subnet  subnet-1
+a         EC2 subnet  subnet-2
+
+INFRA ID  RESOURCES
+a         2 EC2 subnet, 1 EC2 vpc
+`))
+}
@@ -0,0 +1,400 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/openshift/hypershift/cmd/util"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/go-logr/logr"
+
+	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+type CreateInfraOptions struct {
+	AWSCredentialsOpts awsutil.AWSCredentialsOptions
+	Region             string
+	InfraID            string
+	Name               string
+	BaseDomain         string
+	BaseDomainPrefix   string
+	Zones              []string
+	OutputFile         string
+	AdditionalTags     []string
+	EnableProxy        bool
+	SSHKeyFile         string
+	SingleNATGateway   bool
+	VPCID              string
+	PrivateSubnetIDs   []string
+	PublicSubnetIDs    []string
+	DualStack          bool
+
+	CredentialsSecretData *util.CredentialsSecretData
+
+	additionalEC2Tags []*ec2.Tag
+}
+
+type CreateInfraOutputZone struct {
+	Name     string `json:"name"`
+	SubnetID string `json:"subnetID"`
+}
+
+type CreateInfraOutput struct {
+	Region           string                   `json:"region"`
+	Zone             string                   `json:"zone"`
+	InfraID          string                   `json:"infraID"`
+	MachineCIDR      string                   `json:"machineCIDR"`
+	MachineCIDRv6    string                   `json:"machineCIDRv6,omitempty"`
+	VPCID            string                   `json:"vpcID"`
+	Zones            []*CreateInfraOutputZone `json:"zones"`
+	Name             string                   `json:"Name"`
+	BaseDomain       string                   `json:"baseDomain"`
+	BaseDomainPrefix string                   `json:"baseDomainPrefix"`
+	PublicZoneID     string                   `json:"publicZoneID"`
+	PrivateZoneID    string                   `json:"privateZoneID"`
+	LocalZoneID      string                   `json:"localZoneID"`
+	ProxyAddr        string                   `json:"proxyAddr"`
+}
+
+const (
+	DefaultCIDRBlock      = "10.0.0.0/16"
+	basePrivateSubnetCIDR = "10.0.128.0/20"
+	basePublicSubnetCIDR  = "10.0.0.0/20"
+
+	clusterTagValue         = "owned"
+	sharedClusterTagValue   = "shared"
+	hypershiftLocalZoneName = "hypershift.local"
+)
+
+func (o *CreateInfraOptions) Validate() error {
+	if len(o.VPCID) == 0 {
+		if len(o.PrivateSubnetIDs) > 0 || len(o.PublicSubnetIDs) > 0 {
+			return fmt.Errorf("--private-subnet-ids and --public-subnet-ids can only be used with --vpc-id")
+		}
+		return nil
+	}
+	if len(o.PrivateSubnetIDs) == 0 {
+		return fmt.Errorf("at least one private subnet is required with --vpc-id")
+	}
+	if len(o.Zones) > 0 {
+		return fmt.Errorf("--zones cannot be used with --vpc-id, the zones are those of the private subnets")
+	}
+	if o.EnableProxy {
+		return fmt.Errorf("--enable-proxy cannot be used with --vpc-id")
+	}
+	if o.SingleNATGateway {
+		return fmt.Errorf("--single-nat-gateway cannot be used with --vpc-id")
+	}
+	return nil
+}
+
+func (o *CreateInfraOptions) Run(ctx context.Context, l logr.Logger) error {
+	result, err := o.CreateInfra(ctx, l)
+	if err != nil {
+		return err
+	}
+	return o.Output(result)
+}
+
+func (o *CreateInfraOptions) Output(result *CreateInfraOutput) error {
+	// Write out stateful information
+	out := os.Stdout
+	if len(o.OutputFile) > 0 {
+		var err error
+		out, err = os.Create(o.OutputFile)
+		if err != nil {
+			return fmt.Errorf("cannot create output file: %w", err)
+		}
+		defer out.Close()
+	}
+	outputBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize result: %w", err)
+	}
+	_, err = out.Write(outputBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+func (o *CreateInfraOptions) CreateInfra(ctx context.Context, l logr.Logger) (*CreateInfraOutput, error) {
+	l.Info("Creating infrastructure", "id", o.InfraID)
+
+	awsSession, err := o.AWSCredentialsOpts.GetSession("cli-create-infra", o.CredentialsSecretData, o.Region)
+	if err != nil {
+		return nil, err
+	}
+	ec2Client := ec2.New(awsSession, awsutil.NewConfig())
+	route53Client := route53.New(awsSession, awsutil.NewAWSRoute53Config())
+
+	if err := o.parseAdditionalTags(); err != nil {
+		return nil, err
+	}
+
+	result := &CreateInfraOutput{
+		InfraID:          o.InfraID,
+		MachineCIDR:      DefaultCIDRBlock,
+		Region:           o.Region,
+		Name:             o.Name,
+		BaseDomain:       o.BaseDomain,
+		BaseDomainPrefix: o.BaseDomainPrefix,
+	}
+	if len(o.VPCID) > 0 {
+		if err := o.adoptVPC(l, ec2Client, result); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := o.createNetwork(l, ec2Client, result); err != nil {
+			return nil, err
+		}
+	}
+
+	result.PublicZoneID, err = o.LookupPublicZone(ctx, l, route53Client)
+	if err != nil {
+		return nil, err
+	}
+
+	result.PrivateZoneID, err = o.CreatePrivateZone(ctx, l, route53Client, ZoneName(o.Name, o.BaseDomainPrefix, o.BaseDomain), result.VPCID)
+	if err != nil {
+		return nil, err
+	}
+	result.LocalZoneID, err = o.CreatePrivateZone(ctx, l, route53Client, fmt.Sprintf("%s.%s", o.Name, hypershiftLocalZoneName), result.VPCID)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.EnableProxy {
+		var sshKeyFile []byte
+		if o.SSHKeyFile != "" {
+			sshKeyFile, err = os.ReadFile(o.SSHKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read ssh-key-file from %s: %w", o.SSHKeyFile, err)
+			}
+		}
+		result.ProxyAddr, err = o.createProxyHost(ctx, l, ec2Client, result.Zones[0].SubnetID, result.VPCID, string(sshKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy host: %w", err)
+		}
+
+	}
+	return result, nil
+}
+
+// createNetwork creates the VPC of the cluster and its subnets, gateways and route tables.
+func (o *CreateInfraOptions) createNetwork(l logr.Logger, ec2Client ec2iface.EC2API, result *CreateInfraOutput) error {
+	if len(o.Zones) == 0 {
+		zone, err := o.firstZone(l, ec2Client)
+		if err != nil {
+			return err
+		}
+		o.Zones = append(o.Zones, zone)
+	}
+
+	// VPC resources
+	var err error
+	result.VPCID, err = o.createVPC(l, ec2Client)
+	if err != nil {
+		return err
+	}
+	var eigwID string
+	if o.DualStack {
+		result.MachineCIDRv6, err = o.ensureVPCIPv6CIDR(l, ec2Client, result.VPCID)
+		if err != nil {
+			return err
+		}
+		eigwID, err = o.CreateEgressOnlyInternetGateway(l, ec2Client, result.VPCID)
+		if err != nil {
+			return err
+		}
+	}
+	if err = o.CreateDHCPOptions(l, ec2Client, result.VPCID); err != nil {
+		return err
+	}
+	igwID, err := o.CreateInternetGateway(l, ec2Client, result.VPCID)
+	if err != nil {
+		return err
+	}
+
+	// Per zone resources
+	var endpointRouteTableIds []*string
+	var publicSubnetIDs []string
+	_, privateNetwork, err := net.ParseCIDR(basePrivateSubnetCIDR)
+	if err != nil {
+		return err
+	}
+	_, publicNetwork, err := net.ParseCIDR(basePublicSubnetCIDR)
+	if err != nil {
+		return err
+	}
+	var natGatewayID string
+	for i, zone := range o.Zones {
+		var privateIPv6CIDR, publicIPv6CIDR string
+		if o.DualStack {
+			// IPv6 subnets mirror the layout of the IPv4 ones, with private subnets in the upper half of the VPC CIDR
+			if privateIPv6CIDR, err = ipv6SubnetCIDR(result.MachineCIDRv6, 128+i); err != nil {
+				return err
+			}
+			if publicIPv6CIDR, err = ipv6SubnetCIDR(result.MachineCIDRv6, i); err != nil {
+				return err
+			}
+		}
+		privateSubnetID, err := o.CreatePrivateSubnet(l, ec2Client, result.VPCID, zone, privateNetwork.String(), privateIPv6CIDR)
+		if err != nil {
+			return err
+		}
+		publicSubnetID, err := o.CreatePublicSubnet(l, ec2Client, result.VPCID, zone, publicNetwork.String(), publicIPv6CIDR)
+		if err != nil {
+			return err
+		}
+		publicSubnetIDs = append(publicSubnetIDs, publicSubnetID)
+		if !o.EnableProxy && ((natGatewayID == "" && o.SingleNATGateway) || !o.SingleNATGateway) {
+			natGatewayID, err = o.CreateNATGateway(l, ec2Client, publicSubnetID, zone)
+			if err != nil {
+				return err
+			}
+		}
+		privateRouteTable, err := o.CreatePrivateRouteTable(l, ec2Client, result.VPCID, natGatewayID, eigwID, privateSubnetID, zone)
+		if err != nil {
+			return err
+		}
+		endpointRouteTableIds = append(endpointRouteTableIds, aws.String(privateRouteTable))
+		result.Zones = append(result.Zones, &CreateInfraOutputZone{
+			Name:     zone,
+			SubnetID: privateSubnetID,
+		})
+		// increment each subnet by /20
+		privateNetwork.IP[2] = privateNetwork.IP[2] + 16
+		publicNetwork.IP[2] = publicNetwork.IP[2] + 16
+	}
+	publicRouteTable, err := o.CreatePublicRouteTable(l, ec2Client, result.VPCID, igwID, publicSubnetIDs)
+	if err != nil {
+		return err
+	}
+	endpointRouteTableIds = append(endpointRouteTableIds, aws.String(publicRouteTable))
+	return o.CreateVPCS3Endpoint(l, ec2Client, result.VPCID, endpointRouteTableIds)
+}
+
+func (o *CreateInfraOptions) createProxyHost(ctx context.Context, l logr.Logger, client ec2iface.EC2API, subnetID, vpcID string, sshKeys string) (string, error) {
+	const securityGroupName = "proxy-sg"
+	sgCreateResult, err := client.CreateSecurityGroupWithContext(ctx, &ec2.CreateSecurityGroupInput{
+		GroupName:         aws.String(securityGroupName),
+		Description:       aws.String("proxy security group"),
+		VpcId:             aws.String(vpcID),
+		TagSpecifications: o.ec2TagSpecifications("security-group", securityGroupName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create bastion security group: %w", err)
+	}
+
+	var sgResult *ec2.DescribeSecurityGroupsOutput
+	err = retry.OnError(ec2Backoff(), func(error) bool { return true }, func() error {
+		var err error
+		sgResult, err = client.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{
+			GroupIds: []*string{sgCreateResult.GroupId},
+		})
+		if err != nil || len(sgResult.SecurityGroups) == 0 {
+			return errors.New("not found yet")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot find security group that was just created (%s)", aws.StringValue(sgCreateResult.GroupId))
+	}
+	sg := sgResult.SecurityGroups[0]
+	l.Info("Created security group", "name", securityGroupName, "id", aws.StringValue(sg.GroupId))
+
+	permissions := []*ec2.IpPermission{
+		{
+			IpProtocol: aws.String("tcp"),
+			IpRanges: []*ec2.IpRange{{
+				CidrIp: aws.String("0.0.0.0/0"),
+			}},
+			FromPort: aws.Int64(22),
+			ToPort:   aws.Int64(22),
+		},
+		{
+			IpProtocol: aws.String("-1"),
+			IpRanges: []*ec2.IpRange{{
+				CidrIp: aws.String("10.0.0.0/8"),
+			}},
+			FromPort: aws.Int64(-1),
+			ToPort:   aws.Int64(-1),
+		},
+	}
+	_, err = client.AuthorizeSecurityGroupIngressWithContext(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId:       sg.GroupId,
+		IpPermissions: permissions,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to authorize security group: %w", err)
+	}
+	l.Info("Authorized security group for proxy")
+
+	result, err := client.RunInstancesWithContext(ctx, &ec2.RunInstancesInput{
+		ImageId:      aws.String("resolve:ssm:/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-x86_64-gp2"),
+		MaxCount:     aws.Int64(1),
+		MinCount:     aws.Int64(1),
+		InstanceType: aws.String("t2.micro"),
+		UserData:     aws.String(base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(proxyConfigurationScript, sshKeys)))),
+		NetworkInterfaces: []*ec2.InstanceNetworkInterfaceSpecification{
+			{
+				DeviceIndex:              aws.Int64(0),
+				AssociatePublicIpAddress: aws.Bool(true),
+				SubnetId:                 aws.String(subnetID),
+				Groups:                   []*string{sg.GroupId},
+			},
+		},
+		TagSpecifications: o.ec2TagSpecifications("instance", o.Name+"-"+o.InfraID+"-http-proxy"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to launch proxy host: %w", err)
+	}
+	l.Info("Created proxy host")
+
+	return fmt.Sprintf("http://%s:3128", *result.Instances[0].PrivateIpAddress), nil
+}
+
+func ec2Backoff() wait.Backoff {
+	return wait.Backoff{
+		Steps:    10,
+		Duration: 3 * time.Second,
+		Factor:   1.0,
+		Jitter:   0.1,
+	}
+}
+
+func ZoneName(clusterName, prefix, baseDomain string) string {
+	if prefix == "none" {
+		return baseDomain
+	}
+
+	if prefix == "" {
+		prefix = clusterName
+	}
+	return fmt.Sprintf("%s.%s", prefix, baseDomain)
+}
+
+const proxyConfigurationScript = `#!/bin/bash
+yum install -y squid
+# By default, squid only allows connect on port 443
+sed -E 's/(^http_access deny CONNECT.*)/#\1/' -i /etc/squid/squid.conf
+systemctl enable --now squid
+mkdir -p /home/ec2-user/.ssh
+chmod 0700 /home/ec2-user/.ssh
+echo -e '%s' >/home/ec2-user/.ssh/authorized_keys
+chmod 0600 /home/ec2-user/.ssh/authorized_keys
+chown -R ec2-user:ec2-user /home/ec2-user/.ssh
+`
@@ -0,0 +1,219 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+type CreateIAMOptions struct {
+	Region                          string
+	AWSCredentialsOpts              awsutil.AWSCredentialsOptions
+	OIDCStorageProviderS3BucketName string
+	OIDCStorageProviderS3Region     string
+	PublicZoneID                    string
+	PrivateZoneID                   string
+	LocalZoneID                     string
+	InfraID                         string
+	IssuerURL                       string
+	OutputFile                      string
+	KMSKeyARN                       string
+	AdditionalTags                  []string
+	PrintPolicies                   bool
+	ValidateRolesFile               string
+
+	CredentialsSecretData *util.CredentialsSecretData
+
+	additionalIAMTags []*iam.Tag
+}
+
+type CreateIAMOutput struct {
+	Region             string              `json:"region"`
+	ProfileName        string              `json:"profileName"`
+	InfraID            string              `json:"infraID"`
+	IssuerURL          string              `json:"issuerURL"`
+	Roles              hyperv1.AWSRolesRef `json:"roles"`
+	KMSKeyARN          string              `json:"kmsKeyARN"`
+	KMSProviderRoleARN string              `json:"kmsProviderRoleARN"`
+}
+
+func (o *CreateIAMOptions) Run(ctx context.Context, client crclient.Client, logger logr.Logger) error {
+	results, err := o.CreateIAM(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+	return o.Output(results)
+}
+
+func (o *CreateIAMOptions) Output(results *CreateIAMOutput) error {
+	// Write out stateful information
+	out := os.Stdout
+	if len(o.OutputFile) > 0 {
+		var err error
+		out, err = os.Create(o.OutputFile)
+		if err != nil {
+			return fmt.Errorf("cannot create output file: %w", err)
+		}
+		defer out.Close()
+	}
+	outputBytes, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize result: %w", err)
+	}
+	_, err = out.Write(outputBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+// OutputPolicies writes the permissions policies of the roles to the output file or stdout.
+func (o *CreateIAMOptions) OutputPolicies() error {
+	policies, err := o.Policies()
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if len(o.OutputFile) > 0 {
+		out, err = os.Create(o.OutputFile)
+		if err != nil {
+			return fmt.Errorf("cannot create output file: %w", err)
+		}
+		defer out.Close()
+	}
+	outputBytes, err := json.MarshalIndent(policies, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize policies: %w", err)
+	}
+	if _, err := out.Write(outputBytes); err != nil {
+		return fmt.Errorf("failed to write policies: %w", err)
+	}
+	return nil
+}
+
+// RunValidateRoles checks that the roles in the validate roles file allow every action their component
+// requires, and fails listing the missing permissions otherwise.
+func (o *CreateIAMOptions) RunValidateRoles(ctx context.Context, logger logr.Logger) error {
+	rawRoles, err := os.ReadFile(o.ValidateRolesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read roles file: %w", err)
+	}
+	roles := &CreateIAMOutput{}
+	if err := json.Unmarshal(rawRoles, roles); err != nil {
+		return fmt.Errorf("failed to parse roles file: %w", err)
+	}
+
+	awsSession, err := o.AWSCredentialsOpts.GetSession("cli-validate-iam", o.CredentialsSecretData, o.Region)
+	if err != nil {
+		return err
+	}
+	iamClient := iam.New(awsSession, awsutil.NewConfig())
+
+	missing, err := o.ValidateRoles(ctx, iamClient, roles)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		logger.Info("All roles have the required permissions")
+		return nil
+	}
+	for _, arn := range sortedKeys(missing) {
+		fmt.Printf("%s is missing:\n", arn)
+		for _, p := range missing[arn] {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+	return fmt.Errorf("%d roles are missing permissions", len(missing))
+}
+
+func (o *CreateIAMOptions) CreateIAM(ctx context.Context, client crclient.Client, logger logr.Logger) (*CreateIAMOutput, error) {
+	var err error
+	if err = o.ParseAdditionalTags(); err != nil {
+		return nil, err
+	}
+	// The bucket is only used to construct the issuer URL. Operators which do not publish the
+	// OIDC documents to S3, e.g. with the in-cluster storage provider, need an explicit issuer URL.
+	if o.IssuerURL == "" {
+		if o.OIDCStorageProviderS3BucketName == "" || o.OIDCStorageProviderS3Region == "" {
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-public", Name: "oidc-storage-provider-s3-config"},
+			}
+			if err := client.Get(ctx, crclient.ObjectKeyFromObject(cm), cm); err != nil {
+				return nil, fmt.Errorf("failed to discover OIDC bucket configuration, set --oidc-issuer-url if the OIDC documents are not stored in S3: failed to get the %s/%s configmap: %w", cm.Namespace, cm.Name, err)
+			}
+			// Set both, doesn't make sense to only get one from the configmap
+			o.OIDCStorageProviderS3BucketName = cm.Data["name"]
+			o.OIDCStorageProviderS3Region = cm.Data["region"]
+		}
+
+		var errs []error
+		if o.OIDCStorageProviderS3BucketName == "" {
+			errs = append(errs, errors.New("mandatory --oidc-storage-provider-s3-bucket-name could not be discovered from the cluster's ConfigMap in 'kube-public' and wasn't excplicitly passed either, nor was --oidc-issuer-url"))
+		}
+		if o.OIDCStorageProviderS3Region == "" {
+			errs = append(errs, errors.New("mandatory --oidc-storage-provider-s3-region could not be discovered from cluster's  ConfigMap in 'kube-public' and wasn't explicitly passed either, nor was --oidc-issuer-url"))
+		}
+		if err := utilerrors.NewAggregate(errs); err != nil {
+			return nil, err
+		}
+	}
+
+	awsSession, err := o.AWSCredentialsOpts.GetSession("cli-create-iam", o.CredentialsSecretData, o.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := awsutil.NewConfig()
+	iamClient := iam.New(awsSession, awsConfig)
+
+	results, err := o.CreateOIDCResources(iamClient, logger)
+	if err != nil {
+		return nil, err
+	}
+	profileName := DefaultProfileName(o.InfraID)
+	results.ProfileName = profileName
+	results.KMSKeyARN = o.KMSKeyARN
+	err = o.CreateWorkerInstanceProfile(iamClient, profileName, logger)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Created IAM profile", "name", profileName, "region", o.Region)
+
+	return results, nil
+}
+
+func (o *CreateIAMOptions) ParseAdditionalTags() error {
+	parsed, err := util.ParseAWSTags(o.AdditionalTags)
+	if err != nil {
+		return err
+	}
+	for k, v := range parsed {
+		o.additionalIAMTags = append(o.additionalIAMTags, &iam.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(v),
+		})
+	}
+	return nil
+}
+
+func oidcDiscoveryURL(bucketName, region, infraID string) string {
+	if bucketName == "" || region == "" || infraID == "" {
+		panic(fmt.Sprintf("bucket: %q, region: %q, infraID: %q", bucketName, region, infraID))
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucketName, region, infraID)
+}
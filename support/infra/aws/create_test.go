@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCreateInfraOptionsValidate(t *testing.T) {
+	tests := map[string]struct {
+		opts        CreateInfraOptions
+		expectError bool
+	}{
+		"new VPC": {
+			opts: CreateInfraOptions{Zones: []string{"us-east-1a"}, DualStack: true},
+		},
+		"existing VPC": {
+			opts: CreateInfraOptions{VPCID: "vpc-1", PrivateSubnetIDs: []string{"subnet-1"}, PublicSubnetIDs: []string{"subnet-2"}},
+		},
+		"subnets without VPC": {
+			opts:        CreateInfraOptions{PrivateSubnetIDs: []string{"subnet-1"}},
+			expectError: true,
+		},
+		"existing VPC without private subnets": {
+			opts:        CreateInfraOptions{VPCID: "vpc-1", PublicSubnetIDs: []string{"subnet-2"}},
+			expectError: true,
+		},
+		"existing VPC with zones": {
+			opts:        CreateInfraOptions{VPCID: "vpc-1", PrivateSubnetIDs: []string{"subnet-1"}, Zones: []string{"us-east-1a"}},
+			expectError: true,
+		},
+		"existing VPC with proxy": {
+			opts:        CreateInfraOptions{VPCID: "vpc-1", PrivateSubnetIDs: []string{"subnet-1"}, EnableProxy: true},
+			expectError: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			err := test.opts.Validate()
+			if test.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestIPv6SubnetCIDR(t *testing.T) {
+	tests := map[string]struct {
+		vpcCIDR     string
+		index       int
+		expected    string
+		expectError bool
+	}{
+		"first subnet": {
+			vpcCIDR:  "2600:1f18:abcd:ab00::/56",
+			index:    0,
+			expected: "2600:1f18:abcd:ab00::/64",
+		},
+		"private subnet": {
+			vpcCIDR:  "2600:1f18:abcd:ab00::/56",
+			index:    130,
+			expected: "2600:1f18:abcd:ab82::/64",
+		},
+		"index out of range": {
+			vpcCIDR:     "2600:1f18:abcd:ab00::/56",
+			index:       256,
+			expectError: true,
+		},
+		"not a /56": {
+			vpcCIDR:     "2600:1f18:abcd:ab00::/64",
+			expectError: true,
+		},
+		"IPv4": {
+			vpcCIDR:     "10.0.0.0/16",
+			expectError: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			cidr, err := ipv6SubnetCIDR(test.vpcCIDR, test.index)
+			if test.expectError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cidr).To(Equal(test.expected))
+		})
+	}
+}
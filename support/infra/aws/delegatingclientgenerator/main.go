@@ -10,7 +10,7 @@ import (
 	"text/template"
 	"unicode"
 
-	"github.com/openshift/hypershift/cmd/infra/aws"
+	"github.com/openshift/hypershift/support/infra/aws"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
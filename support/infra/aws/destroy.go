@@ -0,0 +1,937 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/go-logr/logr"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+type DestroyInfraOptions struct {
+	Region              string
+	InfraID             string
+	AWSCredentialsOpts  *DelegatedAWSCredentialOptions
+	Name                string
+	BaseDomain          string
+	BaseDomainPrefix    string
+	AwsInfraGracePeriod time.Duration
+	Log                 logr.Logger
+
+	CredentialsSecretData *util.CredentialsSecretData
+
+	AWSEbsCsiDriverControllerCredentialsFile    string
+	CloudControllerCredentialsFile              string
+	CloudNetworkConfigControllerCredentialsFile string
+	ControlPlaneOperatorCredentialsFile         string
+	NodePoolCredentialsFile                     string
+	OpenshiftImageRegistryCredentialsFile       string
+}
+
+type DelegatedAWSCredentialOptions struct {
+	AWSCredentialsOpts *awsutil.AWSCredentialsOptions
+
+	AWSEbsCsiDriverControllerCredentialsFile    string
+	CloudControllerCredentialsFile              string
+	CloudNetworkConfigControllerCredentialsFile string
+	ControlPlaneOperatorCredentialsFile         string
+	NodePoolCredentialsFile                     string
+	OpenshiftImageRegistryCredentialsFile       string
+}
+
+func DefaultDelegatedAWSCredentialOptions() *DelegatedAWSCredentialOptions {
+	return &DelegatedAWSCredentialOptions{
+		AWSCredentialsOpts: &awsutil.AWSCredentialsOptions{},
+	}
+}
+
+func (o *DelegatedAWSCredentialOptions) Validate() error {
+	allComponentCredentialsPresent := true
+	anyComponentCredentialsPresent := false
+	for _, credential := range []string{
+		o.AWSEbsCsiDriverControllerCredentialsFile,
+		o.CloudControllerCredentialsFile,
+		o.CloudNetworkConfigControllerCredentialsFile,
+		o.ControlPlaneOperatorCredentialsFile,
+		o.NodePoolCredentialsFile,
+		o.OpenshiftImageRegistryCredentialsFile,
+	} {
+		if credential == "" {
+			allComponentCredentialsPresent = false
+		} else {
+			anyComponentCredentialsPresent = true
+		}
+	}
+
+	// ensure that only one type of credential has been passed
+	globalCredentialsPresent := o.AWSCredentialsOpts.AWSCredentialsFile != "" || o.AWSCredentialsOpts.STSCredentialsFile != ""
+	if globalCredentialsPresent {
+		if !anyComponentCredentialsPresent {
+			return o.AWSCredentialsOpts.Validate()
+		} else {
+			return fmt.Errorf("cannot set any --aws-creds.component flags at the same time as other credentials")
+		}
+	} else {
+		if !allComponentCredentialsPresent {
+			return fmt.Errorf("either --aws-creds, --sts-creds, or all --aws-creds.component flags must be set")
+		}
+	}
+	return nil
+}
+
+func (o *DestroyInfraOptions) Validate() error {
+	return o.AWSCredentialsOpts.Validate()
+}
+
+func (o *DestroyInfraOptions) Run(ctx context.Context) error {
+	var infraCtx context.Context
+	var destroyInfraCtxCancel context.CancelFunc
+
+	if o.AwsInfraGracePeriod != 0 {
+		infraCtx, destroyInfraCtxCancel = context.WithTimeout(ctx, o.AwsInfraGracePeriod)
+		defer destroyInfraCtxCancel()
+
+		o.Log.Info(fmt.Sprintf("Infra destruction timeout set to %d s", int(o.AwsInfraGracePeriod.Seconds())))
+	} else {
+		infraCtx = ctx
+	}
+
+	return wait.PollImmediateUntil(5*time.Second, func() (bool, error) {
+		err := o.DestroyInfra(infraCtx)
+		if err != nil {
+			if !awsutil.IsErrorRetryable(err) {
+				return false, err
+			}
+			o.Log.Info("WARNING: error during destroy, will retry", "error", err.Error())
+			return false, nil
+		}
+		return true, nil
+	}, infraCtx.Done())
+}
+
+func (o *DestroyInfraOptions) DestroyInfra(ctx context.Context) error {
+	var ec2Client ec2iface.EC2API
+	var elbClient elbiface.ELBAPI
+	var elbv2Client elbv2iface.ELBV2API
+	var route53Client route53iface.Route53API
+	var s3Client s3iface.S3API
+	if o.AWSCredentialsOpts.AWSCredentialsOpts.AWSCredentialsFile != "" || o.AWSCredentialsOpts.AWSCredentialsOpts.STSCredentialsFile != "" || o.CredentialsSecretData != nil {
+		awsSession, err := o.AWSCredentialsOpts.AWSCredentialsOpts.GetSession("cli-destroy-infra", o.CredentialsSecretData, o.Region)
+		if err != nil {
+			return err
+		}
+		awsConfig := awsutil.NewConfig()
+		ec2Client = ec2.New(awsSession, awsConfig)
+		elbClient = elb.New(awsSession, awsConfig)
+		elbv2Client = elbv2.New(awsSession, awsConfig)
+		route53Client = route53.New(awsSession, awsutil.NewAWSRoute53Config())
+		s3Client = s3.New(awsSession, awsConfig)
+	} else {
+		delegatingClent, err := NewDelegatingClient(
+			o.AWSEbsCsiDriverControllerCredentialsFile,
+			o.CloudControllerCredentialsFile,
+			o.CloudNetworkConfigControllerCredentialsFile,
+			o.ControlPlaneOperatorCredentialsFile,
+			o.NodePoolCredentialsFile,
+			o.OpenshiftImageRegistryCredentialsFile,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create delegating client: %w", err)
+		}
+		ec2Client = delegatingClent.EC2API
+		elbClient = delegatingClent.ELBAPI
+		elbv2Client = delegatingClent.ELBV2API
+		route53Client = delegatingClent.Route53API
+		s3Client = delegatingClent.S3API
+	}
+	return o.destroyInfra(ctx, ec2Client, elbClient, elbv2Client, route53Client, s3Client)
+}
+
+func (o *DestroyInfraOptions) destroyInfra(ctx context.Context, ec2Client ec2iface.EC2API, elbClient elbiface.ELBAPI, elbv2Client elbv2iface.ELBV2API, route53Client route53iface.Route53API, s3Client s3iface.S3API) error {
+	errs := o.destroyInstances(ctx, ec2Client)
+	errs = append(errs, o.DestroyInternetGateways(ctx, ec2Client)...)
+	errs = append(errs, o.DestroyEgressOnlyInternetGateways(ctx, ec2Client)...)
+	errs = append(errs, o.DestroyDNS(ctx, route53Client)...)
+	errs = append(errs, o.DestroyS3Buckets(ctx, s3Client)...)
+	errs = append(errs, o.DestroyVPCEndpointServices(ctx, ec2Client)...)
+	errs = append(errs, o.DestroyVPCs(ctx, ec2Client, elbClient, elbv2Client, route53Client)...)
+	errs = append(errs, o.ReleaseSharedVPCs(ctx, ec2Client, elbClient, elbv2Client, route53Client)...)
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return err
+	}
+	errs = append(errs, o.DestroyEIPs(ctx, ec2Client)...)
+	errs = append(errs, o.DestroyDHCPOptions(ctx, ec2Client)...)
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (o *DestroyInfraOptions) DestroyS3Buckets(ctx context.Context, client s3iface.S3API) []error {
+	var errs []error
+	result, err := client.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		errs = append(errs, err)
+		return errs
+	}
+	for _, bucket := range result.Buckets {
+		if strings.HasPrefix(*bucket.Name, fmt.Sprintf("%s-image-registry-", o.InfraID)) {
+			if err := emptyBucket(ctx, client, *bucket.Name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to empty bucket %s: %w", *bucket.Name, err))
+				continue
+			}
+			_, err := client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{
+				Bucket: bucket.Name,
+			})
+			if err != nil {
+				if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchBucket {
+					o.Log.Info("S3 Bucket already deleted", "name", *bucket.Name)
+					err = nil
+				} else {
+					errs = append(errs, err)
+				}
+			} else {
+				o.Log.Info("Deleted S3 Bucket", "name", *bucket.Name)
+			}
+		}
+	}
+	return errs
+}
+
+func emptyBucket(ctx context.Context, client s3iface.S3API, name string) error {
+	iter := s3manager.NewDeleteListIterator(client, &s3.ListObjectsInput{
+		Bucket: aws.String(name),
+	})
+
+	if err := s3manager.NewBatchDeleteWithClient(client).Delete(ctx, iter); err != nil {
+		if strings.Contains(err.Error(), s3.ErrCodeNoSuchBucket) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (o *DestroyInfraOptions) DestroyV1ELBs(ctx context.Context, client elbiface.ELBAPI, vpcID *string) []error {
+	return o.destroyV1ELBs(ctx, client, vpcID, false)
+}
+
+// destroyV1ELBs deletes the classic load balancers of the VPC. With clusterOwned set, only the ones tagged as
+// owned by the cluster are deleted.
+func (o *DestroyInfraOptions) destroyV1ELBs(ctx context.Context, client elbiface.ELBAPI, vpcID *string, clusterOwned bool) []error {
+	var names []*string
+	err := client.DescribeLoadBalancersPagesWithContext(ctx,
+		&elb.DescribeLoadBalancersInput{},
+		func(out *elb.DescribeLoadBalancersOutput, _ bool) bool {
+			for _, lb := range out.LoadBalancerDescriptions {
+				if aws.StringValue(lb.VPCId) == aws.StringValue(vpcID) {
+					names = append(names, lb.LoadBalancerName)
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return []error{err}
+	}
+	if clusterOwned {
+		if names, err = o.clusterOwnedV1ELBs(ctx, client, names); err != nil {
+			return []error{err}
+		}
+	}
+
+	var errs []error
+	for _, name := range names {
+		_, err := client.DeleteLoadBalancerWithContext(ctx, &elb.DeleteLoadBalancerInput{
+			LoadBalancerName: name,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			o.Log.Info("Deleted ELB", "name", aws.StringValue(name))
+		}
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) clusterOwnedV1ELBs(ctx context.Context, client elbiface.ELBAPI, names []*string) ([]*string, error) {
+	var owned []*string
+	for start := 0; start < len(names); start += elbDescribeTagsLimit {
+		end := min(start+elbDescribeTagsLimit, len(names))
+		output, err := client.DescribeTagsWithContext(ctx, &elb.DescribeTagsInput{LoadBalancerNames: names[start:end]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe tags of classic load balancers: %w", err)
+		}
+		for _, description := range output.TagDescriptions {
+			for _, tag := range description.Tags {
+				if aws.StringValue(tag.Key) == clusterTag(o.InfraID) && aws.StringValue(tag.Value) == clusterTagValue {
+					owned = append(owned, description.LoadBalancerName)
+					break
+				}
+			}
+		}
+	}
+	return owned, nil
+}
+
+func (o *DestroyInfraOptions) DestroyV2ELBs(ctx context.Context, client elbv2iface.ELBV2API, vpcID *string) []error {
+	return o.destroyV2ELBs(ctx, client, vpcID, false)
+}
+
+// destroyV2ELBs deletes the load balancers and target groups of the VPC. With clusterOwned set, only the ones
+// tagged as owned by the cluster are deleted.
+func (o *DestroyInfraOptions) destroyV2ELBs(ctx context.Context, client elbv2iface.ELBV2API, vpcID *string, clusterOwned bool) []error {
+	var errs []error
+	var lbARNs []*string
+	err := client.DescribeLoadBalancersPagesWithContext(ctx,
+		&elbv2.DescribeLoadBalancersInput{},
+		func(out *elbv2.DescribeLoadBalancersOutput, _ bool) bool {
+			for _, lb := range out.LoadBalancers {
+				if aws.StringValue(lb.VpcId) == aws.StringValue(vpcID) {
+					lbARNs = append(lbARNs, lb.LoadBalancerArn)
+				}
+			}
+			return true
+		})
+	if err == nil && clusterOwned {
+		lbARNs, err = o.clusterOwnedV2Resources(ctx, client, lbARNs)
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, arn := range lbARNs {
+		_, err := client.DeleteLoadBalancerWithContext(ctx, &elbv2.DeleteLoadBalancerInput{
+			LoadBalancerArn: arn,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			o.Log.Info("Deleted ELB", "arn", aws.StringValue(arn))
+		}
+	}
+
+	var tgARNs []*string
+	err = client.DescribeTargetGroupsPagesWithContext(ctx,
+		&elbv2.DescribeTargetGroupsInput{},
+		func(out *elbv2.DescribeTargetGroupsOutput, _ bool) bool {
+			for _, tg := range out.TargetGroups {
+				if aws.StringValue(tg.VpcId) == aws.StringValue(vpcID) {
+					tgARNs = append(tgARNs, tg.TargetGroupArn)
+				}
+			}
+			return true
+		})
+	if err == nil && clusterOwned {
+		tgARNs, err = o.clusterOwnedV2Resources(ctx, client, tgARNs)
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, arn := range tgARNs {
+		_, err := client.DeleteTargetGroupWithContext(ctx, &elbv2.DeleteTargetGroupInput{
+			TargetGroupArn: arn,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			o.Log.Info("Deleted TargetGroup", "arn", aws.StringValue(arn))
+		}
+	}
+
+	return errs
+}
+
+func (o *DestroyInfraOptions) clusterOwnedV2Resources(ctx context.Context, client elbv2iface.ELBV2API, arns []*string) ([]*string, error) {
+	var owned []*string
+	for start := 0; start < len(arns); start += elbDescribeTagsLimit {
+		end := min(start+elbDescribeTagsLimit, len(arns))
+		output, err := client.DescribeTagsWithContext(ctx, &elbv2.DescribeTagsInput{ResourceArns: arns[start:end]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe tags of load balancer resources: %w", err)
+		}
+		for _, description := range output.TagDescriptions {
+			for _, tag := range description.Tags {
+				if aws.StringValue(tag.Key) == clusterTag(o.InfraID) && aws.StringValue(tag.Value) == clusterTagValue {
+					owned = append(owned, description.ResourceArn)
+					break
+				}
+			}
+		}
+	}
+	return owned, nil
+}
+
+func (o *DestroyInfraOptions) DestroyVPCEndpoints(ctx context.Context, client ec2iface.EC2API, vpcID *string) []error {
+	return o.destroyVPCEndpoints(ctx, client, vpcFilter(vpcID))
+}
+
+func (o *DestroyInfraOptions) destroyVPCEndpoints(ctx context.Context, client ec2iface.EC2API, filters []*ec2.Filter) []error {
+	var errs []error
+	deleteVPCEndpoints := func(out *ec2.DescribeVpcEndpointsOutput, _ bool) bool {
+		ids := make([]*string, 0, len(out.VpcEndpoints))
+		for _, ep := range out.VpcEndpoints {
+			ids = append(ids, ep.VpcEndpointId)
+		}
+		if len(ids) > 0 {
+			_, err := client.DeleteVpcEndpointsWithContext(ctx, &ec2.DeleteVpcEndpointsInput{
+				VpcEndpointIds: ids,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				epIDs := make([]string, 0, len(ids))
+				for _, id := range ids {
+					epIDs = append(epIDs, aws.StringValue(id))
+				}
+				o.Log.Info("Deleted VPC endpoints", "IDs", strings.Join(epIDs, " "))
+			}
+		}
+		return true
+	}
+	err := client.DescribeVpcEndpointsPagesWithContext(ctx,
+		&ec2.DescribeVpcEndpointsInput{Filters: filters},
+		deleteVPCEndpoints)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroyVPCEndpointServices(ctx context.Context, client ec2iface.EC2API) []error {
+	var errs []error
+	deleteVPCEndpointServices := func(desc *ec2.DescribeVpcEndpointServiceConfigurationsOutput, _ bool) bool {
+		var ids []string
+		for _, cfg := range desc.ServiceConfigurations {
+			ids = append(ids, *cfg.ServiceId)
+		}
+		if len(ids) < 1 {
+			return true
+		}
+
+		endpointConnections, err := client.DescribeVpcEndpointConnections(&ec2.DescribeVpcEndpointConnectionsInput{Filters: []*ec2.Filter{{Name: aws.String("service-id"), Values: aws.StringSlice(ids)}}})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list endpoint conncetions: %w", err))
+			return false
+		}
+		endpointConnectionsByServiceID := map[*string][]*string{}
+		for _, endpointConnection := range endpointConnections.VpcEndpointConnections {
+			endpointConnectionsByServiceID[endpointConnection.ServiceId] = append(endpointConnectionsByServiceID[endpointConnection.ServiceId], endpointConnection.VpcEndpointId)
+		}
+		for service, endpoints := range endpointConnectionsByServiceID {
+			if _, err := client.RejectVpcEndpointConnectionsWithContext(ctx, &ec2.RejectVpcEndpointConnectionsInput{ServiceId: service, VpcEndpointIds: endpoints}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to reject endpoint connections for service %s endpoints %v", aws.StringValue(service), aws.StringValueSlice(endpoints)))
+				return false
+			}
+			o.Log.Info("Deleted endpoint connections", "serviceID", aws.StringValue(service), "endpoints", fmt.Sprintf("%v", aws.StringValueSlice(endpoints)))
+		}
+
+		if _, err := client.DeleteVpcEndpointServiceConfigurationsWithContext(ctx, &ec2.DeleteVpcEndpointServiceConfigurationsInput{
+			ServiceIds: aws.StringSlice(ids),
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete vpc endpoint services with ids %v: %w", ids, err))
+		} else {
+			o.Log.Info("Deleted VPC endpoint services", "IDs", ids)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeVpcEndpointServiceConfigurationsPagesWithContext(ctx,
+		&ec2.DescribeVpcEndpointServiceConfigurationsInput{Filters: o.ec2Filters()},
+		deleteVPCEndpointServices,
+	); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroyRouteTables(ctx context.Context, client ec2iface.EC2API, vpcID *string) []error {
+	var errs []error
+	deleteRouteTables := func(out *ec2.DescribeRouteTablesOutput, _ bool) bool {
+		for _, routeTable := range out.RouteTables {
+			var routeErrs []error
+			for _, route := range routeTable.Routes {
+				if aws.StringValue(route.Origin) == "CreateRoute" {
+					_, err := client.DeleteRouteWithContext(ctx, &ec2.DeleteRouteInput{
+						RouteTableId:             routeTable.RouteTableId,
+						DestinationCidrBlock:     route.DestinationCidrBlock,
+						DestinationIpv6CidrBlock: route.DestinationIpv6CidrBlock,
+						DestinationPrefixListId:  route.DestinationPrefixListId,
+					})
+					if err != nil {
+						routeErrs = append(routeErrs, err)
+					} else {
+						o.Log.Info("Deleted route from route table", "table", aws.StringValue(routeTable.RouteTableId), "destination", aws.StringValue(route.DestinationCidrBlock))
+					}
+				}
+			}
+			if len(routeErrs) > 0 {
+				errs = append(errs, routeErrs...)
+				continue
+			}
+			hasMain := false
+			var assocErrs []error
+			for _, assoc := range routeTable.Associations {
+				if aws.BoolValue(assoc.Main) {
+					hasMain = true
+					continue
+				}
+				_, err := client.DisassociateRouteTableWithContext(ctx, &ec2.DisassociateRouteTableInput{
+					AssociationId: assoc.RouteTableAssociationId,
+				})
+				if err != nil {
+					assocErrs = append(assocErrs, err)
+				} else {
+					o.Log.Info("Removed route table association", "table", aws.StringValue(routeTable.RouteTableId), "association", aws.StringValue(assoc.RouteTableId))
+				}
+			}
+			if len(assocErrs) > 0 {
+				errs = append(errs, assocErrs...)
+				continue
+			}
+			if hasMain {
+				continue
+			}
+			_, err := client.DeleteRouteTableWithContext(ctx, &ec2.DeleteRouteTableInput{
+				RouteTableId: routeTable.RouteTableId,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				o.Log.Info("Deleted route table", "table", aws.StringValue(routeTable.RouteTableId))
+			}
+		}
+		return false
+	}
+
+	err := client.DescribeRouteTablesPagesWithContext(ctx,
+		&ec2.DescribeRouteTablesInput{Filters: vpcFilter(vpcID)},
+		deleteRouteTables)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroySecurityGroups(ctx context.Context, client ec2iface.EC2API, vpcID *string) []error {
+	return o.destroySecurityGroups(ctx, client, vpcFilter(vpcID))
+}
+
+func (o *DestroyInfraOptions) destroySecurityGroups(ctx context.Context, client ec2iface.EC2API, filters []*ec2.Filter) []error {
+	var errs []error
+	deleteSecurityGroups := func(out *ec2.DescribeSecurityGroupsOutput, _ bool) bool {
+		for _, sg := range out.SecurityGroups {
+			var permissionErrs []error
+			if len(sg.IpPermissions) > 0 {
+				_, err := client.RevokeSecurityGroupIngressWithContext(ctx, &ec2.RevokeSecurityGroupIngressInput{
+					GroupId:       sg.GroupId,
+					IpPermissions: sg.IpPermissions,
+				})
+				if err != nil {
+					permissionErrs = append(permissionErrs, err)
+				} else {
+					o.Log.Info("Revoked security group ingress permissions", "group", aws.StringValue(sg.GroupId))
+				}
+			}
+
+			if len(sg.IpPermissionsEgress) > 0 {
+				_, err := client.RevokeSecurityGroupEgressWithContext(ctx, &ec2.RevokeSecurityGroupEgressInput{
+					GroupId:       sg.GroupId,
+					IpPermissions: sg.IpPermissionsEgress,
+				})
+				if err != nil {
+					permissionErrs = append(permissionErrs, err)
+				} else {
+					o.Log.Info("Revoked security group egress permissions", "group", aws.StringValue(sg.GroupId))
+				}
+			}
+			if len(permissionErrs) > 0 {
+				errs = append(errs, permissionErrs...)
+				continue
+			}
+			if aws.StringValue(sg.GroupName) == "default" {
+				continue
+			}
+			_, err := client.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{
+				GroupId: sg.GroupId,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				o.Log.Info("Deleted security group", "group", aws.StringValue(sg.GroupId))
+			}
+		}
+
+		return true
+	}
+
+	err := client.DescribeSecurityGroupsPagesWithContext(ctx,
+		&ec2.DescribeSecurityGroupsInput{Filters: filters},
+		deleteSecurityGroups)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroyNATGateways(ctx context.Context, client ec2iface.EC2API, vpcID *string) []error {
+	var errs []error
+	deleteNATGateways := func(out *ec2.DescribeNatGatewaysOutput, _ bool) bool {
+		for _, natGateway := range out.NatGateways {
+			if natGateway.State != nil && *natGateway.State == "deleted" {
+				continue
+			}
+			if natGateway.State != nil && *natGateway.State == "deleting" {
+				errs = append(errs, fmt.Errorf("NAT gateway %s still deleting", aws.StringValue(natGateway.NatGatewayId)))
+				continue
+			}
+			_, err := client.DeleteNatGatewayWithContext(ctx, &ec2.DeleteNatGatewayInput{
+				NatGatewayId: natGateway.NatGatewayId,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				errs = append(errs, fmt.Errorf("deleting NAT gateway %s", aws.StringValue(natGateway.NatGatewayId)))
+			}
+		}
+		return true
+	}
+	err := client.DescribeNatGatewaysPagesWithContext(ctx,
+		&ec2.DescribeNatGatewaysInput{Filter: vpcFilter(vpcID)},
+		deleteNATGateways)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) destroyInstances(ctx context.Context, client ec2iface.EC2API) []error {
+	var errs []error
+	deleteInstances := func(out *ec2.DescribeInstancesOutput, _ bool) bool {
+		var instanceIDs []*string
+		for _, reservation := range out.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceIDs = append(instanceIDs, aws.String(*instance.InstanceId))
+			}
+		}
+		if len(instanceIDs) > 0 {
+			if _, err := client.TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: instanceIDs}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to terminate instances: %w", err))
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{Filters: o.ec2Filters()}, deleteInstances); err != nil {
+		errs = append(errs, fmt.Errorf("failed to describe instances: %w", err))
+	}
+
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroyInternetGateways(ctx context.Context, client ec2iface.EC2API) []error {
+	var errs []error
+	deleteInternetGateways := func(out *ec2.DescribeInternetGatewaysOutput, _ bool) bool {
+		for _, igw := range out.InternetGateways {
+			var detachErrs []error
+			for _, attachment := range igw.Attachments {
+				_, err := client.DetachInternetGatewayWithContext(ctx, &ec2.DetachInternetGatewayInput{
+					InternetGatewayId: igw.InternetGatewayId,
+					VpcId:             attachment.VpcId,
+				})
+				if err != nil {
+					detachErrs = append(detachErrs, err)
+				} else {
+					o.Log.Info("Detached internet gateway from VPC", "gateway id", aws.StringValue(igw.InternetGatewayId), "vpc", aws.StringValue(attachment.VpcId))
+				}
+			}
+			if len(detachErrs) > 0 {
+				errs = append(errs, detachErrs...)
+				continue
+			}
+			_, err := client.DeleteInternetGatewayWithContext(ctx, &ec2.DeleteInternetGatewayInput{
+				InternetGatewayId: igw.InternetGatewayId,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				o.Log.Info("Deleted internet gateway", "id", aws.StringValue(igw.InternetGatewayId))
+			}
+		}
+		return true
+	}
+
+	err := client.DescribeInternetGatewaysPagesWithContext(ctx,
+		&ec2.DescribeInternetGatewaysInput{Filters: o.ec2Filters()},
+		deleteInternetGateways)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return nil
+}
+
+func (o *DestroyInfraOptions) DestroyEgressOnlyInternetGateways(ctx context.Context, client ec2iface.EC2API) []error {
+	out, err := client.DescribeEgressOnlyInternetGatewaysWithContext(ctx, &ec2.DescribeEgressOnlyInternetGatewaysInput{
+		Filters: o.ec2Filters(),
+	})
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, eigw := range out.EgressOnlyInternetGateways {
+		_, err := client.DeleteEgressOnlyInternetGatewayWithContext(ctx, &ec2.DeleteEgressOnlyInternetGatewayInput{
+			EgressOnlyInternetGatewayId: eigw.EgressOnlyInternetGatewayId,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			o.Log.Info("Deleted egress only internet gateway", "id", aws.StringValue(eigw.EgressOnlyInternetGatewayId))
+		}
+	}
+	return errs
+}
+
+// destroyNetworkInterfaces deletes the detached network interfaces of the VPC tagged as owned by the cluster.
+func (o *DestroyInfraOptions) destroyNetworkInterfaces(ctx context.Context, client ec2iface.EC2API, vpcID *string) []error {
+	var errs []error
+	filters := append(vpcFilter(vpcID), o.ec2Filters()...)
+	filters = append(filters, &ec2.Filter{
+		Name:   aws.String("status"),
+		Values: []*string{aws.String(ec2.NetworkInterfaceStatusAvailable)},
+	})
+	deleteNetworkInterfaces := func(out *ec2.DescribeNetworkInterfacesOutput, _ bool) bool {
+		for _, eni := range out.NetworkInterfaces {
+			_, err := client.DeleteNetworkInterfaceWithContext(ctx, &ec2.DeleteNetworkInterfaceInput{
+				NetworkInterfaceId: eni.NetworkInterfaceId,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				o.Log.Info("Deleted network interface", "id", aws.StringValue(eni.NetworkInterfaceId))
+			}
+		}
+		return true
+	}
+	err := client.DescribeNetworkInterfacesPagesWithContext(ctx,
+		&ec2.DescribeNetworkInterfacesInput{Filters: filters},
+		deleteNetworkInterfaces)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroySubnets(ctx context.Context, client ec2iface.EC2API, vpcID *string) []error {
+	var errs []error
+	deleteSubnets := func(out *ec2.DescribeSubnetsOutput, _ bool) bool {
+		for _, subnet := range out.Subnets {
+			_, err := client.DeleteSubnetWithContext(ctx, &ec2.DeleteSubnetInput{
+				SubnetId: subnet.SubnetId,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				o.Log.Info("Deleted subnet", "id", aws.StringValue(subnet.SubnetId))
+			}
+		}
+		return true
+	}
+	err := client.DescribeSubnetsPagesWithContext(ctx,
+		&ec2.DescribeSubnetsInput{Filters: vpcFilter(vpcID)},
+		deleteSubnets)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroyVPCs(ctx context.Context, ec2client ec2iface.EC2API, elbclient elbiface.ELBAPI, elbv2client elbv2iface.ELBV2API, route53client route53iface.Route53API) []error {
+	var errs []error
+	deleteVPC := func(out *ec2.DescribeVpcsOutput, _ bool) bool {
+		for _, vpc := range out.Vpcs {
+			var childErrs []error
+			childErrs = append(childErrs, o.DestroyV1ELBs(ctx, elbclient, vpc.VpcId)...)
+			childErrs = append(childErrs, o.DestroyV2ELBs(ctx, elbv2client, vpc.VpcId)...)
+			childErrs = append(childErrs, o.DestroyVPCEndpoints(ctx, ec2client, vpc.VpcId)...)
+			childErrs = append(childErrs, o.DestroyPrivateZones(ctx, route53client, vpc.VpcId)...)
+			childErrs = append(childErrs, o.DestroyRouteTables(ctx, ec2client, vpc.VpcId)...)
+			childErrs = append(childErrs, o.DestroyNATGateways(ctx, ec2client, vpc.VpcId)...)
+			if len(childErrs) > 0 {
+				errs = append(errs, childErrs...)
+				continue
+			}
+			childErrs = append(childErrs, o.DestroySecurityGroups(ctx, ec2client, vpc.VpcId)...)
+			childErrs = append(childErrs, o.DestroySubnets(ctx, ec2client, vpc.VpcId)...)
+			if len(childErrs) > 0 {
+				errs = append(errs, childErrs...)
+				continue
+			}
+			_, err := ec2client.DeleteVpcWithContext(ctx, &ec2.DeleteVpcInput{
+				VpcId: vpc.VpcId,
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete vpc with id %s: %w", *vpc.VpcId, err))
+			} else {
+				o.Log.Info("Deleted VPC", "id", aws.StringValue(vpc.VpcId))
+			}
+		}
+		return true
+	}
+	err := ec2client.DescribeVpcsPagesWithContext(ctx,
+		&ec2.DescribeVpcsInput{Filters: o.ec2Filters()},
+		deleteVPC)
+
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// ReleaseSharedVPCs cleans up after the cluster in existing VPCs that were adopted with `create infra aws --vpc-id`.
+// Only the resources owned by the cluster are deleted, including the load balancers and network interfaces created
+// by the cloud provider of the cluster. The VPCs and their subnets are kept and the tag that shares them with the
+// cluster is removed.
+func (o *DestroyInfraOptions) ReleaseSharedVPCs(ctx context.Context, ec2client ec2iface.EC2API, elbclient elbiface.ELBAPI, elbv2client elbv2iface.ELBV2API, route53client route53iface.Route53API) []error {
+	var errs []error
+	releaseVPC := func(out *ec2.DescribeVpcsOutput, _ bool) bool {
+		for _, vpc := range out.Vpcs {
+			var childErrs []error
+			childErrs = append(childErrs, o.destroyV1ELBs(ctx, elbclient, vpc.VpcId, true)...)
+			childErrs = append(childErrs, o.destroyV2ELBs(ctx, elbv2client, vpc.VpcId, true)...)
+			childErrs = append(childErrs, o.destroyVPCEndpoints(ctx, ec2client, append(vpcFilter(vpc.VpcId), o.ec2Filters()...))...)
+			childErrs = append(childErrs, o.destroyNetworkInterfaces(ctx, ec2client, vpc.VpcId)...)
+			childErrs = append(childErrs, o.DestroyClusterPrivateZones(ctx, route53client, vpc.VpcId)...)
+			if len(childErrs) > 0 {
+				errs = append(errs, childErrs...)
+				continue
+			}
+			childErrs = append(childErrs, o.destroySecurityGroups(ctx, ec2client, append(vpcFilter(vpc.VpcId), o.ec2Filters()...))...)
+			if len(childErrs) > 0 {
+				errs = append(errs, childErrs...)
+				continue
+			}
+
+			resources := []*string{vpc.VpcId}
+			subnets, err := ec2client.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{
+				Filters: append(vpcFilter(vpc.VpcId), o.sharedEC2Filters()...),
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to list shared subnets of vpc %s: %w", aws.StringValue(vpc.VpcId), err))
+				continue
+			}
+			for _, subnet := range subnets.Subnets {
+				resources = append(resources, subnet.SubnetId)
+			}
+			_, err = ec2client.DeleteTagsWithContext(ctx, &ec2.DeleteTagsInput{
+				Resources: resources,
+				Tags:      []*ec2.Tag{{Key: aws.String(clusterTag(o.InfraID))}},
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to untag vpc %s: %w", aws.StringValue(vpc.VpcId), err))
+			} else {
+				o.Log.Info("Released shared VPC", "id", aws.StringValue(vpc.VpcId), "subnets", len(subnets.Subnets))
+			}
+		}
+		return true
+	}
+	err := ec2client.DescribeVpcsPagesWithContext(ctx,
+		&ec2.DescribeVpcsInput{Filters: o.sharedEC2Filters()},
+		releaseVPC)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroyDHCPOptions(ctx context.Context, client ec2iface.EC2API) []error {
+	var errs []error
+	deleteDHCPOptions := func(out *ec2.DescribeDhcpOptionsOutput, _ bool) bool {
+		for _, dhcpOpt := range out.DhcpOptions {
+			_, err := client.DeleteDhcpOptionsWithContext(ctx, &ec2.DeleteDhcpOptionsInput{
+				DhcpOptionsId: dhcpOpt.DhcpOptionsId,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				o.Log.Info("Deleted DHCP options", "id", aws.StringValue(dhcpOpt.DhcpOptionsId))
+			}
+		}
+		return true
+	}
+	err := client.DescribeDhcpOptionsPagesWithContext(ctx,
+		&ec2.DescribeDhcpOptionsInput{Filters: o.ec2Filters()},
+		deleteDHCPOptions)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) DestroyEIPs(ctx context.Context, client ec2iface.EC2API) []error {
+	var errs []error
+	out, err := client.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{
+		Filters: o.ec2Filters(),
+	})
+	if err != nil {
+		errs = append(errs, err)
+		return errs
+	}
+
+	for _, addr := range out.Addresses {
+		_, err := client.ReleaseAddressWithContext(ctx, &ec2.ReleaseAddressInput{
+			AllocationId: addr.AllocationId,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			o.Log.Info("Deleted EIP", "id", aws.StringValue(addr.AllocationId))
+		}
+	}
+	return errs
+}
+
+func (o *DestroyInfraOptions) ec2Filters() []*ec2.Filter {
+	return []*ec2.Filter{
+		{
+			Name:   aws.String(fmt.Sprintf("tag:%s", clusterTag(o.InfraID))),
+			Values: []*string{aws.String(clusterTagValue)},
+		},
+	}
+}
+
+func (o *DestroyInfraOptions) sharedEC2Filters() []*ec2.Filter {
+	return []*ec2.Filter{
+		{
+			Name:   aws.String(fmt.Sprintf("tag:%s", clusterTag(o.InfraID))),
+			Values: []*string{aws.String(sharedClusterTagValue)},
+		},
+	}
+}
+
+func vpcFilter(vpcID *string) []*ec2.Filter {
+	return []*ec2.Filter{
+		{
+			Name:   aws.String("vpc-id"),
+			Values: []*string{vpcID},
+		},
+	}
+}
@@ -0,0 +1,212 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+type DestroyIAMOptions struct {
+	Region             string
+	AWSCredentialsOpts awsutil.AWSCredentialsOptions
+	InfraID            string
+	Log                logr.Logger
+
+	CredentialsSecretData *util.CredentialsSecretData
+}
+
+func (o *DestroyIAMOptions) Run(ctx context.Context) error {
+	return wait.PollImmediateUntil(5*time.Second, func() (bool, error) {
+		err := o.DestroyIAM(ctx)
+		if err != nil {
+			if !awsutil.IsErrorRetryable(err) {
+				return false, err
+			}
+			o.Log.Info("WARNING: error during destroy, will retry", "error", err.Error())
+			return false, nil
+		}
+		return true, nil
+	}, ctx.Done())
+}
+
+func (o *DestroyIAMOptions) DestroyIAM(ctx context.Context) error {
+	awsSession, err := o.AWSCredentialsOpts.GetSession("cli-destroy-iam", o.CredentialsSecretData, o.Region)
+	if err != nil {
+		return err
+	}
+	awsConfig := awsutil.NewConfig()
+	iamClient := iam.New(awsSession, awsConfig)
+
+	err = o.DestroyOIDCResources(ctx, iamClient)
+	if err != nil {
+		return err
+	}
+	err = o.DestroyWorkerInstanceProfile(iamClient)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *DestroyIAMOptions) DestroyOIDCResources(ctx context.Context, iamClient iamiface.IAMAPI) error {
+	oidcProviderList, err := iamClient.ListOpenIDConnectProviders(&iam.ListOpenIDConnectProvidersInput{})
+	if err != nil {
+		return err
+	}
+
+	for _, provider := range oidcProviderList.OpenIDConnectProviderList {
+		if strings.Contains(*provider.Arn, o.InfraID) {
+			_, err := iamClient.DeleteOpenIDConnectProvider(&iam.DeleteOpenIDConnectProviderInput{
+				OpenIDConnectProviderArn: provider.Arn,
+			})
+			if err != nil {
+				if aerr, ok := err.(awserr.Error); ok {
+					if aerr.Code() != iam.ErrCodeNoSuchEntityException {
+						o.Log.Error(aerr, "Error deleting OIDC provider", "providerARN", provider.Arn)
+						return aerr
+					}
+				} else {
+					o.Log.Error(err, "Error deleting OIDC provider", "providerARN", provider.Arn)
+					return err
+				}
+			} else {
+				o.Log.Info("Deleted OIDC provider", "providerARN", provider.Arn)
+			}
+			break
+		}
+	}
+	if err = o.DestroyOIDCRole(iamClient, "openshift-ingress"); err != nil {
+		return err
+	}
+	if err = o.DestroyOIDCRole(iamClient, "openshift-image-registry"); err != nil {
+		return err
+	}
+	if err = o.DestroyOIDCRole(iamClient, "aws-ebs-csi-driver-controller"); err != nil {
+		return err
+	}
+	if err = o.DestroyOIDCRole(iamClient, "cloud-controller"); err != nil {
+		return err
+	}
+	if err = o.DestroyOIDCRole(iamClient, "node-pool"); err != nil {
+		return err
+	}
+	if err = o.DestroyOIDCRole(iamClient, "control-plane-operator"); err != nil {
+		return err
+	}
+	if err := o.DestroyOIDCRole(iamClient, "cloud-network-config-controller"); err != nil {
+		return err
+	}
+	if err := o.DestroyOIDCRole(iamClient, "kms-provider"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateOIDCRole create an IAM Role with a trust policy for the OIDC provider
+func (o *DestroyIAMOptions) DestroyOIDCRole(client iamiface.IAMAPI, name string) error {
+	roleName := fmt.Sprintf("%s-%s", o.InfraID, name)
+	_, err := client.DeleteRolePolicy(&iam.DeleteRolePolicyInput{
+		PolicyName: aws.String(roleName),
+		RoleName:   aws.String(roleName),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			if aerr.Code() != iam.ErrCodeNoSuchEntityException {
+				o.Log.Error(aerr, "Error deleting role policy", "role", roleName)
+				return aerr
+			}
+		} else {
+			o.Log.Error(err, "Error deleting role policy", "role", roleName)
+			return err
+		}
+	} else {
+		o.Log.Info("Deleted role policy", "role", roleName)
+	}
+
+	_, err = client.DeleteRole(&iam.DeleteRoleInput{
+		RoleName: aws.String(roleName),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			if aerr.Code() != iam.ErrCodeNoSuchEntityException {
+				o.Log.Error(aerr, "Error deleting role", "role", roleName)
+				return aerr
+			}
+		} else {
+			o.Log.Error(err, "Error deleting role", "role", roleName)
+			return err
+		}
+	} else {
+		o.Log.Info("Deleted role", "role", roleName)
+	}
+	return nil
+}
+
+func (o *DestroyIAMOptions) DestroyWorkerInstanceProfile(client iamiface.IAMAPI) error {
+	profileName := DefaultProfileName(o.InfraID)
+	instanceProfile, err := existingInstanceProfile(client, profileName)
+	if err != nil {
+		return fmt.Errorf("cannot check for existing instance profile: %w", err)
+	}
+	if instanceProfile != nil {
+		for _, role := range instanceProfile.Roles {
+			_, err := client.RemoveRoleFromInstanceProfile(&iam.RemoveRoleFromInstanceProfileInput{
+				InstanceProfileName: aws.String(profileName),
+				RoleName:            role.RoleName,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot remove role %s from instance profile %s: %w", aws.StringValue(role.RoleName), profileName, err)
+			}
+			o.Log.Info("Removed role from instance profile", "profile", profileName, "role", aws.StringValue(role.RoleName))
+		}
+		_, err := client.DeleteInstanceProfile(&iam.DeleteInstanceProfileInput{
+			InstanceProfileName: aws.String(profileName),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot delete instance profile %s: %w", profileName, err)
+		}
+		o.Log.Info("Deleted instance profile", "profile", profileName)
+	}
+	roleName := fmt.Sprintf("%s-role", profileName)
+	policyName := fmt.Sprintf("%s-policy", profileName)
+	role, err := existingRole(client, roleName)
+	if err != nil {
+		return fmt.Errorf("cannot check for existing role: %w", err)
+	}
+	if role != nil {
+		hasPolicy, err := existingRolePolicy(client, roleName, policyName)
+		if err != nil {
+			return fmt.Errorf("cannot check for existing role policy: %w", err)
+		}
+		if hasPolicy {
+			_, err := client.DeleteRolePolicy(&iam.DeleteRolePolicyInput{
+				PolicyName: aws.String(policyName),
+				RoleName:   aws.String(roleName),
+			})
+			if err != nil {
+				return fmt.Errorf("cannot delete role policy %s from role %s: %w", policyName, roleName, err)
+			}
+			o.Log.Info("Deleted role policy", "role", roleName, "policy", policyName)
+		}
+		_, err = client.DeleteRole(&iam.DeleteRoleInput{
+			RoleName: aws.String(roleName),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot delete role %s: %w", roleName, err)
+		}
+		o.Log.Info("Deleted role", "role", roleName)
+	}
+	return nil
+}
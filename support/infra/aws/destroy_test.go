@@ -0,0 +1,233 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// sharedVPCEC2Client fakes the EC2 API of an adopted VPC and records the deletions in order.
+type sharedVPCEC2Client struct {
+	ec2iface.EC2API
+	vpc               *ec2.Vpc
+	networkInterfaces []*ec2.NetworkInterface
+	securityGroups    []*ec2.SecurityGroup
+	deleted           *[]string
+}
+
+func (f *sharedVPCEC2Client) DescribeVpcsPagesWithContext(_ aws.Context, _ *ec2.DescribeVpcsInput, fn func(*ec2.DescribeVpcsOutput, bool) bool, _ ...request.Option) error {
+	fn(&ec2.DescribeVpcsOutput{Vpcs: []*ec2.Vpc{f.vpc}}, true)
+	return nil
+}
+
+func (f *sharedVPCEC2Client) DescribeVpcEndpointsPagesWithContext(_ aws.Context, _ *ec2.DescribeVpcEndpointsInput, fn func(*ec2.DescribeVpcEndpointsOutput, bool) bool, _ ...request.Option) error {
+	fn(&ec2.DescribeVpcEndpointsOutput{}, true)
+	return nil
+}
+
+func (f *sharedVPCEC2Client) DescribeNetworkInterfacesPagesWithContext(_ aws.Context, input *ec2.DescribeNetworkInterfacesInput, fn func(*ec2.DescribeNetworkInterfacesOutput, bool) bool, _ ...request.Option) error {
+	out := &ec2.DescribeNetworkInterfacesOutput{}
+	for _, eni := range f.networkInterfaces {
+		if matchesFilters(input.Filters, aws.StringValue(eni.VpcId), aws.StringValue(eni.Status), eni.TagSet) {
+			out.NetworkInterfaces = append(out.NetworkInterfaces, eni)
+		}
+	}
+	fn(out, true)
+	return nil
+}
+
+func (f *sharedVPCEC2Client) DeleteNetworkInterfaceWithContext(_ aws.Context, input *ec2.DeleteNetworkInterfaceInput, _ ...request.Option) (*ec2.DeleteNetworkInterfaceOutput, error) {
+	*f.deleted = append(*f.deleted, aws.StringValue(input.NetworkInterfaceId))
+	return &ec2.DeleteNetworkInterfaceOutput{}, nil
+}
+
+func (f *sharedVPCEC2Client) DescribeSecurityGroupsPagesWithContext(_ aws.Context, input *ec2.DescribeSecurityGroupsInput, fn func(*ec2.DescribeSecurityGroupsOutput, bool) bool, _ ...request.Option) error {
+	out := &ec2.DescribeSecurityGroupsOutput{}
+	for _, sg := range f.securityGroups {
+		if matchesFilters(input.Filters, aws.StringValue(sg.VpcId), "", sg.Tags) {
+			out.SecurityGroups = append(out.SecurityGroups, sg)
+		}
+	}
+	fn(out, true)
+	return nil
+}
+
+func (f *sharedVPCEC2Client) DeleteSecurityGroupWithContext(_ aws.Context, input *ec2.DeleteSecurityGroupInput, _ ...request.Option) (*ec2.DeleteSecurityGroupOutput, error) {
+	*f.deleted = append(*f.deleted, aws.StringValue(input.GroupId))
+	return &ec2.DeleteSecurityGroupOutput{}, nil
+}
+
+func (f *sharedVPCEC2Client) DescribeSubnetsWithContext(_ aws.Context, _ *ec2.DescribeSubnetsInput, _ ...request.Option) (*ec2.DescribeSubnetsOutput, error) {
+	return &ec2.DescribeSubnetsOutput{}, nil
+}
+
+func (f *sharedVPCEC2Client) DeleteTagsWithContext(_ aws.Context, input *ec2.DeleteTagsInput, _ ...request.Option) (*ec2.DeleteTagsOutput, error) {
+	for _, resource := range input.Resources {
+		*f.deleted = append(*f.deleted, "tag:"+aws.StringValue(resource))
+	}
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+// matchesFilters supports the vpc-id, status and tag filters used when releasing shared VPCs.
+func matchesFilters(filters []*ec2.Filter, vpcID, status string, tags []*ec2.Tag) bool {
+	for _, filter := range filters {
+		var value string
+		switch name := aws.StringValue(filter.Name); name {
+		case "vpc-id":
+			value = vpcID
+		case "status":
+			value = status
+		default:
+			for _, tag := range tags {
+				if "tag:"+aws.StringValue(tag.Key) == name {
+					value = aws.StringValue(tag.Value)
+				}
+			}
+		}
+		if !sets.New(aws.StringValueSlice(filter.Values)...).Has(value) {
+			return false
+		}
+	}
+	return true
+}
+
+type sharedVPCELBClient struct {
+	elbiface.ELBAPI
+	loadBalancers []*elb.LoadBalancerDescription
+	tags          map[string][]*elb.Tag
+	deleted       *[]string
+}
+
+func (f *sharedVPCELBClient) DescribeLoadBalancersPagesWithContext(_ aws.Context, _ *elb.DescribeLoadBalancersInput, fn func(*elb.DescribeLoadBalancersOutput, bool) bool, _ ...request.Option) error {
+	fn(&elb.DescribeLoadBalancersOutput{LoadBalancerDescriptions: f.loadBalancers}, true)
+	return nil
+}
+
+func (f *sharedVPCELBClient) DescribeTagsWithContext(_ aws.Context, input *elb.DescribeTagsInput, _ ...request.Option) (*elb.DescribeTagsOutput, error) {
+	out := &elb.DescribeTagsOutput{}
+	for _, name := range input.LoadBalancerNames {
+		out.TagDescriptions = append(out.TagDescriptions, &elb.TagDescription{LoadBalancerName: name, Tags: f.tags[aws.StringValue(name)]})
+	}
+	return out, nil
+}
+
+func (f *sharedVPCELBClient) DeleteLoadBalancerWithContext(_ aws.Context, input *elb.DeleteLoadBalancerInput, _ ...request.Option) (*elb.DeleteLoadBalancerOutput, error) {
+	*f.deleted = append(*f.deleted, aws.StringValue(input.LoadBalancerName))
+	return &elb.DeleteLoadBalancerOutput{}, nil
+}
+
+type sharedVPCELBV2Client struct {
+	elbv2iface.ELBV2API
+	loadBalancers []*elbv2.LoadBalancer
+	targetGroups  []*elbv2.TargetGroup
+	tags          map[string][]*elbv2.Tag
+	deleted       *[]string
+}
+
+func (f *sharedVPCELBV2Client) DescribeLoadBalancersPagesWithContext(_ aws.Context, _ *elbv2.DescribeLoadBalancersInput, fn func(*elbv2.DescribeLoadBalancersOutput, bool) bool, _ ...request.Option) error {
+	fn(&elbv2.DescribeLoadBalancersOutput{LoadBalancers: f.loadBalancers}, true)
+	return nil
+}
+
+func (f *sharedVPCELBV2Client) DescribeTargetGroupsPagesWithContext(_ aws.Context, _ *elbv2.DescribeTargetGroupsInput, fn func(*elbv2.DescribeTargetGroupsOutput, bool) bool, _ ...request.Option) error {
+	fn(&elbv2.DescribeTargetGroupsOutput{TargetGroups: f.targetGroups}, true)
+	return nil
+}
+
+func (f *sharedVPCELBV2Client) DescribeTagsWithContext(_ aws.Context, input *elbv2.DescribeTagsInput, _ ...request.Option) (*elbv2.DescribeTagsOutput, error) {
+	out := &elbv2.DescribeTagsOutput{}
+	for _, arn := range input.ResourceArns {
+		out.TagDescriptions = append(out.TagDescriptions, &elbv2.TagDescription{ResourceArn: arn, Tags: f.tags[aws.StringValue(arn)]})
+	}
+	return out, nil
+}
+
+func (f *sharedVPCELBV2Client) DeleteLoadBalancerWithContext(_ aws.Context, input *elbv2.DeleteLoadBalancerInput, _ ...request.Option) (*elbv2.DeleteLoadBalancerOutput, error) {
+	*f.deleted = append(*f.deleted, aws.StringValue(input.LoadBalancerArn))
+	return &elbv2.DeleteLoadBalancerOutput{}, nil
+}
+
+func (f *sharedVPCELBV2Client) DeleteTargetGroupWithContext(_ aws.Context, input *elbv2.DeleteTargetGroupInput, _ ...request.Option) (*elbv2.DeleteTargetGroupOutput, error) {
+	*f.deleted = append(*f.deleted, aws.StringValue(input.TargetGroupArn))
+	return &elbv2.DeleteTargetGroupOutput{}, nil
+}
+
+func TestReleaseSharedVPCs(t *testing.T) {
+	g := NewWithT(t)
+
+	const infraID = "cluster"
+	owned := func(id string) *ec2.Tag {
+		return &ec2.Tag{Key: aws.String(clusterTag(id)), Value: aws.String(clusterTagValue)}
+	}
+	elbOwned := []*elb.Tag{{Key: aws.String(clusterTag(infraID)), Value: aws.String(clusterTagValue)}}
+	elbv2Owned := []*elbv2.Tag{{Key: aws.String(clusterTag(infraID)), Value: aws.String(clusterTagValue)}}
+	elbv2OtherCluster := []*elbv2.Tag{{Key: aws.String(clusterTag("other")), Value: aws.String(clusterTagValue)}}
+
+	var deleted []string
+	ec2Client := &sharedVPCEC2Client{
+		vpc: &ec2.Vpc{VpcId: aws.String("vpc-shared")},
+		networkInterfaces: []*ec2.NetworkInterface{
+			{NetworkInterfaceId: aws.String("eni-owned"), VpcId: aws.String("vpc-shared"), Status: aws.String(ec2.NetworkInterfaceStatusAvailable), TagSet: []*ec2.Tag{owned(infraID)}},
+			{NetworkInterfaceId: aws.String("eni-attached"), VpcId: aws.String("vpc-shared"), Status: aws.String(ec2.NetworkInterfaceStatusInUse), TagSet: []*ec2.Tag{owned(infraID)}},
+			{NetworkInterfaceId: aws.String("eni-other-cluster"), VpcId: aws.String("vpc-shared"), Status: aws.String(ec2.NetworkInterfaceStatusAvailable), TagSet: []*ec2.Tag{owned("other")}},
+		},
+		securityGroups: []*ec2.SecurityGroup{
+			{GroupId: aws.String("sg-owned"), GroupName: aws.String("cluster-default-sg"), VpcId: aws.String("vpc-shared"), Tags: []*ec2.Tag{owned(infraID)}},
+			{GroupId: aws.String("sg-other-cluster"), GroupName: aws.String("other-default-sg"), VpcId: aws.String("vpc-shared"), Tags: []*ec2.Tag{owned("other")}},
+		},
+		deleted: &deleted,
+	}
+	elbClient := &sharedVPCELBClient{
+		loadBalancers: []*elb.LoadBalancerDescription{
+			{LoadBalancerName: aws.String("classic-owned"), VPCId: aws.String("vpc-shared")},
+			{LoadBalancerName: aws.String("classic-unowned"), VPCId: aws.String("vpc-shared")},
+			{LoadBalancerName: aws.String("classic-other-vpc"), VPCId: aws.String("vpc-other")},
+		},
+		tags: map[string][]*elb.Tag{
+			"classic-owned":     elbOwned,
+			"classic-other-vpc": elbOwned,
+		},
+		deleted: &deleted,
+	}
+	elbv2Client := &sharedVPCELBV2Client{
+		loadBalancers: []*elbv2.LoadBalancer{
+			{LoadBalancerArn: aws.String("arn:nlb-owned"), VpcId: aws.String("vpc-shared")},
+			{LoadBalancerArn: aws.String("arn:nlb-other-cluster"), VpcId: aws.String("vpc-shared")},
+		},
+		targetGroups: []*elbv2.TargetGroup{
+			{TargetGroupArn: aws.String("arn:tg-owned"), VpcId: aws.String("vpc-shared")},
+			{TargetGroupArn: aws.String("arn:tg-other-cluster"), VpcId: aws.String("vpc-shared")},
+		},
+		tags: map[string][]*elbv2.Tag{
+			"arn:nlb-owned":         elbv2Owned,
+			"arn:nlb-other-cluster": elbv2OtherCluster,
+			"arn:tg-owned":          elbv2Owned,
+			"arn:tg-other-cluster":  elbv2OtherCluster,
+		},
+		deleted: &deleted,
+	}
+	o := &DestroyInfraOptions{InfraID: infraID, Name: "cluster", BaseDomain: "example.com", Region: "us-east-1", Log: logr.Discard()}
+
+	errs := o.ReleaseSharedVPCs(context.Background(), ec2Client, elbClient, elbv2Client, &fakeRoute53Client{})
+	g.Expect(errs).To(BeEmpty())
+	g.Expect(deleted).To(Equal([]string{
+		"classic-owned",
+		"arn:nlb-owned",
+		"arn:tg-owned",
+		"eni-owned",
+		"sg-owned",
+		"tag:vpc-shared",
+	}))
+}
@@ -2,6 +2,7 @@ package aws
 
 import (
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/openshift/hypershift/cmd/util"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 )
@@ -105,6 +107,173 @@ func (o *CreateInfraOptions) existingVPC(client ec2iface.EC2API, vpcName string)
 	return vpcID, nil
 }
 
+// adoptVPC validates an existing VPC and its subnets and tags them as shared with the cluster, so that other
+// clusters and the owner of the VPC can keep using them. Gateways, route tables and endpoints of the VPC are
+// left as they are.
+func (o *CreateInfraOptions) adoptVPC(l logr.Logger, client ec2iface.EC2API, result *CreateInfraOutput) error {
+	vpcResult, err := client.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: []*string{aws.String(o.VPCID)}})
+	if err != nil {
+		return fmt.Errorf("cannot describe vpc %s: %w", o.VPCID, err)
+	}
+	if len(vpcResult.Vpcs) == 0 {
+		return fmt.Errorf("vpc %s not found", o.VPCID)
+	}
+	vpc := vpcResult.Vpcs[0]
+	result.VPCID = o.VPCID
+	result.MachineCIDR = aws.StringValue(vpc.CidrBlock)
+	l.Info("Using existing VPC", "id", o.VPCID, "cidr", result.MachineCIDR)
+
+	for _, attribute := range []string{ec2.VpcAttributeNameEnableDnsSupport, ec2.VpcAttributeNameEnableDnsHostnames} {
+		attributeResult, err := client.DescribeVpcAttribute(&ec2.DescribeVpcAttributeInput{
+			VpcId:     aws.String(o.VPCID),
+			Attribute: aws.String(attribute),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot describe attribute %s of vpc %s: %w", attribute, o.VPCID, err)
+		}
+		enabled := attributeResult.EnableDnsSupport
+		if attribute == ec2.VpcAttributeNameEnableDnsHostnames {
+			enabled = attributeResult.EnableDnsHostnames
+		}
+		if enabled == nil || !aws.BoolValue(enabled.Value) {
+			return fmt.Errorf("vpc %s must have %s enabled", o.VPCID, attribute)
+		}
+	}
+	if o.DualStack {
+		result.MachineCIDRv6, err = vpcIPv6CIDR(client, o.VPCID)
+		if err != nil {
+			return err
+		}
+		if len(result.MachineCIDRv6) == 0 {
+			return fmt.Errorf("vpc %s has no IPv6 CIDR, it is required with --dual-stack", o.VPCID)
+		}
+	}
+
+	subnetIDs := append(append([]string{}, o.PrivateSubnetIDs...), o.PublicSubnetIDs...)
+	subnetResult, err := client.DescribeSubnets(&ec2.DescribeSubnetsInput{SubnetIds: aws.StringSlice(subnetIDs)})
+	if err != nil {
+		return fmt.Errorf("cannot describe subnets: %w", err)
+	}
+	subnets := map[string]*ec2.Subnet{}
+	for _, subnet := range subnetResult.Subnets {
+		subnets[aws.StringValue(subnet.SubnetId)] = subnet
+	}
+	for _, subnetID := range subnetIDs {
+		subnet, ok := subnets[subnetID]
+		if !ok {
+			return fmt.Errorf("subnet %s not found", subnetID)
+		}
+		if aws.StringValue(subnet.VpcId) != o.VPCID {
+			return fmt.Errorf("subnet %s belongs to vpc %s, not %s", subnetID, aws.StringValue(subnet.VpcId), o.VPCID)
+		}
+		if o.DualStack && !hasAssociatedIPv6CIDR(subnet) {
+			return fmt.Errorf("subnet %s has no IPv6 CIDR, it is required with --dual-stack", subnetID)
+		}
+	}
+	zones := sets.New[string]()
+	for _, subnetID := range o.PrivateSubnetIDs {
+		zone := aws.StringValue(subnets[subnetID].AvailabilityZone)
+		if zones.Has(zone) {
+			return fmt.Errorf("more than one private subnet in zone %s, at most one is supported per zone", zone)
+		}
+		zones.Insert(zone)
+		result.Zones = append(result.Zones, &CreateInfraOutputZone{
+			Name:     zone,
+			SubnetID: subnetID,
+		})
+	}
+
+	_, err = client.CreateTags(&ec2.CreateTagsInput{
+		Resources: aws.StringSlice(append([]string{o.VPCID}, subnetIDs...)),
+		Tags: []*ec2.Tag{{
+			Key:   aws.String(clusterTag(o.InfraID)),
+			Value: aws.String(sharedClusterTagValue),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot tag vpc and subnets as shared with the cluster: %w", err)
+	}
+	l.Info("Tagged VPC and subnets as shared with the cluster", "vpc", o.VPCID, "subnets", subnetIDs)
+	return nil
+}
+
+func hasAssociatedIPv6CIDR(subnet *ec2.Subnet) bool {
+	for _, association := range subnet.Ipv6CidrBlockAssociationSet {
+		if association.Ipv6CidrBlockState != nil && aws.StringValue(association.Ipv6CidrBlockState.State) == ec2.SubnetCidrBlockStateCodeAssociated {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureVPCIPv6CIDR associates an Amazon provided IPv6 CIDR with the VPC if it has none yet and returns it.
+func (o *CreateInfraOptions) ensureVPCIPv6CIDR(l logr.Logger, client ec2iface.EC2API, vpcID string) (string, error) {
+	cidr, err := vpcIPv6CIDR(client, vpcID)
+	if err != nil {
+		return "", err
+	}
+	if len(cidr) > 0 {
+		l.Info("Found existing IPv6 CIDR on VPC", "id", vpcID, "cidr", cidr)
+		return cidr, nil
+	}
+	_, err = client.AssociateVpcCidrBlock(&ec2.AssociateVpcCidrBlockInput{
+		VpcId:                       aws.String(vpcID),
+		AmazonProvidedIpv6CidrBlock: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to associate IPv6 CIDR with VPC: %w", err)
+	}
+	err = retry.OnError(ec2Backoff(), func(error) bool { return true }, func() error {
+		cidr, err = vpcIPv6CIDR(client, vpcID)
+		if err != nil {
+			return err
+		}
+		if len(cidr) == 0 {
+			return fmt.Errorf("not associated yet")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("IPv6 CIDR of VPC %s was not associated: %w", vpcID, err)
+	}
+	l.Info("Associated IPv6 CIDR with VPC", "id", vpcID, "cidr", cidr)
+	return cidr, nil
+}
+
+func vpcIPv6CIDR(client ec2iface.EC2API, vpcID string) (string, error) {
+	result, err := client.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: []*string{aws.String(vpcID)}})
+	if err != nil {
+		return "", fmt.Errorf("cannot describe vpc %s: %w", vpcID, err)
+	}
+	for _, vpc := range result.Vpcs {
+		for _, association := range vpc.Ipv6CidrBlockAssociationSet {
+			if association.Ipv6CidrBlockState != nil && aws.StringValue(association.Ipv6CidrBlockState.State) == ec2.VpcCidrBlockStateCodeAssociated {
+				return aws.StringValue(association.Ipv6CidrBlock), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// ipv6SubnetCIDR returns the index-th /64 subnet of the /56 IPv6 CIDR that AWS assigns to VPCs.
+func ipv6SubnetCIDR(vpcCIDR string, index int) (string, error) {
+	_, network, err := net.ParseCIDR(vpcCIDR)
+	if err != nil {
+		return "", fmt.Errorf("invalid IPv6 CIDR %q: %w", vpcCIDR, err)
+	}
+	ones, bits := network.Mask.Size()
+	if bits != net.IPv6len*8 || ones != 56 {
+		return "", fmt.Errorf("expected a /56 IPv6 CIDR, got %q", vpcCIDR)
+	}
+	if index < 0 || index > 255 {
+		return "", fmt.Errorf("subnet index %d is out of range of %q", index, vpcCIDR)
+	}
+	subnet := net.IPNet{IP: make(net.IP, net.IPv6len), Mask: net.CIDRMask(64, 128)}
+	copy(subnet.IP, network.IP)
+	subnet.IP[7] = byte(index)
+	return subnet.String(), nil
+}
+
 func (o *CreateInfraOptions) CreateVPCS3Endpoint(l logr.Logger, client ec2iface.EC2API, vpcID string, routeTableIds []*string) error {
 	existingEndpoint, err := o.existingVPCS3Endpoint(client)
 	if err != nil {
@@ -204,15 +373,17 @@ func (o *CreateInfraOptions) existingDHCPOptions(client ec2iface.EC2API) (string
 	return optID, nil
 }
 
-func (o *CreateInfraOptions) CreatePrivateSubnet(l logr.Logger, client ec2iface.EC2API, vpcID string, zone string, cidr string) (string, error) {
-	return o.CreateSubnet(l, client, vpcID, zone, cidr, fmt.Sprintf("%s-private-%s", o.InfraID, zone), tagNameSubnetInternalELB)
+func (o *CreateInfraOptions) CreatePrivateSubnet(l logr.Logger, client ec2iface.EC2API, vpcID string, zone string, cidr, ipv6CIDR string) (string, error) {
+	return o.CreateSubnet(l, client, vpcID, zone, cidr, ipv6CIDR, fmt.Sprintf("%s-private-%s", o.InfraID, zone), tagNameSubnetInternalELB)
 }
 
-func (o *CreateInfraOptions) CreatePublicSubnet(l logr.Logger, client ec2iface.EC2API, vpcID string, zone string, cidr string) (string, error) {
-	return o.CreateSubnet(l, client, vpcID, zone, cidr, fmt.Sprintf("%s-public-%s", o.InfraID, zone), tagNameSubnetPublicELB)
+func (o *CreateInfraOptions) CreatePublicSubnet(l logr.Logger, client ec2iface.EC2API, vpcID string, zone string, cidr, ipv6CIDR string) (string, error) {
+	return o.CreateSubnet(l, client, vpcID, zone, cidr, ipv6CIDR, fmt.Sprintf("%s-public-%s", o.InfraID, zone), tagNameSubnetPublicELB)
 }
 
-func (o *CreateInfraOptions) CreateSubnet(l logr.Logger, client ec2iface.EC2API, vpcID, zone, cidr, name, scopeTag string) (string, error) {
+// CreateSubnet creates a subnet in the VPC. If ipv6CIDR is set, the subnet is dual-stack and instances in it
+// are assigned an IPv6 address on creation.
+func (o *CreateInfraOptions) CreateSubnet(l logr.Logger, client ec2iface.EC2API, vpcID, zone, cidr, ipv6CIDR, name, scopeTag string) (string, error) {
 	subnetID, err := o.existingSubnet(client, name)
 	if err != nil {
 		return "", err
@@ -227,12 +398,16 @@ func (o *CreateInfraOptions) CreateSubnet(l logr.Logger, client ec2iface.EC2API,
 		Value: aws.String("1"),
 	})
 
-	result, err := client.CreateSubnet(&ec2.CreateSubnetInput{
+	input := &ec2.CreateSubnetInput{
 		AvailabilityZone:  aws.String(zone),
 		VpcId:             aws.String(vpcID),
 		CidrBlock:         aws.String(cidr),
 		TagSpecifications: tagSpec,
-	})
+	}
+	if len(ipv6CIDR) > 0 {
+		input.Ipv6CidrBlock = aws.String(ipv6CIDR)
+	}
+	result, err := client.CreateSubnet(input)
 	if err != nil {
 		return "", fmt.Errorf("cannot create public subnet: %w", err)
 	}
@@ -257,7 +432,16 @@ func (o *CreateInfraOptions) CreateSubnet(l logr.Logger, client ec2iface.EC2API,
 		return "", fmt.Errorf("cannot find subnet that was just created (%s)", aws.StringValue(result.Subnet.SubnetId))
 	}
 	subnetID = aws.StringValue(result.Subnet.SubnetId)
-	l.Info("Created subnet", "name", name, "id", subnetID)
+	if len(ipv6CIDR) > 0 {
+		_, err = client.ModifySubnetAttribute(&ec2.ModifySubnetAttributeInput{
+			SubnetId:                    aws.String(subnetID),
+			AssignIpv6AddressOnCreation: &ec2.AttributeBooleanValue{Value: aws.Bool(true)},
+		})
+		if err != nil {
+			return "", fmt.Errorf("cannot enable IPv6 address assignment on subnet %s: %w", subnetID, err)
+		}
+	}
+	l.Info("Created subnet", "name", name, "id", subnetID, "ipv6 cidr", ipv6CIDR)
 	return subnetID, nil
 }
 
@@ -323,6 +507,30 @@ func (o *CreateInfraOptions) existingInternetGateway(client ec2iface.EC2API, nam
 	return nil, nil
 }
 
+// CreateEgressOnlyInternetGateway creates the gateway through which IPv6 traffic from the private subnets
+// reaches the internet.
+func (o *CreateInfraOptions) CreateEgressOnlyInternetGateway(l logr.Logger, client ec2iface.EC2API, vpcID string) (string, error) {
+	gatewayName := fmt.Sprintf("%s-eigw", o.InfraID)
+	result, err := client.DescribeEgressOnlyInternetGateways(&ec2.DescribeEgressOnlyInternetGatewaysInput{Filters: o.ec2Filters(gatewayName)})
+	if err != nil {
+		return "", fmt.Errorf("cannot list egress only internet gateways: %w", err)
+	}
+	for _, eigw := range result.EgressOnlyInternetGateways {
+		l.Info("Found existing egress only internet gateway", "id", aws.StringValue(eigw.EgressOnlyInternetGatewayId))
+		return aws.StringValue(eigw.EgressOnlyInternetGatewayId), nil
+	}
+	createResult, err := client.CreateEgressOnlyInternetGateway(&ec2.CreateEgressOnlyInternetGatewayInput{
+		VpcId:             aws.String(vpcID),
+		TagSpecifications: o.ec2TagSpecifications("egress-only-internet-gateway", gatewayName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot create egress only internet gateway: %w", err)
+	}
+	eigwID := aws.StringValue(createResult.EgressOnlyInternetGateway.EgressOnlyInternetGatewayId)
+	l.Info("Created egress only internet gateway", "id", eigwID)
+	return eigwID, nil
+}
+
 func (o *CreateInfraOptions) CreateNATGateway(l logr.Logger, client ec2iface.EC2API, publicSubnetID, availabilityZone string) (string, error) {
 	natGatewayName := fmt.Sprintf("%s-nat-%s", o.InfraID, availabilityZone)
 	natGateway, _ := o.existingNATGateway(client, natGatewayName)
@@ -402,7 +610,7 @@ func (o *CreateInfraOptions) existingNATGateway(client ec2iface.EC2API, name str
 	return nil, nil
 }
 
-func (o *CreateInfraOptions) CreatePrivateRouteTable(l logr.Logger, client ec2iface.EC2API, vpcID, natGatewayID, subnetID, zone string) (string, error) {
+func (o *CreateInfraOptions) CreatePrivateRouteTable(l logr.Logger, client ec2iface.EC2API, vpcID, natGatewayID, eigwID, subnetID, zone string) (string, error) {
 	tableName := fmt.Sprintf("%s-private-%s", o.InfraID, zone)
 	routeTable, err := o.existingRouteTable(l, client, tableName)
 	if err != nil {
@@ -442,6 +650,21 @@ func (o *CreateInfraOptions) CreatePrivateRouteTable(l logr.Logger, client ec2if
 	} else {
 		l.Info("Found existing route to NAT gateway", "route table", aws.StringValue(routeTable.RouteTableId), "nat gateway", natGatewayID)
 	}
+	if len(eigwID) > 0 {
+		if !o.hasIPv6DefaultRoute(routeTable, eigwID) {
+			_, err = client.CreateRoute(&ec2.CreateRouteInput{
+				RouteTableId:                routeTable.RouteTableId,
+				EgressOnlyInternetGatewayId: aws.String(eigwID),
+				DestinationIpv6CidrBlock:    aws.String("::/0"),
+			})
+			if err != nil {
+				return "", fmt.Errorf("cannot create egress only internet gateway route in private route table: %w", err)
+			}
+			l.Info("Created route to egress only internet gateway", "route table", aws.StringValue(routeTable.RouteTableId), "egress only internet gateway", eigwID)
+		} else {
+			l.Info("Found existing route to egress only internet gateway", "route table", aws.StringValue(routeTable.RouteTableId), "egress only internet gateway", eigwID)
+		}
+	}
 	if !o.hasAssociatedSubnet(routeTable, subnetID) {
 		_, err = client.AssociateRouteTable(&ec2.AssociateRouteTableInput{
 			RouteTableId: routeTable.RouteTableId,
@@ -522,6 +745,21 @@ func (o *CreateInfraOptions) CreatePublicRouteTable(l logr.Logger, client ec2ifa
 	} else {
 		l.Info("Found existing route to internet gateway", "route table", tableID, "internet gateway", igwID)
 	}
+	if o.DualStack {
+		if !o.hasIPv6DefaultRoute(routeTable, igwID) {
+			_, err = client.CreateRoute(&ec2.CreateRouteInput{
+				DestinationIpv6CidrBlock: aws.String("::/0"),
+				RouteTableId:             aws.String(tableID),
+				GatewayId:                aws.String(igwID),
+			})
+			if err != nil {
+				return "", fmt.Errorf("cannot create IPv6 route to internet gateway: %w", err)
+			}
+			l.Info("Created IPv6 route to internet gateway", "route table", tableID, "internet gateway", igwID)
+		} else {
+			l.Info("Found existing IPv6 route to internet gateway", "route table", tableID, "internet gateway", igwID)
+		}
+	}
 
 	// Associate the route table with the public subnet ID
 	for _, subnetID := range subnetIDs {
@@ -585,6 +823,16 @@ func (o *CreateInfraOptions) hasInternetGatewayRoute(table *ec2.RouteTable, igwI
 	return false
 }
 
+func (o *CreateInfraOptions) hasIPv6DefaultRoute(table *ec2.RouteTable, gatewayID string) bool {
+	for _, route := range table.Routes {
+		if (aws.StringValue(route.GatewayId) == gatewayID || aws.StringValue(route.EgressOnlyInternetGatewayId) == gatewayID) &&
+			aws.StringValue(route.DestinationIpv6CidrBlock) == "::/0" {
+			return true
+		}
+	}
+	return false
+}
+
 func (o *CreateInfraOptions) hasAssociatedSubnet(table *ec2.RouteTable, subnetID string) bool {
 	for _, assoc := range table.Associations {
 		if aws.StringValue(assoc.RouteTableId) == subnetID {
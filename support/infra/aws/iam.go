@@ -12,6 +12,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/go-logr/logr"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
 )
 
 type policyBinding struct {
@@ -475,22 +477,7 @@ func (o *CreateIAMOptions) CreateOIDCResources(iamClient iamiface.IAMAPI, logger
 		IssuerURL: o.IssuerURL,
 	}
 
-	// TODO: The policies and secrets for these roles can be extracted from the
-	// release payload, avoiding this current hardcoding.
-	bindings := map[*string]policyBinding{
-		&output.Roles.IngressARN:              ingressPermPolicy(o.PublicZoneID, o.PrivateZoneID),
-		&output.Roles.ImageRegistryARN:        imageRegistryPermPolicy,
-		&output.Roles.StorageARN:              awsEBSCSIPermPolicy,
-		&output.Roles.KubeCloudControllerARN:  cloudControllerPolicy,
-		&output.Roles.NodePoolManagementARN:   nodePoolPolicy,
-		&output.Roles.ControlPlaneOperatorARN: controlPlaneOperatorPolicy(o.LocalZoneID),
-		&output.Roles.NetworkARN:              cloudNetworkConfigControllerPolicy,
-	}
-	if len(o.KMSKeyARN) > 0 {
-		bindings[&output.KMSProviderRoleARN] = kmsProviderPolicy(o.KMSKeyARN)
-	}
-
-	for into, binding := range bindings {
+	for into, binding := range o.rolePolicyBindings(&output.Roles, &output.KMSProviderRoleARN) {
 		trustPolicy := oidcTrustPolicy(providerARN, providerName, binding.serviceAccounts...)
 		arn, err := o.CreateOIDCRole(iamClient, binding.name, trustPolicy, binding.policy, logger)
 		if err != nil {
@@ -502,6 +489,26 @@ func (o *CreateIAMOptions) CreateOIDCResources(iamClient iamiface.IAMAPI, logger
 	return output, nil
 }
 
+// rolePolicyBindings returns the permissions policies of the roles of a cluster, keyed by the field
+// holding the ARN of each role.
+func (o *CreateIAMOptions) rolePolicyBindings(roles *hyperv1.AWSRolesRef, kmsProviderRoleARN *string) map[*string]policyBinding {
+	// TODO: The policies and secrets for these roles can be extracted from the
+	// release payload, avoiding this current hardcoding.
+	bindings := map[*string]policyBinding{
+		&roles.IngressARN:              ingressPermPolicy(o.PublicZoneID, o.PrivateZoneID),
+		&roles.ImageRegistryARN:        imageRegistryPermPolicy,
+		&roles.StorageARN:              awsEBSCSIPermPolicy,
+		&roles.KubeCloudControllerARN:  cloudControllerPolicy,
+		&roles.NodePoolManagementARN:   nodePoolPolicy,
+		&roles.ControlPlaneOperatorARN: controlPlaneOperatorPolicy(o.LocalZoneID),
+		&roles.NetworkARN:              cloudNetworkConfigControllerPolicy,
+	}
+	if len(o.KMSKeyARN) > 0 {
+		bindings[kmsProviderRoleARN] = kmsProviderPolicy(o.KMSKeyARN)
+	}
+	return bindings
+}
+
 func (o *CreateIAMOptions) CreateOIDCProvider(iamClient iamiface.IAMAPI, logger logr.Logger) (string, error) {
 	oidcProviderList, err := iamClient.ListOpenIDConnectProviders(&iam.ListOpenIDConnectProvidersInput{})
 	if err != nil {
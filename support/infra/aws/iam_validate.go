@@ -0,0 +1,310 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+)
+
+// stringOrSlice is an IAM policy element that is either a single string or a list of strings.
+type stringOrSlice []string
+
+func (s *stringOrSlice) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = []string{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+type policyStatement struct {
+	Effect      string
+	Action      stringOrSlice
+	NotAction   stringOrSlice
+	Resource    stringOrSlice
+	NotResource stringOrSlice
+	Condition   json.RawMessage
+}
+
+// policyStatements is the Statement element of an IAM policy, either a single statement or a list.
+type policyStatements []policyStatement
+
+func (s *policyStatements) UnmarshalJSON(data []byte) error {
+	var single policyStatement
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = []policyStatement{single}
+		return nil
+	}
+	var list []policyStatement
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+type policyDocument struct {
+	Statement policyStatements
+}
+
+func parsePolicyDocument(document string) (*policyDocument, error) {
+	doc := &policyDocument{}
+	if err := json.Unmarshal([]byte(document), doc); err != nil {
+		return nil, fmt.Errorf("invalid policy document: %w", err)
+	}
+	return doc, nil
+}
+
+// permission is an action allowed on a resource.
+type permission struct {
+	action   string
+	resource string
+}
+
+func (p permission) String() string {
+	return fmt.Sprintf("%s on %s", p.action, p.resource)
+}
+
+// requiredPermissions returns the actions and resources allowed by the policy of a binding.
+func (b policyBinding) requiredPermissions() ([]permission, error) {
+	doc, err := parsePolicyDocument(b.policy)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.name, err)
+	}
+	var permissions []permission
+	for _, statement := range doc.Statement {
+		if statement.Effect != "Allow" {
+			continue
+		}
+		for _, action := range statement.Action {
+			for _, resource := range statement.Resource {
+				permissions = append(permissions, permission{action: action, resource: resource})
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// matchesPattern reports whether value matches an IAM pattern, where * matches any sequence of
+// characters and ? any single character. Actions are matched case-insensitively.
+func matchesPattern(pattern, value string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		pattern, value = strings.ToLower(pattern), strings.ToLower(value)
+	}
+	p, v := 0, 0
+	// position of the last * in the pattern and of the value when it was reached, to backtrack to
+	star, starValue := -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			star, starValue = p, v
+			p++
+		case star >= 0:
+			starValue++
+			p, v = star+1, starValue
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+func matchesAny(patterns []string, value string, caseInsensitive bool) bool {
+	for _, pattern := range patterns {
+		if matchesPattern(pattern, value, caseInsensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// appliesTo reports whether the statement applies to the permission. A pattern of the permission,
+// e.g. a wildcard resource, is only covered by a statement with the same or a broader pattern.
+func (s policyStatement) appliesTo(p permission) bool {
+	if len(s.NotAction) > 0 {
+		if matchesAny(s.NotAction, p.action, true) {
+			return false
+		}
+	} else if !matchesAny(s.Action, p.action, true) {
+		return false
+	}
+	if len(s.NotResource) > 0 {
+		return !matchesAny(s.NotResource, p.resource, false)
+	}
+	return matchesAny(s.Resource, p.resource, false)
+}
+
+// missingPermissions returns the required permissions that are not allowed by the granted policies.
+// Conditions are not evaluated, statements with conditions are assumed to apply. Permission boundaries,
+// service control policies and resource policies are not taken into account.
+func missingPermissions(required []permission, granted []*policyDocument) []permission {
+	var missing []permission
+	for _, p := range required {
+		allowed, denied := false, false
+		for _, doc := range granted {
+			for _, statement := range doc.Statement {
+				if !statement.appliesTo(p) {
+					continue
+				}
+				switch statement.Effect {
+				case "Allow":
+					allowed = true
+				case "Deny":
+					denied = true
+				}
+			}
+		}
+		if !allowed || denied {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// roleNameFromARN returns the name of a role from its ARN, e.g. arn:aws:iam::123456789012:role/path/name.
+func roleNameFromARN(arn string) (string, error) {
+	idx := strings.Index(arn, ":role/")
+	if !strings.HasPrefix(arn, "arn:") || idx < 0 {
+		return "", fmt.Errorf("invalid role ARN %q", arn)
+	}
+	resource := arn[idx+len(":role/"):]
+	return resource[strings.LastIndex(resource, "/")+1:], nil
+}
+
+// rolePolicies returns the inline and attached managed policies of a role.
+func rolePolicies(ctx context.Context, client iamiface.IAMAPI, roleName string) ([]*policyDocument, error) {
+	var documents []string
+
+	var inlinePolicyNames []string
+	if err := client.ListRolePoliciesPagesWithContext(ctx, &iam.ListRolePoliciesInput{RoleName: aws.String(roleName)}, func(out *iam.ListRolePoliciesOutput, _ bool) bool {
+		inlinePolicyNames = append(inlinePolicyNames, aws.StringValueSlice(out.PolicyNames)...)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list inline policies of role %s: %w", roleName, err)
+	}
+	for _, name := range inlinePolicyNames {
+		out, err := client.GetRolePolicyWithContext(ctx, &iam.GetRolePolicyInput{RoleName: aws.String(roleName), PolicyName: aws.String(name)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get inline policy %s of role %s: %w", name, roleName, err)
+		}
+		documents = append(documents, aws.StringValue(out.PolicyDocument))
+	}
+
+	var attachedPolicyARNs []string
+	if err := client.ListAttachedRolePoliciesPagesWithContext(ctx, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)}, func(out *iam.ListAttachedRolePoliciesOutput, _ bool) bool {
+		for _, policy := range out.AttachedPolicies {
+			attachedPolicyARNs = append(attachedPolicyARNs, aws.StringValue(policy.PolicyArn))
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list attached policies of role %s: %w", roleName, err)
+	}
+	for _, arn := range attachedPolicyARNs {
+		policy, err := client.GetPolicyWithContext(ctx, &iam.GetPolicyInput{PolicyArn: aws.String(arn)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get policy %s: %w", arn, err)
+		}
+		version, err := client.GetPolicyVersionWithContext(ctx, &iam.GetPolicyVersionInput{PolicyArn: aws.String(arn), VersionId: policy.Policy.DefaultVersionId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get default version of policy %s: %w", arn, err)
+		}
+		documents = append(documents, aws.StringValue(version.PolicyVersion.Document))
+	}
+
+	var result []*policyDocument
+	for _, document := range documents {
+		// policy documents returned by IAM are URL encoded
+		decoded, err := url.QueryUnescape(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode policy document of role %s: %w", roleName, err)
+		}
+		doc, err := parsePolicyDocument(decoded)
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %w", roleName, err)
+		}
+		result = append(result, doc)
+	}
+	return result, nil
+}
+
+// ValidateRoles checks that the roles of a cluster allow every action their component requires and
+// returns the missing permissions by role ARN.
+func (o *CreateIAMOptions) ValidateRoles(ctx context.Context, client iamiface.IAMAPI, roles *CreateIAMOutput) (map[string][]string, error) {
+	result := map[string][]string{}
+	for arn, binding := range o.rolePolicyBindings(&roles.Roles, &roles.KMSProviderRoleARN) {
+		if *arn == "" {
+			continue
+		}
+		required, err := binding.requiredPermissions()
+		if err != nil {
+			return nil, err
+		}
+		roleName, err := roleNameFromARN(*arn)
+		if err != nil {
+			return nil, err
+		}
+		granted, err := rolePolicies(ctx, client, roleName)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range missingPermissions(required, granted) {
+			result[*arn] = append(result[*arn], p.String())
+		}
+	}
+	return result, nil
+}
+
+// printedPolicy is a permissions policy printed by `create iam aws --print-policies`.
+type printedPolicy struct {
+	ServiceAccounts []string        `json:"serviceAccounts"`
+	Policy          json.RawMessage `json:"policy"`
+}
+
+// Policies returns the permissions policies of the roles of a cluster and the service accounts allowed
+// to assume them, by role name.
+func (o *CreateIAMOptions) Policies() (map[string]printedPolicy, error) {
+	result := map[string]printedPolicy{}
+	var roles hyperv1.AWSRolesRef
+	var kmsProviderRoleARN string
+	for _, binding := range o.rolePolicyBindings(&roles, &kmsProviderRoleARN) {
+		if _, err := parsePolicyDocument(binding.policy); err != nil {
+			return nil, fmt.Errorf("%s: %w", binding.name, err)
+		}
+		result[binding.name] = printedPolicy{
+			ServiceAccounts: binding.serviceAccounts,
+			Policy:          json.RawMessage(binding.policy),
+		}
+	}
+	return result, nil
+}
+
+// sortedKeys returns the keys of a map of missing permissions in order.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
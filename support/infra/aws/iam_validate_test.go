@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+)
+
+func TestMatchesPattern(t *testing.T) {
+	testCases := []struct {
+		pattern         string
+		value           string
+		caseInsensitive bool
+		expected        bool
+	}{
+		{pattern: "*", value: "ec2:CreateVpcEndpoint", expected: true},
+		{pattern: "ec2:*", value: "ec2:CreateVpcEndpoint", expected: true},
+		{pattern: "ec2:Describe*", value: "ec2:CreateVpcEndpoint", expected: false},
+		{pattern: "ec2:describevpc*", value: "ec2:DescribeVpcEndpoints", caseInsensitive: true, expected: true},
+		{pattern: "ec2:describevpc*", value: "ec2:DescribeVpcEndpoints", expected: false},
+		{pattern: "ec2:?escribeVpcs", value: "ec2:DescribeVpcs", expected: true},
+		{pattern: "arn:aws:route53:::hostedzone/*", value: "arn:aws:route53:::hostedzone/Z1", expected: true},
+		{pattern: "arn:aws:route53:::hostedzone/Z2", value: "arn:aws:route53:::hostedzone/Z1", expected: false},
+		{pattern: "arn:*:iam::*:role/*-worker-role", value: "arn:*:iam::*:role/*-worker-role", expected: true},
+		{pattern: "arn:*:iam::*:role/*-worker-role", value: "arn:aws:iam::1:role/a-worker-role-b", expected: false},
+		{pattern: "kms:*Key*", value: "kms:DescribeKey", expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.value, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(matchesPattern(tc.pattern, tc.value, tc.caseInsensitive)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestMissingPermissions(t *testing.T) {
+	required := []permission{
+		{action: "ec2:DescribeVpcs", resource: "*"},
+		{action: "ec2:CreateVpcEndpoint", resource: "*"},
+		{action: "route53:ChangeResourceRecordSets", resource: "arn:aws:route53:::hostedzone/Z1"},
+	}
+	testCases := []struct {
+		name     string
+		granted  []string
+		expected []permission
+	}{
+		{
+			name:    "all allowed by wildcards",
+			granted: []string{`{"Statement":{"Effect":"Allow","Action":["ec2:*","route53:Change*"],"Resource":"*"}}`},
+		},
+		{
+			name: "allowed across policies",
+			granted: []string{
+				`{"Statement":[{"Effect":"Allow","Action":"ec2:*","Resource":"*"}]}`,
+				`{"Statement":[{"Effect":"Allow","Action":"route53:ChangeResourceRecordSets","Resource":"arn:aws:route53:::hostedzone/*"}]}`,
+			},
+		},
+		{
+			name:    "missing actions",
+			granted: []string{`{"Statement":[{"Effect":"Allow","Action":"ec2:Describe*","Resource":"*"}]}`},
+			expected: []permission{
+				{action: "ec2:CreateVpcEndpoint", resource: "*"},
+				{action: "route53:ChangeResourceRecordSets", resource: "arn:aws:route53:::hostedzone/Z1"},
+			},
+		},
+		{
+			name:     "wrong resource",
+			granted:  []string{`{"Statement":[{"Effect":"Allow","Action":["ec2:*","route53:*"],"Resource":["*"]},{"Effect":"Deny","Action":"route53:*","NotResource":"arn:aws:route53:::hostedzone/Z2"}]}`},
+			expected: []permission{{action: "route53:ChangeResourceRecordSets", resource: "arn:aws:route53:::hostedzone/Z1"}},
+		},
+		{
+			name:     "explicit deny",
+			granted:  []string{`{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"},{"Effect":"Deny","Action":"ec2:CreateVpcEndpoint","Resource":"*"}]}`},
+			expected: []permission{{action: "ec2:CreateVpcEndpoint", resource: "*"}},
+		},
+		{
+			name:    "not action",
+			granted: []string{`{"Statement":[{"Effect":"Allow","NotAction":"iam:*","Resource":"*"}]}`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var granted []*policyDocument
+			for _, document := range tc.granted {
+				doc, err := parsePolicyDocument(document)
+				g.Expect(err).ToNot(HaveOccurred())
+				granted = append(granted, doc)
+			}
+			g.Expect(missingPermissions(required, granted)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	g := NewWithT(t)
+	opts := &CreateIAMOptions{PublicZoneID: "Z1", PrivateZoneID: "Z2", LocalZoneID: "Z3", KMSKeyARN: "arn:aws:kms:us-east-1:1:key/1"}
+	policies, err := opts.Policies()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(policies).To(HaveLen(8))
+	g.Expect(string(policies["openshift-ingress"].Policy)).To(ContainSubstring("arn:aws:route53:::hostedzone/Z1"))
+	g.Expect(policies["control-plane-operator"].ServiceAccounts).To(Equal([]string{"system:serviceaccount:kube-system:control-plane-operator"}))
+
+	// every policy is satisfied by itself
+	var roles hyperv1.AWSRolesRef
+	var kmsProviderRoleARN string
+	for _, binding := range opts.rolePolicyBindings(&roles, &kmsProviderRoleARN) {
+		required, err := binding.requiredPermissions()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(required).ToNot(BeEmpty())
+		doc, err := parsePolicyDocument(binding.policy)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(missingPermissions(required, []*policyDocument{doc})).To(BeEmpty(), binding.name)
+	}
+}
+
+type fakeIAMClient struct {
+	iamiface.IAMAPI
+	inlinePolicies   map[string]map[string]string
+	attachedPolicies map[string][]string
+	managedPolicies  map[string]string
+}
+
+func (f *fakeIAMClient) ListRolePoliciesPagesWithContext(_ aws.Context, input *iam.ListRolePoliciesInput, fn func(*iam.ListRolePoliciesOutput, bool) bool, _ ...request.Option) error {
+	out := &iam.ListRolePoliciesOutput{}
+	for name := range f.inlinePolicies[aws.StringValue(input.RoleName)] {
+		out.PolicyNames = append(out.PolicyNames, aws.String(name))
+	}
+	fn(out, true)
+	return nil
+}
+
+func (f *fakeIAMClient) GetRolePolicyWithContext(_ aws.Context, input *iam.GetRolePolicyInput, _ ...request.Option) (*iam.GetRolePolicyOutput, error) {
+	document := f.inlinePolicies[aws.StringValue(input.RoleName)][aws.StringValue(input.PolicyName)]
+	return &iam.GetRolePolicyOutput{PolicyDocument: aws.String(url.QueryEscape(document))}, nil
+}
+
+func (f *fakeIAMClient) ListAttachedRolePoliciesPagesWithContext(_ aws.Context, input *iam.ListAttachedRolePoliciesInput, fn func(*iam.ListAttachedRolePoliciesOutput, bool) bool, _ ...request.Option) error {
+	out := &iam.ListAttachedRolePoliciesOutput{}
+	for _, arn := range f.attachedPolicies[aws.StringValue(input.RoleName)] {
+		out.AttachedPolicies = append(out.AttachedPolicies, &iam.AttachedPolicy{PolicyArn: aws.String(arn)})
+	}
+	fn(out, true)
+	return nil
+}
+
+func (f *fakeIAMClient) GetPolicyWithContext(_ aws.Context, input *iam.GetPolicyInput, _ ...request.Option) (*iam.GetPolicyOutput, error) {
+	return &iam.GetPolicyOutput{Policy: &iam.Policy{Arn: input.PolicyArn, DefaultVersionId: aws.String("v2")}}, nil
+}
+
+func (f *fakeIAMClient) GetPolicyVersionWithContext(_ aws.Context, input *iam.GetPolicyVersionInput, _ ...request.Option) (*iam.GetPolicyVersionOutput, error) {
+	document := f.managedPolicies[aws.StringValue(input.PolicyArn)]
+	return &iam.GetPolicyVersionOutput{PolicyVersion: &iam.PolicyVersion{Document: aws.String(url.QueryEscape(document))}}, nil
+}
+
+func TestValidateRoles(t *testing.T) {
+	g := NewWithT(t)
+	opts := &CreateIAMOptions{PublicZoneID: "Z1", PrivateZoneID: "Z2", LocalZoneID: "Z3"}
+	client := &fakeIAMClient{
+		inlinePolicies: map[string]map[string]string{
+			"network": {"policy": cloudNetworkConfigControllerPolicy.policy},
+			"cpo": {"policy": `{
+				"Statement": [
+					{"Effect": "Allow", "Action": ["ec2:*Vpc*", "ec2:*SecurityGroup*", "ec2:CreateTags", "route53:ListHostedZones"], "Resource": "*"},
+					{"Effect": "Allow", "Action": "route53:ChangeResourceRecordSets", "Resource": "arn:aws:route53:::hostedzone/Z3"}
+				]
+			}`},
+		},
+		attachedPolicies: map[string][]string{
+			"ingress": {"arn:aws:iam::1:policy/ingress"},
+		},
+		managedPolicies: map[string]string{
+			"arn:aws:iam::1:policy/ingress": `{"Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}`,
+		},
+	}
+	missing, err := opts.ValidateRoles(context.Background(), client, &CreateIAMOutput{Roles: hyperv1.AWSRolesRef{
+		IngressARN:              "arn:aws:iam::1:role/ingress",
+		NetworkARN:              "arn:aws:iam::1:role/network",
+		ControlPlaneOperatorARN: "arn:aws:iam::1:role/path/cpo",
+	}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(missing).To(Equal(map[string][]string{
+		"arn:aws:iam::1:role/path/cpo": {"route53:ListResourceRecordSets on arn:aws:route53:::hostedzone/Z3"},
+	}))
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 	"github.com/go-logr/logr"
 	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 )
@@ -126,6 +127,31 @@ func (o *DestroyInfraOptions) DestroyPrivateZones(ctx context.Context, client ro
 	return errs
 }
 
+// DestroyClusterPrivateZones deletes the private zones of the cluster associated with a VPC, leaving the zones
+// of other users of the VPC alone.
+func (o *DestroyInfraOptions) DestroyClusterPrivateZones(ctx context.Context, client route53iface.Route53API, vpcID *string) []error {
+	var output *route53.ListHostedZonesByVPCOutput
+	if err := retryRoute53WithBackoff(ctx, func() (err error) {
+		output, err = client.ListHostedZonesByVPCWithContext(ctx, &route53.ListHostedZonesByVPCInput{VPCId: vpcID, VPCRegion: aws.String(o.Region)})
+		return err
+	}); err != nil {
+		return []error{fmt.Errorf("failed to list hosted zones for vpc %s: %w", *vpcID, err)}
+	}
+
+	clusterZones := sets.New(ZoneName(o.Name, o.BaseDomainPrefix, o.BaseDomain), fmt.Sprintf("%s.%s", o.Name, hypershiftLocalZoneName))
+	for _, zone := range output.HostedZoneSummaries {
+		if !clusterZones.Has(strings.TrimSuffix(aws.StringValue(zone.Name), ".")) {
+			continue
+		}
+		id := cleanZoneID(*zone.HostedZoneId)
+		if err := deleteZone(ctx, id, client, o.Log); err != nil {
+			return []error{fmt.Errorf("failed to delete private hosted zone %s of vpc %s: %w", aws.StringValue(zone.Name), *vpcID, err)}
+		}
+		o.Log.Info("Deleted private hosted zone", "id", id, "name", *zone.Name)
+	}
+	return nil
+}
+
 func (o *DestroyInfraOptions) CleanupPublicZone(ctx context.Context, client route53iface.Route53API) error {
 	name := o.BaseDomain
 	id, err := lookupZone(ctx, client, name, false)
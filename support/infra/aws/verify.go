@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+
+	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+)
+
+// VerifyInfra checks that the resources reported by an earlier CreateInfra still exist, without creating
+// or changing anything. It only issues a few describe calls, so that drift can be detected much more
+// cheaply than by running CreateInfra again.
+func (o *CreateInfraOptions) VerifyInfra(ctx context.Context, result *CreateInfraOutput) error {
+	awsSession, err := o.AWSCredentialsOpts.GetSession("cli-verify-infra", o.CredentialsSecretData, o.Region)
+	if err != nil {
+		return err
+	}
+	ec2Client := ec2.New(awsSession, awsutil.NewConfig())
+	route53Client := route53.New(awsSession, awsutil.NewAWSRoute53Config())
+	return o.verifyInfra(ctx, ec2Client, route53Client, result)
+}
+
+func (o *CreateInfraOptions) verifyInfra(ctx context.Context, ec2Client ec2iface.EC2API, route53Client route53iface.Route53API, result *CreateInfraOutput) error {
+	vpcs, err := ec2Client.DescribeVpcsWithContext(ctx, &ec2.DescribeVpcsInput{VpcIds: []*string{aws.String(result.VPCID)}})
+	if err != nil {
+		return fmt.Errorf("failed to describe VPC %s: %w", result.VPCID, err)
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return fmt.Errorf("VPC %s not found", result.VPCID)
+	}
+
+	var subnetIDs []*string
+	for _, zone := range result.Zones {
+		subnetIDs = append(subnetIDs, aws.String(zone.SubnetID))
+	}
+	if len(subnetIDs) > 0 {
+		subnets, err := ec2Client.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{SubnetIds: subnetIDs})
+		if err != nil {
+			return fmt.Errorf("failed to describe subnets: %w", err)
+		}
+		if len(subnets.Subnets) != len(subnetIDs) {
+			return fmt.Errorf("found %d of the %d private subnets", len(subnets.Subnets), len(subnetIDs))
+		}
+	}
+
+	// the gateways of adopted VPCs are not managed
+	if len(o.VPCID) == 0 {
+		vpcFilter := &ec2.Filter{Name: aws.String("vpc-id"), Values: []*string{aws.String(result.VPCID)}}
+		internetGateways, err := ec2Client.DescribeInternetGatewaysWithContext(ctx, &ec2.DescribeInternetGatewaysInput{
+			Filters: []*ec2.Filter{{Name: aws.String("attachment.vpc-id"), Values: []*string{aws.String(result.VPCID)}}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe internet gateways: %w", err)
+		}
+		if len(internetGateways.InternetGateways) == 0 {
+			return fmt.Errorf("internet gateway of VPC %s not found", result.VPCID)
+		}
+
+		expectedNATGateways := len(result.Zones)
+		if o.EnableProxy {
+			expectedNATGateways = 0
+		} else if o.SingleNATGateway && expectedNATGateways > 1 {
+			expectedNATGateways = 1
+		}
+		natGateways, err := ec2Client.DescribeNatGatewaysWithContext(ctx, &ec2.DescribeNatGatewaysInput{
+			Filter: []*ec2.Filter{vpcFilter, {Name: aws.String("state"), Values: aws.StringSlice([]string{"pending", "available"})}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe NAT gateways: %w", err)
+		}
+		if len(natGateways.NatGateways) < expectedNATGateways {
+			return fmt.Errorf("found %d of the %d NAT gateways of VPC %s", len(natGateways.NatGateways), expectedNATGateways, result.VPCID)
+		}
+	}
+
+	for _, zoneID := range []string{result.PrivateZoneID, result.LocalZoneID} {
+		if len(zoneID) == 0 {
+			continue
+		}
+		if _, err := route53Client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: aws.String(zoneID)}); err != nil {
+			return fmt.Errorf("failed to get hosted zone %s: %w", zoneID, err)
+		}
+	}
+	return nil
+}
+
+// VerifyIAM checks that the instance profile and the roles reported by an earlier CreateIAM still exist,
+// without creating or changing anything.
+func (o *CreateIAMOptions) VerifyIAM(ctx context.Context, result *CreateIAMOutput) error {
+	awsSession, err := o.AWSCredentialsOpts.GetSession("cli-verify-iam", o.CredentialsSecretData, o.Region)
+	if err != nil {
+		return err
+	}
+	return verifyIAM(ctx, iam.New(awsSession, awsutil.NewConfig()), result)
+}
+
+func verifyIAM(ctx context.Context, iamClient iamiface.IAMAPI, result *CreateIAMOutput) error {
+	if _, err := iamClient.GetInstanceProfileWithContext(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(result.ProfileName)}); err != nil {
+		return fmt.Errorf("failed to get instance profile %s: %w", result.ProfileName, err)
+	}
+	roles := result.Roles
+	for _, arn := range []string{roles.IngressARN, roles.ImageRegistryARN, roles.StorageARN, roles.NetworkARN, roles.KubeCloudControllerARN, roles.NodePoolManagementARN, roles.ControlPlaneOperatorARN} {
+		if len(arn) == 0 {
+			continue
+		}
+		roleName := arn[strings.LastIndex(arn, "/")+1:]
+		if _, err := iamClient.GetRoleWithContext(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)}); err != nil {
+			return fmt.Errorf("failed to get role %s: %w", roleName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+)
+
+type verifyEC2Client struct {
+	ec2iface.EC2API
+	subnets          int
+	internetGateways int
+	natGateways      int
+}
+
+func (f *verifyEC2Client) DescribeVpcsWithContext(_ aws.Context, input *ec2.DescribeVpcsInput, _ ...request.Option) (*ec2.DescribeVpcsOutput, error) {
+	return &ec2.DescribeVpcsOutput{Vpcs: []*ec2.Vpc{{VpcId: input.VpcIds[0]}}}, nil
+}
+
+func (f *verifyEC2Client) DescribeSubnetsWithContext(_ aws.Context, _ *ec2.DescribeSubnetsInput, _ ...request.Option) (*ec2.DescribeSubnetsOutput, error) {
+	return &ec2.DescribeSubnetsOutput{Subnets: make([]*ec2.Subnet, f.subnets)}, nil
+}
+
+func (f *verifyEC2Client) DescribeInternetGatewaysWithContext(_ aws.Context, _ *ec2.DescribeInternetGatewaysInput, _ ...request.Option) (*ec2.DescribeInternetGatewaysOutput, error) {
+	return &ec2.DescribeInternetGatewaysOutput{InternetGateways: make([]*ec2.InternetGateway, f.internetGateways)}, nil
+}
+
+func (f *verifyEC2Client) DescribeNatGatewaysWithContext(_ aws.Context, _ *ec2.DescribeNatGatewaysInput, _ ...request.Option) (*ec2.DescribeNatGatewaysOutput, error) {
+	return &ec2.DescribeNatGatewaysOutput{NatGateways: make([]*ec2.NatGateway, f.natGateways)}, nil
+}
+
+type verifyRoute53Client struct {
+	route53iface.Route53API
+	missingZoneID string
+}
+
+func (f *verifyRoute53Client) GetHostedZoneWithContext(_ aws.Context, input *route53.GetHostedZoneInput, _ ...request.Option) (*route53.GetHostedZoneOutput, error) {
+	if aws.StringValue(input.Id) == f.missingZoneID {
+		return nil, errors.New("NoSuchHostedZone")
+	}
+	return &route53.GetHostedZoneOutput{}, nil
+}
+
+type verifyIAMClient struct {
+	iamiface.IAMAPI
+	missingRole string
+}
+
+func (f *verifyIAMClient) GetInstanceProfileWithContext(_ aws.Context, _ *iam.GetInstanceProfileInput, _ ...request.Option) (*iam.GetInstanceProfileOutput, error) {
+	return &iam.GetInstanceProfileOutput{}, nil
+}
+
+func (f *verifyIAMClient) GetRoleWithContext(_ aws.Context, input *iam.GetRoleInput, _ ...request.Option) (*iam.GetRoleOutput, error) {
+	if aws.StringValue(input.RoleName) == f.missingRole {
+		return nil, errors.New("NoSuchEntity")
+	}
+	return &iam.GetRoleOutput{}, nil
+}
+
+func TestVerifyInfra(t *testing.T) {
+	result := &CreateInfraOutput{
+		VPCID:         "vpc-1",
+		Zones:         []*CreateInfraOutputZone{{Name: "us-east-1a", SubnetID: "subnet-1"}, {Name: "us-east-1b", SubnetID: "subnet-2"}},
+		PrivateZoneID: "Z2",
+		LocalZoneID:   "Z3",
+	}
+
+	testCases := []struct {
+		name          string
+		options       CreateInfraOptions
+		ec2Client     *verifyEC2Client
+		missingZoneID string
+		expectedErr   string
+	}{
+		{
+			name:      "When all the resources exist it should succeed",
+			ec2Client: &verifyEC2Client{subnets: 2, internetGateways: 1, natGateways: 2},
+		},
+		{
+			name:        "When a NAT gateway was deleted it should fail",
+			ec2Client:   &verifyEC2Client{subnets: 2, internetGateways: 1, natGateways: 1},
+			expectedErr: "found 1 of the 2 NAT gateways of VPC vpc-1",
+		},
+		{
+			name:      "When a single NAT gateway is used it should succeed with one",
+			options:   CreateInfraOptions{SingleNATGateway: true},
+			ec2Client: &verifyEC2Client{subnets: 2, internetGateways: 1, natGateways: 1},
+		},
+		{
+			name:      "When the VPC is adopted it should not check its gateways",
+			options:   CreateInfraOptions{VPCID: "vpc-1"},
+			ec2Client: &verifyEC2Client{subnets: 2},
+		},
+		{
+			name:        "When a subnet was deleted it should fail",
+			ec2Client:   &verifyEC2Client{subnets: 1, internetGateways: 1, natGateways: 2},
+			expectedErr: "found 1 of the 2 private subnets",
+		},
+		{
+			name:          "When a private zone was deleted it should fail",
+			ec2Client:     &verifyEC2Client{subnets: 2, internetGateways: 1, natGateways: 2},
+			missingZoneID: "Z3",
+			expectedErr:   "failed to get hosted zone Z3: NoSuchHostedZone",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := tc.options.verifyInfra(context.Background(), tc.ec2Client, &verifyRoute53Client{missingZoneID: tc.missingZoneID}, result)
+			if tc.expectedErr == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(tc.expectedErr))
+			}
+		})
+	}
+}
+
+func TestVerifyIAM(t *testing.T) {
+	g := NewWithT(t)
+	result := &CreateIAMOutput{
+		ProfileName: "infra-1-worker",
+		Roles: hyperv1.AWSRolesRef{
+			IngressARN:            "arn:aws:iam::1:role/infra-1-openshift-ingress",
+			NodePoolManagementARN: "arn:aws:iam::1:role/infra-1-node-pool",
+		},
+	}
+
+	g.Expect(verifyIAM(context.Background(), &verifyIAMClient{}, result)).To(Succeed())
+	g.Expect(verifyIAM(context.Background(), &verifyIAMClient{missingRole: "infra-1-node-pool"}, result)).To(MatchError("failed to get role infra-1-node-pool: NoSuchEntity"))
+}
@@ -0,0 +1,66 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+// VerifyInfra checks that the resources reported by an earlier Run still exist in the resource group of
+// the infrastructure, without creating or changing anything, so that drift can be detected much more
+// cheaply than by running Run again.
+func (o *CreateInfraOptions) VerifyInfra(ctx context.Context, l logr.Logger, result *CreateInfraOutput) error {
+	subscriptionID, azureCreds, err := setupCredentials(l, o.Credentials, o.CredentialsFile)
+	if err != nil {
+		return fmt.Errorf("failed to setup Azure credentials: %w", err)
+	}
+	resourcesClient, err := armresources.NewClient(subscriptionID, azureCreds, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create new resources client: %w", err)
+	}
+
+	var resourceIDs []string
+	pager := resourcesClient.NewListByResourceGroupPager(result.ResourceGroupName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list the resources of resource group %s: %w", result.ResourceGroupName, err)
+		}
+		for _, resource := range page.Value {
+			resourceIDs = append(resourceIDs, *resource.ID)
+		}
+	}
+
+	expectedIDs := []string{result.MachineIdentityID, result.PrivateZoneID, result.BootImageID}
+	// existing networks may live in other resource groups
+	if len(o.VnetID) == 0 {
+		expectedIDs = append(expectedIDs, result.VNetID)
+	}
+	if len(o.NetworkSecurityGroupID) == 0 {
+		expectedIDs = append(expectedIDs, result.SecurityGroupID)
+	}
+	if missing := missingResources(expectedIDs, resourceIDs); len(missing) > 0 {
+		return fmt.Errorf("resources not found in resource group %s: %s", result.ResourceGroupName, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// missingResources returns the expected resource IDs which are not in resourceIDs. Azure resource IDs are
+// case-insensitive.
+func missingResources(expectedIDs, resourceIDs []string) []string {
+	existing := make(map[string]bool, len(resourceIDs))
+	for _, id := range resourceIDs {
+		existing[strings.ToLower(id)] = true
+	}
+	var missing []string
+	for _, id := range expectedIDs {
+		if len(id) > 0 && !existing[strings.ToLower(id)] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,24 @@
+package azure
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMissingResources(t *testing.T) {
+	g := NewWithT(t)
+
+	resourceIDs := []string{
+		"/subscriptions/1/resourceGroups/example-infra-1/providers/Microsoft.Network/virtualNetworks/example-infra-1",
+		"/subscriptions/1/resourceGroups/example-infra-1/providers/Microsoft.Compute/images/rhcos.x86_64.vhd",
+	}
+	expectedIDs := []string{
+		"/subscriptions/1/resourceGroups/EXAMPLE-INFRA-1/providers/Microsoft.Network/virtualNetworks/example-infra-1",
+		"/subscriptions/1/resourceGroups/example-infra-1/providers/Microsoft.Network/networkSecurityGroups/example-infra-1-nsg",
+		"",
+	}
+	g.Expect(missingResources(expectedIDs, resourceIDs)).To(Equal([]string{
+		"/subscriptions/1/resourceGroups/example-infra-1/providers/Microsoft.Network/networkSecurityGroups/example-infra-1-nsg",
+	}))
+}
@@ -26,8 +26,8 @@ var (
 
 // validateCloudInstanceByID
 // validates cloud instance's existence by id
-func validateCloudInstanceByID(ctx context.Context, cloudInstanceID string) (*resourcecontrollerv2.ResourceInstance, error) {
-	rcv2, err := resourcecontrollerv2.NewResourceControllerV2(&resourcecontrollerv2.ResourceControllerV2Options{Authenticator: getIAMAuth()})
+func validateCloudInstanceByID(ctx context.Context, apiKey string, cloudInstanceID string) (*resourcecontrollerv2.ResourceInstance, error) {
+	rcv2, err := resourcecontrollerv2.NewResourceControllerV2(&resourcecontrollerv2.ResourceControllerV2Options{Authenticator: getIAMAuth(apiKey)})
 	if err != nil {
 		return nil, err
 	}
@@ -50,8 +50,8 @@ func validateCloudInstanceByID(ctx context.Context, cloudInstanceID string) (*re
 
 // validateCloudInstanceByName
 // validates cloud instance's existence by name
-func validateCloudInstanceByName(ctx context.Context, cloudInstance string, resourceGroupID string, powerVsZone string, serviceID string, servicePlanID string) (*resourcecontrollerv2.ResourceInstance, error) {
-	rcv2, err := resourcecontrollerv2.NewResourceControllerV2(&resourcecontrollerv2.ResourceControllerV2Options{Authenticator: getIAMAuth()})
+func validateCloudInstanceByName(ctx context.Context, apiKey string, cloudInstance string, resourceGroupID string, powerVsZone string, serviceID string, servicePlanID string) (*resourcecontrollerv2.ResourceInstance, error) {
+	rcv2, err := resourcecontrollerv2.NewResourceControllerV2(&resourcecontrollerv2.ResourceControllerV2Options{Authenticator: getIAMAuth(apiKey)})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,43 @@
+package powervs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM-Cloud/power-go-client/clients/instance"
+	"github.com/IBM/vpc-go-sdk/vpcv1"
+	"github.com/go-logr/logr"
+)
+
+// VerifyInfra checks that the resources of an infra set up by an earlier SetupInfra still exist, without
+// creating or changing anything, so that drift can be detected much more cheaply than by running
+// SetupInfra again
+func (options *CreateInfraOptions) VerifyInfra(ctx context.Context, logger logr.Logger, infra *Infra) error {
+	if options.APIKey == "" {
+		return fmt.Errorf("cloud API Key not set")
+	}
+
+	if _, err := validateCloudInstanceByID(ctx, options.APIKey, infra.CloudInstanceID); err != nil {
+		return fmt.Errorf("error verifying cloud instance %s: %w", infra.CloudInstanceID, err)
+	}
+
+	v1, err := createVpcService(logger, options.APIKey, options.VPCRegion)
+	if err != nil {
+		return fmt.Errorf("error creating vpc service: %w", err)
+	}
+	if _, _, err := v1.GetVPCWithContext(ctx, &vpcv1.GetVPCOptions{ID: &infra.VPCID}); err != nil {
+		return fmt.Errorf("error getting vpc %s: %w", infra.VPCID, err)
+	}
+	if _, _, err := v1.GetSubnetWithContext(ctx, &vpcv1.GetSubnetOptions{ID: &infra.VPCSubnetID}); err != nil {
+		return fmt.Errorf("error getting vpc subnet %s: %w", infra.VPCSubnetID, err)
+	}
+
+	session, err := createPowerVSSession(options.APIKey, infra.AccountID, options.Region, options.Zone, options.Debug)
+	if err != nil {
+		return fmt.Errorf("error creating powervs session: %w", err)
+	}
+	if _, err := instance.NewIBMPIDhcpClient(ctx, session, infra.CloudInstanceID).Get(infra.DHCPID); err != nil {
+		return fmt.Errorf("error getting dhcp server %s: %w", infra.DHCPID, err)
+	}
+	return nil
+}
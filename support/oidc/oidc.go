@@ -26,18 +26,45 @@ type KeyResponse struct {
 
 type OIDCDocumentGeneratorFunc func(params ODICGeneratorParams) (io.ReadSeeker, error)
 
+// GenerateJWKSDocument returns the JWKS document with every RSA public key of PubKey, which may hold
+// several PEM blocks while a service account signing key is rotated.
 func GenerateJWKSDocument(params ODICGeneratorParams) (io.ReadSeeker, error) {
-	block, _ := pem.Decode(params.PubKey)
-	if block == nil || block.Type != "RSA PUBLIC KEY" {
+	var keys []jose.JSONWebKey
+	for rest := params.PubKey; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		key, err := jsonWebKey(block)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
 		return nil, fmt.Errorf("failed to decode PEM block containing RSA public key")
 	}
+
+	jwks, err := json.MarshalIndent(KeyResponse{Keys: keys}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(jwks), nil
+}
+
+func jsonWebKey(block *pem.Block) (jose.JSONWebKey, error) {
+	if block.Type != "RSA PUBLIC KEY" {
+		return jose.JSONWebKey{}, fmt.Errorf("failed to decode PEM block containing RSA public key")
+	}
 	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse public key: %w", err)
+		return jose.JSONWebKey{}, fmt.Errorf("failed to parse public key: %w", err)
 	}
 	rsaPubKey, ok := pubKey.(*rsa.PublicKey)
 	if !ok {
-		return nil, fmt.Errorf("public key is not RSA")
+		return jose.JSONWebKey{}, fmt.Errorf("public key is not RSA")
 	}
 
 	hasher := crypto.SHA256.New()
@@ -45,20 +72,12 @@ func GenerateJWKSDocument(params ODICGeneratorParams) (io.ReadSeeker, error) {
 	hash := hasher.Sum(nil)
 	kid := base64.RawURLEncoding.EncodeToString(hash)
 
-	var keys []jose.JSONWebKey
-	keys = append(keys, jose.JSONWebKey{
+	return jose.JSONWebKey{
 		Key:       rsaPubKey,
 		KeyID:     kid,
 		Algorithm: string(jose.RS256),
 		Use:       "sig",
-	})
-
-	jwks, err := json.MarshalIndent(KeyResponse{Keys: keys}, "", "  ")
-	if err != nil {
-		return nil, err
-	}
-
-	return bytes.NewReader(jwks), nil
+	}, nil
 }
 
 const (
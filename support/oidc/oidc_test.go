@@ -0,0 +1,68 @@
+package oidc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/hypershift/support/certs"
+)
+
+func TestGenerateJWKSDocument(t *testing.T) {
+	publicKey := func() []byte {
+		key, err := certs.PrivateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		pem, err := certs.PublicKeyToPem(&key.PublicKey)
+		if err != nil {
+			t.Fatalf("failed to serialize public key: %v", err)
+		}
+		return pem
+	}
+	current, next := publicKey(), publicKey()
+
+	testCases := []struct {
+		name         string
+		pubKey       []byte
+		expectedKeys int
+		expectErr    bool
+	}{
+		{
+			name:         "When there is a single public key it should publish it",
+			pubKey:       current,
+			expectedKeys: 1,
+		},
+		{
+			name:         "When a key is being rotated it should publish both public keys",
+			pubKey:       bytes.Join([][]byte{current, next}, []byte("\n")),
+			expectedKeys: 2,
+		},
+		{
+			name:      "When there is no public key it should fail",
+			pubKey:    []byte("not a key"),
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			document, err := GenerateJWKSDocument(ODICGeneratorParams{PubKey: tc.pubKey})
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			content, err := io.ReadAll(document)
+			g.Expect(err).ToNot(HaveOccurred())
+			response := KeyResponse{}
+			g.Expect(json.Unmarshal(content, &response)).To(Succeed())
+			g.Expect(response.Keys).To(HaveLen(tc.expectedKeys))
+			if tc.expectedKeys == 2 {
+				g.Expect(response.Keys[0].KeyID).ToNot(Equal(response.Keys[1].KeyID))
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Storage publishes the OIDC discovery documents of hosted clusters at the location their
+// issuer URL points to. Keys are the path of a document below the host of the issuer URL.
+type Storage interface {
+	// Put stores the document under the key, replacing any previous version.
+	Put(ctx context.Context, key string, body io.ReadSeeker) error
+	// Delete removes the documents under the keys. Keys which do not exist are ignored.
+	Delete(ctx context.Context, keys ...string) error
+	// String describes the storage for messages.
+	String() string
+}
+
+// Storage providers of the hypershift operator.
+const (
+	StorageProviderS3        = "s3"
+	StorageProviderAzureBlob = "azure-blob"
+	StorageProviderInCluster = "in-cluster"
+)
+
+const (
+	// ConfigMapStorageLabel marks the ConfigMaps which hold OIDC documents served by the ConfigMapStorage.
+	ConfigMapStorageLabel = "hypershift.openshift.io/oidc-discovery"
+
+	documentContentType          = "application/json"
+	configMapStorageNamePrefix   = "oidc-discovery-"
+	configMapStorageKeySeparator = "_"
+)
+
+// S3Storage stores the documents in an S3 bucket. It works with any S3 compatible
+// endpoint the client is configured for.
+type S3Storage struct {
+	Client s3iface.S3API
+	Bucket string
+}
+
+var _ Storage = &S3Storage{}
+
+func (s *S3Storage) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	_, err := s.Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Body:   body,
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		wrapped := fmt.Errorf("failed to upload %s to %s", key, s)
+		if awsErr := awserr.Error(nil); errors.As(err, &awsErr) {
+			switch awsErr.Code() {
+			case s3.ErrCodeNoSuchBucket:
+				wrapped = fmt.Errorf("%w: %s: this could be a misconfiguration of the hypershift operator; check the --oidc-storage-provider-s3-bucket-name flag", wrapped, awsErr.Code())
+			default:
+				// Generally, the underlying message from AWS has unique per-request
+				// info not suitable for publishing as condition messages, so just
+				// return the code. If other specific error types can be handled, add
+				// new switch cases and try to provide more actionable info to the
+				// user.
+				wrapped = fmt.Errorf("%w: aws returned an error: %s", wrapped, awsErr.Code())
+			}
+		}
+		return wrapped
+	}
+	return nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, keys ...string) error {
+	var objectsToDelete []*s3.ObjectIdentifier
+	for _, key := range keys {
+		objectsToDelete = append(objectsToDelete, &s3.ObjectIdentifier{
+			Key: aws.String(key),
+		})
+	}
+
+	if _, err := s.Client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(s.Bucket),
+		Delete: &s3.Delete{Objects: objectsToDelete},
+	}); err != nil {
+		if awsErr := awserr.Error(nil); !errors.As(err, &awsErr) || awsErr.Code() != s3.ErrCodeNoSuchBucket {
+			return fmt.Errorf("failed to delete OIDC objects from %s: %w", s, err)
+		}
+	}
+	return nil
+}
+
+func (s *S3Storage) String() string {
+	return fmt.Sprintf("the %s s3 bucket", s.Bucket)
+}
+
+// AzureBlobStorage stores the documents in an Azure Blob container. To serve them, the
+// container is usually the $web container of a storage account with static websites enabled.
+type AzureBlobStorage struct {
+	Client *container.Client
+}
+
+var _ Storage = &AzureBlobStorage{}
+
+// NewAzureBlobStorage returns a storage for the container at the URL,
+// e.g. https://myaccount.blob.core.windows.net/$web.
+func NewAzureBlobStorage(containerURL string, credential azcore.TokenCredential) (*AzureBlobStorage, error) {
+	client, err := container.NewClient(containerURL, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for Azure Blob container %s: %w", containerURL, err)
+	}
+	return &AzureBlobStorage{Client: client}, nil
+}
+
+func (s *AzureBlobStorage) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	_, err := s.Client.NewBlockBlobClient(key).Upload(ctx, streaming.NopCloser(body), &blockblob.UploadOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: to.Ptr(documentContentType)},
+	})
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+			return fmt.Errorf("failed to upload %s to %s: %s: this could be a misconfiguration of the hypershift operator; check the --oidc-storage-provider-azure-blob-container-url flag", key, s, bloberror.ContainerNotFound)
+		}
+		return fmt.Errorf("failed to upload %s to %s: %w", key, s, err)
+	}
+	return nil
+}
+
+func (s *AzureBlobStorage) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if _, err := s.Client.NewBlobClient(key).Delete(ctx, nil); err != nil {
+			if bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.ContainerNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to delete %s from %s: %w", key, s, err)
+		}
+	}
+	return nil
+}
+
+func (s *AzureBlobStorage) String() string {
+	return fmt.Sprintf("the %s Azure Blob container", s.Client.URL())
+}
+
+// ConfigMapStorage stores the documents in ConfigMaps of the management cluster, one per
+// hosted cluster, and serves them over HTTP. The issuer URL of a hosted cluster is the
+// external URL of the server, usually a Route, followed by the first segment of the keys.
+type ConfigMapStorage struct {
+	Client    client.Client
+	Namespace string
+}
+
+var (
+	_ Storage      = &ConfigMapStorage{}
+	_ http.Handler = &ConfigMapStorage{}
+)
+
+func (s *ConfigMapStorage) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	name, dataKey, err := configMapStorageLocation(key)
+	if err != nil {
+		return err
+	}
+	document, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: s.Namespace, Name: name}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, s.Client, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[ConfigMapStorageLabel] = "true"
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[dataKey] = string(document)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to store %s in %s: %w", key, s, err)
+	}
+	return nil
+}
+
+func (s *ConfigMapStorage) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		name, dataKey, err := configMapStorageLocation(key)
+		if err != nil {
+			return err
+		}
+		cm := &corev1.ConfigMap{}
+		if err := s.Client.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: name}, cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get %s: %w", name, err)
+		}
+		delete(cm.Data, dataKey)
+		if len(cm.Data) == 0 {
+			err = s.Client.Delete(ctx, cm)
+		} else {
+			err = s.Client.Update(ctx, cm)
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s from %s: %w", key, s, err)
+		}
+	}
+	return nil
+}
+
+func (s *ConfigMapStorage) String() string {
+	return fmt.Sprintf("the ConfigMaps of the %s namespace", s.Namespace)
+}
+
+// ServeHTTP serves the stored documents.
+func (s *ConfigMapStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name, dataKey, err := configMapStorageLocation(r.URL.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	cm := &corev1.ConfigMap{}
+	if err := s.Client.Get(r.Context(), client.ObjectKey{Namespace: s.Namespace, Name: name}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	document, ok := cm.Data[dataKey]
+	if !ok || cm.Labels[ConfigMapStorageLabel] != "true" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", documentContentType)
+	_, _ = io.WriteString(w, document)
+}
+
+// configMapStorageLocation returns the name of the ConfigMap and the data key a document is
+// stored at. The first segment of the key names the ConfigMap, the rest is the data key with
+// slashes replaced, as they are not allowed in ConfigMap keys.
+func configMapStorageLocation(key string) (string, string, error) {
+	prefix, path, found := strings.Cut(strings.TrimPrefix(key, "/"), "/")
+	if !found || path == "" || strings.Contains(path, configMapStorageKeySeparator) {
+		return "", "", fmt.Errorf("invalid OIDC document key %q", key)
+	}
+	name := configMapStorageNamePrefix + prefix
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid OIDC document key %q: %s", key, strings.Join(errs, ", "))
+	}
+	dataKey := strings.ReplaceAll(path, "/", configMapStorageKeySeparator)
+	if errs := validation.IsConfigMapKey(dataKey); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid OIDC document key %q: %s", key, strings.Join(errs, ", "))
+	}
+	return name, dataKey, nil
+}
+
+// DiscoveryServer serves the documents of a ConfigMapStorage. It runs on every replica of the
+// operator, not only on the leader.
+type DiscoveryServer struct {
+	Addr    string
+	Storage *ConfigMapStorage
+}
+
+func (s *DiscoveryServer) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Storage,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve OIDC documents: %w", err)
+	}
+	return nil
+}
+
+func (s *DiscoveryServer) NeedLeaderElection() bool {
+	return false
+}
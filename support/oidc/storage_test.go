@@ -0,0 +1,103 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigMapStorageLocation(t *testing.T) {
+	testCases := []struct {
+		name            string
+		key             string
+		expectedName    string
+		expectedDataKey string
+		expectErr       bool
+	}{
+		{
+			name:            "When the key is a discovery document it should be stored in the ConfigMap of the cluster",
+			key:             "my-infra/.well-known/openid-configuration",
+			expectedName:    "oidc-discovery-my-infra",
+			expectedDataKey: ".well-known_openid-configuration",
+		},
+		{
+			name:            "When the key is a request path it should ignore the leading slash",
+			key:             "/my-infra/openid/v1/jwks",
+			expectedName:    "oidc-discovery-my-infra",
+			expectedDataKey: "openid_v1_jwks",
+		},
+		{
+			name:      "When the key has no path it should fail",
+			key:       "/my-infra",
+			expectErr: true,
+		},
+		{
+			name:      "When the path has the separator it should fail",
+			key:       "/my-infra/openid_v1/jwks",
+			expectErr: true,
+		},
+		{
+			name:      "When the prefix is not a valid name it should fail",
+			key:       "/My_Infra/openid/v1/jwks",
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			name, dataKey, err := configMapStorageLocation(tc.key)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(name).To(Equal(tc.expectedName))
+			g.Expect(dataKey).To(Equal(tc.expectedDataKey))
+		})
+	}
+}
+
+func TestConfigMapStorage(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	storage := &ConfigMapStorage{
+		Client:    fake.NewClientBuilder().Build(),
+		Namespace: "hypershift",
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		storage.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	g.Expect(storage.Put(ctx, "my-infra/.well-known/openid-configuration", strings.NewReader(`{"issuer":"a"}`))).To(Succeed())
+	g.Expect(storage.Put(ctx, "my-infra"+JWKSURI, strings.NewReader(`{"keys":[]}`))).To(Succeed())
+
+	response := get("/my-infra/.well-known/openid-configuration")
+	g.Expect(response.Code).To(Equal(http.StatusOK))
+	g.Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+	g.Expect(response.Body.String()).To(Equal(`{"issuer":"a"}`))
+	g.Expect(get("/my-infra" + JWKSURI).Body.String()).To(Equal(`{"keys":[]}`))
+	g.Expect(get("/other-infra" + JWKSURI).Code).To(Equal(http.StatusNotFound))
+
+	g.Expect(storage.Put(ctx, "my-infra/.well-known/openid-configuration", strings.NewReader(`{"issuer":"b"}`))).To(Succeed())
+	g.Expect(get("/my-infra/.well-known/openid-configuration").Body.String()).To(Equal(`{"issuer":"b"}`))
+
+	g.Expect(storage.Delete(ctx, "my-infra/.well-known/openid-configuration")).To(Succeed())
+	g.Expect(get("/my-infra/.well-known/openid-configuration").Code).To(Equal(http.StatusNotFound))
+	g.Expect(get("/my-infra" + JWKSURI).Code).To(Equal(http.StatusOK))
+
+	// Deleting the last document removes the ConfigMap, deleting it again is a no-op.
+	g.Expect(storage.Delete(ctx, "my-infra"+JWKSURI)).To(Succeed())
+	err := storage.Client.Get(ctx, client.ObjectKey{Namespace: "hypershift", Name: "oidc-discovery-my-infra"}, &corev1.ConfigMap{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	g.Expect(storage.Delete(ctx, "my-infra"+JWKSURI)).To(Succeed())
+}
@@ -0,0 +1,129 @@
+package validations
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/support/config"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateHostedCluster validates the fields of a HostedCluster which can not be expressed
+// in the CRD schema. It is shared by the HostedCluster admission webhook and controller so
+// invalid input is rejected at admission time and reported the same way on reconciliation.
+func ValidateHostedCluster(hc *hyperv1.HostedCluster) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, ValidateClusterID(hc)...)
+	errs = append(errs, ValidatePublishingStrategyMapping(hc)...)
+	errs = append(errs, ValidateControlPlaneComponentOverrides(hc)...)
+	return errs
+}
+
+// ValidateHostedClusterUpdate validates a HostedCluster update, including the fields
+// which can not change once the cluster has been created. The spec is only validated
+// when it changes, so HostedClusters created before a validation rule existed can still
+// be updated, e.g. to remove their finalizers.
+func ValidateHostedClusterUpdate(oldHC, newHC *hyperv1.HostedCluster) field.ErrorList {
+	if equality.Semantic.DeepEqual(oldHC.Spec, newHC.Spec) {
+		return nil
+	}
+	var errs field.ErrorList
+	errs = append(errs, validateHostedClusterImmutableFields(oldHC, newHC)...)
+	errs = append(errs, ValidateHostedCluster(newHC)...)
+	return errs
+}
+
+// validateHostedClusterImmutableFields returns an error for every field which
+// would break the existing control plane or infrastructure if it was changed.
+func validateHostedClusterImmutableFields(oldHC, newHC *hyperv1.HostedCluster) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if oldHC.Spec.Platform.Type != newHC.Spec.Platform.Type {
+		errs = append(errs, field.Forbidden(specPath.Child("platform", "type"), "platform type is immutable"))
+	}
+	// The InfraID is defaulted by the controller when empty, it can only be set once.
+	if oldHC.Spec.InfraID != "" && oldHC.Spec.InfraID != newHC.Spec.InfraID {
+		errs = append(errs, field.Forbidden(specPath.Child("infraID"), "infraID is immutable once set"))
+	}
+	if oldHC.Spec.Etcd.ManagementType != newHC.Spec.Etcd.ManagementType {
+		errs = append(errs, field.Forbidden(specPath.Child("etcd", "managementType"), "etcd management type is immutable"))
+	}
+
+	networkingPath := specPath.Child("networking")
+	oldNetworking, newNetworking := oldHC.Spec.Networking, newHC.Spec.Networking
+	if oldNetworking.NetworkType != newNetworking.NetworkType {
+		errs = append(errs, field.Forbidden(networkingPath.Child("networkType"), "network type is immutable"))
+	}
+	if !equality.Semantic.DeepEqual(oldNetworking.ClusterNetwork, newNetworking.ClusterNetwork) {
+		errs = append(errs, field.Forbidden(networkingPath.Child("clusterNetwork"), "cluster network is immutable"))
+	}
+	if !equality.Semantic.DeepEqual(oldNetworking.ServiceNetwork, newNetworking.ServiceNetwork) {
+		errs = append(errs, field.Forbidden(networkingPath.Child("serviceNetwork"), "service network is immutable"))
+	}
+	if !equality.Semantic.DeepEqual(oldNetworking.MachineNetwork, newNetworking.MachineNetwork) {
+		errs = append(errs, field.Forbidden(networkingPath.Child("machineNetwork"), "machine network is immutable"))
+	}
+	return errs
+}
+
+// ValidateClusterID validates that the cluster ID, when set, is a valid UUID.
+func ValidateClusterID(hc *hyperv1.HostedCluster) field.ErrorList {
+	if len(hc.Spec.ClusterID) > 0 {
+		if _, err := uuid.Parse(hc.Spec.ClusterID); err != nil {
+			return field.ErrorList{field.Invalid(field.NewPath("spec", "clusterID"), hc.Spec.ClusterID, fmt.Sprintf("cannot parse cluster ID: %v", err))}
+		}
+	}
+	return nil
+}
+
+// ValidatePublishingStrategyMapping validates that each published serviceType has a unique hostname.
+func ValidatePublishingStrategyMapping(hc *hyperv1.HostedCluster) field.ErrorList {
+	var errs field.ErrorList
+	hostnameServiceMap := make(map[string]string, len(hc.Spec.Services))
+	for i, svc := range hc.Spec.Services {
+		hostname := ""
+		hostnamePath := field.NewPath("spec", "services").Index(i)
+		if svc.Type == hyperv1.LoadBalancer && svc.LoadBalancer != nil {
+			hostname = svc.LoadBalancer.Hostname
+			hostnamePath = hostnamePath.Child("servicePublishingStrategy", "loadBalancer", "hostname")
+		}
+		if svc.Type == hyperv1.Route && svc.Route != nil {
+			hostname = svc.Route.Hostname
+			hostnamePath = hostnamePath.Child("servicePublishingStrategy", "route", "hostname")
+		}
+
+		if hostname == "" {
+			continue
+		}
+
+		serviceType, exists := hostnameServiceMap[hostname]
+		if exists {
+			errs = append(errs, field.Invalid(hostnamePath, hostname, fmt.Sprintf("service type %s can't be published with the same hostname %s as service type %s", svc.Service, hostname, serviceType)))
+			continue
+		}
+
+		hostnameServiceMap[hostname] = string(svc.Service)
+	}
+
+	return errs
+}
+
+// ValidateControlPlaneComponentOverrides validates that the extraArgs of the component overrides only set the
+// flags which are allowed for their component.
+func ValidateControlPlaneComponentOverrides(hc *hyperv1.HostedCluster) field.ErrorList {
+	var errs field.ErrorList
+	for i, override := range hc.Spec.ControlPlaneComponentOverrides {
+		for j, container := range override.Containers {
+			for k, arg := range container.ExtraArgs {
+				if !config.ExtraArgAllowed(string(override.Name), arg) {
+					path := field.NewPath("spec", "controlPlaneComponentOverrides").Index(i).Child("containers").Index(j).Child("extraArgs").Index(k)
+					errs = append(errs, field.Forbidden(path, fmt.Sprintf("flag %s can not be set on %s", arg, override.Name)))
+				}
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,163 @@
+package validations
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"github.com/openshift/hypershift/api/util/ipnet"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateHostedCluster(t *testing.T) {
+	hostedCluster := func(mutate func(*hyperv1.HostedCluster)) *hyperv1.HostedCluster {
+		hc := &hyperv1.HostedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hc", Namespace: "any"},
+			Spec: hyperv1.HostedClusterSpec{
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform},
+				Networking: hyperv1.ClusterNetworking{
+					ClusterNetwork: []hyperv1.ClusterNetworkEntry{{CIDR: *ipnet.MustParseCIDR("10.132.0.0/14")}},
+					ServiceNetwork: []hyperv1.ServiceNetworkEntry{{CIDR: *ipnet.MustParseCIDR("172.31.0.0/16")}},
+					MachineNetwork: []hyperv1.MachineNetworkEntry{{CIDR: *ipnet.MustParseCIDR("10.0.0.0/16")}},
+				},
+			},
+		}
+		if mutate != nil {
+			mutate(hc)
+		}
+		return hc
+	}
+
+	tests := []struct {
+		name           string
+		hc             *hyperv1.HostedCluster
+		expectedFields []string
+	}{
+		{
+			name: "When the HostedCluster is valid it should succeed",
+			hc:   hostedCluster(nil),
+		},
+		{
+			name: "When the cluster ID is not a UUID it should fail",
+			hc: hostedCluster(func(hc *hyperv1.HostedCluster) {
+				hc.Spec.ClusterID = "not-a-uuid"
+			}),
+			expectedFields: []string{"spec.clusterID"},
+		},
+		{
+			name: "When two services are published with the same hostname it should fail",
+			hc: hostedCluster(func(hc *hyperv1.HostedCluster) {
+				hc.Spec.Services = []hyperv1.ServicePublishingStrategyMapping{
+					{
+						Service: hyperv1.APIServer,
+						ServicePublishingStrategy: hyperv1.ServicePublishingStrategy{
+							Type:         hyperv1.LoadBalancer,
+							LoadBalancer: &hyperv1.LoadBalancerPublishingStrategy{Hostname: "api.example.com"},
+						},
+					},
+					{
+						Service: hyperv1.OAuthServer,
+						ServicePublishingStrategy: hyperv1.ServicePublishingStrategy{
+							Type:  hyperv1.Route,
+							Route: &hyperv1.RoutePublishingStrategy{Hostname: "api.example.com"},
+						},
+					},
+				}
+			}),
+			expectedFields: []string{"spec.services[1].servicePublishingStrategy.route.hostname"},
+		},
+		{
+			name: "When a component override sets a flag which is not allowed for the component it should fail",
+			hc: hostedCluster(func(hc *hyperv1.HostedCluster) {
+				hc.Spec.ControlPlaneComponentOverrides = []hyperv1.ControlPlaneComponentOverride{{
+					Name: "kube-apiserver",
+					Containers: []hyperv1.ControlPlaneContainerOverride{{
+						Name:      "kube-apiserver",
+						ExtraArgs: []string{"--max-requests-inflight=5000", "--anonymous-auth=true"},
+					}},
+				}}
+			}),
+			expectedFields: []string{"spec.controlPlaneComponentOverrides[0].containers[0].extraArgs[1]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := ValidateHostedCluster(tt.hc)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tt.expectedFields))
+		})
+	}
+}
+
+func TestValidateHostedClusterUpdate(t *testing.T) {
+	oldHC := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "hc", Namespace: "any"},
+		Spec: hyperv1.HostedClusterSpec{
+			InfraID:  "hc-abcde",
+			Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform},
+			Etcd:     hyperv1.EtcdSpec{ManagementType: hyperv1.Managed},
+			Networking: hyperv1.ClusterNetworking{
+				NetworkType:    hyperv1.OVNKubernetes,
+				ClusterNetwork: []hyperv1.ClusterNetworkEntry{{CIDR: *ipnet.MustParseCIDR("10.132.0.0/14")}},
+				ServiceNetwork: []hyperv1.ServiceNetworkEntry{{CIDR: *ipnet.MustParseCIDR("172.31.0.0/16")}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		mutate         func(*hyperv1.HostedCluster)
+		expectedFields []string
+	}{
+		{
+			name: "When mutable fields change it should succeed",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.Release.Image = "release:4.16.1"
+				hc.Spec.Networking.APIServer = &hyperv1.APIServerNetworking{AllowedCIDRBlocks: []hyperv1.CIDRBlock{"10.0.0.0/8"}}
+			},
+		},
+		{
+			name: "When immutable fields change it should fail with an error per field",
+			mutate: func(hc *hyperv1.HostedCluster) {
+				hc.Spec.InfraID = "hc-fghij"
+				hc.Spec.Platform.Type = hyperv1.NonePlatform
+				hc.Spec.Etcd.ManagementType = hyperv1.Unmanaged
+				hc.Spec.Networking.NetworkType = hyperv1.OpenShiftSDN
+				hc.Spec.Networking.ServiceNetwork = []hyperv1.ServiceNetworkEntry{{CIDR: *ipnet.MustParseCIDR("172.30.0.0/16")}}
+			},
+			expectedFields: []string{
+				"spec.platform.type",
+				"spec.infraID",
+				"spec.etcd.managementType",
+				"spec.networking.networkType",
+				"spec.networking.serviceNetwork",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			newHC := oldHC.DeepCopy()
+			tt.mutate(newHC)
+			errs := ValidateHostedClusterUpdate(oldHC, newHC)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tt.expectedFields))
+		})
+	}
+
+	t.Run("When the InfraID is set for the first time it should succeed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		withoutInfraID := oldHC.DeepCopy()
+		withoutInfraID.Spec.InfraID = ""
+		g.Expect(ValidateHostedClusterUpdate(withoutInfraID, oldHC)).To(BeEmpty())
+	})
+}
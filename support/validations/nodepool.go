@@ -0,0 +1,66 @@
+package validations
+
+import (
+	"fmt"
+
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateNodePool validates the fields of a NodePool which can not be expressed in the CRD schema.
+func ValidateNodePool(nodePool *hyperv1.NodePool) field.ErrorList {
+	var errs field.ErrorList
+	// The upgrade type is required by the CRD schema, which reports it when missing.
+	if nodePool.Spec.Management.UpgradeType != "" {
+		errs = append(errs, ValidateNodePoolManagement(nodePool)...)
+	}
+	return errs
+}
+
+// ValidateNodePoolUpdate validates a NodePool update. The spec is only validated when it
+// changes, so NodePools created before a validation rule existed can still be updated,
+// e.g. to remove their finalizers.
+func ValidateNodePoolUpdate(oldNodePool, newNodePool *hyperv1.NodePool) field.ErrorList {
+	if equality.Semantic.DeepEqual(oldNodePool.Spec, newNodePool.Spec) {
+		return nil
+	}
+	return ValidateNodePool(newNodePool)
+}
+
+// ValidateNodePoolManagement does additional backend validation of the NodePool management.
+// API validation/default should prevent this from ever fail.
+func ValidateNodePoolManagement(nodePool *hyperv1.NodePool) field.ErrorList {
+	managementPath := field.NewPath("spec", "management")
+
+	// TODO actually validate the inplace upgrade type
+	if nodePool.Spec.Management.UpgradeType == hyperv1.UpgradeTypeInPlace {
+		return nil
+	}
+
+	// Only upgradeType "Replace" is supported atm.
+	if nodePool.Spec.Management.UpgradeType != hyperv1.UpgradeTypeReplace {
+		return field.ErrorList{field.NotSupported(managementPath.Child("upgradeType"), nodePool.Spec.Management.UpgradeType,
+			[]string{string(hyperv1.UpgradeTypeReplace), string(hyperv1.UpgradeTypeInPlace)})}
+	}
+	if nodePool.Spec.Management.Replace == nil {
+		return field.ErrorList{field.Required(managementPath.Child("replace"),
+			fmt.Sprintf("%q upgrade type requires a strategy: %q or %q", hyperv1.UpgradeTypeReplace, hyperv1.UpgradeStrategyRollingUpdate, hyperv1.UpgradeStrategyOnDelete))}
+	}
+
+	replacePath := managementPath.Child("replace")
+	if nodePool.Spec.Management.Replace.Strategy != hyperv1.UpgradeStrategyRollingUpdate &&
+		nodePool.Spec.Management.Replace.Strategy != hyperv1.UpgradeStrategyOnDelete {
+		return field.ErrorList{field.NotSupported(replacePath.Child("strategy"), nodePool.Spec.Management.Replace.Strategy,
+			[]string{string(hyperv1.UpgradeStrategyRollingUpdate), string(hyperv1.UpgradeStrategyOnDelete)})}
+	}
+
+	// RollingUpdate strategy requires MaxUnavailable and MaxSurge
+	if nodePool.Spec.Management.Replace.Strategy == hyperv1.UpgradeStrategyRollingUpdate &&
+		nodePool.Spec.Management.Replace.RollingUpdate == nil {
+		return field.ErrorList{field.Required(replacePath.Child("rollingUpdate"),
+			fmt.Sprintf("%q upgrade type with strategy %q requires a MaxUnavailable and MaxSurge", hyperv1.UpgradeTypeReplace, hyperv1.UpgradeStrategyRollingUpdate))}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package validations
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateNodePoolUpdate(t *testing.T) {
+	oldNodePool := &hyperv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "np", Namespace: "any"},
+		Spec: hyperv1.NodePoolSpec{
+			ClusterName: "hc",
+			Management: hyperv1.NodePoolManagement{
+				UpgradeType: hyperv1.UpgradeTypeReplace,
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		mutate         func(*hyperv1.NodePool)
+		expectedFields []string
+	}{
+		{
+			name: "When the spec does not change it should succeed even if the NodePool is invalid",
+			mutate: func(np *hyperv1.NodePool) {
+				np.Finalizers = nil
+			},
+		},
+		{
+			name: "When the spec changes and the replace strategy is missing it should fail",
+			mutate: func(np *hyperv1.NodePool) {
+				np.Spec.Release.Image = "release:4.16.1"
+			},
+			expectedFields: []string{"spec.management.replace"},
+		},
+		{
+			name: "When the spec changes and the NodePool is valid it should succeed",
+			mutate: func(np *hyperv1.NodePool) {
+				np.Spec.Management.Replace = &hyperv1.ReplaceUpgrade{Strategy: hyperv1.UpgradeStrategyOnDelete}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			newNodePool := oldNodePool.DeepCopy()
+			tt.mutate(newNodePool)
+			errs := ValidateNodePoolUpdate(oldNodePool, newNodePool)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tt.expectedFields))
+		})
+	}
+}
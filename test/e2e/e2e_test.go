@@ -31,10 +31,10 @@ import (
 	"github.com/openshift/hypershift/cmd/cluster/kubevirt"
 	"github.com/openshift/hypershift/cmd/cluster/none"
 	"github.com/openshift/hypershift/cmd/cluster/powervs"
-	awsinfra "github.com/openshift/hypershift/cmd/infra/aws"
 	awscmdutil "github.com/openshift/hypershift/cmd/infra/aws/util"
 	kubevirtnodepool "github.com/openshift/hypershift/cmd/nodepool/kubevirt"
 	"github.com/openshift/hypershift/cmd/version"
+	awsinfra "github.com/openshift/hypershift/support/infra/aws"
 	"github.com/openshift/hypershift/test/e2e/podtimingcontroller"
 	"github.com/openshift/hypershift/test/e2e/util"
 	e2eutil "github.com/openshift/hypershift/test/e2e/util"